@@ -0,0 +1,274 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/akashicode/kash/internal/bench"
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/eval"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/retrieval"
+	"github.com/akashicode/kash/internal/server"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+var (
+	benchDir         string
+	benchQueries     string
+	benchRequests    int
+	benchConcurrency []int
+	benchCompletions bool
+	benchOutput      string
+)
+
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark",
+	Short: "Measure query latency and throughput against the local stores",
+	Long: `Runs a query workload against the compiled databases and prints p50/p95/p99
+latency and throughput at each concurrency level for:
+
+  vector   semantic search in data/memory.chromem (includes query embedding)
+  graph    keyword search in data/knowledge.cayley (skipped for a
+           vector-only build)
+  chat     end-to-end /v1/chat/completions through the runtime handler
+           (only with --completions, since it calls your LLM)
+
+Queries are read from --queries (one per line), or from the questions in
+eval.yaml when no file is given.`,
+	RunE: runBenchmark,
+}
+
+func init() {
+	benchmarkCmd.Flags().StringVarP(&benchDir, "dir", "d", ".", "Path to the agent project directory")
+	benchmarkCmd.Flags().StringVarP(&benchQueries, "queries", "q", "", "File with one query per line (default: questions from eval.yaml)")
+	benchmarkCmd.Flags().IntVarP(&benchRequests, "requests", "n", 100, "Requests per benchmark and concurrency level")
+	benchmarkCmd.Flags().IntSliceVarP(&benchConcurrency, "concurrency", "c", []int{1, 4, 16}, "Concurrency levels to test")
+	benchmarkCmd.Flags().BoolVar(&benchCompletions, "completions", false, "Also benchmark end-to-end chat completions (calls the LLM)")
+	benchmarkCmd.Flags().StringVarP(&benchOutput, "output", "o", "", "Write results as JSON to this file")
+	rootCmd.AddCommand(benchmarkCmd)
+}
+
+func runBenchmark(_ *cobra.Command, _ []string) error {
+	for _, path := range []*string{&benchQueries, &benchOutput} {
+		if *path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*path)
+		if err != nil {
+			return fmt.Errorf("resolve %q: %w", *path, err)
+		}
+		*path = abs
+	}
+	if benchDir != "." {
+		abs, err := filepath.Abs(benchDir)
+		if err != nil {
+			return fmt.Errorf("resolve directory %q: %w", benchDir, err)
+		}
+		if err := os.Chdir(abs); err != nil {
+			return fmt.Errorf("change to directory %q: %w", abs, err)
+		}
+	}
+
+	queries, err := loadBenchQueries(benchQueries)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := agentconfig.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	agentconfig.ApplyAgentYAMLDimensions(cfg, "agent.yaml")
+	if err := agentconfig.ValidateEmbedder(cfg); err != nil {
+		return err
+	}
+	if benchCompletions {
+		if err := agentconfig.ValidateLLM(cfg); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat("data/memory.chromem"); err != nil {
+		return errors.New("data/memory.chromem not found — run 'kash build' first")
+	}
+
+	display.Header("⏱️  Kash Benchmark")
+	fmt.Println()
+	display.KeyValue("Queries", len(queries), display.BrightCyan)
+	display.KeyValue("Requests", benchRequests, display.BrightCyan)
+	display.KeyValue("Concurrency", strings.Trim(fmt.Sprint(benchConcurrency), "[]"), display.BrightCyan)
+	fmt.Println()
+
+	ctx := context.Background()
+	var results []bench.Result
+	run := func(name string, op bench.Op) {
+		for _, c := range benchConcurrency {
+			results = append(results, bench.Run(ctx, name, benchRequests, c, queries, op))
+		}
+	}
+
+	vs, err := vector.NewStoreFromPath("data/memory.chromem", &cfg.Embedder)
+	if err != nil {
+		return fmt.Errorf("open vector store: %w", err)
+	}
+	display.StepDetail(fmt.Sprintf("vector: %d chunks", vs.Count()))
+	run("vector", func(ctx context.Context, q string) error {
+		_, err := vs.Query(ctx, q, 5)
+		return err
+	})
+
+	// A vector-only build has no graph to search
+	if _, err := os.Stat("data/knowledge.cayley"); err == nil {
+		gdb, err := graph.NewDBFromPath("data/knowledge.cayley")
+		if err != nil {
+			return fmt.Errorf("open graph db: %w", err)
+		}
+		if hookCfg, err := retrieval.AgentYAMLHooks("agent.yaml"); err == nil {
+			gdb.SetSynonyms(hookCfg.Synonyms)
+		}
+		display.StepDetail(fmt.Sprintf("graph: %d triples", gdb.Count()))
+		run("graph", func(ctx context.Context, q string) error {
+			_, err := gdb.Search(ctx, q, 10)
+			return err
+		})
+		// The bolt store allows a single opener; release it for the server
+		if err := gdb.Close(); err != nil {
+			return fmt.Errorf("close graph db: %w", err)
+		}
+	}
+
+	if benchCompletions {
+		srv, err := server.New(server.Config{
+			VectorStorePath: "data/memory.chromem",
+			GraphDBPath:     "data/knowledge.cayley",
+			AgentYAMLPath:   "agent.yaml",
+			AppCfg:          cfg,
+			Quiet:           true,
+		})
+		if err != nil {
+			return fmt.Errorf("initialize server: %w", err)
+		}
+		defer srv.Close()
+		display.StepDetail("chat: " + cfg.LLM.Model)
+		run("chat", chatCompletionOp(srv.Handler()))
+	}
+
+	fmt.Println()
+	printBenchResults(results)
+
+	if benchOutput != "" {
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal results: %w", err)
+		}
+		if err := os.WriteFile(benchOutput, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("write results: %w", err)
+		}
+		display.FileCreated(benchOutput)
+	}
+	return nil
+}
+
+// loadBenchQueries reads one query per line from path, or the questions of
+// eval.yaml when path is empty.
+func loadBenchQueries(path string) ([]string, error) {
+	if path == "" {
+		suite, err := eval.LoadSuite(eval.DefaultPath)
+		if err != nil {
+			return nil, fmt.Errorf("no --queries file given and %s could not be used: %w", eval.DefaultPath, err)
+		}
+		queries := make([]string, len(suite.Cases))
+		for i, c := range suite.Cases {
+			queries[i] = c.Question
+		}
+		return queries, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open queries: %w", err)
+	}
+	defer f.Close()
+	var queries []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if q := strings.TrimSpace(sc.Text()); q != "" && !strings.HasPrefix(q, "#") {
+			queries = append(queries, q)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read queries: %w", err)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("%s contains no queries", path)
+	}
+	return queries, nil
+}
+
+// chatCompletionOp posts a non-streaming chat completion to the runtime
+// handler in-process, so timings cover retrieval plus the LLM round trip
+// without network overhead on the Kash side.
+func chatCompletionOp(h http.Handler) bench.Op {
+	apiKey := os.Getenv("AGENT_API_KEY")
+	return func(ctx context.Context, q string) error {
+		body, _ := json.Marshal(map[string]interface{}{
+			"messages": []map[string]string{{"role": "user", "content": q}},
+		})
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body)).WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			msg := strings.TrimSpace(rec.Body.String())
+			if msg == "" {
+				msg = http.StatusText(rec.Code)
+			}
+			return errors.New(msg)
+		}
+		return nil
+	}
+}
+
+func printBenchResults(results []bench.Result) {
+	fmt.Printf("  %-8s %5s %6s %6s %10s %10s %10s %10s %10s\n",
+		"bench", "conc", "reqs", "errs", "mean", "p50", "p95", "p99", "req/s")
+	for _, r := range results {
+		fmt.Printf("  %-8s %5d %6d %6d %10s %10s %10s %10s %10.1f\n",
+			r.Name, r.Concurrency, r.Requests, r.Errors,
+			formatLatency(r.Mean), formatLatency(r.P50), formatLatency(r.P95), formatLatency(r.P99), r.Throughput)
+	}
+	for _, r := range results {
+		if r.FirstError != "" {
+			display.StepWarn(fmt.Sprintf("%s (c=%d): %d error(s), first: %s", r.Name, r.Concurrency, r.Errors, r.FirstError))
+		}
+	}
+}
+
+func formatLatency(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "-"
+	case d < time.Millisecond:
+		return fmt.Sprintf("%.0fµs", float64(d)/float64(time.Microsecond))
+	case d < time.Second:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	}
+}
@@ -1,23 +1,22 @@
 package cmd
 
 import (
+	"bufio"
 	"context"
-	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
+	"golang.org/x/term"
 
-	"github.com/akashicode/kash/internal/chunker"
+	"github.com/akashicode/kash/internal/buildreport"
 	agentconfig "github.com/akashicode/kash/internal/config"
 	"github.com/akashicode/kash/internal/display"
 	"github.com/akashicode/kash/internal/graph"
-	"github.com/akashicode/kash/internal/llm"
-	"github.com/akashicode/kash/internal/reader"
-	"github.com/akashicode/kash/internal/vector"
+	"github.com/akashicode/kash/internal/snapshot"
+	"github.com/akashicode/kash/pkg/kash"
 )
 
 var buildCmd = &cobra.Command{
@@ -28,14 +27,91 @@ var buildCmd = &cobra.Command{
   2. Generates vector embeddings via the configured embedder
   3. Extracts knowledge graph triples via LLM
   4. Persists databases to data/memory.chromem/ and data/knowledge.cayley/
-  5. Updates agent.yaml with optimized MCP tool descriptions`,
+  5. Updates agent.yaml with optimized MCP tool descriptions
+
+Every build, including a failed one, writes .kash/build-report.json and
+.kash/build-report.md with per-document chunk counts, skipped files, triple
+extraction results, LLM token usage, warnings, and stage timings.
+
+Chunks whose content did not change since the last build keep their
+embeddings. With --if-changed, a build whose documents and models all match
+the last build stops after chunking and leaves the databases untouched.
+
+With --review, the triples the LLM extracts are written to
+.kash/triples-review.yaml (or the file given) instead of the graph. Approve
+them there, then load them with 'kash graph apply'. Structured and curated
+triples go into the graph as usual.
+
+With --skip-graph, or extraction.skip_graph: true in agent.yaml, the build
+is vector-only: it extracts no triples, makes no extraction LLM calls, and
+removes the graph store of an earlier build. 'kash serve' then answers from
+the vector index alone.
+
+With --graph-only, the build extracts the knowledge graph again from scratch,
+e.g. after a change to the extraction prompt, and leaves the vector index of
+the last build as it is: nothing is embedded. Triples added with 'kash graph
+add' are lost; the snapshot of the last build keeps them.
+
+--stages runs only the pipeline stages listed: load, chunk, embed, extract,
+and describe. A skipped load or chunk stage reads the documents or chunks
+cached in data/build-stages/ when it last ran; a skipped embed, extract, or
+describe stage keeps the vector index, graph, or tool description of the last
+build. --graph-only is --stages load,chunk,extract,describe.
+
+A successful build is saved as the next snapshot under .kash/snapshots/;
+'kash snapshots list' shows them and 'kash rollback' restores one.
+
+Before the first embedding or LLM call, the build prints an estimate of the
+calls, tokens, cost, and duration ahead. When the cost exceeds
+pricing.confirm_above in config.yaml ($1.00 by default), it asks before going
+on; --yes skips the question. Without a terminal to ask on, such a build stops
+unless --yes is given.`,
 	RunE: runBuild,
 }
 
-var buildDir string
+var (
+	buildDir       string
+	buildReportDir string
+	buildIfChanged bool
+	buildYes       bool
+	buildReview    string
+	buildSkipGraph bool
+	buildGraphOnly bool
+	buildStages    string
+)
+
+// buildResult is what 'kash build --json' prints.
+type buildResult struct {
+	Documents  int    `json:"documents"`
+	Skipped    int    `json:"skipped"`
+	Chunks     int    `json:"chunks"`
+	Vectors    int    `json:"vectors"`
+	Triples    int64  `json:"triples"`
+	Manifest   string `json:"manifest"`
+	Report     string `json:"report,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Unchanged  bool   `json:"unchanged,omitempty"`
+	NoGraph    bool   `json:"no_graph,omitempty"`
+	Snapshot   string `json:"snapshot,omitempty"`
+	// Review is the review file of a --review build
+	Review        string   `json:"review,omitempty"`
+	ReviewTriples int      `json:"review_triples,omitempty"`
+	Warnings      []string `json:"warnings"`
+	// Tokens counts the LLM and embedding tokens the build used
+	Tokens  int     `json:"tokens"`
+	CostUSD float64 `json:"cost_usd"`
+}
 
 func init() {
 	buildCmd.Flags().StringVarP(&buildDir, "dir", "d", ".", "Path to the agent project directory")
+	buildCmd.Flags().StringVar(&buildReportDir, "report-dir", buildreport.DefaultDir, "Directory for build-report.json and build-report.md (empty to skip)")
+	buildCmd.Flags().BoolVar(&buildIfChanged, "if-changed", false, "Skip the rebuild when documents and models are unchanged since the last build")
+	buildCmd.Flags().BoolVarP(&buildYes, "yes", "y", false, "Build without asking when the estimated cost exceeds pricing.confirm_above")
+	buildCmd.Flags().StringVar(&buildReview, "review", "", "Write extracted triples to this review file instead of the graph (default "+graph.DefaultReviewFile+")")
+	buildCmd.Flags().Lookup("review").NoOptDefVal = graph.DefaultReviewFile
+	buildCmd.Flags().BoolVar(&buildSkipGraph, "skip-graph", false, "Build a vector-only agent without extracting a knowledge graph")
+	buildCmd.Flags().BoolVar(&buildGraphOnly, "graph-only", false, "Rebuild only the knowledge graph, keeping the vector index untouched")
+	buildCmd.Flags().StringVar(&buildStages, "stages", "", "Comma-separated stages to run: load, chunk, embed, extract, describe (default: all)")
 }
 
 func runBuild(cmd *cobra.Command, args []string) error {
@@ -48,247 +124,172 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		if err := os.Chdir(abs); err != nil {
 			return fmt.Errorf("change to directory %q: %w", abs, err)
 		}
-		fmt.Printf("Working directory: %s\n", abs)
+		fmt.Fprintf(display.Output(), "Working directory: %s\n", abs)
 	}
 
-	ctx := context.Background()
-
 	// Load unified config (env vars take priority over config.yaml)
 	cfg, err := agentconfig.Load()
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
 
-	// Ensure we're in a Kash agent project
-	if _, err := os.Stat("agent.yaml"); os.IsNotExist(err) {
-		return errors.New("agent.yaml not found — run 'kash init <name>' first")
-	}
-	if _, err := os.Stat("data"); os.IsNotExist(err) {
-		return errors.New("data/ directory not found — run 'kash init <name>' first")
+	var stages []kash.Stage
+	if buildStages != "" {
+		if stages, err = kash.ParseStages(buildStages); err != nil {
+			return err
+		}
 	}
 
-	// Apply dimensions from agent.yaml (canonical source) before validation
-	agentconfig.ApplyAgentYAMLDimensions(cfg, "agent.yaml")
-
-	if err := agentconfig.ValidateBuild(cfg); err != nil {
+	b, err := kash.NewBuilder(kash.BuildOptions{
+		Config:        cfg,
+		ReportDir:     buildReportDir,
+		Version:       version,
+		Progress:      displayProgress{},
+		SkipUnchanged: buildIfChanged,
+		Confirm:       confirmBuild(cmd, cfg.Pricing.Threshold()),
+		ReviewFile:    buildReview,
+		SkipGraph:     buildSkipGraph,
+		GraphOnly:     buildGraphOnly,
+		Stages:        stages,
+	})
+	if err != nil {
 		return err
 	}
 
 	display.Header("⚡ Kash Build Pipeline")
-	fmt.Println()
+	display.Newline()
+	if cfg.Profile != "" {
+		display.KeyValue("Profile", cfg.Profile, display.Bold+display.BrightCyan)
+	}
 	display.KeyValue("Embed Dimensions", cfg.Embedder.Dimensions, display.Bold+display.BrightYellow)
 	display.KeyValue("LLM Model", cfg.LLM.Model, display.BrightMagenta)
+	if b := cfg.BuildLLMConfig(); b.Model != cfg.LLM.Model || b.BaseURL != cfg.LLM.BaseURL {
+		display.KeyValue("Build LLM", b.Model+" at "+b.BaseURL, display.BrightMagenta)
+	}
 	display.KeyValue("Embed Endpoint", cfg.Embedder.BaseURL, display.Dim+display.White)
-	fmt.Println()
+	display.Newline()
 
-	// Step 1: Load documents
-	display.Step(1, 5, "Loading documents from data/...")
-	docs, err := reader.LoadDirectory("data")
+	res, err := b.Build(context.Background())
 	if err != nil {
-		return fmt.Errorf("load documents: %w", err)
-	}
-	if len(docs) == 0 {
-		return errors.New("no supported documents found in data/ (add .md, .txt, or .pdf files)")
-	}
-	display.StepResult("Loaded", fmt.Sprintf("%d document(s)", len(docs)))
-	for _, doc := range docs {
-		display.StepDetail("• " + doc.Name)
+		return err
 	}
 
-	// Step 2: Chunk documents
-	display.Step(2, 5, "Chunking documents...")
-
-	// If max_tokens is set in agent.yaml, auto-tune chunk size
-	maxTokens := agentconfig.AgentYAMLMaxTokens("agent.yaml")
-	var chunkOpts chunker.Options
-	if maxTokens > 0 {
-		chunkOpts = chunker.OptionsFromMaxTokens(maxTokens)
-		display.KeyValue("Embed Max Tokens", maxTokens, display.BrightYellow)
-		display.KeyValue("Chunk Size (chars)", chunkOpts.ChunkSize, display.Dim+display.White)
+	display.Newline()
+	if res.Unchanged {
+		display.Success("Already up to date, nothing rebuilt")
 	} else {
-		chunkOpts = chunker.DefaultOptions()
+		display.Success("Build complete!")
 	}
-
-	ck, err := chunker.NewChunker(chunkOpts)
-	if err != nil {
-		return fmt.Errorf("create chunker: %w", err)
-	}
-
-	var allChunks []chunker.Chunk
-	for _, doc := range docs {
-		chunks, err := ck.SplitBySentence(doc.Content, doc.Name)
-		if err != nil {
-			return fmt.Errorf("chunk document %q: %w", doc.Name, err)
-		}
-		allChunks = append(allChunks, chunks...)
+	display.Newline()
+	display.KeyValue("Vector index", fmt.Sprintf("%s (%d documents)", kash.VectorDir, res.Vectors), display.BrightGreen)
+	if res.NoGraph {
+		display.KeyValue("Graph store", "skipped (vector-only build)", display.BrightGreen)
+	} else {
+		display.KeyValue("Graph store", fmt.Sprintf("%s (%d triples)", kash.GraphDir, res.Triples), display.BrightGreen)
 	}
-	display.StepResult("Created", fmt.Sprintf("%d chunk(s)", len(allChunks)))
-
-	// Step 3: Build vector store
-	display.Step(3, 5, "Building vector index (this may take a while)...")
-	vectorPath := filepath.Join("data", "memory.chromem")
-	if err := os.MkdirAll(vectorPath, 0755); err != nil {
-		return fmt.Errorf("create vector store directory: %w", err)
+	display.KeyValue("Manifest", kash.ManifestFile, display.BrightGreen)
+	reviewPath := ""
+	if buildReview != "" && !res.Unchanged {
+		reviewPath = buildReview
+		display.KeyValue("Review", fmt.Sprintf("%s (%d triples)", buildReview, res.ReviewTriples), display.BrightGreen)
 	}
-
-	vs, err := vector.NewPersistentStore(vectorPath, &cfg.Embedder)
-	if err != nil {
-		return fmt.Errorf("create vector store: %w", err)
-	}
-
-	if err := vs.AddChunks(ctx, allChunks, agentconfig.AgentYAMLParallelEmbedding("agent.yaml")); err != nil {
-		return fmt.Errorf("add chunks to vector store: %w", err)
+	if res.Snapshot != "" {
+		display.KeyValue("Snapshot", filepath.Join(snapshot.DefaultDir, res.Snapshot), display.BrightGreen)
 	}
-	display.StepResult("Indexed", fmt.Sprintf("%d vectors", vs.Count()))
-
-	// Step 4: Extract knowledge graph
-	display.Step(4, 5, "Extracting knowledge graph triples...")
-	graphPath := filepath.Join("data", "knowledge.cayley")
-	if err := os.MkdirAll(graphPath, 0755); err != nil {
-		return fmt.Errorf("create graph store directory: %w", err)
+	tokens := 0
+	for _, t := range res.Report.Phases {
+		tokens += t.TotalTokens
 	}
-
-	gdb, err := graph.NewDBFromPath(graphPath)
-	if err != nil {
-		return fmt.Errorf("create graph store: %w", err)
+	if tokens > 0 {
+		display.KeyValue("Tokens", fmt.Sprintf("%d (~$%.2f)", tokens, res.Report.CostUSD), display.BrightGreen)
 	}
-	defer gdb.Close()
-
-	llmClient, err := llm.NewClient(&cfg.LLM)
-	if err != nil {
-		return fmt.Errorf("create LLM client: %w", err)
+	reportPath := ""
+	if buildReportDir != "" {
+		reportPath = filepath.Join(buildReportDir, buildreport.JSONFile)
+		display.KeyValue("Build report", filepath.Join(buildReportDir, buildreport.MarkdownFile), display.BrightGreen)
 	}
 
-	totalTriples := int64(0)
-	// Process chunks in batches to extract triples
-	batchSize := 10
-	for i := 0; i < len(allChunks); i += batchSize {
-		end := i + batchSize
-		if end > len(allChunks) {
-			end = len(allChunks)
-		}
-		batch := allChunks[i:end]
-
-		// Combine batch into single text for efficiency
-		var combined strings.Builder
-		for _, ch := range batch {
-			combined.WriteString(ch.Content)
-			combined.WriteString("\n\n")
-		}
-
-		var triples []llm.Triple
-		var extractErr error
-		maxRetries := 2
-		for attempt := 0; attempt <= maxRetries; attempt++ {
-			triples, extractErr = llmClient.ExtractTriples(ctx, combined.String())
-			if extractErr == nil {
-				break
-			}
-			if attempt < maxRetries {
-				display.StepWarn(fmt.Sprintf("triple extraction failed for batch %d-%d (attempt %d/%d, retrying): %v", i, end, attempt+1, maxRetries+1, extractErr))
+	// kash init --smoke-test prints its own next steps
+	if cmd.Name() == "build" {
+		steps := []string{"docker compose up --build"}
+		if reviewPath != "" && res.ReviewTriples > 0 {
+			steps = []string{
+				"Set approved: true on the triples to keep in " + buildReview,
+				"kash graph apply " + buildReview,
+				"docker compose up --build",
 			}
 		}
-		if extractErr != nil {
-			display.StepWarn(fmt.Sprintf("triple extraction failed for batch %d-%d after %d attempts: %v", i, end, maxRetries+1, extractErr))
-			continue
-		}
-
-		if err := gdb.AddTriples(ctx, triples); err != nil {
-			display.StepWarn(fmt.Sprintf("failed to add triples for batch %d-%d: %v", i, end, err))
-			continue
-		}
-
-		totalTriples += int64(len(triples))
-		display.StepDetail(fmt.Sprintf("Chunks %d-%d: +%d triples (total: %d)", i+1, end, len(triples), totalTriples))
-	}
-	display.StepResult("Knowledge graph", fmt.Sprintf("%d triples", gdb.Count()))
-
-	// Step 5: Generate MCP descriptions
-	display.Step(5, 5, "Generating optimized MCP tool descriptions...")
-	var sampleContent strings.Builder
-	limit := 3
-	if len(allChunks) < limit {
-		limit = len(allChunks)
-	}
-	for i := 0; i < limit; i++ {
-		sampleContent.WriteString(allChunks[i].Content)
-		sampleContent.WriteString("\n\n")
-	}
-
-	agentYAMLData, err := os.ReadFile("agent.yaml")
-	if err != nil {
-		return fmt.Errorf("read agent.yaml: %w", err)
+		display.NextSteps(steps)
 	}
 
-	var agentConfig map[string]interface{}
-	if err := yaml.Unmarshal(agentYAMLData, &agentConfig); err != nil {
-		return fmt.Errorf("parse agent.yaml: %w", err)
-	}
-
-	agentName := "agent"
-	if a, ok := agentConfig["agent"].(map[string]interface{}); ok {
-		if name, ok := a["name"].(string); ok {
-			agentName = strings.ToLower(strings.ReplaceAll(name, " ", "_"))
-		}
-	}
-
-	mcpDesc, err := llmClient.GenerateMCPDescription(ctx, agentName, sampleContent.String())
-	if err != nil {
-		display.StepWarn(fmt.Sprintf("MCP description generation failed: %v", err))
-		mcpDesc = fmt.Sprintf("Search the %s expert knowledge base for relevant information.", agentName)
-	}
-
-	// Update agent.yaml with new MCP description
-	if err := updateAgentYAMLMCPDescription("agent.yaml", agentName, mcpDesc); err != nil {
-		display.StepWarn(fmt.Sprintf("failed to update agent.yaml: %v", err))
-	} else {
-		display.StepResult("Updated", "agent.yaml with MCP tool description")
+	if jsonOutput {
+		return printJSON(buildResult{
+			Documents:     res.Documents,
+			Skipped:       skippedFiles(res.Report),
+			Chunks:        res.Chunks,
+			Vectors:       res.Vectors,
+			Triples:       res.Triples,
+			Manifest:      kash.ManifestFile,
+			Report:        reportPath,
+			DurationMS:    res.Duration.Milliseconds(),
+			Unchanged:     res.Unchanged,
+			NoGraph:       res.NoGraph,
+			Snapshot:      res.Snapshot,
+			Review:        reviewPath,
+			ReviewTriples: res.ReviewTriples,
+			Warnings:      collectedWarnings(),
+			Tokens:        tokens,
+			CostUSD:       res.Report.CostUSD,
+		})
 	}
-
-	fmt.Println()
-	display.Success("Build complete!")
-	fmt.Println()
-	display.KeyValue("Vector index", fmt.Sprintf("%s (%d documents)", vectorPath, vs.Count()), display.BrightGreen)
-	display.KeyValue("Graph store", fmt.Sprintf("%s (%d triples)", graphPath, gdb.Count()), display.BrightGreen)
-
-	display.NextSteps([]string{
-		"docker compose up --build",
-	})
-
 	return nil
 }
 
-func updateAgentYAMLMCPDescription(path, agentName, description string) error {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("read agent.yaml: %w", err)
+// confirmBuild returns the hook that asks whether to go on with a build
+// estimated to cost more than threshold, or nil under --yes.
+func confirmBuild(cmd *cobra.Command, threshold float64) func(kash.BuildEstimate) error {
+	if buildYes {
+		return nil
 	}
-
-	var config map[string]interface{}
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return fmt.Errorf("parse agent.yaml: %w", err)
-	}
-
-	// Update or create mcp.tools section
-	mcpSection, _ := config["mcp"].(map[string]interface{})
-	if mcpSection == nil {
-		mcpSection = map[string]interface{}{}
+	return func(est kash.BuildEstimate) error {
+		// Neither answer is a usage error
+		cmd.SilenceUsage = true
+		if jsonOutput || !term.IsTerminal(int(os.Stdin.Fd())) {
+			return fmt.Errorf("estimated cost ~$%.2f exceeds pricing.confirm_above ($%.2f): pass --yes to build anyway", est.CostUSD, threshold)
+		}
+		display.Newline()
+		display.Warn(fmt.Sprintf("This build is estimated to cost ~$%.2f, above pricing.confirm_above ($%.2f)", est.CostUSD, threshold))
+		display.KeyValue("Embeddings", fmt.Sprintf("%d call(s), ~%d tokens (%s)", est.EmbedCalls, est.EmbedTokens, est.EmbedModel), display.BrightYellow)
+		display.KeyValue("LLM", fmt.Sprintf("%d call(s), ~%d prompt + ~%d completion tokens (%s)", est.LLMCalls, est.LLMPromptTokens, est.LLMCompletionTokens, est.LLMModel), display.BrightYellow)
+		display.KeyValue("Duration", (time.Duration(est.DurationMS) * time.Millisecond).Round(time.Second), display.BrightYellow)
+		answer, err := prompt(bufio.NewReader(cmd.InOrStdin()), "Continue? [y/N]", "n")
+		if err != nil {
+			return err
+		}
+		if !isYes(answer) {
+			return kash.ErrBuildDeclined
+		}
+		display.Newline()
+		return nil
 	}
+}
 
-	tools := []map[string]interface{}{
-		{
-			"name":        "search_" + agentName + "_knowledge",
-			"description": description,
-		},
+// skippedFiles counts the files and remote items a build skipped, leaving
+// out Kash's own files in data/.
+func skippedFiles(r *kash.BuildReport) int {
+	n := 0
+	for _, s := range r.Skipped {
+		if s.Reason != "excluded" {
+			n++
+		}
 	}
-	mcpSection["tools"] = tools
-	config["mcp"] = mcpSection
+	return n
+}
 
-	// Marshal back to YAML
-	output, err := yaml.Marshal(config)
-	if err != nil {
-		return fmt.Errorf("marshal agent.yaml: %w", err)
-	}
+// displayProgress prints build progress as the CLI's numbered steps.
+type displayProgress struct{}
 
-	return os.WriteFile(path, output, 0644)
-}
+func (displayProgress) Step(n, total int, msg string) { display.Step(n, total, msg) }
+func (displayProgress) Result(label, detail string)   { display.StepResult(label, detail) }
+func (displayProgress) Detail(msg string)             { display.StepDetail(msg) }
+func (displayProgress) Warn(msg string)               { display.StepWarn(msg) }
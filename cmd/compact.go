@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+var (
+	compactDir    string
+	compactDryRun bool
+)
+
+var compactCmd = &cobra.Command{
+	Use:   "compact",
+	Short: "Verify and compact the vector store",
+	Long: `Checks every chunk in data/memory.chromem and rewrites the store without:
+  - files chromem-go cannot decode, one of which keeps the whole store from loading
+  - vectors whose dimensions differ from the rest, or that are empty or not finite
+  - chunks stored twice
+  - orphaned chunks: chunks of documents that are neither in the manifest
+    nor in data/, and chunks the manifest no longer lists for their document
+  - files and directories that are not part of the store
+
+It then reports the space reclaimed. A store with nothing to remove is left
+as it is. Chunks that 'kash serve --live-ingest' added since the last build
+are kept while their files are in data/.
+
+No provider configuration is needed. Send SIGHUP to a running 'kash serve'
+afterwards, or run it with --watch.`,
+	Args: cobra.NoArgs,
+	RunE: runCompact,
+}
+
+func init() {
+	compactCmd.Flags().StringVarP(&compactDir, "dir", "d", ".", "Path to the agent project directory")
+	compactCmd.Flags().BoolVar(&compactDryRun, "dry-run", false, "Report what would be removed without rewriting the store")
+	rootCmd.AddCommand(compactCmd)
+}
+
+func runCompact(_ *cobra.Command, _ []string) error {
+	if err := chdirProject(compactDir); err != nil {
+		return err
+	}
+	const vectorPath = "data/memory.chromem"
+	if _, err := os.Stat(vectorPath); err != nil {
+		return fmt.Errorf("%s not found — run 'kash build' first", vectorPath)
+	}
+	m, err := manifest.Load(manifest.DefaultPath)
+	if err != nil && !errors.Is(err, manifest.ErrNotFound) {
+		return err
+	}
+
+	report, err := vector.Compact(context.Background(), vectorPath, vector.CompactOptions{
+		Keep:   keepChunk(m),
+		DryRun: compactDryRun,
+	})
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(report)
+	}
+	printCompact(report)
+	return nil
+}
+
+// keepChunk decides which chunks belong in the store. Without a manifest
+// every chunk is kept.
+func keepChunk(m *manifest.Manifest) func(vector.Document) bool {
+	if m == nil {
+		return nil
+	}
+	// docs maps each document to its chunk IDs, nil when the build that
+	// wrote the manifest did not record them
+	docs := make(map[string]map[string]bool, len(m.Documents))
+	for _, doc := range m.Documents {
+		var ids map[string]bool
+		if len(doc.ChunkIDs) > 0 {
+			ids = make(map[string]bool, len(doc.ChunkIDs))
+			for _, id := range doc.ChunkIDs {
+				ids[id] = true
+			}
+		}
+		docs[doc.Name] = ids
+	}
+	return func(d vector.Document) bool {
+		if ids, ok := docs[d.Source]; ok {
+			return ids == nil || ids[d.ID]
+		}
+		// Live ingestion adds documents before the next build lists them
+		_, err := os.Stat(filepath.Join("data", d.Source))
+		return err == nil
+	}
+}
+
+func printCompact(r vector.CompactReport) {
+	display.Header("🗜️  Kash Compact")
+	display.Newline()
+	display.KeyValue("Chunks kept", r.Chunks, display.BrightCyan)
+	display.KeyValue("Dimensions", r.Dimensions, display.Bold+display.BrightYellow)
+	if d := agentconfig.AgentYAMLDimensions("agent.yaml"); d > 0 && r.Dimensions > 0 && d != r.Dimensions {
+		display.Warn(fmt.Sprintf("agent.yaml configures %d dimensions, but the store has %d — run 'kash build' to re-embed", d, r.Dimensions))
+	}
+
+	if r.Removed() == 0 {
+		display.Newline()
+		display.Success(fmt.Sprintf("Every chunk is intact, nothing to remove (%s)", formatBytes(r.BytesBefore)))
+		return
+	}
+
+	verb := "Removed"
+	if !r.Rewritten {
+		verb = "Would remove"
+	}
+	display.SubHeader(verb)
+	for _, group := range []struct {
+		label string
+		items []string
+	}{
+		{"Undecodable files", r.Undecodable},
+		{"Wrong dimensions", r.WrongDimensions},
+		{"Invalid vectors", r.InvalidVectors},
+		{"Duplicates", r.Duplicates},
+		{"Orphaned chunks", r.Orphaned},
+		{"Stray files", r.StrayFiles},
+	} {
+		if len(group.items) == 0 {
+			continue
+		}
+		display.KeyValue(group.label, len(group.items), display.BrightYellow)
+		for i, item := range group.items {
+			if i == 5 {
+				display.StepDetail(fmt.Sprintf("… %d more (--json lists all)", len(group.items)-i))
+				break
+			}
+			display.StepDetail(item)
+		}
+	}
+
+	display.Newline()
+	if !r.Rewritten {
+		display.Info("Dry run — run without --dry-run to rewrite the store")
+		return
+	}
+	display.Success(fmt.Sprintf("Compacted %s → %s, reclaimed %s",
+		formatBytes(r.BytesBefore), formatBytes(r.BytesAfter), formatBytes(r.BytesBefore-r.BytesAfter)))
+	display.NextSteps([]string{"Send SIGHUP to a running 'kash serve' to load the compacted store (or run it with --watch)"})
+}
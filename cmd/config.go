@@ -0,0 +1,458 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+var (
+	configListRedact      bool
+	configProject         bool
+	configValidateOffline bool
+	configValidateTimeout time.Duration
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Read and change settings in ~/.kash/config.yaml",
+	Long: `Manages the global config file without editing YAML by hand. Keys use dotted
+names such as llm.model or embedder.base_url; run 'kash config list' to see
+them all. Environment variables (LLM_MODEL, ...) still take priority over the
+file, and list shows where each effective value comes from.
+
+  kash config set llm.model gpt-4o
+  kash config set llm.api_key -        # read the value from stdin
+  kash config set-secret llm.api_key   # store it in the OS keyring instead
+  kash config get llm.model
+  kash config list --redact
+  kash config unset reranker.model
+  kash config validate                 # probe the endpoints before a build
+
+API keys can also stay out of the file entirely: set llm.api_key_file (or
+LLM_API_KEY_FILE) to a file holding the key, such as a Docker secret, or use
+set-secret to keep it in the OS keyring. Environment variables always win.
+
+Named profiles under profiles: override the top-level settings when selected
+with --profile, KASH_PROFILE, or the profile key; 'kash config profiles' lists
+them.
+
+A config.yaml in an agent project directory (next to agent.yaml) overrides
+the global file for that project. Pass --project to read and write it instead
+of ~/.kash/config.yaml; --config selects any other global file.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get [key]",
+	Short: "Print the effective value of a key (or all set keys)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Write a key to the config file (use - to read the value from stdin)",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a key from the config file",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every key with its effective value and origin",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+var configSetSecretCmd = &cobra.Command{
+	Use:   "set-secret <key>",
+	Short: "Store a secret such as llm.api_key in the OS keyring",
+	Long: `Prompts for the value (or reads it from stdin when not a terminal), stores it
+in the OS keyring (macOS Keychain, Windows Credential Manager, or the Secret
+Service on Linux), and lists the key under keyring: in the config file so it
+is loaded from there.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigSetSecret,
+}
+
+var configUnsetSecretCmd = &cobra.Command{
+	Use:   "unset-secret <key>",
+	Short: "Remove a secret from the OS keyring",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnsetSecret,
+}
+
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List the provider profiles defined in config.yaml",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigProfiles,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check required settings and probe the provider endpoints",
+	Long: `Checks that the settings kash build and kash serve need are present, then
+sends a tiny request to the chat, embeddings, and rerank endpoints and reports
+the latency of each. The embedder's reply must have at least as many
+dimensions as configured (agent.yaml in the current directory, or the
+default of 1024), and as many as a compiled store in data/ holds.
+
+Run it before a long build to catch a wrong URL, key, or model in seconds.
+The command exits with an error when any check fails.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigValidate,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the config file",
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.PersistentFlags().BoolVar(&configProject, "project", false, "Use ./config.yaml of the current agent project")
+	configListCmd.Flags().BoolVar(&configListRedact, "redact", false, "Mask API keys and other secrets")
+	configValidateCmd.Flags().BoolVar(&configValidateOffline, "offline", false, "Only check that required settings are present")
+	configValidateCmd.Flags().DurationVar(&configValidateTimeout, "timeout", 30*time.Second, "Timeout for each endpoint probe")
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd, configSetSecretCmd, configUnsetSecretCmd, configListCmd, configProfilesCmd, configValidateCmd, configPathCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// configFilePath returns the file the config subcommands edit: the project
+// config with --project, the file given with --config, or ~/.kash/config.yaml.
+func configFilePath() (string, error) {
+	if configProject {
+		return filepath.Abs(agentconfig.ProjectConfigFile)
+	}
+	return globalConfigPath()
+}
+
+func globalConfigPath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	return agentconfig.ConfigFilePath()
+}
+
+func runConfigGet(_ *cobra.Command, args []string) error {
+	cfg, err := agentconfig.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if len(args) == 1 {
+		value, err := cfg.Get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(value)
+		return nil
+	}
+	for _, k := range agentconfig.Keys() {
+		if v, _ := cfg.Get(k.Name); v != "" {
+			fmt.Printf("%s=%s\n", k.Name, v)
+		}
+	}
+	return nil
+}
+
+func runConfigSet(_ *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	if value == "-" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("read value from stdin: %w", err)
+		}
+		value = strings.TrimRight(line, "\r\n")
+	}
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	if err := agentconfig.SetFileValue(path, key, value); err != nil {
+		return err
+	}
+
+	shown := value
+	if agentconfig.IsSecret(key) {
+		shown = agentconfig.Redact(value)
+	}
+	display.Success(fmt.Sprintf("%s = %s", key, shown))
+	display.StepDetail(path)
+	if agentconfig.IsSecret(key) {
+		display.StepDetail(fmt.Sprintf("stored in plain text; 'kash config set-secret %s' keeps it in the OS keyring instead", key))
+	}
+	warnEnvOverride(key)
+	return nil
+}
+
+func runConfigSetSecret(_ *cobra.Command, args []string) error {
+	key := args[0]
+	if !agentconfig.IsSecret(key) {
+		return fmt.Errorf("%s is not a secret key — use 'kash config set' instead", key)
+	}
+	value, err := readSecretInput(key)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return errors.New("no value given")
+	}
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	if err := agentconfig.SetKeyringSecret(path, key, value); err != nil {
+		return err
+	}
+	// A plaintext copy in the file would take priority over the keyring
+	if _, err := agentconfig.UnsetFileValue(path, key); err != nil {
+		return err
+	}
+
+	display.Success(fmt.Sprintf("%s = %s stored in the OS keyring", key, agentconfig.Redact(value)))
+	display.StepDetail(path)
+	warnEnvOverride(key)
+	return nil
+}
+
+func runConfigUnsetSecret(_ *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	removed, err := agentconfig.DeleteKeyringSecret(path, args[0])
+	if err != nil {
+		return err
+	}
+	if !removed {
+		display.Info(args[0] + " is not stored in the OS keyring")
+		return nil
+	}
+	display.Success("Removed " + args[0] + " from the OS keyring")
+	return nil
+}
+
+// readSecretInput prompts for a secret without echo on a terminal, or reads
+// one line from piped stdin.
+func readSecretInput(key string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Value for %s: ", key)
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("read value: %w", err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read value from stdin: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func runConfigUnset(_ *cobra.Command, args []string) error {
+	path, err := configFilePath()
+	if err != nil {
+		return err
+	}
+	removed, err := agentconfig.UnsetFileValue(path, args[0])
+	if err != nil {
+		return err
+	}
+	if !removed {
+		display.Info(fmt.Sprintf("%s is not set in %s", args[0], path))
+		return nil
+	}
+	display.Success("Removed " + args[0])
+	display.StepDetail(path)
+	warnEnvOverride(args[0])
+	return nil
+}
+
+// warnEnvOverride notes when an environment variable masks the file value.
+func warnEnvOverride(key string) {
+	if k, ok := agentconfig.LookupKey(key); ok && k.Env != "" && os.Getenv(k.Env) != "" {
+		display.Warn(fmt.Sprintf("%s is set in the environment and takes priority over the file", k.Env))
+	}
+}
+
+func runConfigList(_ *cobra.Command, _ []string) error {
+	path, err := globalConfigPath()
+	if err != nil {
+		return err
+	}
+	fileValues, err := agentconfig.ReadFileValues(path)
+	if err != nil {
+		return err
+	}
+	projectPath := agentconfig.ProjectConfigPath()
+	projectValues := map[string]string{}
+	if projectPath != "" && projectPath != path {
+		if projectValues, err = agentconfig.ReadFileValues(projectPath); err != nil {
+			return err
+		}
+	}
+	cfg, err := agentconfig.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	profileValues := map[string]string{}
+	if p, ok := cfg.Profiles[strings.ToLower(cfg.Profile)]; ok {
+		for _, k := range agentconfig.Keys() {
+			if v, _ := p.Get(k.Name); v != "" {
+				profileValues[k.Name] = v
+			}
+		}
+	}
+
+	display.Header("⚙️  Kash Config")
+	fmt.Println()
+	display.KeyValue("Global file", path, display.Dim+display.White)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		display.Warn("global config file does not exist yet — 'kash config set' creates it")
+	}
+	if len(projectValues) > 0 {
+		display.KeyValue("Project file", projectPath, display.Dim+display.White)
+	}
+	if cfg.Profile != "" {
+		display.KeyValue("Profile", cfg.Profile, display.BrightMagenta)
+	}
+	fmt.Println()
+
+	for _, k := range agentconfig.Keys() {
+		value, _ := cfg.Get(k.Name)
+		origin := ""
+		switch {
+		case k.Env != "" && os.Getenv(k.Env) != "":
+			origin = "env " + k.Env
+		case profileValues[k.Name] != "":
+			origin = "profile " + cfg.Profile
+		case projectValues[k.Name] != "":
+			origin = "project"
+		case fileValues[k.Name] != "":
+			origin = "global"
+		case value != "" && strings.HasSuffix(k.Name, ".api_key") && (projectValues[k.Name+"_file"] != "" || fileValues[k.Name+"_file"] != ""):
+			origin = "api_key_file"
+		case value != "" && slices.Contains(cfg.Keyring, k.Name):
+			origin = "keyring"
+		case value != "":
+			origin = "default"
+		}
+		if value != "" && k.Secret && configListRedact {
+			value = agentconfig.Redact(value)
+		}
+		color := display.BrightCyan
+		if value == "" {
+			value, color = "-", display.Dim+display.White
+		}
+		fmt.Printf("    %s%-30s%s %s%-44s%s %s%s%s\n",
+			display.Dim, k.Name, display.Reset, color, value, display.Reset, display.Dim+display.Yellow, origin, display.Reset)
+	}
+	return nil
+}
+
+func runConfigProfiles(_ *cobra.Command, _ []string) error {
+	cfg, err := agentconfig.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	names := agentconfig.ProfileNames(cfg)
+	if len(names) == 0 {
+		display.Info("No profiles defined — add a profiles: block to config.yaml")
+		return nil
+	}
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		marker := "  "
+		if strings.EqualFold(name, cfg.Profile) {
+			marker = display.BrightGreen + "* " + display.Reset
+		}
+		var parts []string
+		if p.LLM.Model != "" {
+			parts = append(parts, "llm "+p.LLM.Model)
+		}
+		if p.Embedder.Model != "" || p.Embedder.BaseURL != "" {
+			parts = append(parts, "embedder "+firstNonEmpty(p.Embedder.Model, p.Embedder.BaseURL))
+		}
+		if p.Reranker.Model != "" {
+			parts = append(parts, "reranker "+p.Reranker.Model)
+		}
+		fmt.Printf("  %s%s%-20s%s %s%s%s\n", marker, display.Bold, name, display.Reset, display.Dim, strings.Join(parts, ", "), display.Reset)
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func runConfigValidate(_ *cobra.Command, _ []string) error {
+	cfg, err := agentconfig.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	agentconfig.ApplyAgentYAMLDimensions(cfg, "agent.yaml")
+
+	// Compare against a compiled store when run inside a built project
+	storedDims := 0
+	if _, err := os.Stat("data/memory.chromem"); err == nil {
+		if storedDims, err = vector.StoredDimensions("data/memory.chromem"); err != nil {
+			return err
+		}
+	}
+
+	display.Header("🔌 Kash Config Validate")
+	d := &doctor{timeout: configValidateTimeout}
+
+	display.SubHeader("Settings")
+	d.checkConfig(cfg)
+
+	display.SubHeader("Endpoints")
+	if configValidateOffline {
+		display.StepDetail("skipped (--offline)")
+	} else {
+		d.checkEndpoints(cfg, storedDims)
+	}
+
+	fmt.Println()
+	if d.failures > 0 {
+		return fmt.Errorf("%d check(s) failed, %d warning(s)", d.failures, d.warnings)
+	}
+	display.Success("Configuration is valid")
+	return nil
+}
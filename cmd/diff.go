@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/snapshot"
+)
+
+// workingBuild names the stores in data/ as a side of 'kash diff'.
+const workingBuild = "data"
+
+var (
+	diffDir      string
+	diffTriples  int
+	diffExitCode bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [from] [to]",
+	Short: "Show what changed in the knowledge base between two builds",
+	Long: `Compares two builds and lists the added, removed, and changed documents
+with their chunk counts, and the added and removed triples.
+
+Each side is a snapshot version from 'kash snapshots list' (v3, or just 3) or
+"data" for the stores in data/. Without arguments, the snapshot before the
+current build is compared with data/, which shows what the last build changed.
+With one argument, that snapshot is compared with data/.
+
+A changed document reports its new chunks: chunks whose text the older build
+did not have. No provider configuration is needed.`,
+	Example: `  kash diff            # what the last build changed
+  kash diff v3         # snapshot v3 against data/
+  kash diff v3 v5      # snapshot v3 against v5
+  kash diff --json v3 | jq '.triples_added | length'`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffDir, "dir", "d", ".", "Path to the agent project directory")
+	diffCmd.Flags().IntVar(&diffTriples, "triples", 20, "Number of added and removed triples to list (-1 for all)")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with an error when the builds differ")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// diffResult is the --json output of 'kash diff'.
+type diffResult struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	snapshot.Diff
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	if err := chdirProject(diffDir); err != nil {
+		return err
+	}
+	infos, err := snapshot.List(snapshot.DefaultDir)
+	if err != nil {
+		return err
+	}
+
+	from, to := "", workingBuild
+	switch len(args) {
+	case 2:
+		from, to = args[0], args[1]
+	case 1:
+		from = args[0]
+	default:
+		if len(infos) == 0 {
+			return fmt.Errorf("no snapshots in %s — name two builds, or run 'kash build' first", snapshot.DefaultDir)
+		}
+		prev, err := snapshot.Find(infos, "", snapshot.Current(infos, manifest.DefaultPath))
+		if err != nil {
+			return err
+		}
+		from = prev.Version
+	}
+	fromName, fromPaths, err := resolveBuild(infos, from)
+	if err != nil {
+		return err
+	}
+	toName, toPaths, err := resolveBuild(infos, to)
+	if err != nil {
+		return err
+	}
+
+	d, err := snapshot.Compare(context.Background(), fromPaths, toPaths)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		if err := printJSON(diffResult{From: fromName, To: toName, Diff: d}); err != nil {
+			return err
+		}
+	} else {
+		printDiff(fromName, toName, d)
+	}
+	if diffExitCode && !d.Empty() {
+		// A difference is not a usage mistake
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%s and %s differ", fromName, toName)
+	}
+	return nil
+}
+
+// resolveBuild locates the stores of a 'kash diff' side.
+func resolveBuild(infos []snapshot.Info, name string) (string, snapshot.Paths, error) {
+	if name == workingBuild || name == "data/" {
+		p := snapshot.Paths{
+			Vectors:  filepath.Join("data", "memory.chromem"),
+			Graph:    filepath.Join("data", "knowledge.cayley"),
+			Manifest: manifest.DefaultPath,
+		}
+		for _, path := range []string{p.Vectors, p.Graph} {
+			if _, err := os.Stat(path); err != nil {
+				return "", p, fmt.Errorf("%s not found — run 'kash build' first", path)
+			}
+		}
+		return workingBuild, p, nil
+	}
+	info, err := snapshot.Find(infos, name, "")
+	if err != nil {
+		return "", snapshot.Paths{}, fmt.Errorf("%w — see 'kash snapshots list'", err)
+	}
+	return info.Version, info.Paths(snapshot.DefaultDir), nil
+}
+
+func printDiff(from, to string, d snapshot.Diff) {
+	display.Header(fmt.Sprintf("🔀 Kash Diff: %s → %s", from, to))
+	if d.Empty() {
+		display.Newline()
+		display.Success("No changes")
+		return
+	}
+
+	display.SubHeader("Documents")
+	for _, doc := range d.Documents {
+		switch doc.Status {
+		case snapshot.Added:
+			fmt.Printf("    %s+ %s%s  %s%d chunks%s\n", display.BrightGreen, doc.Name, display.Reset, display.Dim, doc.ChunksAfter, display.Reset)
+		case snapshot.Removed:
+			fmt.Printf("    %s- %s%s  %s%d chunks%s\n", display.BrightRed, doc.Name, display.Reset, display.Dim, doc.ChunksBefore, display.Reset)
+		default:
+			fmt.Printf("    %s~ %s%s  %s%d → %d chunks, %d new%s\n", display.BrightYellow, doc.Name, display.Reset, display.Dim, doc.ChunksBefore, doc.ChunksAfter, doc.NewChunks, display.Reset)
+		}
+	}
+	if d.UnchangedDocuments > 0 {
+		fmt.Printf("    %s%d unchanged%s\n", display.Dim, d.UnchangedDocuments, display.Reset)
+	}
+
+	display.SubHeader("Triples")
+	if len(d.TriplesAdded) == 0 && len(d.TriplesRemoved) == 0 {
+		fmt.Printf("    %sno changes%s\n", display.Dim, display.Reset)
+	}
+	printDiffTriples("+", display.BrightGreen, d.TriplesAdded)
+	printDiffTriples("-", display.BrightRed, d.TriplesRemoved)
+
+	display.SubHeader("Totals")
+	display.KeyValue("Chunks", fmt.Sprintf("%d → %d (%+d)", d.ChunksBefore, d.ChunksAfter, d.ChunksAfter-d.ChunksBefore), display.BrightCyan)
+	display.KeyValue("Triples", fmt.Sprintf("%d → %d (+%d −%d)", d.TriplesBefore, d.TriplesAfter, len(d.TriplesAdded), len(d.TriplesRemoved)), display.BrightCyan)
+}
+
+func printDiffTriples(sign, color string, triples []graph.Triple) {
+	shown := len(triples)
+	if diffTriples >= 0 && shown > diffTriples {
+		shown = diffTriples
+	}
+	for _, t := range triples[:shown] {
+		fmt.Printf("    %s%s %s %s%s%s %s%s\n", color, sign, t.Subject, display.Dim, t.Predicate, display.Reset+color, t.Object, display.Reset)
+	}
+	if shown < len(triples) {
+		fmt.Printf("    %s%s %d more (--triples -1 lists all)%s\n", display.Dim, sign, len(triples)-shown, display.Reset)
+	}
+}
@@ -0,0 +1,470 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/reader"
+	"github.com/akashicode/kash/internal/source"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+var (
+	doctorDir     string
+	doctorOffline bool
+	doctorTimeout time.Duration
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose configuration, provider endpoints, and compiled data",
+	Long: `Checks that everything kash build and kash serve need is in place:
+
+  config     required LLM and embedder settings, optional providers
+  project    agent.yaml, data/, sidecars, and the compiled databases
+  endpoints  a live request to the LLM, embedder, and reranker (skip with --offline)
+
+The embedding dimensions reported by the embedder, set in agent.yaml, and
+stored in data/memory.chromem must agree, or queries fail or return noise.
+Every problem is printed with the change that fixes it. The command exits
+with an error when any check fails; warnings do not fail it.`,
+	RunE: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().StringVarP(&doctorDir, "dir", "d", ".", "Path to the agent project directory")
+	doctorCmd.Flags().BoolVar(&doctorOffline, "offline", false, "Skip the live endpoint checks")
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 30*time.Second, "Timeout for each endpoint check")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// doctor collects check outcomes and prints them as they are made.
+type doctor struct {
+	// timeout bounds each endpoint check
+	timeout  time.Duration
+	warnings int
+	failures int
+}
+
+func (d *doctor) ok(name, detail string) {
+	fmt.Printf("    %s✓%s %-18s %s%s%s\n", display.Bold+display.BrightGreen, display.Reset, name, display.Dim+display.White, detail, display.Reset)
+}
+
+func (d *doctor) warn(name, detail, fix string) {
+	d.warnings++
+	fmt.Printf("    %s⚠%s %-18s %s%s%s\n", display.Bold+display.BrightYellow, display.Reset, name, display.Yellow, detail, display.Reset)
+	d.fix(fix)
+}
+
+func (d *doctor) fail(name, detail, fix string) {
+	d.failures++
+	fmt.Printf("    %s✗%s %-18s %s%s%s\n", display.Bold+display.BrightRed, display.Reset, name, display.Red, detail, display.Reset)
+	d.fix(fix)
+}
+
+func (d *doctor) fix(fix string) {
+	if fix != "" {
+		fmt.Printf("      %s%s→ %s%s\n", strings.Repeat(" ", 19), display.Cyan, fix, display.Reset)
+	}
+}
+
+func runDoctor(_ *cobra.Command, _ []string) error {
+	if doctorDir != "." {
+		abs, err := filepath.Abs(doctorDir)
+		if err != nil {
+			return fmt.Errorf("resolve directory %q: %w", doctorDir, err)
+		}
+		if err := os.Chdir(abs); err != nil {
+			return fmt.Errorf("change to directory %q: %w", abs, err)
+		}
+	}
+
+	cfg, err := agentconfig.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	agentconfig.ApplyAgentYAMLDimensions(cfg, "agent.yaml")
+
+	display.Header("🩺 Kash Doctor")
+	d := &doctor{timeout: doctorTimeout}
+
+	display.SubHeader("Configuration")
+	d.checkConfig(cfg)
+
+	display.SubHeader("Project")
+	storedDims := d.checkProject(cfg)
+
+	display.SubHeader("Endpoints")
+	if doctorOffline {
+		display.StepDetail("skipped (--offline)")
+	} else {
+		d.checkEndpoints(cfg, storedDims)
+	}
+
+	fmt.Println()
+	switch {
+	case d.failures > 0:
+		return fmt.Errorf("%d check(s) failed, %d warning(s)", d.failures, d.warnings)
+	case d.warnings > 0:
+		display.Warn(fmt.Sprintf("No failures, %d warning(s)", d.warnings))
+	default:
+		display.Success("All checks passed")
+	}
+	return nil
+}
+
+func (d *doctor) checkConfig(cfg *agentconfig.Config) {
+	cfgPath, err := agentconfig.ConfigFilePath()
+	if err == nil {
+		if _, statErr := os.Stat(cfgPath); statErr == nil {
+			d.ok("config file", cfgPath)
+		} else {
+			d.warn("config file", cfgPath+" not found, using environment variables only",
+				"run 'kash init <name>' once to create it, or keep setting LLM_* and EMBED_* variables")
+		}
+	}
+
+	if path := agentconfig.ProjectConfigPath(); path != "" {
+		d.ok("project config", path+" (overrides the global file)")
+	}
+	if files, err := agentconfig.LoadDotEnv(); err != nil {
+		d.fail(".env", err.Error(), "fix the KEY=value syntax in the file")
+	} else if len(files) > 0 {
+		d.ok(".env", "loaded "+strings.Join(files, ", "))
+	}
+	if cfg.Profile != "" {
+		d.ok("profile", cfg.Profile)
+	}
+
+	if err := agentconfig.ValidateLLM(cfg); err != nil {
+		d.fail("llm", "missing "+missingSettings(err), "set them in ~/.kash/config.yaml under llm: or as environment variables")
+	} else {
+		d.ok("llm", cfg.LLM.Model+" at "+cfg.LLM.BaseURL)
+		if b := cfg.BuildLLM; b.BaseURL != "" || b.Model != "" {
+			p := cfg.BuildLLMConfig()
+			d.ok("build llm", p.Model+" at "+p.BaseURL)
+		}
+	}
+
+	if err := agentconfig.ValidateEmbedder(cfg); err != nil {
+		d.fail("embedder", "missing "+missingSettings(err), "set them in ~/.kash/config.yaml under embedder: or as environment variables")
+	} else {
+		model := cfg.Embedder.Model
+		if model == "" {
+			model = "(router default)"
+		}
+		d.ok("embedder", fmt.Sprintf("%s at %s, %d dimensions", model, cfg.Embedder.BaseURL, cfg.Embedder.Dimensions))
+		for _, code := range cfg.Embedder.LanguageCodes() {
+			route, _ := cfg.Embedder.ForLanguage(code)
+			d.ok("embedder", fmt.Sprintf("%s: %s at %s", code, firstNonEmpty(route.Model, "(router default)"), route.BaseURL))
+		}
+	}
+
+	optional := func(name string, p agentconfig.ProviderConfig, envPrefix string) {
+		switch {
+		case p.Local():
+			d.ok(name, "local model "+p.Model)
+		case p.BaseURL == "" && p.Model == "":
+			d.ok(name, "not configured (optional)")
+		case p.BaseURL == "" || p.Model == "":
+			d.warn(name, "partially configured, it will be ignored",
+				fmt.Sprintf("set both %s.base_url and %s.model (or %s_BASE_URL and %s_MODEL)", name, name, envPrefix, envPrefix))
+		case p.APIKey == "":
+			d.warn(name, p.Model+" has no api_key", fmt.Sprintf("set %s.api_key or %s_API_KEY if the endpoint requires one", name, envPrefix))
+		default:
+			d.ok(name, p.Model+" at "+p.BaseURL)
+		}
+	}
+	optional("reranker", cfg.Reranker, "RERANK")
+	if cfg.Transcriber.BaseURL != "" || cfg.Transcriber.Model != "" {
+		optional("transcriber", cfg.Transcriber, "TRANSCRIBE")
+	}
+}
+
+// missingSettings condenses the multi-line Validate* error into a single line.
+func missingSettings(err error) string {
+	msg := err.Error()
+	if i := strings.Index(msg, "\n\n"); i >= 0 {
+		msg = msg[:i]
+	}
+	var settings []string
+	for _, line := range strings.Split(strings.TrimPrefix(msg, "missing required config:"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			settings = append(settings, line)
+		}
+	}
+	return strings.Join(settings, ", ")
+}
+
+// checkProject verifies the agent project layout and returns the embedding
+// dimensions of the persisted vector store, or 0 when unknown.
+func (d *doctor) checkProject(cfg *agentconfig.Config) int {
+	data, err := os.ReadFile("agent.yaml")
+	if err != nil {
+		d.fail("agent.yaml", "not found in "+mustGetwd(), "run 'kash init <name>' or pass --dir <project>")
+		return 0
+	}
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		d.fail("agent.yaml", "invalid YAML: "+err.Error(), "fix the syntax error in agent.yaml")
+	} else if agentconfig.AgentYAMLDimensions("agent.yaml") == 0 {
+		d.warn("agent.yaml", "runtime.embedder.dimensions not set, defaulting to "+fmt.Sprint(cfg.Embedder.Dimensions),
+			"pin runtime.embedder.dimensions in agent.yaml to your embedding model's output size")
+	} else {
+		d.ok("agent.yaml", fmt.Sprintf("embedder dimensions %d", cfg.Embedder.Dimensions))
+	}
+
+	d.checkDataDir()
+
+	storedDims := 0
+	const vectorPath = "data/memory.chromem"
+	if _, err := os.Stat(vectorPath); err != nil {
+		d.warn("vector store", vectorPath+" not found", "run 'kash build' to compile the knowledge base")
+	} else if docs, err := vector.ReadDocuments(vectorPath); err != nil {
+		d.fail("vector store", "unreadable: "+err.Error(), "run 'kash compact' to drop the damaged files, or delete "+vectorPath+" and run 'kash build'")
+	} else if len(docs) == 0 {
+		d.warn("vector store", "empty", "run 'kash build' to index data/")
+	} else {
+		storedDims = len(docs[0].Embedding)
+		mixed := 0
+		for _, doc := range docs {
+			if len(doc.Embedding) != storedDims {
+				mixed++
+			}
+		}
+		switch {
+		case mixed > 0:
+			d.fail("vector store", fmt.Sprintf("%d of %d chunks have a different embedding size", mixed, len(docs)),
+				"run 'kash compact' to drop the odd ones, or delete "+vectorPath+" and run 'kash build' to re-embed everything with one model")
+		case storedDims != cfg.Embedder.Dimensions:
+			d.fail("vector store", fmt.Sprintf("%d chunks with %d dimensions, but agent.yaml configures %d", len(docs), storedDims, cfg.Embedder.Dimensions),
+				fmt.Sprintf("set runtime.embedder.dimensions: %d in agent.yaml, or run 'kash build' to re-embed", storedDims))
+		default:
+			d.ok("vector store", fmt.Sprintf("%d chunks, %d dimensions", len(docs), storedDims))
+		}
+	}
+
+	const graphPath = "data/knowledge.cayley"
+	m, _ := manifest.Load(manifest.DefaultPath)
+	if entries, err := os.ReadDir(graphPath); m != nil && m.NoGraph && err != nil {
+		d.ok("graph store", "skipped (vector-only build)")
+	} else if err != nil {
+		d.warn("graph store", graphPath+" not found", "run 'kash build' to extract the knowledge graph")
+	} else if len(entries) == 0 {
+		d.warn("graph store", "empty", "run 'kash build' to extract the knowledge graph")
+	} else {
+		d.ok("graph store", graphPath)
+	}
+
+	d.checkManifest(cfg, storedDims)
+	return storedDims
+}
+
+// checkDataDir counts ingestible files in data/ and flags sidecars whose
+// document is missing.
+func (d *doctor) checkDataDir() {
+	entries, err := os.ReadDir("data")
+	if err != nil {
+		d.fail("data/", "not found", "create data/ and add documents, or run 'kash init <name>'")
+		return
+	}
+	plugins := make(map[string]bool)
+	for _, p := range agentconfig.AgentYAMLIngest("agent.yaml").Plugins {
+		for _, ext := range p.Extensions {
+			plugins[strings.TrimPrefix(strings.ToLower(ext), ".")] = true
+		}
+	}
+	supported, unsupported := 0, 0
+	var orphans []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		switch {
+		case reader.IsSidecar(name):
+			if _, err := os.Stat(filepath.Join("data", strings.TrimSuffix(name, reader.SidecarSuffix))); err != nil {
+				orphans = append(orphans, name)
+			}
+		case name == filepath.Base(manifest.DefaultPath) || name == source.URLListFile:
+		case reader.IsSupported(name) || plugins[strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")]:
+			supported++
+		default:
+			unsupported++
+		}
+	}
+	switch {
+	case supported == 0:
+		d.warn("data/", "no supported documents", "add .md, .txt, .pdf, .html, .csv, .json, or other supported files, or configure sources in agent.yaml")
+	case unsupported > 0:
+		d.ok("data/", fmt.Sprintf("%d document(s), %d unsupported file(s) ignored", supported, unsupported))
+	default:
+		d.ok("data/", fmt.Sprintf("%d document(s)", supported))
+	}
+	if len(orphans) > 0 {
+		d.warn("sidecars", "no matching document for "+strings.Join(orphans, ", "),
+			"rename each sidecar to <document file>"+reader.SidecarSuffix+" or delete it")
+	}
+}
+
+// checkManifest compares the build manifest with the current configuration.
+func (d *doctor) checkManifest(cfg *agentconfig.Config, storedDims int) {
+	m, err := manifest.Load(manifest.DefaultPath)
+	if errors.Is(err, manifest.ErrNotFound) {
+		d.warn("manifest", manifest.DefaultPath+" not found", "run 'kash build' to record what the databases were built from")
+		return
+	}
+	if err != nil {
+		d.fail("manifest", err.Error(), "delete "+manifest.DefaultPath+" and run 'kash build'")
+		return
+	}
+	built := fmt.Sprintf("built %s with kash %s", m.BuiltAt.Local().Format("2006-01-02 15:04"), m.KashVersion)
+	languagesErr := m.CheckLanguages(manifest.Embedder(cfg.Embedder))
+	switch {
+	case languagesErr != nil:
+		d.fail("manifest", languagesErr.Error(), "queries must be embedded with the models the store was built with")
+	case m.Embedder.Model != "" && cfg.Embedder.Model != "" && m.Embedder.Model != cfg.Embedder.Model:
+		d.warn("manifest", fmt.Sprintf("%s using embedder %s, but %s is configured", built, m.Embedder.Model, cfg.Embedder.Model),
+			"queries must use the model the store was built with: switch back or run 'kash build'")
+	case storedDims > 0 && m.Vectors > 0 && m.Embedder.Dimensions > 0 && m.Embedder.Dimensions != storedDims:
+		d.warn("manifest", fmt.Sprintf("records %d dimensions but the store has %d", m.Embedder.Dimensions, storedDims),
+			"the store was modified after the build; run 'kash build' to bring them back in sync")
+	default:
+		d.ok("manifest", built)
+	}
+}
+
+// checkEndpoints sends a minimal request to each configured provider.
+func (d *doctor) checkEndpoints(cfg *agentconfig.Config, storedDims int) {
+	probe := func(fn func(ctx context.Context) error) (time.Duration, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+		defer cancel()
+		start := time.Now()
+		err := fn(ctx)
+		return time.Since(start).Round(time.Millisecond), err
+	}
+
+	if agentconfig.ValidateLLM(cfg) == nil {
+		client, err := llm.NewClient(&cfg.LLM)
+		if err == nil {
+			var took time.Duration
+			took, err = probe(func(ctx context.Context) error {
+				_, err := client.Complete(ctx, "Reply with the single word OK.", "ping")
+				return err
+			})
+			if err == nil {
+				d.ok("llm", fmt.Sprintf("responded in %s", took))
+			}
+		}
+		if err != nil {
+			d.fail("llm", err.Error(), endpointFix("llm", "LLM", err))
+		}
+		if b := cfg.BuildLLM; b.BaseURL != "" || b.Model != "" {
+			buildCfg := cfg.BuildLLMConfig()
+			client, err := llm.NewClient(&buildCfg)
+			if err == nil {
+				var took time.Duration
+				took, err = probe(func(ctx context.Context) error {
+					_, err := client.Complete(ctx, "Reply with the single word OK.", "ping")
+					return err
+				})
+				if err == nil {
+					d.ok("build llm", fmt.Sprintf("responded in %s", took))
+				}
+			}
+			if err != nil {
+				d.fail("build llm", err.Error(), endpointFix("build_llm", "BUILD_LLM", err))
+			}
+		}
+	} else {
+		d.warn("llm", "skipped, not configured", "")
+	}
+
+	if agentconfig.ValidateEmbedder(cfg) == nil {
+		embedder, err := llm.NewEmbedder(&cfg.Embedder)
+		var vec []float32
+		var took time.Duration
+		if err == nil {
+			took, err = probe(func(ctx context.Context) error {
+				vec, err = embedder.Embed(ctx, "ping")
+				return err
+			})
+		}
+		configured := cfg.Embedder.Dimensions
+		switch {
+		case err != nil:
+			d.fail("embedder", err.Error(), endpointFix("embedder", "EMBED", err))
+		case len(vec) < configured:
+			d.fail("embedder", fmt.Sprintf("returns %d dimensions, but agent.yaml configures %d", len(vec), configured),
+				fmt.Sprintf("set runtime.embedder.dimensions: %d in agent.yaml and run 'kash build', or use a model with at least %d dimensions", len(vec), configured))
+		case storedDims > 0 && len(vec) < storedDims:
+			d.fail("embedder", fmt.Sprintf("returns %d dimensions, but the store holds %d", len(vec), storedDims),
+				"switch back to the embedding model the store was built with, or run 'kash build'")
+		case len(vec) > configured:
+			d.ok("embedder", fmt.Sprintf("responded in %s, %d dimensions truncated to %d", took, len(vec), configured))
+		default:
+			d.ok("embedder", fmt.Sprintf("responded in %s, %d dimensions", took, len(vec)))
+		}
+	} else {
+		d.warn("embedder", "skipped, not configured", "")
+	}
+
+	reranker, err := llm.NewReranker(&cfg.Reranker)
+	switch {
+	case errors.Is(err, llm.ErrNoONNXRuntime):
+		d.fail("reranker", err.Error(), "build kash with CGO_ENABLED=1 go build -tags onnx ./cmd/kash, or use a rerank API")
+	case err != nil && cfg.Reranker.Local():
+		d.fail("reranker", err.Error(), "set reranker.model to a cross-encoder exported to ONNX, with ONNX Runtime installed (or ONNXRUNTIME_LIB pointing at it)")
+	case err != nil:
+		d.fail("reranker", err.Error(), endpointFix("reranker", "RERANK", err))
+	case reranker == nil:
+		d.ok("reranker", "not configured (optional)")
+	default:
+		took, err := probe(func(ctx context.Context) error {
+			_, err := reranker.Rerank(ctx, "ping", []string{"pong"})
+			return err
+		})
+		if err != nil {
+			d.fail("reranker", err.Error(), endpointFix("reranker", "RERANK", err))
+		} else {
+			d.ok("reranker", fmt.Sprintf("responded in %s", took))
+		}
+	}
+}
+
+// endpointFix suggests a remedy based on how a provider request failed.
+func endpointFix(section, envPrefix string, err error) string {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || strings.Contains(msg, "deadline exceeded") || strings.Contains(msg, "timeout"):
+		return fmt.Sprintf("the endpoint did not answer within %s; check %s.base_url and your network, or raise --timeout", doctorTimeout, section)
+	case strings.Contains(msg, "401") || strings.Contains(msg, "403") || strings.Contains(msg, "unauthorized") || strings.Contains(msg, "api key"):
+		return fmt.Sprintf("the API key was rejected; check %s.api_key or %s_API_KEY", section, envPrefix)
+	case strings.Contains(msg, "404") || strings.Contains(msg, "not found") || strings.Contains(msg, "does not exist"):
+		return fmt.Sprintf("check %s.base_url (usually ends in /v1) and %s.model", section, section)
+	case strings.Contains(msg, "connection refused") || strings.Contains(msg, "no such host"):
+		return fmt.Sprintf("nothing is listening at %s.base_url (%s_BASE_URL); check the URL or start the provider", section, envPrefix)
+	default:
+		return fmt.Sprintf("check the %s settings in ~/.kash/config.yaml or the %s_* environment variables", section, envPrefix)
+	}
+}
+
+func mustGetwd() string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "."
+	}
+	return wd
+}
@@ -0,0 +1,415 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/eval"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/retrieval"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+var (
+	evalDir       string
+	evalK         int
+	evalOutput    string
+	evalBaseline  string
+	evalMinRecall float64
+	evalVerbose   bool
+	evalStrategy  string
+
+	evalAnswers         bool
+	evalJudgeModel      string
+	evalMinFaithfulness float64
+	evalMinRelevance    float64
+	evalMinCitations    float64
+)
+
+var evalCmd = &cobra.Command{
+	Use:   "eval [questions.yaml]",
+	Short: "Measure retrieval quality against a question set",
+	Long: `Runs every question in eval.yaml (or the given file) against the compiled
+vector store and reports recall@k, hit rate, and MRR (mean reciprocal rank),
+listing the questions for which nothing relevant was retrieved.
+
+Each question names the sources and/or text snippets a good answer needs:
+
+  k: 5
+  questions:
+    - question: How long do refunds take?
+      sources: [billing-faq.md]
+      snippets: ["within 14 days"]
+
+Questions run through the agent's retrieval as agent.yaml configures it —
+query transforms, retrievers, filters, min_similarity, and strategy —
+without the reranker.
+Use --strategy to try another way of merging the ranked lists, e.g.
+--strategy rrf, without editing agent.yaml.
+
+Save a report with --output before changing chunking or retrieval settings,
+then pass it as --baseline after rebuilding to see what changed.
+
+With --answers, each question is also answered by the configured LLM from the
+retrieved passages and graded by an LLM judge for faithfulness to those
+passages, answer relevance, citation accuracy, and (when the question has an
+"answer:") correctness against the golden answer. Use the --min-* flags to
+fail CI when a metric regresses; an --output ending in .md writes Markdown.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEval,
+}
+
+func init() {
+	evalCmd.Flags().StringVarP(&evalDir, "dir", "d", ".", "Path to the agent project directory")
+	evalCmd.Flags().IntVarP(&evalK, "k", "k", 0, "Results retrieved per question (overrides the suite's k)")
+	evalCmd.Flags().StringVarP(&evalOutput, "output", "o", "", "Write the report as JSON to this file")
+	evalCmd.Flags().StringVar(&evalBaseline, "baseline", "", "Compare against a report saved with --output")
+	evalCmd.Flags().Float64Var(&evalMinRecall, "min-recall", 0, "Exit with an error when recall@k is below this value (0-1)")
+	evalCmd.Flags().BoolVarP(&evalVerbose, "verbose", "v", false, "Show every question, not just misses")
+	evalCmd.Flags().StringVar(&evalStrategy, "strategy", "", "Retrieval strategy: concat or rrf (default: agent.yaml's)")
+	evalCmd.Flags().BoolVar(&evalAnswers, "answers", false, "Also generate and LLM-judge an answer for every question")
+	evalCmd.Flags().StringVar(&evalJudgeModel, "judge-model", "", "Model for the judge (default: the LLM model)")
+	evalCmd.Flags().Float64Var(&evalMinFaithfulness, "min-faithfulness", 0, "Exit with an error when mean faithfulness is below this value (0-1)")
+	evalCmd.Flags().Float64Var(&evalMinRelevance, "min-relevance", 0, "Exit with an error when mean answer relevance is below this value (0-1)")
+	evalCmd.Flags().Float64Var(&evalMinCitations, "min-citations", 0, "Exit with an error when mean citation accuracy is below this value (0-1)")
+	rootCmd.AddCommand(evalCmd)
+}
+
+func runEval(_ *cobra.Command, args []string) error {
+	suitePath := eval.DefaultPath
+	if len(args) > 0 {
+		abs, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("resolve %q: %w", args[0], err)
+		}
+		suitePath = abs
+	}
+	var baseline *eval.Report
+	if evalBaseline != "" {
+		var err error
+		if baseline, err = eval.LoadReport(evalBaseline); err != nil {
+			return err
+		}
+	}
+	if evalOutput != "" {
+		abs, err := filepath.Abs(evalOutput)
+		if err != nil {
+			return fmt.Errorf("resolve %q: %w", evalOutput, err)
+		}
+		evalOutput = abs
+	}
+
+	if evalDir != "." {
+		abs, err := filepath.Abs(evalDir)
+		if err != nil {
+			return fmt.Errorf("resolve directory %q: %w", evalDir, err)
+		}
+		if err := os.Chdir(abs); err != nil {
+			return fmt.Errorf("change to directory %q: %w", abs, err)
+		}
+	}
+
+	suite, err := eval.LoadSuite(suitePath)
+	if err != nil {
+		return err
+	}
+	if evalK > 0 {
+		suite.K = evalK
+	}
+
+	cfg, err := agentconfig.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	agentconfig.ApplyAgentYAMLDimensions(cfg, "agent.yaml")
+	if err := agentconfig.ValidateEmbedder(cfg); err != nil {
+		return err
+	}
+	if _, err := os.Stat("data/memory.chromem"); err != nil {
+		return errors.New("vector store not found at data/memory.chromem — run 'kash build' first")
+	}
+	vs, err := vector.NewStoreFromPath("data/memory.chromem", &cfg.Embedder)
+	if err != nil {
+		return fmt.Errorf("open vector store: %w", err)
+	}
+	retriever, err := newEvalRetriever(vs)
+	if err != nil {
+		return err
+	}
+	defer retriever.graph.Close()
+
+	var opts eval.Options
+	if evalAnswers {
+		if err := agentconfig.ValidateLLM(cfg); err != nil {
+			return err
+		}
+		if opts, err = newEvalOptions(cfg); err != nil {
+			return err
+		}
+	}
+
+	display.Header("🧪 Kash Retrieval Eval")
+	display.Newline()
+	display.KeyValue("Questions", len(suite.Cases), display.BrightCyan)
+	display.KeyValue("k", suite.K, display.BrightCyan)
+	display.KeyValue("Strategy", retriever.opts.Strategy, display.BrightCyan)
+	if evalAnswers {
+		judgeModel := evalJudgeModel
+		if judgeModel == "" {
+			judgeModel = cfg.LLM.Model
+		}
+		display.KeyValue("Answer model", cfg.LLM.Model, display.BrightMagenta)
+		display.KeyValue("Judge model", judgeModel, display.BrightMagenta)
+	}
+	display.Newline()
+
+	report, err := eval.Run(context.Background(), retriever, suite, opts)
+	if err != nil {
+		return fmt.Errorf("run eval: %w", err)
+	}
+	report.Strategy = retriever.opts.Strategy
+
+	if jsonOutput {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	}
+	printEvalReport(report, baseline)
+
+	if evalOutput != "" {
+		if strings.EqualFold(filepath.Ext(evalOutput), ".md") {
+			err = os.WriteFile(evalOutput, []byte(report.Markdown()), 0o644)
+		} else {
+			err = report.Save(evalOutput)
+		}
+		if err != nil {
+			return fmt.Errorf("write eval report: %w", err)
+		}
+		display.FileCreated(evalOutput)
+	}
+	return checkEvalThresholds(report)
+}
+
+// evalRetriever runs eval questions through the agent's retrieval, so the
+// metrics reflect its query transforms, retrievers, filters, and strategy.
+// The reranker is left out to keep runs cheap and repeatable.
+type evalRetriever struct {
+	vectors *vector.Store
+	graph   *graph.DB
+	opts    retrieval.Options
+}
+
+// newEvalRetriever reads the retrieval block of agent.yaml, with --strategy
+// taking precedence, and opens the knowledge graph when it was built.
+func newEvalRetriever(vs *vector.Store) (*evalRetriever, error) {
+	hookCfg, err := retrieval.AgentYAMLHooks("agent.yaml")
+	if err != nil {
+		return nil, err
+	}
+	if evalStrategy != "" {
+		if !retrieval.ValidStrategy(evalStrategy) {
+			return nil, fmt.Errorf("--strategy must be %s or %s", retrieval.StrategyConcat, retrieval.StrategyRRF)
+		}
+		hookCfg.Strategy = evalStrategy
+	}
+	hooks, err := hookCfg.Hooks()
+	if err != nil {
+		return nil, fmt.Errorf("agent.yaml retrieval: %w", err)
+	}
+	if hookCfg.Strategy == "" {
+		hookCfg.Strategy = retrieval.StrategyConcat
+	}
+
+	var gdb *graph.DB
+	if _, statErr := os.Stat("data/knowledge.cayley"); statErr == nil {
+		gdb, err = graph.OpenSnapshot("data/knowledge.cayley")
+	} else {
+		gdb, err = graph.NewDB()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open graph store: %w", err)
+	}
+	gdb.SetSynonyms(hookCfg.Synonyms)
+	return &evalRetriever{vectors: vs, graph: gdb, opts: retrieval.Options{
+		Fusion:        hookCfg.Fusion,
+		Strategy:      hookCfg.Strategy,
+		RRFK:          hookCfg.RRFK,
+		Hooks:         hooks,
+		MinSimilarity: hookCfg.MinSimilarity,
+		Window:        hookCfg.Window,
+		Guard:         hookCfg.Guard,
+		Language:      hookCfg.Language,
+	}}, nil
+}
+
+// QueryFiltered implements eval.Retriever, returning at most topK chunks.
+func (r *evalRetriever) QueryFiltered(ctx context.Context, query string, topK int, filter map[string]string) ([]vector.SearchResult, error) {
+	opts := r.opts
+	opts.TopK, opts.Filter = topK, filter
+	res, err := retrieval.Search(ctx, r.vectors, r.graph, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Chunks) > topK {
+		return res.Chunks[:topK], nil
+	}
+	return res.Chunks, nil
+}
+
+// newEvalOptions builds the answer generator and judge from the LLM config.
+func newEvalOptions(cfg *agentconfig.Config) (eval.Options, error) {
+	gen, err := llm.NewClient(&cfg.LLM)
+	if err != nil {
+		return eval.Options{}, fmt.Errorf("create LLM client: %w", err)
+	}
+	opts := eval.Options{
+		Generator:    gen,
+		SystemPrompt: agentconfig.AgentYAMLSystemPrompt("agent.yaml"),
+	}
+	if evalJudgeModel != "" && evalJudgeModel != cfg.LLM.Model {
+		judgeCfg := cfg.LLM
+		judgeCfg.Model = evalJudgeModel
+		if opts.Judge, err = llm.NewClient(&judgeCfg); err != nil {
+			return eval.Options{}, fmt.Errorf("create judge client: %w", err)
+		}
+	}
+	return opts, nil
+}
+
+// checkEvalThresholds fails the command when a metric is below its --min-*
+// flag, so eval can gate CI on knowledge updates.
+func checkEvalThresholds(report *eval.Report) error {
+	var failed []string
+	check := func(name string, value, threshold float64) {
+		if threshold > 0 && value < threshold {
+			failed = append(failed, fmt.Sprintf("%s %.3f < %.3f", name, value, threshold))
+		}
+	}
+	check(fmt.Sprintf("recall@%d", report.K), report.RecallAtK, evalMinRecall)
+	if a := report.Answers; a != nil {
+		check("faithfulness", a.Faithfulness, evalMinFaithfulness)
+		check("relevance", a.Relevance, evalMinRelevance)
+		check("citation accuracy", a.CitationAccuracy, evalMinCitations)
+	} else if evalMinFaithfulness > 0 || evalMinRelevance > 0 || evalMinCitations > 0 {
+		return errors.New("--min-faithfulness, --min-relevance, and --min-citations require --answers")
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("eval below threshold: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func printEvalReport(report, baseline *eval.Report) {
+	if evalVerbose {
+		for _, c := range report.Cases {
+			rank := "miss"
+			if c.Rank > 0 {
+				rank = fmt.Sprintf("rank %d", c.Rank)
+			}
+			line := fmt.Sprintf("%-8s recall %.2f  %s", rank, c.Recall, c.Question)
+			if sc := c.Scores; sc != nil {
+				line += fmt.Sprintf("  [faithful %.2f, relevant %.2f, citations %.2f]", sc.Faithfulness, sc.Relevance, sc.CitationAccuracy)
+			}
+			display.StepDetail(line)
+		}
+		display.Newline()
+	}
+
+	misses := report.Misses()
+	if len(misses) > 0 {
+		display.SubHeader(fmt.Sprintf("Nothing relevant retrieved (%d)", len(misses)))
+		for _, c := range misses {
+			display.StepWarn(c.Question)
+			if c.Error != "" {
+				display.StepDetail("error: " + c.Error)
+				continue
+			}
+			display.StepDetail("expected: " + strings.Join(c.Missing, ", "))
+			if len(c.Retrieved) > 0 {
+				display.StepDetail("retrieved: " + strings.Join(c.Retrieved, ", "))
+			}
+		}
+		display.Newline()
+	}
+
+	for _, c := range report.Cases {
+		if c.Rank > 0 && c.Error != "" {
+			display.StepWarn(fmt.Sprintf("%s: %s", c.Question, c.Error))
+		}
+	}
+
+	metric := func(label string, value float64, base func(*eval.Report) float64) {
+		text := fmt.Sprintf("%.3f", value)
+		if baseline != nil {
+			text += fmt.Sprintf("  (%+.3f vs baseline)", value-base(baseline))
+		}
+		display.KeyValue(label, text, display.Bold+display.BrightGreen)
+	}
+	if baseline != nil && baseline.Strategy != "" && baseline.Strategy != report.Strategy {
+		display.KeyValue("Baseline strategy", baseline.Strategy, display.BrightCyan)
+	}
+	metric(fmt.Sprintf("Recall@%d", report.K), report.RecallAtK, func(r *eval.Report) float64 { return r.RecallAtK })
+	metric(fmt.Sprintf("Hit rate@%d", report.K), report.HitRate, func(r *eval.Report) float64 { return r.HitRate })
+	metric("MRR", report.MRR, func(r *eval.Report) float64 { return r.MRR })
+	if a := report.Answers; a != nil {
+		answerMetric := func(label string, value float64, base func(*eval.AnswerSummary) float64) {
+			metric(label, value, func(r *eval.Report) float64 {
+				if r.Answers == nil {
+					return value
+				}
+				return base(r.Answers)
+			})
+		}
+		answerMetric("Faithfulness", a.Faithfulness, func(s *eval.AnswerSummary) float64 { return s.Faithfulness })
+		answerMetric("Answer relevance", a.Relevance, func(s *eval.AnswerSummary) float64 { return s.Relevance })
+		answerMetric("Citation accuracy", a.CitationAccuracy, func(s *eval.AnswerSummary) float64 { return s.CitationAccuracy })
+		if a.Correctness != nil {
+			display.KeyValue("Correctness", fmt.Sprintf("%.3f", *a.Correctness), display.Bold+display.BrightGreen)
+		}
+	}
+
+	if baseline != nil {
+		printEvalChanges(report, baseline)
+	}
+}
+
+// printEvalChanges lists questions whose first relevant rank moved since the
+// baseline run.
+func printEvalChanges(report, baseline *eval.Report) {
+	before := make(map[string]int, len(baseline.Cases))
+	for _, c := range baseline.Cases {
+		before[c.Question] = c.Rank
+	}
+	describe := func(rank int) string {
+		if rank == 0 {
+			return "miss"
+		}
+		return fmt.Sprintf("rank %d", rank)
+	}
+
+	var changes []string
+	for _, c := range report.Cases {
+		old, ok := before[c.Question]
+		if !ok || old == c.Rank {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s → %s  %s", describe(old), describe(c.Rank), c.Question))
+	}
+	if len(changes) == 0 {
+		return
+	}
+	display.Newline()
+	display.SubHeader(fmt.Sprintf("Changed since baseline (%d)", len(changes)))
+	for _, line := range changes {
+		display.StepDetail(line)
+	}
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/pkg/kash"
+)
+
+var (
+	graphDir       string
+	graphSubject   string
+	graphPredicate string
+	graphObject    string
+	graphDryRun    bool
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Correct the triples in the compiled knowledge graph",
+	Long: `Adds and removes triples in data/knowledge.cayley without a rebuild, so a
+wrong extraction can be corrected in place. 'kash inspect triples' finds
+them first. 'kash graph apply' loads the triples approved in the review file
+of 'kash build --review'.
+
+A rebuild adds to the graph, so added triples stay, but it extracts every
+chunk again and a removed triple can come back. Put facts that must be
+rebuilt with the agent in a data/*.triples.csv file.
+
+No provider configuration is needed. The graph store allows a single
+writer, so wait for a running 'kash build' to finish. Send SIGHUP to a
+running 'kash serve' afterwards, or run it with --watch.`,
+}
+
+var graphAddCmd = &cobra.Command{
+	Use:     "add <subject> <predicate> <object>",
+	Short:   "Add a triple to the knowledge graph",
+	Example: `  kash graph add "Acme Cloud" "refund window" "30 days"`,
+	Args:    cobra.ExactArgs(3),
+	RunE:    runGraphAdd,
+}
+
+var graphApplyCmd = &cobra.Command{
+	Use:   "apply [review-file]",
+	Short: "Add the approved triples of a review file to the knowledge graph",
+	Long: `Adds the triples marked approved: true in a review file written by
+'kash build --review' (default ` + graph.DefaultReviewFile + `) to the
+knowledge graph. Unapproved triples are left out and counted. Applying a
+file twice adds nothing new.`,
+	Example: `  kash build --review
+  kash graph apply
+  kash graph apply reviewed.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGraphApply,
+}
+
+var graphRemoveCmd = &cobra.Command{
+	Use:   "remove [<subject> <predicate> <object>]",
+	Short: "Remove triples from the knowledge graph",
+	Long: `Removes one triple, given exactly, or every triple matching the --subject,
+--predicate, and --object patterns. A pattern matches a field that contains
+its text, ignoring case, as in 'kash inspect triples'. Use --dry-run to list
+the matching triples first; 'kash rollback' restores the graph of the last
+build.`,
+	Example: `  kash graph remove "Acme Cloud" "refund window" "40 days"
+  kash graph remove --predicate "mentioned in" --dry-run
+  kash graph remove --subject "lorem ipsum"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		switch {
+		case len(args) == 3:
+			if graphSubject != "" || graphPredicate != "" || graphObject != "" {
+				return errors.New("give either a triple or --subject, --predicate, and --object patterns, not both")
+			}
+		case len(args) > 0:
+			return errors.New("give a subject, predicate, and object")
+		case graphSubject == "" && graphPredicate == "" && graphObject == "":
+			return errors.New("give a triple or at least one of --subject, --predicate, and --object")
+		}
+		return nil
+	},
+	RunE: runGraphRemove,
+}
+
+func init() {
+	graphCmd.PersistentFlags().StringVarP(&graphDir, "dir", "d", ".", "Path to the agent project directory")
+	graphRemoveCmd.Flags().StringVar(&graphSubject, "subject", "", "Remove triples whose subject contains this text")
+	graphRemoveCmd.Flags().StringVar(&graphPredicate, "predicate", "", "Remove triples whose predicate contains this text")
+	graphRemoveCmd.Flags().StringVar(&graphObject, "object", "", "Remove triples whose object contains this text")
+	graphRemoveCmd.Flags().BoolVar(&graphDryRun, "dry-run", false, "List the triples that would be removed without removing them")
+	graphCmd.AddCommand(graphAddCmd, graphRemoveCmd, graphApplyCmd)
+	rootCmd.AddCommand(graphCmd)
+}
+
+// graphEdit is the --json output of the 'kash graph' commands.
+type graphEdit struct {
+	Added   []graph.Triple `json:"added,omitempty"`
+	Removed []graph.Triple `json:"removed,omitempty"`
+	DryRun  bool           `json:"dry_run,omitempty"`
+	// Unapproved counts the triples of a review file left out by apply
+	Unapproved int   `json:"unapproved,omitempty"`
+	Triples    int64 `json:"triples"`
+}
+
+// openProjectGraph opens the graph store of the project in graphDir for
+// writing.
+func openProjectGraph() (*graph.DB, error) {
+	if err := chdirProject(graphDir); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(kash.GraphDir); err != nil {
+		return nil, missingGraph()
+	}
+	gdb, err := graph.NewDBFromPath(kash.GraphDir)
+	if err != nil {
+		return nil, fmt.Errorf("open graph db: %w", err)
+	}
+	return gdb, nil
+}
+
+// missingGraph explains why the project has no graph store.
+func missingGraph() error {
+	if m, err := manifest.Load(manifest.DefaultPath); err == nil && m.NoGraph {
+		return errors.New("the last build skipped the knowledge graph — run 'kash build' without --skip-graph or extraction.skip_graph first")
+	}
+	return fmt.Errorf("%s not found — run 'kash build' first", kash.GraphDir)
+}
+
+func runGraphAdd(_ *cobra.Command, args []string) error {
+	t := graph.Triple{Subject: args[0], Predicate: args[1], Object: args[2]}
+	for _, field := range args {
+		if strings.TrimSpace(field) == "" {
+			return errors.New("subject, predicate, and object must not be empty")
+		}
+	}
+	gdb, err := openProjectGraph()
+	if err != nil {
+		return err
+	}
+	defer gdb.Close()
+
+	if err := gdb.AddTriples(context.Background(), []graph.Triple{t}); err != nil {
+		return err
+	}
+	return finishGraphEdit(gdb, graphEdit{Added: []graph.Triple{t}})
+}
+
+func runGraphApply(_ *cobra.Command, args []string) error {
+	// A review file given is found from the working directory, the default
+	// one in the project
+	path := graph.DefaultReviewFile
+	if len(args) > 0 {
+		abs, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("resolve review file %q: %w", args[0], err)
+		}
+		path = abs
+	}
+	gdb, err := openProjectGraph()
+	if err != nil {
+		return err
+	}
+	defer gdb.Close()
+
+	review, err := graph.LoadReview(path)
+	if err != nil {
+		return err
+	}
+	approved, unapproved := review.Approved()
+
+	if err := gdb.AddTriples(context.Background(), approved); err != nil {
+		return err
+	}
+	return finishGraphEdit(gdb, graphEdit{Added: approved, Unapproved: unapproved})
+}
+
+func runGraphRemove(_ *cobra.Command, args []string) error {
+	gdb, err := openProjectGraph()
+	if err != nil {
+		return err
+	}
+	defer gdb.Close()
+
+	ctx := context.Background()
+	var remove []graph.Triple
+	if len(args) == 3 {
+		remove = []graph.Triple{{Subject: args[0], Predicate: args[1], Object: args[2]}}
+	} else {
+		all, err := gdb.Triples(ctx)
+		if err != nil {
+			return err
+		}
+		for _, t := range all {
+			if graphSubject != "" && !containsFold(t.Subject, graphSubject) ||
+				graphPredicate != "" && !containsFold(t.Predicate, graphPredicate) ||
+				graphObject != "" && !containsFold(t.Object, graphObject) {
+				continue
+			}
+			remove = append(remove, t)
+		}
+	}
+
+	if graphDryRun {
+		return finishGraphEdit(gdb, graphEdit{Removed: remove, DryRun: true})
+	}
+	n, err := gdb.RemoveTriples(ctx, remove)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		if len(args) == 3 {
+			return fmt.Errorf("the graph has no triple (%s, %s, %s)", args[0], args[1], args[2])
+		}
+		remove = nil
+	}
+	return finishGraphEdit(gdb, graphEdit{Removed: remove})
+}
+
+// finishGraphEdit records the new triple count in the manifest and reports
+// the edit.
+func finishGraphEdit(gdb *graph.DB, edit graphEdit) error {
+	edit.Triples = gdb.Count()
+	if !edit.DryRun {
+		m, err := manifest.Load(manifest.DefaultPath)
+		switch {
+		case err == nil:
+			m.Triples = edit.Triples
+			if err := m.Save(manifest.DefaultPath); err != nil {
+				return err
+			}
+		case !errors.Is(err, manifest.ErrNotFound):
+			return err
+		}
+	}
+	if jsonOutput {
+		return printJSON(edit)
+	}
+
+	// A review file can hold thousands of triples; list only a few edits
+	const listed = 20
+	list := len(edit.Added)+len(edit.Removed) <= listed
+	for _, t := range edit.Added {
+		if list {
+			display.Success(fmt.Sprintf("Added (%s, %s, %s)", t.Subject, t.Predicate, t.Object))
+		}
+	}
+	removed := "Removed"
+	if edit.DryRun {
+		removed = "Would remove"
+	}
+	for _, t := range edit.Removed {
+		if list || edit.DryRun {
+			display.Info(fmt.Sprintf("%s (%s, %s, %s)", removed, t.Subject, t.Predicate, t.Object))
+		}
+	}
+	if edit.Unapproved > 0 {
+		display.Info(fmt.Sprintf("Left out %d unapproved triple(s)", edit.Unapproved))
+	}
+	if edit.Added == nil && edit.Removed == nil {
+		if edit.Unapproved == 0 {
+			display.Info("No triples match")
+		}
+		return nil
+	}
+	if edit.DryRun {
+		display.KeyValue(removed, len(edit.Removed), display.BrightCyan)
+		return nil
+	}
+	if !list {
+		if edit.Added != nil {
+			display.KeyValue("Added", len(edit.Added), display.BrightCyan)
+		}
+		if edit.Removed != nil {
+			display.KeyValue(removed, len(edit.Removed), display.BrightCyan)
+		}
+	}
+	display.KeyValue("Triples", edit.Triples, display.BrightCyan)
+	display.NextSteps([]string{"Send SIGHUP to a running 'kash serve' to load the edited graph (or run it with --watch)"})
+	return nil
+}
@@ -1,15 +1,25 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	agentconfig "github.com/akashicode/kash/internal/config"
 	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/server"
 )
 
 var initCmd = &cobra.Command{
@@ -23,20 +33,76 @@ var initCmd = &cobra.Command{
   - .dockerignore  Excludes raw data files from the final image
 
 Also generates ~/.kash/config.yaml (if it doesn't already exist)
-with an empty skeleton for LLM, embedder, and reranker settings.`,
+with an empty skeleton for LLM, embedder, and reranker settings.
+
+--template tailors the project to a use case with a tuned system prompt,
+chunking and retrieval settings, MCP tool name, and sample data:
+  support-bot      Customer support from a help center and FAQs
+  docs-agent       Questions about product documentation
+  code-assistant   A codebase, its architecture, and its conventions
+
+On a terminal, init asks for the description, port, and whether to add sample
+data; flags answer those questions in advance. --non-interactive never asks,
+for scripts and CI. --smoke-test builds the sample data right away and asks
+the agent a canned question, so a working agent is one command away:
+
+  kash init my-agent --with-sample-data --smoke-test --non-interactive`,
 	Args: cobra.ExactArgs(1),
 	RunE: runInit,
 }
 
+var (
+	initTemplateName   string
+	initDescription    string
+	initPort           int
+	initSampleData     bool
+	initNonInteractive bool
+	initSmokeTest      bool
+)
+
+func init() {
+	f := initCmd.Flags()
+	f.StringVarP(&initTemplateName, "template", "t", "", "Project template: "+strings.Join(templateNames(), ", "))
+	f.StringVar(&initDescription, "description", "", "Agent description (default: the template's)")
+	f.IntVar(&initPort, "port", 8000, "Port the agent serves on")
+	f.BoolVar(&initSampleData, "with-sample-data", false, "Add a sample document to data/ (templates always do)")
+	f.BoolVar(&initNonInteractive, "non-interactive", false, "Never prompt; use flags and defaults")
+	f.BoolVar(&initSmokeTest, "smoke-test", false, "Build the sample data and ask the agent a test question (implies --with-sample-data)")
+}
+
 func runInit(cmd *cobra.Command, args []string) error {
 	name := args[0]
 	projectDir := filepath.Join(".", name)
 
+	tmpl, err := lookupTemplate(initTemplateName)
+	if err != nil {
+		return err
+	}
+
 	// Check if directory already exists
 	if _, err := os.Stat(projectDir); err == nil {
 		return fmt.Errorf("directory %q already exists", name)
 	}
 
+	if initInteractive() {
+		if err := promptInit(cmd, tmpl); err != nil {
+			return err
+		}
+	}
+	if initDescription != "" {
+		tmpl.Description = initDescription
+	}
+	if initPort <= 0 || initPort > 65535 {
+		return fmt.Errorf("--port must be between 1 and 65535, got %d", initPort)
+	}
+	if initSmokeTest {
+		initSampleData = true
+	}
+	if initSampleData && len(tmpl.SampleData) == 0 {
+		tmpl.SampleData = genericSampleData
+		tmpl.SampleQuery = genericSampleQuery
+	}
+
 	// Ensure ~/.kash/config.yaml exists
 	created, err := agentconfig.EnsureConfigFile()
 	if err != nil {
@@ -44,7 +110,11 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	display.Header("⚡ Initializing Agent Project: " + name)
-	fmt.Println()
+	display.Newline()
+	if initTemplateName != "" {
+		display.KeyValue("Template", initTemplateName, display.BrightCyan)
+		display.Newline()
+	}
 
 	// Create directory structure
 	dirs := []string{
@@ -58,14 +128,22 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	// Write agent.yaml
-	agentYAML := generateAgentYAML(name)
+	agentYAML := generateAgentYAML(name, tmpl, initPort)
 	if err := writeFile(filepath.Join(projectDir, "agent.yaml"), agentYAML); err != nil {
 		return fmt.Errorf("write agent.yaml: %w", err)
 	}
 	display.FileCreated("agent.yaml")
 
+	// Write sample documents
+	for _, f := range tmpl.SampleData {
+		if err := writeFile(filepath.Join(projectDir, "data", f.Name), f.Content); err != nil {
+			return fmt.Errorf("write sample data %q: %w", f.Name, err)
+		}
+		display.FileCreated("data/" + f.Name)
+	}
+
 	// Write Dockerfile
-	if err := writeFile(filepath.Join(projectDir, "Dockerfile"), generateDockerfile()); err != nil {
+	if err := writeFile(filepath.Join(projectDir, "Dockerfile"), generateDockerfile(initPort)); err != nil {
 		return fmt.Errorf("write Dockerfile: %w", err)
 	}
 	display.FileCreated("Dockerfile")
@@ -83,37 +161,55 @@ func runInit(cmd *cobra.Command, args []string) error {
 	display.FileCreated(".dockerignore")
 
 	// Write README
-	if err := writeFile(filepath.Join(projectDir, "README.md"), generateReadme(name)); err != nil {
+	if err := writeFile(filepath.Join(projectDir, "README.md"), generateReadme(name, initPort)); err != nil {
 		return fmt.Errorf("write README.md: %w", err)
 	}
 	display.FileCreated("README.md")
 
 	// Write docker-compose.yml
-	if err := writeFile(filepath.Join(projectDir, "docker-compose.yml"), generateDockerCompose(name)); err != nil {
+	if err := writeFile(filepath.Join(projectDir, "docker-compose.yml"), generateDockerCompose(name, initPort)); err != nil {
 		return fmt.Errorf("write docker-compose.yml: %w", err)
 	}
 	display.FileCreated("docker-compose.yml")
 
-	fmt.Println()
+	display.Newline()
 	display.Success("Project created successfully!")
-	fmt.Println()
+	display.Newline()
 
 	// Warn about empty config
 	cfgPath, _ := agentconfig.ConfigFilePath()
 	if created {
 		display.Info(fmt.Sprintf("Config file created: %s", cfgPath))
 		display.Warn("Please fill in your LLM and embedder API keys before running 'kash build'.")
-		fmt.Println()
+		display.Newline()
 	} else if !agentconfig.IsConfigured() {
 		display.Warn(fmt.Sprintf("Config file is empty: %s", cfgPath))
 		display.Warn("Fill in your LLM and embedder API keys, or 'kash build' will fail.")
-		fmt.Println()
+		display.Newline()
 	}
 
+	if initSmokeTest {
+		if err := runSmokeTest(cmd, projectDir, tmpl.SampleQuery); err != nil {
+			return fmt.Errorf("smoke test: %w", err)
+		}
+		display.NextSteps([]string{
+			fmt.Sprintf("cd %s", name),
+			"Replace the sample documents in data/ with your own",
+			"Run: kash build",
+			"Copy .env.example to .env (for Docker runtime keys)",
+			"Run: docker compose up --build",
+		})
+		return nil
+	}
+
+	addDocs := "Add documents to the data/ directory"
+	if len(tmpl.SampleData) > 0 {
+		addDocs = "Replace the sample documents in data/ with your own"
+	}
 	display.NextSteps([]string{
 		fmt.Sprintf("cd %s", name),
 		fmt.Sprintf("Edit %s with your API keys", cfgPath),
-		"Add documents to the data/ directory",
+		addDocs,
 		"Edit agent.yaml to configure your agent's persona",
 		"Run: kash build",
 		"Copy .env.example to .env (for Docker runtime keys)",
@@ -127,22 +223,170 @@ func writeFile(path, content string) error {
 	return os.WriteFile(path, []byte(content), 0644)
 }
 
-func generateAgentYAML(name string) string {
+// initInteractive reports whether init may prompt: stdin is a terminal and
+// neither --non-interactive, --json, nor --quiet was given.
+func initInteractive() bool {
+	return !initNonInteractive && !jsonOutput && !quiet && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// promptInit asks for the settings not given as flags.
+func promptInit(cmd *cobra.Command, tmpl initTemplate) error {
+	in := bufio.NewReader(cmd.InOrStdin())
+	flags := cmd.Flags()
+
+	if !flags.Changed("description") {
+		answer, err := prompt(in, "Description", tmpl.Description)
+		if err != nil {
+			return err
+		}
+		initDescription = answer
+	}
+	if !flags.Changed("port") {
+		answer, err := prompt(in, "Port", strconv.Itoa(initPort))
+		if err != nil {
+			return err
+		}
+		port, err := strconv.Atoi(answer)
+		if err != nil {
+			return fmt.Errorf("port must be a number, got %q", answer)
+		}
+		initPort = port
+	}
+	if len(tmpl.SampleData) == 0 && !flags.Changed("with-sample-data") && !flags.Changed("smoke-test") {
+		answer, err := prompt(in, "Add sample data? [y/N]", "n")
+		if err != nil {
+			return err
+		}
+		initSampleData = isYes(answer)
+	}
+	if !flags.Changed("smoke-test") && agentconfig.IsConfigured() {
+		answer, err := prompt(in, "Build and run a smoke test now? [y/N]", "n")
+		if err != nil {
+			return err
+		}
+		initSmokeTest = isYes(answer)
+	}
+	display.Newline()
+	return nil
+}
+
+// prompt prints question with its default and returns the trimmed answer, or
+// def when the answer is empty.
+func prompt(in *bufio.Reader, question, def string) (string, error) {
+	fmt.Fprintf(display.Output(), "  %s (%s): ", question, def)
+	line, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("read answer: %w", err)
+	}
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return def, nil
+}
+
+func isYes(answer string) bool {
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// runSmokeTest builds the new project and asks the agent query through the
+// runtime handler in-process, failing unless the agent answers.
+func runSmokeTest(cmd *cobra.Command, projectDir, query string) error {
+	display.Header("🧪 Smoke Test")
+	display.Newline()
+
+	// runBuild changes into the project directory
+	buildDir = projectDir
+	if err := runBuild(cmd, nil); err != nil {
+		return fmt.Errorf("build: %w", err)
+	}
+
+	cfg, err := agentconfig.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	srv, err := server.New(server.Config{
+		VectorStorePath: "data/memory.chromem",
+		GraphDBPath:     "data/knowledge.cayley",
+		AgentYAMLPath:   "agent.yaml",
+		AppCfg:          cfg,
+		Quiet:           true,
+	})
+	if err != nil {
+		return fmt.Errorf("initialize server: %w", err)
+	}
+	defer srv.Close()
+
+	display.Newline()
+	display.KeyValue("Question", query, display.BrightCyan)
+	answer, err := askAgent(srv.Handler(), query)
+	if err != nil {
+		return err
+	}
+	if len(answer) > 300 {
+		answer = answer[:300] + "..."
+	}
+	display.KeyValue("Answer", strings.Join(strings.Fields(answer), " "), display.BrightGreen)
+	display.Newline()
+	display.Success("Smoke test passed: the agent answered from its knowledge base")
+	return nil
+}
+
+// askAgent posts a chat completion to h and returns the answer text.
+func askAgent(h http.Handler, query string) (string, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"messages": []map[string]string{{"role": "user", "content": query}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key := os.Getenv("AGENT_API_KEY"); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		return "", fmt.Errorf("chat completion returned %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("parse chat completion: %w", err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", errors.New("the agent returned an empty answer")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func generateAgentYAML(name string, tmpl initTemplate, port int) string {
 	slug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
+	command := name
+	if initTemplateName != "" {
+		command += " --template " + initTemplateName
+	}
+	if port != 8000 {
+		command += " --port " + strconv.Itoa(port)
+	}
 	return fmt.Sprintf(`# Kash Agent Configuration
 # Generated by: kash init %s
 
 agent:
   name: "%s"
   version: "1.0.0"
-  description: "An expert AI agent powered by Kash"
+  description: %q
 
   # System prompt injected at runtime for all interfaces
   system_prompt: |
-    You are %s, a highly knowledgeable expert assistant.
-    You have access to a specialized knowledge base compiled from expert documents.
-    Always ground your responses in the retrieved context.
-    Be precise, helpful, and cite your knowledge when possible.
+%s
 
 # Runtime settings
 # Model names are resolved from env vars / config.yaml (not here).
@@ -153,31 +397,100 @@ runtime:
     # max_tokens: 8192  # optional: max token limit for the embedding model
                         # if set, chunk sizes are auto-tuned to stay within this limit
                         # check your model docs (e.g. voyage-3: 32000, text-embedding-3-small: 8191)
-    # parallel: true    # optional: enable parallel embedding requests (for local embedders)
-                        # default: false (sequential with retry, safe for hosted APIs)
+    # concurrency: 8    # optional: most embedding requests at once (default: 32)
+                        # the build ramps up to it while the embedder keeps up and
+                        # backs off on rate limits (429) or rising latency
+
+%s
+%s
+# Ingestion settings for structured formats (optional)
+# ingest:
+#   csv:                    # applies to .csv and .tsv files
+#     rows_per_chunk: 1     # rows grouped into each chunk
+#     id_column: "sku"      # column that identifies a row
+#     emit_triples: false   # add (id, column, value) facts to the graph directly
+#   json:                   # applies to .json and .jsonl files
+#     records_path: "data"  # dotted path to the records array (default: root)
+#     fields: []            # dotted paths to keep (default: all)
+#     exclude: []           # dotted paths to drop
+#     id_field: "id"        # path that identifies a record
+#     records_per_chunk: 1
+
+# Remote sources fetched by 'kash build' (optional)
+# URLs can also be listed one per line in data/urls.txt.
+# sources:
+#   urls:
+#     - "https://example.com/docs/getting-started"
+#   crawl:
+#     - start_url: "https://docs.example.com/"
+#       max_depth: 2          # link hops from start_url
+#       max_pages: 100
+#       include: ["/docs/"]   # regex; only matching URLs are ingested
+#       exclude: ["/blog/"]
+#       sitemap: true         # seed from sitemap.xml (robots.txt is always honored)
+#   git:
+#     - url: "https://github.com/example/project.git"
+#       ref: "v1.2.0"         # branch, tag, or commit (default: remote HEAD)
+#       paths: ["docs/**/*.md", "README.md"]
+#       exclude: ["docs/archive/**"]
+#   drive:                    # needs google.credentials_file in ~/.kash/config.yaml
+#     - folder_id: "1AbCdEfGhIjKlMnOp"
+#       recursive: true
+#   storage:                  # credentials from ~/.kash/config.yaml or AWS_* / AZURE_STORAGE_* env
+#     - url: "s3://my-bucket/docs/"   # also gs://bucket/prefix and az://container/prefix
+#       region: "us-east-1"
+#       exclude: ["drafts/**"]
+#   youtube:                  # ingests captions with timestamp metadata
+#     videos: ["https://www.youtube.com/watch?v=VIDEO_ID"]
+#     channels: ["https://www.youtube.com/@handle"]   # latest uploads
+#     language: "en"
+#     segment_seconds: 60
+
+# Peer agents consulted on every query (optional)
+# Their answers are added to this agent's context. Peers can be other Kash
+# agents or any A2A-compliant agent (protocol: "a2a").
+# peers:
+#   - name: "billing"
+#     url: "http://billing-agent:8000"
+#     description: "Invoices, refunds, and payment methods"
+#     mode: "query"             # "query" (peer answers) or "search" (raw chunks)
+#     api_key_env: "BILLING_AGENT_KEY"   # env var holding the peer's AGENT_API_KEY
+#     timeout: "20s"
+
+# Query audit log (optional): one JSON line per query with the time, API key
+# id, query, retrieved sources, and response length. AUDIT_LOG_PATH overrides path.
+# audit:
+#   path: "logs/audit.jsonl"
+#   queries: "full"           # "full", "hash" (SHA-256 only), or "none"
+#   responses: false          # also log the response text
+#   max_size_mb: 100          # rotate to audit.jsonl.1, .2, ... past this size
+#   max_backups: 5
 
 # MCP tool definitions (auto-populated by 'kash build')
 mcp:
   tools:
-    - name: "search_%s_knowledge"
-      description: "Search the expert knowledge base for relevant information. Use this tool when the user asks about topics covered in the agent's domain."
+    - name: "search_%s_%s"
+      description: "%s"
       # NOTE: This description will be auto-optimized by 'kash build'
 
 # Server configuration
 server:
-  port: 8000
+  port: %d
   cors_origins:
     - "*"
-`, name, name, name, slug)
+  # usage_window: "24h"     # how far back GET /v1/usage reports
+  # deep_health: true       # /readyz also probes the LLM and embedder (cached 30s)
+`, command, name, tmpl.Description, indent(fmt.Sprintf(tmpl.SystemPrompt, name), "    "),
+		tmpl.chunkingBlock(), tmpl.retrievalBlock(), slug, tmpl.ToolSuffix, tmpl.ToolDescription, port)
 }
 
-func generateDockerfile() string {
-	return `# Kash Runtime Dockerfile
+func generateDockerfile(port int) string {
+	return fmt.Sprintf(`# Kash Runtime Dockerfile
 # Packages compiled databases with the kash binary (~50MB)
 #
 # Uses the multi-arch base image (amd64 + arm64) published to GHCR.
 # At runtime it executes "kash serve" which starts the HTTP server
-# exposing REST, MCP, and A2A interfaces on port 8000.
+# exposing REST, MCP, and A2A interfaces on port %[1]d.
 #
 # Build for current architecture:
 #   docker build -t my-agent:latest .
@@ -189,9 +502,13 @@ FROM ghcr.io/akashicode/kash:latest
 
 WORKDIR /app
 
-# Copy the compiled database artifacts from 'kash build'
+# Copy the compiled database artifacts from 'kash build' (a build with
+# --skip-graph has no knowledge.cayley, and one with ingest.skip_documents no
+# documents.store; remove their lines)
 COPY data/memory.chromem/ /app/data/memory.chromem/
 COPY data/knowledge.cayley/ /app/data/knowledge.cayley/
+COPY data/documents.store/ /app/data/documents.store/
+COPY data/manifest.json /app/data/manifest.json
 
 # Copy the agent configuration
 COPY agent.yaml /app/agent.yaml
@@ -209,17 +526,21 @@ ENV EMBED_DIMENSIONS="1024"
 ENV RERANK_BASE_URL=""
 ENV RERANK_API_KEY=""
 ENV RERANK_MODEL=""
+# Structured logs for log collectors; LOG_LEVEL=debug shows each search step
+ENV LOG_FORMAT="json"
+ENV LOG_LEVEL="info"
+ENV PORT="%[1]d"
 
-EXPOSE 8000
+EXPOSE %[1]d
 
 HEALTHCHECK --interval=30s --timeout=3s --start-period=5s \
-  CMD wget -qO- http://localhost:8000/health || exit 1
+  CMD wget -qO- http://localhost:%[1]d/readyz || exit 1
 
 ENTRYPOINT ["/app/kash", "serve"]
-`
+`, port)
 }
 
-func generateDockerCompose(name string) string {
+func generateDockerCompose(name string, port int) string {
 	slug := strings.ToLower(strings.ReplaceAll(name, " ", "-"))
 	return fmt.Sprintf(`# docker-compose.yml
 # Builds and runs the %s agent locally.
@@ -234,17 +555,17 @@ services:
     build: .
     image: %s:latest
     ports:
-      - "8000:8000"
+      - "%[3]d:%[3]d"
     env_file:
       - .env
     healthcheck:
-      test: ["CMD", "wget", "-qO-", "http://localhost:8000/health"]
+      test: ["CMD", "wget", "-qO-", "http://localhost:%[3]d/readyz"]
       interval: 30s
       timeout: 3s
       retries: 3
       start_period: 5s
     restart: unless-stopped
-`, name, slug)
+`, name, slug, port)
 }
 
 func generateEnvExample() string {
@@ -277,13 +598,29 @@ EMBED_MODEL=
 
 func generateDockerIgnore() string {
 	return `# Exclude raw source files from Docker image
-# The compiled databases (memory.chromem/, knowledge.cayley/) are what matters
+# The compiled databases (memory.chromem/, knowledge.cayley/, documents.store/)
+# are what matters
 
 # Raw data sources
 data/*.pdf
 data/*.md
 data/*.txt
 data/*.docx
+data/*.epub
+data/*.csv
+data/*.tsv
+data/*.json
+data/*.jsonl
+data/*.mp3
+data/*.wav
+data/*.m4a
+data/*.png
+data/*.jpg
+data/*.jpeg
+data/*.meta.yaml
+
+# Keep the build manifest (it is JSON but not a data source)
+!data/manifest.json
 
 # Development artifacts
 *.log
@@ -291,13 +628,22 @@ data/*.docx
 .env
 .env.local
 
+# Project-level provider config (may hold API keys; use env vars at runtime)
+config.yaml
+
+# Kash build cache (fetched remote sources)
+.kash/
+
+# Cached output of the load and chunk build stages
+data/build-stages/
+
 # Go build artifacts (if any)
 bin/
 `
 }
 
-func generateReadme(name string) string {
-	return fmt.Sprintf(`# %s — Kash Agent
+func generateReadme(name string, port int) string {
+	return fmt.Sprintf(`# %[1]s — Kash Agent
 
 An expert AI agent built with [Kash](https://github.com/akashicode/kash).
 
@@ -325,12 +671,12 @@ An expert AI agent built with [Kash](https://github.com/akashicode/kash).
 
 4. Build the Docker image (current architecture):
    `+"```"+`bash
-   docker build -t %s:latest .
+   docker build -t %[1]s:latest .
    `+"```"+`
 
 5. Or build multi-arch and push (share with the world):
    `+"```"+`bash
-   docker buildx build --platform linux/amd64,linux/arm64 -t my-registry/%s:v1 --push .
+   docker buildx build --platform linux/amd64,linux/arm64 -t my-registry/%[1]s:v1 --push .
    `+"```"+`
 
 ### Run
@@ -345,7 +691,7 @@ docker compose up --build
 **Manual — docker run:**
 
 `+"```"+`bash
-docker run -p 8000:8000 \\
+docker run -p %[2]d:%[2]d \\
   -e LLM_BASE_URL="https://api.openai.com/v1" \\
   -e LLM_API_KEY="sk-..." \\
   -e LLM_MODEL="gpt-4o" \\
@@ -355,13 +701,13 @@ docker run -p 8000:8000 \\
   -e RERANK_BASE_URL=""   `+"`# optional`"+` \\
   -e RERANK_API_KEY=""    `+"`# optional`"+` \\
   -e RERANK_MODEL=""      `+"`# optional`"+` \\
-  %s:latest
+  %[1]s:latest
 `+"```"+`
 
 ## Interfaces
 
-- **REST API**: `+"`POST http://localhost:8000/v1/chat/completions`"+` (OpenAI-compatible)
-- **MCP Server**: `+"`GET http://localhost:8000/mcp`"+` (for Cursor, Windsurf)
-- **A2A Protocol**: `+"`POST http://localhost:8000/rpc/agent`"+` (for AutoGen, CrewAI)
-`, name, name, name, name)
+- **REST API**: `+"`POST http://localhost:%[2]d/v1/chat/completions`"+` (OpenAI-compatible)
+- **MCP Server**: `+"`GET http://localhost:%[2]d/mcp`"+` (for Cursor, Windsurf)
+- **A2A Protocol**: `+"`POST http://localhost:%[2]d/rpc/agent`"+` (for AutoGen, CrewAI)
+`, name, port)
 }
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// initTemplate tailors the project 'kash init' scaffolds to a use case.
+type initTemplate struct {
+	// Description becomes agent.description
+	Description string
+	// SystemPrompt is a format string taking the agent name
+	SystemPrompt string
+	// ChunkSize and ChunkOverlap set the chunking block; zero leaves it
+	// commented out
+	ChunkSize    int
+	ChunkOverlap int
+	// TopK and GraphTopK set the retrieval block; zero leaves it commented out
+	TopK      int
+	GraphTopK int
+	// ToolSuffix names the MCP tool search_<slug>_<suffix>
+	ToolSuffix      string
+	ToolDescription string
+	// SampleData is written to data/ so the first build has something to index
+	SampleData []sampleFile
+	// SampleQuery is the question 'kash init --smoke-test' asks about SampleData
+	SampleQuery string
+}
+
+// sampleFile is a document written to data/ by 'kash init'.
+type sampleFile struct {
+	Name    string
+	Content string
+}
+
+// defaultTemplate is the generic skeleton used without --template.
+var defaultTemplate = initTemplate{
+	Description: "An expert AI agent powered by Kash",
+	SystemPrompt: `You are %s, a highly knowledgeable expert assistant.
+You have access to a specialized knowledge base compiled from expert documents.
+Always ground your responses in the retrieved context.
+Be precise, helpful, and cite your knowledge when possible.`,
+	ToolSuffix:      "knowledge",
+	ToolDescription: "Search the expert knowledge base for relevant information. Use this tool when the user asks about topics covered in the agent's domain.",
+}
+
+// genericSampleData is what --with-sample-data adds without a template.
+var genericSampleData = []sampleFile{{Name: "about-kash.md", Content: `# About This Agent
+
+This agent was created with ` + "`kash init`" + `. Kash compiles the documents in
+the data/ directory into two embedded databases:
+
+- a vector index of text chunks, searched by meaning, and
+- a knowledge graph of subject–predicate–object facts extracted by an LLM.
+
+When a question comes in, the agent searches both databases and answers from
+what it finds. Replace this file with your own documents and run
+` + "`kash build`" + ` again.
+`}}
+
+const genericSampleQuery = "How does this agent answer questions?"
+
+// initTemplates are the use cases selectable with 'kash init --template'.
+var initTemplates = map[string]initTemplate{
+	"support-bot": {
+		Description: "A customer support agent that answers from your help center and FAQs",
+		SystemPrompt: `You are %s, a friendly and patient customer support agent.
+Answer only from the retrieved help center articles and FAQs.
+Give short answers with numbered steps when the user has to do something.
+If the knowledge base does not cover the question, say so and suggest contacting
+a human agent. Never invent prices, policies, or deadlines.`,
+		// FAQ entries are short; small chunks keep each answer in one piece
+		ChunkSize:       600,
+		ChunkOverlap:    100,
+		TopK:            4,
+		GraphTopK:       8,
+		ToolSuffix:      "help_center",
+		ToolDescription: "Search the help center and FAQs. Use this tool for questions about accounts, billing, orders, and troubleshooting.",
+		SampleData: []sampleFile{{Name: "faq.md", Content: `# Frequently Asked Questions
+
+## How do I reset my password?
+
+1. Open the sign-in page and click **Forgot password**.
+2. Enter the email address on your account.
+3. Follow the link in the email within 30 minutes.
+
+## How do I cancel my subscription?
+
+Go to **Settings → Billing** and click **Cancel subscription**. Your plan stays
+active until the end of the current billing period.
+
+## Which payment methods do you accept?
+
+We accept Visa, Mastercard, American Express, and PayPal.
+`}},
+		SampleQuery: "How do I reset my password?",
+	},
+	"docs-agent": {
+		Description: "A documentation agent that answers questions about your product docs",
+		SystemPrompt: `You are %s, an expert on this product's documentation.
+Answer from the retrieved documentation and name the page each fact comes from.
+Include configuration and code examples exactly as the docs show them.
+If the docs do not answer the question, say which topic is missing instead of guessing.`,
+		ChunkSize:       1200,
+		ChunkOverlap:    200,
+		TopK:            6,
+		GraphTopK:       10,
+		ToolSuffix:      "docs",
+		ToolDescription: "Search the product documentation. Use this tool for questions about installation, configuration, features, and APIs.",
+		SampleData: []sampleFile{{Name: "getting-started.md", Content: `# Getting Started
+
+## Installation
+
+Download the latest release for your platform and put the binary on your PATH.
+
+## Configuration
+
+Settings are read from ` + "`config.yaml`" + ` in the working directory:
+
+` + "```yaml" + `
+server:
+  port: 8080
+  log_level: info
+` + "```" + `
+
+## First run
+
+Start the service with ` + "`app serve`" + ` and open http://localhost:8080.
+`}},
+		SampleQuery: "How do I change the server port?",
+	},
+	"code-assistant": {
+		Description: "A coding assistant that knows your codebase, architecture, and conventions",
+		SystemPrompt: `You are %s, a senior engineer who knows this codebase well.
+Answer from the retrieved code, design documents, and conventions.
+Reference file paths and function names, and follow the project's conventions in any code you write.
+If the context does not show how something works, say so rather than guessing.`,
+		// Code and design docs need larger chunks to keep functions together
+		ChunkSize:       1500,
+		ChunkOverlap:    300,
+		TopK:            8,
+		GraphTopK:       12,
+		ToolSuffix:      "codebase",
+		ToolDescription: "Search the codebase, architecture notes, and coding conventions. Use this tool before writing or reviewing code in this project.",
+		SampleData: []sampleFile{{Name: "conventions.md", Content: `# Coding Conventions
+
+## Errors
+
+Wrap errors with context: ` + "`fmt.Errorf(\"load config: %w\", err)`" + `.
+Never ignore an error without a comment explaining why.
+
+## Layout
+
+- ` + "`cmd/`" + ` holds the command-line entry points.
+- ` + "`internal/`" + ` holds packages that are not part of the public API.
+
+## Tests
+
+Tests are table-driven and live next to the code they test.
+`}},
+		SampleQuery: "How should errors be wrapped?",
+	},
+}
+
+// templateNames returns the --template values in sorted order.
+func templateNames() []string {
+	names := make([]string, 0, len(initTemplates))
+	for name := range initTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupTemplate returns the template for --template name, or the generic
+// skeleton when name is empty.
+func lookupTemplate(name string) (initTemplate, error) {
+	if name == "" {
+		return defaultTemplate, nil
+	}
+	t, ok := initTemplates[name]
+	if !ok {
+		return initTemplate{}, fmt.Errorf("unknown template %q (use %s)", name, strings.Join(templateNames(), ", "))
+	}
+	return t, nil
+}
+
+// chunkingBlock renders the chunking block of agent.yaml.
+func (t initTemplate) chunkingBlock() string {
+	if t.ChunkSize == 0 {
+		return `# Chunking (optional; sizes in characters)
+# chunking:
+#   size: 1000      # default: 1000, or derived from runtime.embedder.max_tokens
+#   overlap: 200    # default: size / 5
+`
+	}
+	return fmt.Sprintf(`# Chunking (sizes in characters)
+chunking:
+  size: %d
+  overlap: %d
+`, t.ChunkSize, t.ChunkOverlap)
+}
+
+// retrievalBlock renders the retrieval block of agent.yaml.
+func (t initTemplate) retrievalBlock() string {
+	if t.TopK == 0 {
+		return `# Retrieval (optional)
+# retrieval:
+#   top_k: 5          # document chunks given to the LLM
+#   graph_top_k: 10   # knowledge graph triples given to the LLM
+`
+	}
+	return fmt.Sprintf(`# Retrieval
+retrieval:
+  top_k: %d          # document chunks given to the LLM
+  graph_top_k: %d    # knowledge graph triples given to the LLM
+`, t.TopK, t.GraphTopK)
+}
+
+// indent prefixes every line of s with prefix.
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
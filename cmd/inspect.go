@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+var (
+	inspectDir        string
+	inspectSource     string
+	inspectQuery      string
+	inspectSemantic   bool
+	inspectFilter     map[string]string
+	inspectLimit      int
+	inspectFull       bool
+	inspectComponents int
+
+	inspectSubject   string
+	inspectPredicate string
+	inspectObject    string
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Browse the chunks, vectors, and triples in the compiled stores",
+	Long: `Prints what kash build stored, so you can see exactly what was embedded and
+which facts were extracted:
+
+  kash inspect chunks   --source faq.md
+  kash inspect chunks   --query "refund window" --semantic
+  kash inspect vectors  --source faq.md --components 16
+  kash inspect triples  --predicate "located in"
+
+Reading chunks and vectors needs no provider configuration unless --semantic
+is given. The graph store allows a single reader, so wait for a running
+'kash build' to finish before inspecting triples.`,
+}
+
+var inspectChunksCmd = &cobra.Command{
+	Use:   "chunks",
+	Short: "List stored chunks with their source and metadata",
+	Args:  cobra.NoArgs,
+	RunE:  runInspectChunks,
+}
+
+var inspectVectorsCmd = &cobra.Command{
+	Use:   "vectors",
+	Short: "List stored embeddings with their dimensions and leading components",
+	Args:  cobra.NoArgs,
+	RunE:  runInspectVectors,
+}
+
+var inspectTriplesCmd = &cobra.Command{
+	Use:   "triples",
+	Short: "List knowledge graph triples",
+	Args:  cobra.NoArgs,
+	RunE:  runInspectTriples,
+}
+
+func init() {
+	inspectCmd.PersistentFlags().StringVarP(&inspectDir, "dir", "d", ".", "Path to the agent project directory")
+	inspectCmd.PersistentFlags().StringVarP(&inspectQuery, "query", "q", "", "Only show entries containing every word of this query")
+	inspectCmd.PersistentFlags().IntVarP(&inspectLimit, "limit", "n", 20, "Maximum entries to show (0 for all)")
+
+	for _, c := range []*cobra.Command{inspectChunksCmd, inspectVectorsCmd} {
+		c.Flags().StringVarP(&inspectSource, "source", "s", "", "Only show chunks whose source contains this text")
+		c.Flags().StringToStringVar(&inspectFilter, "filter", nil, "Only show chunks with matching metadata (key=value)")
+		c.Flags().BoolVar(&inspectSemantic, "semantic", false, "Rank by semantic similarity to --query instead of matching words (calls the embedder)")
+	}
+	inspectChunksCmd.Flags().BoolVar(&inspectFull, "full", false, "Print full chunk text instead of a preview")
+	inspectVectorsCmd.Flags().IntVar(&inspectComponents, "components", 8, "Leading embedding components to print")
+
+	inspectTriplesCmd.Flags().StringVar(&inspectSubject, "subject", "", "Only show triples whose subject contains this text")
+	inspectTriplesCmd.Flags().StringVar(&inspectPredicate, "predicate", "", "Only show triples whose predicate contains this text")
+	inspectTriplesCmd.Flags().StringVar(&inspectObject, "object", "", "Only show triples whose object contains this text")
+
+	inspectCmd.AddCommand(inspectChunksCmd, inspectVectorsCmd, inspectTriplesCmd)
+	rootCmd.AddCommand(inspectCmd)
+}
+
+// inspectedChunk is the JSON form of a chunk or vector listing.
+type inspectedChunk struct {
+	ID         string            `json:"id"`
+	Source     string            `json:"source"`
+	Similarity *float32          `json:"similarity,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	Content    string            `json:"content,omitempty"`
+	Dimensions int               `json:"dimensions,omitempty"`
+	Norm       float64           `json:"norm,omitempty"`
+	Embedding  []float32         `json:"embedding,omitempty"`
+}
+
+func enterInspectDir() error {
+	if inspectDir == "." {
+		return nil
+	}
+	abs, err := filepath.Abs(inspectDir)
+	if err != nil {
+		return fmt.Errorf("resolve directory %q: %w", inspectDir, err)
+	}
+	if err := os.Chdir(abs); err != nil {
+		return fmt.Errorf("change to directory %q: %w", abs, err)
+	}
+	return nil
+}
+
+// loadInspectedChunks reads the vector store and applies the source,
+// metadata, and query filters. With --semantic the results are ranked by
+// similarity to the query.
+func loadInspectedChunks() ([]inspectedChunk, int, error) {
+	if err := enterInspectDir(); err != nil {
+		return nil, 0, err
+	}
+	const vectorPath = "data/memory.chromem"
+	if _, err := os.Stat(vectorPath); err != nil {
+		return nil, 0, fmt.Errorf("%s not found — run 'kash build' first", vectorPath)
+	}
+	docs, err := vector.ReadDocuments(vectorPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var similarity map[string]float32
+	if inspectSemantic {
+		if inspectQuery == "" {
+			return nil, 0, errors.New("--semantic requires --query")
+		}
+		if similarity, err = semanticRanking(inspectQuery, len(docs)); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var out []inspectedChunk
+	for _, d := range docs {
+		if inspectSource != "" && !containsFold(d.Source, inspectSource) {
+			continue
+		}
+		if !vector.MatchesFilter(d.Metadata, inspectFilter) {
+			continue
+		}
+		c := inspectedChunk{ID: d.ID, Source: d.Source, Metadata: d.Metadata, Content: d.Content, Embedding: d.Embedding}
+		if similarity != nil {
+			s, ok := similarity[d.ID]
+			if !ok {
+				continue
+			}
+			c.Similarity = &s
+		} else if !matchesQuery(inspectQuery, d.Content) {
+			continue
+		}
+		out = append(out, c)
+	}
+	if similarity != nil {
+		sort.SliceStable(out, func(i, j int) bool { return *out[i].Similarity > *out[j].Similarity })
+	}
+	return out, len(docs), nil
+}
+
+// semanticRanking embeds the query and returns the similarity of every chunk.
+func semanticRanking(query string, n int) (map[string]float32, error) {
+	cfg, err := agentconfig.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	agentconfig.ApplyAgentYAMLDimensions(cfg, "agent.yaml")
+	if err := agentconfig.ValidateEmbedder(cfg); err != nil {
+		return nil, err
+	}
+	vs, err := vector.NewStoreFromPath("data/memory.chromem", &cfg.Embedder)
+	if err != nil {
+		return nil, fmt.Errorf("open vector store: %w", err)
+	}
+	results, err := vs.Query(context.Background(), query, n)
+	if err != nil {
+		return nil, fmt.Errorf("semantic search: %w", err)
+	}
+	out := make(map[string]float32, len(results))
+	for _, r := range results {
+		out[r.ID] = r.Similarity
+	}
+	return out, nil
+}
+
+func runInspectChunks(_ *cobra.Command, _ []string) error {
+	chunks, total, err := loadInspectedChunks()
+	if err != nil {
+		return err
+	}
+	shown := limitEntries(len(chunks))
+	if jsonOutput {
+		for i := range chunks {
+			chunks[i].Embedding = nil
+		}
+		return printInspectJSON(chunks[:shown])
+	}
+
+	display.Header("🔎 Chunks")
+	for _, c := range chunks[:shown] {
+		fmt.Println()
+		fmt.Printf("  %s%s%s %s%s%s\n", display.Bold+display.BrightCyan, chunkLabel(c), display.Reset, display.Dim, c.ID, display.Reset)
+		if meta := formatChunkMetadata(c.Metadata); meta != "" {
+			fmt.Printf("  %s%s%s\n", display.Magenta, meta, display.Reset)
+		}
+		content := strings.TrimSpace(c.Content)
+		if !inspectFull {
+			content = preview(content, 400)
+		}
+		for _, line := range strings.Split(content, "\n") {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	printInspectFooter(shown, len(chunks), total, "chunks")
+	return nil
+}
+
+func runInspectVectors(_ *cobra.Command, _ []string) error {
+	chunks, total, err := loadInspectedChunks()
+	if err != nil {
+		return err
+	}
+	shown := limitEntries(len(chunks))
+	for i := range chunks {
+		chunks[i].Dimensions = len(chunks[i].Embedding)
+		chunks[i].Norm = vectorNorm(chunks[i].Embedding)
+		chunks[i].Content = ""
+	}
+	if jsonOutput {
+		return printInspectJSON(chunks[:shown])
+	}
+
+	display.Header("🔢 Vectors")
+	fmt.Println()
+	for _, c := range chunks[:shown] {
+		n := inspectComponents
+		if n > len(c.Embedding) || n < 0 {
+			n = len(c.Embedding)
+		}
+		parts := make([]string, n)
+		for i, v := range c.Embedding[:n] {
+			parts[i] = fmt.Sprintf("%+.4f", v)
+		}
+		if n < len(c.Embedding) {
+			parts = append(parts, "…")
+		}
+		fmt.Printf("  %s%-40s%s %4dd  norm %.3f  [%s]\n",
+			display.BrightCyan, preview(chunkLabel(c), 40), display.Reset, c.Dimensions, c.Norm, strings.Join(parts, " "))
+	}
+	printInspectFooter(shown, len(chunks), total, "vectors")
+	return nil
+}
+
+func runInspectTriples(_ *cobra.Command, _ []string) error {
+	if err := enterInspectDir(); err != nil {
+		return err
+	}
+	const graphPath = "data/knowledge.cayley"
+	if _, err := os.Stat(graphPath); err != nil {
+		return missingGraph()
+	}
+	gdb, err := graph.NewDBFromPath(graphPath)
+	if err != nil {
+		return fmt.Errorf("open graph db: %w", err)
+	}
+	defer gdb.Close()
+
+	all, err := gdb.Triples(context.Background())
+	if err != nil {
+		return err
+	}
+	var triples []graph.Triple
+	for _, t := range all {
+		if inspectSubject != "" && !containsFold(t.Subject, inspectSubject) ||
+			inspectPredicate != "" && !containsFold(t.Predicate, inspectPredicate) ||
+			inspectObject != "" && !containsFold(t.Object, inspectObject) ||
+			!matchesQuery(inspectQuery, t.Subject+" "+t.Predicate+" "+t.Object) {
+			continue
+		}
+		triples = append(triples, t)
+	}
+	shown := limitEntries(len(triples))
+	if jsonOutput {
+		return printInspectJSON(triples[:shown])
+	}
+
+	display.Header("🕸️  Triples")
+	fmt.Println()
+	for _, t := range triples[:shown] {
+		fmt.Printf("  %s%s%s  %s—%s→%s  %s%s%s\n",
+			display.BrightCyan, t.Subject, display.Reset,
+			display.Magenta, t.Predicate, display.Reset,
+			display.BrightGreen, t.Object, display.Reset)
+	}
+	printInspectFooter(shown, len(triples), len(all), "triples")
+	return nil
+}
+
+func limitEntries(n int) int {
+	if inspectLimit > 0 && inspectLimit < n {
+		return inspectLimit
+	}
+	return n
+}
+
+func printInspectJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printInspectFooter(shown, matched, total int, noun string) {
+	fmt.Println()
+	msg := fmt.Sprintf("%d of %d %s", shown, total, noun)
+	if matched != total {
+		msg = fmt.Sprintf("%d of %d matching %s (%d total)", shown, matched, noun, total)
+	}
+	if shown < matched {
+		msg += " — raise --limit or pass --limit 0 to see all"
+	}
+	display.Info(msg)
+}
+
+// chunkLabel identifies a chunk by its source and position.
+func chunkLabel(c inspectedChunk) string {
+	label := c.Source
+	if idx, ok := c.Metadata["index"]; ok {
+		label += " #" + idx
+	}
+	if c.Similarity != nil {
+		label += fmt.Sprintf(" (%.3f)", *c.Similarity)
+	}
+	return label
+}
+
+// formatChunkMetadata renders user metadata as "key=value" pairs, omitting
+// the reserved source and index keys shown in the chunk label.
+func formatChunkMetadata(meta map[string]string) string {
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		if k != "source" && k != "index" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + meta[k]
+	}
+	return strings.Join(parts, "  ")
+}
+
+// matchesQuery reports whether text contains every word of query, ignoring case.
+func matchesQuery(query, text string) bool {
+	for _, term := range strings.Fields(query) {
+		if !containsFold(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func preview(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+func vectorNorm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
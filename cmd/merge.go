@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/pkg/kash"
+)
+
+var (
+	mergeOut         string
+	mergeForce       bool
+	mergeMixedModels bool
+)
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge <agent> <agent>... --out <dir>",
+	Short: "Combine the knowledge of several built agents into one",
+	Long: `Combines the vector indexes and knowledge graphs of built agents into the
+agent at --out, without embedding or extracting triples again. Each agent is
+a directory, optionally named as name=dir; the name defaults to the
+directory name.
+
+Every agent must have vectors of the same dimensions, from the same
+embedding model. Document names and chunk IDs are prefixed with the agent
+name ("billing/faq.md"), and every chunk gets an "agent" metadata field, so
+a query can be filtered to one agent. Triples held by several agents are
+stored once, compared case-insensitively.
+
+--out keeps its agent.yaml; without one, the first agent's is copied. The
+merged agent is recomposed by running 'kash merge' again: 'kash build' in
+--out rebuilds it from its own data/ and drops the merged chunks.`,
+	Example: `  kash merge ./billing ./support --out ./help-desk
+  kash merge billing=./agents/b support=./agents/s --out ./help-desk --force`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runMerge,
+}
+
+func init() {
+	mergeCmd.Flags().StringVarP(&mergeOut, "out", "o", "", "Agent directory that receives the merged knowledge (required)")
+	mergeCmd.Flags().BoolVar(&mergeForce, "force", false, "Replace the knowledge base already built in --out")
+	mergeCmd.Flags().BoolVar(&mergeMixedModels, "allow-mixed-models", false, "Merge agents embedded with different models of the same dimensions")
+	_ = mergeCmd.MarkFlagRequired("out")
+	rootCmd.AddCommand(mergeCmd)
+}
+
+// mergeResult is the --json output of 'kash merge'.
+type mergeResult struct {
+	Agents           []string `json:"agents"`
+	Out              string   `json:"out"`
+	Documents        int      `json:"documents"`
+	Chunks           int      `json:"chunks"`
+	Dimensions       int      `json:"dimensions"`
+	Triples          int64    `json:"triples"`
+	DuplicateTriples int      `json:"duplicate_triples"`
+	Snapshot         string   `json:"snapshot,omitempty"`
+	DurationMS       int64    `json:"duration_ms"`
+	Warnings         []string `json:"warnings"`
+}
+
+func runMerge(_ *cobra.Command, args []string) error {
+	opts := kash.MergeOptions{
+		Dir:              mergeOut,
+		Force:            mergeForce,
+		AllowMixedModels: mergeMixedModels,
+		Version:          version,
+		Progress:         displayProgress{},
+	}
+	names := make([]string, 0, len(args))
+	for _, spec := range args {
+		name, dir, err := parseAgentSpec(spec)
+		if err != nil {
+			return err
+		}
+		opts.Agents = append(opts.Agents, kash.MergeAgent{Name: name, Dir: dir})
+		names = append(names, name)
+	}
+
+	display.Header("🧩 Kash Merge")
+	display.Newline()
+	res, err := kash.Merge(context.Background(), opts)
+	if err != nil {
+		return err
+	}
+
+	display.Newline()
+	display.Success("Merge complete!")
+	display.Newline()
+	display.KeyValue("Vector index", fmt.Sprintf("%s (%d chunks, %d dimensions)", kash.VectorDir, res.Chunks, res.Dimensions), display.BrightGreen)
+	display.KeyValue("Graph store", fmt.Sprintf("%s (%d triples)", kash.GraphDir, res.Triples), display.BrightGreen)
+	display.KeyValue("Manifest", fmt.Sprintf("%s (%d documents)", kash.ManifestFile, res.Documents), display.BrightGreen)
+	display.NextSteps([]string{
+		fmt.Sprintf("kash serve --dir %s", mergeOut),
+	})
+
+	if jsonOutput {
+		return printJSON(mergeResult{
+			Agents:           names,
+			Out:              mergeOut,
+			Documents:        res.Documents,
+			Chunks:           res.Chunks,
+			Dimensions:       res.Dimensions,
+			Triples:          res.Triples,
+			DuplicateTriples: res.DuplicateTriples,
+			Snapshot:         res.Snapshot,
+			DurationMS:       res.Duration.Milliseconds(),
+			Warnings:         collectedWarnings(),
+		})
+	}
+	return nil
+}
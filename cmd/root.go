@@ -1,15 +1,37 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/display"
+)
+
+var (
+	cfgFile string
+	profile string
+	// jsonOutput prints a command's result as JSON on stdout
+	jsonOutput bool
+	// quiet hides progress output
+	quiet bool
+	// noColor prints plain text even on a terminal
+	noColor bool
 )
 
-var cfgFile string
+// warnings collects the warnings printed while a command runs, for --json
+// and the build report.
+var warnings struct {
+	mu   sync.Mutex
+	msgs []string
+}
 
 var rootCmd = &cobra.Command{
 	Use:   "kash",
@@ -29,8 +51,12 @@ func Execute() {
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.OnInitialize(initConfig, initOutput)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ~/.kash/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "provider profile from config.yaml (env: KASH_PROFILE)")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "print the result as JSON instead of colored text (build, merge, stats, eval, inspect, graph, compact, snapshots, rollback, diff, upgrade)")
+	rootCmd.PersistentFlags().BoolVar(&quiet, "quiet", false, "only print warnings and errors")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also: NO_COLOR env var, or when stdout is not a terminal)")
 
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(buildCmd)
@@ -50,9 +76,49 @@ func initConfig() {
 		viper.SetConfigName("config")
 	}
 
+	// The flag wins over the environment; config.Load reads the result
+	if profile != "" {
+		os.Setenv(agentconfig.ProfileEnv, profile)
+	}
+
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err != nil {
 		// Silence the warning — config.yaml is optional when env vars are set
 	}
 }
+
+// initOutput applies --no-color, --json, and --quiet and starts collecting
+// warnings. With --json or --quiet progress output is dropped and warnings
+// still reach stderr.
+func initOutput() {
+	if noColor {
+		display.DisableColor()
+	}
+	silent := jsonOutput || quiet
+	if silent {
+		display.SetOutput(io.Discard)
+	}
+	display.OnWarning(func(msg string) {
+		warnings.mu.Lock()
+		warnings.msgs = append(warnings.msgs, msg)
+		warnings.mu.Unlock()
+		if silent {
+			fmt.Fprintln(os.Stderr, "warning: "+msg)
+		}
+	})
+}
+
+// collectedWarnings returns the warnings printed so far, never nil.
+func collectedWarnings() []string {
+	warnings.mu.Lock()
+	defer warnings.mu.Unlock()
+	return append([]string{}, warnings.msgs...)
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
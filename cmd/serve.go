@@ -1,28 +1,52 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 
 	agentconfig "github.com/akashicode/kash/internal/config"
 	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/logging"
+	"github.com/akashicode/kash/internal/manifest"
 	"github.com/akashicode/kash/internal/server"
+	"github.com/akashicode/kash/internal/snapshot"
 )
 
 var (
-	serveAgentYAML string
-	serveDir       string
+	serveAgentYAML     string
+	serveDir           string
+	serveWatch         bool
+	serveLiveIngest    bool
+	serveWatchInterval time.Duration
+	serveAgents        []string
+	serveDev           bool
 )
 
+// errRestart is returned by a serve pass that stopped because agent.yaml
+// changed under --dev; runServe starts a fresh one.
+var errRestart = errors.New("agent.yaml changed")
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the Kash runtime server",
-	Long: `Starts the runtime HTTP server on port 8000 (or config/PORT env).
-Requires compiled databases in data/memory.chromem/ and data/knowledge.cayley/.
+	Long: `Starts the runtime HTTP server on port 8000, or on the port set by the PORT
+env var, agent.yaml server.port, or config.yaml, in that order.
+Requires compiled databases in data/memory.chromem/ and, unless the build
+skipped the graph, data/knowledge.cayley/.
 
 Exposes three interfaces:
   POST /v1/chat/completions  - OpenAI-compatible REST API
@@ -30,13 +54,51 @@ Exposes three interfaces:
   POST /rpc/agent            - A2A JSON-RPC endpoint
 
 Provider config is resolved from environment variables first,
-then falls back to ~/.kash/config.yaml.`,
+then falls back to ~/.kash/config.yaml.
+
+Send SIGHUP to reload the databases after 'kash build' without a restart, or
+pass --watch to reload whenever data/manifest.json changes. Requests already
+running finish against the previous databases.
+
+With --live-ingest, documents dropped into data/ are chunked and embedded
+into the running server's vector index as soon as they stop changing, and
+deleted documents are removed from it. The knowledge graph is left to the
+next 'kash build'.
+
+With --agents, one process serves several agent directories, each with its
+own agent.yaml and data/, sharing the LLM, embedder, and reranker settings:
+
+  kash serve --agents ./support,./docs,handbook=./hr-handbook
+
+Each agent is reachable under /agents/<name>/ (e.g. /agents/support/mcp) or
+through a Host header whose first label is its name (support.example.com).
+GET /health summarizes all agents.
+
+Set AGENT_ADMIN_KEY to enable the /admin API, which re-ingests data/, reloads
+the databases, rotates the API key, toggles the reranker, and shows the
+effective config without a restart.
+
+Set GRPC_PORT (or grpc_port in config.yaml) to also serve the gRPC interface
+defined in proto/kash/v1/kash.proto on that port. It is not served with
+--agents.
+
+--dev is for working on an agent locally: it turns on --watch and
+--live-ingest, logs every retrieved chunk at debug level, restarts the server
+in-process when agent.yaml changes, redirects / to the playground at /ui/,
+and answers CORS requests from any origin with credentials. Production mode
+(no --dev) keeps CORS at a wildcard origin without credentials, logs at info,
+and reloads only with --watch or --live-ingest.`,
 	RunE: runServe,
 }
 
 func init() {
 	serveCmd.Flags().StringVar(&serveAgentYAML, "agent", "agent.yaml", "Path to agent.yaml")
 	serveCmd.Flags().StringVarP(&serveDir, "dir", "d", ".", "Path to the agent project directory")
+	serveCmd.Flags().BoolVar(&serveWatch, "watch", false, "Reload the databases when a build finishes (data/manifest.json changes)")
+	serveCmd.Flags().StringSliceVar(&serveAgents, "agents", nil, "Serve several agent directories from one process, as [name=]dir (repeatable)")
+	serveCmd.Flags().BoolVar(&serveLiveIngest, "live-ingest", false, "Embed documents added to or changed in data/ into the running server")
+	serveCmd.Flags().DurationVar(&serveWatchInterval, "watch-interval", 5*time.Second, "How often --watch and --live-ingest check for changes")
+	serveCmd.Flags().BoolVar(&serveDev, "dev", false, "Development mode: auto-reload, debug retrieval logs, relaxed CORS, / redirects to the playground")
 	rootCmd.AddCommand(serveCmd)
 }
 
@@ -52,7 +114,22 @@ func runServe(_ *cobra.Command, _ []string) error {
 		}
 		fmt.Printf("Working directory: %s\n", abs)
 	}
+	if serveDev {
+		serveWatch, serveLiveIngest = true, true
+	}
 
+	for {
+		err := serveOnce()
+		if !errors.Is(err, errRestart) {
+			return err
+		}
+		display.Info("agent.yaml changed, restarting")
+	}
+}
+
+// serveOnce loads the config and serves until shutdown, or until agent.yaml
+// changes under --dev, in which case it returns errRestart.
+func serveOnce() error {
 	// Load unified config (env vars take priority over config.yaml)
 	cfg, err := agentconfig.Load()
 	if err != nil {
@@ -61,16 +138,43 @@ func runServe(_ *cobra.Command, _ []string) error {
 
 	// Apply dimensions from agent.yaml (canonical source for agent-specific settings)
 	agentconfig.ApplyAgentYAMLDimensions(cfg, serveAgentYAML)
+	if len(serveAgents) == 0 {
+		agentconfig.ApplyAgentYAMLPort(cfg, serveAgentYAML)
+	}
 
 	if err := agentconfig.ValidateServe(cfg); err != nil {
 		return err
 	}
+	httpServer, err := cfg.HTTP.NewHTTPServer(fmt.Sprintf(":%d", cfg.Port), nil)
+	if err != nil {
+		return err
+	}
+	logOpts := logging.OptionsFromEnv()
+	if serveDev && logOpts.Level == "" {
+		logOpts.Level = "debug"
+	}
+	logger, err := logging.New(logOpts)
+	if err != nil {
+		return err
+	}
+	defer logger.Close()
+
+	if len(serveAgents) > 0 {
+		return runServeMulti(cfg, httpServer, logger)
+	}
 
 	srvCfg := server.Config{
 		VectorStorePath: "data/memory.chromem",
 		GraphDBPath:     "data/knowledge.cayley",
+		DocumentsPath:   "data/documents.store",
 		AgentYAMLPath:   serveAgentYAML,
+		ManifestPath:    manifest.DefaultPath,
+		DataDir:         "data",
+		SnapshotDir:     snapshot.DefaultDir,
 		AppCfg:          cfg,
+		Reingest:        reingestFunc("."),
+		Logger:          logger,
+		Dev:             serveDev,
 	}
 
 	srv, err := server.New(srvCfg)
@@ -78,13 +182,229 @@ func runServe(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("initialize server: %w", err)
 	}
 
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort > 0 {
+		grpcServer = srv.GRPCServer()
+	}
+
 	// Print fancy startup banner
 	display.PrintBanner(srv.Info())
 
-	httpServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", cfg.Port),
-		Handler: srv.Handler(),
+	httpServer.Handler = srv.Handler()
+	return listenAndServe(httpServer, grpcServer, cfg.GRPCPort, srv, []string{serveAgentYAML})
+}
+
+// runServeMulti serves every --agents directory from this process, sharing
+// the provider clients.
+func runServeMulti(cfg *agentconfig.Config, httpServer *http.Server, logger *logging.Logger) error {
+	clients, err := server.NewClients(cfg)
+	if err != nil {
+		return err
+	}
+
+	agents := map[string]*server.Server{}
+	var agentYAMLs []string
+	for _, spec := range serveAgents {
+		name, dir, err := parseAgentSpec(spec)
+		if err != nil {
+			return err
+		}
+		if _, dup := agents[name]; dup {
+			return fmt.Errorf("agent name %q is used twice — name them with name=dir", name)
+		}
+		// Each agent applies the dimensions from its own agent.yaml
+		appCfg := *cfg
+		srv, err := server.New(server.Config{
+			VectorStorePath: filepath.Join(dir, "data", "memory.chromem"),
+			GraphDBPath:     filepath.Join(dir, "data", "knowledge.cayley"),
+			DocumentsPath:   filepath.Join(dir, "data", "documents.store"),
+			AgentYAMLPath:   filepath.Join(dir, "agent.yaml"),
+			ManifestPath:    filepath.Join(dir, manifest.DefaultPath),
+			DataDir:         filepath.Join(dir, "data"),
+			SnapshotDir:     filepath.Join(dir, snapshot.DefaultDir),
+			AppCfg:          &appCfg,
+			Clients:         clients,
+			Reingest:        reingestFunc(dir),
+			Logger:          logger,
+			Dev:             serveDev,
+		})
+		if err != nil {
+			return fmt.Errorf("initialize agent %q: %w", name, err)
+		}
+		agents[name] = srv
+		agentYAMLs = append(agentYAMLs, filepath.Join(dir, "agent.yaml"))
 	}
 
-	return httpServer.ListenAndServe()
+	multi, err := server.NewMulti(agents)
+	if err != nil {
+		return err
+	}
+	infos := make([]display.ServerInfo, 0, len(agents))
+	for _, name := range multi.Names() {
+		infos = append(infos, multi.Agent(name).Info())
+	}
+	display.PrintMultiBanner(multi.Names(), infos)
+	if cfg.GRPCPort > 0 {
+		display.Warn("grpc_port is ignored with --agents: the gRPC interface serves a single agent")
+	}
+
+	httpServer.Handler = multi.Handler()
+	return listenAndServe(httpServer, nil, 0, multi, agentYAMLs)
+}
+
+// parseAgentSpec splits an --agents entry of the form [name=]dir. The name
+// defaults to the directory's base name.
+func parseAgentSpec(spec string) (name, dir string, err error) {
+	name, dir, ok := strings.Cut(spec, "=")
+	if !ok {
+		dir, name = spec, ""
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("resolve directory %q: %w", dir, err)
+	}
+	if name == "" {
+		name = filepath.Base(abs)
+	}
+	return strings.ToLower(name), abs, nil
+}
+
+// reingestFunc returns the hook behind POST /admin/reingest: it runs
+// 'kash build' on dir in a child process, so the build's working directory
+// and output stay apart from the server's.
+func reingestFunc(dir string) func(context.Context, io.Writer, server.ReingestOptions) error {
+	return func(ctx context.Context, out io.Writer, opts server.ReingestOptions) error {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("locate kash binary: %w", err)
+		}
+		// The admin asked for this build, and there is no one to confirm its cost
+		args := []string{"build", "--dir", dir, "--yes"}
+		if cfgFile != "" {
+			args = append(args, "--config", cfgFile)
+		}
+		if opts.IfChanged {
+			args = append(args, "--if-changed")
+		}
+		build := exec.CommandContext(ctx, exe, args...)
+		build.Stdout, build.Stderr = out, out
+		if err := build.Run(); err != nil {
+			return fmt.Errorf("kash build: %w", err)
+		}
+		return nil
+	}
+}
+
+// dataReloader is a server whose stores can be reloaded and released.
+type dataReloader interface {
+	Reload() error
+	WatchData(ctx context.Context, interval time.Duration)
+	RunSchedule(ctx context.Context)
+	LiveIngest(ctx context.Context, interval time.Duration)
+	Close() error
+}
+
+// listenAndServe runs httpServer, and grpcServer on grpcPort when it is not
+// nil, until SIGINT or SIGTERM, reloading the stores on SIGHUP and, with
+// --watch, after each build. Scheduled re-ingests and, with --live-ingest,
+// live ingestion run in the background. With --dev it also stops, returning
+// errRestart, when one of agentYAMLs changes.
+func listenAndServe(httpServer *http.Server, grpcServer *grpc.Server, grpcPort int, srv dataReloader, agentYAMLs []string) error {
+	errCh := make(chan error, 2)
+	if grpcServer != nil {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
+		if err != nil {
+			return fmt.Errorf("listen for gRPC: %w", err)
+		}
+		go func() { errCh <- grpcServer.Serve(lis) }()
+		defer grpcServer.Stop()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if serveWatch {
+		go srv.WatchData(ctx, serveWatchInterval)
+	}
+	if serveLiveIngest {
+		go srv.LiveIngest(ctx, serveWatchInterval)
+	}
+	go srv.RunSchedule(ctx)
+	var changed <-chan struct{}
+	if serveDev {
+		changed = watchFiles(ctx, agentYAMLs, serveWatchInterval)
+	}
+
+	go func() { errCh <- httpServer.ListenAndServe() }()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	for {
+		select {
+		case err := <-errCh:
+			srv.Close()
+			return err
+		case <-changed:
+			shutdown(httpServer, grpcServer, srv)
+			return errRestart
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				srv.Reload()
+				continue
+			}
+			return shutdown(httpServer, grpcServer, srv)
+		}
+	}
+}
+
+// shutdown lets in-flight requests finish before releasing the stores.
+// Requests still running after the grace period are cut off.
+func shutdown(httpServer *http.Server, grpcServer *grpc.Server, srv dataReloader) error {
+	ctx, stop := context.WithTimeout(context.Background(), 30*time.Second)
+	defer stop()
+	if grpcServer != nil {
+		go func() {
+			<-ctx.Done()
+			grpcServer.Stop()
+		}()
+		grpcServer.GracefulStop()
+	}
+	if err := httpServer.Shutdown(ctx); err != nil {
+		httpServer.Close()
+	}
+	return srv.Close()
+}
+
+// watchFiles checks the modification times of paths every interval until ctx
+// ends, and signals once on the returned channel when any of them changes.
+func watchFiles(ctx context.Context, paths []string, interval time.Duration) <-chan struct{} {
+	modTimes := func() []time.Time {
+		times := make([]time.Time, len(paths))
+		for i, p := range paths {
+			if info, err := os.Stat(p); err == nil {
+				times[i] = info.ModTime()
+			}
+		}
+		return times
+	}
+	changed := make(chan struct{}, 1)
+	go func() {
+		last := modTimes()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for i, t := range modTimes() {
+					if !t.Equal(last[i]) {
+						changed <- struct{}{}
+						return
+					}
+				}
+			}
+		}
+	}()
+	return changed
 }
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/snapshot"
+)
+
+var snapshotsDir string
+
+var snapshotsCmd = &cobra.Command{
+	Use:   "snapshots",
+	Short: "Manage the snapshots taken by 'kash build'",
+	Long: `Every successful 'kash build' saves a copy of data/memory.chromem,
+data/knowledge.cayley, and data/manifest.json as the next version under
+.kash/snapshots/ (v1, v2, ...). The newest five are kept; set snapshots.keep
+in agent.yaml to change that, or snapshots.disabled to stop taking them.
+
+Use 'kash rollback' to serve an earlier version again.`,
+}
+
+var snapshotsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the kept snapshots",
+	Args:  cobra.NoArgs,
+	RunE:  runSnapshotsList,
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [version]",
+	Short: "Restore the compiled stores from a snapshot",
+	Long: `Replaces data/memory.chromem, data/knowledge.cayley, and data/manifest.json
+with the copies in a snapshot. Without a version, the snapshot before the
+current build is restored. The snapshots themselves are left as they are,
+so a rollback can be undone by rolling back to the newer version.
+
+A running 'kash serve' picks up the restored stores on SIGHUP or with
+--watch; POST /admin/rollback restores and reloads in one step.`,
+	Example: `  kash rollback       # back to the previous build
+  kash rollback v3    # back to snapshot v3`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRollback,
+}
+
+func init() {
+	snapshotsListCmd.Flags().StringVarP(&snapshotsDir, "dir", "d", ".", "Path to the agent project directory")
+	rollbackCmd.Flags().StringVarP(&snapshotsDir, "dir", "d", ".", "Path to the agent project directory")
+	snapshotsCmd.AddCommand(snapshotsListCmd)
+	rootCmd.AddCommand(snapshotsCmd)
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+// snapshotList is the --json output of 'kash snapshots list'.
+type snapshotList struct {
+	Current   string          `json:"current,omitempty"`
+	Snapshots []snapshot.Info `json:"snapshots"`
+}
+
+func runSnapshotsList(_ *cobra.Command, _ []string) error {
+	if err := chdirProject(snapshotsDir); err != nil {
+		return err
+	}
+	infos, err := snapshot.List(snapshot.DefaultDir)
+	if err != nil {
+		return err
+	}
+	list := snapshotList{Current: snapshot.Current(infos, manifest.DefaultPath), Snapshots: infos}
+	if list.Snapshots == nil {
+		list.Snapshots = []snapshot.Info{}
+	}
+	if jsonOutput {
+		return printJSON(list)
+	}
+
+	display.Header("📸 Kash Snapshots")
+	if len(infos) == 0 {
+		display.Newline()
+		display.Info("No snapshots yet — 'kash build' takes one after every successful build")
+		return nil
+	}
+	display.Newline()
+	for i := len(infos) - 1; i >= 0; i-- {
+		info := infos[i]
+		label := info.Version
+		if info.Version == list.Current {
+			label += " (current)"
+		}
+		display.KeyValue(label, fmt.Sprintf("built %s · %d documents · %d chunks · %d vectors · %d triples · %s",
+			info.BuiltAt.Local().Format("2006-01-02 15:04"), info.Documents, info.Chunks, info.Vectors, info.Triples, formatBytes(info.Bytes)),
+			display.BrightCyan)
+	}
+	return nil
+}
+
+func runRollback(_ *cobra.Command, args []string) error {
+	if err := chdirProject(snapshotsDir); err != nil {
+		return err
+	}
+	infos, err := snapshot.List(snapshot.DefaultDir)
+	if err != nil {
+		return err
+	}
+	if len(infos) == 0 {
+		return fmt.Errorf("no snapshots in %s — 'kash build' takes one after every successful build", snapshot.DefaultDir)
+	}
+	version := ""
+	if len(args) > 0 {
+		version = args[0]
+	}
+	current := snapshot.Current(infos, manifest.DefaultPath)
+	info, err := snapshot.Find(infos, version, current)
+	if err != nil {
+		return err
+	}
+	if err := snapshot.Restore(snapshot.DefaultDir, info, snapshot.Paths{
+		Vectors:   filepath.Join("data", "memory.chromem"),
+		Graph:     filepath.Join("data", "knowledge.cayley"),
+		Documents: filepath.Join("data", "documents.store"),
+		Manifest:  manifest.DefaultPath,
+	}); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(struct {
+			Previous string        `json:"previous,omitempty"`
+			Restored snapshot.Info `json:"restored"`
+		}{current, info})
+	}
+	display.Success(fmt.Sprintf("Rolled back to %s (built %s)", info.Version, info.BuiltAt.Local().Format("2006-01-02 15:04")))
+	steps := []string{"Send SIGHUP to a running 'kash serve' to load the restored stores (or run it with --watch)"}
+	if current != "" {
+		steps = append(steps, "'kash rollback "+current+"' returns to the build you replaced")
+	}
+	display.NextSteps(steps)
+	return nil
+}
+
+// chdirProject changes to the agent project directory dir.
+func chdirProject(dir string) error {
+	if dir == "." {
+		return nil
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve directory %q: %w", dir, err)
+	}
+	if err := os.Chdir(abs); err != nil {
+		return fmt.Errorf("change to directory %q: %w", abs, err)
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+
+	"github.com/akashicode/kash/internal/buildreport"
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+var (
+	statsDir string
+	statsTop int
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Summarize the compiled knowledge base",
+	Long: `Reads data/memory.chromem, data/knowledge.cayley, and data/manifest.json and
+reports documents, chunks, average chunk length, vectors and their dimensions,
+triples and the most common predicates, store sizes on disk, and an estimate of
+the memory 'kash serve' needs to hold the stores. When the last build left a
+report in .kash/, it also shows that build's tokens and cost by phase.
+
+No provider configuration is needed. The graph store allows a single reader,
+so wait for a running 'kash build' to finish first.`,
+	Args: cobra.NoArgs,
+	RunE: runStats,
+}
+
+func init() {
+	statsCmd.Flags().StringVarP(&statsDir, "dir", "d", ".", "Path to the agent project directory")
+	statsCmd.Flags().IntVar(&statsTop, "top", 10, "Number of predicates to list")
+	rootCmd.AddCommand(statsCmd)
+}
+
+// agentStats summarizes a built agent.
+type agentStats struct {
+	BuiltAt   string `json:"built_at,omitempty"`
+	Documents int    `json:"documents"`
+	Chunks    int    `json:"chunks"`
+	// AvgChunkChars is the mean chunk length in characters
+	AvgChunkChars  int              `json:"avg_chunk_chars"`
+	MinChunkChars  int              `json:"min_chunk_chars"`
+	MaxChunkChars  int              `json:"max_chunk_chars"`
+	Vectors        int              `json:"vectors"`
+	Dimensions     int              `json:"dimensions"`
+	EmbedderModel  string           `json:"embedder_model,omitempty"`
+	Triples        int              `json:"triples"`
+	Entities       int              `json:"entities"`
+	Predicates     int              `json:"predicates"`
+	TopPredicates  []predicateCount `json:"top_predicates,omitempty"`
+	VectorBytes    int64            `json:"vector_store_bytes"`
+	GraphBytes     int64            `json:"graph_store_bytes"`
+	EstMemoryBytes int64            `json:"estimated_memory_bytes"`
+
+	// BuildTokens are the tokens of the last build by phase, from its report
+	BuildTokens  map[string]buildreport.Tokens `json:"build_tokens,omitempty"`
+	BuildCostUSD float64                       `json:"build_cost_usd,omitempty"`
+}
+
+type predicateCount struct {
+	Predicate string `json:"predicate"`
+	Count     int    `json:"count"`
+}
+
+func runStats(_ *cobra.Command, _ []string) error {
+	if statsDir != "." {
+		abs, err := filepath.Abs(statsDir)
+		if err != nil {
+			return fmt.Errorf("resolve directory %q: %w", statsDir, err)
+		}
+		if err := os.Chdir(abs); err != nil {
+			return fmt.Errorf("change to directory %q: %w", abs, err)
+		}
+	}
+
+	const vectorPath, graphPath = "data/memory.chromem", "data/knowledge.cayley"
+	if _, err := os.Stat(vectorPath); err != nil {
+		return fmt.Errorf("%s not found — run 'kash build' first", vectorPath)
+	}
+
+	var st agentStats
+	docs, err := vector.ReadDocuments(vectorPath)
+	if err != nil {
+		return err
+	}
+	var contentBytes, metadataBytes int64
+	sources := map[string]bool{}
+	totalChars := 0
+	for i, d := range docs {
+		sources[d.Source] = true
+		n := utf8.RuneCountInString(d.Content)
+		totalChars += n
+		if i == 0 || n < st.MinChunkChars {
+			st.MinChunkChars = n
+		}
+		if n > st.MaxChunkChars {
+			st.MaxChunkChars = n
+		}
+		if len(d.Embedding) > 0 {
+			st.Vectors++
+			st.Dimensions = len(d.Embedding)
+		}
+		contentBytes += int64(len(d.Content) + len(d.ID))
+		for k, v := range d.Metadata {
+			metadataBytes += int64(len(k) + len(v))
+		}
+	}
+	st.Chunks = len(docs)
+	st.Documents = len(sources)
+	if st.Chunks > 0 {
+		st.AvgChunkChars = totalChars / st.Chunks
+	}
+
+	if m, err := manifest.Load(manifest.DefaultPath); err == nil {
+		st.BuiltAt = m.BuiltAt.Local().Format("2006-01-02 15:04")
+		st.EmbedderModel = m.Embedder.Model
+		if len(m.Documents) > 0 {
+			st.Documents = len(m.Documents)
+		}
+	} else if !errors.Is(err, manifest.ErrNotFound) {
+		return err
+	}
+
+	// A vector-only build has no graph store; it counts as empty
+	gdb, err := graph.OpenSnapshot(graphPath)
+	if err != nil {
+		return fmt.Errorf("open graph db: %w", err)
+	}
+	triples, err := gdb.Triples(context.Background())
+	gdb.Close()
+	if err != nil {
+		return err
+	}
+	st.Triples = len(triples)
+	st.Entities, st.TopPredicates = tripleDistribution(triples)
+	st.Predicates = len(st.TopPredicates)
+	if statsTop >= 0 && len(st.TopPredicates) > statsTop {
+		st.TopPredicates = st.TopPredicates[:statsTop]
+	}
+
+	if st.VectorBytes, err = dirSize(vectorPath); err != nil {
+		return err
+	}
+	if _, err := os.Stat(graphPath); err == nil {
+		if st.GraphBytes, err = dirSize(graphPath); err != nil {
+			return err
+		}
+	}
+	st.EstMemoryBytes = estimateMemory(st, contentBytes+metadataBytes)
+	if report, err := buildreport.Load(buildreport.DefaultDir); err == nil {
+		st.BuildTokens, st.BuildCostUSD = report.Phases, report.CostUSD
+	}
+
+	if jsonOutput {
+		return printJSON(st)
+	}
+	printStats(st)
+	return nil
+}
+
+// tripleDistribution counts distinct entities and orders predicates by
+// frequency.
+func tripleDistribution(triples []graph.Triple) (int, []predicateCount) {
+	entities := map[string]bool{}
+	counts := map[string]int{}
+	for _, t := range triples {
+		entities[strings.ToLower(t.Subject)] = true
+		entities[strings.ToLower(t.Object)] = true
+		counts[t.Predicate]++
+	}
+	out := make([]predicateCount, 0, len(counts))
+	for p, n := range counts {
+		out = append(out, predicateCount{Predicate: p, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Predicate < out[j].Predicate
+	})
+	return len(entities), out
+}
+
+// estimateMemory approximates the resident size of the stores in 'kash serve'.
+// chromem-go keeps every chunk in memory: float32 embeddings, text, metadata,
+// and roughly 250 bytes of map and struct overhead per chunk. The bolt graph
+// is memory-mapped, so its file size counts once pages are touched.
+func estimateMemory(st agentStats, textBytes int64) int64 {
+	const perChunkOverhead = 250
+	vectors := int64(st.Vectors) * int64(st.Dimensions) * 4
+	return vectors + textBytes + int64(st.Chunks)*perChunkOverhead + st.GraphBytes
+}
+
+func dirSize(path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("measure %s: %w", path, err)
+	}
+	return total, nil
+}
+
+func printStats(st agentStats) {
+	display.Header("📊 Kash Stats")
+	if st.BuiltAt != "" {
+		display.Newline()
+		display.KeyValue("Built", st.BuiltAt, display.Dim+display.White)
+	}
+
+	display.SubHeader("Documents")
+	display.KeyValue("Documents", st.Documents, display.BrightCyan)
+	display.KeyValue("Chunks", st.Chunks, display.BrightCyan)
+	display.KeyValue("Avg chunk length", fmt.Sprintf("%d chars (min %d, max %d)", st.AvgChunkChars, st.MinChunkChars, st.MaxChunkChars), display.BrightCyan)
+
+	display.SubHeader("Vectors")
+	display.KeyValue("Vectors", st.Vectors, display.BrightCyan)
+	display.KeyValue("Dimensions", st.Dimensions, display.Bold+display.BrightYellow)
+	if st.EmbedderModel != "" {
+		display.KeyValue("Embedder", st.EmbedderModel, display.BrightMagenta)
+	}
+
+	display.SubHeader("Knowledge graph")
+	display.KeyValue("Triples", st.Triples, display.BrightCyan)
+	display.KeyValue("Entities", st.Entities, display.BrightCyan)
+	display.KeyValue("Predicates", st.Predicates, display.BrightCyan)
+	for _, p := range st.TopPredicates {
+		share := 0.0
+		if st.Triples > 0 {
+			share = 100 * float64(p.Count) / float64(st.Triples)
+		}
+		display.StepDetail(fmt.Sprintf("%6d  %5.1f%%  %s", p.Count, share, p.Predicate))
+	}
+
+	display.SubHeader("Footprint")
+	display.KeyValue("Vector store", formatBytes(st.VectorBytes), display.BrightGreen)
+	display.KeyValue("Graph store", formatBytes(st.GraphBytes), display.BrightGreen)
+	display.KeyValue("Est. memory", formatBytes(st.EstMemoryBytes), display.Bold+display.BrightGreen)
+
+	if len(st.BuildTokens) > 0 {
+		display.SubHeader("Last build's tokens")
+		phases := make([]string, 0, len(st.BuildTokens))
+		for phase := range st.BuildTokens {
+			phases = append(phases, phase)
+		}
+		sort.Strings(phases)
+		for _, phase := range phases {
+			t := st.BuildTokens[phase]
+			display.KeyValue(phase, fmt.Sprintf("%d call(s), %d prompt + %d completion tokens, $%.4f", t.Calls, t.PromptTokens, t.CompletionTokens, t.CostUSD), display.BrightCyan)
+		}
+		display.KeyValue("Cost", fmt.Sprintf("$%.4f", st.BuildCostUSD), display.Bold+display.BrightGreen)
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
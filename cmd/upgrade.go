@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/selfupdate"
+)
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Update kash to the latest release",
+	Long: `Checks GitHub for the latest kash release, downloads the archive for this
+platform, verifies it against the release's SHA-256 checksums, and replaces
+the running binary in place.
+
+Release builds also verify the Ed25519 signature of checksums.txt and refuse
+unsigned releases. Set GITHUB_TOKEN to avoid GitHub's API rate limit.
+
+--check only reports whether an update is available, for CI:
+
+  kash upgrade --check --json | jq -e '.update_available == false'`,
+	Args: cobra.NoArgs,
+	RunE: runUpgrade,
+}
+
+var (
+	upgradeCheck bool
+	upgradeForce bool
+)
+
+// upgradeResult is what 'kash upgrade --json' prints.
+type upgradeResult struct {
+	Current         string `json:"current"`
+	Latest          string `json:"latest"`
+	UpdateAvailable bool   `json:"update_available"`
+	Upgraded        bool   `json:"upgraded"`
+	Path            string `json:"path,omitempty"`
+	ReleaseURL      string `json:"release_url,omitempty"`
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeCheck, "check", false, "Only report whether an update is available")
+	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Install the latest release even if it is not newer, or over a development build")
+	rootCmd.AddCommand(upgradeCmd)
+}
+
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	pub, err := selfupdate.ParsePublicKey(releasePublicKey)
+	if err != nil {
+		return err
+	}
+	u := &selfupdate.Updater{Token: os.Getenv("GITHUB_TOKEN"), PublicKey: pub}
+	ctx := context.Background()
+
+	display.Header("⬆️  Kash Upgrade")
+	display.Newline()
+
+	rel, err := u.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	res := upgradeResult{
+		Current:         version,
+		Latest:          rel.Tag,
+		UpdateAvailable: selfupdate.Newer(rel.Tag, version),
+		ReleaseURL:      rel.URL,
+	}
+	display.KeyValue("Current", version, display.BrightYellow)
+	display.KeyValue("Latest", rel.Tag, display.BrightGreen)
+	display.Newline()
+
+	if upgradeCheck || (!res.UpdateAvailable && !upgradeForce) {
+		switch {
+		case res.UpdateAvailable:
+			display.Info(fmt.Sprintf("kash %s is available: run 'kash upgrade' (%s)", rel.Tag, rel.URL))
+		default:
+			display.Success("kash is up to date")
+		}
+		return printUpgradeResult(res)
+	}
+	if version == "dev" && !upgradeForce {
+		return fmt.Errorf("this is a development build; pass --force to replace it with %s", rel.Tag)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate kash binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("locate kash binary: %w", err)
+	}
+
+	display.Step(1, 2, "Downloading "+u.AssetName()+"...")
+	binary, err := u.Download(ctx, rel)
+	if err != nil {
+		return err
+	}
+	if pub != nil {
+		display.StepResult("Verified", "checksum signature and SHA-256 checksum")
+	} else {
+		display.StepResult("Verified", "SHA-256 checksum")
+		display.StepDetail("This build has no release public key, so the checksum signature was not checked")
+	}
+
+	display.Step(2, 2, "Replacing "+exe+"...")
+	if err := selfupdate.Replace(exe, binary); err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return fmt.Errorf("%w (run again with sudo, or reinstall kash to a directory you can write)", err)
+		}
+		return err
+	}
+	res.Upgraded, res.Path = true, exe
+
+	display.Newline()
+	display.Success(fmt.Sprintf("Upgraded kash %s → %s", version, rel.Tag))
+	return printUpgradeResult(res)
+}
+
+func printUpgradeResult(res upgradeResult) error {
+	if jsonOutput {
+		return printJSON(res)
+	}
+	return nil
+}
@@ -12,6 +12,9 @@ var (
 	version   = "dev"
 	commit    = "none"
 	buildDate = "unknown"
+	// releasePublicKey is the base64 DER Ed25519 key 'kash upgrade' checks
+	// release checksum signatures with; empty in development builds
+	releasePublicKey = ""
 )
 
 var versionCmd = &cobra.Command{
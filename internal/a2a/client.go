@@ -0,0 +1,321 @@
+// Package a2a is an outbound Agent-to-Agent client. It lets an agent delegate
+// a question to peer agents — other Kash agents through their agent.query and
+// agent.search methods, or any A2A-compliant agent through message/send —
+// and use their answers as extra context. It also keeps the tasks an agent
+// runs in the background and pushes their updates to callers.
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DepthHeader carries how many agents a request has already passed through.
+// Servers stop delegating once it reaches MaxDepth, so peers that list each
+// other cannot loop.
+const DepthHeader = "X-Kash-Peer-Depth"
+
+// MaxDepth is the number of hops after which peers are no longer consulted.
+const MaxDepth = 2
+
+// Protocols a peer can speak.
+const (
+	ProtocolKash = "kash" // agent.query / agent.search on /rpc/agent
+	ProtocolA2A  = "a2a"  // A2A message/send
+)
+
+// Modes for Kash peers.
+const (
+	ModeQuery  = "query"  // ask the peer's LLM for an answer
+	ModeSearch = "search" // fetch the peer's raw retrieval results
+)
+
+// Peer is an entry of the peers: list in agent.yaml.
+type Peer struct {
+	Name string `yaml:"name"`
+	// URL is the peer's base URL (e.g. http://billing:8000) or the full
+	// JSON-RPC endpoint
+	URL string `yaml:"url"`
+	// Description says what the peer knows; it labels the peer's answers
+	Description string `yaml:"description"`
+	// Protocol is "kash" (default) or "a2a"
+	Protocol string `yaml:"protocol"`
+	// Mode is "query" (default) or "search"; only used with Kash peers
+	Mode string `yaml:"mode"`
+	// APIKeyEnv names the environment variable holding the peer's API key
+	APIKeyEnv string `yaml:"api_key_env"`
+	// Timeout bounds each call, e.g. "20s" (default 30s)
+	Timeout string `yaml:"timeout"`
+	// TopK is the number of results in search mode (default 5)
+	TopK int `yaml:"top_k"`
+}
+
+// Answer is what a peer returned for a question.
+type Answer struct {
+	Peer    string
+	Content string
+	Err     error
+	Took    time.Duration
+}
+
+// Client calls one peer.
+type Client struct {
+	peer     Peer
+	endpoint string
+	apiKey   string
+	timeout  time.Duration
+	http     *http.Client
+}
+
+// NewClient validates a peer definition and returns a client for it.
+func NewClient(p Peer) (*Client, error) {
+	if p.Name == "" {
+		return nil, errors.New("peer name is required")
+	}
+	if p.URL == "" {
+		return nil, fmt.Errorf("peer %q: url is required", p.Name)
+	}
+	switch p.Protocol {
+	case "":
+		p.Protocol = ProtocolKash
+	case ProtocolKash, ProtocolA2A:
+	default:
+		return nil, fmt.Errorf("peer %q: unknown protocol %q (use kash or a2a)", p.Name, p.Protocol)
+	}
+	switch p.Mode {
+	case "":
+		p.Mode = ModeQuery
+	case ModeQuery, ModeSearch:
+	default:
+		return nil, fmt.Errorf("peer %q: unknown mode %q (use query or search)", p.Name, p.Mode)
+	}
+	if p.TopK <= 0 {
+		p.TopK = 5
+	}
+	timeout := 30 * time.Second
+	if p.Timeout != "" {
+		d, err := time.ParseDuration(p.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("peer %q: invalid timeout %q", p.Name, p.Timeout)
+		}
+		timeout = d
+	}
+
+	endpoint := strings.TrimSuffix(p.URL, "/")
+	if p.Protocol == ProtocolKash && !strings.HasSuffix(endpoint, "/rpc/agent") {
+		endpoint += "/rpc/agent"
+	}
+
+	c := &Client{
+		peer:     p,
+		endpoint: endpoint,
+		timeout:  timeout,
+		http:     &http.Client{},
+	}
+	if p.APIKeyEnv != "" {
+		c.apiKey = os.Getenv(p.APIKeyEnv)
+	}
+	return c, nil
+}
+
+// Peer returns the peer definition with defaults applied.
+func (c *Client) Peer() Peer {
+	return c.peer
+}
+
+// Ask sends question to the peer and returns its answer as text.
+func (c *Client) Ask(ctx context.Context, question string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if c.peer.Protocol == ProtocolA2A {
+		return c.askA2A(ctx, question)
+	}
+	if c.peer.Mode == ModeSearch {
+		return c.search(ctx, question)
+	}
+	var result struct {
+		Answer string `json:"answer"`
+	}
+	if err := c.call(ctx, "agent.query", map[string]interface{}{"query": question}, &result); err != nil {
+		return "", err
+	}
+	return result.Answer, nil
+}
+
+func (c *Client) search(ctx context.Context, question string) (string, error) {
+	var result struct {
+		VectorResults []struct {
+			Content string `json:"content"`
+			Source  string `json:"source"`
+		} `json:"vector_results"`
+	}
+	params := map[string]interface{}{"query": question, "top_k": c.peer.TopK}
+	if err := c.call(ctx, "agent.search", params, &result); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, r := range result.VectorResults {
+		fmt.Fprintf(&sb, "(%s) %s\n\n", r.Source, strings.TrimSpace(r.Content))
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// askA2A sends an A2A message/send request. The reply is either a Message or
+// a Task, whose text lives in its status message or artifacts.
+func (c *Client) askA2A(ctx context.Context, question string) (string, error) {
+	params := map[string]interface{}{
+		"message": map[string]interface{}{
+			"role":      "user",
+			"kind":      "message",
+			"messageId": strconv.FormatInt(time.Now().UnixNano(), 10),
+			"parts":     []map[string]string{{"kind": "text", "text": question}},
+		},
+	}
+	type part struct {
+		Kind string `json:"kind"`
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	var result struct {
+		Parts  []part `json:"parts"`
+		Status struct {
+			Message struct {
+				Parts []part `json:"parts"`
+			} `json:"message"`
+		} `json:"status"`
+		Artifacts []struct {
+			Parts []part `json:"parts"`
+		} `json:"artifacts"`
+	}
+	if err := c.call(ctx, "message/send", params, &result); err != nil {
+		return "", err
+	}
+
+	var texts []string
+	collect := func(parts []part) {
+		for _, p := range parts {
+			if p.Text != "" {
+				texts = append(texts, p.Text)
+			}
+		}
+	}
+	collect(result.Parts)
+	for _, a := range result.Artifacts {
+		collect(a.Parts)
+	}
+	if len(texts) == 0 {
+		collect(result.Status.Message.Parts)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// call makes one JSON-RPC 2.0 request and decodes its result into out.
+func (c *Client) call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal %s request: %w", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(DepthHeader, strconv.Itoa(Depth(ctx)+1))
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request: %w", method, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read %s response: %w", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var rpc struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &rpc); err != nil {
+		return fmt.Errorf("decode %s response: %w", method, err)
+	}
+	if rpc.Error != nil {
+		return fmt.Errorf("peer error %d: %s", rpc.Error.Code, rpc.Error.Message)
+	}
+	if err := json.Unmarshal(rpc.Result, out); err != nil {
+		return fmt.Errorf("decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// AskAll asks every peer concurrently and returns their answers in peer order.
+// Failed peers are reported through Answer.Err; they never fail the others.
+func AskAll(ctx context.Context, clients []*Client, question string) []Answer {
+	answers := make([]Answer, len(clients))
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// A panicking peer call fails that peer only
+			defer func() {
+				if p := recover(); p != nil {
+					answers[i] = Answer{Peer: c.peer.Name, Err: fmt.Errorf("ask peer panicked: %v", p)}
+				}
+			}()
+			start := time.Now()
+			content, err := c.Ask(ctx, question)
+			answers[i] = Answer{Peer: c.peer.Name, Content: content, Err: err, Took: time.Since(start)}
+		}()
+	}
+	wg.Wait()
+	return answers
+}
+
+type depthKey struct{}
+
+// WithDepth records the hop count of an incoming request in ctx.
+func WithDepth(ctx context.Context, depth int) context.Context {
+	return context.WithValue(ctx, depthKey{}, depth)
+}
+
+// Depth returns the hop count recorded by WithDepth, or 0.
+func Depth(ctx context.Context) int {
+	d, _ := ctx.Value(depthKey{}).(int)
+	return d
+}
+
+// ParseDepth reads the DepthHeader value of an incoming request.
+func ParseDepth(h http.Header) int {
+	d, err := strconv.Atoi(h.Get(DepthHeader))
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,113 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientAsk(t *testing.T) {
+	var gotPath, gotAuth, gotDepth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth, gotDepth = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get(DepthHeader)
+		var req struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var result interface{}
+		switch req.Method {
+		case "agent.query":
+			result = map[string]string{"answer": "Refunds take 14 days."}
+		case "agent.search":
+			result = map[string]interface{}{"vector_results": []map[string]string{
+				{"content": "Refunds take 14 days.", "source": "faq.md"},
+			}}
+		case "message/send":
+			result = map[string]interface{}{
+				"kind":      "task",
+				"status":    map[string]interface{}{"state": "completed"},
+				"artifacts": []map[string]interface{}{{"parts": []map[string]string{{"kind": "text", "text": "Invoices are monthly."}}}},
+			}
+		default:
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": -32601, "message": "method not found"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+	}))
+	defer srv.Close()
+	t.Setenv("PEER_KEY", "secret")
+
+	tests := []struct {
+		name     string
+		peer     Peer
+		wantPath string
+		want     string
+	}{
+		{"kash query", Peer{Name: "billing", URL: srv.URL}, "/rpc/agent", "Refunds take 14 days."},
+		{"kash search", Peer{Name: "billing", URL: srv.URL + "/rpc/agent", Mode: ModeSearch}, "/rpc/agent", "(faq.md) Refunds take 14 days."},
+		{"a2a task", Peer{Name: "invoices", URL: srv.URL + "/a2a", Protocol: ProtocolA2A}, "/a2a", "Invoices are monthly."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.peer.APIKeyEnv = "PEER_KEY"
+			c, err := NewClient(tt.peer)
+			require.NoError(t, err)
+			got, err := c.Ask(WithDepth(context.Background(), 1), "How long do refunds take?")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantPath, gotPath)
+			assert.Equal(t, "Bearer secret", gotAuth)
+			assert.Equal(t, "2", gotDepth)
+		})
+	}
+}
+
+func TestNewClientValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		peer Peer
+		want string
+	}{
+		{"missing name", Peer{URL: "http://x"}, "peer name is required"},
+		{"missing url", Peer{Name: "a"}, `peer "a": url is required`},
+		{"bad protocol", Peer{Name: "a", URL: "http://x", Protocol: "grpc"}, "unknown protocol"},
+		{"bad mode", Peer{Name: "a", URL: "http://x", Mode: "chat"}, "unknown mode"},
+		{"bad timeout", Peer{Name: "a", URL: "http://x", Timeout: "soon"}, "invalid timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.peer)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestAskAllReportsFailures(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"answer":"yes"}}`))
+	}))
+	defer ok.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	a, err := NewClient(Peer{Name: "a", URL: ok.URL})
+	require.NoError(t, err)
+	b, err := NewClient(Peer{Name: "b", URL: down.URL})
+	require.NoError(t, err)
+
+	answers := AskAll(context.Background(), []*Client{a, b}, "q")
+	require.Len(t, answers, 2)
+	assert.Equal(t, "yes", answers[0].Content)
+	assert.NoError(t, answers[0].Err)
+	assert.Equal(t, "b", answers[1].Peer)
+	assert.ErrorContains(t, answers[1].Err, "status 503")
+}
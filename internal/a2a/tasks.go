@@ -0,0 +1,255 @@
+package a2a
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akashicode/kash/internal/webhook"
+)
+
+// Task states, as in the A2A protocol.
+const (
+	TaskSubmitted = "submitted"
+	TaskWorking   = "working"
+	TaskCompleted = "completed"
+	TaskFailed    = "failed"
+)
+
+// TokenHeader carries a PushConfig's token in every update sent to it.
+const TokenHeader = "X-A2A-Notification-Token"
+
+// maxTasks bounds the tasks kept for lookups; past it, the oldest ended
+// tasks are dropped.
+const maxTasks = 1000
+
+// DefaultMaxRunning is the most tasks running at once when PushOptions sets
+// no max_running.
+const DefaultMaxRunning = 16
+
+// TaskTimeout bounds the query a task runs, which no caller waits on.
+const TaskTimeout = 5 * time.Minute
+
+// ErrTooManyTasks is returned by Start while PushOptions.MaxRunning tasks
+// are running.
+var ErrTooManyTasks = errors.New("too many tasks running — retry later")
+
+// PushOptions is the a2a.push_notifications block of agent.yaml.
+type PushOptions struct {
+	Enabled bool `yaml:"enabled"`
+	// AllowedHosts limits callback URLs to these hosts; "*.example.com"
+	// allows the subdomains of example.com (default: any host)
+	AllowedHosts []string `yaml:"allowed_hosts"`
+	// AllowPrivate lets callbacks reach loopback and private addresses,
+	// for receivers on an internal network; link-local addresses are
+	// refused regardless
+	AllowPrivate bool `yaml:"allow_private"`
+	// MaxRunning is the most tasks running at once (default:
+	// DefaultMaxRunning)
+	MaxRunning int `yaml:"max_running"`
+}
+
+// PushConfig is where a caller wants a task's updates POSTed.
+type PushConfig struct {
+	URL string `json:"url"`
+	// Token is sent back in TokenHeader, so the receiver can check that an
+	// update is for a task it started
+	Token string `json:"token,omitempty"`
+}
+
+// Task is a query run in the background, in the shape of an A2A task.
+type Task struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind"`
+	Status    TaskStatus `json:"status"`
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+}
+
+// TaskStatus is the state of a task and what it is doing.
+type TaskStatus struct {
+	State   string    `json:"state"`
+	Message string    `json:"message,omitempty"`
+	Time    time.Time `json:"timestamp"`
+}
+
+// Artifact is a result of a task.
+type Artifact struct {
+	Name  string `json:"name"`
+	Parts []Part `json:"parts"`
+}
+
+// Part is a text or data part of an artifact.
+type Part struct {
+	Kind string      `json:"kind"`
+	Text string      `json:"text,omitempty"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Tasks keeps the background tasks of an agent and POSTs their updates to
+// the callbacks registered for them.
+type Tasks struct {
+	opts PushOptions
+	// post delivers an update; webhook.Post unless a test replaces it
+	post func(ctx context.Context, url, typ string, header http.Header, body []byte) error
+
+	mu    sync.Mutex
+	tasks map[string]*task
+	order []string
+}
+
+type task struct {
+	Task
+	// owner is the audit key id of the caller; only it may look the task up
+	owner string
+	push  PushConfig
+}
+
+// NewTasks returns the task store for opts, or nil when push notifications
+// are disabled.
+func NewTasks(opts PushOptions) *Tasks {
+	if !opts.Enabled {
+		return nil
+	}
+	return &Tasks{opts: opts, post: webhook.Poster(opts.AllowPrivate), tasks: map[string]*task{}}
+}
+
+// Start registers a submitted task for owner whose updates go to push. It
+// fails when the callback URL is invalid or its host is not allowed, and
+// with ErrTooManyTasks while MaxRunning tasks have not ended.
+func (t *Tasks) Start(owner string, push PushConfig) (Task, error) {
+	if err := t.checkURL(push.URL); err != nil {
+		return Task{}, err
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return Task{}, fmt.Errorf("generate task id: %w", err)
+	}
+	tk := &task{
+		Task:  Task{ID: hex.EncodeToString(b), Kind: "task", Status: TaskStatus{State: TaskSubmitted, Time: time.Now().UTC()}},
+		owner: owner,
+		push:  push,
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.runningLocked() >= t.maxRunning() {
+		return Task{}, ErrTooManyTasks
+	}
+	t.tasks[tk.ID] = tk
+	t.order = append(t.order, tk.ID)
+	t.evictLocked()
+	return tk.Task, nil
+}
+
+// evictLocked drops the oldest ended tasks while more than maxTasks are
+// kept.
+func (t *Tasks) evictLocked() {
+	for i := 0; len(t.tasks) > maxTasks && i < len(t.order); {
+		id := t.order[i]
+		if tk := t.tasks[id]; tk != nil && !ended(tk.Status.State) {
+			i++
+			continue
+		}
+		delete(t.tasks, id)
+		t.order = append(t.order[:i], t.order[i+1:]...)
+	}
+}
+
+func (t *Tasks) runningLocked() int {
+	n := 0
+	for _, tk := range t.tasks {
+		if !ended(tk.Status.State) {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *Tasks) maxRunning() int {
+	if t.opts.MaxRunning > 0 {
+		return t.opts.MaxRunning
+	}
+	return DefaultMaxRunning
+}
+
+func ended(state string) bool {
+	return state == TaskCompleted || state == TaskFailed
+}
+
+// checkURL reports whether u may receive push notifications. A host given
+// as an address is checked here as well as when the update is sent, so the
+// caller learns at once.
+func (t *Tasks) checkURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid push notification url %q", u)
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if err := webhook.CheckAddr(addr, t.opts.AllowPrivate); err != nil {
+			return fmt.Errorf("push notification host %q is not allowed", host)
+		}
+	} else if !t.opts.AllowPrivate && (host == "localhost" || strings.HasSuffix(host, ".localhost")) {
+		return fmt.Errorf("push notification host %q is not allowed", host)
+	}
+	if len(t.opts.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, allowed := range t.opts.AllowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+			return nil
+		}
+	}
+	return fmt.Errorf("push notification host %q is not allowed", host)
+}
+
+// Update sets the status of task id, and its artifacts when given, and POSTs
+// the task to its callback. It returns the error of the delivery.
+func (t *Tasks) Update(ctx context.Context, id, state, message string, artifacts []Artifact) error {
+	t.mu.Lock()
+	tk, ok := t.tasks[id]
+	if !ok {
+		t.mu.Unlock()
+		return fmt.Errorf("task %s not found", id)
+	}
+	tk.Status = TaskStatus{State: state, Message: message, Time: time.Now().UTC()}
+	if artifacts != nil {
+		tk.Artifacts = artifacts
+	}
+	snapshot, push := tk.Task, tk.push
+	t.mu.Unlock()
+
+	body, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("marshal task: %w", err)
+	}
+	header := http.Header{}
+	if push.Token != "" {
+		header.Set(TokenHeader, push.Token)
+	}
+	if err := t.post(ctx, push.URL, "task."+state, header, body); err != nil {
+		return fmt.Errorf("push task %s update: %w", id, err)
+	}
+	return nil
+}
+
+// Get returns task id if owner started it.
+func (t *Tasks) Get(owner, id string) (Task, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tk, ok := t.tasks[id]
+	if !ok || tk.owner != owner {
+		return Task{}, false
+	}
+	return tk.Task, true
+}
@@ -0,0 +1,91 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTasks(t *testing.T) {
+	type pushed struct {
+		url, typ, token string
+		task            Task
+	}
+	var got []pushed
+	tasks := NewTasks(PushOptions{Enabled: true, AllowedHosts: []string{"orchestrator", "*.example.com"}})
+	tasks.post = func(_ context.Context, url, typ string, header http.Header, body []byte) error {
+		var tk Task
+		require.NoError(t, json.Unmarshal(body, &tk))
+		got = append(got, pushed{url, typ, header.Get(TokenHeader), tk})
+		return nil
+	}
+
+	tk, err := tasks.Start("key1", PushConfig{URL: "https://hooks.example.com/a2a", Token: "t0k"})
+	require.NoError(t, err)
+	assert.Equal(t, TaskSubmitted, tk.Status.State)
+
+	ctx := context.Background()
+	require.NoError(t, tasks.Update(ctx, tk.ID, TaskWorking, "searching", nil))
+	answer := []Artifact{{Name: "answer", Parts: []Part{{Kind: "text", Text: "Refunds take 14 days."}}}}
+	require.NoError(t, tasks.Update(ctx, tk.ID, TaskCompleted, "", answer))
+
+	require.Len(t, got, 2)
+	assert.Equal(t, pushed{"https://hooks.example.com/a2a", "task.working", "t0k", got[0].task}, got[0])
+	assert.Equal(t, "searching", got[0].task.Status.Message)
+	assert.Equal(t, TaskCompleted, got[1].task.Status.State)
+	assert.Equal(t, answer, got[1].task.Artifacts)
+
+	stored, ok := tasks.Get("key1", tk.ID)
+	require.True(t, ok)
+	assert.Equal(t, TaskCompleted, stored.Status.State)
+	_, ok = tasks.Get("key2", tk.ID)
+	assert.False(t, ok, "only the caller that started a task sees it")
+
+	tasks.post = func(context.Context, string, string, http.Header, []byte) error { return errors.New("returned 500") }
+	assert.Error(t, tasks.Update(ctx, tk.ID, TaskFailed, "", nil))
+	assert.Error(t, tasks.Update(ctx, "missing", TaskWorking, "", nil))
+
+	for _, u := range []string{"ftp://orchestrator/x", "http://", "http://example.com.evil.io/", "http://internal/"} {
+		_, err := tasks.Start("key1", PushConfig{URL: u})
+		assert.Error(t, err, u)
+	}
+	_, err = tasks.Start("key1", PushConfig{URL: "http://orchestrator:9000/updates"})
+	assert.NoError(t, err)
+
+	assert.Nil(t, NewTasks(PushOptions{}), "push notifications are off by default")
+}
+
+func TestTasksRefusePrivateCallbacks(t *testing.T) {
+	tasks := NewTasks(PushOptions{Enabled: true})
+	for _, u := range []string{"http://127.0.0.1:8080/", "http://localhost/", "http://[::1]/", "http://10.0.0.5/", "http://169.254.169.254/latest/meta-data/"} {
+		_, err := tasks.Start("key1", PushConfig{URL: u})
+		assert.ErrorContains(t, err, "not allowed", u)
+	}
+	_, err := tasks.Start("key1", PushConfig{URL: "https://hooks.example.com/a2a"})
+	assert.NoError(t, err)
+
+	tasks = NewTasks(PushOptions{Enabled: true, AllowPrivate: true})
+	_, err = tasks.Start("key1", PushConfig{URL: "http://127.0.0.1:8080/"})
+	assert.NoError(t, err, "allow_private admits loopback callbacks")
+	_, err = tasks.Start("key1", PushConfig{URL: "http://169.254.169.254/"})
+	assert.Error(t, err, "but never link-local ones")
+}
+
+func TestTasksMaxRunning(t *testing.T) {
+	tasks := NewTasks(PushOptions{Enabled: true, MaxRunning: 1})
+	tasks.post = func(context.Context, string, string, http.Header, []byte) error { return nil }
+
+	first, err := tasks.Start("key1", PushConfig{URL: "https://hooks.example.com/a2a"})
+	require.NoError(t, err)
+	_, err = tasks.Start("key2", PushConfig{URL: "https://hooks.example.com/a2a"})
+	assert.ErrorIs(t, err, ErrTooManyTasks)
+
+	require.NoError(t, tasks.Update(context.Background(), first.ID, TaskCompleted, "", nil))
+	_, err = tasks.Start("key2", PushConfig{URL: "https://hooks.example.com/a2a"})
+	assert.NoError(t, err, "an ended task frees its slot")
+}
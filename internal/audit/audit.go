@@ -0,0 +1,189 @@
+// Package audit writes an append-only JSONL log of the queries an agent
+// answers: who asked, what they asked, which sources were retrieved, and how
+// long the response was.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/akashicode/kash/internal/moderation"
+)
+
+// Query logging modes.
+const (
+	QueriesFull = "full" // log the query text
+	QueriesHash = "hash" // log a SHA-256 of the query instead
+	QueriesNone = "none" // leave the query out
+)
+
+// Options is the audit: block of agent.yaml.
+type Options struct {
+	// Path is the log file; the audit log is off when it is empty
+	Path string `yaml:"path"`
+	// Queries is "full" (default), "hash", or "none"
+	Queries string `yaml:"queries"`
+	// Responses also logs the response text, not just its length
+	Responses bool `yaml:"responses"`
+	// MaxSizeMB rotates the file when it would grow past this size (default 100)
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// MaxBackups is the number of rotated files kept, path.1 being the newest
+	// (default 5)
+	MaxBackups int `yaml:"max_backups"`
+}
+
+// Entry is one audited request.
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Agent     string    `json:"agent,omitempty"`
+	KeyID     string    `json:"key_id,omitempty"`
+	Remote    string    `json:"remote,omitempty"`
+	Endpoint  string    `json:"endpoint"`
+	Query     string    `json:"query,omitempty"`
+	QueryHash string    `json:"query_sha256,omitempty"`
+	// Filter is the metadata filter the query was restricted by
+	Filter         map[string]string `json:"filter,omitempty"`
+	Sources        []string          `json:"sources"`
+	ResponseLength int               `json:"response_length"`
+	Response       string            `json:"response,omitempty"`
+	Status         int               `json:"status"`
+	DurationMS     int64             `json:"duration_ms"`
+	// Moderation lists what moderation flagged in the query or response
+	Moderation []moderation.Verdict `json:"moderation,omitempty"`
+}
+
+// Logger appends entries to the audit log and rotates it by size. It is safe
+// for concurrent use.
+type Logger struct {
+	opts Options
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// New opens (or creates) the audit log described by opts.
+func New(opts Options) (*Logger, error) {
+	if opts.Path == "" {
+		return nil, fmt.Errorf("audit log path is required")
+	}
+	switch opts.Queries {
+	case "":
+		opts.Queries = QueriesFull
+	case QueriesFull, QueriesHash, QueriesNone:
+	default:
+		return nil, fmt.Errorf("unknown audit queries mode %q (use full, hash, or none)", opts.Queries)
+	}
+	if opts.MaxSizeMB <= 0 {
+		opts.MaxSizeMB = 100
+	}
+	if opts.MaxBackups <= 0 {
+		opts.MaxBackups = 5
+	}
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0755); err != nil {
+		return nil, fmt.Errorf("create audit log directory: %w", err)
+	}
+
+	l := &Logger{opts: opts}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path returns the file the logger writes to.
+func (l *Logger) Path() string {
+	return l.opts.Path
+}
+
+// Log redacts e according to the options and appends it.
+func (l *Logger) Log(e Entry) error {
+	switch l.opts.Queries {
+	case QueriesHash:
+		if e.Query != "" {
+			sum := sha256.Sum256([]byte(e.Query))
+			e.QueryHash = hex.EncodeToString(sum[:])
+		}
+		e.Query = ""
+	case QueriesNone:
+		e.Query = ""
+	}
+	if !l.opts.Responses {
+		e.Response = ""
+	}
+	if e.Sources == nil {
+		e.Sources = []string{}
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.size > 0 && l.size+int64(len(line)) > int64(l.opts.MaxSizeMB)<<20 {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.f.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+// Close closes the log file.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+func (l *Logger) open() error {
+	f, err := os.OpenFile(l.opts.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat audit log: %w", err)
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+// rotate shifts path.N to path.N+1, dropping the oldest, moves the current
+// file to path.1, and starts a new one.
+func (l *Logger) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return fmt.Errorf("close audit log: %w", err)
+	}
+	for i := l.opts.MaxBackups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", l.opts.Path, i)
+		if _, err := os.Stat(from); err == nil {
+			os.Rename(from, fmt.Sprintf("%s.%d", l.opts.Path, i+1))
+		}
+	}
+	if err := os.Rename(l.opts.Path, l.opts.Path+".1"); err != nil {
+		return fmt.Errorf("rotate audit log: %w", err)
+	}
+	return l.open()
+}
+
+// KeyID identifies an API key in the log without revealing it.
+func KeyID(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return "key_" + hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	var entries []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 4<<20)
+	for sc.Scan() {
+		var e Entry
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &e))
+		entries = append(entries, e)
+	}
+	require.NoError(t, sc.Err())
+	return entries
+}
+
+func TestLogRedaction(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         Options
+		wantQuery    string
+		wantHash     bool
+		wantResponse string
+	}{
+		{"full", Options{}, "what is the refund policy?", false, ""},
+		{"hash", Options{Queries: QueriesHash}, "", true, ""},
+		{"none with responses", Options{Queries: QueriesNone, Responses: true}, "", false, "14 days."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Path = filepath.Join(t.TempDir(), "audit", "queries.jsonl")
+			l, err := New(tt.opts)
+			require.NoError(t, err)
+			require.NoError(t, l.Log(Entry{
+				Endpoint:       "/v1/chat/completions",
+				KeyID:          KeyID("secret"),
+				Query:          "what is the refund policy?",
+				Sources:        []string{"faq.md"},
+				Response:       "14 days.",
+				ResponseLength: 8,
+			}))
+			require.NoError(t, l.Close())
+
+			entries := readEntries(t, tt.opts.Path)
+			require.Len(t, entries, 1)
+			e := entries[0]
+			assert.Equal(t, tt.wantQuery, e.Query)
+			assert.Equal(t, tt.wantHash, e.QueryHash != "")
+			assert.Equal(t, tt.wantResponse, e.Response)
+			assert.Equal(t, 8, e.ResponseLength)
+			assert.Equal(t, []string{"faq.md"}, e.Sources)
+			assert.True(t, strings.HasPrefix(e.KeyID, "key_"))
+		})
+	}
+}
+
+func TestLogRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := New(Options{Path: path, Responses: true, MaxSizeMB: 1, MaxBackups: 2})
+	require.NoError(t, err)
+	big := strings.Repeat("x", 600<<10)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, l.Log(Entry{Endpoint: "/v1/retrieve", Response: big}))
+	}
+	require.NoError(t, l.Close())
+
+	assert.Len(t, readEntries(t, path), 1)
+	assert.Len(t, readEntries(t, path+".1"), 1)
+	assert.Len(t, readEntries(t, path+".2"), 1)
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestNewRejectsUnknownMode(t *testing.T) {
+	_, err := New(Options{Path: filepath.Join(t.TempDir(), "a.jsonl"), Queries: "partial"})
+	assert.ErrorContains(t, err, "unknown audit queries mode")
+}
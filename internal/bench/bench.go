@@ -0,0 +1,123 @@
+// Package bench measures operation latency and throughput under concurrency.
+package bench
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Op is one timed operation, run with a query from the workload.
+type Op func(ctx context.Context, query string) error
+
+// Result summarizes one benchmark run.
+type Result struct {
+	Name        string        `json:"name"`
+	Concurrency int           `json:"concurrency"`
+	Requests    int           `json:"requests"`
+	Errors      int           `json:"errors"`
+	Mean        time.Duration `json:"mean_ns"`
+	P50         time.Duration `json:"p50_ns"`
+	P95         time.Duration `json:"p95_ns"`
+	P99         time.Duration `json:"p99_ns"`
+	Max         time.Duration `json:"max_ns"`
+	// Throughput is successful operations per second of wall-clock time
+	Throughput float64 `json:"throughput_per_sec"`
+	// FirstError is the first failure seen, to explain a nonzero Errors
+	FirstError string `json:"first_error,omitempty"`
+}
+
+// Run executes op requests times across concurrency workers, cycling
+// through queries, and reports latency percentiles over successful calls.
+// It stops early when ctx is cancelled.
+func Run(ctx context.Context, name string, requests, concurrency int, queries []string, op Op) Result {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	res := Result{Name: name, Concurrency: concurrency}
+	if requests <= 0 || len(queries) == 0 {
+		return res
+	}
+
+	jobs := make(chan string)
+	var mu sync.Mutex
+	latencies := make([]time.Duration, 0, requests)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for q := range jobs {
+				t := time.Now()
+				err := op(ctx, q)
+				d := time.Since(t)
+
+				mu.Lock()
+				res.Requests++
+				if err != nil {
+					res.Errors++
+					if res.FirstError == "" {
+						res.FirstError = err.Error()
+					}
+				} else {
+					latencies = append(latencies, d)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < requests; i++ {
+		select {
+		case jobs <- queries[i%len(queries)]:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	summarize(&res, latencies, elapsed)
+	return res
+}
+
+func summarize(res *Result, latencies []time.Duration, elapsed time.Duration) {
+	if len(latencies) == 0 {
+		return
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, d := range latencies {
+		total += d
+	}
+	res.Mean = total / time.Duration(len(latencies))
+	res.P50 = Percentile(latencies, 50)
+	res.P95 = Percentile(latencies, 95)
+	res.P99 = Percentile(latencies, 99)
+	res.Max = latencies[len(latencies)-1]
+	if elapsed > 0 {
+		res.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+}
+
+// Percentile returns the p-th percentile (0-100) of sorted latencies using
+// the nearest-rank method.
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,64 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	ms := func(v ...int) []time.Duration {
+		out := make([]time.Duration, len(v))
+		for i, n := range v {
+			out[i] = time.Duration(n) * time.Millisecond
+		}
+		return out
+	}
+	hundred := make([]int, 100)
+	for i := range hundred {
+		hundred[i] = i + 1
+	}
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{name: "empty", p: 50, want: 0},
+		{name: "single", sorted: ms(7), p: 99, want: 7 * time.Millisecond},
+		{name: "p50 of 100", sorted: ms(hundred...), p: 50, want: 50 * time.Millisecond},
+		{name: "p95 of 100", sorted: ms(hundred...), p: 95, want: 95 * time.Millisecond},
+		{name: "p99 of 10", sorted: ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), p: 99, want: 10 * time.Millisecond},
+		{name: "p0 clamps to first", sorted: ms(3, 4), p: 0, want: 3 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Percentile(tt.sorted, tt.p))
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	var calls atomic.Int32
+	op := func(_ context.Context, q string) error {
+		calls.Add(1)
+		if q == "bad" {
+			return errors.New("boom")
+		}
+		return nil
+	}
+
+	res := Run(context.Background(), "vector", 20, 4, []string{"good", "bad"}, op)
+	assert.Equal(t, int32(20), calls.Load())
+	assert.Equal(t, 20, res.Requests)
+	assert.Equal(t, 10, res.Errors)
+	assert.Equal(t, "boom", res.FirstError)
+	assert.Equal(t, 4, res.Concurrency)
+	assert.Greater(t, res.Throughput, 0.0)
+	assert.LessOrEqual(t, res.P50, res.P99)
+}
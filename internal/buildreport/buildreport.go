@@ -0,0 +1,407 @@
+// Package buildreport records what happened during a 'kash build' — chunk
+// counts per document, skipped files, triple extraction results, LLM token
+// usage, warnings, and stage timings — as JSON for CI to archive and as
+// Markdown for people to review.
+package buildreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akashicode/kash/internal/pii"
+)
+
+// DefaultDir is where 'kash build' writes its report, relative to the project.
+const DefaultDir = ".kash"
+
+// File names of the report inside its directory.
+const (
+	JSONFile     = "build-report.json"
+	MarkdownFile = "build-report.md"
+)
+
+// Report describes one build.
+type Report struct {
+	BuiltAt     time.Time `json:"built_at"`
+	KashVersion string    `json:"kash_version"`
+	// Status is "ok" or "failed"
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+
+	Documents  []Document `json:"documents"`
+	Skipped    []Skipped  `json:"skipped"`
+	Chunks     int        `json:"chunks"`
+	Vectors    int        `json:"vectors"`
+	Triples    int64      `json:"triples"`
+	Extraction Extraction `json:"extraction"`
+	// PII is what the pii block of agent.yaml found; nil when it is off
+	PII      *pii.Summary `json:"pii,omitempty"`
+	Tokens   Tokens       `json:"tokens"`
+	Stages   []Stage      `json:"stages"`
+	Warnings []string     `json:"warnings"`
+
+	// Estimate is what the build was expected to take before it called the
+	// providers; nil when it stopped before that
+	Estimate *Estimate `json:"estimate,omitempty"`
+	// Phases counts the tokens of every provider call by phase (embedding,
+	// extraction, description, ...), including the embeddings Tokens leaves
+	// out
+	Phases map[string]Tokens `json:"phases"`
+	// CostUSD is what the calls cost in US dollars, by the price table;
+	// calls of models without a price add nothing
+	CostUSD float64 `json:"cost_usd"`
+
+	mu sync.Mutex
+}
+
+// Document is one ingested document.
+type Document struct {
+	Name   string `json:"name"`
+	Origin string `json:"origin"`
+	Chunks int    `json:"chunks"`
+	Bytes  int    `json:"bytes"`
+	// LoadMS is how long reading and extracting the file took; zero for
+	// remote documents, which are timed by their source
+	LoadMS int64 `json:"load_ms"`
+}
+
+// Skipped is a file or remote item that was not ingested.
+type Skipped struct {
+	// Path is a file path, URL, or object key
+	Path string `json:"path"`
+	// Origin is where it came from: "data", "crawl", "git", ...
+	Origin string `json:"origin"`
+	Reason string `json:"reason"`
+}
+
+// Extraction summarizes LLM triple extraction, which runs in batches of
+// chunks.
+type Extraction struct {
+	Batches   int `json:"batches"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	// Retries counts failed attempts that were retried
+	Retries int `json:"retries"`
+	// SuccessRate is Succeeded / Batches, or 1 when nothing was extracted
+	SuccessRate float64 `json:"success_rate"`
+	// Triples counts triples extracted by the LLM, excluding structured ones
+	Triples int64 `json:"triples"`
+}
+
+// Tokens counts LLM tokens used by the build.
+type Tokens struct {
+	Calls            int `json:"calls"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+	// Estimated is set when any call's usage was approximated because the
+	// provider did not report it
+	Estimated bool `json:"estimated"`
+	// CostUSD is set in Phases only
+	CostUSD float64 `json:"cost_usd,omitempty"`
+}
+
+// Estimate predicts the provider calls, tokens, cost, and duration of a
+// build. Tokens are approximated from text length.
+type Estimate struct {
+	// EmbedCalls counts the chunks to embed; reused embeddings are left out
+	EmbedCalls  int    `json:"embed_calls"`
+	EmbedTokens int    `json:"embed_tokens"`
+	EmbedModel  string `json:"embed_model,omitempty"`
+	// LLMCalls counts triple extraction batches and the MCP description
+	LLMCalls            int    `json:"llm_calls"`
+	LLMPromptTokens     int    `json:"llm_prompt_tokens"`
+	LLMCompletionTokens int    `json:"llm_completion_tokens"`
+	LLMModel            string `json:"llm_model,omitempty"`
+	// CostUSD is the approximate cost in US dollars of the priced models
+	CostUSD float64 `json:"cost_usd"`
+	// Unpriced lists the models with calls but no price, left out of CostUSD
+	Unpriced   []string `json:"unpriced,omitempty"`
+	DurationMS int64    `json:"duration_ms"`
+}
+
+// Stage is the duration of one build step.
+type Stage struct {
+	Name       string `json:"name"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// New starts a report for a build beginning now.
+func New(version string) *Report {
+	return &Report{
+		BuiltAt:     time.Now().UTC(),
+		KashVersion: version,
+		Status:      "ok",
+		Documents:   []Document{},
+		Skipped:     []Skipped{},
+		Stages:      []Stage{},
+		Warnings:    []string{},
+		Phases:      map[string]Tokens{},
+	}
+}
+
+// Skip records an item that was not ingested. It is safe for concurrent use.
+func (r *Report) Skip(origin, path, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Skipped = append(r.Skipped, Skipped{Path: path, Origin: origin, Reason: reason})
+}
+
+// SkipAll records every entry of a path → reason map, as returned by the
+// remote sources.
+func (r *Report) SkipAll(origin string, skipped map[string]string) {
+	for path, reason := range skipped {
+		r.Skip(origin, path, reason)
+	}
+}
+
+// AddTokens records the usage of one LLM call. It is safe for concurrent use.
+func (r *Report) AddTokens(prompt, completion int, estimated bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Tokens.Calls++
+	r.Tokens.PromptTokens += prompt
+	r.Tokens.CompletionTokens += completion
+	r.Tokens.TotalTokens += prompt + completion
+	r.Tokens.Estimated = r.Tokens.Estimated || estimated
+}
+
+// AddPhase records the usage of one LLM or embedding call in phase, which
+// cost cost US dollars. It is safe for concurrent use.
+func (r *Report) AddPhase(phase string, prompt, completion int, cost float64, estimated bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if phase == "" {
+		phase = "other"
+	}
+	t := r.Phases[phase]
+	t.Calls++
+	t.PromptTokens += prompt
+	t.CompletionTokens += completion
+	t.TotalTokens += prompt + completion
+	t.Estimated = t.Estimated || estimated
+	t.CostUSD += cost
+	r.Phases[phase] = t
+	r.CostUSD += cost
+}
+
+// Stage records that the named step took d.
+func (r *Report) Stage(name string, d time.Duration) {
+	r.Stages = append(r.Stages, Stage{Name: name, DurationMS: d.Milliseconds()})
+}
+
+// StageDuration returns how long the named step took, or 0 when the report
+// does not record it.
+func (r *Report) StageDuration(name string) time.Duration {
+	for _, s := range r.Stages {
+		if s.Name == name {
+			return time.Duration(s.DurationMS) * time.Millisecond
+		}
+	}
+	return 0
+}
+
+// Load reads the JSONFile report in dir.
+func Load(dir string) (*Report, error) {
+	data, err := os.ReadFile(filepath.Join(dir, JSONFile))
+	if err != nil {
+		return nil, fmt.Errorf("read build report: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse build report: %w", err)
+	}
+	return &r, nil
+}
+
+// Fail marks the build as failed with err.
+func (r *Report) Fail(err error) {
+	r.Status = "failed"
+	r.Error = err.Error()
+}
+
+// finish fills in the derived fields and sorts lists for stable output.
+func (r *Report) finish() {
+	e := &r.Extraction
+	e.SuccessRate = 1
+	if e.Batches > 0 {
+		e.SuccessRate = float64(e.Succeeded) / float64(e.Batches)
+	}
+	sort.Slice(r.Skipped, func(i, j int) bool {
+		if r.Skipped[i].Origin != r.Skipped[j].Origin {
+			return r.Skipped[i].Origin < r.Skipped[j].Origin
+		}
+		return r.Skipped[i].Path < r.Skipped[j].Path
+	})
+}
+
+// Save writes the report to dir as JSONFile and MarkdownFile, creating dir
+// if needed.
+func (r *Report) Save(dir string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finish()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create report directory %q: %w", dir, err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal build report: %w", err)
+	}
+	jsonPath := filepath.Join(dir, JSONFile)
+	if err := os.WriteFile(jsonPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write build report %q: %w", jsonPath, err)
+	}
+	mdPath := filepath.Join(dir, MarkdownFile)
+	if err := os.WriteFile(mdPath, []byte(r.markdown()), 0644); err != nil {
+		return fmt.Errorf("write build report %q: %w", mdPath, err)
+	}
+	return nil
+}
+
+// Markdown renders the report as a Markdown document, e.g. for a CI job
+// summary.
+func (r *Report) Markdown() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finish()
+	return r.markdown()
+}
+
+func (r *Report) markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# Kash build report\n\n")
+	sb.WriteString("| | |\n|---|---|\n")
+	status := r.Status
+	if r.Error != "" {
+		status += ": " + r.Error
+	}
+	fmt.Fprintf(&sb, "| Status | %s |\n", cell(status))
+	fmt.Fprintf(&sb, "| Built at | %s |\n", r.BuiltAt.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "| Kash version | %s |\n", cell(r.KashVersion))
+	fmt.Fprintf(&sb, "| Duration | %s |\n", ms(r.DurationMS))
+	fmt.Fprintf(&sb, "| Documents | %d |\n", len(r.Documents))
+	fmt.Fprintf(&sb, "| Skipped | %d |\n", len(r.Skipped))
+	fmt.Fprintf(&sb, "| Chunks | %d |\n", r.Chunks)
+	fmt.Fprintf(&sb, "| Vectors | %d |\n", r.Vectors)
+	fmt.Fprintf(&sb, "| Triples | %d |\n", r.Triples)
+	fmt.Fprintf(&sb, "| Warnings | %d |\n", len(r.Warnings))
+
+	e := r.Extraction
+	sb.WriteString("\n## Triple extraction\n\n")
+	sb.WriteString("| Batches | Succeeded | Failed | Retries | Success rate | Triples |\n|---|---|---|---|---|---|\n")
+	fmt.Fprintf(&sb, "| %d | %d | %d | %d | %.1f%% | %d |\n",
+		e.Batches, e.Succeeded, e.Failed, e.Retries, e.SuccessRate*100, e.Triples)
+
+	if p := r.PII; p != nil {
+		sb.WriteString("\n## Personal data\n\n")
+		fmt.Fprintf(&sb, "Action: %s. %d chunk(s) with personal data, %d left out.\n", p.Action, p.Chunks, p.Blocked)
+		if len(p.Found) > 0 {
+			sb.WriteString("\n| Kind | Matches |\n|---|---|\n")
+			for _, kind := range sortedKeys(p.Found) {
+				fmt.Fprintf(&sb, "| %s | %d |\n", cell(kind), p.Found[kind])
+			}
+			sb.WriteString("\n| Document | Chunks |\n|---|---|\n")
+			for _, doc := range sortedKeys(p.Documents) {
+				fmt.Fprintf(&sb, "| %s | %d |\n", cell(doc), p.Documents[doc])
+			}
+		}
+	}
+
+	t := r.Tokens
+	sb.WriteString("\n## LLM tokens\n\n")
+	sb.WriteString("| Calls | Prompt | Completion | Total |\n|---|---|---|---|\n")
+	fmt.Fprintf(&sb, "| %d | %d | %d | %d |\n", t.Calls, t.PromptTokens, t.CompletionTokens, t.TotalTokens)
+	if t.Estimated {
+		sb.WriteString("\nSome counts are estimated because the provider did not report usage.\n")
+	}
+
+	if len(r.Phases) > 0 {
+		sb.WriteString("\n## Tokens by phase\n\n| Phase | Calls | Prompt | Completion | Cost |\n|---|---|---|---|---|\n")
+		phases := make([]string, 0, len(r.Phases))
+		for phase := range r.Phases {
+			phases = append(phases, phase)
+		}
+		sort.Strings(phases)
+		for _, phase := range phases {
+			p := r.Phases[phase]
+			fmt.Fprintf(&sb, "| %s | %d | %d | %d | $%.4f |\n", cell(phase), p.Calls, p.PromptTokens, p.CompletionTokens, p.CostUSD)
+		}
+		fmt.Fprintf(&sb, "| **Total** | | | | **$%.4f** |\n", r.CostUSD)
+	}
+
+	if e := r.Estimate; e != nil {
+		sb.WriteString("\n## Estimate\n\n")
+		sb.WriteString("| Embedding calls | Embedding tokens | LLM calls | LLM tokens | Cost | Duration |\n|---|---|---|---|---|---|\n")
+		fmt.Fprintf(&sb, "| %d | %d | %d | %d | $%.2f | %s |\n", e.EmbedCalls, e.EmbedTokens, e.LLMCalls,
+			e.LLMPromptTokens+e.LLMCompletionTokens, e.CostUSD, ms(e.DurationMS))
+		if len(e.Unpriced) > 0 {
+			fmt.Fprintf(&sb, "\nThe cost leaves out models without a price: %s.\n", strings.Join(e.Unpriced, ", "))
+		}
+	}
+
+	if len(r.Stages) > 0 {
+		sb.WriteString("\n## Timings\n\n| Stage | Duration |\n|---|---|\n")
+		for _, s := range r.Stages {
+			fmt.Fprintf(&sb, "| %s | %s |\n", cell(s.Name), ms(s.DurationMS))
+		}
+	}
+
+	if len(r.Documents) > 0 {
+		sb.WriteString("\n## Documents\n\n| Document | Origin | Chunks | Bytes | Load |\n|---|---|---|---|---|\n")
+		for _, d := range r.Documents {
+			load := "-"
+			if d.Origin == "data" {
+				load = ms(d.LoadMS)
+			}
+			fmt.Fprintf(&sb, "| %s | %s | %d | %d | %s |\n", cell(d.Name), d.Origin, d.Chunks, d.Bytes, load)
+		}
+	}
+
+	if len(r.Skipped) > 0 {
+		sb.WriteString("\n## Skipped\n\n| Path | Origin | Reason |\n|---|---|---|\n")
+		for _, s := range r.Skipped {
+			fmt.Fprintf(&sb, "| %s | %s | %s |\n", cell(s.Path), s.Origin, cell(s.Reason))
+		}
+	}
+
+	if len(r.Warnings) > 0 {
+		sb.WriteString("\n## Warnings\n\n")
+		for _, w := range r.Warnings {
+			fmt.Fprintf(&sb, "- %s\n", strings.Join(strings.Fields(w), " "))
+		}
+	}
+	return sb.String()
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ms formats a millisecond count as a rounded duration, e.g. "1.5s".
+func ms(n int64) string {
+	d := time.Duration(n) * time.Millisecond
+	if d >= time.Second {
+		return d.Round(100 * time.Millisecond).String()
+	}
+	return d.String()
+}
+
+// cell escapes text for use inside a Markdown table cell.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,61 @@
+package buildreport
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportSave(t *testing.T) {
+	r := New("v1.2.3")
+	r.Documents = append(r.Documents, Document{Name: "guide.md", Origin: "data", Chunks: 3, Bytes: 1200})
+	r.SkipAll("git", map[string]string{"logo.svg": "unsupported format"})
+	r.Skip("data", "data/scan.pdf", "no text layer")
+	r.AddTokens(100, 20, false)
+	r.AddTokens(50, 10, true)
+	r.AddPhase("extraction", 100, 20, 0.5, false)
+	r.AddPhase("embedding", 30, 0, 0.25, true)
+	r.AddPhase("embedding", 10, 0, 0.25, false)
+	r.Extraction.Batches, r.Extraction.Succeeded, r.Extraction.Failed = 4, 3, 1
+	r.Stage("load", 1500*time.Millisecond)
+	r.Warnings = append(r.Warnings, "triple extraction failed for batch 30-40")
+	r.Fail(errors.New("embed: connection refused"))
+
+	dir := filepath.Join(t.TempDir(), "reports")
+	require.NoError(t, r.Save(dir))
+
+	data, err := os.ReadFile(filepath.Join(dir, JSONFile))
+	require.NoError(t, err)
+	var got Report
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, "failed", got.Status)
+	assert.Equal(t, 0.75, got.Extraction.SuccessRate)
+	assert.Equal(t, Tokens{Calls: 2, PromptTokens: 150, CompletionTokens: 30, TotalTokens: 180, Estimated: true}, got.Tokens)
+	assert.Equal(t, Tokens{Calls: 2, PromptTokens: 40, TotalTokens: 40, Estimated: true, CostUSD: 0.5}, got.Phases["embedding"])
+	assert.Equal(t, 1.0, got.CostUSD)
+	require.Len(t, got.Skipped, 2)
+	assert.Equal(t, "data/scan.pdf", got.Skipped[0].Path, "skipped items are sorted by origin")
+
+	md, err := os.ReadFile(filepath.Join(dir, MarkdownFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(md), "| Status | failed: embed: connection refused |")
+	assert.Contains(t, string(md), "| 4 | 3 | 1 | 0 | 75.0% | 0 |")
+	assert.Contains(t, string(md), "| load | 1.5s |")
+	assert.Contains(t, string(md), "| embedding | 2 | 40 | 0 | $0.5000 |")
+	assert.Contains(t, string(md), "| logo.svg | git | unsupported format |")
+	assert.Contains(t, string(md), "estimated")
+}
+
+func TestReportEmptyExtraction(t *testing.T) {
+	r := New("dev")
+	md := r.Markdown()
+	assert.Equal(t, 1.0, r.Extraction.SuccessRate)
+	assert.NotContains(t, md, "## Skipped")
+	assert.NotContains(t, md, "## Warnings")
+}
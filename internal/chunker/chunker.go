@@ -23,6 +23,17 @@ type Chunk struct {
 	Source string
 	// Index is the position of this chunk within the source
 	Index int
+	// Metadata holds attributes inherited from the source section (e.g. chapter title)
+	Metadata map[string]string
+}
+
+// Section is a logical part of a document that is chunked independently so
+// chunks never straddle section boundaries (e.g. book chapters).
+type Section struct {
+	// Content is the section text
+	Content string
+	// Metadata is copied onto every chunk produced from this section
+	Metadata map[string]string
 }
 
 // Options configures the chunking behavior.
@@ -31,6 +42,10 @@ type Options struct {
 	ChunkSize int
 	// Overlap is the number of characters to overlap between chunks
 	Overlap int
+	// Sentences makes every sentence a chunk of its own, marked with the
+	// "unit" metadata key "sentence", for sentence-window retrieval.
+	// ChunkSize still splits a longer sentence
+	Sentences bool
 }
 
 // DefaultOptions returns sensible defaults for chunking.
@@ -143,7 +158,7 @@ func ChunkDocument(text string, chunkSize int) ([]Chunk, error) {
 // SplitBySentence splits text into sentence-aware chunks, attempting to break
 // at sentence boundaries when possible. Oversized paragraphs are sub-split
 // at sentence boundaries; truly huge sentences fall back to character-level
-// splitting via ChunkText.
+// splitting via ChunkText. With Options.Sentences, each sentence is a chunk.
 func (c *Chunker) SplitBySentence(text, source string) ([]Chunk, error) {
 	if !utf8.ValidString(text) {
 		return nil, errors.New("text is not valid UTF-8")
@@ -162,12 +177,16 @@ func (c *Chunker) SplitBySentence(text, source string) ([]Chunk, error) {
 	flush := func() {
 		content := strings.TrimSpace(builder.String())
 		if content != "" {
-			chunks = append(chunks, Chunk{
+			ch := Chunk{
 				ID:      buildChunkID(source, idx),
 				Content: content,
 				Source:  source,
 				Index:   idx,
-			})
+			}
+			if c.opts.Sentences {
+				ch.Metadata = map[string]string{"unit": "sentence"}
+			}
+			chunks = append(chunks, ch)
 			idx++
 		}
 		builder.Reset()
@@ -179,7 +198,7 @@ func (c *Chunker) SplitBySentence(text, source string) ([]Chunk, error) {
 		if frag == "" {
 			return
 		}
-		if builder.Len()+len(frag)+2 > c.opts.ChunkSize && builder.Len() > 0 {
+		if (c.opts.Sentences || builder.Len()+len(frag)+2 > c.opts.ChunkSize) && builder.Len() > 0 {
 			flush()
 		}
 		if builder.Len() > 0 {
@@ -195,12 +214,13 @@ func (c *Chunker) SplitBySentence(text, source string) ([]Chunk, error) {
 		}
 
 		// If the paragraph fits, accumulate it normally
-		if len(para) <= c.opts.ChunkSize {
+		if len(para) <= c.opts.ChunkSize && !c.opts.Sentences {
 			addFragment(para)
 			continue
 		}
 
-		// Paragraph is oversized — flush any accumulated text first
+		// Paragraph is oversized, or split into sentences anyway — flush
+		// any accumulated text first
 		flush()
 
 		// Try to sub-split at sentence boundaries
@@ -239,6 +259,63 @@ func (c *Chunker) SplitBySentence(text, source string) ([]Chunk, error) {
 	return chunks, nil
 }
 
+// SplitSections chunks each section with SplitBySentence, numbering chunks
+// continuously across sections and copying section metadata onto each chunk.
+func (c *Chunker) SplitSections(sections []Section, source string) ([]Chunk, error) {
+	chunks := []Chunk{}
+	stream := c.NewStream(source)
+	for _, sec := range sections {
+		secChunks, err := stream.Split(sec)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, secChunks...)
+	}
+	return chunks, nil
+}
+
+// Options returns the options the chunker splits with.
+func (c *Chunker) Options() Options {
+	return c.opts
+}
+
+// Stream chunks a document whose sections arrive one at a time, such as a
+// file too large to hold in memory, numbering chunks like SplitSections.
+type Stream struct {
+	c      *Chunker
+	source string
+	next   int
+}
+
+// NewStream starts chunking the sections of source.
+func (c *Chunker) NewStream(source string) *Stream {
+	return &Stream{c: c, source: source}
+}
+
+// Split chunks the next section of the document.
+func (s *Stream) Split(sec Section) ([]Chunk, error) {
+	chunks, err := s.c.SplitBySentence(sec.Content, s.source)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chunks {
+		chunks[i].ID = buildChunkID(s.source, s.next)
+		chunks[i].Index = s.next
+		if len(sec.Metadata) > 0 {
+			meta := make(map[string]string, len(sec.Metadata)+len(chunks[i].Metadata))
+			for k, v := range sec.Metadata {
+				meta[k] = v
+			}
+			for k, v := range chunks[i].Metadata {
+				meta[k] = v
+			}
+			chunks[i].Metadata = meta
+		}
+		s.next++
+	}
+	return chunks, nil
+}
+
 // splitSentences splits text at sentence boundaries (. ! ?) followed by a space
 // or end of string. It keeps the delimiter attached to the preceding sentence.
 func splitSentences(text string) []string {
@@ -158,6 +158,22 @@ func TestSplitBySentence_MixedSizes(t *testing.T) {
 	}
 }
 
+func TestSplitBySentence_Sentences(t *testing.T) {
+	text := "Refunds take 14 days. Annual plans are prorated!\n\nSupport is open on weekdays."
+
+	c, err := NewChunker(Options{ChunkSize: 500, Overlap: 100, Sentences: true})
+	require.NoError(t, err)
+
+	chunks, err := c.SplitBySentence(text, "faq")
+	require.NoError(t, err)
+	require.Len(t, chunks, 3, "each sentence is a chunk of its own")
+	assert.Equal(t, "Annual plans are prorated!", chunks[1].Content)
+	for i, ch := range chunks {
+		assert.Equal(t, i, ch.Index)
+		assert.Equal(t, "sentence", ch.Metadata["unit"])
+	}
+}
+
 func TestOptionsFromMaxTokens(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
+
+	"github.com/akashicode/kash/internal/pricing"
 )
 
 // ErrNilConfig is returned when a nil Config is provided.
@@ -34,10 +37,98 @@ func ConfigFilePath() (string, error) {
 
 // ProviderConfig holds connection details for a single AI provider.
 type ProviderConfig struct {
-	BaseURL    string `mapstructure:"base_url"    yaml:"base_url"`
-	APIKey     string `mapstructure:"api_key"     yaml:"api_key"`
-	Model      string `mapstructure:"model"       yaml:"model"`
-	Dimensions int    `mapstructure:"dimensions"  yaml:"dimensions,omitempty"`
+	BaseURL    string `mapstructure:"base_url"     yaml:"base_url"`
+	APIKey     string `mapstructure:"api_key"      yaml:"api_key"`
+	APIKeyFile string `mapstructure:"api_key_file" yaml:"api_key_file,omitempty"` // read when APIKey is empty, e.g. a Docker secret
+	Model      string `mapstructure:"model"        yaml:"model"`
+	Dimensions int    `mapstructure:"dimensions"   yaml:"dimensions,omitempty"`
+	// Provider "local" runs the reranker on this machine, with Model the
+	// path of a cross-encoder model instead of a model name; only the
+	// reranker supports it
+	Provider string `mapstructure:"provider"     yaml:"provider,omitempty"`
+	// Languages gives texts in a language, by ISO 639-1 code such as "fr",
+	// an embedding model of their own; unset fields are inherited. Only the
+	// embedder supports it
+	Languages map[string]ProviderConfig `mapstructure:"languages" yaml:"languages,omitempty"`
+}
+
+// Local reports whether the provider runs on this machine rather than behind
+// BaseURL.
+func (p ProviderConfig) Local() bool {
+	return strings.EqualFold(p.Provider, "local")
+}
+
+// ForLanguage returns the provider for texts in the language code: its
+// Languages entry, with the fields it leaves unset taken from p, or p
+// itself when the language has no entry. The dimensions are always p's.
+func (p ProviderConfig) ForLanguage(code string) (ProviderConfig, bool) {
+	route, ok := p.Languages[code]
+	if !ok {
+		return p, false
+	}
+	if route.BaseURL == "" {
+		route.BaseURL = p.BaseURL
+	}
+	if route.APIKey == "" && route.APIKeyFile == "" {
+		route.APIKey = p.APIKey
+	}
+	if route.Model == "" {
+		route.Model = p.Model
+	}
+	route.Dimensions = p.Dimensions
+	route.Languages = nil
+	return route, true
+}
+
+// BuildLLMConfig returns the provider 'kash build' extracts triples and
+// writes the MCP description with: build_llm, with the fields it leaves
+// unset taken from llm.
+func (c *Config) BuildLLMConfig() ProviderConfig {
+	p := c.BuildLLM
+	if p.BaseURL == "" {
+		p.BaseURL = c.LLM.BaseURL
+	}
+	if p.APIKey == "" && p.APIKeyFile == "" {
+		p.APIKey = c.LLM.APIKey
+	}
+	if p.Model == "" {
+		p.Model = c.LLM.Model
+	}
+	p.Dimensions = 0
+	p.Languages = nil
+	return p
+}
+
+// LanguageCodes returns the languages with an entry in Languages, sorted.
+func (p ProviderConfig) LanguageCodes() []string {
+	codes := make([]string, 0, len(p.Languages))
+	for code := range p.Languages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// GoogleConfig holds Google service account credentials used by the Drive
+// and Cloud Storage (gs://) sources.
+type GoogleConfig struct {
+	CredentialsFile string `mapstructure:"credentials_file" yaml:"credentials_file"`
+}
+
+// AWSConfig holds credentials for s3:// storage sources.
+type AWSConfig struct {
+	AccessKeyID     string `mapstructure:"access_key_id"     yaml:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key" yaml:"secret_access_key"`
+	SessionToken    string `mapstructure:"session_token"     yaml:"session_token,omitempty"`
+	Region          string `mapstructure:"region"            yaml:"region"`
+}
+
+// AzureConfig holds credentials for az:// storage sources.
+// Either AccountKey or SASToken is required.
+type AzureConfig struct {
+	AccountName string `mapstructure:"account_name" yaml:"account_name"`
+	AccountKey  string `mapstructure:"account_key"  yaml:"account_key,omitempty"`
+	SASToken    string `mapstructure:"sas_token"    yaml:"sas_token,omitempty"`
 }
 
 // Config holds the unified application configuration.
@@ -48,42 +139,111 @@ type Config struct {
 	Embedder ProviderConfig `mapstructure:"embedder"  yaml:"embedder"`
 	Reranker ProviderConfig `mapstructure:"reranker"  yaml:"reranker"`
 	Port     int            `mapstructure:"port"      yaml:"port"`
+	HTTP     HTTPConfig     `mapstructure:"http"      yaml:"http,omitempty"`
+	Google   GoogleConfig   `mapstructure:"google"    yaml:"google,omitempty"`
+	AWS      AWSConfig      `mapstructure:"aws"       yaml:"aws,omitempty"`
+	Azure    AzureConfig    `mapstructure:"azure"     yaml:"azure,omitempty"`
+
+	// GRPCPort serves the gRPC interface on this port; 0 disables it
+	GRPCPort int `mapstructure:"grpc_port" yaml:"grpc_port,omitempty"`
+	// Transcriber is an optional Whisper-compatible endpoint for audio files in data/
+	Transcriber ProviderConfig `mapstructure:"transcriber" yaml:"transcriber,omitempty"`
+	// BuildLLM is an optional LLM for the triple extraction and MCP
+	// description of 'kash build'; unset fields come from LLM
+	BuildLLM ProviderConfig `mapstructure:"build_llm" yaml:"build_llm,omitempty"`
+	// OCR selects how images and scanned PDFs are read
+	OCR OCRConfig `mapstructure:"ocr" yaml:"ocr,omitempty"`
+	// Pricing prices builds before they run
+	Pricing PricingConfig `mapstructure:"pricing" yaml:"pricing,omitempty"`
+	// Keyring lists the secret keys (e.g. "llm.api_key") stored in the OS
+	// keyring by 'kash config set-secret'
+	Keyring []string `mapstructure:"keyring" yaml:"keyring,omitempty"`
+
+	// Profile selects one of Profiles by default; --profile and KASH_PROFILE
+	// override it
+	Profile string `mapstructure:"profile" yaml:"profile,omitempty"`
+	// Profiles are named sets of settings (e.g. "openai", "local-ollama")
+	// that override the top-level ones when selected
+	Profiles map[string]Config `mapstructure:"profiles" yaml:"profiles,omitempty"`
+}
+
+// OCRConfig selects the OCR engine for images and scanned PDFs.
+type OCRConfig struct {
+	// Engine is "tesseract", "vision" (the configured LLM), or "none".
+	// Empty uses tesseract when it is installed.
+	Engine string `mapstructure:"engine"   yaml:"engine"`
+	// Language is the tesseract language code (default: "eng")
+	Language string `mapstructure:"language" yaml:"language,omitempty"`
+	// Model overrides the LLM model used by the vision engine
+	Model string `mapstructure:"model"    yaml:"model,omitempty"`
+}
+
+// PricingConfig is the price table 'kash build' estimates its cost with.
+type PricingConfig struct {
+	// ConfirmAbove is the estimated cost in US dollars above which a build
+	// asks before it starts (default: pricing.DefaultConfirmAbove; negative
+	// never asks)
+	ConfirmAbove float64 `mapstructure:"confirm_above" yaml:"confirm_above,omitempty"`
+	// Models add to and override the built-in prices, keyed by model name
+	Models pricing.Table `mapstructure:"models" yaml:"models,omitempty"`
+}
+
+// Prices returns the built-in price table with Models applied.
+func (p PricingConfig) Prices() pricing.Table {
+	return pricing.New(p.Models)
+}
+
+// Threshold returns the cost above which a build asks for confirmation, or
+// a negative value when it never asks.
+func (p PricingConfig) Threshold() float64 {
+	if p.ConfirmAbove == 0 {
+		return pricing.DefaultConfirmAbove
+	}
+	return p.ConfirmAbove
 }
 
 // Load reads the unified config. Environment variables take priority over
 // config.yaml values. This makes the same binary work for both CLI (config.yaml)
-// and container (env vars) usage.
+// and container (env vars) usage. A config.yaml in the current (project)
+// directory overrides the global file, and variables from a .env or
+// .env.local file there count as environment variables.
 func Load() (*Config, error) {
+	// 0. Apply the project's .env files without overriding the real environment
+	if _, err := LoadDotEnv(); err != nil {
+		return nil, err
+	}
+
 	// 1. Read config.yaml via Viper (may be empty/missing — that's OK)
 	var cfg Config
 	if err := viper.Unmarshal(&cfg); err != nil {
 		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
 
-	// 2. Override with environment variables where set
-	applyEnv(&cfg.LLM.BaseURL, "LLM_BASE_URL")
-	applyEnv(&cfg.LLM.APIKey, "LLM_API_KEY")
-	applyEnv(&cfg.LLM.Model, "LLM_MODEL")
-
-	applyEnv(&cfg.Embedder.BaseURL, "EMBED_BASE_URL")
-	applyEnv(&cfg.Embedder.APIKey, "EMBED_API_KEY")
-	applyEnv(&cfg.Embedder.Model, "EMBED_MODEL")
-
-	// NOTE: Dimensions are NOT set from env vars.
-	// agent.yaml is the canonical source for dimensions. The default of 1024
-	// is applied in ApplyAgentYAMLDimensions() after agent.yaml is consulted.
+	// 2. Overlay the project's config.yaml, next to agent.yaml
+	if err := applyProjectConfig(&cfg, viper.ConfigFileUsed()); err != nil {
+		return nil, err
+	}
 
-	applyEnv(&cfg.Reranker.BaseURL, "RERANK_BASE_URL")
-	applyEnv(&cfg.Reranker.APIKey, "RERANK_API_KEY")
-	applyEnv(&cfg.Reranker.Model, "RERANK_MODEL")
+	// 3. Apply the selected profile, if any
+	if err := applyProfile(&cfg); err != nil {
+		return nil, err
+	}
 
-	if portStr := os.Getenv("PORT"); portStr != "" {
-		var p int
-		if _, err := fmt.Sscanf(portStr, "%d", &p); err == nil && p > 0 {
-			cfg.Port = p
+	// 4. Override with environment variables where set. Dimensions are NOT
+	// read from env vars: agent.yaml is the canonical source, and the default
+	// of 1024 is applied in ApplyAgentYAMLDimensions() after it is consulted.
+	for key, env := range envVars {
+		if v := os.Getenv(env); v != "" {
+			// Invalid values (e.g. a non-numeric PORT) are ignored
+			_ = cfg.Set(key, v)
 		}
 	}
 
+	// 5. Fill secrets still unset from api_key_file references and the OS keyring
+	if err := resolveSecrets(&cfg, filepath.Dir(viper.ConfigFileUsed())); err != nil {
+		return nil, err
+	}
+
 	// Default port
 	if cfg.Port == 0 {
 		cfg.Port = 8000
@@ -92,23 +252,6 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
-// applyEnv overwrites dst with the value of the environment variable if set.
-func applyEnv(dst *string, envKey string) {
-	if v := os.Getenv(envKey); v != "" {
-		*dst = v
-	}
-}
-
-// applyEnvInt overwrites dst with the int value of the environment variable if set.
-func applyEnvInt(dst *int, envKey string) {
-	if v := os.Getenv(envKey); v != "" {
-		var n int
-		if _, err := fmt.Sscanf(v, "%d", &n); err == nil && n > 0 {
-			*dst = n
-		}
-	}
-}
-
 // ValidateLLM checks that LLM provider settings are configured.
 func ValidateLLM(cfg *Config) error {
 	var missing []string
@@ -143,6 +286,15 @@ func ValidateEmbedder(cfg *Config) error {
 	if cfg.Embedder.Dimensions <= 0 {
 		return fmt.Errorf("embedder dimensions must be > 0 (got %d), set via runtime.embedder.dimensions in agent.yaml", cfg.Embedder.Dimensions)
 	}
+	for _, code := range cfg.Embedder.LanguageCodes() {
+		route := cfg.Embedder.Languages[code]
+		if code == "" || strings.IndexFunc(code, func(r rune) bool { return r < 'a' || r > 'z' }) >= 0 {
+			return fmt.Errorf("embedder.languages: %q is not a language code such as \"fr\"", code)
+		}
+		if route.Model == "" && route.BaseURL == "" {
+			return fmt.Errorf("embedder.languages.%s: set a model or base_url", code)
+		}
+	}
 	return nil
 }
 
@@ -201,6 +353,12 @@ embedder:
   api_key: ""
   model: ""       # optional — omit when using a router
   dimensions: 1024 # default: 1024
+  # Per-language models (optional): chunks and questions detected in one of
+  # these languages are embedded with its model. Unset fields come from the
+  # embedder above; every model must return at least the dimensions.
+  # languages:
+  #   ja:
+  #     model: "cl-nagoya/ruri-large"
 
 # Reranking provider (optional) — must be OpenAI-compatible
 reranker:
@@ -208,8 +366,85 @@ reranker:
   api_key: ""
   model: ""
 
+# LLM for 'kash build' (optional) — triple extraction and the MCP tool
+# description, e.g. a cheaper model than the one that answers. Unset fields
+# come from llm above.
+# build_llm:
+#   model: "gpt-4o-mini"
+
+# Audio transcription (optional) — Whisper-compatible /audio/transcriptions
+# endpoint used by 'kash build' for .mp3, .wav, and .m4a files in data/.
+transcriber:
+  base_url: ""
+  api_key: ""
+  model: ""       # default: whisper-1
+
+# OCR for .png/.jpg files and scanned PDFs (optional)
+# engine: "tesseract" (local binary), "vision" (uses the llm provider above),
+# or "none". Leave empty to use tesseract when it is installed.
+ocr:
+  engine: ""
+  language: "eng"  # tesseract language code
+  model: ""        # vision model override (default: llm.model)
+
+# Build cost estimates (optional). 'kash build' asks before a build whose
+# estimated cost exceeds confirm_above (US dollars, default 1.00; -1 never
+# asks). Prices are US dollars per million tokens and extend the built-in
+# table of common hosted models.
+# pricing:
+#   confirm_above: 5.00
+#   models:
+#     my-embedding-model:
+#       input: 0.02
+#     my-llm:
+#       input: 0.15
+#       output: 0.60
+
 # Server port (default: 8000)
 port: 8000
+
+# HTTP server tuning (optional). Durations use Go syntax; "0" disables one.
+# http:
+#   read_header_timeout: "10s"
+#   read_timeout: "0"         # whole request; none by default
+#   write_timeout: "0"        # none by default, so SSE streams are not cut off
+#   idle_timeout: "120s"      # keep-alive; raise it above your proxy's
+#   max_header_bytes: 1048576
+#   http2: "off"              # "h2c" also accepts cleartext HTTP/2 from a proxy
+#   http2_max_concurrent_streams: 250
+
+# Named profiles (optional) — each overrides the settings above when selected
+# with --profile, KASH_PROFILE, or the profile key below.
+# profile: "openai"
+# profiles:
+#   openai:
+#     llm:
+#       base_url: "https://api.openai.com/v1"
+#       model: "gpt-4o-mini"
+#   local-ollama:
+#     llm:
+#       base_url: "http://localhost:11434/v1"
+#       model: "llama3.1"
+#     embedder:
+#       base_url: "http://localhost:11434/v1"
+#       model: "nomic-embed-text"
+#       dimensions: 768
+
+# Google service account key (optional) — used by sources.drive and gs://
+# storage sources in agent.yaml. Share Drive folders with the service
+# account's email address.
+# google:
+#   credentials_file: "/path/to/service-account.json"
+
+# Object storage credentials (optional) — used by sources.storage in agent.yaml.
+# The standard AWS_* and AZURE_STORAGE_* environment variables also work.
+# aws:
+#   access_key_id: ""
+#   secret_access_key: ""
+#   region: "us-east-1"
+# azure:
+#   account_name: ""
+#   account_key: ""   # or sas_token: ""
 `
 	if err := os.WriteFile(cfgPath, []byte(skeleton), 0600); err != nil {
 		return false, fmt.Errorf("write config file: %w", err)
@@ -272,24 +507,379 @@ func AgentYAMLMaxTokens(path string) int {
 	return parsed.Runtime.Embedder.MaxTokens
 }
 
-// AgentYAMLParallelEmbedding reads runtime.embedder.parallel from an agent.yaml file.
-// Returns false if the file doesn't exist or the field is not set.
-func AgentYAMLParallelEmbedding(path string) bool {
+// AgentYAMLEmbedConcurrency reads runtime.embedder.concurrency, the most
+// embedding calls made at once, from an agent.yaml file. Without it, the
+// deprecated parallel: false of earlier versions, which embedded one chunk
+// at a time, still means 1.
+// Returns 0 if the file doesn't exist or neither field is set.
+func AgentYAMLEmbedConcurrency(path string) int {
+	concurrency, parallel := agentYAMLEmbedConcurrency(path)
+	if concurrency == 0 && parallel != nil && !*parallel {
+		return 1
+	}
+	return concurrency
+}
+
+// AgentYAMLEmbedParallelWarning returns the deprecation warning for
+// runtime.embedder.parallel in an agent.yaml file, or "" when it is not set.
+func AgentYAMLEmbedParallelWarning(path string) string {
+	concurrency, parallel := agentYAMLEmbedConcurrency(path)
+	switch {
+	case parallel == nil:
+		return ""
+	case concurrency > 0:
+		return "runtime.embedder.parallel is deprecated and ignored, since runtime.embedder.concurrency is set — remove it"
+	case *parallel:
+		return "runtime.embedder.parallel is deprecated — embedding concurrency now adapts to the embedder; remove it, or cap it with runtime.embedder.concurrency"
+	}
+	return "runtime.embedder.parallel is deprecated — parallel: false is read as runtime.embedder.concurrency: 1; set that instead"
+}
+
+func agentYAMLEmbedConcurrency(path string) (concurrency int, parallel *bool) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return false
+		return 0, nil
 	}
 	var parsed struct {
 		Runtime struct {
 			Embedder struct {
-				Parallel bool `yaml:"parallel"`
+				Concurrency int   `yaml:"concurrency"`
+				Parallel    *bool `yaml:"parallel"`
 			} `yaml:"embedder"`
 		} `yaml:"runtime"`
 	}
 	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return 0, nil
+	}
+	return parsed.Runtime.Embedder.Concurrency, parsed.Runtime.Embedder.Parallel
+}
+
+// CSVIngestConfig is the ingest.csv block in agent.yaml.
+type CSVIngestConfig struct {
+	RowsPerChunk int    `yaml:"rows_per_chunk"`
+	IDColumn     string `yaml:"id_column"`
+	EmitTriples  bool   `yaml:"emit_triples"`
+}
+
+// JSONIngestConfig is the ingest.json block in agent.yaml.
+type JSONIngestConfig struct {
+	RecordsPath     string   `yaml:"records_path"`
+	Fields          []string `yaml:"fields"`
+	Exclude         []string `yaml:"exclude"`
+	IDField         string   `yaml:"id_field"`
+	RecordsPerChunk int      `yaml:"records_per_chunk"`
+}
+
+// TriplesIngestConfig is the ingest.triples block in agent.yaml, for the
+// curated *.triples.csv and *.triples.jsonl files in data/.
+type TriplesIngestConfig struct {
+	// MinConfidence drops the triples with a lower confidence column
+	// (default: keep all)
+	MinConfidence float64 `yaml:"min_confidence"`
+}
+
+// ReaderPluginConfig is an ingest.plugins entry in agent.yaml: an external
+// program that extracts text from files with the listed extensions.
+type ReaderPluginConfig struct {
+	Extensions []string `yaml:"extensions"`
+	// Command is the program and its arguments; a relative program path is
+	// resolved against the agent directory
+	Command []string `yaml:"command"`
+	// Output is "text" (default) or "json"
+	Output string `yaml:"output"`
+	// Timeout bounds each run, e.g. "30s" (default 2m)
+	Timeout string `yaml:"timeout"`
+}
+
+// IngestConfig is the ingest block in agent.yaml, holding per-format reader settings.
+type IngestConfig struct {
+	CSV     CSVIngestConfig      `yaml:"csv"`
+	JSON    JSONIngestConfig     `yaml:"json"`
+	Triples TriplesIngestConfig  `yaml:"triples"`
+	Plugins []ReaderPluginConfig `yaml:"plugins"`
+	// Workers is how many files in data/ are read at once (default: the
+	// number of CPUs)
+	Workers int `yaml:"workers"`
+	// StreamThresholdMB is the size from which .txt and .jsonl files in
+	// data/ are streamed instead of read whole (default: 64; negative: never)
+	StreamThresholdMB int `yaml:"stream_threshold_mb"`
+	// MaxFileMB skips files larger than this (default: no limit)
+	MaxFileMB int `yaml:"max_file_mb"`
+	// SkipDocuments leaves the full text of the documents out of the build,
+	// which the server returns for whole-document retrieval
+	SkipDocuments bool `yaml:"skip_documents"`
+}
+
+// AgentYAMLIngest reads the ingest block from an agent.yaml file.
+// Returns a zero IngestConfig if the file doesn't exist or the block is not set.
+func AgentYAMLIngest(path string) IngestConfig {
+	var parsed struct {
+		Ingest IngestConfig `yaml:"ingest"`
+	}
+	if !readAgentYAML(path, &parsed) {
+		return IngestConfig{}
+	}
+	return parsed.Ingest
+}
+
+// ChunkingConfig is the chunking block in agent.yaml. Sizes are in
+// characters; zero values keep the defaults.
+type ChunkingConfig struct {
+	Size    int `yaml:"size"`
+	Overlap int `yaml:"overlap"`
+	// Strategy is ChunkingChunks (default) or ChunkingSentences
+	Strategy string `yaml:"strategy"`
+}
+
+// Chunking strategies of agent.yaml's chunking block.
+const (
+	// ChunkingChunks packs paragraphs into chunks of about chunking.size
+	ChunkingChunks = "chunks"
+	// ChunkingSentences embeds every sentence on its own, for
+	// sentence-window retrieval
+	ChunkingSentences = "sentences"
+)
+
+// Validate checks the chunking strategy.
+func (c ChunkingConfig) Validate() error {
+	switch c.Strategy {
+	case "", ChunkingChunks, ChunkingSentences:
+		return nil
+	}
+	return fmt.Errorf("chunking.strategy: unknown strategy %q (want %s or %s)", c.Strategy, ChunkingChunks, ChunkingSentences)
+}
+
+// AgentYAMLChunking reads the chunking block from an agent.yaml file.
+// Returns a zero ChunkingConfig if the file doesn't exist or the block is not set.
+func AgentYAMLChunking(path string) ChunkingConfig {
+	var parsed struct {
+		Chunking ChunkingConfig `yaml:"chunking"`
+	}
+	if !readAgentYAML(path, &parsed) {
+		return ChunkingConfig{}
+	}
+	return parsed.Chunking
+}
+
+// ExtractionConfig is the extraction block in agent.yaml, which fits the
+// LLM's triple extraction to the corpus. Zero values keep the default prompt.
+type ExtractionConfig struct {
+	// Prompt replaces the instructions of the default system prompt; the
+	// JSON output format is always added to it
+	Prompt string `yaml:"prompt"`
+	// EntityTypes are the kinds of subjects and objects to extract, e.g.
+	// [statute, court, party]
+	EntityTypes []string `yaml:"entity_types"`
+	// Predicates are the relations to use, e.g. [cites, overrules]
+	Predicates []string `yaml:"predicates"`
+	// StrictPredicates drops the triples whose predicate is not one of
+	// Predicates
+	StrictPredicates bool `yaml:"strict_predicates"`
+	// Rules are domain-specific instructions added to the prompt
+	Rules []string `yaml:"rules"`
+	// BatchSize is how many chunks one extraction call reads (default: 10)
+	BatchSize int `yaml:"batch_size"`
+	// MaxBatchTokens ends a batch before its chunks exceed about this many
+	// tokens (default: no limit)
+	MaxBatchTokens int `yaml:"max_batch_tokens"`
+	// SkipGraph builds a vector-only agent: no triples are extracted or
+	// loaded and the knowledge graph is left out
+	SkipGraph bool `yaml:"skip_graph"`
+}
+
+// Validate checks the batch limits and that strict_predicates has
+// predicates to keep.
+func (c ExtractionConfig) Validate() error {
+	if c.StrictPredicates && len(c.Predicates) == 0 {
+		return errors.New("extraction.strict_predicates needs extraction.predicates")
+	}
+	if c.BatchSize < 0 {
+		return fmt.Errorf("extraction.batch_size must not be negative, got %d", c.BatchSize)
+	}
+	if c.MaxBatchTokens < 0 {
+		return fmt.Errorf("extraction.max_batch_tokens must not be negative, got %d", c.MaxBatchTokens)
+	}
+	return nil
+}
+
+// AgentYAMLExtraction reads the extraction block from an agent.yaml file.
+// Returns a zero ExtractionConfig if the file doesn't exist or the block is not set.
+func AgentYAMLExtraction(path string) ExtractionConfig {
+	var parsed struct {
+		Extraction ExtractionConfig `yaml:"extraction"`
+	}
+	if !readAgentYAML(path, &parsed) {
+		return ExtractionConfig{}
+	}
+	return parsed.Extraction
+}
+
+// SourcesConfig is the sources block in agent.yaml, listing remote content
+// that 'kash build' fetches and ingests alongside data/.
+type SourcesConfig struct {
+	URLs    []string        `yaml:"urls"`
+	Crawl   []CrawlConfig   `yaml:"crawl"`
+	Git     []GitConfig     `yaml:"git"`
+	Drive   []DriveConfig   `yaml:"drive"`
+	Storage []StorageConfig `yaml:"storage"`
+	YouTube YouTubeConfig   `yaml:"youtube"`
+}
+
+// YouTubeConfig is the sources.youtube block listing videos and channels
+// whose captions are ingested.
+type YouTubeConfig struct {
+	Videos         []string `yaml:"videos"`
+	Channels       []string `yaml:"channels"`
+	Language       string   `yaml:"language"`
+	MaxVideos      int      `yaml:"max_videos"`
+	SegmentSeconds int      `yaml:"segment_seconds"`
+}
+
+// StorageConfig is a single sources.storage entry describing an object-storage
+// prefix (s3://, gs://, or az://).
+type StorageConfig struct {
+	URL      string   `yaml:"url"`
+	Region   string   `yaml:"region"`
+	Endpoint string   `yaml:"endpoint"`
+	Exclude  []string `yaml:"exclude"`
+}
+
+// DriveConfig is a single sources.drive entry describing a Google Drive folder.
+type DriveConfig struct {
+	FolderID  string `yaml:"folder_id"`
+	Recursive bool   `yaml:"recursive"`
+}
+
+// GitConfig is a single sources.git entry describing a repository to ingest.
+type GitConfig struct {
+	URL     string   `yaml:"url"`
+	Ref     string   `yaml:"ref"`
+	Paths   []string `yaml:"paths"`
+	Exclude []string `yaml:"exclude"`
+}
+
+// CrawlConfig is a single sources.crawl entry describing a bounded website crawl.
+type CrawlConfig struct {
+	StartURL     string   `yaml:"start_url"`
+	MaxDepth     int      `yaml:"max_depth"`
+	MaxPages     int      `yaml:"max_pages"`
+	Include      []string `yaml:"include"`
+	Exclude      []string `yaml:"exclude"`
+	Sitemap      bool     `yaml:"sitemap"`
+	IgnoreRobots bool     `yaml:"ignore_robots"`
+	DelayMS      int      `yaml:"delay_ms"`
+}
+
+// AgentYAMLSources reads the sources block from an agent.yaml file.
+// Returns a zero SourcesConfig if the file doesn't exist or the block is not set.
+func AgentYAMLSources(path string) SourcesConfig {
+	var parsed struct {
+		Sources SourcesConfig `yaml:"sources"`
+	}
+	if !readAgentYAML(path, &parsed) {
+		return SourcesConfig{}
+	}
+	return parsed.Sources
+}
+
+// SnapshotConfig is the snapshots block in agent.yaml.
+type SnapshotConfig struct {
+	// Keep is how many snapshots to keep (0 keeps the default)
+	Keep int `yaml:"keep"`
+	// Disabled stops builds from taking snapshots
+	Disabled bool `yaml:"disabled"`
+}
+
+// AgentYAMLSnapshots reads the snapshots block from an agent.yaml file.
+// Returns a zero SnapshotConfig if the file doesn't exist or the block is not set.
+func AgentYAMLSnapshots(path string) SnapshotConfig {
+	var parsed struct {
+		Snapshots SnapshotConfig `yaml:"snapshots"`
+	}
+	if !readAgentYAML(path, &parsed) {
+		return SnapshotConfig{}
+	}
+	return parsed.Snapshots
+}
+
+// PIIConfig is the pii block in agent.yaml, which has 'kash build' look
+// for personal data in chunks before they are indexed.
+type PIIConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Action is "redact" (default), "tag", or "block"
+	Action string `yaml:"action"`
+	// Detect limits the built-in detectors to these kinds, e.g. [email]
+	// (default: all of them)
+	Detect []string `yaml:"detect"`
+	// Patterns are regular expressions detected besides the built-in
+	// kinds, by name, e.g. {employee_id: 'EMP-\d{6}'}
+	Patterns map[string]string `yaml:"patterns"`
+}
+
+// AgentYAMLPII reads the pii block from an agent.yaml file.
+// Returns a zero PIIConfig if the file doesn't exist or the block is not set.
+func AgentYAMLPII(path string) PIIConfig {
+	var parsed struct {
+		PII PIIConfig `yaml:"pii"`
+	}
+	if !readAgentYAML(path, &parsed) {
+		return PIIConfig{}
+	}
+	return parsed.PII
+}
+
+// AgentYAMLSystemPrompt reads agent.system_prompt from an agent.yaml file.
+// Returns "" if the file doesn't exist or the field is not set.
+func AgentYAMLSystemPrompt(path string) string {
+	var parsed struct {
+		Agent struct {
+			SystemPrompt string `yaml:"system_prompt"`
+		} `yaml:"agent"`
+	}
+	readAgentYAML(path, &parsed)
+	return parsed.Agent.SystemPrompt
+}
+
+// AgentYAMLName reads agent.name from an agent.yaml file.
+// Returns "" if the file doesn't exist or the field is not set.
+func AgentYAMLName(path string) string {
+	var parsed struct {
+		Agent struct {
+			Name string `yaml:"name"`
+		} `yaml:"agent"`
+	}
+	readAgentYAML(path, &parsed)
+	return parsed.Agent.Name
+}
+
+// readAgentYAML unmarshals an agent.yaml file into out.
+// Returns false if the file doesn't exist or cannot be parsed.
+func readAgentYAML(path string, out interface{}) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
 		return false
 	}
-	return parsed.Runtime.Embedder.Parallel
+	return yaml.Unmarshal(data, out) == nil
+}
+
+// ApplyAgentYAMLPort applies server.port from agent.yaml. Priority (highest
+// to lowest):
+//  1. PORT environment variable
+//  2. agent.yaml server.port
+//  3. config.yaml port
+//  4. Default: 8000
+func ApplyAgentYAMLPort(cfg *Config, agentYAMLPath string) {
+	if os.Getenv(envVars["port"]) != "" {
+		return
+	}
+	var parsed struct {
+		Server struct {
+			Port int `yaml:"port"`
+		} `yaml:"server"`
+	}
+	if readAgentYAML(agentYAMLPath, &parsed) && parsed.Server.Port > 0 {
+		cfg.Port = parsed.Server.Port
+	}
 }
 
 // ApplyAgentYAMLDimensions reads dimensions from agent.yaml and applies them
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLLMConfig(t *testing.T) {
+	cfg := Config{LLM: ProviderConfig{BaseURL: "https://llm.example/v1", APIKey: "k", Model: "large"}}
+	assert.Equal(t, cfg.LLM, cfg.BuildLLMConfig())
+
+	cfg.BuildLLM.Model = "small"
+	assert.Equal(t, ProviderConfig{BaseURL: "https://llm.example/v1", APIKey: "k", Model: "small"}, cfg.BuildLLMConfig())
+
+	cfg.BuildLLM = ProviderConfig{BaseURL: "http://localhost:11434/v1", APIKeyFile: "/run/secrets/local"}
+	assert.Equal(t, ProviderConfig{BaseURL: "http://localhost:11434/v1", APIKeyFile: "/run/secrets/local", Model: "large"}, cfg.BuildLLMConfig(),
+		"a key file of its own replaces the llm key")
+
+	key, ok := LookupKey("build_llm.model")
+	assert.True(t, ok)
+	assert.Equal(t, "BUILD_LLM_MODEL", key.Env)
+}
+
+func TestAgentYAMLEmbedConcurrency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	write := func(embedder string) {
+		require.NoError(t, os.WriteFile(path, []byte("runtime:\n  embedder:\n"+embedder), 0644))
+	}
+
+	write("    concurrency: 8\n")
+	assert.Equal(t, 8, AgentYAMLEmbedConcurrency(path))
+	assert.Empty(t, AgentYAMLEmbedParallelWarning(path))
+
+	write("    parallel: false\n")
+	assert.Equal(t, 1, AgentYAMLEmbedConcurrency(path), "parallel: false keeps embedding one chunk at a time")
+	assert.Contains(t, AgentYAMLEmbedParallelWarning(path), "concurrency: 1")
+
+	write("    parallel: true\n")
+	assert.Equal(t, 0, AgentYAMLEmbedConcurrency(path))
+	assert.Contains(t, AgentYAMLEmbedParallelWarning(path), "deprecated")
+
+	write("    parallel: false\n    concurrency: 4\n")
+	assert.Equal(t, 4, AgentYAMLEmbedConcurrency(path), "concurrency wins")
+	assert.Contains(t, AgentYAMLEmbedParallelWarning(path), "ignored")
+}
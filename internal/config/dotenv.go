@@ -0,0 +1,34 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// DotEnvFiles are the project-local environment files Load reads, highest
+// priority first. Variables already set in the process environment are never
+// overridden.
+var DotEnvFiles = []string{".env.local", ".env"}
+
+// LoadDotEnv applies the DotEnvFiles found in the current directory to the
+// process environment and returns their names. It uses the same KEY=value
+// format as 'docker run --env-file', so one file serves local and container
+// runs alike. Calling it again is harmless.
+func LoadDotEnv() ([]string, error) {
+	var loaded []string
+	for _, name := range DotEnvFiles {
+		if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		// godotenv.Load never overrides variables that are already set, so
+		// earlier files and the real environment take priority
+		if err := godotenv.Load(name); err != nil {
+			return loaded, fmt.Errorf("load %s: %w", name, err)
+		}
+		loaded = append(loaded, name)
+	}
+	return loaded, nil
+}
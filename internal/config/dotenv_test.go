@@ -0,0 +1,27 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDotEnv(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile(".env", []byte("KASH_TEST_A=from-env\nKASH_TEST_B=from-env\nKASH_TEST_C=from-env\n"), 0600))
+	require.NoError(t, os.WriteFile(".env.local", []byte("# local overrides\nKASH_TEST_B=from-local\n"), 0600))
+	t.Setenv("KASH_TEST_C", "from-process")
+	t.Cleanup(func() {
+		os.Unsetenv("KASH_TEST_A")
+		os.Unsetenv("KASH_TEST_B")
+	})
+
+	loaded, err := LoadDotEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{".env.local", ".env"}, loaded)
+	assert.Equal(t, "from-env", os.Getenv("KASH_TEST_A"))
+	assert.Equal(t, "from-local", os.Getenv("KASH_TEST_B"))
+	assert.Equal(t, "from-process", os.Getenv("KASH_TEST_C"))
+}
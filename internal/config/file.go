@@ -0,0 +1,227 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReadFileValues returns the settings present in a config file, keyed by
+// dotted name. A missing file yields an empty map.
+func ReadFileValues(path string) (map[string]string, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config file %q: %w", path, err)
+	}
+	out := map[string]string{}
+	for _, k := range Keys() {
+		if v, _ := cfg.Get(k.Name); v != "" {
+			out[k.Name] = v
+		}
+	}
+	return out, nil
+}
+
+// SetFileValue writes a single setting to a config file, creating the file
+// and any parent mappings as needed. Comments and the order of existing keys
+// are preserved.
+func SetFileValue(path, name, value string) error {
+	var probe Config
+	if err := probe.Set(name, value); err != nil {
+		return err
+	}
+	v, _ := probe.field(name)
+
+	doc, err := readConfigNode(path)
+	if err != nil {
+		return err
+	}
+	node := doc.Content[0]
+	parts := strings.Split(name, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child := mappingValue(node, part)
+		if child == nil || child.Kind != yaml.MappingNode {
+			if child == nil {
+				child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}, child)
+			} else {
+				// Replace a scalar or null placeholder with a mapping
+				*child = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: child.HeadComment, LineComment: child.LineComment}
+			}
+		}
+		node = child
+	}
+
+	leaf := parts[len(parts)-1]
+	scalar := mappingValue(node, leaf)
+	if scalar == nil {
+		scalar = &yaml.Node{Kind: yaml.ScalarNode}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: leaf}, scalar)
+	}
+	scalar.Kind = yaml.ScalarNode
+	scalar.Content = nil
+	if v.Kind() == reflect.Int {
+		scalar.Tag, scalar.Value, scalar.Style = "!!int", fmt.Sprint(v.Int()), 0
+	} else {
+		scalar.Tag, scalar.Value = "!!str", value
+		if scalar.Style == 0 {
+			scalar.Style = yaml.DoubleQuotedStyle
+		}
+	}
+	return writeConfigNode(path, doc)
+}
+
+// UnsetFileValue removes a setting from a config file. It reports whether
+// the key was present.
+func UnsetFileValue(path, name string) (bool, error) {
+	if _, err := (&Config{}).field(name); err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	doc, err := readConfigNode(path)
+	if err != nil {
+		return false, err
+	}
+	node := doc.Content[0]
+	parts := strings.Split(name, ".")
+	for _, part := range parts[:len(parts)-1] {
+		if node = mappingValue(node, part); node == nil || node.Kind != yaml.MappingNode {
+			return false, nil
+		}
+	}
+	leaf := parts[len(parts)-1]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == leaf {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true, writeConfigNode(path, doc)
+		}
+	}
+	return false, nil
+}
+
+// addFileListItem appends item to the top-level sequence key in a config
+// file unless it is already present.
+func addFileListItem(path, key, item string) error {
+	doc, err := readConfigNode(path)
+	if err != nil {
+		return err
+	}
+	root := doc.Content[0]
+	seq := mappingValue(root, key)
+	if seq == nil {
+		seq = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, seq)
+	} else if seq.Kind != yaml.SequenceNode {
+		*seq = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	for _, n := range seq.Content {
+		if n.Value == item {
+			return nil
+		}
+	}
+	seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+	return writeConfigNode(path, doc)
+}
+
+// removeFileListItem deletes item from the top-level sequence key in a
+// config file, dropping the key when the sequence becomes empty. It reports
+// whether the item was present.
+func removeFileListItem(path, key, item string) (bool, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	doc, err := readConfigNode(path)
+	if err != nil {
+		return false, err
+	}
+	root := doc.Content[0]
+	seq := mappingValue(root, key)
+	if seq == nil || seq.Kind != yaml.SequenceNode {
+		return false, nil
+	}
+	for i, n := range seq.Content {
+		if n.Value != item {
+			continue
+		}
+		seq.Content = append(seq.Content[:i], seq.Content[i+1:]...)
+		if len(seq.Content) == 0 {
+			for j := 0; j+1 < len(root.Content); j += 2 {
+				if root.Content[j].Value == key {
+					root.Content = append(root.Content[:j], root.Content[j+2:]...)
+					break
+				}
+			}
+		}
+		return true, writeConfigNode(path, doc)
+	}
+	return false, nil
+}
+
+// readConfigNode parses a config file into a document node whose root is a
+// mapping. A missing or empty file yields an empty mapping.
+func readConfigNode(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse config file %q: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode}
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file %q is not a YAML mapping", path)
+	}
+	return &doc, nil
+}
+
+// writeConfigNode encodes doc to path with owner-only permissions, since the
+// file holds API keys.
+func writeConfigNode(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode config file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode config file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("write config file: %w", err)
+	}
+	return nil
+}
+
+// mappingValue returns the value node for key in a mapping node.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
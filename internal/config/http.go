@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPConfig tunes the runtime's HTTP server. Durations use Go syntax
+// ("10s", "2m"); "0" disables a timeout.
+type HTTPConfig struct {
+	// ReadHeaderTimeout bounds reading the request headers (default: 10s)
+	ReadHeaderTimeout string `mapstructure:"read_header_timeout" yaml:"read_header_timeout,omitempty"`
+	// ReadTimeout bounds reading the whole request (default: none)
+	ReadTimeout string `mapstructure:"read_timeout" yaml:"read_timeout,omitempty"`
+	// WriteTimeout bounds writing the response (default: none, since SSE
+	// streams stay open for the whole answer)
+	WriteTimeout string `mapstructure:"write_timeout" yaml:"write_timeout,omitempty"`
+	// IdleTimeout closes keep-alive connections idle this long (default: 120s)
+	IdleTimeout string `mapstructure:"idle_timeout" yaml:"idle_timeout,omitempty"`
+	// MaxHeaderBytes caps the size of request headers (default: 1 MiB)
+	MaxHeaderBytes int `mapstructure:"max_header_bytes" yaml:"max_header_bytes,omitempty"`
+	// HTTP2 is "h2c" to also accept cleartext HTTP/2, e.g. from a proxy that
+	// speaks HTTP/2 to its backends, or "off" (default) for HTTP/1.1 only
+	HTTP2 string `mapstructure:"http2" yaml:"http2,omitempty"`
+	// HTTP2MaxConcurrentStreams caps the streams per HTTP/2 connection
+	// (default: 250)
+	HTTP2MaxConcurrentStreams int `mapstructure:"http2_max_concurrent_streams" yaml:"http2_max_concurrent_streams,omitempty"`
+	// SSEKeepAlive is how long an SSE stream may go without output before
+	// a comment is sent, so proxies keep it open (default: 15s)
+	SSEKeepAlive string `mapstructure:"sse_keepalive" yaml:"sse_keepalive,omitempty"`
+	// SSEResumeWindow is how long a chat stream's events are kept for a
+	// client to resume it with Last-Event-ID, and how long its answer is
+	// generated on without a client (default: none, resuming is off)
+	SSEResumeWindow string `mapstructure:"sse_resume_window" yaml:"sse_resume_window,omitempty"`
+}
+
+// HTTP server defaults. ReadTimeout and WriteTimeout default to none.
+const (
+	DefaultReadHeaderTimeout = 10 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultSSEKeepAlive      = 15 * time.Second
+)
+
+// KeepAlive returns the SSE keep-alive interval; 0 disables keep-alives.
+func (h HTTPConfig) KeepAlive() (time.Duration, error) {
+	return parseDuration("http.sse_keepalive", h.SSEKeepAlive, DefaultSSEKeepAlive)
+}
+
+// ResumeWindow returns how long chat streams can be resumed; 0 disables
+// resuming.
+func (h HTTPConfig) ResumeWindow() (time.Duration, error) {
+	return parseDuration("http.sse_resume_window", h.SSEResumeWindow, 0)
+}
+
+// parseDuration parses value, the setting name, as a non-negative duration,
+// or returns def when it is empty.
+func parseDuration(name, value string, def time.Duration) (time.Duration, error) {
+	if value == "" {
+		return def, nil
+	}
+	v, err := time.ParseDuration(value)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("%s must be a duration such as 30s, got %q", name, value)
+	}
+	return v, nil
+}
+
+// NewHTTPServer returns an http.Server for addr and handler with the settings
+// applied.
+func (h HTTPConfig) NewHTTPServer(addr string, handler http.Handler) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:           addr,
+		Handler:        handler,
+		MaxHeaderBytes: h.MaxHeaderBytes,
+	}
+	durations := []struct {
+		name  string
+		value string
+		def   time.Duration
+		dst   *time.Duration
+	}{
+		{"http.read_header_timeout", h.ReadHeaderTimeout, DefaultReadHeaderTimeout, &srv.ReadHeaderTimeout},
+		{"http.read_timeout", h.ReadTimeout, 0, &srv.ReadTimeout},
+		{"http.write_timeout", h.WriteTimeout, 0, &srv.WriteTimeout},
+		{"http.idle_timeout", h.IdleTimeout, DefaultIdleTimeout, &srv.IdleTimeout},
+	}
+	for _, d := range durations {
+		v, err := parseDuration(d.name, d.value, d.def)
+		if err != nil {
+			return nil, err
+		}
+		*d.dst = v
+	}
+	if _, err := h.KeepAlive(); err != nil {
+		return nil, err
+	}
+	if _, err := h.ResumeWindow(); err != nil {
+		return nil, err
+	}
+
+	switch h.HTTP2 {
+	case "", "off":
+	case "h2c":
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+		srv.HTTP2 = &http.HTTP2Config{MaxConcurrentStreams: h.HTTP2MaxConcurrentStreams}
+	default:
+		return nil, fmt.Errorf("http.http2 must be \"h2c\" or \"off\", got %q", h.HTTP2)
+	}
+	return srv, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+	srv, err := HTTPConfig{}.NewHTTPServer(":8000", nil)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultReadHeaderTimeout, srv.ReadHeaderTimeout)
+	assert.Equal(t, DefaultIdleTimeout, srv.IdleTimeout)
+	assert.Zero(t, srv.WriteTimeout)
+	assert.Nil(t, srv.Protocols)
+	keepAlive, err := HTTPConfig{}.KeepAlive()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSSEKeepAlive, keepAlive)
+	keepAlive, err = HTTPConfig{SSEKeepAlive: "0"}.KeepAlive()
+	require.NoError(t, err)
+	assert.Zero(t, keepAlive)
+	resume, err := HTTPConfig{}.ResumeWindow()
+	require.NoError(t, err)
+	assert.Zero(t, resume, "resuming is off by default")
+
+	srv, err = HTTPConfig{
+		ReadHeaderTimeout:         "5s",
+		WriteTimeout:              "0",
+		IdleTimeout:               "10m",
+		MaxHeaderBytes:            64 << 10,
+		HTTP2:                     "h2c",
+		HTTP2MaxConcurrentStreams: 100,
+	}.NewHTTPServer(":8000", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, 10*time.Minute, srv.IdleTimeout)
+	assert.Equal(t, 64<<10, srv.MaxHeaderBytes)
+	require.NotNil(t, srv.Protocols)
+	assert.True(t, srv.Protocols.UnencryptedHTTP2())
+	assert.True(t, srv.Protocols.HTTP1())
+	assert.Equal(t, 100, srv.HTTP2.MaxConcurrentStreams)
+
+	for _, bad := range []HTTPConfig{{IdleTimeout: "soon"}, {ReadTimeout: "-1s"}, {HTTP2: "h3"}, {SSEKeepAlive: "often"}, {SSEResumeWindow: "-5s"}} {
+		_, err := bad.NewHTTPServer(":8000", nil)
+		assert.Error(t, err)
+	}
+}
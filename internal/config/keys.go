@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// envVars maps config keys to the environment variables that override them.
+// Dimensions are deliberately absent: agent.yaml is their canonical source.
+var envVars = map[string]string{
+	"llm.base_url":             "LLM_BASE_URL",
+	"llm.api_key":              "LLM_API_KEY",
+	"llm.api_key_file":         "LLM_API_KEY_FILE",
+	"llm.model":                "LLM_MODEL",
+	"embedder.base_url":        "EMBED_BASE_URL",
+	"embedder.api_key":         "EMBED_API_KEY",
+	"embedder.api_key_file":    "EMBED_API_KEY_FILE",
+	"embedder.model":           "EMBED_MODEL",
+	"reranker.base_url":        "RERANK_BASE_URL",
+	"reranker.api_key":         "RERANK_API_KEY",
+	"reranker.api_key_file":    "RERANK_API_KEY_FILE",
+	"reranker.model":           "RERANK_MODEL",
+	"reranker.provider":        "RERANK_PROVIDER",
+	"transcriber.base_url":     "TRANSCRIBE_BASE_URL",
+	"transcriber.api_key":      "TRANSCRIBE_API_KEY",
+	"transcriber.api_key_file": "TRANSCRIBE_API_KEY_FILE",
+	"transcriber.model":        "TRANSCRIBE_MODEL",
+	"build_llm.base_url":       "BUILD_LLM_BASE_URL",
+	"build_llm.api_key":        "BUILD_LLM_API_KEY",
+	"build_llm.api_key_file":   "BUILD_LLM_API_KEY_FILE",
+	"build_llm.model":          "BUILD_LLM_MODEL",
+	"ocr.engine":               "OCR_ENGINE",
+	"ocr.language":             "OCR_LANGUAGE",
+	"ocr.model":                "OCR_MODEL",
+	"google.credentials_file":  "GOOGLE_APPLICATION_CREDENTIALS",
+	"aws.access_key_id":        "AWS_ACCESS_KEY_ID",
+	"aws.secret_access_key":    "AWS_SECRET_ACCESS_KEY",
+	"aws.session_token":        "AWS_SESSION_TOKEN",
+	"aws.region":               "AWS_REGION",
+	"azure.account_name":       "AZURE_STORAGE_ACCOUNT",
+	"azure.account_key":        "AZURE_STORAGE_KEY",
+	"azure.sas_token":          "AZURE_STORAGE_SAS_TOKEN",
+	"port":                     "PORT",
+	"grpc_port":                "GRPC_PORT",
+	"http.read_header_timeout": "HTTP_READ_HEADER_TIMEOUT",
+	"http.read_timeout":        "HTTP_READ_TIMEOUT",
+	"http.write_timeout":       "HTTP_WRITE_TIMEOUT",
+	"http.idle_timeout":        "HTTP_IDLE_TIMEOUT",
+	"http.max_header_bytes":    "HTTP_MAX_HEADER_BYTES",
+	"http.http2":               "HTTP2",
+	"http.sse_keepalive":       "HTTP_SSE_KEEPALIVE",
+	"http.sse_resume_window":   "HTTP_SSE_RESUME_WINDOW",
+	"profile":                  ProfileEnv,
+}
+
+// secretFields are the leaf names whose values are credentials.
+var secretFields = map[string]bool{
+	"api_key":           true,
+	"secret_access_key": true,
+	"session_token":     true,
+	"account_key":       true,
+	"sas_token":         true,
+}
+
+// Key describes one setting in config.yaml.
+type Key struct {
+	// Name is the dotted path, e.g. "llm.model"
+	Name string
+	// Env is the environment variable that overrides it, if any
+	Env string
+	// Secret marks credentials that should be redacted when displayed
+	Secret bool
+}
+
+// Keys lists every config.yaml setting in a stable order.
+func Keys() []Key {
+	var keys []Key
+	walkFields(reflect.TypeOf(Config{}), "", func(name string, _ []int) {
+		keys = append(keys, Key{Name: name, Env: envVars[name], Secret: IsSecret(name)})
+	})
+	sort.SliceStable(keys, func(i, j int) bool {
+		return sectionOf(keys[i].Name) < sectionOf(keys[j].Name)
+	})
+	return keys
+}
+
+// LookupKey returns the Key with the given dotted name.
+func LookupKey(name string) (Key, bool) {
+	for _, k := range Keys() {
+		if k.Name == name {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// IsSecret reports whether a dotted key holds a credential.
+func IsSecret(name string) bool {
+	return secretFields[name[strings.LastIndex(name, ".")+1:]]
+}
+
+// Redact masks a secret value, keeping the last four characters of long
+// values so keys can still be told apart.
+func Redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= 8 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
+
+// Get returns the value of a dotted key, e.g. "llm.model".
+func (c *Config) Get(name string) (string, error) {
+	v, err := c.field(name)
+	if err != nil {
+		return "", err
+	}
+	if v.Kind() == reflect.Int {
+		if v.Int() == 0 {
+			return "", nil
+		}
+		return strconv.FormatInt(v.Int(), 10), nil
+	}
+	return v.String(), nil
+}
+
+// Set assigns a dotted key from its string form. Integer settings must be
+// positive.
+func (c *Config) Set(name, value string) error {
+	v, err := c.field(name)
+	if err != nil {
+		return err
+	}
+	if v.Kind() == reflect.Int {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n <= 0 {
+			return fmt.Errorf("%s must be a positive integer, got %q", name, value)
+		}
+		v.SetInt(int64(n))
+		return nil
+	}
+	v.SetString(value)
+	return nil
+}
+
+// field resolves a dotted key to the settable struct field behind it.
+func (c *Config) field(name string) (reflect.Value, error) {
+	var index []int
+	walkFields(reflect.TypeOf(Config{}), "", func(n string, idx []int) {
+		if n == name {
+			index = idx
+		}
+	})
+	if index == nil {
+		return reflect.Value{}, fmt.Errorf("unknown config key %q (run 'kash config list' to see valid keys)", name)
+	}
+	return reflect.ValueOf(c).Elem().FieldByIndex(index), nil
+}
+
+// walkFields calls fn with the dotted yaml name and field index of every
+// string and int leaf in t.
+func walkFields(t reflect.Type, prefix string, fn func(name string, index []int)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := prefix + tag
+		// Only the embedder uses dimensions, although every provider has the field
+		if tag == "dimensions" && prefix != "embedder." {
+			continue
+		}
+		switch f.Type.Kind() {
+		case reflect.Struct:
+			walkFields(f.Type, name+".", func(n string, idx []int) {
+				fn(n, append([]int{i}, idx...))
+			})
+		case reflect.String, reflect.Int:
+			fn(name, []int{i})
+		}
+	}
+}
+
+func sectionOf(name string) int {
+	order := []string{"profile", "llm", "embedder", "reranker", "transcriber", "build_llm", "ocr", "port", "http", "google", "aws", "azure"}
+	section := strings.SplitN(name, ".", 2)[0]
+	for i, s := range order {
+		if s == section {
+			return i
+		}
+	}
+	return len(order)
+}
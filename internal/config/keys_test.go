@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigGetSet(t *testing.T) {
+	var cfg Config
+	require.NoError(t, cfg.Set("llm.model", "gpt-4o"))
+	require.NoError(t, cfg.Set("port", "9000"))
+	assert.Equal(t, "gpt-4o", cfg.LLM.Model)
+	assert.Equal(t, 9000, cfg.Port)
+
+	got, err := cfg.Get("embedder.dimensions")
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	assert.Error(t, cfg.Set("port", "abc"))
+	assert.Error(t, cfg.Set("llm.temperature", "1"))
+	_, err = cfg.Get("llm")
+	assert.Error(t, err)
+}
+
+func TestKeys(t *testing.T) {
+	key, ok := LookupKey("reranker.api_key")
+	require.True(t, ok)
+	assert.Equal(t, "RERANK_API_KEY", key.Env)
+	assert.True(t, key.Secret)
+
+	key, ok = LookupKey("embedder.dimensions")
+	require.True(t, ok)
+	assert.Empty(t, key.Env)
+	assert.False(t, key.Secret)
+
+	assert.Equal(t, "profile", Keys()[0].Name)
+	assert.Equal(t, "llm.base_url", Keys()[1].Name)
+	assert.Equal(t, "****", Redact("short"))
+	assert.Equal(t, "****cdef", Redact("sk-0123456789abcdef"))
+}
+
+func TestSetFileValue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`# Kash Configuration
+llm:
+  base_url: "" # OpenAI-compatible
+  api_key: ""
+# Server port
+port: 8000
+`), 0600))
+
+	require.NoError(t, SetFileValue(path, "llm.model", "gpt-4o"))
+	require.NoError(t, SetFileValue(path, "llm.base_url", "https://api.openai.com/v1"))
+	require.NoError(t, SetFileValue(path, "reranker.model", "rerank-2"))
+	require.NoError(t, SetFileValue(path, "port", "9000"))
+	assert.Error(t, SetFileValue(path, "port", "-1"))
+	assert.Error(t, SetFileValue(path, "nope", "x"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `# Kash Configuration
+llm:
+  base_url: "https://api.openai.com/v1" # OpenAI-compatible
+  api_key: ""
+  model: "gpt-4o"
+# Server port
+port: 9000
+reranker:
+  model: "rerank-2"
+`, string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	values, err := ReadFileValues(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"llm.base_url":   "https://api.openai.com/v1",
+		"llm.model":      "gpt-4o",
+		"reranker.model": "rerank-2",
+		"port":           "9000",
+	}, values)
+
+	removed, err := UnsetFileValue(path, "llm.model")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = UnsetFileValue(path, "llm.model")
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func TestSetFileValue_CreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+	require.NoError(t, SetFileValue(path, "embedder.dimensions", "768"))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "embedder:\n  dimensions: 768\n", string(data))
+}
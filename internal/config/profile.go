@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/akashicode/kash/internal/pricing"
+)
+
+// ProfileEnv selects a profile, overriding the profile key in config.yaml.
+// The global --profile flag sets it.
+const ProfileEnv = "KASH_PROFILE"
+
+// ActiveProfile returns the name of the profile Load applies to cfg, or "".
+func ActiveProfile(cfg *Config) string {
+	if name := os.Getenv(ProfileEnv); name != "" {
+		return name
+	}
+	return cfg.Profile
+}
+
+// ProfileNames returns the profiles defined in cfg, sorted.
+func ProfileNames(cfg *Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile overlays the selected profile onto the top-level settings.
+func applyProfile(cfg *Config) error {
+	name := ActiveProfile(cfg)
+	if name == "" {
+		return nil
+	}
+	// Viper lowercases map keys, so match names case-insensitively
+	var p Config
+	ok := false
+	for n, candidate := range cfg.Profiles {
+		if strings.EqualFold(n, name) {
+			p, ok = candidate, true
+			break
+		}
+	}
+	if !ok {
+		available := "none are defined"
+		if names := ProfileNames(cfg); len(names) > 0 {
+			available = "available: " + strings.Join(names, ", ")
+		}
+		return fmt.Errorf("profile %q not found in config.yaml (%s)", name, available)
+	}
+	overlayConfig(cfg, &p)
+	cfg.Profile = name
+	return nil
+}
+
+// overlayConfig copies every setting src sets onto dst, merging the keyring
+// lists, model prices, language embedders, and profile definitions.
+func overlayConfig(dst, src *Config) {
+	for _, k := range Keys() {
+		if v, _ := src.Get(k.Name); v != "" {
+			_ = dst.Set(k.Name, v)
+		}
+	}
+	for _, name := range src.Keyring {
+		if !slices.Contains(dst.Keyring, name) {
+			dst.Keyring = append(dst.Keyring, name)
+		}
+	}
+	if src.Pricing.ConfirmAbove != 0 {
+		dst.Pricing.ConfirmAbove = src.Pricing.ConfirmAbove
+	}
+	for model, p := range src.Pricing.Models {
+		if dst.Pricing.Models == nil {
+			dst.Pricing.Models = pricing.Table{}
+		}
+		dst.Pricing.Models[model] = p
+	}
+	for code, p := range src.Embedder.Languages {
+		if dst.Embedder.Languages == nil {
+			dst.Embedder.Languages = map[string]ProviderConfig{}
+		}
+		dst.Embedder.Languages[code] = p
+	}
+	for name, p := range src.Profiles {
+		if dst.Profiles == nil {
+			dst.Profiles = map[string]Config{}
+		}
+		merged := dst.Profiles[name]
+		overlayConfig(&merged, &p)
+		dst.Profiles[name] = merged
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProfile(t *testing.T) {
+	base := func() Config {
+		return Config{
+			LLM:      ProviderConfig{BaseURL: "https://api.openai.com/v1", APIKey: "sk-default", Model: "gpt-4o"},
+			Embedder: ProviderConfig{BaseURL: "https://api.voyageai.com/v1", APIKey: "pa-default"},
+			Profiles: map[string]Config{
+				"local-ollama": {
+					LLM:      ProviderConfig{BaseURL: "http://localhost:11434/v1", Model: "llama3.1"},
+					Embedder: ProviderConfig{BaseURL: "http://localhost:11434/v1", Model: "nomic-embed-text"},
+				},
+				"prod": {Port: 9000},
+			},
+		}
+	}
+
+	t.Run("no profile selected", func(t *testing.T) {
+		cfg := base()
+		require.NoError(t, applyProfile(&cfg))
+		assert.Equal(t, "gpt-4o", cfg.LLM.Model)
+	})
+
+	t.Run("selected in config", func(t *testing.T) {
+		cfg := base()
+		cfg.Profile = "local-ollama"
+		require.NoError(t, applyProfile(&cfg))
+		assert.Equal(t, "llama3.1", cfg.LLM.Model)
+		assert.Equal(t, "http://localhost:11434/v1", cfg.LLM.BaseURL)
+		assert.Equal(t, "sk-default", cfg.LLM.APIKey, "unset profile keys keep the top-level value")
+		assert.Equal(t, "nomic-embed-text", cfg.Embedder.Model)
+	})
+
+	t.Run("env overrides config", func(t *testing.T) {
+		t.Setenv(ProfileEnv, "PROD")
+		cfg := base()
+		cfg.Profile = "local-ollama"
+		require.NoError(t, applyProfile(&cfg))
+		assert.Equal(t, "gpt-4o", cfg.LLM.Model)
+		assert.Equal(t, 9000, cfg.Port)
+		assert.Equal(t, "PROD", cfg.Profile)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		cfg := base()
+		cfg.Profile = "staging"
+		err := applyProfile(&cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "available: local-ollama, prod")
+	})
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigFile is the per-project config file, read from the agent
+// project directory (next to agent.yaml). Its settings override the global
+// ~/.kash/config.yaml, and environment variables override both.
+const ProjectConfigFile = "config.yaml"
+
+// ProjectConfigPath returns the absolute path of the project config file in
+// the current directory, or "" when there is none.
+func ProjectConfigPath() string {
+	abs, err := filepath.Abs(ProjectConfigFile)
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return ""
+	}
+	return abs
+}
+
+// applyProjectConfig overlays the project config file onto cfg. Only the
+// keys present in the file are changed, and a relative api_key_file is made
+// relative to the project directory.
+func applyProjectConfig(cfg *Config, globalPath string) error {
+	path := ProjectConfigPath()
+	if path == "" || sameFile(path, globalPath) {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read project config: %w", err)
+	}
+	var overlay Config
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return fmt.Errorf("parse project config %q: %w", path, err)
+	}
+	for _, p := range []struct{ project, global *ProviderConfig }{
+		{&overlay.LLM, &cfg.LLM},
+		{&overlay.Embedder, &cfg.Embedder},
+		{&overlay.Reranker, &cfg.Reranker},
+		{&overlay.Transcriber, &cfg.Transcriber},
+		{&overlay.BuildLLM, &cfg.BuildLLM},
+	} {
+		if p.project.APIKeyFile == "" {
+			continue
+		}
+		if !filepath.IsAbs(p.project.APIKeyFile) && p.project.APIKeyFile[0] != '~' {
+			p.project.APIKeyFile = filepath.Join(filepath.Dir(path), p.project.APIKeyFile)
+		}
+		// A project key file replaces a global inline key
+		if p.project.APIKey == "" {
+			p.global.APIKey = ""
+		}
+	}
+	overlayConfig(cfg, &overlay)
+	return nil
+}
+
+func sameFile(a, b string) bool {
+	if b == "" {
+		return false
+	}
+	ia, errA := os.Stat(a)
+	ib, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return os.SameFile(ia, ib)
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.WriteFile(ProjectConfigFile, []byte(`llm:
+  model: llama3.1
+  api_key_file: secrets/llm.key
+embedder:
+  base_url: http://localhost:11434/v1
+keyring:
+  - reranker.api_key
+`), 0600))
+
+	cfg := Config{
+		LLM:      ProviderConfig{BaseURL: "https://api.openai.com/v1", APIKey: "sk-global", Model: "gpt-4o"},
+		Embedder: ProviderConfig{BaseURL: "https://api.voyageai.com/v1", APIKey: "pa-global", Model: "voyage-3"},
+		Port:     9000,
+		Keyring:  []string{"llm.api_key"},
+	}
+	require.NoError(t, applyProjectConfig(&cfg, filepath.Join(t.TempDir(), "config.yaml")))
+
+	assert.Equal(t, "llama3.1", cfg.LLM.Model)
+	assert.Equal(t, "https://api.openai.com/v1", cfg.LLM.BaseURL)
+	assert.Empty(t, cfg.LLM.APIKey, "project key file replaces the global inline key")
+	assert.Equal(t, filepath.Join(dir, "secrets", "llm.key"), cfg.LLM.APIKeyFile)
+	assert.Equal(t, "http://localhost:11434/v1", cfg.Embedder.BaseURL)
+	assert.Equal(t, "pa-global", cfg.Embedder.APIKey)
+	assert.Equal(t, "voyage-3", cfg.Embedder.Model)
+	assert.Equal(t, 9000, cfg.Port)
+	assert.Equal(t, []string{"llm.api_key", "reranker.api_key"}, cfg.Keyring)
+}
+
+func TestApplyProjectConfig_SameAsGlobal(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	require.NoError(t, os.WriteFile(ProjectConfigFile, []byte("llm:\n  model: other\n"), 0600))
+
+	cfg := Config{LLM: ProviderConfig{Model: "gpt-4o"}}
+	require.NoError(t, applyProjectConfig(&cfg, filepath.Join(dir, ProjectConfigFile)))
+	assert.Equal(t, "gpt-4o", cfg.LLM.Model)
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringService is the service name under which 'kash config set-secret'
+// stores credentials in the OS keyring (macOS Keychain, Windows Credential
+// Manager, or the Secret Service on Linux).
+const KeyringService = "kash"
+
+// SetKeyringSecret stores a secret config value, e.g. "llm.api_key", in the
+// OS keyring and records the key under keyring: in the config file at path so
+// Load knows to read it back.
+func SetKeyringSecret(path, name, value string) error {
+	if !IsSecret(name) {
+		return fmt.Errorf("%s is not a secret key (run 'kash config list' to see which are)", name)
+	}
+	if err := keyring.Set(KeyringService, name, value); err != nil {
+		return fmt.Errorf("store %s in OS keyring: %w", name, err)
+	}
+	return addFileListItem(path, "keyring", name)
+}
+
+// DeleteKeyringSecret removes a secret from the OS keyring and from the
+// keyring: list in the config file at path. It reports whether the secret
+// existed.
+func DeleteKeyringSecret(path, name string) (bool, error) {
+	if !IsSecret(name) {
+		return false, fmt.Errorf("%s is not a secret key", name)
+	}
+	err := keyring.Delete(KeyringService, name)
+	existed := err == nil
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return false, fmt.Errorf("delete %s from OS keyring: %w", name, err)
+	}
+	listed, err := removeFileListItem(path, "keyring", name)
+	if err != nil {
+		return false, err
+	}
+	return existed || listed, nil
+}
+
+// resolveSecrets fills provider API keys from api_key_file references and
+// the secrets listed under keyring: from the OS keyring. Priority, highest first:
+//  1. the *_API_KEY environment variable
+//  2. a *_API_KEY_FILE environment variable
+//  3. api_key in config.yaml
+//  4. api_key_file in config.yaml (relative paths are resolved against the
+//     config file's directory)
+//  5. the OS keyring
+func resolveSecrets(cfg *Config, configDir string) error {
+	for _, p := range []struct {
+		name string
+		cfg  *ProviderConfig
+	}{
+		{"llm", &cfg.LLM},
+		{"embedder", &cfg.Embedder},
+		{"reranker", &cfg.Reranker},
+		{"transcriber", &cfg.Transcriber},
+		{"build_llm", &cfg.BuildLLM},
+	} {
+		if p.cfg.APIKeyFile == "" || os.Getenv(envVars[p.name+".api_key"]) != "" {
+			continue
+		}
+		fromEnv := os.Getenv(envVars[p.name+".api_key_file"]) != ""
+		if p.cfg.APIKey != "" && !fromEnv {
+			continue
+		}
+		key, err := readSecretFile(p.cfg.APIKeyFile, configDir)
+		if err != nil {
+			return fmt.Errorf("read %s.api_key_file: %w", p.name, err)
+		}
+		p.cfg.APIKey = key
+	}
+	for code, route := range cfg.Embedder.Languages {
+		if route.APIKeyFile == "" || route.APIKey != "" {
+			continue
+		}
+		key, err := readSecretFile(route.APIKeyFile, configDir)
+		if err != nil {
+			return fmt.Errorf("read embedder.languages.%s.api_key_file: %w", code, err)
+		}
+		route.APIKey = key
+		cfg.Embedder.Languages[code] = route
+	}
+
+	for _, name := range cfg.Keyring {
+		if !IsSecret(name) {
+			return fmt.Errorf("keyring: %q is not a secret key", name)
+		}
+		if v, _ := cfg.Get(name); v != "" {
+			continue
+		}
+		v, err := keyring.Get(KeyringService, name)
+		if err != nil {
+			return fmt.Errorf("read %s from OS keyring (run 'kash config set-secret %s' again or set it another way): %w", name, name, err)
+		}
+		_ = cfg.Set(name, v)
+	}
+	return nil
+}
+
+// readSecretFile reads a secret from path, expanding a leading "~/" and
+// trimming surrounding whitespace.
+func readSecretFile(path, baseDir string) (string, error) {
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("determine home directory: %w", err)
+		}
+		path = filepath.Join(home, path[2:])
+	} else if !filepath.IsAbs(path) && baseDir != "" && baseDir != "." {
+		path = filepath.Join(baseDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return secret, nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zalando/go-keyring"
+)
+
+func TestResolveSecrets_APIKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "llm.key"), []byte("sk-from-file\n"), 0600))
+	abs := filepath.Join(dir, "embed.key")
+	require.NoError(t, os.WriteFile(abs, []byte("pa-from-file"), 0600))
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		cfg  Config
+		want string
+	}{
+		{name: "relative to config dir", cfg: Config{LLM: ProviderConfig{APIKeyFile: "llm.key"}}, want: "sk-from-file"},
+		{name: "api_key wins over file", cfg: Config{LLM: ProviderConfig{APIKey: "sk-inline", APIKeyFile: "llm.key"}}, want: "sk-inline"},
+		{
+			name: "env key wins over everything",
+			env:  map[string]string{"LLM_API_KEY": "sk-env"},
+			cfg:  Config{LLM: ProviderConfig{APIKey: "sk-env", APIKeyFile: "llm.key"}},
+			want: "sk-env",
+		},
+		{
+			name: "env file wins over api_key",
+			env:  map[string]string{"LLM_API_KEY_FILE": abs},
+			cfg:  Config{LLM: ProviderConfig{APIKey: "sk-inline", APIKeyFile: abs}},
+			want: "pa-from-file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg := tt.cfg
+			require.NoError(t, resolveSecrets(&cfg, dir))
+			assert.Equal(t, tt.want, cfg.LLM.APIKey)
+		})
+	}
+
+	cfg := Config{Reranker: ProviderConfig{APIKeyFile: "missing.key"}}
+	assert.Error(t, resolveSecrets(&cfg, dir))
+}
+
+func TestKeyringSecrets(t *testing.T) {
+	keyring.MockInit()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, SetKeyringSecret(path, "embedder.api_key", "pa-secret"))
+	assert.Error(t, SetKeyringSecret(path, "llm.model", "gpt-4o"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "keyring:\n  - embedder.api_key\n", string(data))
+
+	cfg := Config{Keyring: []string{"embedder.api_key"}}
+	require.NoError(t, resolveSecrets(&cfg, ""))
+	assert.Equal(t, "pa-secret", cfg.Embedder.APIKey)
+
+	t.Setenv("EMBED_API_KEY", "pa-env")
+	cfg = Config{Embedder: ProviderConfig{APIKey: "pa-env"}, Keyring: []string{"embedder.api_key"}}
+	require.NoError(t, resolveSecrets(&cfg, ""))
+	assert.Equal(t, "pa-env", cfg.Embedder.APIKey)
+
+	removed, err := DeleteKeyringSecret(path, "embedder.api_key")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{}\n", string(data))
+
+	cfg = Config{Keyring: []string{"embedder.api_key"}}
+	assert.Error(t, resolveSecrets(&cfg, ""))
+}
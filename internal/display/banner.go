@@ -2,12 +2,12 @@ package display
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"strings"
 )
 
-// ANSI color codes
-const (
+// ANSI color codes; DisableColor blanks them
+var (
 	reset   = "\033[0m"
 	bold    = "\033[1m"
 	dim     = "\033[2m"
@@ -59,16 +59,25 @@ type ServerInfo struct {
 	RerankModel   string
 	RerankBaseURL string
 
+	// AdminEnabled is set when AGENT_ADMIN_KEY enables the /admin API
+	AdminEnabled bool
+	// AuditLog is the audit log file; empty when auditing is off
+	AuditLog string
+
 	// Security
 	AuthEnabled bool
 
 	// Server
 	Port int
+	// GRPCPort is the port of the gRPC interface; 0 when it is off
+	GRPCPort int
+	// Dev is set by kash serve --dev
+	Dev bool
 }
 
 // PrintBanner prints a fancy colorful startup banner with all server information.
 func PrintBanner(info ServerInfo) {
-	w := os.Stdout
+	w := out
 
 	addr := fmt.Sprintf(":%d", info.Port)
 	host := fmt.Sprintf("http://localhost%s", addr)
@@ -128,6 +137,15 @@ func PrintBanner(info ServerInfo) {
 	} else {
 		printKVColored(w, "Auth", "✗ open (set AGENT_API_KEY to enable)", brightYellow)
 	}
+	if info.AdminEnabled {
+		printKVColored(w, "Admin API", "✓ /admin (AGENT_ADMIN_KEY)", brightGreen)
+	}
+	if info.AuditLog != "" {
+		printKVColored(w, "Audit Log", info.AuditLog, brightGreen)
+	}
+	if info.Dev {
+		printKVColored(w, "Mode", "development (--dev) — not for production", brightYellow)
+	}
 	fmt.Fprintln(w)
 
 	// Endpoints section
@@ -136,6 +154,11 @@ func PrintBanner(info ServerInfo) {
 	printEndpoint(w, "MCP  ", "GET ", host+"/mcp", brightCyan)
 	printEndpoint(w, "A2A  ", "POST", host+"/rpc/agent", brightMagenta)
 	printEndpoint(w, "Health", "GET ", host+"/health", green)
+	printEndpoint(w, "Card  ", "GET ", host+"/.well-known/agent.json", green)
+	printEndpoint(w, "UI    ", "GET ", host+"/ui/", brightYellow)
+	if info.GRPCPort > 0 {
+		printEndpoint(w, "gRPC ", "RPC ", fmt.Sprintf("localhost:%d", info.GRPCPort), brightBlue)
+	}
 	fmt.Fprintln(w)
 
 	// Footer
@@ -145,21 +168,84 @@ func PrintBanner(info ServerInfo) {
 	fmt.Fprintln(w)
 }
 
-func printSectionHeader(w *os.File, title string) {
+// PrintMultiBanner prints the startup banner for several agents served from
+// one process. The runtime configuration is shared, so it is taken from the
+// first agent.
+func PrintMultiBanner(names []string, infos []ServerInfo) {
+	w := out
+	if len(infos) == 0 {
+		return
+	}
+	shared := infos[0]
+	host := fmt.Sprintf("http://localhost:%d", shared.Port)
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  %s%s⚡ Kash Runtime Server%s %s(%d agents)%s\n", bold, brightCyan, reset, dim, len(infos), reset)
+	fmt.Fprintf(w, "  %s%s━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━%s\n", dim, cyan, reset)
+	fmt.Fprintln(w)
+
+	printSectionHeader(w, "🤖 Agents")
+	for i, info := range infos {
+		stats := fmt.Sprintf("%s vectors, %s triples, %d dims", formatCount(info.VectorCount), formatCount64(info.TripleCount), info.EmbedDimensions)
+		fmt.Fprintf(w, "    %s%s%s  %s%s%s  %s%s%s\n",
+			bold+brightWhite, padRight(names[i], 18), reset,
+			brightGreen, stats, reset,
+			dim+white, host+"/agents/"+names[i], reset,
+		)
+	}
+	fmt.Fprintln(w)
+
+	printSectionHeader(w, "⚙️  Runtime Configuration")
+	printKV(w, "LLM Model", shared.LLMModel, brightMagenta)
+	printKV(w, "LLM Endpoint", maskURL(shared.LLMBaseURL), dim+white)
+	printKV(w, "Embed Endpoint", maskURL(shared.EmbedBaseURL), dim+white)
+	if shared.RerankBaseURL != "" {
+		printKVColored(w, "Reranker", "✓ enabled", brightGreen)
+	} else {
+		printKVColored(w, "Reranker", "✗ disabled", dim+white)
+	}
+	if shared.AuthEnabled {
+		printKVColored(w, "Auth", "✓ API key required", brightGreen)
+	} else {
+		printKVColored(w, "Auth", "✗ open (set AGENT_API_KEY to enable)", brightYellow)
+	}
+	if shared.AdminEnabled {
+		printKVColored(w, "Admin API", "✓ /agents/<name>/admin (AGENT_ADMIN_KEY)", brightGreen)
+	}
+	fmt.Fprintln(w)
+
+	printSectionHeader(w, "🌐 Endpoints")
+	base := host + "/agents/<name>"
+	printEndpoint(w, "REST ", "POST", base+"/v1/chat/completions", brightBlue)
+	printEndpoint(w, "MCP  ", "GET ", base+"/mcp", brightCyan)
+	printEndpoint(w, "A2A  ", "POST", base+"/rpc/agent", brightMagenta)
+	printEndpoint(w, "UI    ", "GET ", base+"/ui/", brightYellow)
+	printEndpoint(w, "Health", "GET ", host+"/health", green)
+	printEndpoint(w, "Agents", "GET ", host+"/agents", green)
+	fmt.Fprintf(w, "    %sA <name>.* Host header also selects an agent, without the path prefix%s\n", dim, reset)
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "  %s%s━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━%s\n", dim, cyan, reset)
+	fmt.Fprintf(w, "  %s%s🚀 Server listening on %s%s%s%s\n", dim, white, reset, bold+brightGreen, host, reset)
+	fmt.Fprintf(w, "  %s%s━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━%s\n", dim, cyan, reset)
+	fmt.Fprintln(w)
+}
+
+func printSectionHeader(w io.Writer, title string) {
 	fmt.Fprintf(w, "  %s%s%s%s\n", bold, brightYellow, title, reset)
 }
 
-func printKV(w *os.File, key, value, valueColor string) {
+func printKV(w io.Writer, key, value, valueColor string) {
 	paddedKey := padRight(key, 18)
 	fmt.Fprintf(w, "    %s%s%s  %s%s%s\n", dim, paddedKey, reset, valueColor, value, reset)
 }
 
-func printKVColored(w *os.File, key, value, valueColor string) {
+func printKVColored(w io.Writer, key, value, valueColor string) {
 	paddedKey := padRight(key, 18)
 	fmt.Fprintf(w, "    %s%s%s  %s%s%s%s\n", dim, paddedKey, reset, bold, valueColor, value, reset)
 }
 
-func printEndpoint(w *os.File, label, method, url, color string) {
+func printEndpoint(w io.Writer, label, method, url, color string) {
 	paddedLabel := padRight(label, 8)
 	fmt.Fprintf(w, "    %s%s%s %s%s%-5s%s %s%s%s\n",
 		dim, paddedLabel, reset,
@@ -7,10 +7,11 @@ import (
 )
 
 // ────────────────────────────────────────────────────────────
-// Exported color constants for use outside the display package
+// Exported color codes for use outside the display package; empty when
+// colors are disabled
 // ────────────────────────────────────────────────────────────
 
-const (
+var (
 	Reset   = reset
 	Bold    = bold
 	Dim     = dim
@@ -39,7 +40,7 @@ const (
 
 // Step prints a build/init pipeline step like "  [1/5] Loading documents..."
 func Step(step, total int, msg string) {
-	fmt.Fprintf(os.Stdout, "  %s%s[%d/%d]%s %s%s%s\n",
+	fmt.Fprintf(out, "  %s%s[%d/%d]%s %s%s%s\n",
 		bold, brightCyan, step, total, reset,
 		white, msg, reset,
 	)
@@ -47,12 +48,12 @@ func Step(step, total int, msg string) {
 
 // StepDetail prints an indented detail line under a step.
 func StepDetail(msg string) {
-	fmt.Fprintf(os.Stdout, "        %s%s%s\n", dim+white, msg, reset)
+	fmt.Fprintf(out, "        %s%s%s\n", dim+white, msg, reset)
 }
 
 // StepResult prints a success result for a step with a highlighted value.
 func StepResult(label string, value interface{}) {
-	fmt.Fprintf(os.Stdout, "        %s%s%s %s%s%v%s\n",
+	fmt.Fprintf(out, "        %s%s%s %s%s%v%s\n",
 		dim, label, reset,
 		bold+brightGreen, "", value, reset,
 	)
@@ -60,22 +61,24 @@ func StepResult(label string, value interface{}) {
 
 // StepWarn prints a warning detail under a step.
 func StepWarn(msg string) {
-	fmt.Fprintf(os.Stdout, "        %s%s⚠ %s%s\n", yellow, bold, msg, reset)
+	warned(msg)
+	fmt.Fprintf(out, "        %s%s⚠ %s%s\n", yellow, bold, msg, reset)
 }
 
 // Info prints a general info message.
 func Info(msg string) {
-	fmt.Fprintf(os.Stdout, "  %s%sℹ%s %s\n", brightBlue, bold, reset, msg)
+	fmt.Fprintf(out, "  %s%sℹ%s %s\n", brightBlue, bold, reset, msg)
 }
 
 // Success prints a green success message.
 func Success(msg string) {
-	fmt.Fprintf(os.Stdout, "  %s%s✓%s %s\n", brightGreen, bold, reset, msg)
+	fmt.Fprintf(out, "  %s%s✓%s %s\n", brightGreen, bold, reset, msg)
 }
 
 // Warn prints a yellow warning message.
 func Warn(msg string) {
-	fmt.Fprintf(os.Stdout, "  %s%s⚠%s %s%s%s\n", brightYellow, bold, reset, yellow, msg, reset)
+	warned(msg)
+	fmt.Fprintf(out, "  %s%s⚠%s %s%s%s\n", brightYellow, bold, reset, yellow, msg, reset)
 }
 
 // Error prints a red error message.
@@ -85,39 +88,39 @@ func ErrorMsg(msg string) {
 
 // Header prints a section header line.
 func Header(msg string) {
-	fmt.Fprintln(os.Stdout)
-	fmt.Fprintf(os.Stdout, "  %s%s%s%s\n", bold, brightCyan, msg, reset)
-	fmt.Fprintf(os.Stdout, "  %s%s%s%s\n", dim, cyan, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━", reset)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  %s%s%s%s\n", bold, brightCyan, msg, reset)
+	fmt.Fprintf(out, "  %s%s%s%s\n", dim, cyan, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━", reset)
 }
 
 // SubHeader prints a smaller section divider.
 func SubHeader(msg string) {
-	fmt.Fprintf(os.Stdout, "\n  %s%s%s%s\n", bold, brightYellow, msg, reset)
+	fmt.Fprintf(out, "\n  %s%s%s%s\n", bold, brightYellow, msg, reset)
 }
 
 // KeyValue prints a labeled value.
 func KeyValue(key string, value interface{}, valueColor string) {
 	paddedKey := padRight(key, 18)
-	fmt.Fprintf(os.Stdout, "    %s%s%s  %s%v%s\n", dim, paddedKey, reset, valueColor, value, reset)
+	fmt.Fprintf(out, "    %s%s%s  %s%v%s\n", dim, paddedKey, reset, valueColor, value, reset)
 }
 
 // NextSteps prints an ordered list of next steps.
 func NextSteps(steps []string) {
-	fmt.Fprintln(os.Stdout)
-	fmt.Fprintf(os.Stdout, "  %s%s📋 Next Steps%s\n", bold, brightYellow, reset)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "  %s%s📋 Next Steps%s\n", bold, brightYellow, reset)
 	for i, step := range steps {
-		fmt.Fprintf(os.Stdout, "    %s%s%d.%s %s\n", bold, brightWhite, i+1, reset, step)
+		fmt.Fprintf(out, "    %s%s%d.%s %s\n", bold, brightWhite, i+1, reset, step)
 	}
 }
 
 // FileCreated prints a file creation notice.
 func FileCreated(path string) {
-	fmt.Fprintf(os.Stdout, "    %s%s✓%s %s%s%s\n", brightGreen, bold, reset, dim+white, path, reset)
+	fmt.Fprintf(out, "    %s%s✓%s %s%s%s\n", brightGreen, bold, reset, dim+white, path, reset)
 }
 
 // DirCreated prints a directory creation notice.
 func DirCreated(path string) {
-	fmt.Fprintf(os.Stdout, "    %s%s📁%s %s%s%s\n", brightBlue, bold, reset, dim+white, path, reset)
+	fmt.Fprintf(out, "    %s%s📁%s %s%s%s\n", brightBlue, bold, reset, dim+white, path, reset)
 }
 
 // ────────────────────────────────────────────────────────────
@@ -130,7 +133,7 @@ func LogRequest(method, path string, status int, duration time.Duration, remote
 	statusColor := colorForStatus(status)
 	dur := formatDuration(duration)
 
-	fmt.Fprintf(os.Stdout, "  %s%s%-7s%s %s%-35s%s %s%s%d%s %s%s%s %s%s%s\n",
+	fmt.Fprintf(out, "  %s%s%-7s%s %s%-35s%s %s%s%d%s %s%s%s %s%s%s\n",
 		bold, methodColor, method, reset,
 		white, path, reset,
 		bold, statusColor, status, reset,
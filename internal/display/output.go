@@ -0,0 +1,69 @@
+package display
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Colors are on only when stdout is a terminal, TERM is not "dumb", and
+// NO_COLOR (https://no-color.org) is unset; --no-color turns them off too.
+func init() {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || !term.IsTerminal(int(os.Stdout.Fd())) {
+		DisableColor()
+	}
+}
+
+// DisableColor blanks every color code, so all display output, and any
+// caller formatting with the exported codes, is plain text.
+func DisableColor() {
+	for _, c := range []*string{
+		&reset, &bold, &dim, &italic,
+		&red, &green, &yellow, &blue, &magenta, &cyan, &white,
+		&brightRed, &brightGreen, &brightYellow, &brightBlue, &brightMagenta, &brightCyan, &brightWhite,
+		&bgBlue, &bgMagenta, &bgCyan,
+		&Reset, &Bold, &Dim, &Italic,
+		&Red, &Green, &Yellow, &Blue, &Magenta, &Cyan, &White,
+		&BrightRed, &BrightGreen, &BrightYellow, &BrightBlue, &BrightMagenta, &BrightCyan, &BrightWhite,
+	} {
+		*c = ""
+	}
+}
+
+// out receives everything the display helpers print except errors, which
+// always go to stderr.
+var out io.Writer = os.Stdout
+
+// warnHook receives every warning when set; see OnWarning.
+var warnHook func(msg string)
+
+// SetOutput redirects the display helpers, e.g. to io.Discard for --quiet
+// and --json.
+func SetOutput(w io.Writer) {
+	out = w
+}
+
+// Output returns the writer the display helpers print to.
+func Output() io.Writer {
+	return out
+}
+
+// OnWarning registers fn to receive the message of every StepWarn and Warn
+// call, whether or not it is printed, so warnings can be collected for
+// machine-readable output or sent to stderr.
+func OnWarning(fn func(msg string)) {
+	warnHook = fn
+}
+
+func warned(msg string) {
+	if warnHook != nil {
+		warnHook(msg)
+	}
+}
+
+// Newline prints an empty line.
+func Newline() {
+	fmt.Fprintln(out)
+}
@@ -0,0 +1,202 @@
+// Package docstore keeps the full text of an agent's documents next to its
+// compiled stores, so the server can return a whole source document without
+// reading data/, which the container does not ship.
+package docstore
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// indexFile lists the documents inside the store directory.
+const indexFile = "index.json"
+
+// ErrNotFound is returned for a document that is not stored.
+var ErrNotFound = errors.New("document not stored")
+
+// Document describes one stored document.
+type Document struct {
+	// Name is the document identifier used as the chunk source
+	Name string `json:"name"`
+	// Origin is where the document came from: "data", "url", "git", ...
+	Origin   string            `json:"origin"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Bytes is the length of the text
+	Bytes int64 `json:"bytes"`
+	// File holds the gzipped text, relative to the store directory
+	File string `json:"file"`
+}
+
+// Writer builds a store next to its final directory; Commit swaps it into
+// place, so a server never reads a store that is half written.
+type Writer struct {
+	dir, tmp string
+	docs     []Document
+	seen     map[string]bool
+}
+
+// Create starts a new store to replace the one at dir.
+func Create(dir string) (*Writer, error) {
+	tmp := dir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, fmt.Errorf("clear %s: %w", tmp, err)
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return nil, fmt.Errorf("create document store: %w", err)
+	}
+	return &Writer{dir: dir, tmp: tmp, seen: map[string]bool{}}, nil
+}
+
+// Add stores text as the document doc describes. A second document of the
+// same name is ignored, as its chunks share one source.
+func (w *Writer) Add(doc Document, text string) error {
+	if w.seen[doc.Name] {
+		return nil
+	}
+	w.seen[doc.Name] = true
+	sum := sha256.Sum256([]byte(doc.Name))
+	doc.File = hex.EncodeToString(sum[:8]) + ".txt.gz"
+	doc.Bytes = int64(len(text))
+
+	f, err := os.Create(filepath.Join(w.tmp, doc.File))
+	if err != nil {
+		return fmt.Errorf("store %s: %w", doc.Name, err)
+	}
+	zw := gzip.NewWriter(f)
+	if _, err := io.WriteString(zw, text); err != nil {
+		f.Close()
+		return fmt.Errorf("store %s: %w", doc.Name, err)
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("store %s: %w", doc.Name, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("store %s: %w", doc.Name, err)
+	}
+	w.docs = append(w.docs, doc)
+	return nil
+}
+
+// Commit writes the index and replaces the store at dir.
+func (w *Writer) Commit() error {
+	sort.Slice(w.docs, func(i, j int) bool { return w.docs[i].Name < w.docs[j].Name })
+	data, err := json.MarshalIndent(w.docs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal document index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(w.tmp, indexFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write document index: %w", err)
+	}
+	old := w.dir + ".old"
+	os.RemoveAll(old)
+	if err := os.Rename(w.dir, old); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("replace %s: %w", w.dir, err)
+	}
+	if err := os.Rename(w.tmp, w.dir); err != nil {
+		return fmt.Errorf("replace %s: %w", w.dir, err)
+	}
+	os.RemoveAll(old)
+	return nil
+}
+
+// Abort discards the new store and leaves the one at dir as it is.
+func (w *Writer) Abort() {
+	os.RemoveAll(w.tmp)
+}
+
+// Store is a document store opened for reading. It is safe for concurrent
+// use.
+type Store struct {
+	dir    string
+	docs   []Document
+	byName map[string]int
+}
+
+// Open reads the index of the store at dir. The error wraps os.ErrNotExist
+// when the build stored no documents.
+func Open(dir string) (*Store, error) {
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil {
+		return nil, fmt.Errorf("open document store: %w", err)
+	}
+	s := &Store{dir: dir, byName: map[string]int{}}
+	if err := json.Unmarshal(data, &s.docs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Join(dir, indexFile), err)
+	}
+	for i, doc := range s.docs {
+		s.byName[doc.Name] = i
+	}
+	return s, nil
+}
+
+// List returns the stored documents, sorted by name.
+func (s *Store) List() []Document {
+	return s.docs
+}
+
+// Get returns the document named name.
+func (s *Store) Get(name string) (Document, bool) {
+	i, ok := s.byName[name]
+	if !ok {
+		return Document{}, false
+	}
+	return s.docs[i], true
+}
+
+// Size returns the length of the text of the document named name.
+func (s *Store) Size(name string) (int64, bool) {
+	doc, ok := s.Get(name)
+	return doc.Bytes, ok
+}
+
+// Open returns a reader of the text of the document named name.
+func (s *Store) Open(name string) (io.ReadCloser, error) {
+	doc, ok := s.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	f, err := os.Open(filepath.Join(s.dir, doc.File))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	return readCloser{zr, f}, nil
+}
+
+// Text returns the text of the document named name.
+func (s *Store) Text(name string) (string, error) {
+	rc, err := s.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// readCloser reads the gzip stream and closes the file under it.
+type readCloser struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (r readCloser) Close() error {
+	r.Reader.Close()
+	return r.f.Close()
+}
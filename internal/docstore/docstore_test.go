@@ -0,0 +1,70 @@
+package docstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "documents.store")
+
+	_, err := Open(dir)
+	assert.True(t, errors.Is(err, os.ErrNotExist), "a build without stored documents has no store")
+
+	for _, texts := range []map[string]string{
+		{"guide.md": "old guide"},
+		{"guide.md": "# Guide\n\nKash compiles documents.", "faq.txt": "Refunds take 14 days."},
+	} {
+		w, err := Create(dir)
+		require.NoError(t, err)
+		for name, text := range texts {
+			require.NoError(t, w.Add(Document{Name: name, Origin: "data"}, text))
+		}
+		require.NoError(t, w.Add(Document{Name: "faq.txt", Origin: "url"}, "ignored"), "a repeated name is ignored")
+		require.NoError(t, w.Commit())
+	}
+	_, err = os.Stat(dir + ".tmp")
+	assert.True(t, os.IsNotExist(err), "commit leaves no temporary store")
+
+	s, err := Open(dir)
+	require.NoError(t, err)
+	docs := s.List()
+	require.Len(t, docs, 2)
+	assert.Equal(t, "faq.txt", docs[0].Name, "documents are listed by name")
+	assert.Equal(t, "data", docs[0].Origin)
+
+	text, err := s.Text("guide.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Guide\n\nKash compiles documents.", text)
+	size, ok := s.Size("guide.md")
+	assert.True(t, ok)
+	assert.Equal(t, int64(len(text)), size)
+
+	_, err = s.Text("missing.md")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, ok = s.Size("missing.md")
+	assert.False(t, ok)
+}
+
+func TestAbort(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "documents.store")
+	w, err := Create(dir)
+	require.NoError(t, err)
+	require.NoError(t, w.Add(Document{Name: "guide.md"}, "guide"))
+	require.NoError(t, w.Commit())
+
+	w, err = Create(dir)
+	require.NoError(t, err)
+	require.NoError(t, w.Add(Document{Name: "other.md"}, "other"))
+	w.Abort()
+
+	s, err := Open(dir)
+	require.NoError(t, err)
+	_, ok := s.Get("guide.md")
+	assert.True(t, ok, "an aborted store leaves the previous one")
+}
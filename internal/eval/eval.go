@@ -0,0 +1,300 @@
+// Package eval measures retrieval quality against a user-supplied set of
+// questions with known relevant sources or answer snippets.
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// DefaultPath is where kash eval looks for the question set.
+const DefaultPath = "eval.yaml"
+
+// DefaultK is the number of results retrieved per question when the suite
+// does not set k.
+const DefaultK = 5
+
+// Suite is a set of evaluation questions loaded from YAML.
+type Suite struct {
+	// K is the number of results retrieved per question
+	K     int    `yaml:"k"`
+	Cases []Case `yaml:"questions"`
+}
+
+// Case is one question with the evidence a good retrieval should surface.
+// A result is relevant when its source matches one of Sources or its text
+// contains one of Snippets. Answer is an optional golden answer used when
+// grading generated answers.
+type Case struct {
+	Question string            `yaml:"question"`
+	Sources  []string          `yaml:"sources"`
+	Snippets []string          `yaml:"snippets"`
+	Answer   string            `yaml:"answer"`
+	Filter   map[string]string `yaml:"filter"`
+}
+
+// Retriever runs a vector search. *vector.Store satisfies it.
+type Retriever interface {
+	QueryFiltered(ctx context.Context, query string, topK int, filter map[string]string) ([]vector.SearchResult, error)
+}
+
+// CaseResult is the outcome of one question.
+type CaseResult struct {
+	Question string `json:"question"`
+	// Rank is the 1-based position of the first relevant result, or 0 when
+	// none of the top K results was relevant
+	Rank int `json:"rank"`
+	// Recall is the fraction of expected sources and snippets found in the
+	// top K results
+	Recall    float64  `json:"recall"`
+	Retrieved []string `json:"retrieved"`
+	Missing   []string `json:"missing,omitempty"`
+	Error     string   `json:"error,omitempty"`
+
+	// Answer, Cited, and Scores are set when answers are evaluated
+	Answer string        `json:"answer,omitempty"`
+	Cited  []string      `json:"cited,omitempty"`
+	Scores *AnswerScores `json:"scores,omitempty"`
+}
+
+// Report summarizes a suite run.
+type Report struct {
+	K int `json:"k"`
+	// Strategy is the retrieval strategy the questions were run with
+	Strategy  string  `json:"strategy,omitempty"`
+	Questions int     `json:"questions"`
+	RecallAtK float64 `json:"recall_at_k"`
+	HitRate   float64 `json:"hit_rate"`
+	MRR       float64 `json:"mrr"`
+	// Answers summarizes LLM-judged answer quality when it was evaluated
+	Answers *AnswerSummary `json:"answers,omitempty"`
+	Cases   []CaseResult   `json:"cases"`
+}
+
+// Misses returns the questions for which nothing relevant was retrieved.
+func (r *Report) Misses() []CaseResult {
+	var out []CaseResult
+	for _, c := range r.Cases {
+		if c.Rank == 0 {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// LoadSuite reads and validates a question set.
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read eval suite: %w", err)
+	}
+	var s Suite
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse eval suite %q: %w", path, err)
+	}
+	if len(s.Cases) == 0 {
+		return nil, fmt.Errorf("eval suite %q has no questions", path)
+	}
+	for i, c := range s.Cases {
+		if strings.TrimSpace(c.Question) == "" {
+			return nil, fmt.Errorf("eval suite %q: question %d is empty", path, i+1)
+		}
+		if len(c.Sources) == 0 && len(c.Snippets) == 0 {
+			return nil, fmt.Errorf("eval suite %q: question %q needs sources or snippets", path, c.Question)
+		}
+	}
+	if s.K <= 0 {
+		s.K = DefaultK
+	}
+	return &s, nil
+}
+
+// Run retrieves the top K results for every question and scores them.
+// A failed query is recorded on its case and counts as a miss. When
+// opts.Generator is set, each question is also answered from its results and
+// the answer graded by opts.Judge.
+func Run(ctx context.Context, r Retriever, s *Suite, opts Options) (*Report, error) {
+	if s == nil || len(s.Cases) == 0 {
+		return nil, errors.New("eval suite is empty")
+	}
+	k := s.K
+	if k <= 0 {
+		k = DefaultK
+	}
+
+	report := &Report{K: k, Questions: len(s.Cases)}
+	var recallSum, rrSum float64
+	hits := 0
+	for _, c := range s.Cases {
+		results, err := r.QueryFiltered(ctx, c.Question, k, c.Filter)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			report.Cases = append(report.Cases, CaseResult{Question: c.Question, Error: err.Error(), Missing: expected(c)})
+			continue
+		}
+		cr := Score(c, results)
+		if opts.Generator != nil {
+			if err := evaluateAnswer(ctx, opts, c, results, &cr); err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				cr.Error = err.Error()
+			}
+		}
+		report.Cases = append(report.Cases, cr)
+		recallSum += cr.Recall
+		if cr.Rank > 0 {
+			hits++
+			rrSum += 1 / float64(cr.Rank)
+		}
+	}
+
+	n := float64(len(s.Cases))
+	report.RecallAtK = recallSum / n
+	report.HitRate = float64(hits) / n
+	report.MRR = rrSum / n
+	if opts.Generator != nil {
+		report.Answers = summarizeAnswers(report.Cases)
+	}
+	return report, nil
+}
+
+// evaluateAnswer generates and grades an answer for one case. Citations of
+// passages that were never retrieved count against citation accuracy.
+func evaluateAnswer(ctx context.Context, opts Options, c Case, results []vector.SearchResult, cr *CaseResult) error {
+	text, err := answer(ctx, opts.Generator, opts.SystemPrompt, c.Question, results)
+	if err != nil {
+		return fmt.Errorf("generate answer: %w", err)
+	}
+	cr.Answer = text
+
+	j := opts.Judge
+	if j == nil {
+		j = opts.Generator
+	}
+	scores, err := judge(ctx, j, c, text, results)
+	if err != nil {
+		return fmt.Errorf("judge answer: %w", err)
+	}
+
+	cited := Citations(text)
+	valid := 0
+	for _, n := range cited {
+		if n >= 1 && n <= len(results) {
+			valid++
+			cr.Cited = append(cr.Cited, results[n-1].Source)
+		}
+	}
+	if len(cited) > 0 {
+		scores.CitationAccuracy *= float64(valid) / float64(len(cited))
+	}
+	cr.Scores = scores
+	return nil
+}
+
+// Score grades one question's results.
+func Score(c Case, results []vector.SearchResult) CaseResult {
+	cr := CaseResult{Question: c.Question}
+	found := map[string]bool{}
+	for i, r := range results {
+		cr.Retrieved = append(cr.Retrieved, r.Source)
+		relevant := false
+		for _, src := range c.Sources {
+			if sourceMatches(r.Source, src) {
+				found["source:"+src] = true
+				relevant = true
+			}
+		}
+		for _, snip := range c.Snippets {
+			if containsFold(r.Content, snip) {
+				found["snippet:"+snip] = true
+				relevant = true
+			}
+		}
+		if relevant && cr.Rank == 0 {
+			cr.Rank = i + 1
+		}
+	}
+
+	total := 0
+	for _, src := range c.Sources {
+		total++
+		if !found["source:"+src] {
+			cr.Missing = append(cr.Missing, src)
+		}
+	}
+	for _, snip := range c.Snippets {
+		total++
+		if !found["snippet:"+snip] {
+			cr.Missing = append(cr.Missing, fmt.Sprintf("%q", snip))
+		}
+	}
+	if total > 0 {
+		cr.Recall = float64(total-len(cr.Missing)) / float64(total)
+	}
+	return cr
+}
+
+// expected lists every source and snippet a case is looking for.
+func expected(c Case) []string {
+	out := append([]string(nil), c.Sources...)
+	for _, snip := range c.Snippets {
+		out = append(out, fmt.Sprintf("%q", snip))
+	}
+	return out
+}
+
+// sourceMatches compares a chunk source with an expected source. A bare file
+// name matches that file in any directory or remote source prefix.
+func sourceMatches(got, want string) bool {
+	got, want = strings.ToLower(got), strings.ToLower(strings.TrimSpace(want))
+	if got == want {
+		return true
+	}
+	return !strings.Contains(want, "/") && path.Base(got) == want
+}
+
+// containsFold reports whether text contains snippet, ignoring case and
+// differences in whitespace.
+func containsFold(text, snippet string) bool {
+	norm := func(s string) string { return strings.ToLower(strings.Join(strings.Fields(s), " ")) }
+	snippet = norm(snippet)
+	return snippet != "" && strings.Contains(norm(text), snippet)
+}
+
+// Save writes the report as indented JSON.
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal eval report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write eval report: %w", err)
+	}
+	return nil
+}
+
+// LoadReport reads a report previously written by Save, e.g. to compare a
+// run against a baseline taken before a configuration change.
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read eval report: %w", err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse eval report %q: %w", path, err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,127 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+type fakeRetriever map[string][]vector.SearchResult
+
+func (f fakeRetriever) QueryFiltered(_ context.Context, query string, topK int, _ map[string]string) ([]vector.SearchResult, error) {
+	results, ok := f[query]
+	if !ok {
+		return nil, errors.New("boom")
+	}
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+func TestScore(t *testing.T) {
+	results := []vector.SearchResult{
+		{Source: "docs/intro.md", Content: "Welcome."},
+		{Source: "docs/billing-faq.md", Content: "Refunds are issued within 14 days."},
+		{Source: "terms.pdf", Content: "Refund   policy applies."},
+	}
+
+	tests := []struct {
+		name       string
+		c          Case
+		wantRank   int
+		wantRecall float64
+		wantMiss   []string
+	}{
+		{
+			name:       "bare file name matches nested source",
+			c:          Case{Sources: []string{"billing-faq.md"}},
+			wantRank:   2,
+			wantRecall: 1,
+		},
+		{
+			name:       "snippet match ignores case and spacing",
+			c:          Case{Snippets: []string{"refund policy"}},
+			wantRank:   3,
+			wantRecall: 1,
+		},
+		{
+			name:       "partial recall",
+			c:          Case{Sources: []string{"billing-faq.md", "pricing.md"}},
+			wantRank:   2,
+			wantRecall: 0.5,
+			wantMiss:   []string{"pricing.md"},
+		},
+		{
+			name:     "miss",
+			c:        Case{Sources: []string{"other/billing-faq.md"}, Snippets: []string{"30 days"}},
+			wantMiss: []string{"other/billing-faq.md", `"30 days"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Score(tt.c, results)
+			assert.Equal(t, tt.wantRank, got.Rank)
+			assert.InDelta(t, tt.wantRecall, got.Recall, 1e-9)
+			assert.Equal(t, tt.wantMiss, got.Missing)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	r := fakeRetriever{
+		"first":  {{Source: "a.md"}, {Source: "b.md"}},
+		"second": {{Source: "c.md"}, {Source: "a.md"}},
+		"third":  {{Source: "c.md"}},
+	}
+	suite := &Suite{K: 2, Cases: []Case{
+		{Question: "first", Sources: []string{"a.md"}},
+		{Question: "second", Sources: []string{"a.md"}},
+		{Question: "third", Sources: []string{"a.md"}},
+		{Question: "broken", Sources: []string{"a.md"}},
+	}}
+
+	report, err := Run(context.Background(), r, suite, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Questions)
+	assert.InDelta(t, 0.5, report.RecallAtK, 1e-9)
+	assert.InDelta(t, 0.5, report.HitRate, 1e-9)
+	assert.InDelta(t, (1+0.5)/4, report.MRR, 1e-9)
+
+	misses := report.Misses()
+	require.Len(t, misses, 2)
+	assert.Equal(t, "third", misses[0].Question)
+	assert.Equal(t, "boom", misses[1].Error)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	require.NoError(t, report.Save(path))
+	loaded, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, report, loaded)
+}
+
+func TestLoadSuite(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(body), 0o644))
+		return p
+	}
+
+	s, err := LoadSuite(write("ok.yaml", "questions:\n  - question: Q?\n    sources: [a.md]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultK, s.K)
+
+	_, err = LoadSuite(write("empty.yaml", "k: 3\n"))
+	assert.ErrorContains(t, err, "no questions")
+
+	_, err = LoadSuite(write("noexp.yaml", "questions:\n  - question: Q?\n"))
+	assert.ErrorContains(t, err, "needs sources or snippets")
+}
@@ -0,0 +1,188 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// LLM sends a single-turn prompt to a chat model. *llm.Client satisfies it.
+type LLM interface {
+	Complete(ctx context.Context, systemPrompt, userMessage string) (string, error)
+}
+
+// Options enables end-to-end answer evaluation in Run. When Generator is
+// nil only retrieval is measured.
+type Options struct {
+	// Generator answers each question from the retrieved passages
+	Generator LLM
+	// Judge grades the answers; it defaults to Generator
+	Judge LLM
+	// SystemPrompt is the agent's persona, prepended to the answer prompt
+	SystemPrompt string
+}
+
+// AnswerScores are the LLM-judged grades of one generated answer, each
+// between 0 and 1.
+type AnswerScores struct {
+	// Faithfulness is how well every claim is supported by the passages
+	Faithfulness float64 `json:"faithfulness"`
+	// Relevance is how directly the answer addresses the question
+	Relevance float64 `json:"relevance"`
+	// CitationAccuracy is the share of [n] citations whose passage supports
+	// the sentence citing it
+	CitationAccuracy float64 `json:"citation_accuracy"`
+	// Correctness is agreement with the golden answer, when the case has one
+	Correctness *float64 `json:"correctness,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+}
+
+// AnswerSummary averages AnswerScores over the judged questions.
+type AnswerSummary struct {
+	Judged           int      `json:"judged"`
+	Faithfulness     float64  `json:"faithfulness"`
+	Relevance        float64  `json:"relevance"`
+	CitationAccuracy float64  `json:"citation_accuracy"`
+	Correctness      *float64 `json:"correctness,omitempty"`
+}
+
+const answerInstructions = `Answer the question using only the numbered passages below. Cite the passages that support each sentence as [1], [2], and so on. If the passages do not contain the answer, say so.`
+
+const judgeSystemPrompt = `You grade answers produced by a retrieval-augmented assistant. Reply with a single JSON object and nothing else:
+{"faithfulness": 0-1, "relevance": 0-1, "citation_accuracy": 0-1, "correctness": 0-1 or null, "reason": "one sentence"}
+
+- faithfulness: fraction of the answer's claims supported by the passages (1 = no unsupported claims)
+- relevance: how directly and completely the answer addresses the question
+- citation_accuracy: fraction of [n] citations whose passage actually supports the cited sentence (1 if the answer needs and has no citations, 0 if it makes claims without any)
+- correctness: agreement with the reference answer; null when no reference answer is given`
+
+// answer generates a cited answer to the question from results.
+func answer(ctx context.Context, gen LLM, systemPrompt, question string, results []vector.SearchResult) (string, error) {
+	prompt := strings.TrimSpace(systemPrompt + "\n\n" + answerInstructions)
+	return gen.Complete(ctx, prompt, formatPassages(results)+"\nQuestion: "+question)
+}
+
+// judge grades an answer against the passages it was generated from.
+func judge(ctx context.Context, j LLM, c Case, answerText string, results []vector.SearchResult) (*AnswerScores, error) {
+	var sb strings.Builder
+	sb.WriteString(formatPassages(results))
+	sb.WriteString("\nQuestion: " + c.Question + "\n")
+	if c.Answer != "" {
+		sb.WriteString("\nReference answer: " + c.Answer + "\n")
+	}
+	sb.WriteString("\nAnswer to grade:\n" + answerText + "\n")
+
+	raw, err := j.Complete(ctx, judgeSystemPrompt, sb.String())
+	if err != nil {
+		return nil, err
+	}
+	scores, err := parseScores(raw)
+	if err != nil {
+		return nil, err
+	}
+	if c.Answer == "" {
+		scores.Correctness = nil
+	}
+	return scores, nil
+}
+
+func formatPassages(results []vector.SearchResult) string {
+	var sb strings.Builder
+	for i, r := range results {
+		fmt.Fprintf(&sb, "[%d] Source: %s\n%s\n\n", i+1, r.Source, strings.TrimSpace(r.Content))
+	}
+	return sb.String()
+}
+
+// parseScores extracts the judge's JSON verdict, tolerating surrounding prose
+// or code fences, and clamps every score to [0, 1].
+func parseScores(raw string) (*AnswerScores, error) {
+	start, end := strings.Index(raw, "{"), strings.LastIndex(raw, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("judge returned no JSON object: %q", truncate(raw, 200))
+	}
+	var s AnswerScores
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &s); err != nil {
+		return nil, fmt.Errorf("parse judge verdict: %w", err)
+	}
+	s.Faithfulness = clamp01(s.Faithfulness)
+	s.Relevance = clamp01(s.Relevance)
+	s.CitationAccuracy = clamp01(s.CitationAccuracy)
+	if s.Correctness != nil {
+		v := clamp01(*s.Correctness)
+		s.Correctness = &v
+	}
+	return &s, nil
+}
+
+var citationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// Citations returns the distinct passage numbers cited in an answer, in
+// order of first appearance.
+func Citations(answerText string) []int {
+	var out []int
+	seen := map[int]bool{}
+	for _, m := range citationPattern.FindAllStringSubmatch(answerText, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	return out
+}
+
+// summarizeAnswers averages the scores of every judged case.
+func summarizeAnswers(cases []CaseResult) *AnswerSummary {
+	sum := &AnswerSummary{}
+	var correctness float64
+	withReference := 0
+	for _, c := range cases {
+		if c.Scores == nil {
+			continue
+		}
+		sum.Judged++
+		sum.Faithfulness += c.Scores.Faithfulness
+		sum.Relevance += c.Scores.Relevance
+		sum.CitationAccuracy += c.Scores.CitationAccuracy
+		if c.Scores.Correctness != nil {
+			withReference++
+			correctness += *c.Scores.Correctness
+		}
+	}
+	if sum.Judged == 0 {
+		return sum
+	}
+	n := float64(sum.Judged)
+	sum.Faithfulness /= n
+	sum.Relevance /= n
+	sum.CitationAccuracy /= n
+	if withReference > 0 {
+		avg := correctness / float64(withReference)
+		sum.Correctness = &avg
+	}
+	return sum
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
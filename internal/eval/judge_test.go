@@ -0,0 +1,93 @@
+package eval
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLLM func(systemPrompt, userMessage string) (string, error)
+
+func (f fakeLLM) Complete(_ context.Context, systemPrompt, userMessage string) (string, error) {
+	return f(systemPrompt, userMessage)
+}
+
+func TestParseScores(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    AnswerScores
+		wantErr bool
+	}{
+		{
+			name: "fenced with prose",
+			raw:  "Here you go:\n```json\n{\"faithfulness\": 0.9, \"relevance\": 1, \"citation_accuracy\": 0.5, \"correctness\": null, \"reason\": \"ok\"}\n```",
+			want: AnswerScores{Faithfulness: 0.9, Relevance: 1, CitationAccuracy: 0.5, Reason: "ok"},
+		},
+		{
+			name: "clamped",
+			raw:  `{"faithfulness": 1.4, "relevance": -1, "citation_accuracy": 1}`,
+			want: AnswerScores{Faithfulness: 1, Relevance: 0, CitationAccuracy: 1},
+		},
+		{name: "no json", raw: "looks fine to me", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseScores(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+}
+
+func TestCitations(t *testing.T) {
+	assert.Equal(t, []int{2, 1, 10}, Citations("Refunds take 14 days [2][1]. See also [2] and [10]."))
+	assert.Empty(t, Citations("No citations here."))
+}
+
+func TestRun_Answers(t *testing.T) {
+	r := fakeRetriever{
+		"How long do refunds take?": {
+			{Source: "billing.md", Content: "Refunds are issued within 14 days."},
+			{Source: "intro.md", Content: "Welcome."},
+		},
+	}
+	gen := fakeLLM(func(system, user string) (string, error) {
+		assert.Contains(t, system, "You are a billing expert.")
+		assert.Contains(t, user, "[1] Source: billing.md")
+		return "Within 14 days [1][7].", nil
+	})
+	judge := fakeLLM(func(_, user string) (string, error) {
+		assert.Contains(t, user, "Reference answer: 14 days")
+		return `{"faithfulness": 1, "relevance": 0.8, "citation_accuracy": 1, "correctness": 1, "reason": "matches"}`, nil
+	})
+	suite := &Suite{K: 2, Cases: []Case{
+		{Question: "How long do refunds take?", Sources: []string{"billing.md"}, Answer: "14 days"},
+	}}
+
+	report, err := Run(context.Background(), r, suite, Options{Generator: gen, Judge: judge, SystemPrompt: "You are a billing expert."})
+	require.NoError(t, err)
+
+	c := report.Cases[0]
+	assert.Equal(t, "Within 14 days [1][7].", c.Answer)
+	assert.Equal(t, []string{"billing.md"}, c.Cited)
+	require.NotNil(t, c.Scores)
+	assert.InDelta(t, 0.5, c.Scores.CitationAccuracy, 1e-9, "citing a passage that was not retrieved halves accuracy")
+
+	require.NotNil(t, report.Answers)
+	assert.Equal(t, 1, report.Answers.Judged)
+	assert.InDelta(t, 0.8, report.Answers.Relevance, 1e-9)
+	require.NotNil(t, report.Answers.Correctness)
+	assert.InDelta(t, 1, *report.Answers.Correctness, 1e-9)
+
+	md := report.Markdown()
+	assert.Contains(t, md, "| Faithfulness | 1.000 |")
+	assert.True(t, strings.Contains(md, "| How long do refunds take? | 1 | 1.00 | 1.00 | 0.80 | 0.50 | matches |"), md)
+}
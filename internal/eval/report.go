@@ -0,0 +1,69 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders the report as a Markdown document, e.g. for a CI job
+// summary or a pull request comment.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("# Kash eval report\n\n")
+	sb.WriteString("| Metric | Value |\n|---|---|\n")
+	fmt.Fprintf(&sb, "| Questions | %d |\n", r.Questions)
+	if r.Strategy != "" {
+		fmt.Fprintf(&sb, "| Strategy | %s |\n", r.Strategy)
+	}
+	fmt.Fprintf(&sb, "| Recall@%d | %.3f |\n", r.K, r.RecallAtK)
+	fmt.Fprintf(&sb, "| Hit rate@%d | %.3f |\n", r.K, r.HitRate)
+	fmt.Fprintf(&sb, "| MRR | %.3f |\n", r.MRR)
+	if a := r.Answers; a != nil {
+		fmt.Fprintf(&sb, "| Answers judged | %d |\n", a.Judged)
+		fmt.Fprintf(&sb, "| Faithfulness | %.3f |\n", a.Faithfulness)
+		fmt.Fprintf(&sb, "| Answer relevance | %.3f |\n", a.Relevance)
+		fmt.Fprintf(&sb, "| Citation accuracy | %.3f |\n", a.CitationAccuracy)
+		if a.Correctness != nil {
+			fmt.Fprintf(&sb, "| Correctness | %.3f |\n", *a.Correctness)
+		}
+	}
+
+	sb.WriteString("\n## Questions\n\n")
+	if r.Answers != nil {
+		sb.WriteString("| Question | Rank | Recall | Faithful | Relevant | Citations | Notes |\n|---|---|---|---|---|---|---|\n")
+	} else {
+		sb.WriteString("| Question | Rank | Recall | Notes |\n|---|---|---|---|\n")
+	}
+	for _, c := range r.Cases {
+		rank := "miss"
+		if c.Rank > 0 {
+			rank = fmt.Sprintf("%d", c.Rank)
+		}
+		notes := c.Error
+		if notes == "" && len(c.Missing) > 0 {
+			notes = "missing " + strings.Join(c.Missing, ", ")
+		}
+		if r.Answers == nil {
+			fmt.Fprintf(&sb, "| %s | %s | %.2f | %s |\n", cell(c.Question), rank, c.Recall, cell(notes))
+			continue
+		}
+		faithful, relevant, citations := "-", "-", "-"
+		if s := c.Scores; s != nil {
+			faithful = fmt.Sprintf("%.2f", s.Faithfulness)
+			relevant = fmt.Sprintf("%.2f", s.Relevance)
+			citations = fmt.Sprintf("%.2f", s.CitationAccuracy)
+			if notes == "" {
+				notes = s.Reason
+			}
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %.2f | %s | %s | %s | %s |\n",
+			cell(c.Question), rank, c.Recall, faithful, relevant, citations, cell(notes))
+	}
+	return sb.String()
+}
+
+// cell escapes text for use inside a Markdown table cell.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
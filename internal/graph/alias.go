@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// aliasPredicates are the predicates, lowercased without spaces or
+// punctuation, of triples whose subject and object name the same thing.
+var aliasPredicates = map[string]bool{
+	"sameas":         true,
+	"owlsameas":      true,
+	"alsoknownas":    true,
+	"aka":            true,
+	"alias":          true,
+	"aliasof":        true,
+	"isaliasof":      true,
+	"abbreviationof": true,
+	"abbreviatedas":  true,
+	"shortfor":       true,
+	"isshortfor":     true,
+}
+
+// aliases expands query words into the other names of what they name: the
+// synonyms of SetSynonyms and the subjects and objects of the graph's
+// sameAs triples. The graph's are gathered on the first search after it
+// changes.
+type aliases struct {
+	mu       sync.Mutex
+	synonyms map[string][]string
+	graph    map[string][]string
+	built    bool
+}
+
+// invalidate has the next search gather the graph's aliases again.
+func (a *aliases) invalidate() {
+	a.mu.Lock()
+	a.built = false
+	a.graph = nil
+	a.mu.Unlock()
+}
+
+// SetSynonyms sets groups of words Search treats as one, in addition to the
+// sameAs triples of the graph: each key and its values are aliases of each
+// other, e.g. {k8s: [kubernetes]}. Words compare case-insensitively.
+func (db *DB) SetSynonyms(synonyms map[string][]string) {
+	m := map[string][]string{}
+	for word, alts := range synonyms {
+		group := append([]string{word}, alts...)
+		for _, a := range group {
+			for _, b := range group {
+				link(m, a, b)
+			}
+		}
+	}
+	db.aliases.mu.Lock()
+	db.aliases.synonyms = m
+	db.aliases.mu.Unlock()
+}
+
+// expand groups each query term with its aliases, the term first.
+func (db *DB) expand(ctx context.Context, terms []string) [][]string {
+	a := &db.aliases
+	a.mu.Lock()
+	if !a.built {
+		a.graph = db.sameAs(ctx)
+		a.built = true
+	}
+	synonyms, graphAliases := a.synonyms, a.graph
+	a.mu.Unlock()
+
+	groups := make([][]string, len(terms))
+	for i, term := range terms {
+		group := []string{term}
+		seen := map[string]bool{term: true}
+		for _, alts := range [][]string{synonyms[term], graphAliases[term]} {
+			for _, alt := range alts {
+				if !seen[alt] {
+					seen[alt] = true
+					group = append(group, alt)
+				}
+			}
+		}
+		groups[i] = group
+	}
+	return groups
+}
+
+// sameAs maps the subjects and objects of alias triples, lowercased, to each
+// other.
+func (db *DB) sameAs(ctx context.Context) map[string][]string {
+	m := map[string][]string{}
+	it := db.store.QuadsAllIterator()
+	defer it.Close()
+	for it.Next(ctx) {
+		q := db.store.Quad(it.Result())
+		pred := strings.Map(func(r rune) rune {
+			if !unicode.IsLetter(r) {
+				return -1
+			}
+			return unicode.ToLower(r)
+		}, quadValueStr(q.Predicate))
+		if !aliasPredicates[pred] {
+			continue
+		}
+		subj, obj := quadValueStr(q.Subject), quadValueStr(q.Object)
+		link(m, subj, obj)
+		link(m, obj, subj)
+	}
+	return m
+}
+
+// link records b as an alias of a, lowercased.
+func link(m map[string][]string, a, b string) {
+	a, b = strings.ToLower(strings.TrimSpace(a)), strings.ToLower(strings.TrimSpace(b))
+	if a == "" || b == "" || a == b {
+		return
+	}
+	for _, have := range m[a] {
+		if have == b {
+			return
+		}
+	}
+	m[a] = append(m[a], b)
+}
@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchAliases(t *testing.T) {
+	db, err := NewDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	require.NoError(t, db.AddTriples(ctx, []Triple{
+		{Subject: "Kubernetes", Predicate: "schedules", Object: "containers"},
+		{Subject: "Postgres", Predicate: "stores", Object: "invoices"},
+	}))
+
+	got, err := db.Search(ctx, "k8s", 5)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	db.SetSynonyms(map[string][]string{"K8s": {"kubernetes"}})
+	got, err = db.Search(ctx, "what does k8s do", 5)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "Kubernetes", got[0].Subject)
+	assert.Equal(t, []string{"k8s"}, MatchedTerms("what does k8s do", got[0]), "the match is named after the query word")
+
+	// Short words count when they have aliases
+	require.NoError(t, db.AddTriples(ctx, []Triple{{Subject: "PG", Predicate: "same as", Object: "Postgres"}}))
+	got, err = db.Search(ctx, "pg invoices", 5)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "Postgres", got[0].Subject)
+	assert.Equal(t, 2.0, got[0].Score)
+}
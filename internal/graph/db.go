@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/cayleygraph/cayley"
@@ -27,11 +31,17 @@ type SearchResult struct {
 	Predicate string  `json:"predicate"`
 	Object    string  `json:"object"`
 	Score     float64 `json:"score"`
+	// Matched are the query words Search matched in the triple, directly
+	// or through an alias
+	Matched []string `json:"-"`
 }
 
 // DB wraps a cayley graph database.
 type DB struct {
 	store *cayley.Handle
+	// snapshotDir is the private copy opened by OpenSnapshot, removed on Close
+	snapshotDir string
+	aliases     aliases
 }
 
 // NewDB creates a new in-memory graph DB.
@@ -58,6 +68,58 @@ func NewDBFromPath(path string) (*DB, error) {
 	return &DB{store: store}, nil
 }
 
+// OpenSnapshot copies the bolt graph at path to a temporary directory and
+// opens the copy. The original stays unlocked, so 'kash build' can rewrite it
+// while a server reads the snapshot; Close removes the copy. A graph store
+// that does not exist, as after 'kash build --skip-graph', opens as an empty
+// in-memory graph.
+func OpenSnapshot(path string) (*DB, error) {
+	entries, err := os.ReadDir(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewDB()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read graph store %q: %w", path, err)
+	}
+	dir, err := os.MkdirTemp("", "kash-graph-")
+	if err != nil {
+		return nil, fmt.Errorf("create graph snapshot dir: %w", err)
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if err := copyFile(filepath.Join(path, e.Name()), filepath.Join(dir, e.Name())); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("snapshot graph store %q: %w", path, err)
+		}
+	}
+	db, err := NewDBFromPath(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	db.snapshotDir = dir
+	return db, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
 // AddTriples inserts a batch of triples into the graph.
 func (db *DB) AddTriples(ctx context.Context, triples []Triple) error {
 	if len(triples) == 0 {
@@ -80,9 +142,57 @@ func (db *DB) AddTriples(ctx context.Context, triples []Triple) error {
 	if err := db.store.AddQuadSet(quads); err != nil {
 		return fmt.Errorf("add quads: %w", err)
 	}
+	db.aliases.invalidate()
 	return nil
 }
 
+// RemoveTriples deletes the given triples from the graph and returns how
+// many it found. Triples are compared after trimming, as AddTriples stores
+// them.
+func (db *DB) RemoveTriples(ctx context.Context, triples []Triple) (int, error) {
+	remove := make(map[Triple]bool, len(triples))
+	for _, t := range triples {
+		remove[Triple{Subject: normalise(t.Subject), Predicate: normalise(t.Predicate), Object: normalise(t.Object)}] = true
+	}
+	if len(remove) == 0 {
+		return 0, nil
+	}
+
+	it := db.store.QuadsAllIterator()
+	var quads []quad.Quad
+	found := map[Triple]bool{}
+	for it.Next(ctx) {
+		q := db.store.Quad(it.Result())
+		t := Triple{
+			Subject:   quadValueStr(q.Subject),
+			Predicate: quadValueStr(q.Predicate),
+			Object:    quadValueStr(q.Object),
+		}
+		if remove[t] {
+			quads = append(quads, q)
+			found[t] = true
+		}
+	}
+	err := it.Err()
+	it.Close()
+	if err != nil {
+		return 0, fmt.Errorf("iterate quads: %w", err)
+	}
+	if len(quads) == 0 {
+		return 0, nil
+	}
+
+	tx := cayley.NewTransaction()
+	for _, q := range quads {
+		tx.RemoveQuad(q)
+	}
+	if err := db.store.ApplyTransaction(tx); err != nil {
+		return 0, fmt.Errorf("remove quads: %w", err)
+	}
+	db.aliases.invalidate()
+	return len(found), nil
+}
+
 // Search queries the graph for entities related to the query terms.
 func (db *DB) Search(ctx context.Context, query string, topK int) ([]SearchResult, error) {
 	if query == "" {
@@ -92,7 +202,7 @@ func (db *DB) Search(ctx context.Context, query string, topK int) ([]SearchResul
 		topK = 10
 	}
 
-	queryTerms := strings.Fields(strings.ToLower(query))
+	queryTerms := db.expand(ctx, strings.Fields(strings.ToLower(query)))
 	results := []SearchResult{}
 	seen := map[string]bool{}
 
@@ -112,15 +222,19 @@ func (db *DB) Search(ctx context.Context, query string, topK int) ([]SearchResul
 			continue
 		}
 
-		score := scoreMatch(queryTerms, subj, pred, obj)
-		if score > 0 {
+		matched := matchTerms(queryTerms, subj, pred, obj)
+		if len(matched) > 0 {
 			seen[key] = true
-			results = append(results, SearchResult{
+			r := SearchResult{
 				Subject:   subj,
 				Predicate: pred,
 				Object:    obj,
-				Score:     score,
-			})
+			}
+			for _, m := range matched {
+				r.Score += m.weight
+				r.Matched = append(r.Matched, m.term)
+			}
+			results = append(results, r)
 		}
 
 		if len(results) >= topK*3 {
@@ -154,6 +268,44 @@ func FormatResults(results []SearchResult) string {
 	return sb.String()
 }
 
+// Triples returns every distinct triple in the graph, sorted by subject,
+// predicate, and object.
+func (db *DB) Triples(ctx context.Context) ([]Triple, error) {
+	it := db.store.QuadsAllIterator()
+	defer it.Close()
+
+	var out []Triple
+	seen := map[Triple]bool{}
+	for it.Next(ctx) {
+		q := db.store.Quad(it.Result())
+		t := Triple{
+			Subject:   quadValueStr(q.Subject),
+			Predicate: quadValueStr(q.Predicate),
+			Object:    quadValueStr(q.Object),
+		}
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("iterate quads: %w", err)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Subject != b.Subject {
+			return a.Subject < b.Subject
+		}
+		if a.Predicate != b.Predicate {
+			return a.Predicate < b.Predicate
+		}
+		return a.Object < b.Object
+	})
+	return out, nil
+}
+
 // Count returns the number of quads in the graph.
 func (db *DB) Count() int64 {
 	stats, err := db.store.Stats(context.Background(), false)
@@ -165,7 +317,11 @@ func (db *DB) Count() int64 {
 
 // Close shuts down the graph store.
 func (db *DB) Close() error {
-	return db.store.Close()
+	err := db.store.Close()
+	if db.snapshotDir != "" {
+		os.RemoveAll(db.snapshotDir)
+	}
+	return err
 }
 
 func normalise(s string) string {
@@ -182,16 +338,17 @@ func quadValueStr(v quad.Value) string {
 	return strings.TrimSpace(s)
 }
 
-func scoreMatch(terms []string, values ...string) float64 {
-	combined := strings.ToLower(strings.Join(values, " "))
-	score := 0.0
-	for _, term := range terms {
-		if len(term) < 3 {
-			continue
-		}
-		if strings.Contains(combined, term) {
-			score += 1.0
-		}
+// MatchedTerms returns the words of query that Search matched in r: those
+// of three or more letters found in its subject, predicate, or object, as
+// they are, by their stem, with a typo (see matchTerms), or through an
+// alias when r came from Search.
+func MatchedTerms(query string, r SearchResult) []string {
+	if r.Matched != nil {
+		return r.Matched
+	}
+	var terms []string
+	for _, m := range matchTerms(singleTerms(strings.Fields(strings.ToLower(query))), r.Subject, r.Predicate, r.Object) {
+		terms = append(terms, m.term)
 	}
-	return score
+	return terms
 }
@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriples(t *testing.T) {
+	db, err := NewDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	require.NoError(t, db.AddTriples(ctx, []Triple{
+		{Subject: "Refunds", Predicate: "take", Object: "14 days"},
+		{Subject: " Billing ", Predicate: "handles", Object: "Refunds"},
+		{Subject: "Refunds", Predicate: "require", Object: "receipt"},
+		{Subject: "", Predicate: "dropped", Object: "x"},
+	}))
+
+	got, err := db.Triples(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Triple{
+		{Subject: "Billing", Predicate: "handles", Object: "Refunds"},
+		{Subject: "Refunds", Predicate: "require", Object: "receipt"},
+		{Subject: "Refunds", Predicate: "take", Object: "14 days"},
+	}, got)
+}
+
+func TestOpenSnapshot(t *testing.T) {
+	ctx := context.Background()
+	path := t.TempDir()
+	db, err := NewDBFromPath(path)
+	require.NoError(t, err)
+	require.NoError(t, db.AddTriples(ctx, []Triple{{Subject: "Refunds", Predicate: "take", Object: "14 days"}}))
+	require.NoError(t, db.Close())
+
+	snap, err := OpenSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), snap.Count())
+
+	// The original is not locked by the snapshot
+	writer, err := NewDBFromPath(path)
+	require.NoError(t, err)
+	require.NoError(t, writer.AddTriples(ctx, []Triple{{Subject: "Billing", Predicate: "handles", Object: "Refunds"}}))
+	require.NoError(t, writer.Close())
+	assert.Equal(t, int64(1), snap.Count())
+
+	dir := snap.snapshotDir
+	require.NoError(t, snap.Close())
+	assert.NoDirExists(t, dir)
+
+	// A vector-only build has no graph store to snapshot
+	empty, err := OpenSnapshot(filepath.Join(path, "missing"))
+	require.NoError(t, err)
+	assert.Zero(t, empty.Count())
+	require.NoError(t, empty.Close())
+}
+
+func TestRemoveTriples(t *testing.T) {
+	ctx := context.Background()
+	db, err := NewDBFromPath(t.TempDir())
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.AddTriples(ctx, []Triple{
+		{Subject: "Refunds", Predicate: "take", Object: "14 days"},
+		{Subject: "Refunds", Predicate: "take", Object: "40 days"},
+		{Subject: "Billing", Predicate: "handles", Object: "Refunds"},
+	}))
+
+	n, err := db.RemoveTriples(ctx, []Triple{
+		{Subject: " Refunds", Predicate: "take", Object: "40 days "},
+		{Subject: "Refunds", Predicate: "take", Object: "missing"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	got, err := db.Triples(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Triple{
+		{Subject: "Billing", Predicate: "handles", Object: "Refunds"},
+		{Subject: "Refunds", Predicate: "take", Object: "14 days"},
+	}, got)
+	assert.Equal(t, int64(2), db.Count())
+
+	results, err := db.Search(ctx, "refunds take", 10)
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.NotEqual(t, "40 days", r.Object)
+	}
+}
@@ -0,0 +1,161 @@
+package graph
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Weights of the ways a query term can match a triple.
+const (
+	// exactWeight is a term found as it is in the triple
+	exactWeight = 1.0
+	// stemWeight is a term whose stem a word of the triple shares, as
+	// "databases" with "database"
+	stemWeight = 1.0
+	// fuzzyWeight is a term within maxEdits of a word of the triple, as
+	// "kuberntes" with "kubernetes"
+	fuzzyWeight = 0.5
+)
+
+// termMatch is a query term matched in a triple.
+type termMatch struct {
+	term   string
+	weight float64
+}
+
+// matchTerms matches the query terms of three or more letters against the
+// values of a triple: as substrings, then by their stems, then allowing a
+// few typos. Each term is a group of the query word and its aliases, and
+// matches once, the best way any of them can; the match is named after the
+// query word.
+func matchTerms(terms [][]string, values ...string) []termMatch {
+	combined := strings.ToLower(strings.Join(values, " "))
+	var words []string
+	var matched []termMatch
+	for _, group := range terms {
+		best := 0.0
+		for _, term := range group {
+			if len(term) < 3 {
+				continue
+			}
+			if strings.Contains(combined, term) {
+				best = exactWeight
+				break
+			}
+			if words == nil {
+				words = strings.FieldsFunc(combined, func(r rune) bool {
+					return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+				})
+			}
+			word := strings.TrimFunc(term, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+			best = max(best, matchWord(word, words))
+		}
+		if best > 0 {
+			matched = append(matched, termMatch{group[0], best})
+		}
+	}
+	return matched
+}
+
+// singleTerms groups each of terms on its own, without aliases.
+func singleTerms(terms []string) [][]string {
+	groups := make([][]string, len(terms))
+	for i, t := range terms {
+		groups[i] = []string{t}
+	}
+	return groups
+}
+
+// matchWord returns the weight of the best match of term among words by
+// stem or edit distance, or 0.
+func matchWord(term string, words []string) float64 {
+	if len(term) < 3 {
+		return 0
+	}
+	termStem := stem(term)
+	edits := maxEdits(term)
+	best := 0.0
+	for _, w := range words {
+		if stem(w) == termStem {
+			return stemWeight
+		}
+		if edits > 0 && best < fuzzyWeight && withinEdits(term, w, edits) {
+			best = fuzzyWeight
+		}
+	}
+	return best
+}
+
+// maxEdits is how many typos a term may have and still match: none below
+// five letters, where one edit makes another word, one up to eight, and two
+// beyond.
+func maxEdits(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 5:
+		return 0
+	case n < 9:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// stem strips the common English inflections from a lowercase word, so
+// "databases", "database", and "indexing", "indexed" share a stem. It is a
+// light stemmer: it only merges forms of one word, never related words.
+func stem(w string) string {
+	switch {
+	case len(w) > 4 && strings.HasSuffix(w, "ies"):
+		w = w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case len(w) > 3 && strings.HasSuffix(w, "s") &&
+		!strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is"):
+		w = w[:len(w)-1]
+	}
+	for _, suffix := range []string{"ing", "ed"} {
+		if len(w)-len(suffix) >= 3 && strings.HasSuffix(w, suffix) {
+			w = w[:len(w)-len(suffix)]
+			// "running" and "stopped" double their last consonant
+			if n := len(w); n >= 2 && w[n-1] == w[n-2] && !strings.ContainsRune("aeioulsz", rune(w[n-1])) {
+				w = w[:n-1]
+			}
+			break
+		}
+	}
+	if len(w) > 3 {
+		w = strings.TrimSuffix(w, "e")
+	}
+	return w
+}
+
+// withinEdits reports whether the Levenshtein distance between a and b is
+// at most max.
+func withinEdits(a, b string, max int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return false
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return false
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)] <= max
+}
@@ -0,0 +1,73 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStem(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"databases", "database"},
+		{"policies", "policy"},
+		{"indexing", "indexed"},
+		{"running", "run"},
+		{"stopped", "stops"},
+		{"classes", "class"},
+	} {
+		assert.Equal(t, stem(pair[1]), stem(pair[0]), pair[0]+" and "+pair[1])
+	}
+	assert.Equal(t, "status", stem("status"))
+	assert.NotEqual(t, stem("refund"), stem("refunding policy"))
+}
+
+func TestWithinEdits(t *testing.T) {
+	assert.True(t, withinEdits("kubernets", "kubernetes", 1))
+	assert.True(t, withinEdits("recieve", "receive", 2))
+	assert.False(t, withinEdits("recieve", "receive", 1), "a swap is two edits")
+	assert.False(t, withinEdits("billing", "building", 1))
+	assert.False(t, withinEdits("cat", "category", 2))
+}
+
+func TestSearchMatching(t *testing.T) {
+	db, err := NewDB()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx := context.Background()
+	require.NoError(t, db.AddTriples(ctx, []Triple{
+		{Subject: "Postgres", Predicate: "is a", Object: "database"},
+		{Subject: "Kubernetes", Predicate: "schedules", Object: "containers"},
+		{Subject: "Refunds", Predicate: "take", Object: "14 days"},
+	}))
+
+	tests := []struct {
+		query string
+		want  string
+		score float64
+	}{
+		{query: "which databases", want: "Postgres", score: stemWeight},
+		{query: "kuberntes scheduling", want: "Kubernetes", score: fuzzyWeight + stemWeight},
+		{query: "refunds", want: "Refunds", score: exactWeight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := db.Search(ctx, tt.query, 5)
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			assert.Equal(t, tt.want, got[0].Subject)
+			assert.Equal(t, tt.score, got[0].Score)
+		})
+	}
+
+	got, err := db.Search(ctx, "kuberntes", 5)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, []string{"kuberntes"}, MatchedTerms("kuberntes", got[0]))
+
+	got, err = db.Search(ctx, "cat", 5)
+	require.NoError(t, err)
+	assert.Empty(t, got, "short terms need an exact match")
+}
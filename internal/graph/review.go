@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultReviewFile is where 'kash build --review' writes the extracted
+// triples, relative to the project.
+const DefaultReviewFile = ".kash/triples-review.yaml"
+
+const reviewHeader = `# Triples extracted by 'kash build --review', not yet in the knowledge graph.
+# Set approved: true on the triples to keep, correcting them if needed, then
+# run 'kash graph apply' on this file. Triples left unapproved are not loaded.
+`
+
+// Review is a review file: the triples the LLM extracted in one build,
+// waiting for a person to approve them.
+type Review struct {
+	BuiltAt time.Time      `yaml:"built_at"`
+	Triples []ReviewTriple `yaml:"triples"`
+
+	index map[Triple]int
+}
+
+// ReviewTriple is a triple in a review file.
+type ReviewTriple struct {
+	Subject   string `yaml:"subject"`
+	Predicate string `yaml:"predicate"`
+	Object    string `yaml:"object"`
+	// Sources are the documents of the chunks it was extracted from
+	Sources  []string `yaml:"sources,omitempty"`
+	Approved bool     `yaml:"approved"`
+}
+
+// Add records a triple extracted from sources. A triple extracted again
+// gains the new sources instead of a second entry.
+func (r *Review) Add(t Triple, sources []string) {
+	t = Triple{Subject: normalise(t.Subject), Predicate: normalise(t.Predicate), Object: normalise(t.Object)}
+	if t.Subject == "" || t.Predicate == "" || t.Object == "" {
+		return
+	}
+	if r.index == nil {
+		r.index = map[Triple]int{}
+	}
+	i, ok := r.index[t]
+	if !ok {
+		i = len(r.Triples)
+		r.index[t] = i
+		r.Triples = append(r.Triples, ReviewTriple{Subject: t.Subject, Predicate: t.Predicate, Object: t.Object})
+	}
+	for _, s := range sources {
+		if !slices.Contains(r.Triples[i].Sources, s) {
+			r.Triples[i].Sources = append(r.Triples[i].Sources, s)
+		}
+	}
+}
+
+// Approved returns the approved triples and how many were left unapproved.
+func (r *Review) Approved() ([]Triple, int) {
+	var out []Triple
+	pending := 0
+	for _, t := range r.Triples {
+		if !t.Approved {
+			pending++
+			continue
+		}
+		out = append(out, Triple{Subject: t.Subject, Predicate: t.Predicate, Object: t.Object})
+	}
+	return out, pending
+}
+
+// Save writes the review file to path, creating its directory.
+func (r *Review) Save(path string) error {
+	var buf bytes.Buffer
+	buf.WriteString(reviewHeader)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("encode review file: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode review file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create review file directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write review file: %w", err)
+	}
+	return nil
+}
+
+// LoadReview reads the review file at path. An approved triple needs a
+// subject, predicate, and object.
+func LoadReview(path string) (*Review, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read review file: %w", err)
+	}
+	var r Review
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parse review file %s: %w", path, err)
+	}
+	for i, t := range r.Triples {
+		if t.Approved && (normalise(t.Subject) == "" || normalise(t.Predicate) == "" || normalise(t.Object) == "") {
+			return nil, fmt.Errorf("%s: triple %d is approved but lacks a subject, predicate, or object", path, i+1)
+		}
+	}
+	return &r, nil
+}
@@ -0,0 +1,41 @@
+package graph
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReview(t *testing.T) {
+	var r Review
+	r.Add(Triple{Subject: "Refunds", Predicate: "take", Object: "14 days"}, []string{"faq.md"})
+	r.Add(Triple{Subject: " Refunds", Predicate: "take", Object: "14 days "}, []string{"faq.md", "policy.md"})
+	r.Add(Triple{Subject: "Billing", Predicate: "handles", Object: "Refunds"}, []string{"policy.md"})
+	r.Add(Triple{Subject: "", Predicate: "dropped", Object: "x"}, nil)
+	require.Len(t, r.Triples, 2)
+	assert.Equal(t, []string{"faq.md", "policy.md"}, r.Triples[0].Sources)
+
+	path := filepath.Join(t.TempDir(), ".kash", "triples-review.yaml")
+	require.NoError(t, r.Save(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Triples extracted by 'kash build --review'")
+	assert.Contains(t, string(data), "approved: false")
+
+	// A reviewer approves the second triple after correcting it
+	edited := strings.Replace(string(data), "object: Refunds\n    sources:\n      - policy.md\n    approved: false", "object: Refund requests\n    sources:\n      - policy.md\n    approved: true", 1)
+	require.NoError(t, os.WriteFile(path, []byte(edited), 0644))
+	loaded, err := LoadReview(path)
+	require.NoError(t, err)
+	approved, unapproved := loaded.Approved()
+	assert.Equal(t, []Triple{{Subject: "Billing", Predicate: "handles", Object: "Refund requests"}}, approved)
+	assert.Equal(t, 1, unapproved)
+
+	require.NoError(t, os.WriteFile(path, []byte("triples:\n  - subject: Billing\n    predicate: handles\n    approved: true\n"), 0644))
+	_, err = LoadReview(path)
+	assert.ErrorContains(t, err, "triple 1 is approved")
+}
@@ -0,0 +1,152 @@
+// Package grounding checks that an agent's answers are supported by the
+// context retrieved for them, with an LLM judging each claim.
+package grounding
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/akashicode/kash/internal/llm"
+)
+
+// Actions on an answer whose score is below the threshold.
+const (
+	// Score only reports the score (default)
+	Score = "score"
+	// Disclaim appends the disclaimer to the answer
+	Disclaim = "disclaimer"
+	// Regenerate has the LLM answer once more, told which claims were
+	// unsupported, and disclaims the new answer if it scores low too
+	Regenerate = "regenerate"
+)
+
+// DefaultThreshold is the score below which Config.Action applies when it
+// sets none.
+const DefaultThreshold = 0.7
+
+// DefaultDisclaimer is appended to low-scoring answers when Config sets no
+// disclaimer.
+const DefaultDisclaimer = "Note: parts of this answer may not be supported by the knowledge base."
+
+// Config is the grounding block in agent.yaml.
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// Model judges instead of the agent's LLM model, e.g. a cheaper one
+	Model string `yaml:"model"`
+	// Threshold is the lowest score accepted, between 0 and 1 (default:
+	// DefaultThreshold)
+	Threshold *float64 `yaml:"threshold"`
+	// Action is Score (default), Disclaim, or Regenerate
+	Action string `yaml:"action"`
+	// Disclaimer is appended to low-scoring answers (default:
+	// DefaultDisclaimer)
+	Disclaimer string `yaml:"disclaimer"`
+}
+
+// Judge splits an answer into claims and judges each against the context.
+// *llm.Client satisfies it.
+type Judge interface {
+	JudgeClaims(ctx context.Context, passages, answer string) ([]llm.Claim, error)
+}
+
+// Report is the grounding check of one answer.
+type Report struct {
+	// Score is the share of the answer's claims the context supports; an
+	// answer without claims scores 1
+	Score  float64 `json:"score"`
+	Claims int     `json:"claims"`
+	// Unsupported are the claims the context does not support
+	Unsupported []string `json:"unsupported,omitempty"`
+	// Regenerated is set when the answer was generated again
+	Regenerated bool `json:"regenerated,omitempty"`
+	// Disclaimed is set when the disclaimer was appended
+	Disclaimed bool `json:"disclaimed,omitempty"`
+}
+
+// Verifier applies the grounding block of agent.yaml.
+type Verifier struct {
+	cfg       Config
+	threshold float64
+	judge     Judge
+}
+
+// New creates a Verifier that asks judge. It returns nil when grounding is
+// disabled; a nil Verifier checks nothing.
+func New(cfg Config, judge Judge) (*Verifier, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	switch cfg.Action {
+	case "":
+		cfg.Action = Score
+	case Score, Disclaim, Regenerate:
+	default:
+		return nil, fmt.Errorf("unknown action %q (want %s, %s, or %s)", cfg.Action, Score, Disclaim, Regenerate)
+	}
+	threshold := DefaultThreshold
+	if cfg.Threshold != nil {
+		threshold = *cfg.Threshold
+	}
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("threshold %g must be between 0 and 1", threshold)
+	}
+	if strings.TrimSpace(cfg.Disclaimer) == "" {
+		cfg.Disclaimer = DefaultDisclaimer
+	}
+	return &Verifier{cfg: cfg, threshold: threshold, judge: judge}, nil
+}
+
+// Action is what v does with a low-scoring answer; "" when v is nil.
+func (v *Verifier) Action() string {
+	if v == nil {
+		return ""
+	}
+	return v.cfg.Action
+}
+
+// Check judges answer against passages. It returns nil when v is nil or
+// there are no passages to check against.
+func (v *Verifier) Check(ctx context.Context, passages, answer string) (*Report, error) {
+	if v == nil || strings.TrimSpace(passages) == "" || strings.TrimSpace(answer) == "" {
+		return nil, nil
+	}
+	claims, err := v.judge.JudgeClaims(ctx, passages, answer)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Score: 1, Claims: len(claims)}
+	for _, c := range claims {
+		if !c.Supported {
+			r.Unsupported = append(r.Unsupported, c.Text)
+		}
+	}
+	if len(claims) > 0 {
+		r.Score = float64(len(claims)-len(r.Unsupported)) / float64(len(claims))
+	}
+	return r, nil
+}
+
+// Low reports whether r scores below the threshold.
+func (v *Verifier) Low(r *Report) bool {
+	return v != nil && r != nil && r.Score < v.threshold
+}
+
+// Disclaim marks r as disclaimed and returns the text to append to its
+// answer.
+func (v *Verifier) Disclaim(r *Report) string {
+	r.Disclaimed = true
+	return "\n\n" + v.cfg.Disclaimer
+}
+
+// RegenerateNote is the instruction that asks the LLM for a new answer
+// without the unsupported claims of r.
+func RegenerateNote(r *Report) string {
+	var sb strings.Builder
+	sb.WriteString("A previous answer to this question made claims the context does not support:\n")
+	for _, c := range r.Unsupported {
+		sb.WriteString("- " + c + "\n")
+	}
+	sb.WriteString("Answer again using only the context. Leave out what it does not support, and say so when it lacks the answer.")
+	return sb.String()
+}
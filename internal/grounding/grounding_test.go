@@ -0,0 +1,107 @@
+package grounding
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/llm"
+)
+
+// fakeJudge returns the same claims for every answer, or fails with err.
+type fakeJudge struct {
+	claims []llm.Claim
+	err    error
+}
+
+func (f fakeJudge) JudgeClaims(context.Context, string, string) ([]llm.Claim, error) {
+	return f.claims, f.err
+}
+
+func TestVerifier(t *testing.T) {
+	claims := []llm.Claim{
+		{Text: "Refunds take 14 days.", Supported: true},
+		{Text: "Refunds are paid in cash.", Supported: false},
+		{Text: "Support is open on weekdays.", Supported: true},
+	}
+	half := 0.5
+	tests := []struct {
+		name     string
+		cfg      Config
+		judge    fakeJudge
+		passages string
+		want     *Report
+		low      bool
+	}{
+		{
+			name:     "one unsupported claim",
+			cfg:      Config{Enabled: true},
+			judge:    fakeJudge{claims: claims},
+			passages: "Refunds take 14 days. Support is open on weekdays.",
+			want:     &Report{Score: 2.0 / 3, Claims: 3, Unsupported: []string{"Refunds are paid in cash."}},
+			low:      true,
+		},
+		{
+			name:     "below a lower threshold only",
+			cfg:      Config{Enabled: true, Threshold: &half},
+			judge:    fakeJudge{claims: claims},
+			passages: "Refunds take 14 days.",
+			want:     &Report{Score: 2.0 / 3, Claims: 3, Unsupported: []string{"Refunds are paid in cash."}},
+		},
+		{
+			name:     "no claims",
+			cfg:      Config{Enabled: true},
+			judge:    fakeJudge{},
+			passages: "Refunds take 14 days.",
+			want:     &Report{Score: 1},
+		},
+		{
+			name:  "no context",
+			cfg:   Config{Enabled: true},
+			judge: fakeJudge{claims: claims},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := New(tt.cfg, tt.judge)
+			require.NoError(t, err)
+			got, err := v.Check(context.Background(), tt.passages, "Refunds take 14 days and are paid in cash.")
+			require.NoError(t, err)
+			if tt.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.InDelta(t, tt.want.Score, got.Score, 1e-9)
+			assert.Equal(t, tt.want.Claims, got.Claims)
+			assert.Equal(t, tt.want.Unsupported, got.Unsupported)
+			assert.Equal(t, tt.low, v.Low(got))
+		})
+	}
+
+	v, err := New(Config{Enabled: true, Action: Disclaim, Disclaimer: "Check the docs."}, fakeJudge{err: errors.New("timeout")})
+	require.NoError(t, err)
+	_, err = v.Check(context.Background(), "context", "answer")
+	assert.Error(t, err)
+	r := &Report{Score: 0}
+	assert.Equal(t, "\n\nCheck the docs.", v.Disclaim(r))
+	assert.True(t, r.Disclaimed)
+	assert.Contains(t, RegenerateNote(&Report{Unsupported: []string{"Refunds are paid in cash."}}), "- Refunds are paid in cash.\n")
+
+	var off *Verifier
+	got, err := off.Check(context.Background(), "context", "answer")
+	assert.NoError(t, err)
+	assert.Nil(t, got, "disabled grounding checks nothing")
+	assert.False(t, off.Low(&Report{}))
+
+	tooHigh := 1.5
+	for _, cfg := range []Config{
+		{Enabled: true, Action: "retry"},
+		{Enabled: true, Threshold: &tooHigh},
+	} {
+		_, err := New(cfg, fakeJudge{})
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,142 @@
+// Package ingest holds the steps that 'kash build' and live ingestion in
+// 'kash serve' share: reading documents with the reader settings of
+// agent.yaml and splitting them into chunks.
+package ingest
+
+import (
+	"strings"
+
+	"github.com/akashicode/kash/internal/chunker"
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/lang"
+	"github.com/akashicode/kash/internal/reader"
+)
+
+// DefaultStreamThresholdMB is the size from which 'kash build' streams .txt
+// and .jsonl files in data/ instead of reading them whole.
+const DefaultStreamThresholdMB = 64
+
+// ReaderOptions returns the reader settings of the ingest block in
+// agent.yaml. Transcription, OCR, and plugins need providers or programs
+// and are left to the caller.
+func ReaderOptions(cfg agentconfig.IngestConfig) reader.Options {
+	return reader.Options{
+		CSV: reader.CSVOptions{
+			RowsPerChunk: cfg.CSV.RowsPerChunk,
+			IDColumn:     cfg.CSV.IDColumn,
+			EmitTriples:  cfg.CSV.EmitTriples,
+		},
+		JSON: reader.JSONOptions{
+			RecordsPath:     cfg.JSON.RecordsPath,
+			Fields:          cfg.JSON.Fields,
+			Exclude:         cfg.JSON.Exclude,
+			IDField:         cfg.JSON.IDField,
+			RecordsPerChunk: cfg.JSON.RecordsPerChunk,
+		},
+		Triples:         reader.TripleOptions{MinConfidence: cfg.Triples.MinConfidence},
+		Workers:         cfg.Workers,
+		StreamThreshold: streamThreshold(cfg.StreamThresholdMB),
+		MaxFileSize:     int64(max(cfg.MaxFileMB, 0)) << 20,
+	}
+}
+
+// streamThreshold converts ingest.stream_threshold_mb to bytes: zero keeps
+// the default and a negative value turns streaming off.
+func streamThreshold(mb int) int64 {
+	switch {
+	case mb < 0:
+		return 0
+	case mb == 0:
+		mb = DefaultStreamThresholdMB
+	}
+	return int64(mb) << 20
+}
+
+// ChunkerOptions sizes chunks from the embedder's max_tokens and the
+// chunking block of agent.yaml: chunking.size when set and within maxTokens,
+// else the size derived from maxTokens, else the default. capped reports a
+// chunking.size that exceeded maxTokens and was ignored.
+func ChunkerOptions(maxTokens int, chunking agentconfig.ChunkingConfig) (opts chunker.Options, capped bool) {
+	if maxTokens > 0 {
+		opts = chunker.OptionsFromMaxTokens(maxTokens)
+	} else {
+		opts = chunker.DefaultOptions()
+	}
+
+	// An explicit chunking.size wins, as long as it fits the token limit
+	if chunking.Size > 0 {
+		if maxTokens > 0 && chunking.Size > opts.ChunkSize {
+			capped = true
+		} else {
+			opts = chunker.Options{ChunkSize: chunking.Size, Overlap: chunking.Size / 5}
+		}
+	}
+	if chunking.Overlap > 0 {
+		opts.Overlap = chunking.Overlap
+	}
+	opts.Sentences = chunking.Strategy == agentconfig.ChunkingSentences
+	return opts, capped
+}
+
+// Sections converts a loaded document into chunker sections. Documents
+// without explicit sections become a single section. Document-level metadata
+// is merged into every section; section metadata wins on conflicts.
+func Sections(doc reader.Document) []chunker.Section {
+	if len(doc.Sections) == 0 {
+		return []chunker.Section{{Content: doc.Content, Metadata: doc.Metadata}}
+	}
+	sections := make([]chunker.Section, 0, len(doc.Sections))
+	for _, sec := range doc.Sections {
+		sections = append(sections, Section(doc, sec))
+	}
+	return sections
+}
+
+// Section converts one section of doc, such as one passed on by
+// reader.StreamFile, into a chunker section with the document metadata
+// merged in.
+func Section(doc reader.Document, sec reader.Section) chunker.Section {
+	meta := make(map[string]string, len(doc.Metadata)+len(sec.Metadata))
+	for k, v := range doc.Metadata {
+		meta[k] = v
+	}
+	for k, v := range sec.Metadata {
+		meta[k] = v
+	}
+	return chunker.Section{Content: sec.Content, Metadata: meta}
+}
+
+// Chunk splits doc into chunks whose source is the document name, tagged
+// with their language.
+func Chunk(ck *chunker.Chunker, doc reader.Document) ([]chunker.Chunk, error) {
+	chunks, err := ck.SplitSections(Sections(doc), doc.Name)
+	if err != nil {
+		return nil, err
+	}
+	TagLanguage(chunks)
+	return chunks, nil
+}
+
+// TagLanguage sets the "language" metadata of each chunk to the language
+// its text is detected in, unless the document already gave one, as in
+// "language: fr" front matter. A chunk whose language cannot be told is
+// left untagged.
+func TagLanguage(chunks []chunker.Chunk) {
+	for i := range chunks {
+		ch := &chunks[i]
+		if code := strings.ToLower(strings.TrimSpace(ch.Metadata[lang.MetadataKey])); code != "" {
+			ch.Metadata[lang.MetadataKey] = code
+			continue
+		}
+		code := lang.Detect(ch.Content)
+		if code == "" {
+			continue
+		}
+		meta := make(map[string]string, len(ch.Metadata)+1)
+		for k, v := range ch.Metadata {
+			meta[k] = v
+		}
+		meta[lang.MetadataKey] = code
+		ch.Metadata = meta
+	}
+}
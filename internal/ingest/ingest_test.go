@@ -0,0 +1,66 @@
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/chunker"
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/reader"
+)
+
+func TestChunkerOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxTokens  int
+		chunking   agentconfig.ChunkingConfig
+		want       chunker.Options
+		wantCapped bool
+	}{
+		{"defaults", 0, agentconfig.ChunkingConfig{}, chunker.DefaultOptions(), false},
+		{"from max tokens", 512, agentconfig.ChunkingConfig{}, chunker.OptionsFromMaxTokens(512), false},
+		{"explicit size", 512, agentconfig.ChunkingConfig{Size: 800}, chunker.Options{ChunkSize: 800, Overlap: 160}, false},
+		{"size over the token limit", 128, agentconfig.ChunkingConfig{Size: 4000, Overlap: 50}, chunker.Options{ChunkSize: 460, Overlap: 50}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, capped := ChunkerOptions(tt.maxTokens, tt.chunking)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantCapped, capped)
+		})
+	}
+}
+
+func TestChunk(t *testing.T) {
+	ck, err := chunker.NewChunker(chunker.DefaultOptions())
+	require.NoError(t, err)
+	doc := reader.Document{
+		Name:     "book.epub",
+		Metadata: map[string]string{"author": "Ada", "chapter": "none"},
+		Sections: []reader.Section{
+			{Content: "First chapter.", Metadata: map[string]string{"chapter": "1"}},
+			{Content: "Second chapter.", Metadata: map[string]string{"chapter": "2"}},
+		},
+	}
+	chunks, err := Chunk(ck, doc)
+	require.NoError(t, err)
+	require.Len(t, chunks, 2)
+	assert.Equal(t, "book.epub", chunks[1].Source)
+	assert.Equal(t, map[string]string{"author": "Ada", "chapter": "2"}, chunks[1].Metadata)
+}
+
+func TestTagLanguage(t *testing.T) {
+	chunks := []chunker.Chunk{
+		{Content: "Refunds are issued to the original payment method within 14 days."},
+		{Content: "Les remboursements sont effectués dans un délai de 14 jours.", Metadata: map[string]string{"page": "2"}},
+		{Content: "Refunds take 14 days.", Metadata: map[string]string{"language": " FR "}},
+		{Content: "Acme Cloud"},
+	}
+	TagLanguage(chunks)
+	assert.Equal(t, map[string]string{"language": "en"}, chunks[0].Metadata)
+	assert.Equal(t, map[string]string{"page": "2", "language": "fr"}, chunks[1].Metadata)
+	assert.Equal(t, map[string]string{"language": "fr"}, chunks[2].Metadata, "the document's own language wins")
+	assert.Empty(t, chunks[3].Metadata)
+}
@@ -0,0 +1,177 @@
+// Package lang detects the language of a text, well enough to tell the
+// languages of a bilingual corpus apart. It recognizes the script of the
+// text and, for Latin and Cyrillic, its most common words; it needs no
+// models or network.
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MetadataKey is the chunk metadata key holding the language of a chunk.
+const MetadataKey = "language"
+
+// sampleRunes is how much of a text Detect reads.
+const sampleRunes = 2000
+
+// scripts whose letters name one language.
+var scripts = []struct {
+	table *unicode.RangeTable
+	code  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// stopwords are frequent words of each language, mostly function words.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "are", "was", "this", "on", "be", "as", "by", "not", "or", "from", "have", "you", "what", "how", "which", "can", "do", "does", "an", "my", "i"},
+	"es": {"el", "la", "los", "las", "de", "del", "que", "y", "en", "es", "por", "para", "con", "una", "un", "se", "no", "su", "al", "lo", "como", "más", "pero", "está", "son", "qué", "cómo", "cuál", "puedo", "mi"},
+	"fr": {"le", "la", "les", "de", "des", "du", "et", "est", "un", "une", "que", "qui", "en", "dans", "pour", "pas", "sur", "au", "aux", "avec", "ce", "il", "elle", "sont", "je", "vous", "comment", "quel", "quelle", "mon"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "den", "dem", "mit", "von", "auf", "für", "sich", "auch", "wie", "im", "es", "sind", "ich", "sie", "wir", "was", "kann", "wird", "oder", "bei", "mein"},
+	"it": {"il", "lo", "la", "gli", "le", "di", "che", "e", "è", "un", "una", "per", "con", "non", "sono", "del", "della", "nel", "nella", "come", "anche", "ma", "più", "si", "cosa", "posso", "questo", "questa", "al", "mio"},
+	"pt": {"o", "a", "os", "as", "de", "do", "da", "dos", "das", "que", "e", "é", "um", "uma", "para", "com", "não", "em", "no", "na", "por", "se", "mais", "como", "são", "está", "você", "posso", "qual", "meu"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "met", "voor", "die", "er", "ook", "aan", "maar", "bij", "wat", "hoe", "kan", "ik", "je", "wordt", "naar", "deze", "dit", "om", "mijn"},
+	"sv": {"och", "att", "det", "som", "en", "är", "på", "för", "med", "av", "inte", "till", "den", "har", "de", "om", "ett", "var", "jag", "du", "vi", "kan", "hur", "vad", "från", "eller", "men", "så", "sig", "min"},
+	"pl": {"i", "w", "nie", "na", "się", "z", "jest", "to", "do", "że", "jak", "o", "co", "ale", "po", "tak", "za", "od", "czy", "są", "dla", "tym", "przez", "może", "jestem", "mój", "ten", "ta", "jego", "już"},
+	"tr": {"ve", "bir", "bu", "da", "de", "için", "ile", "ne", "değil", "çok", "daha", "gibi", "olarak", "var", "ben", "sen", "o", "ama", "mi", "mı", "nasıl", "nedir", "olan", "kadar", "sonra", "her", "şey", "en", "veya", "benim"},
+	"ru": {"и", "в", "не", "на", "что", "с", "как", "это", "по", "к", "но", "из", "у", "за", "от", "о", "так", "же", "для", "бы", "мы", "вы", "он", "она", "есть", "или", "если", "я", "мне", "мой"},
+	"uk": {"і", "та", "в", "не", "на", "що", "з", "як", "це", "до", "але", "із", "у", "за", "від", "для", "ми", "ви", "він", "вона", "є", "або", "якщо", "я", "мій", "бути", "так", "же", "його", "які"},
+	"bg": {"и", "в", "не", "на", "че", "с", "как", "това", "по", "за", "от", "да", "се", "е", "са", "ще", "ли", "но", "или", "ако", "аз", "ние", "вие", "той", "тя", "моят", "към", "със", "при", "си"},
+}
+
+// letters are letters that only some languages of a script use, for texts
+// too short to hold stopwords, such as a query.
+var letters = map[rune][]string{
+	'ñ': {"es"}, '¿': {"es"}, '¡': {"es"},
+	'ß': {"de"},
+	'ã': {"pt"}, 'õ': {"pt"},
+	'œ': {"fr"}, 'è': {"fr", "it"}, 'ê': {"fr", "pt"}, 'ç': {"fr", "pt", "tr"},
+	'å': {"sv"}, 'ä': {"de", "sv"}, 'ö': {"de", "sv", "tr"}, 'ü': {"de", "tr"},
+	'ą': {"pl"}, 'ę': {"pl"}, 'ł': {"pl"}, 'ś': {"pl"}, 'ź': {"pl"}, 'ż': {"pl"}, 'ń': {"pl"},
+	'ğ': {"tr"}, 'ş': {"tr"}, 'ı': {"tr"},
+	'ы': {"ru"}, 'э': {"ru"}, 'ё': {"ru"},
+	'ї': {"uk"}, 'є': {"uk"}, 'ґ': {"uk"},
+	'ъ': {"bg", "ru"},
+}
+
+var stopwordSets = func() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(stopwords))
+	for code, words := range stopwords {
+		set := make(map[string]bool, len(words))
+		for _, w := range words {
+			set[w] = true
+		}
+		sets[code] = set
+	}
+	return sets
+}()
+
+// Detect returns the ISO 639-1 code of the language text is written in,
+// e.g. "en" or "fr", or "" when it cannot tell, as for a text of names and
+// numbers or one that matches two languages equally.
+func Detect(text string) string {
+	var latin, cyrillic, han, kana, total int
+	counts := make(map[string]int, len(scripts))
+	n := 0
+	for _, r := range text {
+		if n++; n > sampleRunes {
+			break
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for _, s := range scripts {
+				if unicode.Is(s.table, r) {
+					counts[s.code]++
+					break
+				}
+			}
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+
+	// Japanese mixes kana into its Han characters; Chinese has none
+	if (han+kana)*2 > total {
+		if kana*10 >= han+kana {
+			return "ja"
+		}
+		return "zh"
+	}
+	for _, s := range scripts {
+		if counts[s.code]*2 > total {
+			return s.code
+		}
+	}
+	switch {
+	case cyrillic*2 > total:
+		return byWords(text, "ru", "uk", "bg")
+	case latin*2 > total:
+		return byWords(text, "en", "es", "fr", "de", "it", "pt", "nl", "sv", "pl", "tr")
+	}
+	return ""
+}
+
+// byWords picks the language among codes whose stopwords and letters text
+// uses most, or "" on a tie or when it uses none.
+func byWords(text string, codes ...string) string {
+	scores := make(map[string]int, len(codes))
+	words := strings.FieldsFunc(strings.ToLower(truncate(text)), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, w := range words {
+		for _, code := range codes {
+			if stopwordSets[code][w] {
+				scores[code] += 2
+			}
+		}
+	}
+	for _, r := range strings.ToLower(truncate(text)) {
+		for _, code := range letters[r] {
+			scores[code]++
+		}
+	}
+
+	best, bestScore, tie := "", 0, false
+	for _, code := range codes {
+		switch s := scores[code]; {
+		case s > bestScore:
+			best, bestScore, tie = code, s, false
+		case s == bestScore && s > 0:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
+
+// truncate cuts text to the runes Detect samples.
+func truncate(text string) string {
+	n := 0
+	for i := range text {
+		if n++; n > sampleRunes {
+			return text[:i]
+		}
+	}
+	return text
+}
@@ -0,0 +1,39 @@
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "english", text: "Refunds are issued to the original payment method within 14 days.", want: "en"},
+		{name: "english question", text: "How do I reset my password?", want: "en"},
+		{name: "spanish", text: "Los reembolsos se emiten al método de pago original en un plazo de 14 días.", want: "es"},
+		{name: "spanish question", text: "¿Cómo cambio mi contraseña?", want: "es"},
+		{name: "french", text: "Les remboursements sont effectués sur le moyen de paiement d'origine dans un délai de 14 jours.", want: "fr"},
+		{name: "german", text: "Die Rückerstattung erfolgt innerhalb von 14 Tagen auf das ursprüngliche Zahlungsmittel.", want: "de"},
+		{name: "italian", text: "I rimborsi vengono emessi sul metodo di pagamento originale entro 14 giorni, come per gli altri ordini.", want: "it"},
+		{name: "portuguese", text: "Os reembolsos são feitos no método de pagamento original em até 14 dias, não em dinheiro.", want: "pt"},
+		{name: "dutch", text: "Terugbetalingen worden binnen 14 dagen op de oorspronkelijke betaalmethode gedaan, maar niet contant.", want: "nl"},
+		{name: "russian", text: "Возврат средств производится на исходный способ оплаты в течение 14 дней, и это не займёт много времени.", want: "ru"},
+		{name: "ukrainian", text: "Повернення коштів здійснюється протягом 14 днів, і це не займає багато часу для вас.", want: "uk"},
+		{name: "chinese", text: "退款将在14天内退回到原付款方式。", want: "zh"},
+		{name: "japanese", text: "返金は14日以内に元のお支払い方法に返金されます。", want: "ja"},
+		{name: "korean", text: "환불은 14일 이내에 원래 결제 수단으로 처리됩니다.", want: "ko"},
+		{name: "arabic", text: "يتم رد المبلغ إلى طريقة الدفع الأصلية خلال 14 يومًا.", want: "ar"},
+		{name: "too short to tell", text: "refund policy", want: ""},
+		{name: "no letters", text: "12:30 — 14/02", want: ""},
+		{name: "empty", text: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Detect(tt.text))
+		})
+	}
+}
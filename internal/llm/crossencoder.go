@@ -0,0 +1,310 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrNoONNXRuntime is returned for a local reranker when kash was built
+// without ONNX Runtime support.
+var ErrNoONNXRuntime = errors.New("kash was built without ONNX Runtime support")
+
+// crossEncoderBatch is how many query-document pairs are scored at once.
+const crossEncoderBatch = 16
+
+// crossEncoderMaxTokens bounds a query-document pair, the input length of
+// BERT-style cross-encoders.
+const crossEncoderMaxTokens = 512
+
+// crossEncoderSession runs a cross-encoder model.
+type crossEncoderSession interface {
+	// Run scores batch rows of seqLen token IDs, given row by row with
+	// their attention mask and token types, and returns the model's logits
+	// for each row, one or more per row.
+	Run(ids, mask, types []int64, batch, seqLen int) ([]float32, error)
+}
+
+// crossEncoder scores query-document pairs with a model on this machine.
+type crossEncoder struct {
+	tok     *wordPiece
+	session crossEncoderSession
+}
+
+// newCrossEncoder loads the cross-encoder at path: an ONNX model file, or a
+// directory holding model.onnx or onnx/model.onnx. The WordPiece vocabulary
+// is read from vocab.txt next to the model or in its parent directory, as
+// Hugging Face exports lay them out.
+func newCrossEncoder(path string) (*crossEncoder, error) {
+	modelFile, vocabFile, err := crossEncoderFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := loadWordPiece(vocabFile)
+	if err != nil {
+		return nil, err
+	}
+	session, err := newONNXSession(modelFile)
+	if err != nil {
+		return nil, err
+	}
+	return &crossEncoder{tok: tok, session: session}, nil
+}
+
+func crossEncoderFiles(path string) (model, vocab string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", "", fmt.Errorf("reranker model: %w", err)
+	}
+	model = path
+	if info.IsDir() {
+		model = ""
+		for _, name := range []string{"model.onnx", filepath.Join("onnx", "model.onnx")} {
+			if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+				model = filepath.Join(path, name)
+				break
+			}
+		}
+		if model == "" {
+			return "", "", fmt.Errorf("reranker model: no model.onnx in %s", path)
+		}
+	}
+	dir := filepath.Dir(model)
+	for _, d := range []string{dir, filepath.Dir(dir)} {
+		if _, err := os.Stat(filepath.Join(d, "vocab.txt")); err == nil {
+			return model, filepath.Join(d, "vocab.txt"), nil
+		}
+	}
+	return "", "", fmt.Errorf("reranker model: no vocab.txt next to %s", model)
+}
+
+// score returns the relevance of each document to query, between 0 and 1.
+func (ce *crossEncoder) score(ctx context.Context, query string, docs []string) ([]float64, error) {
+	q := ce.tok.tokenize(query)
+	scores := make([]float64, 0, len(docs))
+	for start := 0; start < len(docs); start += crossEncoderBatch {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := min(start+crossEncoderBatch, len(docs))
+		rows := make([][]int64, 0, end-start)
+		types := make([][]int64, 0, end-start)
+		seqLen := 0
+		for _, doc := range docs[start:end] {
+			ids, tt := ce.tok.encodePair(q, ce.tok.tokenize(doc), crossEncoderMaxTokens)
+			rows, types = append(rows, ids), append(types, tt)
+			seqLen = max(seqLen, len(ids))
+		}
+
+		// Pad every row to the longest one
+		batch := len(rows)
+		ids := make([]int64, batch*seqLen)
+		mask := make([]int64, batch*seqLen)
+		tt := make([]int64, batch*seqLen)
+		for i, row := range rows {
+			copy(ids[i*seqLen:], row)
+			copy(tt[i*seqLen:], types[i])
+			for j := range row {
+				mask[i*seqLen+j] = 1
+			}
+		}
+		logits, err := ce.session.Run(ids, mask, tt, batch, seqLen)
+		if err != nil {
+			return nil, fmt.Errorf("run reranker model: %w", err)
+		}
+		if len(logits) == 0 || len(logits)%batch != 0 {
+			return nil, fmt.Errorf("reranker model returned %d values for %d documents", len(logits), batch)
+		}
+		labels := len(logits) / batch
+		for i := 0; i < batch; i++ {
+			scores = append(scores, relevance(logits[i*labels:(i+1)*labels]))
+		}
+	}
+	return scores, nil
+}
+
+// relevance turns the logits of one pair into a score between 0 and 1: the
+// sigmoid of a single logit, or the softmax probability of the last label.
+func relevance(logits []float32) float64 {
+	if len(logits) == 1 {
+		return 1 / (1 + math.Exp(-float64(logits[0])))
+	}
+	top := float64(logits[0])
+	for _, l := range logits {
+		top = math.Max(top, float64(l))
+	}
+	var sum float64
+	for _, l := range logits {
+		sum += math.Exp(float64(l) - top)
+	}
+	return math.Exp(float64(logits[len(logits)-1])-top) / sum
+}
+
+// wordPiece is the BERT tokenizer cross-encoders are trained with.
+type wordPiece struct {
+	vocab     map[string]int64
+	lowercase bool
+	cls, sep  int64
+	unk       int64
+}
+
+// loadWordPiece reads a vocab.txt, one token per line. Text is lowercased
+// unless a tokenizer_config.json next to it sets do_lower_case to false.
+func loadWordPiece(path string) (*wordPiece, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open vocabulary: %w", err)
+	}
+	defer f.Close()
+	wp := &wordPiece{vocab: map[string]int64{}, lowercase: true}
+	sc := bufio.NewScanner(f)
+	for id := int64(0); sc.Scan(); id++ {
+		wp.vocab[strings.TrimRight(sc.Text(), "\r")] = id
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read vocabulary: %w", err)
+	}
+	for tok, dst := range map[string]*int64{"[CLS]": &wp.cls, "[SEP]": &wp.sep, "[UNK]": &wp.unk} {
+		id, ok := wp.vocab[tok]
+		if !ok {
+			return nil, fmt.Errorf("vocabulary %s has no %s token", path, tok)
+		}
+		*dst = id
+	}
+
+	if data, err := os.ReadFile(filepath.Join(filepath.Dir(path), "tokenizer_config.json")); err == nil {
+		var cfg struct {
+			DoLowerCase *bool `json:"do_lower_case"`
+		}
+		if json.Unmarshal(data, &cfg) == nil && cfg.DoLowerCase != nil {
+			wp.lowercase = *cfg.DoLowerCase
+		}
+	}
+	return wp, nil
+}
+
+// encodePair returns the token IDs and token types of
+// "[CLS] a [SEP] b [SEP]", cutting the query to half of maxTokens and the
+// document to what is left.
+func (wp *wordPiece) encodePair(a, b []int64, maxTokens int) (ids, types []int64) {
+	room := maxTokens - 3
+	if len(a) > room/2 {
+		a = a[:room/2]
+	}
+	if len(b) > room-len(a) {
+		b = b[:room-len(a)]
+	}
+	ids = make([]int64, 0, len(a)+len(b)+3)
+	ids = append(ids, wp.cls)
+	ids = append(ids, a...)
+	ids = append(ids, wp.sep)
+	types = make([]int64, len(ids), len(a)+len(b)+3)
+	ids = append(ids, b...)
+	ids = append(ids, wp.sep)
+	for len(types) < len(ids) {
+		types = append(types, 1)
+	}
+	return ids, types
+}
+
+// tokenize splits text into words and punctuation as BERT's basic tokenizer
+// does, then each word into the longest vocabulary pieces.
+func (wp *wordPiece) tokenize(text string) []int64 {
+	var ids []int64
+	for _, word := range wp.words(text) {
+		ids = append(ids, wp.pieces(word)...)
+	}
+	return ids
+}
+
+func (wp *wordPiece) words(text string) []string {
+	if wp.lowercase {
+		// Lowercase and strip accents
+		var sb strings.Builder
+		for _, r := range norm.NFD.String(strings.ToLower(text)) {
+			if !unicode.Is(unicode.Mn, r) {
+				sb.WriteRune(r)
+			}
+		}
+		text = sb.String()
+	}
+	var words []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			words = append(words, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || unicode.IsControl(r) && !unicode.IsSpace(r):
+		case unicode.IsSpace(r):
+			flush()
+		case isPunctuation(r) || isCJK(r):
+			flush()
+			words = append(words, string(r))
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// pieces splits word greedily into the longest prefixes in the vocabulary,
+// continuations marked with "##". A word that does not split is [UNK].
+func (wp *wordPiece) pieces(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > 100 {
+		return []int64{wp.unk}
+	}
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		found := false
+		for ; end > start; end-- {
+			sub := string(runes[start:end])
+			if start > 0 {
+				sub = "##" + sub
+			}
+			if id, ok := wp.vocab[sub]; ok {
+				ids = append(ids, id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []int64{wp.unk}
+		}
+		start = end
+	}
+	return ids
+}
+
+// isPunctuation counts every non-alphanumeric ASCII symbol as punctuation,
+// as BERT does, besides the Unicode punctuation classes.
+func isPunctuation(r rune) bool {
+	if r >= 33 && r <= 47 || r >= 58 && r <= 64 || r >= 91 && r <= 96 || r >= 123 && r <= 126 {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+// isCJK reports whether r is a CJK ideograph, which BERT treats as a word of
+// its own.
+func isCJK(r rune) bool {
+	return r >= 0x4E00 && r <= 0x9FFF || r >= 0x3400 && r <= 0x4DBF ||
+		r >= 0x20000 && r <= 0x2A6DF || r >= 0x2A700 && r <= 0x2B73F ||
+		r >= 0x2B740 && r <= 0x2B81F || r >= 0x2B820 && r <= 0x2CEAF ||
+		r >= 0xF900 && r <= 0xFAFF || r >= 0x2F800 && r <= 0x2FA1F
+}
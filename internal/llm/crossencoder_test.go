@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testVocab = "[PAD]\n[UNK]\n[CLS]\n[SEP]\nthe\ncafe\nopen\n##s\nat\n9\n.\n,\nbilling\nrefund\n"
+
+func testWordPiece(t *testing.T) *wordPiece {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	require.NoError(t, os.WriteFile(path, []byte(testVocab), 0o644))
+	wp, err := loadWordPiece(path)
+	require.NoError(t, err)
+	return wp
+}
+
+func TestWordPiece(t *testing.T) {
+	wp := testWordPiece(t)
+	tests := []struct {
+		text string
+		want []int64
+	}{
+		{"The café opens at 9.", []int64{4, 5, 6, 7, 8, 9, 10}},
+		{"BILLING,refund", []int64{12, 11, 13}},
+		{"unknown words", []int64{1, 1}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, wp.tokenize(tt.text))
+		})
+	}
+
+	ids, types := wp.encodePair([]int64{4, 5}, []int64{6, 7, 8, 9}, 8)
+	assert.Equal(t, []int64{2, 4, 5, 3, 6, 7, 8, 3}, ids)
+	assert.Equal(t, []int64{0, 0, 0, 0, 1, 1, 1, 1}, types)
+	ids, _ = wp.encodePair([]int64{4, 5, 6, 7}, []int64{6, 7, 8, 9}, 8)
+	assert.Equal(t, []int64{2, 4, 5, 3, 6, 7, 8, 3}, ids, "the query is cut to half, the document to the rest")
+}
+
+// overlapSession scores a pair by how many token IDs of the query appear in
+// the document.
+type overlapSession struct{ batches int }
+
+func (s *overlapSession) Run(ids, mask, types []int64, batch, seqLen int) ([]float32, error) {
+	s.batches++
+	logits := make([]float32, batch)
+	for i := 0; i < batch; i++ {
+		row, tt, m := ids[i*seqLen:(i+1)*seqLen], types[i*seqLen:(i+1)*seqLen], mask[i*seqLen:(i+1)*seqLen]
+		query := map[int64]bool{}
+		for j, id := range row {
+			switch {
+			case m[j] == 0 || id == 2 || id == 3:
+			case tt[j] == 0:
+				query[id] = true
+			case query[id]:
+				logits[i]++
+			}
+		}
+	}
+	return logits, nil
+}
+
+func TestRerankLocal(t *testing.T) {
+	session := &overlapSession{}
+	r := &Reranker{local: &crossEncoder{tok: testWordPiece(t), session: session}}
+
+	docs := []string{"billing", "the cafe opens", "refund"}
+	for i := 0; i < crossEncoderBatch; i++ {
+		docs = append(docs, fmt.Sprintf("filler %d", i))
+	}
+	results, err := r.RerankTop(context.Background(), "When does the café open?", docs, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 1, results[0].Index)
+	assert.Equal(t, "the cafe opens", results[0].Content)
+	assert.InDelta(t, 0.95, results[0].RelevanceScore, 0.01, "sigmoid of three matching tokens")
+	assert.Equal(t, 2, session.batches)
+}
+
+func TestCrossEncoderFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "onnx"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "onnx", "model.onnx"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vocab.txt"), []byte(testVocab), 0o644))
+
+	for _, path := range []string{dir, filepath.Join(dir, "onnx", "model.onnx")} {
+		model, vocab, err := crossEncoderFiles(path)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "onnx", "model.onnx"), model)
+		assert.Equal(t, filepath.Join(dir, "vocab.txt"), vocab)
+	}
+
+	_, _, err := crossEncoderFiles(t.TempDir())
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "no model.onnx"))
+}
@@ -59,6 +59,9 @@ type embedResponse struct {
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
+	Usage *struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage,omitempty"`
 }
 
 // EmbedBatch generates embeddings for a batch of strings.
@@ -108,6 +111,15 @@ func (e *Embedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32,
 	if embedResp.Error != nil {
 		return nil, fmt.Errorf("embed API error: %s", embedResp.Error.Message)
 	}
+	u := Usage{Phase: PhaseEmbedding, Model: e.model, Estimated: true}
+	if embedResp.Usage != nil && embedResp.Usage.PromptTokens > 0 {
+		u.PromptTokens, u.Estimated = embedResp.Usage.PromptTokens, false
+	} else {
+		for _, t := range texts {
+			u.PromptTokens += EstimateTokens(t)
+		}
+	}
+	ReportUsage(ctx, u)
 
 	// Sort by index and extract embeddings
 	result := make([][]float32, len(texts))
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"strings"
+
+	"github.com/akashicode/kash/internal/config"
+)
+
+// defaultExtractionPrompt is the instructions of the extraction system
+// prompt unless agent.yaml's extraction.prompt replaces them.
+const defaultExtractionPrompt = `You are a knowledge extraction expert. Extract factual relationships from the provided text as Subject-Predicate-Object triples.
+
+Rules:
+- Extract only factual, verifiable relationships
+- Subjects and Objects should be named entities (people, places, organizations, concepts)
+- Predicates should be concise verb phrases
+- Extract 5-20 triples per chunk`
+
+// extractionFormat is the end of every extraction system prompt, which
+// parseTriples depends on.
+const extractionFormat = `Output:
+- Return ONLY valid JSON array, no explanation
+- Format: [{"subject": "X", "predicate": "Y", "object": "Z"}]
+- If no clear triples exist, return []`
+
+// ExtractionPrompt returns the system prompt of triple extraction: the
+// instructions, the entity types, predicates, and rules of cfg, and the
+// output format.
+func ExtractionPrompt(cfg config.ExtractionConfig) string {
+	var sb strings.Builder
+	if p := strings.TrimSpace(cfg.Prompt); p != "" {
+		sb.WriteString(p)
+	} else {
+		sb.WriteString(defaultExtractionPrompt)
+	}
+	if len(cfg.EntityTypes) > 0 {
+		sb.WriteString("\n\nEntity types: subjects and objects should be entities of these types: ")
+		sb.WriteString(strings.Join(cfg.EntityTypes, ", "))
+	}
+	if len(cfg.Predicates) > 0 {
+		if cfg.StrictPredicates {
+			sb.WriteString("\n\nPredicates: use only these predicates, written exactly as here: ")
+		} else {
+			sb.WriteString("\n\nPredicates: prefer these predicates where they fit: ")
+		}
+		sb.WriteString(strings.Join(cfg.Predicates, ", "))
+	}
+	if len(cfg.Rules) > 0 {
+		sb.WriteString("\n\nDomain rules:")
+		for _, r := range cfg.Rules {
+			sb.WriteString("\n- ")
+			sb.WriteString(strings.TrimSpace(r))
+		}
+	}
+	sb.WriteString("\n\n")
+	sb.WriteString(extractionFormat)
+	return sb.String()
+}
+
+// keepPredicates drops the triples whose predicate is not one of
+// predicates, compared case-insensitively.
+func keepPredicates(triples []Triple, predicates []string) []Triple {
+	allowed := make(map[string]bool, len(predicates))
+	for _, p := range predicates {
+		allowed[strings.ToLower(strings.TrimSpace(p))] = true
+	}
+	kept := triples[:0]
+	for _, t := range triples {
+		if allowed[strings.ToLower(strings.TrimSpace(t.Predicate))] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akashicode/kash/internal/config"
+)
+
+func TestExtractionPrompt(t *testing.T) {
+	def := ExtractionPrompt(config.ExtractionConfig{})
+	assert.True(t, strings.HasPrefix(def, "You are a knowledge extraction expert."))
+	assert.True(t, strings.HasSuffix(def, extractionFormat))
+
+	custom := ExtractionPrompt(config.ExtractionConfig{
+		Prompt:           "You extract holdings from court decisions.",
+		EntityTypes:      []string{"court", "statute"},
+		Predicates:       []string{"cites", "overrules"},
+		StrictPredicates: true,
+		Rules:            []string{"Name cases by their short title"},
+	})
+	assert.True(t, strings.HasPrefix(custom, "You extract holdings from court decisions.\n\nEntity types:"))
+	assert.NotContains(t, custom, "knowledge extraction expert")
+	assert.Contains(t, custom, "these types: court, statute")
+	assert.Contains(t, custom, "use only these predicates, written exactly as here: cites, overrules")
+	assert.Contains(t, custom, "Domain rules:\n- Name cases by their short title")
+	assert.True(t, strings.HasSuffix(custom, extractionFormat), "the output format cannot be replaced")
+
+	assert.Contains(t, ExtractionPrompt(config.ExtractionConfig{Predicates: []string{"cites"}}), "prefer these predicates")
+}
+
+func TestKeepPredicates(t *testing.T) {
+	triples := []Triple{
+		{Subject: "Roe", Predicate: "Overrules", Object: "Doe"},
+		{Subject: "Roe", Predicate: "mentions", Object: "Doe"},
+		{Subject: "Roe", Predicate: " cites", Object: "Smith"},
+	}
+	assert.Equal(t, []Triple{
+		{Subject: "Roe", Predicate: "Overrules", Object: "Doe"},
+		{Subject: "Roe", Predicate: " cites", Object: "Smith"},
+	}, keepPredicates(triples, []string{"cites", "overrules"}))
+}
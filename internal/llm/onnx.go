@@ -0,0 +1,101 @@
+//go:build onnx
+
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// onnxRuntime loads the ONNX Runtime shared library once per process.
+var onnxRuntime struct {
+	sync.Once
+	err error
+}
+
+// initONNXRuntime loads the library named by ONNXRUNTIME_LIB, or the
+// platform's default library name from the loader's search path.
+func initONNXRuntime() error {
+	onnxRuntime.Do(func() {
+		lib := os.Getenv("ONNXRUNTIME_LIB")
+		if lib == "" {
+			switch runtime.GOOS {
+			case "windows":
+				lib = "onnxruntime.dll"
+			case "darwin":
+				lib = "libonnxruntime.dylib"
+			default:
+				lib = "libonnxruntime.so"
+			}
+		}
+		ort.SetSharedLibraryPath(lib)
+		if err := ort.InitializeEnvironment(); err != nil {
+			onnxRuntime.err = fmt.Errorf("load ONNX Runtime from %s (set ONNXRUNTIME_LIB): %w", lib, err)
+		}
+	})
+	return onnxRuntime.err
+}
+
+// onnxSession runs a cross-encoder with ONNX Runtime.
+type onnxSession struct {
+	session *ort.DynamicAdvancedSession
+	// inputs are the model's inputs in its order
+	inputs []string
+}
+
+func newONNXSession(path string) (crossEncoderSession, error) {
+	if err := initONNXRuntime(); err != nil {
+		return nil, err
+	}
+	inputs, outputs, err := ort.GetInputOutputInfo(path)
+	if err != nil {
+		return nil, fmt.Errorf("read reranker model %s: %w", path, err)
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("reranker model %s has no outputs", path)
+	}
+	s := &onnxSession{}
+	for _, in := range inputs {
+		switch in.Name {
+		case "input_ids", "attention_mask", "token_type_ids":
+			s.inputs = append(s.inputs, in.Name)
+		default:
+			return nil, fmt.Errorf("reranker model %s has unsupported input %q", path, in.Name)
+		}
+	}
+	s.session, err = ort.NewDynamicAdvancedSession(path, s.inputs, []string{outputs[0].Name}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("load reranker model %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Run implements crossEncoderSession.
+func (s *onnxSession) Run(ids, mask, types []int64, batch, seqLen int) ([]float32, error) {
+	shape := ort.NewShape(int64(batch), int64(seqLen))
+	data := map[string][]int64{"input_ids": ids, "attention_mask": mask, "token_type_ids": types}
+	inputs := make([]ort.Value, len(s.inputs))
+	for i, name := range s.inputs {
+		t, err := ort.NewTensor(shape, data[name])
+		if err != nil {
+			return nil, err
+		}
+		defer t.Destroy()
+		inputs[i] = t
+	}
+	outputs := []ort.Value{nil}
+	if err := s.session.Run(inputs, outputs); err != nil {
+		return nil, err
+	}
+	defer outputs[0].Destroy()
+	logits, ok := outputs[0].(*ort.Tensor[float32])
+	if !ok {
+		return nil, errors.New("reranker model output is not float32")
+	}
+	return append([]float32(nil), logits.GetData()...), nil
+}
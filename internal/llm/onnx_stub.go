@@ -0,0 +1,7 @@
+//go:build !onnx
+
+package llm
+
+func newONNXSession(string) (crossEncoderSession, error) {
+	return nil, ErrNoONNXRuntime
+}
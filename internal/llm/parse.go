@@ -6,6 +6,21 @@ import (
 	"strings"
 )
 
+// parseClaims parses the JSON array of claims JudgeClaims asks for, ignoring
+// text around it.
+func parseClaims(raw string) ([]Claim, error) {
+	start := strings.Index(raw, "[")
+	end := strings.LastIndex(raw, "]")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("no JSON array in %q", raw)
+	}
+	var claims []Claim
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &claims); err != nil {
+		return nil, fmt.Errorf("unmarshal claims JSON: %w", err)
+	}
+	return claims, nil
+}
+
 // parseTriples parses a JSON array of triple objects from an LLM response.
 // It is lenient and tries to extract JSON even if surrounded by markdown fences.
 func parseTriples(raw string) ([]Triple, error) {
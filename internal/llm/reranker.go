@@ -11,6 +11,8 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/akashicode/kash/internal/config"
 )
@@ -18,6 +20,27 @@ import (
 // ErrNilRerankConfig is returned when nil rerank config is provided.
 var ErrNilRerankConfig = errors.New("reranker config is nil")
 
+// ErrRerankerUnavailable is returned without calling the API while the
+// reranker cools down after failing too often in a row.
+var ErrRerankerUnavailable = errors.New("reranker unavailable")
+
+// rerankFailureLimit is how many calls in a row may fail before the
+// reranker cools down.
+const rerankFailureLimit = 3
+
+// RerankerOptions tunes how a Reranker handles a slow or failing API. Zero
+// fields keep the defaults.
+type RerankerOptions struct {
+	// Timeout bounds each attempt (default 5s)
+	Timeout time.Duration
+	// Retries is how often an attempt that timed out, could not connect, or
+	// got a 429 or 5xx response is repeated (default 2; negative for none)
+	Retries int
+	// Cooldown is how long the reranker is skipped after three calls in a
+	// row failed (default 30s)
+	Cooldown time.Duration
+}
+
 // RerankResult represents a reranked document.
 type RerankResult struct {
 	Index          int
@@ -25,16 +48,32 @@ type RerankResult struct {
 	Content        string
 }
 
-// Reranker reranks documents using a Cohere-compatible reranking API.
+// Reranker reranks documents using a Cohere-compatible reranking API, or a
+// cross-encoder model on this machine.
 type Reranker struct {
+	// local is set for the local provider, which needs no endpoint
+	local    *crossEncoder
 	endpoint string // fully-resolved POST URL, e.g. https://api.cohere.ai/v1/rerank
 	apiKey   string
 	model    string
 	client   *http.Client
+
+	mu       sync.Mutex
+	timeout  time.Duration
+	retries  int
+	cooldown time.Duration
+	// backoff is the wait before the first retry, doubled for each one
+	backoff time.Duration
+	// failures counts the calls that failed in a row; downUntil is when a
+	// call is tried again after rerankFailureLimit of them
+	failures  int
+	downUntil time.Time
 }
 
 // NewReranker creates a new Reranker from a ProviderConfig.
 // Returns nil, nil if the config has no model or base URL (reranker is optional).
+// With provider "local", Model is the path of a cross-encoder model, loaded
+// with ONNX Runtime, and no base URL is needed.
 //
 // Endpoint resolution order:
 //  1. RERANK_ENDPOINT env var (full URL override)
@@ -44,6 +83,19 @@ func NewReranker(cfg *config.ProviderConfig) (*Reranker, error) {
 	if cfg == nil {
 		return nil, ErrNilRerankConfig
 	}
+	switch {
+	case cfg.Local():
+		if cfg.Model == "" {
+			return nil, errors.New("local reranker: model must be the path of a cross-encoder model")
+		}
+		ce, err := newCrossEncoder(cfg.Model)
+		if err != nil {
+			return nil, fmt.Errorf("local reranker: %w", err)
+		}
+		return &Reranker{local: ce, model: cfg.Model}, nil
+	case cfg.Provider != "":
+		return nil, fmt.Errorf("unknown reranker provider %q (use local, or leave it empty for a rerank API)", cfg.Provider)
+	}
 	// Reranker is optional
 	if cfg.Model == "" || cfg.BaseURL == "" {
 		return nil, nil
@@ -66,9 +118,41 @@ func NewReranker(cfg *config.ProviderConfig) (*Reranker, error) {
 		apiKey:   cfg.APIKey,
 		model:    cfg.Model,
 		client:   &http.Client{},
+		timeout:  5 * time.Second,
+		retries:  2,
+		cooldown: 30 * time.Second,
+		backoff:  200 * time.Millisecond,
 	}, nil
 }
 
+// Configure replaces the defaults with the non-zero fields of opts.
+func (r *Reranker) Configure(opts RerankerOptions) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if opts.Timeout > 0 {
+		r.timeout = opts.Timeout
+	}
+	if opts.Retries < 0 {
+		r.retries = 0
+	} else if opts.Retries > 0 {
+		r.retries = opts.Retries
+	}
+	if opts.Cooldown > 0 {
+		r.cooldown = opts.Cooldown
+	}
+}
+
+// DownUntil returns when the reranker is called again after failing too
+// often in a row, or the zero time while it is healthy.
+func (r *Reranker) DownUntil() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Now().After(r.downUntil) {
+		return time.Time{}
+	}
+	return r.downUntil
+}
+
 // rerankRequest is the Cohere-compatible rerank request body.
 type rerankRequest struct {
 	Model     string   `json:"model"`
@@ -88,10 +172,23 @@ type rerankResponse struct {
 // Rerank reorders documents by relevance to the query using the configured API.
 // If the Reranker is nil (not configured), returns documents in original order.
 func (r *Reranker) Rerank(ctx context.Context, query string, docs []string) ([]RerankResult, error) {
+	return r.RerankTop(ctx, query, docs, 0)
+}
+
+// RerankTop is Rerank returning only the topN most relevant documents; zero
+// returns them all. Each attempt is bounded by the configured timeout, and
+// attempts that time out, cannot connect, or get a 429 or 5xx response are
+// retried. After three calls in a row failed, RerankTop returns
+// ErrRerankerUnavailable for the cooldown without calling the API. A local
+// reranker scores the documents itself, without timeout or retries.
+func (r *Reranker) RerankTop(ctx context.Context, query string, docs []string, topN int) ([]RerankResult, error) {
+	if topN <= 0 || topN > len(docs) {
+		topN = len(docs)
+	}
 	if r == nil {
 		// No reranker configured; return original order
-		results := make([]RerankResult, len(docs))
-		for i, doc := range docs {
+		results := make([]RerankResult, topN)
+		for i, doc := range docs[:topN] {
 			results[i] = RerankResult{
 				Index:          i,
 				RelevanceScore: 1.0,
@@ -100,12 +197,15 @@ func (r *Reranker) Rerank(ctx context.Context, query string, docs []string) ([]R
 		}
 		return results, nil
 	}
+	if r.local != nil {
+		return r.rerankLocal(ctx, query, docs, topN)
+	}
 
 	reqBody := rerankRequest{
 		Model:     r.model,
 		Query:     query,
 		Documents: docs,
-		TopN:      len(docs),
+		TopN:      topN,
 	}
 
 	body, err := json.Marshal(reqBody)
@@ -113,30 +213,35 @@ func (r *Reranker) Rerank(ctx context.Context, query string, docs []string) ([]R
 		return nil, fmt.Errorf("marshal rerank request: %w", err)
 	}
 
-	url := r.endpoint
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("create rerank request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	if r.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	r.mu.Lock()
+	if time.Now().Before(r.downUntil) {
+		until := r.downUntil
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w after %d failed calls, retrying in %s",
+			ErrRerankerUnavailable, rerankFailureLimit, time.Until(until).Round(time.Second))
 	}
+	timeout, retries, wait := r.timeout, r.retries, r.backoff
+	r.mu.Unlock()
 
-	resp, err := r.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("rerank request: %w", err)
+	var respBody []byte
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		respBody, retry, err = r.post(ctx, body, timeout)
+		if err == nil || !retry || attempt == retries || ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+		wait *= 2
 	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read rerank response: %w", err)
+	// A caller that gave up says nothing about the API's health
+	if ctx.Err() == nil {
+		r.record(err)
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("rerank API returned status %d: %s", resp.StatusCode, string(respBody))
+	if err != nil {
+		return nil, err
 	}
 
 	var rerankResp rerankResponse
@@ -153,8 +258,15 @@ func (r *Reranker) Rerank(ctx context.Context, query string, docs []string) ([]R
 		return rerankResp.Results[i].RelevanceScore > rerankResp.Results[j].RelevanceScore
 	})
 
+	// Not every API honors top_n
+	if len(rerankResp.Results) > topN {
+		rerankResp.Results = rerankResp.Results[:topN]
+	}
 	results := make([]RerankResult, len(rerankResp.Results))
 	for i, r := range rerankResp.Results {
+		if r.Index < 0 || r.Index >= len(docs) {
+			return nil, fmt.Errorf("rerank API returned index %d for %d documents", r.Index, len(docs))
+		}
 		results[i] = RerankResult{
 			Index:          r.Index,
 			RelevanceScore: r.RelevanceScore,
@@ -163,3 +275,70 @@ func (r *Reranker) Rerank(ctx context.Context, query string, docs []string) ([]R
 	}
 	return results, nil
 }
+
+// rerankLocal scores docs with the local cross-encoder.
+func (r *Reranker) rerankLocal(ctx context.Context, query string, docs []string, topN int) ([]RerankResult, error) {
+	scores, err := r.local.score(ctx, query, docs)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]RerankResult, len(docs))
+	for i, doc := range docs {
+		results[i] = RerankResult{Index: i, RelevanceScore: scores[i], Content: doc}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RelevanceScore > results[j].RelevanceScore
+	})
+	return results[:topN], nil
+}
+
+// post sends one rerank request, bounded by timeout. retry reports whether
+// a failure is worth another attempt.
+func (r *Reranker) post(ctx context.Context, body []byte, timeout time.Duration) (respBody []byte, retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, fmt.Errorf("create rerank request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		return nil, true, fmt.Errorf("rerank request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("read rerank response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests,
+			fmt.Errorf("rerank API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return respBody, false, nil
+}
+
+// record counts a failed call, starting the cooldown after
+// rerankFailureLimit of them in a row, and resets the count on success.
+func (r *Reranker) record(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		r.failures = 0
+		return
+	}
+	r.failures++
+	if r.failures >= rerankFailureLimit {
+		r.failures = 0
+		r.downUntil = time.Now().Add(r.cooldown)
+	}
+}
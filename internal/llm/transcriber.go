@@ -0,0 +1,145 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/config"
+)
+
+// defaultTranscribeModel is used when the transcriber config has no model.
+const defaultTranscribeModel = "whisper-1"
+
+// TranscriptSegment is a timed span returned by the transcription API.
+type TranscriptSegment struct {
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Speaker string  `json:"speaker,omitempty"`
+	Text    string  `json:"text"`
+}
+
+// Transcriber converts audio to text via an OpenAI-compatible
+// /audio/transcriptions endpoint (Whisper, faster-whisper servers, etc.).
+type Transcriber struct {
+	endpoint string
+	apiKey   string
+	model    string
+	client   *http.Client
+}
+
+// NewTranscriber creates a Transcriber from a ProviderConfig.
+// Returns nil, nil if the config has no base URL (transcription is optional).
+func NewTranscriber(cfg *config.ProviderConfig) (*Transcriber, error) {
+	if cfg == nil {
+		return nil, ErrNilConfig
+	}
+	if cfg.BaseURL == "" {
+		return nil, nil
+	}
+	model := cfg.Model
+	if model == "" {
+		model = defaultTranscribeModel
+	}
+	base := strings.TrimSuffix(cfg.BaseURL, "/")
+	endpoint := base
+	if !strings.HasSuffix(base, "/audio/transcriptions") {
+		endpoint = base + "/audio/transcriptions"
+	}
+	return &Transcriber{
+		endpoint: endpoint,
+		apiKey:   cfg.APIKey,
+		model:    model,
+		// Long recordings can take minutes to transcribe
+		client: &http.Client{Timeout: 30 * time.Minute},
+	}, nil
+}
+
+// transcribeResponse is the verbose_json transcription body. Diarizing
+// services add a speaker label to each segment.
+type transcribeResponse struct {
+	Text     string              `json:"text"`
+	Duration float64             `json:"duration"`
+	Segments []TranscriptSegment `json:"segments"`
+}
+
+// Transcribe uploads an audio file and returns its timed segments. Services
+// that return only plain text yield a single segment spanning the recording.
+func (t *Transcriber) Transcribe(ctx context.Context, path string) ([]TranscriptSegment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open audio file: %w", err)
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return nil, fmt.Errorf("create multipart file: %w", err)
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return nil, fmt.Errorf("read audio file: %w", err)
+	}
+	for k, v := range map[string]string{
+		"model":                     t.model,
+		"response_format":           "verbose_json",
+		"timestamp_granularities[]": "segment",
+	} {
+		if err := w.WriteField(k, v); err != nil {
+			return nil, fmt.Errorf("write multipart field: %w", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("close multipart body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, &body)
+	if err != nil {
+		return nil, fmt.Errorf("create transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transcription request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read transcription response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transcription API returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var tr transcribeResponse
+	if err := json.Unmarshal(respBody, &tr); err != nil {
+		return nil, fmt.Errorf("unmarshal transcription response: %w", err)
+	}
+	if len(tr.Segments) > 0 {
+		return tr.Segments, nil
+	}
+	if strings.TrimSpace(tr.Text) == "" {
+		return nil, errors.New("transcription API returned no text")
+	}
+	return []TranscriptSegment{{Start: 0, End: tr.Duration, Text: tr.Text}}, nil
+}
+
+// Model returns the transcription model name.
+func (t *Transcriber) Model() string {
+	return t.model
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+const imageTextPrompt = `Transcribe all text visible in this image exactly as written, preserving reading order, headings, lists, and table rows.
+Do not describe the image or add commentary. If the image contains no text, reply with an empty message.`
+
+// imageMIMETypes maps image extensions to the MIME types sent in data URLs.
+var imageMIMETypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// ExtractImageText asks a vision-capable model to transcribe the text in an
+// image file. model overrides the client's default model when non-empty.
+func (c *Client) ExtractImageText(ctx context.Context, path, model string) (string, error) {
+	mimeType, ok := imageMIMETypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return "", fmt.Errorf("unsupported image type %q for vision OCR", filepath.Ext(path))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read image: %w", err)
+	}
+	if model == "" {
+		model = c.model
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role: openai.ChatMessageRoleUser,
+				MultiContent: []openai.ChatMessagePart{
+					{Type: openai.ChatMessagePartTypeText, Text: imageTextPrompt},
+					{
+						Type: openai.ChatMessagePartTypeImageURL,
+						ImageURL: &openai.ChatMessageImageURL{
+							URL:    "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+							Detail: openai.ImageURLDetailHigh,
+						},
+					},
+				},
+			},
+		},
+		Temperature: 0,
+	})
+	if err != nil {
+		return "", fmt.Errorf("vision completion: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", ErrEmptyResponse
+	}
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,139 @@
+// Package logging builds the runtime's structured logger from LOG_LEVEL,
+// LOG_FORMAT, and LOG_FILE, with a level that can be changed while the
+// server runs.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Environment variables read by OptionsFromEnv.
+const (
+	LevelEnv  = "LOG_LEVEL"
+	FormatEnv = "LOG_FORMAT"
+	FileEnv   = "LOG_FILE"
+)
+
+// Options configure a Logger. Empty fields use the defaults: level "info",
+// format "text", and stderr.
+type Options struct {
+	// Level is "debug", "info", "warn", or "error"
+	Level string
+	// Format is "text" or "json"
+	Format string
+	// File is appended to instead of stderr when set
+	File string
+}
+
+// OptionsFromEnv reads Options from LOG_LEVEL, LOG_FORMAT, and LOG_FILE.
+func OptionsFromEnv() Options {
+	return Options{
+		Level:  os.Getenv(LevelEnv),
+		Format: os.Getenv(FormatEnv),
+		File:   os.Getenv(FileEnv),
+	}
+}
+
+// Logger is a slog.Logger whose level can be changed at runtime.
+type Logger struct {
+	*slog.Logger
+	level  *slog.LevelVar
+	format string
+	file   *os.File
+}
+
+// New creates a Logger, opening opts.File when set.
+func New(opts Options) (*Logger, error) {
+	level := new(slog.LevelVar)
+	if opts.Level != "" {
+		l, err := ParseLevel(opts.Level)
+		if err != nil {
+			return nil, err
+		}
+		level.Set(l)
+	}
+
+	var out io.Writer = os.Stderr
+	var file *os.File
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open log file: %w", err)
+		}
+		out, file = f, f
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	format := strings.ToLower(opts.Format)
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		format = "text"
+		handler = slog.NewTextHandler(out, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(out, handlerOpts)
+	default:
+		if file != nil {
+			file.Close()
+		}
+		return nil, fmt.Errorf("unknown log format %q (use text or json)", opts.Format)
+	}
+	return &Logger{Logger: slog.New(handler), level: level, format: format, file: file}, nil
+}
+
+// Discard returns a Logger that drops everything.
+func Discard() *Logger {
+	return &Logger{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		level:  new(slog.LevelVar),
+		format: "text",
+	}
+}
+
+// ParseLevel parses "debug", "info", "warn" (or "warning"), or "error".
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (use debug, info, warn, or error)", s)
+}
+
+// Level returns the current level, e.g. "info".
+func (l *Logger) Level() string {
+	return strings.ToLower(l.level.Level().String())
+}
+
+// SetLevel changes the level of every log call from now on.
+func (l *Logger) SetLevel(s string) error {
+	level, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	l.level.Set(level)
+	return nil
+}
+
+// Structured reports whether logs are JSON or go to a file. Terminal-only
+// output such as colored request lines should then go through the logger.
+func (l *Logger) Structured() bool {
+	return l.format == "json" || l.file != nil
+}
+
+// Close closes the log file, if any.
+func (l *Logger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
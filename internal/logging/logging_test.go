@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerLevelAndFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kash.log")
+	l, err := New(Options{Level: "warn", Format: "json", File: path})
+	require.NoError(t, err)
+	assert.True(t, l.Structured())
+	assert.Equal(t, "warn", l.Level())
+
+	l.Info("hidden")
+	l.Warn("shown", "n", 1)
+	require.NoError(t, l.SetLevel("debug"))
+	l.Debug("now shown")
+	assert.Error(t, l.SetLevel("verbose"))
+	require.NoError(t, l.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "shown", entry["msg"])
+	assert.Equal(t, "WARN", entry["level"])
+}
+
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		level   string
+		wantErr bool
+	}{
+		{"defaults", Options{}, "info", false},
+		{"case-insensitive", Options{Level: "DEBUG", Format: "JSON"}, "debug", false},
+		{"unknown level", Options{Level: "trace"}, "", true},
+		{"unknown format", Options{Format: "xml"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := New(tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.level, l.Level())
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/config"
+)
+
+// DefaultPath is where 'kash build' writes the manifest, relative to the project.
+const DefaultPath = "data/manifest.json"
+
+// ErrNotFound is returned when no manifest exists at the requested path.
+var ErrNotFound = errors.New("build manifest not found")
+
+// Manifest records what went into a build so rebuilds are traceable and the
+// runtime can verify compatibility with the persisted stores.
+type Manifest struct {
+	BuiltAt     time.Time    `json:"built_at"`
+	KashVersion string       `json:"kash_version"`
+	LLMModel    string       `json:"llm_model"`
+	Embedder    EmbedderInfo `json:"embedder"`
+	Documents   []Document   `json:"documents"`
+	Sources     []Source     `json:"sources,omitempty"`
+	Chunks      int          `json:"chunks"`
+	Vectors     int          `json:"vectors"`
+	Triples     int64        `json:"triples"`
+	// NoGraph is set by a build that skipped the knowledge graph
+	NoGraph bool `json:"no_graph,omitempty"`
+	// Fingerprint hashes the chunks, direct triples, and models of the
+	// build, so a rebuild can tell when nothing changed
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Merged names the agents 'kash merge' combined into this build
+	Merged []string `json:"merged,omitempty"`
+}
+
+// EmbedderInfo identifies the embedding model used for the vector store.
+type EmbedderInfo struct {
+	Model      string `json:"model,omitempty"`
+	Dimensions int    `json:"dimensions"`
+	// Languages maps the languages embedded with a model of their own, from
+	// embedder.languages, to that model
+	Languages map[string]string `json:"languages,omitempty"`
+}
+
+// Embedder returns the EmbedderInfo of the embedder cfg.
+func Embedder(cfg config.ProviderConfig) EmbedderInfo {
+	info := EmbedderInfo{Model: cfg.Model, Dimensions: cfg.Dimensions}
+	for _, code := range cfg.LanguageCodes() {
+		route, _ := cfg.ForLanguage(code)
+		if info.Languages == nil {
+			info.Languages = make(map[string]string, len(cfg.Languages))
+		}
+		info.Languages[code] = route.Model
+	}
+	return info
+}
+
+// Equal reports whether e and o name the same models and dimensions.
+func (e EmbedderInfo) Equal(o EmbedderInfo) bool {
+	return e.Model == o.Model && e.Dimensions == o.Dimensions && maps.Equal(e.Languages, o.Languages)
+}
+
+// CheckLanguages returns an error when the languages the build embedded with
+// models of their own, and those models, differ from the configured ones,
+// since queries would be embedded by another model than the chunks they
+// are matched against.
+func (m *Manifest) CheckLanguages(configured EmbedderInfo) error {
+	if maps.Equal(m.Embedder.Languages, configured.Languages) {
+		return nil
+	}
+	return fmt.Errorf("the vector store was built with language embedders %s, but embedder.languages configures %s — configure the same models or run 'kash build'",
+		languageList(m.Embedder.Languages), languageList(configured.Languages))
+}
+
+// languageList formats language embedders as "fr=model, ja=model", or
+// "none".
+func languageList(languages map[string]string) string {
+	if len(languages) == 0 {
+		return "none"
+	}
+	var parts []string
+	for code, model := range languages {
+		parts = append(parts, code+"="+model)
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ", ")
+}
+
+// Document describes one ingested document.
+type Document struct {
+	// Name is the document identifier used as the chunk source
+	Name string `json:"name"`
+	// Origin is where the document came from: "data", "url", "crawl", "git", "drive", "storage", "youtube", ...
+	Origin string `json:"origin"`
+	Chunks int    `json:"chunks"`
+	Bytes  int    `json:"bytes"`
+	// ChunkIDs are the IDs of the document's chunks in the vector store, so
+	// the next build can remove those it no longer produces
+	ChunkIDs []string `json:"chunk_ids,omitempty"`
+}
+
+// Source describes a remote source pulled during the build.
+type Source struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Ref       string `json:"ref,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	Documents int    `json:"documents"`
+}
+
+// Document returns the document named name.
+func (m *Manifest) Document(name string) (Document, bool) {
+	for _, doc := range m.Documents {
+		if doc.Name == name {
+			return doc, true
+		}
+	}
+	return Document{}, false
+}
+
+// ChunkIDs returns the IDs of every chunk the manifest records.
+func (m *Manifest) ChunkIDs() []string {
+	var ids []string
+	for _, doc := range m.Documents {
+		ids = append(ids, doc.ChunkIDs...)
+	}
+	return ids
+}
+
+// Load reads a manifest from path.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("read manifest %q: %w", path, err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest %q: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Save writes the manifest to path as indented JSON.
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write manifest %q: %w", path, err)
+	}
+	return nil
+}
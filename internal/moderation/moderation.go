@@ -0,0 +1,202 @@
+// Package moderation checks the questions users ask an agent and the answers
+// it gives against local rules and, optionally, an OpenAI-compatible
+// moderation endpoint.
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Stages a Moderator checks text at.
+const (
+	// Input is the user's question, checked before retrieval
+	Input = "input"
+	// Output is the LLM's answer, checked before it is sent
+	Output = "output"
+)
+
+// Actions on flagged text.
+const (
+	// Block replaces the request's answer with a refusal (default)
+	Block = "block"
+	// Flag lets the text through and only reports it
+	Flag = "flag"
+)
+
+// Providers of moderation beyond the local rules.
+const (
+	// ProviderRules checks the rules only (default)
+	ProviderRules = "rules"
+	// ProviderOpenAI also calls the /moderations endpoint of the LLM
+	// provider
+	ProviderOpenAI = "openai"
+)
+
+// DefaultMessage is the refusal sent for blocked text when Config sets none.
+const DefaultMessage = "I can't help with that request."
+
+// Config is the moderation block in agent.yaml.
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// Provider is ProviderRules (default) or ProviderOpenAI
+	Provider string `yaml:"provider"`
+	// Model is the moderation model, e.g. "omni-moderation-latest"
+	// (default: the provider's)
+	Model string `yaml:"model"`
+	// Categories limits the provider categories that count, e.g.
+	// [self-harm, violence] (default: every flagged category)
+	Categories []string `yaml:"categories"`
+	// Rules are regular expressions by category name, matched
+	// case-insensitively, e.g. {competitors: '\bacme\s+corp\b'}
+	Rules map[string]string `yaml:"rules"`
+	// Check is the stages to check, Input and Output (default: both)
+	Check []string `yaml:"check"`
+	// Action is Block (default) or Flag
+	Action string `yaml:"action"`
+	// Message is the refusal sent for blocked text (default:
+	// DefaultMessage)
+	Message string `yaml:"message"`
+}
+
+// Classifier is a moderation endpoint. It returns the categories it flags
+// text for.
+type Classifier interface {
+	Moderate(ctx context.Context, model, text string) ([]string, error)
+}
+
+// Verdict is the outcome of checking one text.
+type Verdict struct {
+	Stage string `json:"stage"`
+	// Categories are the rules and provider categories the text was
+	// flagged for, sorted
+	Categories []string `json:"categories"`
+	// Action is what was done: Block or Flag
+	Action string `json:"action"`
+}
+
+// Blocked reports whether v is set and blocks the text.
+func (v *Verdict) Blocked() bool {
+	return v != nil && v.Action == Block
+}
+
+// rule is one entry of Config.Rules.
+type rule struct {
+	category string
+	re       *regexp.Regexp
+}
+
+// Moderator applies the moderation block of agent.yaml.
+type Moderator struct {
+	cfg        Config
+	rules      []rule
+	classifier Classifier
+}
+
+// New creates a Moderator from cfg, calling classifier when cfg.Provider is
+// ProviderOpenAI. It returns nil when moderation is disabled; a nil
+// Moderator checks nothing.
+func New(cfg Config, classifier Classifier) (*Moderator, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	switch cfg.Provider {
+	case "":
+		cfg.Provider = ProviderRules
+	case ProviderRules, ProviderOpenAI:
+	default:
+		return nil, fmt.Errorf("unknown provider %q (want %s or %s)", cfg.Provider, ProviderRules, ProviderOpenAI)
+	}
+	switch cfg.Action {
+	case "":
+		cfg.Action = Block
+	case Block, Flag:
+	default:
+		return nil, fmt.Errorf("unknown action %q (want %s or %s)", cfg.Action, Block, Flag)
+	}
+	if len(cfg.Check) == 0 {
+		cfg.Check = []string{Input, Output}
+	}
+	for _, stage := range cfg.Check {
+		if stage != Input && stage != Output {
+			return nil, fmt.Errorf("unknown check %q (want %s or %s)", stage, Input, Output)
+		}
+	}
+	if cfg.Provider == ProviderRules && len(cfg.Rules) == 0 {
+		return nil, fmt.Errorf("provider %s needs at least one rule", ProviderRules)
+	}
+	if strings.TrimSpace(cfg.Message) == "" {
+		cfg.Message = DefaultMessage
+	}
+
+	m := &Moderator{cfg: cfg}
+	if cfg.Provider == ProviderOpenAI {
+		if classifier == nil {
+			return nil, fmt.Errorf("provider %s needs a client", ProviderOpenAI)
+		}
+		m.classifier = classifier
+	}
+	names := make([]string, 0, len(cfg.Rules))
+	for name := range cfg.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		re, err := regexp.Compile(`(?i)` + cfg.Rules[name])
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", name, err)
+		}
+		m.rules = append(m.rules, rule{name, re})
+	}
+	return m, nil
+}
+
+// Checks reports whether m checks text at stage.
+func (m *Moderator) Checks(stage string) bool {
+	return m != nil && slices.Contains(m.cfg.Check, stage)
+}
+
+// Message is the refusal sent for blocked text.
+func (m *Moderator) Message() string {
+	if m == nil {
+		return DefaultMessage
+	}
+	return m.cfg.Message
+}
+
+// Check checks text at stage against the rules and, with ProviderOpenAI, the
+// moderation endpoint. It returns nil when text is not flagged or m does not
+// check stage. When the endpoint fails, the verdict of the rules is returned
+// with the error.
+func (m *Moderator) Check(ctx context.Context, stage, text string) (*Verdict, error) {
+	if !m.Checks(stage) || strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	var categories []string
+	for _, r := range m.rules {
+		if r.re.MatchString(text) {
+			categories = append(categories, r.category)
+		}
+	}
+	var err error
+	if m.classifier != nil {
+		var flagged []string
+		if flagged, err = m.classifier.Moderate(ctx, m.cfg.Model, text); err != nil {
+			err = fmt.Errorf("moderate %s: %w", stage, err)
+		}
+		for _, c := range flagged {
+			if len(m.cfg.Categories) == 0 || slices.Contains(m.cfg.Categories, c) {
+				categories = append(categories, c)
+			}
+		}
+	}
+	if len(categories) == 0 {
+		return nil, err
+	}
+	sort.Strings(categories)
+	return &Verdict{Stage: stage, Categories: slices.Compact(categories), Action: m.cfg.Action}, err
+}
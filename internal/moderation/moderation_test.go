@@ -0,0 +1,101 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClassifier flags every text with its categories, or fails with err.
+type fakeClassifier struct {
+	categories []string
+	err        error
+}
+
+func (f fakeClassifier) Moderate(context.Context, string, string) ([]string, error) {
+	return f.categories, f.err
+}
+
+func TestModerator(t *testing.T) {
+	rules := map[string]string{"competitors": `\bacme\s+corp\b`, "secrets": `password`}
+	tests := []struct {
+		name       string
+		cfg        Config
+		classifier Classifier
+		stage      string
+		text       string
+		want       *Verdict
+		wantErr    bool
+	}{
+		{
+			name:  "rule blocks",
+			cfg:   Config{Enabled: true, Rules: rules},
+			stage: Input,
+			text:  "Is ACME  Corp cheaper? What's the admin password?",
+			want:  &Verdict{Stage: Input, Categories: []string{"competitors", "secrets"}, Action: Block},
+		},
+		{
+			name:  "passes",
+			cfg:   Config{Enabled: true, Rules: rules},
+			stage: Output,
+			text:  "Acme Corporation is a fictional company.",
+		},
+		{
+			name:  "stage not checked",
+			cfg:   Config{Enabled: true, Rules: rules, Check: []string{Output}},
+			stage: Input,
+			text:  "password",
+		},
+		{
+			name:       "provider categories",
+			cfg:        Config{Enabled: true, Provider: ProviderOpenAI, Categories: []string{"violence"}, Action: Flag},
+			classifier: fakeClassifier{categories: []string{"harassment", "violence"}},
+			stage:      Output,
+			text:       "...",
+			want:       &Verdict{Stage: Output, Categories: []string{"violence"}, Action: Flag},
+		},
+		{
+			name:       "provider down, rules still apply",
+			cfg:        Config{Enabled: true, Provider: ProviderOpenAI, Rules: rules},
+			classifier: fakeClassifier{err: errors.New("503")},
+			stage:      Input,
+			text:       "password",
+			want:       &Verdict{Stage: Input, Categories: []string{"secrets"}, Action: Block},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.cfg, tt.classifier)
+			require.NoError(t, err)
+			got, err := m.Check(context.Background(), tt.stage, tt.text)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want != nil && tt.want.Action == Block, got.Blocked())
+		})
+	}
+
+	var off *Moderator
+	v, err := off.Check(context.Background(), Input, "password")
+	assert.NoError(t, err)
+	assert.Nil(t, v, "disabled moderation checks nothing")
+	assert.Equal(t, DefaultMessage, off.Message())
+
+	for _, cfg := range []Config{
+		{Enabled: true, Provider: "azure"},
+		{Enabled: true, Rules: rules, Action: "warn"},
+		{Enabled: true, Rules: rules, Check: []string{"history"}},
+		{Enabled: true},
+		{Enabled: true, Rules: map[string]string{"bad": "("}},
+	} {
+		_, err := New(cfg, nil)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,47 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Tesseract recognizes text by running the local tesseract binary.
+type Tesseract struct {
+	bin  string
+	lang string
+}
+
+// NewTesseract locates the tesseract binary. lang is a Tesseract language
+// code such as "eng" or "eng+deu" (default: "eng").
+func NewTesseract(lang string) (*Tesseract, error) {
+	bin, err := exec.LookPath("tesseract")
+	if err != nil {
+		return nil, errors.New("tesseract executable not found in PATH")
+	}
+	if lang == "" {
+		lang = "eng"
+	}
+	return &Tesseract{bin: bin, lang: lang}, nil
+}
+
+// Available reports whether tesseract is installed.
+func Available() bool {
+	_, err := exec.LookPath("tesseract")
+	return err == nil
+}
+
+// Recognize returns the text tesseract finds in the image at path.
+func (t *Tesseract) Recognize(ctx context.Context, path string) (string, error) {
+	cmd := exec.CommandContext(ctx, t.bin, path, "stdout", "-l", t.lang)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("tesseract: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,289 @@
+// Package pii finds personal data such as email addresses, phone numbers,
+// and credit card numbers in chunks, and redacts it, tags the chunks, or
+// leaves them out of the index.
+package pii
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/akashicode/kash/internal/chunker"
+	agentconfig "github.com/akashicode/kash/internal/config"
+)
+
+// Built-in kinds of personal data.
+const (
+	Email      = "email"
+	Phone      = "phone"
+	CreditCard = "credit_card"
+)
+
+// Actions on a chunk with personal data.
+const (
+	// Redact replaces each match with its kind, e.g. "[EMAIL]" (default)
+	Redact = "redact"
+	// Tag keeps the text and lists the kinds found in the chunk's "pii"
+	// metadata, so retrieval filters can exclude it
+	Tag = "tag"
+	// Block leaves the chunk out of the index
+	Block = "block"
+)
+
+// MetadataKey is the chunk metadata key Tag lists the kinds found under.
+const MetadataKey = "pii"
+
+// detector finds one kind of personal data.
+type detector struct {
+	kind string
+	re   *regexp.Regexp
+	// valid checks a match further, e.g. its checksum; nil accepts all
+	valid func(text string, start, end int) bool
+}
+
+// builtins are the built-in detectors, in the order their matches win over
+// overlapping ones.
+var builtins = []detector{
+	{Email, regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), nil},
+	{CreditCard, regexp.MustCompile(`\d(?:[ -]?\d){12,18}`), func(text string, start, end int) bool {
+		return isolated(text, start, end) && luhn(text[start:end])
+	}},
+	{Phone, regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{1,4}\)[\s.-]?)?\d{2,4}[\s.-]\d{3,4}(?:[\s.-]\d{2,4})?`), func(text string, start, end int) bool {
+		n := digits(text[start:end])
+		return isolated(text, start, end) && n >= 9 && n <= 15
+	}},
+}
+
+// Summary counts what a Scanner found.
+type Summary struct {
+	Action string `json:"action"`
+	// Found counts the matches of each kind
+	Found map[string]int `json:"found"`
+	// Chunks counts the chunks with any match, and Blocked those left out
+	Chunks  int `json:"chunks"`
+	Blocked int `json:"blocked"`
+	// Documents counts the chunks with any match in each source
+	Documents map[string]int `json:"documents"`
+}
+
+// Scanner applies the pii block of agent.yaml to chunks. It is safe for
+// concurrent use.
+type Scanner struct {
+	action    string
+	detectors []detector
+
+	mu      sync.Mutex
+	summary Summary
+}
+
+// New returns a Scanner for cfg, or nil when cfg is not enabled. A nil
+// Scanner passes chunks through.
+func New(cfg agentconfig.PIIConfig) (*Scanner, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	action := cfg.Action
+	switch action {
+	case "":
+		action = Redact
+	case Redact, Tag, Block:
+	default:
+		return nil, fmt.Errorf("pii.action: unknown action %q (want %s, %s, or %s)", action, Redact, Tag, Block)
+	}
+
+	var detectors []detector
+	for _, d := range builtins {
+		if len(cfg.Detect) == 0 || slices.Contains(cfg.Detect, d.kind) {
+			detectors = append(detectors, d)
+		}
+	}
+	for _, kind := range cfg.Detect {
+		if !slices.ContainsFunc(builtins, func(d detector) bool { return d.kind == kind }) {
+			return nil, fmt.Errorf("pii.detect: unknown kind %q (want %s, %s, or %s)", kind, Email, Phone, CreditCard)
+		}
+	}
+	names := make([]string, 0, len(cfg.Patterns))
+	for name := range cfg.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		re, err := regexp.Compile(cfg.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("pii.patterns.%s: %w", name, err)
+		}
+		detectors = append(detectors, detector{kind: name, re: re})
+	}
+
+	return &Scanner{
+		action:    action,
+		detectors: detectors,
+		summary:   Summary{Action: action, Found: map[string]int{}, Documents: map[string]int{}},
+	}, nil
+}
+
+// Apply returns chunks with the personal data in them redacted, tagged, or
+// left out, as the scanner's action says.
+func (s *Scanner) Apply(chunks []chunker.Chunk) []chunker.Chunk {
+	if s == nil {
+		return chunks
+	}
+	out := chunks[:0:0]
+	for _, ch := range chunks {
+		spans := s.find(ch.Content)
+		if len(spans) == 0 {
+			out = append(out, ch)
+			continue
+		}
+		s.record(ch.Source, spans)
+		switch s.action {
+		case Block:
+			continue
+		case Tag:
+			kinds := map[string]bool{}
+			for _, sp := range spans {
+				kinds[sp.kind] = true
+			}
+			list := make([]string, 0, len(kinds))
+			for k := range kinds {
+				list = append(list, k)
+			}
+			sort.Strings(list)
+			ch.Metadata = maps.Clone(ch.Metadata)
+			if ch.Metadata == nil {
+				ch.Metadata = map[string]string{}
+			}
+			ch.Metadata[MetadataKey] = strings.Join(list, ",")
+		default:
+			ch.Content = redact(ch.Content, spans)
+		}
+		out = append(out, ch)
+	}
+	return out
+}
+
+// Redact returns text with the personal data in it redacted, whatever the
+// scanner's action: the full text of a document kept with the index is not
+// subject to the retrieval filters a tag feeds. It is not counted in the
+// summary.
+func (s *Scanner) Redact(text string) string {
+	if s == nil {
+		return text
+	}
+	if spans := s.find(text); len(spans) > 0 {
+		return redact(text, spans)
+	}
+	return text
+}
+
+// Summary returns what the scanner found so far.
+func (s *Scanner) Summary() Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sum := s.summary
+	sum.Found = maps.Clone(sum.Found)
+	sum.Documents = maps.Clone(sum.Documents)
+	return sum
+}
+
+func (s *Scanner) record(source string, spans []span) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sp := range spans {
+		s.summary.Found[sp.kind]++
+	}
+	s.summary.Chunks++
+	s.summary.Documents[source]++
+	if s.action == Block {
+		s.summary.Blocked++
+	}
+}
+
+type span struct {
+	start, end int
+	kind       string
+}
+
+// find returns the matches of every detector in text, in order, leaving out
+// those that overlap the match of an earlier detector.
+func (s *Scanner) find(text string) []span {
+	var spans []span
+	for _, d := range s.detectors {
+		for _, m := range d.re.FindAllStringIndex(text, -1) {
+			if m[0] == m[1] || (d.valid != nil && !d.valid(text, m[0], m[1])) || overlaps(spans, m[0], m[1]) {
+				continue
+			}
+			spans = append(spans, span{m[0], m[1], d.kind})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+// redact replaces each span of text with its kind, e.g. "[EMAIL]".
+func redact(text string, spans []span) string {
+	var sb strings.Builder
+	last := 0
+	for _, sp := range spans {
+		sb.WriteString(text[last:sp.start])
+		sb.WriteString("[" + strings.ToUpper(sp.kind) + "]")
+		last = sp.end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+func overlaps(spans []span, start, end int) bool {
+	for _, sp := range spans {
+		if start < sp.end && sp.start < end {
+			return true
+		}
+	}
+	return false
+}
+
+// isolated reports whether the number at text[start:end] is not part of a
+// longer one, such as an IP address or a version.
+func isolated(text string, start, end int) bool {
+	isDigit := func(i int) bool { return i >= 0 && i < len(text) && text[i] >= '0' && text[i] <= '9' }
+	isJoin := func(i int) bool { return i >= 0 && i < len(text) && strings.IndexByte(".-", text[i]) >= 0 }
+	if isDigit(start-1) || (isJoin(start-1) && isDigit(start-2)) {
+		return false
+	}
+	return !isDigit(end) && !(isJoin(end) && isDigit(end+1))
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum of card
+// numbers.
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func digits(s string) int {
+	n := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			n++
+		}
+	}
+	return n
+}
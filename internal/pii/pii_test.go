@@ -0,0 +1,90 @@
+package pii
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/chunker"
+	agentconfig "github.com/akashicode/kash/internal/config"
+)
+
+func TestScanner(t *testing.T) {
+	chunks := func() []chunker.Chunk {
+		return []chunker.Chunk{
+			{ID: "a_0", Source: "a.md", Content: "Mail jane.doe@example.com or call +1 (555) 123-4567."},
+			{ID: "a_1", Source: "a.md", Content: "Card 4111 1111 1111 1111 expires soon. Staff ID EMP-123456."},
+			{ID: "b_0", Source: "b.md", Content: "Released 2024-10-17 as v1.2.3; the server is 192.168.100.200, order 1234567890123."},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		cfg     agentconfig.PIIConfig
+		want    []string
+		found   map[string]int
+		blocked int
+	}{
+		{
+			name: "redact",
+			cfg:  agentconfig.PIIConfig{Enabled: true, Patterns: map[string]string{"employee_id": `EMP-\d{6}`}},
+			want: []string{
+				"Mail [EMAIL] or call [PHONE].",
+				"Card [CREDIT_CARD] expires soon. Staff ID [EMPLOYEE_ID].",
+				"Released 2024-10-17 as v1.2.3; the server is 192.168.100.200, order 1234567890123.",
+			},
+			found: map[string]int{Email: 1, Phone: 1, CreditCard: 1, "employee_id": 1},
+		},
+		{
+			name:  "detect only email",
+			cfg:   agentconfig.PIIConfig{Enabled: true, Detect: []string{Email}},
+			want:  []string{"Mail [EMAIL] or call +1 (555) 123-4567.", "Card 4111 1111 1111 1111 expires soon. Staff ID EMP-123456.", "Released 2024-10-17 as v1.2.3; the server is 192.168.100.200, order 1234567890123."},
+			found: map[string]int{Email: 1},
+		},
+		{
+			name:    "block",
+			cfg:     agentconfig.PIIConfig{Enabled: true, Action: Block},
+			want:    []string{"Released 2024-10-17 as v1.2.3; the server is 192.168.100.200, order 1234567890123."},
+			found:   map[string]int{Email: 1, Phone: 1, CreditCard: 1},
+			blocked: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.cfg)
+			require.NoError(t, err)
+			var got []string
+			for _, ch := range s.Apply(chunks()) {
+				got = append(got, ch.Content)
+			}
+			assert.Equal(t, tt.want, got)
+			sum := s.Summary()
+			assert.Equal(t, tt.found, sum.Found)
+			assert.Equal(t, tt.blocked, sum.Blocked)
+		})
+	}
+
+	s, err := New(agentconfig.PIIConfig{Enabled: true, Action: Tag})
+	require.NoError(t, err)
+	tagged := s.Apply(chunks())
+	require.Len(t, tagged, 3)
+	assert.Equal(t, "email,phone", tagged[0].Metadata[MetadataKey])
+	assert.Contains(t, tagged[0].Content, "jane.doe@example.com", "tagging keeps the text")
+	assert.Empty(t, tagged[2].Metadata[MetadataKey])
+	assert.Equal(t, map[string]int{"a.md": 2}, s.Summary().Documents)
+	assert.Equal(t, "Mail [EMAIL] or call [PHONE].", s.Redact(chunks()[0].Content), "Redact redacts whatever the action")
+	assert.Equal(t, map[string]int{"a.md": 2}, s.Summary().Documents, "Redact is not counted")
+
+	var off *Scanner
+	assert.Len(t, off.Apply(chunks()), 3, "a disabled scanner passes chunks through")
+	assert.Equal(t, chunks()[0].Content, off.Redact(chunks()[0].Content))
+	for _, cfg := range []agentconfig.PIIConfig{
+		{Enabled: true, Action: "mask"},
+		{Enabled: true, Detect: []string{"ssn"}},
+		{Enabled: true, Patterns: map[string]string{"bad": "("}},
+	} {
+		_, err := New(cfg)
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,73 @@
+// Package pricing converts token counts into approximate provider costs, from
+// a built-in price table that config.yaml can extend or override.
+package pricing
+
+import (
+	"strings"
+)
+
+// DefaultConfirmAbove is the estimated build cost, in US dollars, above which
+// 'kash build' asks before calling the providers when config.yaml does not
+// set pricing.confirm_above.
+const DefaultConfirmAbove = 1.0
+
+// Price is what a model charges, in US dollars per million tokens.
+type Price struct {
+	Input  float64 `mapstructure:"input"  yaml:"input"`
+	Output float64 `mapstructure:"output" yaml:"output,omitempty"`
+}
+
+// Table maps model names to their prices.
+type Table map[string]Price
+
+// defaults are list prices of common hosted models. They go stale; set
+// pricing.models in config.yaml for the models you use.
+var defaults = Table{
+	"text-embedding-3-small": {Input: 0.02},
+	"text-embedding-3-large": {Input: 0.13},
+	"text-embedding-ada-002": {Input: 0.10},
+	"gpt-4o":                 {Input: 2.50, Output: 10.00},
+	"gpt-4o-mini":            {Input: 0.15, Output: 0.60},
+	"gpt-4.1":                {Input: 2.00, Output: 8.00},
+	"gpt-4.1-mini":           {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":           {Input: 0.10, Output: 0.40},
+}
+
+// New returns the built-in table with overrides applied on top.
+func New(overrides Table) Table {
+	t := make(Table, len(defaults)+len(overrides))
+	for model, p := range defaults {
+		t[model] = p
+	}
+	for model, p := range overrides {
+		t[strings.ToLower(model)] = p
+	}
+	return t
+}
+
+// Lookup returns the price of model. A provider prefix such as "openai/" is
+// ignored when the full name is not listed.
+func (t Table) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return Price{}, false
+	}
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		p, ok := t[model[i+1:]]
+		return p, ok
+	}
+	return Price{}, false
+}
+
+// Cost returns what input and output tokens of model cost, and whether the
+// model has a price.
+func (t Table) Cost(model string, input, output int) (float64, bool) {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(input)*p.Input + float64(output)*p.Output) / 1e6, true
+}
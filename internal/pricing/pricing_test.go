@@ -0,0 +1,36 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableCost(t *testing.T) {
+	table := New(Table{
+		"My-Model":    {Input: 1, Output: 2},
+		"gpt-4o-mini": {Input: 0.5, Output: 1},
+	})
+
+	tests := []struct {
+		name          string
+		model         string
+		input, output int
+		want          float64
+		wantOK        bool
+	}{
+		{name: "override", model: "my-model", input: 1_000_000, output: 500_000, want: 2, wantOK: true},
+		{name: "override replaces default", model: "gpt-4o-mini", input: 2_000_000, want: 1, wantOK: true},
+		{name: "default", model: "text-embedding-3-small", input: 1_000_000, want: 0.02, wantOK: true},
+		{name: "provider prefix", model: "openai/text-embedding-3-small", input: 1_000_000, want: 0.02, wantOK: true},
+		{name: "unknown", model: "llama3.1", input: 1_000_000},
+		{name: "router without model", model: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := table.Cost(tt.model, tt.input, tt.output)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
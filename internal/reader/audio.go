@@ -0,0 +1,143 @@
+package reader
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultTranscriptWindow is the default length in seconds of each
+// timestamped transcript section.
+const DefaultTranscriptWindow = 60
+
+// TranscriptSegment is a timed span of transcribed speech.
+type TranscriptSegment struct {
+	// Start and End are offsets in seconds from the beginning of the recording
+	Start float64
+	End   float64
+	// Speaker is the diarized speaker label, if the transcription service provides one
+	Speaker string
+	Text    string
+}
+
+// Transcriber converts an audio file into timed transcript segments.
+type Transcriber interface {
+	Transcribe(path string) ([]TranscriptSegment, error)
+}
+
+// loadAudio transcribes an audio file into a Document with one section per
+// DefaultTranscriptWindow seconds of speech.
+func loadAudio(path string, t Transcriber) (Document, error) {
+	if t == nil {
+		return Document{}, fmt.Errorf("%w: %s (no transcriber configured)", ErrUnsupportedFormat, filepath.Ext(path))
+	}
+	segments, err := t.Transcribe(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("transcribe %q: %w", path, err)
+	}
+
+	sections := TranscriptSections(segments, DefaultTranscriptWindow)
+	parts := make([]string, 0, len(sections))
+	for _, sec := range sections {
+		parts = append(parts, sec.Content)
+	}
+	doc := Document{
+		Path:     path,
+		Name:     filepath.Base(path),
+		Content:  strings.Join(parts, "\n\n"),
+		Metadata: map[string]string{"media": "audio"},
+		Sections: sections,
+	}
+	if n := len(segments); n > 0 {
+		doc.Metadata["duration_seconds"] = strconv.Itoa(int(segments[n-1].End + 0.5))
+	}
+	return doc, nil
+}
+
+// TranscriptSections groups segments into consecutive sections of roughly
+// windowSeconds each. Section metadata carries start_seconds, end_seconds,
+// a m:ss timestamp, and the speakers heard in the window. When segments have
+// speaker labels, each line of the section content is prefixed with one.
+func TranscriptSections(segments []TranscriptSegment, windowSeconds int) []Section {
+	if windowSeconds <= 0 {
+		windowSeconds = DefaultTranscriptWindow
+	}
+
+	var sections []Section
+	var lines []string
+	speakers := map[string]bool{}
+	lastSpeaker := ""
+	start, end := -1.0, 0.0
+
+	flush := func() {
+		if len(lines) == 0 {
+			return
+		}
+		meta := map[string]string{
+			"start_seconds": strconv.Itoa(int(start)),
+			"end_seconds":   strconv.Itoa(int(end + 0.5)),
+			"timestamp":     FormatTimestamp(int(start)),
+		}
+		if len(speakers) > 0 {
+			names := make([]string, 0, len(speakers))
+			for s := range speakers {
+				names = append(names, s)
+			}
+			sort.Strings(names)
+			meta["speakers"] = strings.Join(names, ", ")
+		}
+		sep := " "
+		if len(speakers) > 0 {
+			sep = "\n"
+		}
+		sections = append(sections, Section{
+			Title:    FormatTimestamp(int(start)),
+			Content:  strings.Join(lines, sep),
+			Metadata: meta,
+		})
+		lines = nil
+		speakers = map[string]bool{}
+		lastSpeaker = ""
+		start = -1
+	}
+
+	for _, seg := range segments {
+		text := strings.Join(strings.Fields(seg.Text), " ")
+		if text == "" {
+			continue
+		}
+		if start >= 0 && seg.Start-start >= float64(windowSeconds) {
+			flush()
+		}
+		if start < 0 {
+			start = seg.Start
+		}
+		if seg.Speaker != "" {
+			speakers[seg.Speaker] = true
+			if seg.Speaker != lastSpeaker {
+				text = seg.Speaker + ": " + text
+				lastSpeaker = seg.Speaker
+			} else if n := len(lines); n > 0 {
+				// Continue the current speaker's line
+				lines[n-1] += " " + text
+				end = seg.End
+				continue
+			}
+		}
+		lines = append(lines, text)
+		end = seg.End
+	}
+	flush()
+	return sections
+}
+
+// FormatTimestamp renders seconds as m:ss or h:mm:ss.
+func FormatTimestamp(sec int) string {
+	h, m, s := sec/3600, (sec%3600)/60, sec%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
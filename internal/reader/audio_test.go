@@ -0,0 +1,54 @@
+package reader
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTranscriber []TranscriptSegment
+
+func (f fakeTranscriber) Transcribe(string) ([]TranscriptSegment, error) {
+	return f, nil
+}
+
+func TestTranscriptSections(t *testing.T) {
+	segs := []TranscriptSegment{
+		{Start: 0, End: 4, Speaker: "A", Text: "Hello there."},
+		{Start: 4, End: 8, Speaker: "A", Text: "Welcome."},
+		{Start: 8, End: 20, Speaker: "B", Text: "Thanks!"},
+		{Start: 65, End: 70, Speaker: "B", Text: "Next topic."},
+	}
+	sections := TranscriptSections(segs, 60)
+	require.Len(t, sections, 2)
+
+	assert.Equal(t, "A: Hello there. Welcome.\nB: Thanks!", sections[0].Content)
+	assert.Equal(t, map[string]string{
+		"start_seconds": "0",
+		"end_seconds":   "20",
+		"timestamp":     "0:00",
+		"speakers":      "A, B",
+	}, sections[0].Metadata)
+	assert.Equal(t, "1:05", sections[1].Metadata["timestamp"])
+	assert.Equal(t, "B: Next topic.", sections[1].Content)
+}
+
+func TestLoadAudio(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "talk.mp3")
+	require.NoError(t, os.WriteFile(path, []byte("ID3"), 0644))
+
+	_, err := NewReader(DefaultOptions()).LoadFile(path)
+	assert.True(t, errors.Is(err, ErrUnsupportedFormat))
+
+	opts := DefaultOptions()
+	opts.Transcriber = fakeTranscriber{{Start: 0, End: 3725, Text: "Long talk."}}
+	doc, err := NewReader(opts).LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Long talk.", doc.Content)
+	assert.Equal(t, "3725", doc.Metadata["duration_seconds"])
+	assert.Equal(t, "1:02:05", FormatTimestamp(3725))
+}
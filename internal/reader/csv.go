@@ -0,0 +1,175 @@
+package reader
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions controls how tabular files are converted into sections.
+type CSVOptions struct {
+	// RowsPerChunk is the number of rows grouped into one section (default: 1)
+	RowsPerChunk int
+	// IDColumn names the column whose value identifies a row. It is used as
+	// the subject of emitted triples and recorded in section metadata. A
+	// file without the column is read with a warning, its rows identified
+	// by number.
+	IDColumn string
+	// EmitTriples turns every non-empty cell into an (id, column, value)
+	// triple keyed by IDColumn
+	EmitTriples bool
+}
+
+// loadCSV reads a delimited file with a header row. Each group of rows becomes
+// a section rendered as "column: value" pairs so every chunk carries the
+// column-name context needed for retrieval.
+func loadCSV(path string, delim rune, opts CSVOptions) (Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("open file %q: %w", path, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = delim
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return Document{Path: path, Name: filepath.Base(path)}, nil
+		}
+		return Document{}, fmt.Errorf("read header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	idCol := -1
+	if opts.IDColumn != "" {
+		for i, h := range header {
+			if strings.EqualFold(h, opts.IDColumn) {
+				idCol = i
+				break
+			}
+		}
+		// ingest.csv applies to every file, and not all of them need have
+		// the column; their rows are known by row_start and row_end alone
+		if idCol == -1 {
+			fmt.Fprintf(os.Stderr, "warning: %q has no id column %q; its rows are identified by number and emit no triples\n", path, opts.IDColumn)
+		}
+	}
+
+	rowsPerChunk := opts.RowsPerChunk
+	if rowsPerChunk <= 0 {
+		rowsPerChunk = 1
+	}
+
+	doc := Document{
+		Path:     path,
+		Name:     filepath.Base(path),
+		Metadata: map[string]string{"columns": strings.Join(header, ",")},
+	}
+
+	var group []string
+	var groupIDs []string
+	firstRow := 0
+	rowNum := 0
+
+	flush := func() {
+		if len(group) == 0 {
+			return
+		}
+		meta := map[string]string{
+			"row_start": strconv.Itoa(firstRow),
+			"row_end":   strconv.Itoa(rowNum),
+		}
+		if len(groupIDs) > 0 {
+			meta["row_ids"] = strings.Join(groupIDs, ",")
+		}
+		doc.Sections = append(doc.Sections, Section{
+			Content:  strings.Join(group, "\n\n"),
+			Metadata: meta,
+		})
+		group = nil
+		groupIDs = nil
+	}
+
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Document{}, fmt.Errorf("read row %d: %w", rowNum+1, err)
+		}
+		rowNum++
+
+		line := formatRow(header, record)
+		if line == "" {
+			continue
+		}
+		if len(group) == 0 {
+			firstRow = rowNum
+		}
+		group = append(group, line)
+
+		if idCol >= 0 && idCol < len(record) {
+			id := strings.TrimSpace(record[idCol])
+			if id != "" {
+				groupIDs = append(groupIDs, id)
+				if opts.EmitTriples {
+					doc.Triples = append(doc.Triples, rowTriples(id, idCol, header, record)...)
+				}
+			}
+		}
+
+		if len(group) >= rowsPerChunk {
+			flush()
+		}
+	}
+	flush()
+
+	parts := make([]string, len(doc.Sections))
+	for i, sec := range doc.Sections {
+		parts[i] = sec.Content
+	}
+	doc.Content = strings.Join(parts, "\n\n")
+	return doc, nil
+}
+
+// formatRow renders a record as "column: value, column: value", skipping empty cells.
+func formatRow(header, record []string) string {
+	pairs := make([]string, 0, len(record))
+	for i, v := range record {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		name := "column_" + strconv.Itoa(i+1)
+		if i < len(header) && header[i] != "" {
+			name = header[i]
+		}
+		pairs = append(pairs, name+": "+v)
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// rowTriples emits one (id, column, value) triple per non-empty, non-id cell.
+func rowTriples(id string, idCol int, header, record []string) []Triple {
+	triples := make([]Triple, 0, len(record))
+	for i, v := range record {
+		v = strings.TrimSpace(v)
+		if i == idCol || v == "" || i >= len(header) || header[i] == "" {
+			continue
+		}
+		triples = append(triples, Triple{Subject: id, Predicate: header[i], Object: v})
+	}
+	return triples
+}
@@ -0,0 +1,92 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCSV(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "products.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("sku,name,price\nA1,Widget,9.99\nB2,Gadget,\nC3,Gizmo,4.50\n"), 0644))
+	tsvPath := filepath.Join(dir, "products.tsv")
+	require.NoError(t, os.WriteFile(tsvPath, []byte("sku\tname\nA1\tWidget\n"), 0644))
+
+	tests := []struct {
+		name         string
+		path         string
+		opts         CSVOptions
+		wantSections []string
+		wantTriples  int
+		wantErr      bool
+	}{
+		{
+			name: "one row per chunk",
+			path: csvPath,
+			opts: CSVOptions{RowsPerChunk: 1},
+			wantSections: []string{
+				"sku: A1, name: Widget, price: 9.99",
+				"sku: B2, name: Gadget",
+				"sku: C3, name: Gizmo, price: 4.50",
+			},
+		},
+		{
+			name: "grouped rows with triples",
+			path: csvPath,
+			opts: CSVOptions{RowsPerChunk: 2, IDColumn: "SKU", EmitTriples: true},
+			wantSections: []string{
+				"sku: A1, name: Widget, price: 9.99\n\nsku: B2, name: Gadget",
+				"sku: C3, name: Gizmo, price: 4.50",
+			},
+			wantTriples: 5,
+		},
+		{
+			name:         "tab separated",
+			path:         tsvPath,
+			opts:         CSVOptions{RowsPerChunk: 1},
+			wantSections: []string{"sku: A1, name: Widget"},
+		},
+		{
+			name: "unknown id column falls back to row numbers",
+			path: csvPath,
+			opts: CSVOptions{RowsPerChunk: 3, IDColumn: "missing", EmitTriples: true},
+			wantSections: []string{
+				"sku: A1, name: Widget, price: 9.99\n\nsku: B2, name: Gadget\n\nsku: C3, name: Gizmo, price: 4.50",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewReader(Options{CSV: tt.opts}).LoadFile(tt.path)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got := make([]string, len(doc.Sections))
+			for i, sec := range doc.Sections {
+				got[i] = sec.Content
+			}
+			assert.Equal(t, tt.wantSections, got)
+			assert.Len(t, doc.Triples, tt.wantTriples)
+		})
+	}
+}
+
+func TestLoadCSV_TripleShape(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "people.csv")
+	require.NoError(t, os.WriteFile(p, []byte("name,role\nAda,Engineer\n"), 0644))
+
+	doc, err := NewReader(Options{CSV: CSVOptions{IDColumn: "name", EmitTriples: true}}).LoadFile(p)
+	require.NoError(t, err)
+
+	require.Len(t, doc.Triples, 1)
+	assert.Equal(t, Triple{Subject: "Ada", Predicate: "role", Object: "Engineer"}, doc.Triples[0])
+	assert.Equal(t, "Ada", doc.Sections[0].Metadata["row_ids"])
+}
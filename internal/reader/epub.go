@@ -0,0 +1,273 @@
+package reader
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// epubContainer is META-INF/container.xml, which points at the OPF package file.
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage is the subset of the OPF package document Kash needs.
+type epubPackage struct {
+	Metadata struct {
+		Title    []string `xml:"title"`
+		Creator  []string `xml:"creator"`
+		Language []string `xml:"language"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine struct {
+		Toc      string `xml:"toc,attr"`
+		Itemrefs []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// epubNavPoint is a single entry in an EPUB 2 NCX table of contents.
+type epubNavPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Children []epubNavPoint `xml:"navPoint"`
+}
+
+// epubNCX is the EPUB 2 toc.ncx document.
+type epubNCX struct {
+	NavPoints []epubNavPoint `xml:"navMap>navPoint"`
+}
+
+// loadEPUB reads an EPUB file and returns one section per spine chapter.
+// Chapter titles come from the table of contents (EPUB 3 nav or EPUB 2 NCX),
+// falling back to the chapter's first heading or <title>.
+func loadEPUB(p string) (Document, error) {
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		return Document{}, fmt.Errorf("open EPUB: %w", err)
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var container epubContainer
+	if err := readZipXML(files, "META-INF/container.xml", &container); err != nil {
+		return Document{}, err
+	}
+	if len(container.Rootfiles) == 0 || container.Rootfiles[0].FullPath == "" {
+		return Document{}, errors.New("EPUB container lists no package file")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	opfDir := path.Dir(opfPath)
+
+	var pkg epubPackage
+	if err := readZipXML(files, opfPath, &pkg); err != nil {
+		return Document{}, err
+	}
+
+	hrefByID := make(map[string]string, len(pkg.Manifest))
+	var navHref, ncxHref string
+	for _, item := range pkg.Manifest {
+		hrefByID[item.ID] = item.Href
+		if strings.Contains(item.Properties, "nav") {
+			navHref = item.Href
+		}
+		if item.MediaType == "application/x-dtbncx+xml" {
+			ncxHref = item.Href
+		}
+	}
+	if pkg.Spine.Toc != "" && hrefByID[pkg.Spine.Toc] != "" {
+		ncxHref = hrefByID[pkg.Spine.Toc]
+	}
+
+	// Map chapter file → title from the table of contents
+	titles := map[string]string{}
+	if navHref != "" {
+		collectNavTitles(files, path.Join(opfDir, navHref), titles)
+	}
+	if len(titles) == 0 && ncxHref != "" {
+		collectNCXTitles(files, path.Join(opfDir, ncxHref), titles)
+	}
+
+	doc := Document{
+		Path:     p,
+		Name:     filepath.Base(p),
+		Metadata: map[string]string{},
+	}
+	if len(pkg.Metadata.Title) > 0 {
+		doc.Metadata["title"] = strings.TrimSpace(pkg.Metadata.Title[0])
+	}
+	if len(pkg.Metadata.Creator) > 0 {
+		doc.Metadata["author"] = strings.TrimSpace(pkg.Metadata.Creator[0])
+	}
+	if len(pkg.Metadata.Language) > 0 {
+		doc.Metadata["language"] = strings.TrimSpace(pkg.Metadata.Language[0])
+	}
+
+	var all strings.Builder
+	chapter := 0
+	for _, ref := range pkg.Spine.Itemrefs {
+		href, ok := hrefByID[ref.IDRef]
+		if !ok {
+			continue
+		}
+		full := path.Join(opfDir, href)
+		f, ok := files[full]
+		if !ok {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return Document{}, fmt.Errorf("open chapter %q: %w", full, err)
+		}
+		extracted, err := extractHTMLText(rc)
+		rc.Close()
+		if err != nil {
+			return Document{}, fmt.Errorf("extract chapter %q: %w", full, err)
+		}
+		if extracted.Text == "" {
+			continue
+		}
+
+		chapter++
+		title := titles[full]
+		if title == "" {
+			title = extracted.Heading
+		}
+		if title == "" {
+			title = extracted.Title
+		}
+
+		meta := map[string]string{"chapter": strconv.Itoa(chapter)}
+		if title != "" {
+			meta["chapter_title"] = title
+		}
+		doc.Sections = append(doc.Sections, Section{
+			Title:    title,
+			Content:  extracted.Text,
+			Metadata: meta,
+		})
+
+		if all.Len() > 0 {
+			all.WriteString("\n\n")
+		}
+		all.WriteString(extracted.Text)
+	}
+
+	if len(doc.Sections) == 0 {
+		return Document{}, errors.New("no text extracted from EPUB")
+	}
+	doc.Content = all.String()
+	return doc, nil
+}
+
+// readZipXML decodes an XML file from the archive into v.
+func readZipXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("EPUB is missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	dec := xml.NewDecoder(rc)
+	dec.Strict = false
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// collectNCXTitles fills titles from an EPUB 2 NCX table of contents.
+func collectNCXTitles(files map[string]*zip.File, ncxPath string, titles map[string]string) {
+	var ncx epubNCX
+	if err := readZipXML(files, ncxPath, &ncx); err != nil {
+		return
+	}
+	dir := path.Dir(ncxPath)
+	var walk func([]epubNavPoint)
+	walk = func(points []epubNavPoint) {
+		for _, np := range points {
+			target := path.Join(dir, stripFragment(np.Content.Src))
+			if _, seen := titles[target]; !seen && strings.TrimSpace(np.Label) != "" {
+				titles[target] = collapseSpace(np.Label)
+			}
+			walk(np.Children)
+		}
+	}
+	walk(ncx.NavPoints)
+}
+
+// collectNavTitles fills titles from an EPUB 3 navigation document.
+func collectNavTitles(files map[string]*zip.File, navPath string, titles map[string]string) {
+	f, ok := files[navPath]
+	if !ok {
+		return
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return
+	}
+	defer rc.Close()
+
+	root, err := html.Parse(rc)
+	if err != nil {
+		return
+	}
+	dir := path.Dir(navPath)
+
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			for _, attr := range n.Attr {
+				if attr.Key != "href" {
+					continue
+				}
+				target := path.Join(dir, stripFragment(attr.Val))
+				label := collapseSpace(nodeText(n))
+				if _, seen := titles[target]; !seen && label != "" {
+					titles[target] = label
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+}
+
+// stripFragment removes a "#fragment" suffix from an href.
+func stripFragment(href string) string {
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		return href[:i]
+	}
+	return href
+}
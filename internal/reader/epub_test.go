@@ -0,0 +1,75 @@
+package reader
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestEPUB(t *testing.T, files map[string]string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(p)
+	require.NoError(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return p
+}
+
+func TestLoadEPUB_ChaptersWithTitles(t *testing.T) {
+	p := writeTestEPUB(t, map[string]string{
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container><rootfiles><rootfile full-path="OEBPS/content.opf"/></rootfiles></container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package>
+  <metadata><dc:title>Field Guide</dc:title><dc:creator>A. Author</dc:creator></metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" properties="nav" media-type="application/xhtml+xml"/>
+    <item id="c1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="c1"/><itemref idref="c2"/></spine>
+</package>`,
+		"OEBPS/nav.xhtml": `<html><body><nav><ol>
+<li><a href="text/ch1.xhtml">Getting Started</a></li>
+</ol></nav></body></html>`,
+		"OEBPS/text/ch1.xhtml": `<html><head><title>ignored</title><style>p{}</style></head>
+<body><p>First paragraph.</p><p>Second paragraph.</p></body></html>`,
+		"OEBPS/text/ch2.xhtml": `<html><body><h1>Advanced Topics</h1><p>Deep dive.</p></body></html>`,
+	})
+
+	doc, err := LoadFile(p)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Field Guide", doc.Metadata["title"])
+	assert.Equal(t, "A. Author", doc.Metadata["author"])
+	require.Len(t, doc.Sections, 2)
+
+	assert.Equal(t, "Getting Started", doc.Sections[0].Title)
+	assert.Equal(t, "First paragraph.\n\nSecond paragraph.", doc.Sections[0].Content)
+	assert.Equal(t, "1", doc.Sections[0].Metadata["chapter"])
+
+	// Falls back to the first heading when the TOC has no entry
+	assert.Equal(t, "Advanced Topics", doc.Sections[1].Title)
+	assert.Equal(t, "Advanced Topics", doc.Sections[1].Metadata["chapter_title"])
+	assert.Contains(t, doc.Content, "Deep dive.")
+}
+
+func TestLoadEPUB_MissingContainer(t *testing.T) {
+	p := writeTestEPUB(t, map[string]string{"mimetype": "application/epub+zip"})
+
+	_, err := LoadFile(p)
+	require.Error(t, err)
+}
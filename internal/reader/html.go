@@ -0,0 +1,141 @@
+package reader
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlText holds the readable text and headline information extracted from
+// an HTML or XHTML document.
+type htmlText struct {
+	// Title is the <title> element text, if present
+	Title string
+	// Heading is the first <h1>-<h3> heading, if present
+	Heading string
+	// Text is the visible body text with block elements separated by blank lines
+	Text string
+}
+
+// extractHTMLText converts an HTML document into plain text. Scripts, styles,
+// and other non-content elements are dropped; block-level elements become
+// paragraph breaks so the sentence-aware chunker can split on them.
+func extractHTMLText(r io.Reader) (htmlText, error) {
+	root, err := html.Parse(r)
+	if err != nil {
+		return htmlText{}, fmt.Errorf("parse HTML: %w", err)
+	}
+
+	var out htmlText
+	var sb strings.Builder
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Script, atom.Style, atom.Noscript, atom.Template, atom.Svg, atom.Head:
+				if n.DataAtom == atom.Head {
+					if t := findFirst(n, atom.Title); t != nil {
+						out.Title = collapseSpace(nodeText(t))
+					}
+				}
+				return
+			case atom.H1, atom.H2, atom.H3:
+				if out.Heading == "" {
+					out.Heading = collapseSpace(nodeText(n))
+				}
+			case atom.Br:
+				sb.WriteString("\n")
+				return
+			}
+		}
+
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		if n.Type == html.ElementNode && isBlockElement(n.DataAtom) {
+			sb.WriteString("\n\n")
+		}
+	}
+	walk(root)
+
+	out.Text = normaliseParagraphs(sb.String())
+	return out, nil
+}
+
+// isBlockElement reports whether an element should be rendered as its own paragraph.
+func isBlockElement(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer,
+		atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+		atom.Li, atom.Ul, atom.Ol, atom.Blockquote, atom.Pre,
+		atom.Table, atom.Tr, atom.Dt, atom.Dd, atom.Figcaption:
+		return true
+	}
+	return false
+}
+
+// findFirst returns the first descendant element with the given atom.
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			return c
+		}
+		if found := findFirst(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// nodeText concatenates all text beneath a node.
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// collapseSpace collapses all runs of whitespace into single spaces.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// normaliseParagraphs collapses whitespace within lines and limits blank
+// lines between paragraphs to one.
+func normaliseParagraphs(s string) string {
+	lines := strings.Split(s, "\n")
+	var paras []string
+	var current []string
+	for _, line := range lines {
+		line = collapseSpace(line)
+		if line == "" {
+			if len(current) > 0 {
+				paras = append(paras, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		paras = append(paras, strings.Join(current, "\n"))
+	}
+	return strings.Join(paras, "\n\n")
+}
@@ -0,0 +1,127 @@
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func init() {
+	// Keep pdfcpu from creating a config directory under the user's home
+	model.ConfigPath = "disable"
+}
+
+// OCR extracts printed text from an image file.
+type OCR interface {
+	Recognize(path string) (string, error)
+}
+
+// loadImage runs OCR over a .png or .jpg file.
+func loadImage(path string, ocr OCR) (Document, error) {
+	if ocr == nil {
+		return Document{}, fmt.Errorf("%w: %s (no OCR engine configured)", ErrUnsupportedFormat, filepath.Ext(path))
+	}
+	text, err := ocr.Recognize(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("OCR %q: %w", path, err)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Document{}, fmt.Errorf("no text recognized in %q", path)
+	}
+	return Document{
+		Path:     path,
+		Name:     filepath.Base(path),
+		Content:  text,
+		Metadata: map[string]string{"media": "image", "ocr": "true"},
+	}, nil
+}
+
+// ocrPDFImages recognizes text in the images embedded on the given pages
+// of a PDF (all pages when pageNrs is empty) and returns one section per
+// page that yielded text.
+func ocrPDFImages(path string, ocr OCR, pageNrs []int) ([]Section, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open PDF: %w", err)
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.Cmd = model.EXTRACTIMAGES
+	ctx, err := api.ReadValidateAndOptimize(f, conf)
+	if err != nil {
+		return nil, fmt.Errorf("read PDF: %w", err)
+	}
+	if len(pageNrs) == 0 {
+		for i := 1; i <= ctx.PageCount; i++ {
+			pageNrs = append(pageNrs, i)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "kash-ocr-")
+	if err != nil {
+		return nil, fmt.Errorf("create OCR temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var sections []Section
+	for _, pageNr := range pageNrs {
+		images, err := pdfcpu.ExtractPageImages(ctx, pageNr, false)
+		if err != nil {
+			return nil, fmt.Errorf("extract images from page %d: %w", pageNr, err)
+		}
+		objNrs := make([]int, 0, len(images))
+		for nr := range images {
+			objNrs = append(objNrs, nr)
+		}
+		sort.Ints(objNrs)
+
+		var texts []string
+		for _, nr := range objNrs {
+			img := images[nr]
+			if img.Thumb || img.IsImgMask {
+				continue
+			}
+			imgPath := filepath.Join(tmpDir, fmt.Sprintf("p%d-%d.%s", pageNr, nr, img.FileType))
+			if err := writeImage(imgPath, img); err != nil {
+				return nil, err
+			}
+			text, err := ocr.Recognize(imgPath)
+			if err != nil {
+				return nil, fmt.Errorf("OCR page %d: %w", pageNr, err)
+			}
+			if text = strings.TrimSpace(text); text != "" {
+				texts = append(texts, text)
+			}
+		}
+		if len(texts) == 0 {
+			continue
+		}
+		sections = append(sections, Section{
+			Title:    fmt.Sprintf("Page %d", pageNr),
+			Content:  strings.Join(texts, "\n\n"),
+			Metadata: map[string]string{"page": strconv.Itoa(pageNr), "ocr": "true"},
+		})
+	}
+	return sections, nil
+}
+
+func writeImage(path string, img model.Image) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("write page image: %w", err)
+	}
+	defer out.Close()
+	if _, err := out.ReadFrom(img); err != nil {
+		return fmt.Errorf("write page image: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package reader
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOCR string
+
+func (f fakeOCR) Recognize(string) (string, error) {
+	return string(f), nil
+}
+
+func writePNG(t *testing.T, path string) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	img.Set(1, 1, color.Black)
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, png.Encode(f, img))
+}
+
+func TestLoadImage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.png")
+	writePNG(t, path)
+
+	_, err := NewReader(DefaultOptions()).LoadFile(path)
+	assert.True(t, errors.Is(err, ErrUnsupportedFormat))
+
+	opts := DefaultOptions()
+	opts.OCR = fakeOCR("  Step 1: unplug the device.\n")
+	doc, err := NewReader(opts).LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Step 1: unplug the device.", doc.Content)
+	assert.Equal(t, "image", doc.Metadata["media"])
+}
+
+func TestLoadPDF_ScannedFallsBackToOCR(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "page.png")
+	pdfPath := filepath.Join(dir, "scan.pdf")
+	writePNG(t, imgPath)
+	require.NoError(t, api.ImportImagesFile([]string{imgPath, imgPath}, pdfPath, nil, nil))
+
+	_, err := NewReader(DefaultOptions()).LoadFile(pdfPath)
+	require.Error(t, err)
+
+	opts := DefaultOptions()
+	opts.OCR = fakeOCR("Scanned text")
+	doc, err := NewReader(opts).LoadFile(pdfPath)
+	require.NoError(t, err)
+	require.Len(t, doc.Sections, 2)
+	assert.Equal(t, "2", doc.Sections[1].Metadata["page"])
+	assert.Equal(t, "true", doc.Metadata["ocr"])
+}
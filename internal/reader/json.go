@@ -0,0 +1,322 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONOptions controls how .json and .jsonl files are flattened into sections.
+type JSONOptions struct {
+	// RecordsPath is a dotted path to the array of records inside a .json
+	// file (e.g. "data.items"). Empty means the document root: an array root
+	// yields one record per element, an object root is a single record.
+	RecordsPath string
+	// Fields limits output to these dotted paths (and their children).
+	// Array indices are ignored when matching, so "items.name" selects
+	// "items[0].name", "items[1].name", ...
+	Fields []string
+	// Exclude drops these dotted paths (and their children)
+	Exclude []string
+	// IDField names the path whose value identifies a record
+	IDField string
+	// RecordsPerChunk is the number of records grouped into one section (default: 1)
+	RecordsPerChunk int
+}
+
+// loadJSON reads a .json document and flattens its records.
+func loadJSON(path string, opts JSONOptions) (Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("read file %q: %w", path, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var root interface{}
+	if err := dec.Decode(&root); err != nil {
+		return Document{}, fmt.Errorf("parse JSON: %w", err)
+	}
+
+	node := root
+	if opts.RecordsPath != "" {
+		for _, key := range strings.Split(opts.RecordsPath, ".") {
+			obj, ok := node.(map[string]interface{})
+			if !ok {
+				return Document{}, fmt.Errorf("records path %q: %q is not an object", opts.RecordsPath, key)
+			}
+			node, ok = obj[key]
+			if !ok {
+				return Document{}, fmt.Errorf("records path %q: key %q not found", opts.RecordsPath, key)
+			}
+		}
+	}
+
+	var records []interface{}
+	if arr, ok := node.([]interface{}); ok {
+		records = arr
+	} else {
+		records = []interface{}{node}
+	}
+	return buildJSONDocument(path, records, opts), nil
+}
+
+// loadJSONL reads a newline-delimited JSON file, one record per line.
+func loadJSONL(path string, opts JSONOptions) (Document, error) {
+	var records []interface{}
+	err := scanJSONL(path, func(rec interface{}) error {
+		records = append(records, rec)
+		return nil
+	})
+	if err != nil {
+		return Document{}, err
+	}
+	return buildJSONDocument(path, records, opts), nil
+}
+
+// scanJSONL passes the records of a newline-delimited JSON file to fn one
+// line at a time.
+func scanJSONL(path string, fn func(rec interface{}) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open file %q: %w", path, err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	lineNum := 0
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			lineNum++
+			line = bytes.TrimSpace(line)
+			if len(line) > 0 {
+				dec := json.NewDecoder(bytes.NewReader(line))
+				dec.UseNumber()
+				var rec interface{}
+				if decErr := dec.Decode(&rec); decErr != nil {
+					return fmt.Errorf("parse line %d: %w", lineNum, decErr)
+				}
+				if fnErr := fn(rec); fnErr != nil {
+					return fnErr
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read line %d: %w", lineNum+1, err)
+		}
+	}
+}
+
+// buildJSONDocument groups flattened records into sections.
+func buildJSONDocument(path string, records []interface{}, opts JSONOptions) Document {
+	doc := Document{Path: path, Name: filepath.Base(path)}
+	g := &jsonSections{opts: opts}
+	for _, rec := range records {
+		if sec, ok := g.add(rec); ok {
+			doc.Sections = append(doc.Sections, sec)
+		}
+	}
+	if sec, ok := g.flush(); ok {
+		doc.Sections = append(doc.Sections, sec)
+	}
+
+	parts := make([]string, len(doc.Sections))
+	for i, sec := range doc.Sections {
+		parts[i] = sec.Content
+	}
+	doc.Content = strings.Join(parts, "\n\n")
+	return doc
+}
+
+// jsonSections flattens records one at a time and groups them into sections
+// of RecordsPerChunk records, tagged with the record numbers they hold.
+type jsonSections struct {
+	opts JSONOptions
+	// n is the number of records added so far; first and last number the
+	// first and last record in group
+	n           int
+	first, last int
+	group       []string
+	groupIDs    []string
+}
+
+// add flattens the next record and returns the section it completes, if
+// any.
+func (g *jsonSections) add(rec interface{}) (Section, bool) {
+	g.n++
+	pairs := flattenJSON("", rec, nil)
+	var lines []string
+	var id string
+	for _, p := range pairs {
+		if g.opts.IDField != "" && stripIndices(p.path) == g.opts.IDField && id == "" {
+			id = p.value
+		}
+		if !selectJSONPath(p.path, g.opts.Fields, g.opts.Exclude) {
+			continue
+		}
+		lines = append(lines, p.path+": "+p.value)
+	}
+	if len(lines) == 0 {
+		return Section{}, false
+	}
+	if len(g.group) == 0 {
+		g.first = g.n
+	}
+	g.last = g.n
+	g.group = append(g.group, strings.Join(lines, "\n"))
+	if id != "" {
+		g.groupIDs = append(g.groupIDs, id)
+	}
+	if len(g.group) < max(g.opts.RecordsPerChunk, 1) {
+		return Section{}, false
+	}
+	return g.flush()
+}
+
+// flush returns the section of the records grouped so far, if any. Its
+// record_end is the last record it holds, not a later one without selected
+// fields.
+func (g *jsonSections) flush() (Section, bool) {
+	if len(g.group) == 0 {
+		return Section{}, false
+	}
+	meta := map[string]string{
+		"record_start": strconv.Itoa(g.first),
+		"record_end":   strconv.Itoa(g.last),
+	}
+	if len(g.groupIDs) > 0 {
+		meta["record_ids"] = strings.Join(g.groupIDs, ",")
+	}
+	sec := Section{
+		Content:  strings.Join(g.group, "\n\n"),
+		Metadata: meta,
+	}
+	g.group = nil
+	g.groupIDs = nil
+	return sec, true
+}
+
+// jsonPair is a flattened leaf value with its dotted path.
+type jsonPair struct {
+	path  string
+	value string
+}
+
+// flattenJSON walks a decoded JSON value and returns its leaves as dotted
+// paths. Arrays of scalars are joined into a single comma-separated value;
+// arrays of objects are indexed ("items[0].name").
+func flattenJSON(prefix string, v interface{}, out []jsonPair) []jsonPair {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out = flattenJSON(joinJSONPath(prefix, k), val[k], out)
+		}
+	case []interface{}:
+		if scalars, ok := scalarList(val); ok {
+			if len(scalars) > 0 {
+				out = append(out, jsonPair{path: prefix, value: strings.Join(scalars, ", ")})
+			}
+			return out
+		}
+		for i, item := range val {
+			out = flattenJSON(prefix+"["+strconv.Itoa(i)+"]", item, out)
+		}
+	case nil:
+		// Skip nulls
+	default:
+		s := strings.TrimSpace(fmt.Sprint(val))
+		if s != "" {
+			if prefix == "" {
+				prefix = "value"
+			}
+			out = append(out, jsonPair{path: prefix, value: s})
+		}
+	}
+	return out
+}
+
+// scalarList returns the string form of every element if all are scalars.
+func scalarList(arr []interface{}) ([]string, bool) {
+	out := make([]string, 0, len(arr))
+	for _, item := range arr {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, false
+		case nil:
+			continue
+		default:
+			if s := strings.TrimSpace(fmt.Sprint(item)); s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+	return out, true
+}
+
+func joinJSONPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// stripIndices removes array indices from a path: "items[0].name" → "items.name".
+func stripIndices(p string) string {
+	if !strings.Contains(p, "[") {
+		return p
+	}
+	var sb strings.Builder
+	skip := false
+	for _, r := range p {
+		switch {
+		case r == '[':
+			skip = true
+		case r == ']':
+			skip = false
+		case !skip:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// selectJSONPath reports whether a path passes the include/exclude filters.
+func selectJSONPath(p string, include, exclude []string) bool {
+	p = stripIndices(p)
+	for _, ex := range exclude {
+		if pathHasPrefix(p, ex) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, in := range include {
+		if pathHasPrefix(p, in) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathHasPrefix reports whether p equals prefix or is nested beneath it.
+func pathHasPrefix(p, prefix string) bool {
+	return p == prefix || strings.HasPrefix(p, prefix+".")
+}
@@ -0,0 +1,98 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadJSON(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "tickets.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{
+  "data": {"items": [
+    {"id": 7, "title": "Login fails", "tags": ["auth", "web"], "author": {"name": "Ada", "email": "ada@example.com"}},
+    {"id": 8, "title": "Slow search", "comments": [{"body": "repro'd"}]}
+  ]}
+}`), 0644))
+	jsonlPath := filepath.Join(dir, "events.jsonl")
+	require.NoError(t, os.WriteFile(jsonlPath, []byte("{\"event\":\"signup\"}\n\n{\"event\":\"login\",\"user\":null}\n"), 0644))
+
+	tests := []struct {
+		name         string
+		path         string
+		opts         JSONOptions
+		wantSections []string
+		wantIDs      []string
+		wantErr      bool
+	}{
+		{
+			name: "records path with full flattening",
+			path: jsonPath,
+			opts: JSONOptions{RecordsPath: "data.items", IDField: "id"},
+			wantSections: []string{
+				"author.email: ada@example.com\nauthor.name: Ada\nid: 7\ntags: auth, web\ntitle: Login fails",
+				"comments[0].body: repro'd\nid: 8\ntitle: Slow search",
+			},
+			wantIDs: []string{"7", "8"},
+		},
+		{
+			name: "field selection and exclusion",
+			path: jsonPath,
+			opts: JSONOptions{RecordsPath: "data.items", Fields: []string{"title", "author", "comments.body"}, Exclude: []string{"author.email"}},
+			wantSections: []string{
+				"author.name: Ada\ntitle: Login fails",
+				"comments[0].body: repro'd\ntitle: Slow search",
+			},
+		},
+		{
+			name:         "jsonl grouped records",
+			path:         jsonlPath,
+			opts:         JSONOptions{RecordsPerChunk: 5},
+			wantSections: []string{"event: signup\n\nevent: login"},
+		},
+		{
+			name:    "missing records path",
+			path:    jsonPath,
+			opts:    JSONOptions{RecordsPath: "data.nope"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewReader(Options{JSON: tt.opts}).LoadFile(tt.path)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			got := make([]string, len(doc.Sections))
+			var ids []string
+			for i, sec := range doc.Sections {
+				got[i] = sec.Content
+				if id := sec.Metadata["record_ids"]; id != "" {
+					ids = append(ids, id)
+				}
+			}
+			assert.Equal(t, tt.wantSections, got)
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestLoadJSONLastRecordWithoutFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{\"event\":\"signup\"}\n{\"event\":\"login\"}\n{\"user\":\"ada\"}\n"), 0644))
+
+	doc, err := NewReader(Options{JSON: JSONOptions{Fields: []string{"event"}, RecordsPerChunk: 5}}).LoadFile(path)
+	require.NoError(t, err)
+	require.Len(t, doc.Sections, 1, "the records before one without selected fields are kept")
+	assert.Equal(t, "event: signup\n\nevent: login", doc.Sections[0].Content)
+	assert.Equal(t, "1", doc.Sections[0].Metadata["record_start"])
+	assert.Equal(t, "2", doc.Sections[0].Metadata["record_end"], "the last record the section holds")
+}
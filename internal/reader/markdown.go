@@ -0,0 +1,87 @@
+package reader
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadMarkdown reads a Markdown file, lifting any YAML frontmatter into
+// document metadata so it is not embedded as body text.
+func loadMarkdown(path string) (Document, error) {
+	doc, err := loadTextFile(path)
+	if err != nil {
+		return Document{}, err
+	}
+	meta, body := parseFrontmatter(doc.Content)
+	doc.Content = body
+	if len(meta) > 0 {
+		doc.Metadata = meta
+	}
+	return doc, nil
+}
+
+// parseFrontmatter splits a leading "---" delimited YAML block from text.
+// Scalar values are stored as strings, lists are joined with ", ", and
+// nested maps are ignored. Text without a valid frontmatter block, such as a
+// document that merely opens with a thematic break, is returned unchanged.
+func parseFrontmatter(text string) (map[string]string, string) {
+	normalized := strings.ReplaceAll(strings.TrimPrefix(text, "\ufeff"), "\r\n", "\n")
+	lines := strings.Split(normalized, "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[0]) != "---" {
+		return nil, text
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if l := strings.TrimSpace(lines[i]); l == "---" || l == "..." {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return nil, text
+	}
+	block := strings.Join(lines[1:end], "\n")
+	body := strings.Join(lines[end+1:], "\n")
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(block), &raw); err != nil {
+		return nil, text
+	}
+	meta := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := metadataValue(v); ok && s != "" {
+			meta[strings.ToLower(k)] = s
+		}
+	}
+	return meta, strings.TrimLeft(body, "\n")
+}
+
+// metadataValue renders a YAML value as a metadata string.
+func metadataValue(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return strings.TrimSpace(val), true
+	case time.Time:
+		if val.Hour() == 0 && val.Minute() == 0 && val.Second() == 0 {
+			return val.Format("2006-01-02"), true
+		}
+		return val.Format(time.RFC3339), true
+	case []interface{}:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := metadataValue(item); ok && s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", "), true
+	case map[string]interface{}:
+		return "", false
+	default:
+		return fmt.Sprint(val), true
+	}
+}
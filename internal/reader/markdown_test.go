@@ -0,0 +1,75 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrontmatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantMeta map[string]string
+		wantBody string
+	}{
+		{
+			name: "scalars lists and dates",
+			text: "---\ntitle: Billing FAQ\ntags: [billing, refunds]\ndate: 2024-03-01\naudience: support\nweight: 3\n---\n\n# Billing\n",
+			wantMeta: map[string]string{
+				"title":    "Billing FAQ",
+				"tags":     "billing, refunds",
+				"date":     "2024-03-01",
+				"audience": "support",
+				"weight":   "3",
+			},
+			wantBody: "# Billing\n",
+		},
+		{
+			name:     "nested maps are dropped",
+			text:     "---\nTitle: Setup\nauthor:\n  name: Ana\n---\nBody",
+			wantMeta: map[string]string{"title": "Setup"},
+			wantBody: "Body",
+		},
+		{
+			name:     "no frontmatter",
+			text:     "# Heading\n\nBody",
+			wantBody: "# Heading\n\nBody",
+		},
+		{
+			name:     "leading thematic break is not frontmatter",
+			text:     "---\nJust prose: and more: colons\n---\nBody",
+			wantBody: "---\nJust prose: and more: colons\n---\nBody",
+		},
+		{
+			name:     "unterminated block",
+			text:     "---\ntitle: Draft\n",
+			wantBody: "---\ntitle: Draft\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body := parseFrontmatter(tt.text)
+			if tt.wantMeta == nil {
+				assert.Empty(t, meta)
+			} else {
+				assert.Equal(t, tt.wantMeta, meta)
+			}
+			assert.Equal(t, tt.wantBody, body)
+		})
+	}
+}
+
+func TestLoadMarkdown_Frontmatter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faq.md")
+	require.NoError(t, os.WriteFile(path, []byte("---\ntitle: FAQ\ntags:\n  - billing\n---\nHow do refunds work?\n"), 0o644))
+
+	doc, err := NewReader(DefaultOptions()).LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "How do refunds work?\n", doc.Content)
+	assert.Equal(t, "FAQ", doc.Metadata["title"])
+	assert.Equal(t, "billing", doc.Metadata["tags"])
+}
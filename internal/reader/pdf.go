@@ -1,48 +1,160 @@
 package reader
 
 import (
-	"bytes"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/ledongthuc/pdf"
 )
 
-// extractPDFText extracts plain text from a PDF file.
-// It uses ledongthuc/pdf which decodes font-encoded glyphs into valid UTF-8.
-func extractPDFText(path string) (string, error) {
+// pdfPage is the text extracted from a single PDF page.
+type pdfPage struct {
+	// Number is the 1-based page number
+	Number int
+	Text   string
+}
+
+// extractPDFPages extracts text from each page of a PDF file. Lines are
+// rebuilt from glyph positions so word spacing and top-to-bottom reading
+// order survive content streams that draw text out of order. Pages without
+// a text layer are returned with empty Text.
+func extractPDFPages(path string) ([]pdfPage, error) {
 	f, r, err := pdf.Open(path)
 	if err != nil {
-		return "", fmt.Errorf("open PDF: %w", err)
+		return nil, fmt.Errorf("open PDF: %w", err)
 	}
 	defer f.Close()
 
-	reader, err := r.GetPlainText()
+	fonts := map[string]*pdf.Font{}
+	pages := make([]pdfPage, 0, r.NumPage())
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		for _, name := range p.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := p.Font(name)
+				fonts[name] = &font
+			}
+		}
+		pages = append(pages, pdfPage{Number: i, Text: pageText(p, fonts)})
+	}
+	return pages, nil
+}
+
+// pageText returns the layout-ordered text of a page, falling back to the
+// content-stream order when positional extraction yields nothing.
+func pageText(p pdf.Page, fonts map[string]*pdf.Font) string {
+	text := strings.TrimSpace(sanitizeUTF8(positionalText(p)))
+	if text != "" {
+		return text
+	}
+	plain, err := p.GetPlainText(fonts)
 	if err != nil {
-		return "", fmt.Errorf("extract PDF text: %w", err)
+		return ""
 	}
+	return strings.TrimSpace(sanitizeUTF8(plain))
+}
 
-	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(reader); err != nil {
-		return "", fmt.Errorf("read PDF text: %w", err)
+// positionalText lays out the glyphs of a page. The PDF library panics on
+// some malformed content streams, so those pages yield no text here.
+func positionalText(p pdf.Page) (text string) {
+	defer func() {
+		if recover() != nil {
+			text = ""
+		}
+	}()
+	return layoutText(p.Content().Text)
+}
+
+// layoutText groups positioned glyphs into lines by baseline, orders each
+// line left to right, and inserts spaces where glyphs are visibly apart.
+// A blank line is emitted where the vertical gap suggests a new paragraph.
+func layoutText(glyphs []pdf.Text) string {
+	glyphs = append([]pdf.Text(nil), glyphs...)
+	sort.SliceStable(glyphs, func(i, j int) bool { return glyphs[i].Y > glyphs[j].Y })
+
+	var lines [][]pdf.Text
+	for _, g := range glyphs {
+		if g.S == "" {
+			continue
+		}
+		if n := len(lines); n > 0 {
+			head := lines[n-1][0]
+			if math.Abs(head.Y-g.Y) <= baselineTolerance(head, g) {
+				lines[n-1] = append(lines[n-1], g)
+				continue
+			}
+		}
+		lines = append(lines, []pdf.Text{g})
 	}
 
-	text := buf.String()
-	if text == "" {
-		return "", fmt.Errorf("no text extracted from PDF")
+	var sb strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			prev := lines[i-1][0]
+			sb.WriteString("\n")
+			if prev.Y-line[0].Y > 1.8*glyphSize(prev) {
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString(layoutLine(line))
 	}
+	return sb.String()
+}
+
+// layoutLine renders the glyphs of one line in left-to-right order.
+func layoutLine(line []pdf.Text) string {
+	sort.SliceStable(line, func(i, j int) bool { return line[i].X < line[j].X })
 
-	// Sanitize: replace any remaining invalid UTF-8 sequences with the
-	// Unicode replacement character so downstream processing never fails.
-	if !utf8.ValidString(text) {
-		var sb strings.Builder
-		sb.Grow(len(text))
-		for _, r := range text {
-			sb.WriteRune(r)
+	var sb strings.Builder
+	end := math.Inf(-1)
+	lastSpace := true
+	for _, g := range line {
+		space := strings.TrimSpace(g.S) == ""
+		if !space && !lastSpace && g.X-end > 0.2*glyphSize(g) {
+			sb.WriteByte(' ')
 		}
-		text = sb.String()
+		if space {
+			if !lastSpace {
+				sb.WriteByte(' ')
+			}
+		} else {
+			sb.WriteString(g.S)
+		}
+		lastSpace = space
+		w := g.W
+		if w <= 0 {
+			// Fonts without width tables report zero; assume an average glyph
+			w = 0.5 * glyphSize(g) * float64(utf8.RuneCountInString(g.S))
+		}
+		end = g.X + w
 	}
+	return strings.TrimRight(sb.String(), " ")
+}
 
-	return text, nil
+// baselineTolerance is how far apart two glyph baselines may be while still
+// belonging to the same line, allowing for sub- and superscripts.
+func baselineTolerance(a, b pdf.Text) float64 {
+	return 0.5 * math.Min(glyphSize(a), glyphSize(b))
+}
+
+func glyphSize(g pdf.Text) float64 {
+	if g.FontSize <= 0 {
+		return 10
+	}
+	return g.FontSize
+}
+
+// sanitizeUTF8 replaces invalid UTF-8 sequences with the Unicode replacement
+// character so downstream processing never fails.
+func sanitizeUTF8(text string) string {
+	if utf8.ValidString(text) {
+		return text
+	}
+	return strings.ToValidUTF8(text, "�")
 }
@@ -0,0 +1,125 @@
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayoutText(t *testing.T) {
+	glyphs := func(s string, x, y, size float64) []pdf.Text {
+		var out []pdf.Text
+		for _, r := range s {
+			out = append(out, pdf.Text{S: string(r), X: x, Y: y, W: size / 2, FontSize: size})
+			x += size / 2
+		}
+		return out
+	}
+	concat := func(parts ...[]pdf.Text) []pdf.Text {
+		var out []pdf.Text
+		for _, p := range parts {
+			out = append(out, p...)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		glyphs []pdf.Text
+		want   string
+	}{
+		{
+			name:   "words separated by position",
+			glyphs: concat(glyphs("Hello", 72, 700, 10), glyphs("world", 110, 700, 10)),
+			want:   "Hello world",
+		},
+		{
+			name:   "lines drawn bottom first",
+			glyphs: concat(glyphs("second", 72, 688, 10), glyphs("first", 72, 700, 10)),
+			want:   "first\nsecond",
+		},
+		{
+			name:   "paragraph gap",
+			glyphs: concat(glyphs("one", 72, 700, 10), glyphs("two", 72, 650, 10)),
+			want:   "one\n\ntwo",
+		},
+		{
+			name:   "explicit spaces are not doubled",
+			glyphs: glyphs("a  b", 72, 700, 10),
+			want:   "a b",
+		},
+		{
+			name:   "subscript stays on its line",
+			glyphs: concat(glyphs("H", 72, 700, 10), glyphs("2", 77, 698, 6), glyphs("O", 80, 700, 10)),
+			want:   "H2O",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, layoutText(tt.glyphs))
+		})
+	}
+}
+
+func TestLoadPDF_PageSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "guide.pdf")
+	writeTextPDF(t, path, []string{
+		"BT /F1 12 Tf 72 700 Td (Chapter one) Tj ET",
+		"",
+		"BT /F1 12 Tf 72 680 Td (after) Tj ET BT /F1 12 Tf 72 700 Td (Lines drawn out of order) Tj ET",
+	})
+
+	doc, err := NewReader(DefaultOptions()).LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "3", doc.Metadata["pages"])
+	require.Len(t, doc.Sections, 2)
+	assert.Equal(t, "1", doc.Sections[0].Metadata["page"])
+	assert.Equal(t, "Chapter one", doc.Sections[0].Content)
+	assert.Equal(t, "3", doc.Sections[1].Metadata["page"])
+	assert.Equal(t, "Lines drawn out of order\nafter", doc.Sections[1].Content)
+	assert.Empty(t, doc.Metadata["ocr"])
+}
+
+// writeTextPDF writes a minimal PDF with one page per content stream, using
+// the standard Helvetica font.
+func writeTextPDF(t *testing.T, path string, contents []string) {
+	t.Helper()
+	n := len(contents)
+	// Objects: 1 catalog, 2 pages, 3 font, then a page and content pair per page
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	kids := make([]string, n)
+	for i, c := range contents {
+		pageObj, contentObj := 4+2*i, 5+2*i
+		kids[i] = fmt.Sprintf("%d 0 R", pageObj)
+		objs = append(objs,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentObj),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(c)+1, c),
+		)
+	}
+	objs[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), n)
+
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0o644))
+}
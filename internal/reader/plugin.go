@@ -0,0 +1,202 @@
+package reader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormatReader extracts documents from a file format kash does not read
+// natively, such as an internal ticket export. Register one with Register to
+// make it available to every Reader, or pass it in Options.Plugins.
+type FormatReader interface {
+	// Extensions lists the file extensions the reader handles, e.g. ".tix"
+	Extensions() []string
+	// Read extracts the document at path. Path and Name default to path and
+	// its base name when left empty.
+	Read(path string) (Document, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]FormatReader{}
+)
+
+// Register makes r the reader for its extensions in every Reader, taking
+// precedence over the built-in readers. It is meant to be called from an init
+// function; a later registration for the same extension replaces an earlier one.
+func Register(r FormatReader) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, ext := range r.Extensions() {
+		registry[normalizeExt(ext)] = r
+	}
+}
+
+func registered(ext string) FormatReader {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[ext]
+}
+
+// normalizeExt lowercases ext and adds the leading dot when missing.
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// plugin returns the reader handling ext: one from Options.Plugins, then a
+// registered one. It returns nil when ext is left to the built-in readers.
+func (rd *Reader) plugin(ext string) FormatReader {
+	if r, ok := rd.plugins[ext]; ok {
+		return r
+	}
+	return registered(ext)
+}
+
+// Supports reports whether LoadDirectory ingests files with the extension of
+// path, counting plugins.
+func (rd *Reader) Supports(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return rd.plugin(ext) != nil || IsSupported(path)
+}
+
+// readPlugin reads path with a FormatReader and fills in what it left empty.
+func readPlugin(r FormatReader, path string) (Document, error) {
+	doc, err := r.Read(path)
+	if err != nil {
+		return Document{}, err
+	}
+	if doc.Path == "" {
+		doc.Path = path
+	}
+	if doc.Name == "" {
+		doc.Name = filepath.Base(path)
+	}
+	if strings.TrimSpace(doc.Content) == "" && len(doc.Sections) > 0 {
+		parts := make([]string, len(doc.Sections))
+		for i, sec := range doc.Sections {
+			parts[i] = sec.Content
+		}
+		doc.Content = strings.Join(parts, "\n\n")
+	}
+	if strings.TrimSpace(doc.Content) == "" {
+		return Document{}, fmt.Errorf("no text extracted from %q", path)
+	}
+	return doc, nil
+}
+
+// DefaultExecTimeout bounds one run of an ExecReader program.
+const DefaultExecTimeout = 2 * time.Minute
+
+// ExecReader is a FormatReader backed by an external program, so formats can
+// be added without writing Go. For each file the program is started with the
+// file's absolute path on stdin (followed by a newline) and in the KASH_FILE
+// environment variable. It writes the extracted text to stdout and exits 0;
+// with JSON set it writes an object instead:
+//
+//	{"content": "...", "metadata": {"title": "..."},
+//	 "sections": [{"title": "...", "content": "...", "metadata": {}}],
+//	 "triples": [{"subject": "...", "predicate": "...", "object": "..."}]}
+//
+// A non-zero exit fails the file, with the program's stderr in the error.
+type ExecReader struct {
+	// Exts are the file extensions the program handles
+	Exts []string
+	// Command is the program and its arguments
+	Command []string
+	// JSON reads stdout as a JSON document rather than plain text
+	JSON bool
+	// Dir is the program's working directory
+	Dir string
+	// Timeout bounds each run (default: DefaultExecTimeout)
+	Timeout time.Duration
+}
+
+// Extensions implements FormatReader.
+func (e *ExecReader) Extensions() []string {
+	return e.Exts
+}
+
+// execOutput is the JSON an ExecReader program writes.
+type execOutput struct {
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata"`
+	Sections []struct {
+		Title    string            `json:"title"`
+		Content  string            `json:"content"`
+		Metadata map[string]string `json:"metadata"`
+	} `json:"sections"`
+	Triples []struct {
+		Subject   string `json:"subject"`
+		Predicate string `json:"predicate"`
+		Object    string `json:"object"`
+	} `json:"triples"`
+}
+
+// Read implements FormatReader by running the program on path.
+func (e *ExecReader) Read(path string) (Document, error) {
+	if len(e.Command) == 0 {
+		return Document{}, errors.New("reader plugin has no command")
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("resolve %q: %w", path, err)
+	}
+	timeout := e.Timeout
+	if timeout <= 0 {
+		timeout = DefaultExecTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...)
+	cmd.Dir = e.Dir
+	cmd.Env = append(os.Environ(), "KASH_FILE="+abs)
+	cmd.Stdin = strings.NewReader(abs + "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return Document{}, fmt.Errorf("reader plugin %s timed out after %s", e.Command[0], timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return Document{}, fmt.Errorf("reader plugin %s: %w: %s", e.Command[0], err, msg)
+		}
+		return Document{}, fmt.Errorf("reader plugin %s: %w", e.Command[0], err)
+	}
+
+	doc := Document{Path: path, Name: filepath.Base(path)}
+	if !e.JSON {
+		doc.Content = stdout.String()
+		return doc, nil
+	}
+	var out execOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return Document{}, fmt.Errorf("reader plugin %s: parse output: %w", e.Command[0], err)
+	}
+	doc.Content = out.Content
+	doc.Metadata = out.Metadata
+	for _, sec := range out.Sections {
+		doc.Sections = append(doc.Sections, Section{Title: sec.Title, Content: sec.Content, Metadata: sec.Metadata})
+	}
+	for _, t := range out.Triples {
+		if t.Subject == "" || t.Predicate == "" || t.Object == "" {
+			continue
+		}
+		doc.Triples = append(doc.Triples, Triple{Subject: t.Subject, Predicate: t.Predicate, Object: t.Object})
+	}
+	return doc, nil
+}
@@ -0,0 +1,173 @@
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type upperReader struct{}
+
+func (upperReader) Extensions() []string { return []string{"shout"} }
+
+func (upperReader) Read(path string) (Document, error) {
+	return Document{Content: "LOUD", Metadata: map[string]string{"reader": "upper"}}, nil
+}
+
+// slowReader takes longer for earlier files and records how many files it
+// read at once.
+type slowReader struct {
+	mu           sync.Mutex
+	active, peak int
+}
+
+func (*slowReader) Extensions() []string { return []string{".slow"} }
+
+func (r *slowReader) Read(path string) (Document, error) {
+	r.mu.Lock()
+	r.active++
+	r.peak = max(r.peak, r.active)
+	r.mu.Unlock()
+
+	var n int
+	fmt.Sscanf(filepath.Base(path), "%d.slow", &n)
+	time.Sleep(time.Duration(8-n) * 10 * time.Millisecond)
+
+	r.mu.Lock()
+	r.active--
+	r.mu.Unlock()
+	return Document{Content: filepath.Base(path)}, nil
+}
+
+func writeScript(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("exec plugins are tested with shell scripts")
+	}
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755))
+	return path
+}
+
+func TestExecReader(t *testing.T) {
+	dir := t.TempDir()
+	ticket := filepath.Join(dir, "T-1.tix")
+	require.NoError(t, os.WriteFile(ticket, []byte("Printer jams on tray 2"), 0644))
+
+	tests := []struct {
+		name    string
+		script  string
+		json    bool
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "text output",
+			script: `read f; printf 'Ticket: '; cat "$f"`,
+			want:   "Ticket: Printer jams on tray 2",
+		},
+		{
+			name:   "json output",
+			script: `echo '{"metadata":{"title":"T-1"},"sections":[{"title":"Summary","content":"jams","metadata":{"status":"open"}}],"triples":[{"subject":"T-1","predicate":"affects","object":"tray 2"}]}'`,
+			json:   true,
+			want:   "jams",
+		},
+		{
+			name:    "failure",
+			script:  `echo 'license expired' >&2; exit 3`,
+			wantErr: "license expired",
+		},
+		{
+			name:    "no text",
+			script:  `true`,
+			wantErr: "no text extracted",
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := writeScript(t, dir, "read"+string(rune('a'+i))+".sh", tt.script)
+			rd := NewReader(Options{Plugins: []FormatReader{&ExecReader{Exts: []string{".tix"}, Command: []string{script}, JSON: tt.json}}})
+			doc, err := rd.LoadFile(ticket)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, doc.Content)
+			assert.Equal(t, "T-1.tix", doc.Name)
+			if tt.json {
+				assert.Equal(t, "T-1", doc.Metadata["title"])
+				require.Len(t, doc.Sections, 1)
+				assert.Equal(t, "open", doc.Sections[0].Metadata["status"])
+				assert.Equal(t, []Triple{{Subject: "T-1", Predicate: "affects", Object: "tray 2"}}, doc.Triples)
+			}
+		})
+	}
+}
+
+func TestLoadDirectory_Plugins(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.shout"), []byte("quiet"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.tix"), []byte("x"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.md"), []byte("# Notes"), 0644))
+	failing := writeScript(t, t.TempDir(), "fail.sh", "exit 1")
+
+	assert.False(t, IsSupported("a.shout"))
+	Register(upperReader{})
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, ".shout")
+		registryMu.Unlock()
+	})
+	assert.True(t, IsSupported("a.shout"))
+
+	var skipped []string
+	rd := NewReader(Options{
+		Plugins: []FormatReader{&ExecReader{Exts: []string{"TIX"}, Command: []string{failing}}},
+		OnSkip:  func(path, reason string) { skipped = append(skipped, filepath.Base(path)) },
+	})
+	assert.True(t, rd.Supports("b.tix"))
+	docs, err := rd.LoadDirectory(dir)
+	require.NoError(t, err, "a failing plugin skips the file")
+	require.Len(t, docs, 2)
+	assert.Equal(t, "LOUD", docs[0].Content)
+	assert.Equal(t, "upper", docs[0].Metadata["reader"])
+	assert.Equal(t, "c.md", docs[1].Name)
+	assert.Equal(t, []string{"b.tix"}, skipped)
+}
+
+func TestWalkDirectory_Parallel(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 8 {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.slow", i)), nil, 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "9.md"), []byte("# Last"), 0644))
+
+	slow := &slowReader{}
+	rd := NewReader(Options{Plugins: []FormatReader{slow}, Workers: 4})
+	var names []string
+	err := rd.WalkDirectory(dir, func(doc Document, took time.Duration) error {
+		names = append(names, doc.Name)
+		assert.Positive(t, took)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.slow", "1.slow", "2.slow", "3.slow", "4.slow", "5.slow", "6.slow", "7.slow", "9.md"}, names,
+		"documents arrive in directory order")
+	assert.Equal(t, 4, slow.peak, "files are read four at a time")
+
+	err = rd.WalkDirectory(dir, func(doc Document, _ time.Duration) error {
+		if doc.Name == "2.slow" {
+			return fmt.Errorf("stop")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "stop")
+}
@@ -1,16 +1,30 @@
 package reader
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 // ErrUnsupportedFormat is returned when a file format is not supported.
 var ErrUnsupportedFormat = errors.New("unsupported file format")
 
+// ErrBinaryContent is returned when a file of a text format holds binary
+// data, such as an archive saved as .txt.
+var ErrBinaryContent = errors.New("binary content")
+
+// ErrTooLarge is returned for files larger than Options.MaxFileSize.
+var ErrTooLarge = errors.New("file too large")
+
 // Document represents a loaded document.
 type Document struct {
 	// Path is the source file path
@@ -19,62 +33,422 @@ type Document struct {
 	Name string
 	// Content is the extracted text content
 	Content string
+	// Metadata holds document-level attributes (e.g. title, author)
+	Metadata map[string]string
+	// Sections optionally splits Content into logical parts such as book
+	// chapters. When empty, Content is treated as a single section.
+	Sections []Section
+	// Triples are structured facts taken directly from the source (e.g. CSV
+	// rows) that bypass LLM extraction
+	Triples []Triple
+	// Sidecar holds the metadata read from a <file>.meta.yaml sidecar. It is
+	// already merged into Metadata and is kept separately so it can also be
+	// recorded in the knowledge graph.
+	Sidecar map[string]string
+	// Streamed is set on files WalkDirectory leaves to StreamFile because
+	// they reach Options.StreamThreshold. Content and Sections are empty.
+	Streamed bool
 }
 
-// LoadDirectory reads all supported documents from a directory.
+// Triple is a Subject-Predicate-Object fact read verbatim from a document.
+type Triple struct {
+	Subject   string
+	Predicate string
+	Object    string
+}
+
+// Section is a logical part of a document (e.g. an EPUB chapter).
+type Section struct {
+	// Title is the human-readable section heading, if known
+	Title string
+	// Content is the section text
+	Content string
+	// Metadata holds section-level attributes that flow into chunk metadata
+	Metadata map[string]string
+}
+
+// Options configures format-specific reader behavior.
+type Options struct {
+	// CSV controls how .csv and .tsv files are turned into sections
+	CSV CSVOptions
+	// JSON controls how .json and .jsonl files are flattened into sections
+	JSON JSONOptions
+	// Triples controls how curated triple files are loaded (see
+	// IsTripleFile)
+	Triples TripleOptions
+	// SkipFiles lists base filenames that LoadDirectory ignores (e.g. source lists)
+	SkipFiles []string
+	// Transcriber converts .mp3, .wav, and .m4a files to text. When nil,
+	// audio files are skipped.
+	Transcriber Transcriber
+	// OCR reads text from .png and .jpg files and from scanned PDFs without
+	// a text layer. When nil, images are skipped.
+	OCR OCR
+	// OnSkip is told about every file LoadDirectory passes over and why.
+	// Without it, unreadable binary files are reported on stderr.
+	OnSkip func(path, reason string)
+	// Plugins read the formats they declare, taking precedence over
+	// registered and built-in readers
+	Plugins []FormatReader
+	// Workers is how many files LoadDirectory reads at once (default: the
+	// number of CPUs). Transcriber, OCR, and Plugins are called from that
+	// many goroutines.
+	Workers int
+	// StreamThreshold is the size in bytes from which WalkDirectory leaves
+	// .txt and .jsonl files to StreamFile instead of reading them. Zero
+	// reads every file whole.
+	StreamThreshold int64
+	// MaxFileSize is the size in bytes above which files are not read.
+	// Zero means no limit.
+	MaxFileSize int64
+}
+
+// DefaultOptions returns sensible defaults for reading documents.
+func DefaultOptions() Options {
+	return Options{
+		CSV:  CSVOptions{RowsPerChunk: 1},
+		JSON: JSONOptions{RecordsPerChunk: 1},
+	}
+}
+
+// Reader loads documents from disk using format-specific extractors.
+type Reader struct {
+	opts    Options
+	skip    map[string]bool
+	plugins map[string]FormatReader
+}
+
+// NewReader creates a new Reader with the given options.
+func NewReader(opts Options) *Reader {
+	if opts.CSV.RowsPerChunk <= 0 {
+		opts.CSV.RowsPerChunk = 1
+	}
+	skip := make(map[string]bool, len(opts.SkipFiles))
+	for _, name := range opts.SkipFiles {
+		skip[name] = true
+	}
+	plugins := make(map[string]FormatReader)
+	for _, p := range opts.Plugins {
+		for _, ext := range p.Extensions() {
+			plugins[normalizeExt(ext)] = p
+		}
+	}
+	return &Reader{opts: opts, skip: skip, plugins: plugins}
+}
+
+// textFormats are the extensions LoadDirectory reads as text; a parse error
+// in one of them aborts the load.
+var textFormats = map[string]bool{
+	".md": true, ".markdown": true, ".txt": true, ".csv": true, ".tsv": true,
+	".json": true, ".jsonl": true, ".html": true, ".htm": true,
+}
+
+// binaryFormats are the extensions LoadDirectory reads from binary files,
+// skipping any that fail to parse.
+var binaryFormats = map[string]bool{
+	".pdf": true, ".epub": true, ".mp3": true, ".wav": true, ".m4a": true,
+	".png": true, ".jpg": true, ".jpeg": true,
+}
+
+// IsSupported reports whether LoadDirectory ingests files with the extension
+// of path, counting registered readers but not Options.Plugins.
+func IsSupported(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return textFormats[ext] || binaryFormats[ext] || registered(ext) != nil
+}
+
+// LoadDirectory reads all supported documents from a directory using default options.
 func LoadDirectory(dir string) ([]Document, error) {
+	return NewReader(DefaultOptions()).LoadDirectory(dir)
+}
+
+// LoadFile reads a single document from the given path using default options.
+func LoadFile(path string) (Document, error) {
+	return NewReader(DefaultOptions()).LoadFile(path)
+}
+
+// LoadDirectory reads all supported documents from a directory.
+// Files that fail to parse in binary formats (PDF, EPUB, audio, images) or
+// with a plugin are skipped with a warning, as are files above
+// Options.MaxFileSize and text files holding binary data; other text format
+// errors abort the load.
+func (rd *Reader) LoadDirectory(dir string) ([]Document, error) {
+	var docs []Document
+	err := rd.WalkDirectory(dir, func(doc Document, _ time.Duration) error {
+		docs = append(docs, doc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// loadJob is one file WalkDirectory reads.
+type loadJob struct {
+	path string
+	// lenient skips the file when it fails to load instead of aborting
+	lenient bool
+	// stream leaves the content to StreamFile
+	stream bool
+	doc    Document
+	took   time.Duration
+	err    error
+	done   chan struct{}
+}
+
+// WalkDirectory reads the supported documents of a directory like
+// LoadDirectory, with Options.Workers files read at once, and passes each to
+// fn in directory order as soon as it and the files before it are read,
+// along with how long it took to read. An error from fn stops the walk and
+// is returned. fn and Options.OnSkip are never called concurrently.
+func (rd *Reader) WalkDirectory(dir string, fn func(doc Document, took time.Duration) error) error {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("read directory %q: %w", dir, err)
+		return fmt.Errorf("read directory %q: %w", dir, err)
 	}
 
-	var docs []Document
+	var jobs []*loadJob
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
 		}
-
 		path := filepath.Join(dir, entry.Name())
+		if rd.skip[entry.Name()] {
+			rd.skipped(path, "excluded")
+			continue
+		}
 		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		switch {
+		case rd.opts.StreamThreshold > 0 && rd.CanStream(path) && rd.large(entry):
+			jobs = append(jobs, &loadJob{path: path, stream: true, done: make(chan struct{})})
+		case rd.plugin(ext) == nil && textFormats[ext]:
+			jobs = append(jobs, &loadJob{path: path, done: make(chan struct{})})
+		case rd.plugin(ext) != nil || binaryFormats[ext]:
+			jobs = append(jobs, &loadJob{path: path, lenient: true, done: make(chan struct{})})
+		default:
+			// Skip unsupported formats silently
+			if !IsSidecar(path) {
+				rd.skipped(path, "unsupported format")
+			}
+		}
+	}
 
-		switch ext {
-		case ".md", ".txt", ".markdown":
-			doc, err := loadTextFile(path)
-			if err != nil {
-				return nil, fmt.Errorf("load text file %q: %w", path, err)
+	workers := rd.opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	// Documents read ahead of the one fn waits for are held in memory, so
+	// reading stays at most a few files ahead of fn
+	ahead := make(chan struct{}, 2*workers)
+	running := make(chan struct{}, workers)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for _, job := range jobs {
+			select {
+			case ahead <- struct{}{}:
+			case <-stop:
+				return
 			}
-			docs = append(docs, doc)
-
-		case ".pdf":
-			doc, err := loadPDF(path)
-			if err != nil {
-				// Log and skip PDFs that can't be read
-				fmt.Fprintf(os.Stderr, "warning: skipping PDF %q: %v\n", path, err)
-				continue
+			select {
+			case running <- struct{}{}:
+			case <-stop:
+				return
 			}
-			docs = append(docs, doc)
+			go func() {
+				defer func() { <-running }()
+				defer close(job.done)
+				start := time.Now()
+				if job.stream {
+					job.doc, job.err = rd.streamedDocument(job.path)
+				} else {
+					job.doc, job.err = rd.LoadFile(job.path)
+				}
+				job.took = time.Since(start)
+			}()
+		}
+	}()
 
-		default:
-			// Skip unsupported formats silently
+	for _, job := range jobs {
+		<-job.done
+		<-ahead
+		if job.err != nil {
+			if !job.lenient && !errors.Is(job.err, ErrBinaryContent) && !errors.Is(job.err, ErrTooLarge) {
+				return fmt.Errorf("load text file %q: %w", job.path, job.err)
+			}
+			// Log and skip binary documents that can't be read
+			if rd.opts.OnSkip == nil {
+				fmt.Fprintf(os.Stderr, "warning: skipping %q: %v\n", job.path, job.err)
+			}
+			rd.skipped(job.path, job.err.Error())
 			continue
 		}
+		if err := fn(job.doc, job.took); err != nil {
+			return err
+		}
 	}
-	return docs, nil
+	return nil
 }
 
-// LoadFile reads a single document from the given path.
-func LoadFile(path string) (Document, error) {
+// large reports whether entry reaches Options.StreamThreshold.
+func (rd *Reader) large(entry os.DirEntry) bool {
+	info, err := entry.Info()
+	return err == nil && info.Size() >= rd.opts.StreamThreshold
+}
+
+func (rd *Reader) skipped(path, reason string) {
+	if rd.opts.OnSkip != nil {
+		rd.opts.OnSkip(path, reason)
+	}
+}
+
+// LoadFile reads a single document from the given path, attaching metadata
+// from its <file>.meta.yaml sidecar when one exists. It refuses files above
+// Options.MaxFileSize with ErrTooLarge and text files that hold binary data
+// with ErrBinaryContent.
+func (rd *Reader) LoadFile(path string) (Document, error) {
+	if err := rd.check(path); err != nil {
+		return Document{}, err
+	}
+	sidecar, err := readSidecar(path)
+	if err != nil {
+		return Document{}, err
+	}
+	doc, err := rd.loadFile(path)
+	if err != nil {
+		return Document{}, err
+	}
+	applySidecar(&doc, sidecar)
+	return doc, nil
+}
+
+// check enforces Options.MaxFileSize and, for text formats, that the start
+// of the file looks like text.
+func (rd *Reader) check(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	if rd.opts.MaxFileSize > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("stat file %q: %w", path, err)
+		}
+		if info.Size() > rd.opts.MaxFileSize {
+			return fmt.Errorf("%w: %s, the limit is %s", ErrTooLarge, formatSize(info.Size()), formatSize(rd.opts.MaxFileSize))
+		}
+	}
+	if rd.plugin(ext) != nil || !textFormats[ext] {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open file %q: %w", path, err)
+	}
+	defer f.Close()
+	sample := make([]byte, 8192)
+	n, err := io.ReadFull(f, sample)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("read file %q: %w", path, err)
+	}
+	if looksBinary(sample[:n]) {
+		return fmt.Errorf("%w in a %s file", ErrBinaryContent, ext)
+	}
+	return nil
+}
+
+// looksBinary reports whether sample, the start of a file, is binary data
+// rather than text: it holds a NUL byte, or more than a tenth of its
+// characters are invalid UTF-8 or control characters.
+func looksBinary(sample []byte) bool {
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	bad, n := 0, 0
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		switch {
+		case r == utf8.RuneError && size == 1 && !utf8.FullRune(sample):
+			// A character cut off by the end of the sample
+			size = len(sample)
+		case r == utf8.RuneError && size == 1:
+			bad++
+		case r < 0x20 && !strings.ContainsRune("\t\n\v\f\r\x1b", r):
+			bad++
+		}
+		n++
+		sample = sample[size:]
+	}
+	return bad*10 > n
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
+
+func (rd *Reader) loadFile(path string) (Document, error) {
 	ext := strings.ToLower(filepath.Ext(path))
+	if p := rd.plugin(ext); p != nil {
+		return readPlugin(p, path)
+	}
+	if IsTripleFile(path) {
+		return loadTriples(path, rd.opts.Triples)
+	}
 	switch ext {
-	case ".md", ".txt", ".markdown":
+	case ".md", ".markdown":
+		return loadMarkdown(path)
+	case ".txt":
 		return loadTextFile(path)
+	case ".csv":
+		return loadCSV(path, ',', rd.opts.CSV)
+	case ".tsv":
+		return loadCSV(path, '\t', rd.opts.CSV)
+	case ".html", ".htm":
+		return loadHTMLFile(path)
+	case ".json":
+		return loadJSON(path, rd.opts.JSON)
+	case ".jsonl":
+		return loadJSONL(path, rd.opts.JSON)
 	case ".pdf":
-		return loadPDF(path)
+		return loadPDF(path, rd.opts.OCR)
+	case ".epub":
+		return loadEPUB(path)
+	case ".mp3", ".wav", ".m4a":
+		return loadAudio(path, rd.opts.Transcriber)
+	case ".png", ".jpg", ".jpeg":
+		return loadImage(path, rd.opts.OCR)
 	default:
 		return Document{}, fmt.Errorf("%w: %s", ErrUnsupportedFormat, ext)
 	}
 }
 
+// LoadPlainText reads any text file (e.g. source code) verbatim, rejecting
+// content that looks binary.
+func LoadPlainText(path string) (Document, error) {
+	sidecar, err := readSidecar(path)
+	if err != nil {
+		return Document{}, err
+	}
+	doc, err := loadTextFile(path)
+	if err != nil {
+		return Document{}, err
+	}
+	applySidecar(&doc, sidecar)
+	sample := doc.Content
+	if len(sample) > 8192 {
+		sample = sample[:8192]
+	}
+	if strings.ContainsRune(sample, 0) || !utf8.ValidString(doc.Content) {
+		return Document{}, fmt.Errorf("%w: %s looks like binary content", ErrUnsupportedFormat, filepath.Base(path))
+	}
+	return doc, nil
+}
+
 func loadTextFile(path string) (Document, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -87,16 +461,91 @@ func loadTextFile(path string) (Document, error) {
 	}, nil
 }
 
-func loadPDF(path string) (Document, error) {
-	// PDF extraction requires ledongthuc/pdfcpu or similar.
-	// We use a lightweight approach with pdfcpu's text extraction.
-	content, err := extractPDFText(path)
+func loadHTMLFile(path string) (Document, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return Document{}, fmt.Errorf("extract PDF text from %q: %w", path, err)
+		return Document{}, fmt.Errorf("open file %q: %w", path, err)
 	}
-	return Document{
+	defer f.Close()
+
+	extracted, err := extractHTMLText(f)
+	if err != nil {
+		return Document{}, err
+	}
+	doc := Document{
 		Path:    path,
 		Name:    filepath.Base(path),
-		Content: content,
-	}, nil
+		Content: extracted.Text,
+	}
+	if title := extracted.Title; title != "" {
+		doc.Metadata = map[string]string{"title": title}
+	}
+	return doc, nil
+}
+
+// loadPDF extracts a PDF page by page into one section per page, tagging
+// each with its page number. Pages without a text layer are recognized with
+// OCR when an engine is configured and skipped otherwise.
+func loadPDF(path string, ocr OCR) (Document, error) {
+	pages, err := extractPDFPages(path)
+	if err != nil && ocr == nil {
+		return Document{}, fmt.Errorf("extract PDF text from %q: %w", path, err)
+	}
+
+	var sections []Section
+	var blank []int
+	for _, page := range pages {
+		if page.Text == "" {
+			blank = append(blank, page.Number)
+			continue
+		}
+		sections = append(sections, Section{
+			Title:    fmt.Sprintf("Page %d", page.Number),
+			Content:  page.Text,
+			Metadata: map[string]string{"page": strconv.Itoa(page.Number)},
+		})
+	}
+
+	ocrUsed := false
+	// Scanned pages have no text layer; recognize their images instead. When
+	// the text layer could not be read at all, every page is OCRed.
+	if ocr != nil && (err != nil || len(blank) > 0) {
+		ocrSections, ocrErr := ocrPDFImages(path, ocr, blank)
+		if ocrErr != nil {
+			return Document{}, fmt.Errorf("OCR scanned PDF %q: %w", path, ocrErr)
+		}
+		ocrUsed = len(ocrSections) > 0
+		sections = append(sections, ocrSections...)
+		sort.SliceStable(sections, func(i, j int) bool {
+			a, _ := strconv.Atoi(sections[i].Metadata["page"])
+			b, _ := strconv.Atoi(sections[j].Metadata["page"])
+			return a < b
+		})
+	}
+
+	if len(sections) == 0 {
+		if ocr == nil {
+			return Document{}, fmt.Errorf("extract PDF text from %q: %w", path, errors.New("no text extracted from PDF"))
+		}
+		return Document{}, fmt.Errorf("no text extracted from PDF %q (text layer and OCR both empty)", path)
+	}
+
+	parts := make([]string, len(sections))
+	for i, sec := range sections {
+		parts[i] = sec.Content
+	}
+	doc := Document{
+		Path:     path,
+		Name:     filepath.Base(path),
+		Content:  strings.Join(parts, "\n\n"),
+		Metadata: map[string]string{},
+		Sections: sections,
+	}
+	if len(pages) > 0 {
+		doc.Metadata["pages"] = strconv.Itoa(len(pages))
+	}
+	if ocrUsed {
+		doc.Metadata["ocr"] = "true"
+	}
+	return doc, nil
 }
@@ -0,0 +1,63 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLooksBinary(t *testing.T) {
+	tests := []struct {
+		name   string
+		sample string
+		want   bool
+	}{
+		{"text", "Plain text\twith tabs\r\nand lines.\n", false},
+		{"utf-8", "Grüße, 你好, ☕", false},
+		{"ansi colors", "\x1b[32mok\x1b[0m build passed\n", false},
+		{"cut rune", "café"[:4], false},
+		{"empty", "", false},
+		{"nul byte", "text\x00more text", true},
+		{"zip", "PK\x03\x04\x14\x00\x08\x00", true},
+		{"latin-1 text", "The caf\xe9 serves cr\xe8me br\xfbl\xe9e every day of the week.", false},
+		{"random bytes", "\x89\xfe\x01\x02\xc3\x28\xa0\xa1\x80\x81", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, looksBinary([]byte(tt.sample)))
+		})
+	}
+}
+
+func TestLoadDirectory_SkipsBinaryAndLargeFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("notes.md", "# Notes\n")
+	write("export.txt", "PK\x03\x04\x00\x00binary")
+	write("huge.json", `{"text": "`+strings.Repeat("x", 2048)+`"}`)
+	write("backup.zip", "PK\x03\x04")
+
+	skipped := map[string]string{}
+	rd := NewReader(Options{
+		MaxFileSize: 1024,
+		OnSkip:      func(path, reason string) { skipped[filepath.Base(path)] = reason },
+	})
+	docs, err := rd.LoadDirectory(dir)
+	require.NoError(t, err, "binary and oversized text files are skipped, not fatal")
+	require.Len(t, docs, 1)
+	assert.Equal(t, "notes.md", docs[0].Name)
+	assert.Equal(t, map[string]string{
+		"export.txt": "binary content in a .txt file",
+		"huge.json":  "file too large: 2.0 KB, the limit is 1.0 KB",
+		"backup.zip": "unsupported format",
+	}, skipped)
+
+	_, err = rd.LoadFile(filepath.Join(dir, "export.txt"))
+	assert.ErrorIs(t, err, ErrBinaryContent)
+}
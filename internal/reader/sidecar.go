@@ -0,0 +1,60 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SidecarSuffix is appended to a document's file name to form the path of
+// its optional metadata sidecar, e.g. handbook.pdf.meta.yaml.
+const SidecarSuffix = ".meta.yaml"
+
+// IsSidecar reports whether path names a metadata sidecar rather than a
+// document.
+func IsSidecar(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), SidecarSuffix)
+}
+
+// readSidecar loads the metadata sidecar for the document at path. It
+// returns nil when no sidecar exists. Values are flattened the same way as
+// Markdown frontmatter.
+func readSidecar(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path + SidecarSuffix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read sidecar: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse sidecar %q: %w", path+SidecarSuffix, err)
+	}
+	meta := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := metadataValue(v); ok && s != "" {
+			meta[strings.ToLower(k)] = s
+		}
+	}
+	return meta, nil
+}
+
+// applySidecar merges sidecar metadata into doc. Sidecar values take
+// precedence over metadata extracted from the file itself.
+func applySidecar(doc *Document, sidecar map[string]string) {
+	if len(sidecar) == 0 {
+		return
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]string, len(sidecar))
+	}
+	for k, v := range sidecar {
+		doc.Metadata[k] = v
+	}
+	doc.Sidecar = sidecar
+}
@@ -0,0 +1,44 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile_Sidecar(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.md")
+	require.NoError(t, os.WriteFile(path, []byte("---\ntitle: Leave policy\nowner: ops\n---\nTwenty days.\n"), 0o644))
+	require.NoError(t, os.WriteFile(path+SidecarSuffix, []byte("owner: hr\nacl_group: staff\ntags: [hr, leave]\nexpiry: 2025-12-31\n"), 0o644))
+
+	doc, err := NewReader(DefaultOptions()).LoadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Leave policy", doc.Metadata["title"])
+	assert.Equal(t, "hr", doc.Metadata["owner"], "sidecar overrides frontmatter")
+	assert.Equal(t, "staff", doc.Metadata["acl_group"])
+	assert.Equal(t, "hr, leave", doc.Metadata["tags"])
+	assert.Equal(t, "2025-12-31", doc.Metadata["expiry"])
+	assert.Equal(t, map[string]string{"owner": "hr", "acl_group": "staff", "tags": "hr, leave", "expiry": "2025-12-31"}, doc.Sidecar)
+
+	opts := DefaultOptions()
+	skipped := map[string]string{}
+	opts.OnSkip = func(path, reason string) { skipped[filepath.Base(path)] = reason }
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "diagram.svg"), []byte("<svg/>"), 0o644))
+	docs, err := NewReader(opts).LoadDirectory(dir)
+	require.NoError(t, err)
+	require.Len(t, docs, 1, "sidecar files are not loaded as documents")
+	assert.Equal(t, map[string]string{"diagram.svg": "unsupported format"}, skipped, "sidecars are not reported as skipped")
+}
+
+func TestLoadFile_InvalidSidecar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0o644))
+	require.NoError(t, os.WriteFile(path+SidecarSuffix, []byte("owner: [unclosed"), 0o644))
+
+	_, err := NewReader(DefaultOptions()).LoadFile(path)
+	assert.ErrorContains(t, err, "parse sidecar")
+}
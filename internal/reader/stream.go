@@ -0,0 +1,130 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// streamSectionSize is about how much text StreamFile passes on at once.
+// Sections end at a blank line once they reach it, at any line break once
+// they are twice as long, and anywhere at four times.
+const streamSectionSize = 1 << 20
+
+// streamFormats are the extensions StreamFile reads.
+var streamFormats = map[string]bool{".txt": true, ".jsonl": true}
+
+// CanStream reports whether StreamFile reads files with the extension of
+// path: plain text and JSONL, unless a plugin reads them or they hold
+// curated triples.
+func (rd *Reader) CanStream(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return streamFormats[ext] && rd.plugin(ext) == nil && !IsTripleFile(path)
+}
+
+// streamedDocument describes a file that WalkDirectory leaves to
+// StreamFile: its name and sidecar metadata, without content.
+func (rd *Reader) streamedDocument(path string) (Document, error) {
+	if err := rd.check(path); err != nil {
+		return Document{}, err
+	}
+	sidecar, err := readSidecar(path)
+	if err != nil {
+		return Document{}, err
+	}
+	doc := Document{Path: path, Name: filepath.Base(path), Streamed: true}
+	applySidecar(&doc, sidecar)
+	return doc, nil
+}
+
+// StreamFile reads a .txt or .jsonl file section by section and passes each
+// section to fn, so the file is never held in memory at once. Text is cut
+// into sections of about a megabyte, at blank lines where it can be; JSONL
+// records are grouped into sections as LoadFile groups them. An error from
+// fn stops reading and is returned.
+func (rd *Reader) StreamFile(path string, fn func(Section) error) error {
+	if !rd.CanStream(path) {
+		return fmt.Errorf("%w for streaming: %s", ErrUnsupportedFormat, filepath.Ext(path))
+	}
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		g := &jsonSections{opts: rd.opts.JSON}
+		err := scanJSONL(path, func(rec interface{}) error {
+			if sec, ok := g.add(rec); ok {
+				return fn(sec)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if sec, ok := g.flush(); ok {
+			return fn(sec)
+		}
+		return nil
+	}
+	return streamText(path, fn)
+}
+
+func streamText(path string, fn func(Section) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open file %q: %w", path, err)
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, 64<<10)
+	var buf strings.Builder
+	// emit passes on the text read so far, keeping back a rune cut in half
+	emit := func() error {
+		text, rest := splitIncompleteRune(buf.String())
+		buf.Reset()
+		buf.WriteString(rest)
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		return fn(Section{Content: text})
+	}
+	for {
+		line, err := br.ReadSlice('\n')
+		buf.Write(line)
+		full := bytes.HasSuffix(line, []byte("\n"))
+		blank := full && len(bytes.TrimSpace(line)) == 0
+		if buf.Len() >= streamSectionSize && blank ||
+			buf.Len() >= 2*streamSectionSize && full ||
+			buf.Len() >= 4*streamSectionSize {
+			if emitErr := emit(); emitErr != nil {
+				return emitErr
+			}
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read file %q: %w", path, err)
+		}
+	}
+	return emit()
+}
+
+// splitIncompleteRune splits off the start of a UTF-8 sequence that s ends
+// in the middle of.
+func splitIncompleteRune(s string) (head, tail string) {
+	for i := 1; i < utf8.UTFMax && i <= len(s); i++ {
+		if utf8.RuneStart(s[len(s)-i]) {
+			if !utf8.FullRuneInString(s[len(s)-i:]) {
+				return s[:len(s)-i], s[len(s)-i:]
+			}
+			break
+		}
+	}
+	return s, ""
+}
@@ -0,0 +1,68 @@
+package reader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamFile_Text(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	var sb strings.Builder
+	for i := 0; sb.Len() < 3*streamSectionSize; i++ {
+		fmt.Fprintf(&sb, "Paragraph %d is about ünïcode and keeps going for a while.\n\n", i)
+	}
+	// A line longer than any section, cut mid-line between multi-byte runes
+	sb.WriteString(strings.Repeat("é", 3*streamSectionSize))
+	require.NoError(t, os.WriteFile(path, []byte(sb.String()), 0644))
+
+	rd := NewReader(Options{StreamThreshold: streamSectionSize})
+	var sections []string
+	require.NoError(t, rd.StreamFile(path, func(sec Section) error {
+		sections = append(sections, sec.Content)
+		return nil
+	}))
+	require.Greater(t, len(sections), 3)
+	for _, sec := range sections {
+		assert.True(t, utf8.ValidString(sec))
+		assert.LessOrEqual(t, len(sec), 4*streamSectionSize)
+	}
+	assert.True(t, strings.HasSuffix(sections[0], "\n\n"), "sections end at a blank line when they can")
+	assert.Equal(t, sb.String(), strings.Join(sections, ""))
+
+	docs, err := rd.LoadDirectory(dir)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.True(t, docs[0].Streamed)
+	assert.Empty(t, docs[0].Content)
+}
+
+func TestStreamFile_JSONL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"id":"a","msg":"one"}
+{"id":"b","msg":"two"}
+
+{"id":"c","msg":"three"}
+`), 0644))
+
+	rd := NewReader(Options{JSON: JSONOptions{IDField: "id", RecordsPerChunk: 2}})
+	loaded, err := rd.LoadFile(path)
+	require.NoError(t, err)
+	var streamed []Section
+	require.NoError(t, rd.StreamFile(path, func(sec Section) error {
+		streamed = append(streamed, sec)
+		return nil
+	}))
+	assert.Equal(t, loaded.Sections, streamed)
+	assert.Equal(t, "a,b", streamed[0].Metadata["record_ids"])
+
+	err = rd.StreamFile(filepath.Join(t.TempDir(), "notes.md"), func(Section) error { return nil })
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
@@ -0,0 +1,145 @@
+package reader
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// TripleOptions controls how curated triple files are loaded.
+type TripleOptions struct {
+	// MinConfidence drops the triples whose confidence is below it; triples
+	// without a confidence are always kept
+	MinConfidence float64
+}
+
+// tripleSuffixes mark the files that hold curated triples instead of text.
+var tripleSuffixes = []string{".triples.csv", ".triples.jsonl"}
+
+// IsTripleFile reports whether path holds curated triples, such as
+// "facts.triples.csv" or "facts.triples.jsonl". Their triples go into the
+// knowledge graph verbatim; they are neither chunked nor sent to the LLM.
+func IsTripleFile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	for _, suffix := range tripleSuffixes {
+		if strings.HasSuffix(name, suffix) && len(name) > len(suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// tripleColumns are the columns of a triple file, in their default order.
+var tripleColumns = []string{"subject", "predicate", "object", "confidence"}
+
+// loadTriples reads a curated triple file. A CSV file has the columns
+// subject, predicate, object, and optionally confidence, in that order or
+// as its header row names them; a JSONL file has one object with those keys
+// per line. A row missing a part of its triple is an error.
+func loadTriples(path string, opts TripleOptions) (Document, error) {
+	doc := Document{Path: path, Name: filepath.Base(path)}
+	add := func(line int, subject, predicate, object, confidence string) error {
+		t := Triple{
+			Subject:   strings.TrimSpace(subject),
+			Predicate: strings.TrimSpace(predicate),
+			Object:    strings.TrimSpace(object),
+		}
+		if t.Subject == "" || t.Predicate == "" || t.Object == "" {
+			return fmt.Errorf("line %d: want a subject, predicate, and object", line)
+		}
+		if confidence = strings.TrimSpace(confidence); confidence != "" {
+			c, err := strconv.ParseFloat(confidence, 64)
+			if err != nil || c < 0 || c > 1 {
+				return fmt.Errorf("line %d: confidence %q is not a number between 0 and 1", line, confidence)
+			}
+			if c < opts.MinConfidence {
+				return nil
+			}
+		}
+		doc.Triples = append(doc.Triples, t)
+		return nil
+	}
+
+	if strings.HasSuffix(strings.ToLower(path), ".jsonl") {
+		line := 0
+		err := scanJSONL(path, func(rec interface{}) error {
+			line++
+			obj, ok := rec.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("line %d: want an object with subject, predicate, and object", line)
+			}
+			field := func(key string) string {
+				switch v := obj[key].(type) {
+				case string:
+					return v
+				case json.Number:
+					return v.String()
+				}
+				return ""
+			}
+			return add(line, field("subject"), field("predicate"), field("object"), field("confidence"))
+		})
+		if err != nil {
+			return Document{}, err
+		}
+		return doc, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("open file %q: %w", path, err)
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	columns := map[string]int{}
+	for i, name := range tripleColumns {
+		columns[name] = i
+	}
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return doc, nil
+		}
+		if err != nil {
+			return Document{}, fmt.Errorf("read %q: %w", path, err)
+		}
+		if line == 1 && isTripleHeader(record) {
+			columns = map[string]int{}
+			for i, name := range record {
+				columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+			}
+			continue
+		}
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		if err := add(line, cell("subject"), cell("predicate"), cell("object"), cell("confidence")); err != nil {
+			return Document{}, err
+		}
+	}
+}
+
+// isTripleHeader reports whether record names the subject, predicate, and
+// object columns.
+func isTripleHeader(record []string) bool {
+	found := 0
+	for _, name := range record {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "subject", "predicate", "object":
+			found++
+		}
+	}
+	return found == 3
+}
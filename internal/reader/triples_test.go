@@ -0,0 +1,82 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTriples(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	want := []Triple{
+		{Subject: "Acme Cloud", Predicate: "refund window", Object: "30 days"},
+		{Subject: "Acme Cloud", Predicate: "founded", Object: "2012"},
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		opts    TripleOptions
+		want    []Triple
+		wantErr string
+	}{
+		{
+			name: "csv without header",
+			path: write("plain.triples.csv", "Acme Cloud,refund window,30 days\nAcme Cloud,founded,2012\n"),
+			want: want,
+		},
+		{
+			name: "csv header in another order",
+			path: write("header.triples.csv", "object,subject,predicate,confidence\n30 days,Acme Cloud,refund window,0.9\n2012,Acme Cloud,founded,0.4\n"),
+			want: want,
+		},
+		{
+			name: "min confidence",
+			path: write("confident.triples.csv", "subject,predicate,object,confidence\nAcme Cloud,refund window,30 days,0.9\nAcme Cloud,founded,2012,0.4\n"),
+			opts: TripleOptions{MinConfidence: 0.5},
+			want: want[:1],
+		},
+		{
+			name: "jsonl",
+			path: write("facts.triples.jsonl", `{"subject": "Acme Cloud", "predicate": "refund window", "object": "30 days", "confidence": 1}`+"\n\n"+
+				`{"subject": "Acme Cloud", "predicate": "founded", "object": 2012}`+"\n"),
+			want: want,
+		},
+		{
+			name:    "missing object",
+			path:    write("broken.triples.csv", "Acme Cloud,refund window,30 days\nAcme Cloud,founded\n"),
+			wantErr: "line 2",
+		},
+		{
+			name:    "bad confidence",
+			path:    write("odd.triples.jsonl", `{"subject": "a", "predicate": "b", "object": "c", "confidence": 7}`+"\n"),
+			wantErr: "between 0 and 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewReader(Options{Triples: tt.opts}).LoadFile(tt.path)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, doc.Triples)
+			assert.Empty(t, doc.Content)
+			assert.Empty(t, doc.Sections)
+		})
+	}
+
+	assert.True(t, IsTripleFile("data/Facts.TRIPLES.csv"))
+	assert.False(t, IsTripleFile("data/triples.csv"))
+	assert.False(t, NewReader(Options{}).CanStream("data/facts.triples.jsonl"))
+}
@@ -0,0 +1,104 @@
+package retrieval
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/akashicode/kash/internal/llm"
+)
+
+// compressConcurrency is how many chunks are compressed at once.
+const compressConcurrency = 4
+
+// CompressConfig is the compress block under retrieval in agent.yaml.
+// Compression has an LLM keep only the sentences of each chunk that help
+// answer the query, which suits models with a small context and cuts the
+// tokens of every answer.
+type CompressConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Model compresses instead of the agent's LLM model, e.g. a cheaper one
+	Model string `yaml:"model"`
+	// MaxTokens bounds the compressed chunks together, estimated at four
+	// characters a token; the lowest-ranked are cut (default: no bound)
+	MaxTokens int `yaml:"max_tokens"`
+}
+
+// compress replaces each chunk with its sentences relevant to the query and
+// drops the chunks with none, then keeps the chunks within cfg.MaxTokens. A
+// chunk that fails to compress is kept whole, and the failure recorded in
+// CompressErr.
+func (r *Result) compress(ctx context.Context, client *llm.Client, cfg CompressConfig) {
+	texts := make([]string, len(r.Chunks))
+	errs := make([]error, len(r.Chunks))
+	sem := make(chan struct{}, compressConcurrency)
+	var wg sync.WaitGroup
+	for i, c := range r.Chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			texts[i], errs[i] = client.ExtractRelevant(ctx, r.Query, c.Content)
+		}()
+	}
+	wg.Wait()
+
+	kept := r.Chunks[:0]
+	used := 0
+	for i, c := range r.Chunks {
+		switch {
+		case errs[i] != nil:
+			if r.CompressErr == nil {
+				r.CompressErr = errs[i]
+			}
+		case strings.TrimSpace(texts[i]) == "":
+			r.CompressDropped++
+			continue
+		default:
+			c.Content = texts[i]
+		}
+		if cfg.MaxTokens > 0 {
+			room := cfg.MaxTokens - used
+			if tokens := estimateTokens(c.Content); tokens > room {
+				// Only the first chunk is cut mid-sentence, so that some
+				// context is left
+				c.Content = cutToTokens(c.Content, room, len(kept) == 0)
+				used = cfg.MaxTokens
+			}
+			if c.Content == "" {
+				r.CompressDropped++
+				continue
+			}
+			used = min(used+estimateTokens(c.Content), cfg.MaxTokens)
+		}
+		kept = append(kept, c)
+	}
+	r.Chunks, r.Compressed = kept, true
+}
+
+// estimateTokens approximates the token count of text at four characters
+// per token, as the chunker sizes chunks.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// cutToTokens shortens text to about tokens at the end of a sentence, or
+// when none fits and words is set, at a word.
+func cutToTokens(text string, tokens int, words bool) string {
+	limit := tokens * 4
+	if limit <= 0 {
+		return ""
+	}
+	if len(text) <= limit {
+		return text
+	}
+	head := text[:limit]
+	if i := strings.LastIndexAny(head, ".!?\n"); i > 0 {
+		return strings.TrimSpace(head[:i+1])
+	}
+	if i := strings.LastIndexByte(head, ' '); words && i > 0 {
+		return strings.TrimSpace(head[:i])
+	}
+	return ""
+}
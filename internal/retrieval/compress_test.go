@@ -0,0 +1,74 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+func TestCompress(t *testing.T) {
+	// Keeps the sentences about refunds, and fails on passages about outages
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		passage := req.Messages[len(req.Messages)-1].Content
+		passage = passage[strings.Index(passage, "Passage:"):]
+		if strings.Contains(passage, "outage") {
+			http.Error(w, "boom", http.StatusBadRequest)
+			return
+		}
+		var kept []string
+		for _, s := range strings.SplitAfter(passage, ".") {
+			if strings.Contains(s, "Refund") {
+				kept = append(kept, strings.TrimSpace(s))
+			}
+		}
+		answer := "NONE"
+		if len(kept) > 0 {
+			answer = strings.Join(kept, " ")
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": answer}}},
+		})
+	}))
+	defer srv.Close()
+	client, err := llm.NewClient(&config.ProviderConfig{BaseURL: srv.URL, APIKey: "k", Model: "m"})
+	require.NoError(t, err)
+
+	newResult := func() *Result {
+		return &Result{Query: "how long do refunds take", Chunks: []vector.SearchResult{
+			{ID: "billing", Content: "Billing runs monthly. Refunds take 14 days. Refunds go to the card."},
+			{ID: "office", Content: "The office has plants."},
+			{ID: "status", Content: "An outage is posted on the status page."},
+		}}
+	}
+
+	r := newResult()
+	r.compress(context.Background(), client, CompressConfig{})
+	assert.True(t, r.Compressed)
+	require.Len(t, r.Chunks, 2)
+	assert.Equal(t, "Refunds take 14 days. Refunds go to the card.", r.Chunks[0].Content)
+	assert.Equal(t, "An outage is posted on the status page.", r.Chunks[1].Content, "a chunk that fails is kept whole")
+	assert.Equal(t, 1, r.CompressDropped)
+	assert.Error(t, r.CompressErr)
+
+	r = newResult()
+	r.compress(context.Background(), client, CompressConfig{MaxTokens: 7})
+	require.Len(t, r.Chunks, 1, "the budget runs out on the first chunk")
+	assert.Equal(t, "Refunds take 14 days.", r.Chunks[0].Content)
+	assert.Equal(t, 2, r.CompressDropped)
+}
@@ -0,0 +1,159 @@
+package retrieval
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// minOverlap is the shortest text two chunks must share at their ends to be
+// joined over it, so that a chunk ending in "a" and the next starting with
+// "a" are not run together.
+const minOverlap = 16
+
+// nearDuplicate is the share of a chunk's words a higher-ranked chunk must
+// hold for it to count as a repeat.
+const nearDuplicate = 0.9
+
+// MergeOverlapping joins chunks that follow each other in one source into a
+// single passage, cutting the text they overlap by, and drops chunks whose
+// words nearly all appear in a higher-ranked one, e.g. a document copied
+// into two sources. Neighbours are only joined when their metadata, such as the page,
+// is the same. A passage takes the place, similarity, and score of its best
+// ranked chunk and the ID of its first.
+func MergeOverlapping() ChunkFilter {
+	return ChunkFilterFunc(func(_ context.Context, _ string, chunks []vector.SearchResult) ([]vector.SearchResult, error) {
+		return dropRepeats(mergeNeighbours(chunks)), nil
+	})
+}
+
+func mergeNeighbours(chunks []vector.SearchResult) []vector.SearchResult {
+	type piece struct{ pos, index int }
+	bySource := map[string][]piece{}
+	for i, c := range chunks {
+		idx, err := strconv.Atoi(c.Metadata["index"])
+		if c.Source == "" || err != nil {
+			continue
+		}
+		bySource[c.Source] = append(bySource[c.Source], piece{i, idx})
+	}
+
+	merged := map[int]vector.SearchResult{}
+	dropped := map[int]bool{}
+	for _, pieces := range bySource {
+		sort.Slice(pieces, func(i, j int) bool { return pieces[i].index < pieces[j].index })
+		for start := 0; start < len(pieces); {
+			end := start + 1
+			for end < len(pieces) && pieces[end].index == pieces[end-1].index+1 &&
+				sameSection(chunks[pieces[end].pos], chunks[pieces[start].pos]) {
+				end++
+			}
+			if end-start > 1 {
+				run := pieces[start:end]
+				head := run[0].pos
+				passage := chunks[run[0].pos]
+				for _, p := range run[1:] {
+					c := chunks[p.pos]
+					passage.Content = joinOverlap(passage.Content, c.Content)
+					passage.Similarity = max(passage.Similarity, c.Similarity)
+					passage.Score = max(passage.Score, c.Score)
+					head = min(head, p.pos)
+				}
+				for _, p := range run {
+					dropped[p.pos] = p.pos != head
+				}
+				merged[head] = passage
+			}
+			start = end
+		}
+	}
+
+	out := make([]vector.SearchResult, 0, len(chunks))
+	for i, c := range chunks {
+		if dropped[i] {
+			continue
+		}
+		if m, ok := merged[i]; ok {
+			c = m
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// sameSection reports whether a and b have the same metadata besides their
+// index.
+func sameSection(a, b vector.SearchResult) bool {
+	strip := func(m map[string]string) map[string]string {
+		m = maps.Clone(m)
+		delete(m, "index")
+		return m
+	}
+	return maps.Equal(strip(a.Metadata), strip(b.Metadata))
+}
+
+// joinOverlap appends b to a, leaving out the longest start of b that a ends
+// with, of at least minOverlap bytes or all of b. Chunks without overlap are
+// joined as paragraphs.
+func joinOverlap(a, b string) string {
+	for k := min(len(a), len(b)); k > 0 && k >= min(minOverlap, len(b)); k-- {
+		if strings.HasSuffix(a, b[:k]) {
+			return a + b[k:]
+		}
+	}
+	return a + "\n\n" + b
+}
+
+// dropRepeats drops the chunks whose words nearly all appear in a chunk
+// ranked above them.
+func dropRepeats(chunks []vector.SearchResult) []vector.SearchResult {
+	out := make([]vector.SearchResult, 0, len(chunks))
+	var kept []map[string]bool
+	for _, c := range chunks {
+		words := wordSet(c.Content)
+		repeat := false
+		for _, k := range kept {
+			if coverage(words, k) >= nearDuplicate {
+				repeat = true
+				break
+			}
+		}
+		if repeat {
+			continue
+		}
+		out = append(out, c)
+		kept = append(kept, words)
+	}
+	return out
+}
+
+func wordSet(text string) map[string]bool {
+	words := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), isWordSeparator) {
+		words[w] = true
+	}
+	return words
+}
+
+func isWordSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// coverage is the share of the words of a that b holds.
+func coverage(a, b map[string]bool) float64 {
+	if len(a) == 0 {
+		return 1
+	}
+	shared := 0
+	for w := range a {
+		if b[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a))
+}
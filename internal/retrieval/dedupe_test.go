@@ -0,0 +1,43 @@
+package retrieval
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+func TestMergeOverlapping(t *testing.T) {
+	chunk := func(id, source, index, content string, similarity float32) vector.SearchResult {
+		return vector.SearchResult{
+			ID: id, Source: source, Content: content, Similarity: similarity,
+			Metadata: map[string]string{"source": source, "index": index},
+		}
+	}
+	chunks := []vector.SearchResult{
+		chunk("guide_1", "guide.md", "1", "Refunds are issued within 14 days of a request.", 0.9),
+		chunk("faq_0", "faq.md", "0", "How do refunds work? Refunds are issued within 14 days of a request.", 0.8),
+		chunk("guide_0", "guide.md", "0", "Billing runs monthly. Refunds are issued", 0.7),
+		chunk("copy_0", "copy.md", "0", "Refunds are issued within 14 days of a request!", 0.6),
+		chunk("guide_3", "guide.md", "3", "Support is open on weekdays.", 0.5),
+	}
+	page := chunk("guide_2", "guide.md", "2", "See the pricing page.", 0.4)
+	page.Metadata["page"] = "2"
+	chunks = append(chunks, page)
+
+	got, err := MergeOverlapping().FilterChunks(context.Background(), "q", chunks)
+	require.NoError(t, err)
+	require.Len(t, got, 4)
+
+	assert.Equal(t, "guide_0", got[0].ID, "a passage takes the first chunk's ID")
+	assert.Equal(t, "Billing runs monthly. Refunds are issued within 14 days of a request.", got[0].Content)
+	assert.Equal(t, float32(0.9), got[0].Similarity, "and the place and similarity of the best one")
+	assert.Equal(t, "faq_0", got[1].ID)
+	assert.Equal(t, "guide_3", got[2].ID, "copy_0 repeats the passage")
+	assert.Equal(t, "guide_2", got[3].ID, "a chunk on another page is not joined")
+
+	assert.Equal(t, "a\n\nb", joinOverlap("a", "b"))
+}
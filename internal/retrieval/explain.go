@@ -0,0 +1,93 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// Thresholds an Explanation reports a chunk would be cut by.
+const (
+	CutTopK              = "top_k"
+	CutMinSimilarity     = "min_similarity"
+	CutRerankTopN        = "rerank.top_n"
+	CutMinRelevanceScore = "rerank.min_relevance_score"
+)
+
+// Explanation says why a chunk was found and how the agent's own retrieval
+// would treat it, so a caller given raw results can weigh them itself.
+type Explanation struct {
+	// GraphFacts are the graph facts found for the query whose subject or
+	// object the chunk names
+	GraphFacts []FactMatch `json:"graph_facts,omitempty"`
+	// RerankScore is the reranker's relevance score, and RerankRank the
+	// chunk's place in its order from 1; both are unset without a reranker
+	RerankScore *float64 `json:"rerank_score,omitempty"`
+	RerankRank  int      `json:"rerank_rank,omitempty"`
+	// Cut lists the thresholds that would keep the chunk from the LLM:
+	// CutTopK, CutMinSimilarity, CutRerankTopN, and CutMinRelevanceScore
+	Cut []string `json:"cut,omitempty"`
+}
+
+// FactMatch is a graph fact with the query words it matched.
+type FactMatch struct {
+	Fact  string   `json:"fact"`
+	Terms []string `json:"terms"`
+}
+
+// Explain returns an Explanation for each of chunks, the vector results for
+// query in their vector order, measured against opts.TopK,
+// opts.MinSimilarity, and, when opts.Reranker is set, opts.Rerank. A failed
+// rerank leaves the rerank scores and thresholds out and is returned with
+// the explanations.
+func Explain(ctx context.Context, query string, chunks []vector.SearchResult, facts []graph.SearchResult, opts Options) ([]Explanation, error) {
+	topK := opts.TopK
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+	out := make([]Explanation, len(chunks))
+	for i, c := range chunks {
+		text := strings.ToLower(c.Content)
+		for _, f := range facts {
+			if mentions(f, text) > 0 {
+				out[i].GraphFacts = append(out[i].GraphFacts, FactMatch{
+					Fact:  fmt.Sprintf("%s %s %s", f.Subject, f.Predicate, f.Object),
+					Terms: graph.MatchedTerms(query, f),
+				})
+			}
+		}
+		if i >= topK {
+			out[i].Cut = append(out[i].Cut, CutTopK)
+		}
+		if opts.MinSimilarity > 0 && float64(c.Similarity) < opts.MinSimilarity {
+			out[i].Cut = append(out[i].Cut, CutMinSimilarity)
+		}
+	}
+	if opts.Reranker == nil || len(chunks) == 0 {
+		return out, nil
+	}
+
+	docs := make([]string, len(chunks))
+	for i, c := range chunks {
+		docs[i] = c.Content
+	}
+	ranked, err := opts.Reranker.Rerank(ctx, query, docs)
+	if err != nil {
+		return out, fmt.Errorf("rerank: %w", err)
+	}
+	for rank, rk := range ranked {
+		e := &out[rk.Index]
+		score := rk.RelevanceScore
+		e.RerankScore, e.RerankRank = &score, rank+1
+		if opts.Rerank.TopN > 0 && rank >= opts.Rerank.TopN {
+			e.Cut = append(e.Cut, CutRerankTopN)
+		}
+		if score < opts.Rerank.MinRelevanceScore {
+			e.Cut = append(e.Cut, CutMinRelevanceScore)
+		}
+	}
+	return out, nil
+}
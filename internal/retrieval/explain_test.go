@@ -0,0 +1,60 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+func TestExplain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": []map[string]interface{}{
+			{"index": 2, "relevance_score": 0.9},
+			{"index": 0, "relevance_score": 0.4},
+			{"index": 1, "relevance_score": 0.1},
+		}})
+	}))
+	defer srv.Close()
+	reranker, err := llm.NewReranker(&config.ProviderConfig{BaseURL: srv.URL, Model: "r"})
+	require.NoError(t, err)
+
+	chunks := []vector.SearchResult{
+		{ID: "refunds", Content: "Acme Cloud refunds annual plans within 30 days.", Similarity: 0.8},
+		{ID: "status", Content: "Status page lists incidents.", Similarity: 0.3},
+		{ID: "pricing", Content: "Plans start at $10.", Similarity: 0.6},
+	}
+	facts := []graph.SearchResult{{Subject: "Acme Cloud", Predicate: "offers", Object: "refunds", Score: 1}}
+	query := "how do acme refunds work"
+
+	got, err := Explain(context.Background(), query, chunks, facts, Options{TopK: 2, MinSimilarity: 0.5})
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+	assert.Equal(t, []FactMatch{{Fact: "Acme Cloud offers refunds", Terms: []string{"acme", "refunds"}}}, got[0].GraphFacts)
+	assert.Nil(t, got[0].RerankScore, "no reranker, no score")
+	assert.Empty(t, got[0].Cut)
+	assert.Equal(t, []string{CutMinSimilarity}, got[1].Cut)
+	assert.Equal(t, []string{CutTopK}, got[2].Cut)
+
+	got, err = Explain(context.Background(), query, chunks, nil, Options{
+		TopK: 5, Reranker: reranker, Rerank: RerankConfig{TopN: 2, MinRelevanceScore: 0.2},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, got[2].RerankScore)
+	assert.Equal(t, 0.9, *got[2].RerankScore)
+	assert.Equal(t, 1, got[2].RerankRank)
+	assert.Empty(t, got[2].Cut)
+	assert.Equal(t, 2, got[0].RerankRank)
+	assert.Equal(t, []string{CutRerankTopN, CutMinRelevanceScore}, got[1].Cut)
+}
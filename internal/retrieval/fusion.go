@@ -0,0 +1,155 @@
+package retrieval
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// FusionConfig is the fusion block under retrieval in agent.yaml. Fusion
+// combines the vector, rerank, and graph signals into one relevance score
+// between 0 and 1, and orders the chunks and graph facts by it.
+type FusionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Weights of the signals; all zero uses DefaultFusionWeights
+	Weights FusionWeights `yaml:"weights"`
+}
+
+// FusionWeights weigh the signals fused into a relevance score.
+type FusionWeights struct {
+	// Vector weighs the embedding similarity of a chunk
+	Vector float64 `yaml:"vector"`
+	// Rerank weighs the reranker's score of a chunk, when it was reranked
+	Rerank float64 `yaml:"rerank"`
+	// Graph weighs the keyword match of a graph fact, and for a chunk the
+	// graph facts it mentions
+	Graph float64 `yaml:"graph"`
+}
+
+// DefaultFusionWeights trust the reranker most and let graph facts nudge
+// the order.
+var DefaultFusionWeights = FusionWeights{Vector: 1, Rerank: 2, Graph: 0.5}
+
+// fuse scores every chunk and graph fact and orders both by the score.
+//
+// A chunk's score is the weighted mean of its similarity, its rerank score,
+// and the keyword score of the best graph fact it mentions. A fact's score
+// is the weighted mean of its keyword score, relative to the best fact, and
+// the vector and rerank score of the best chunk mentioning it, so facts
+// that corroborate a chunk rank above those matching only keywords.
+func (r *Result) fuse(w FusionWeights) {
+	if w == (FusionWeights{}) {
+		w = DefaultFusionWeights
+	}
+	best := 0.0
+	for _, f := range r.Graph {
+		best = max(best, f.Score)
+	}
+	keyword := make([]float64, len(r.Graph))
+	for i, f := range r.Graph {
+		if best > 0 {
+			keyword[i] = f.Score / best
+		}
+	}
+
+	// chunkWeight is the weight of the signals a chunk has by itself
+	chunkWeight := w.Vector
+	if r.rerankScores != nil {
+		chunkWeight += w.Rerank
+	}
+	base := make([]float64, len(r.Chunks))
+	for i, c := range r.Chunks {
+		sum := w.Vector * clamp01(float64(c.Similarity))
+		if r.rerankScores != nil {
+			sum += w.Rerank * clamp01(r.rerankScores[i])
+		}
+		base[i] = ratio(sum, chunkWeight)
+	}
+
+	// corroboration is the best keyword score of the facts a chunk
+	// mentions; support is the best base score of a chunk mentioning a fact
+	corroboration := make([]float64, len(r.Chunks))
+	support := make([]float64, len(r.Graph))
+	for i, c := range r.Chunks {
+		text := strings.ToLower(c.Content)
+		for j, f := range r.Graph {
+			m := mentions(f, text)
+			corroboration[i] = max(corroboration[i], m*keyword[j])
+			support[j] = max(support[j], m*base[i])
+		}
+	}
+
+	graphWeight := 0.0
+	if len(r.Graph) > 0 {
+		graphWeight = w.Graph
+	}
+	for i := range r.Chunks {
+		r.Chunks[i].Score = ratio(base[i]*chunkWeight+w.Graph*corroboration[i], chunkWeight+graphWeight)
+	}
+	supportWeight := 0.0
+	if len(r.Chunks) > 0 {
+		supportWeight = chunkWeight
+	}
+	for j := range r.Graph {
+		r.Graph[j].Score = ratio(w.Graph*keyword[j]+supportWeight*support[j], w.Graph+supportWeight)
+	}
+
+	sort.SliceStable(r.Chunks, func(i, j int) bool { return r.Chunks[i].Score > r.Chunks[j].Score })
+	sort.SliceStable(r.Graph, func(i, j int) bool { return r.Graph[i].Score > r.Graph[j].Score })
+	r.Fused = true
+}
+
+// mentions reports how much of fact f the lowercased text names: 1 for its
+// subject and object, 0.5 for one of them. Names shorter than three
+// characters are ignored.
+func mentions(f graph.SearchResult, text string) float64 {
+	m := 0.0
+	for _, name := range []string{f.Subject, f.Object} {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) >= 3 && strings.Contains(text, name) {
+			m += 0.5
+		}
+	}
+	return m
+}
+
+// formatFusedGraph lists the graph facts with the chunks that mention them.
+func formatFusedGraph(facts []graph.SearchResult, chunks []vector.SearchResult) string {
+	if len(facts) == 0 {
+		return ""
+	}
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = strings.ToLower(c.Content)
+	}
+	var sb strings.Builder
+	sb.WriteString("Knowledge Graph Facts:\n")
+	for _, f := range facts {
+		sb.WriteString(fmt.Sprintf("- %s %s %s", f.Subject, f.Predicate, f.Object))
+		var refs []string
+		for i, text := range texts {
+			if mentions(f, text) > 0 {
+				refs = append(refs, fmt.Sprintf("[%d]", i+1))
+			}
+		}
+		if len(refs) > 0 {
+			sb.WriteString(" (supports " + strings.Join(refs, ", ") + ")")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func clamp01(x float64) float64 {
+	return min(max(x, 0), 1)
+}
+
+func ratio(sum, weight float64) float64 {
+	if weight <= 0 {
+		return 0
+	}
+	return sum / weight
+}
@@ -0,0 +1,66 @@
+package retrieval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+func TestFuse(t *testing.T) {
+	newResult := func() *Result {
+		return &Result{
+			Chunks: []vector.SearchResult{
+				{ID: "pricing", Content: "Plans start at $10.", Similarity: 0.82},
+				{ID: "refunds", Content: "Acme Cloud refunds annual plans within 30 days.", Similarity: 0.80},
+				{ID: "status", Content: "Status page lists incidents.", Similarity: 0.40},
+			},
+			Graph: []graph.SearchResult{
+				{Subject: "Support", Predicate: "answers", Object: "tickets", Score: 2},
+				{Subject: "Acme Cloud", Predicate: "offers", Object: "refunds", Score: 1},
+			},
+		}
+	}
+	ids := func(r *Result) []string {
+		var out []string
+		for _, c := range r.Chunks {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	t.Run("graph facts corroborate chunks", func(t *testing.T) {
+		r := newResult()
+		r.fuse(FusionWeights{})
+		assert.True(t, r.Fused)
+		assert.Equal(t, []string{"refunds", "pricing", "status"}, ids(r))
+		assert.InDelta(t, (0.80+0.5*0.5)/1.5, r.Chunks[0].Score, 1e-6)
+		assert.Equal(t, "Acme Cloud", r.Graph[0].Subject, "the fact a chunk mentions ranks above a better keyword match")
+	})
+
+	t.Run("rerank scores", func(t *testing.T) {
+		r := newResult()
+		r.rerankScores = []float64{0.1, 0.2, 0.9}
+		r.fuse(FusionWeights{})
+		assert.Equal(t, []string{"status", "refunds", "pricing"}, ids(r))
+	})
+
+	t.Run("custom weights", func(t *testing.T) {
+		r := newResult()
+		r.fuse(FusionWeights{Vector: 1})
+		assert.Equal(t, []string{"pricing", "refunds", "status"}, ids(r))
+		assert.InDelta(t, 0.82, r.Chunks[0].Score, 1e-6)
+	})
+
+	t.Run("format", func(t *testing.T) {
+		r := newResult()
+		r.fuse(FusionWeights{})
+		out := r.Format()
+		require.Contains(t, out, "**[1] Source: ** (relevance: 0.70)\nAcme Cloud")
+		assert.Contains(t, out, "- Acme Cloud offers refunds (supports [1])\n")
+		assert.Contains(t, out, "- Support answers tickets\n")
+	})
+}
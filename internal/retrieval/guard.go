@@ -0,0 +1,167 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/akashicode/kash/internal/llm"
+)
+
+// Actions of GuardConfig on a chunk that looks like a prompt injection.
+const (
+	// GuardDrop leaves the chunk out of the context (default)
+	GuardDrop = "drop"
+	// GuardFlag keeps the chunk as it is and only reports it
+	GuardFlag = "flag"
+	// GuardSanitize replaces the matched text with "[removed]"; a chunk
+	// flagged by the classifier alone is dropped
+	GuardSanitize = "sanitize"
+)
+
+// guardConcurrency is how many chunks the classifier checks at once.
+const guardConcurrency = 4
+
+// injectionPatterns are phrasings of instructions aimed at the assistant
+// rather than the reader, matched case-insensitively.
+var injectionPatterns = compilePatterns(
+	`\b(ignore|disregard|forget)\s+(all\s+|any\s+)?(of\s+)?(the\s+|your\s+)?(previous|prior|above|earlier|preceding|system)\s+(instructions|prompts?|rules|directions|messages)`,
+	`\boverride\s+(your|the)\s+(instructions|system\s+prompt|rules)`,
+	`\b(reveal|print|show|repeat|output)\s+(your|the)\s+(system\s+prompt|hidden\s+instructions|initial\s+instructions)`,
+	`\bnew\s+(system\s+)?instructions\s*:`,
+	`\bdo\s+not\s+(tell|inform|mention\s+this\s+to)\s+the\s+user\b`,
+	`<\|im_(start|end)\|>|\[/?INST\]|<</?SYS>>`,
+)
+
+func compilePatterns(patterns ...string) []*regexp.Regexp {
+	out := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		out[i] = regexp.MustCompile(`(?i)` + p)
+	}
+	return out
+}
+
+// GuardConfig is the guard block under retrieval in agent.yaml. The guard
+// checks retrieved chunks for prompt injection, text such as "ignore
+// previous instructions" planted in a web page or an upload, before they
+// reach the LLM.
+type GuardConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Action is GuardDrop (default), GuardFlag, or GuardSanitize
+	Action string `yaml:"action"`
+	// Patterns are regular expressions flagged besides the built-in ones,
+	// matched case-insensitively
+	Patterns []string `yaml:"patterns"`
+	// Classify has an LLM check the chunks the patterns pass
+	Classify bool `yaml:"classify"`
+	// Model classifies instead of the agent's LLM model
+	Model string `yaml:"model"`
+}
+
+// Validate checks the action and patterns of c.
+func (c GuardConfig) Validate() error {
+	switch c.Action {
+	case "", GuardDrop, GuardFlag, GuardSanitize:
+	default:
+		return fmt.Errorf("guard: unknown action %q (want %s, %s, or %s)", c.Action, GuardDrop, GuardFlag, GuardSanitize)
+	}
+	for _, p := range c.Patterns {
+		if _, err := regexp.Compile(`(?i)` + p); err != nil {
+			return fmt.Errorf("guard: invalid pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// FlaggedChunk is a chunk the guard found to look like a prompt injection.
+type FlaggedChunk struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	// Reason is the text a pattern matched, or "classifier"
+	Reason string `json:"reason"`
+	// Action is what was done with the chunk
+	Action string `json:"action"`
+}
+
+// guard checks the chunks for prompt injection with the patterns and, when
+// classifier is set, an LLM, and acts on those flagged as cfg.Action says.
+// A failed classification passes the chunk, and the failure is recorded in
+// GuardErr.
+func (r *Result) guard(ctx context.Context, classifier *llm.Client, cfg GuardConfig) {
+	patterns := injectionPatterns
+	if len(cfg.Patterns) > 0 {
+		patterns = append(compilePatterns(cfg.Patterns...), patterns...)
+	}
+	action := cfg.Action
+	if action == "" {
+		action = GuardDrop
+	}
+
+	reasons := make([]string, len(r.Chunks))
+	for i, c := range r.Chunks {
+		for _, p := range patterns {
+			if m := p.FindString(c.Content); m != "" {
+				reasons[i] = m
+				break
+			}
+		}
+	}
+	if classifier != nil {
+		errs := make([]error, len(r.Chunks))
+		sem := make(chan struct{}, guardConcurrency)
+		var wg sync.WaitGroup
+		for i, c := range r.Chunks {
+			if reasons[i] != "" {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				injection, err := classifier.DetectInjection(ctx, c.Content)
+				if injection {
+					reasons[i] = "classifier"
+				}
+				errs[i] = err
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				r.GuardErr = err
+				break
+			}
+		}
+	}
+
+	kept := r.Chunks[:0]
+	for i, c := range r.Chunks {
+		if reasons[i] == "" {
+			kept = append(kept, c)
+			continue
+		}
+		done := action
+		if action == GuardSanitize && reasons[i] == "classifier" {
+			done = GuardDrop
+		}
+		r.Flagged = append(r.Flagged, FlaggedChunk{ID: c.ID, Source: c.Source, Reason: reasons[i], Action: done})
+		switch done {
+		case GuardFlag:
+			kept = append(kept, c)
+		case GuardSanitize:
+			c.Content = sanitize(c.Content, patterns)
+			kept = append(kept, c)
+		}
+	}
+	r.Chunks = kept
+}
+
+// sanitize replaces the text any of patterns matches with "[removed]".
+func sanitize(text string, patterns []*regexp.Regexp) string {
+	for _, p := range patterns {
+		text = p.ReplaceAllString(text, "[removed]")
+	}
+	return text
+}
@@ -0,0 +1,360 @@
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// QueryTransformer rewrites a query before it is searched, e.g. to expand
+// internal jargon. The rewritten query is used by every search and the
+// reranker.
+type QueryTransformer interface {
+	TransformQuery(ctx context.Context, query string) (string, error)
+}
+
+// ChunkRetriever finds chunks the vector index does not hold, e.g. in a
+// keyword index or an external search API. Its chunks are merged with the
+// vector results before reranking.
+type ChunkRetriever interface {
+	RetrieveChunks(ctx context.Context, query string, topK int, filter map[string]string) ([]vector.SearchResult, error)
+}
+
+// ChunkFilter processes the chunks after reranking, before they reach the
+// LLM: dropping, reordering, or rewriting them.
+type ChunkFilter interface {
+	FilterChunks(ctx context.Context, query string, chunks []vector.SearchResult) ([]vector.SearchResult, error)
+}
+
+// Hooks are the extension points of a Search, each run in order. A failing
+// hook does not fail the search: it is skipped and reported in
+// Result.HookErrs.
+type Hooks struct {
+	QueryTransformers []QueryTransformer
+	Retrievers        []ChunkRetriever
+	Filters           []ChunkFilter
+}
+
+// Append returns h followed by other.
+func (h Hooks) Append(other Hooks) Hooks {
+	return Hooks{
+		QueryTransformers: append(append([]QueryTransformer(nil), h.QueryTransformers...), other.QueryTransformers...),
+		Retrievers:        append(append([]ChunkRetriever(nil), h.Retrievers...), other.Retrievers...),
+		Filters:           append(append([]ChunkFilter(nil), h.Filters...), other.Filters...),
+	}
+}
+
+// QueryTransformFunc adapts a function to a QueryTransformer.
+type QueryTransformFunc func(ctx context.Context, query string) (string, error)
+
+// TransformQuery implements QueryTransformer.
+func (f QueryTransformFunc) TransformQuery(ctx context.Context, query string) (string, error) {
+	return f(ctx, query)
+}
+
+// ChunkFilterFunc adapts a function to a ChunkFilter.
+type ChunkFilterFunc func(ctx context.Context, query string, chunks []vector.SearchResult) ([]vector.SearchResult, error)
+
+// FilterChunks implements ChunkFilter.
+func (f ChunkFilterFunc) FilterChunks(ctx context.Context, query string, chunks []vector.SearchResult) ([]vector.SearchResult, error) {
+	return f(ctx, query, chunks)
+}
+
+// HookConfig selects the built-in hooks in the retrieval block of agent.yaml.
+type HookConfig struct {
+	// Query transforms each search query
+	Query struct {
+		// Replace rewrites whole words, case-insensitively, e.g.
+		// {k8s: kubernetes}
+		Replace map[string]string `yaml:"replace"`
+		// MaxLength truncates longer queries to this many characters
+		MaxLength int `yaml:"max_length"`
+	} `yaml:"query"`
+	// Retrievers are external search services queried alongside the index
+	Retrievers []HTTPRetrieverConfig `yaml:"retrievers"`
+	// Filters post-process the retrieved chunks
+	Filters struct {
+		// Dedupe joins chunks that overlap in their source into one
+		// passage and drops repeated text
+		Dedupe bool `yaml:"dedupe"`
+		// MaxPerSource keeps at most this many chunks from one source
+		MaxPerSource int `yaml:"max_per_source"`
+		// Exclude drops chunks whose metadata matches any of these pairs,
+		// e.g. {status: archived}
+		Exclude map[string]string `yaml:"exclude"`
+	} `yaml:"filters"`
+	// Rerank trims the chunks the reranker returns
+	Rerank RerankConfig `yaml:"rerank"`
+	// Fusion orders the chunks and graph facts by one relevance score
+	Fusion FusionConfig `yaml:"fusion"`
+	// Compress keeps the sentences of each chunk relevant to the query
+	Compress CompressConfig `yaml:"compress"`
+	// Guard checks the chunks for prompt injection
+	Guard GuardConfig `yaml:"guard"`
+	// Language filters or boosts the chunks in the query's language
+	Language LanguageConfig `yaml:"language"`
+	// Synonyms are words graph search treats as one, with the sameAs
+	// triples of the graph, e.g. {k8s: [kubernetes]}
+	Synonyms map[string][]string `yaml:"synonyms"`
+	// MinSimilarity drops vector results less similar to the query, between
+	// 0 and 1 (default: keep all)
+	MinSimilarity float64 `yaml:"min_similarity"`
+	// Window widens each vector result with up to this many neighbouring
+	// sentences or chunks on each side (default: 2 for sentence chunks,
+	// else none; -1 for none)
+	Window int `yaml:"window"`
+	// ParentDocument returns the chunks of short documents as the whole
+	// document
+	ParentDocument ParentConfig `yaml:"parent_document"`
+	// Strategy merges the ranked lists of a search: concat (default) or rrf
+	Strategy string `yaml:"strategy"`
+	// RRFK is the rank constant of the rrf strategy (default 60)
+	RRFK int `yaml:"rrf_k"`
+}
+
+// AgentYAMLHooks reads the built-in hook selection from the retrieval block of
+// an agent.yaml file. A missing file selects none.
+func AgentYAMLHooks(path string) (HookConfig, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return HookConfig{}, nil
+	}
+	if err != nil {
+		return HookConfig{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	var parsed struct {
+		Retrieval HookConfig `yaml:"retrieval"`
+	}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return HookConfig{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return parsed.Retrieval, nil
+}
+
+// Hooks builds the hooks c selects.
+func (c HookConfig) Hooks() (Hooks, error) {
+	if !ValidStrategy(c.Strategy) {
+		return Hooks{}, fmt.Errorf("unknown retrieval strategy %q (want %s or %s)", c.Strategy, StrategyConcat, StrategyRRF)
+	}
+	if c.MinSimilarity < 0 || c.MinSimilarity > 1 {
+		return Hooks{}, fmt.Errorf("min_similarity %g is not between 0 and 1", c.MinSimilarity)
+	}
+	if err := c.Guard.Validate(); err != nil {
+		return Hooks{}, err
+	}
+	if err := c.Language.Validate(); err != nil {
+		return Hooks{}, err
+	}
+	var h Hooks
+	if c.Query.MaxLength > 0 {
+		h.QueryTransformers = append(h.QueryTransformers, TruncateQuery(c.Query.MaxLength))
+	}
+	if len(c.Query.Replace) > 0 {
+		h.QueryTransformers = append(h.QueryTransformers, ReplaceWords(c.Query.Replace))
+	}
+	for _, rc := range c.Retrievers {
+		r, err := NewHTTPRetriever(rc)
+		if err != nil {
+			return Hooks{}, err
+		}
+		h.Retrievers = append(h.Retrievers, r)
+	}
+	if len(c.Filters.Exclude) > 0 {
+		h.Filters = append(h.Filters, ExcludeMetadata(c.Filters.Exclude))
+	}
+	if c.Filters.Dedupe {
+		h.Filters = append(h.Filters, MergeOverlapping())
+	}
+	if c.Filters.MaxPerSource > 0 {
+		h.Filters = append(h.Filters, MaxPerSource(c.Filters.MaxPerSource))
+	}
+	return h, nil
+}
+
+// ReplaceWords rewrites each whole-word occurrence of a key of words,
+// matched case-insensitively, with its value.
+func ReplaceWords(words map[string]string) QueryTransformer {
+	keys := make([]string, 0, len(words))
+	for k := range words {
+		keys = append(keys, k)
+	}
+	// Longest first, so "k8s cluster" wins over "k8s"
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	type rule struct {
+		re   *regexp.Regexp
+		with string
+	}
+	rules := make([]rule, len(keys))
+	for i, k := range keys {
+		rules[i] = rule{regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(k) + `\b`), words[k]}
+	}
+	return QueryTransformFunc(func(_ context.Context, query string) (string, error) {
+		for _, r := range rules {
+			query = r.re.ReplaceAllLiteralString(query, r.with)
+		}
+		return query, nil
+	})
+}
+
+// TruncateQuery cuts queries longer than n characters at the last word
+// boundary before n, so a pasted document does not overflow the embedder.
+func TruncateQuery(n int) QueryTransformer {
+	return QueryTransformFunc(func(_ context.Context, query string) (string, error) {
+		runes := []rune(query)
+		if len(runes) <= n {
+			return query, nil
+		}
+		cut := string(runes[:n])
+		if i := strings.LastIndexAny(cut, " \t\n"); i > 0 {
+			cut = cut[:i]
+		}
+		return cut, nil
+	})
+}
+
+// MaxPerSource keeps at most n chunks from each source, so one long document
+// cannot crowd out the rest.
+func MaxPerSource(n int) ChunkFilter {
+	return ChunkFilterFunc(func(_ context.Context, _ string, chunks []vector.SearchResult) ([]vector.SearchResult, error) {
+		seen := make(map[string]int)
+		kept := chunks[:0:0]
+		for _, c := range chunks {
+			if seen[c.Source] < n {
+				seen[c.Source]++
+				kept = append(kept, c)
+			}
+		}
+		return kept, nil
+	})
+}
+
+// ExcludeMetadata drops chunks whose metadata matches any key/value pair of
+// exclude, with the same matching rules as a search filter.
+func ExcludeMetadata(exclude map[string]string) ChunkFilter {
+	return ChunkFilterFunc(func(_ context.Context, _ string, chunks []vector.SearchResult) ([]vector.SearchResult, error) {
+		kept := chunks[:0:0]
+		for _, c := range chunks {
+			drop := false
+			for k, v := range exclude {
+				if vector.MatchesFilter(c.Metadata, map[string]string{k: v}) {
+					drop = true
+					break
+				}
+			}
+			if !drop {
+				kept = append(kept, c)
+			}
+		}
+		return kept, nil
+	})
+}
+
+// HTTPRetrieverConfig is an entry of retrieval.retrievers in agent.yaml.
+type HTTPRetrieverConfig struct {
+	// URL receives POST {"query", "top_k", "filter"} and answers
+	// {"chunks": [{"id", "source", "content", "metadata", "score"}]}
+	URL string `yaml:"url"`
+	// TopK is the number of chunks asked for (default: the agent's top_k)
+	TopK int `yaml:"top_k"`
+	// APIKeyEnv names the environment variable holding a bearer token
+	APIKeyEnv string `yaml:"api_key_env"`
+	// Timeout bounds each call, e.g. "5s" (default 10s)
+	Timeout string `yaml:"timeout"`
+}
+
+// HTTPRetriever is a ChunkRetriever backed by an external search service.
+type HTTPRetriever struct {
+	url    string
+	topK   int
+	apiKey string
+	http   *http.Client
+}
+
+// NewHTTPRetriever validates c and returns a retriever calling its URL.
+func NewHTTPRetriever(c HTTPRetrieverConfig) (*HTTPRetriever, error) {
+	if c.URL == "" {
+		return nil, errors.New("retriever url is required")
+	}
+	timeout := 10 * time.Second
+	if c.Timeout != "" {
+		d, err := time.ParseDuration(c.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("retriever %s: invalid timeout %q", c.URL, c.Timeout)
+		}
+		timeout = d
+	}
+	r := &HTTPRetriever{url: c.URL, topK: c.TopK, http: &http.Client{Timeout: timeout}}
+	if c.APIKeyEnv != "" {
+		r.apiKey = os.Getenv(c.APIKeyEnv)
+	}
+	return r, nil
+}
+
+// RetrieveChunks implements ChunkRetriever.
+func (r *HTTPRetriever) RetrieveChunks(ctx context.Context, query string, topK int, filter map[string]string) ([]vector.SearchResult, error) {
+	if r.topK > 0 {
+		topK = r.topK
+	}
+	body, err := json.Marshal(map[string]interface{}{"query": query, "top_k": topK, "filter": filter})
+	if err != nil {
+		return nil, fmt.Errorf("marshal retriever request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create retriever request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	}
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("retriever %s: %w", r.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("retriever %s returned %d: %s", r.url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out struct {
+		Chunks []struct {
+			ID       string            `json:"id"`
+			Source   string            `json:"source"`
+			Content  string            `json:"content"`
+			Metadata map[string]string `json:"metadata"`
+			Score    float32           `json:"score"`
+		} `json:"chunks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode retriever response: %w", err)
+	}
+	chunks := make([]vector.SearchResult, 0, len(out.Chunks))
+	for _, c := range out.Chunks {
+		if strings.TrimSpace(c.Content) == "" {
+			continue
+		}
+		chunks = append(chunks, vector.SearchResult{
+			ID: c.ID, Source: c.Source, Content: c.Content, Metadata: c.Metadata, Similarity: c.Score,
+		})
+	}
+	return chunks, nil
+}
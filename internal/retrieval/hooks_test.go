@@ -0,0 +1,199 @@
+package retrieval
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+func TestQueryTransformers(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		t     QueryTransformer
+		query string
+		want  string
+	}{
+		{"replace whole words", ReplaceWords(map[string]string{"k8s": "kubernetes", "k8s cluster": "kubernetes cluster"}), "Scale a K8S cluster on k8s", "Scale a kubernetes cluster on kubernetes"},
+		{"replace skips partial words", ReplaceWords(map[string]string{"pr": "pull request"}), "print the PR", "print the pull request"},
+		{"truncate at word boundary", TruncateQuery(12), "reset the router password", "reset the"},
+		{"truncate short query", TruncateQuery(50), "reset", "reset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.t.TransformQuery(ctx, tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestChunkFilters(t *testing.T) {
+	chunks := []vector.SearchResult{
+		{ID: "1", Source: "a.md", Metadata: map[string]string{"status": "current"}},
+		{ID: "2", Source: "a.md", Metadata: map[string]string{"status": "Archived"}},
+		{ID: "3", Source: "a.md"},
+		{ID: "4", Source: "b.md"},
+	}
+	ids := func(f ChunkFilter) []string {
+		out, err := f.FilterChunks(context.Background(), "q", chunks)
+		require.NoError(t, err)
+		var got []string
+		for _, c := range out {
+			got = append(got, c.ID)
+		}
+		return got
+	}
+	assert.Equal(t, []string{"1", "2", "4"}, ids(MaxPerSource(2)))
+	assert.Equal(t, []string{"1", "3", "4"}, ids(ExcludeMetadata(map[string]string{"status": "archived"})))
+	assert.Len(t, chunks, 4, "filters do not modify their input")
+}
+
+func TestHTTPRetriever(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+			TopK  int    `json:"top_k"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "Bearer s3cret", r.Header.Get("Authorization"))
+		assert.Equal(t, 3, req.TopK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"chunks": []map[string]interface{}{
+			{"id": "t-1", "source": "tickets", "content": "Answer to " + req.Query, "score": 0.7},
+			{"id": "t-2", "content": "  "},
+		}})
+	}))
+	defer srv.Close()
+	t.Setenv("SEARCH_TOKEN", "s3cret")
+
+	r, err := NewHTTPRetriever(HTTPRetrieverConfig{URL: srv.URL, TopK: 3, APIKeyEnv: "SEARCH_TOKEN"})
+	require.NoError(t, err)
+	chunks, err := r.RetrieveChunks(context.Background(), "refunds", 5, nil)
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "Answer to refunds", chunks[0].Content)
+	assert.Equal(t, float32(0.7), chunks[0].Similarity)
+
+	_, err = NewHTTPRetriever(HTTPRetrieverConfig{URL: srv.URL, Timeout: "soon"})
+	assert.ErrorContains(t, err, "invalid timeout")
+}
+
+func TestAgentYAMLHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "agent.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`retrieval:
+  top_k: 4
+  query:
+    max_length: 200
+    replace: {k8s: kubernetes}
+  filters:
+    max_per_source: 2
+`), 0644))
+	cfg, err := AgentYAMLHooks(path)
+	require.NoError(t, err)
+	hooks, err := cfg.Hooks()
+	require.NoError(t, err)
+	assert.Len(t, hooks.QueryTransformers, 2)
+	assert.Empty(t, hooks.Retrievers)
+	assert.Len(t, hooks.Filters, 1)
+
+	cfg, err = AgentYAMLHooks(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	hooks, err = cfg.Hooks()
+	require.NoError(t, err)
+	assert.Empty(t, hooks.Append(Hooks{}).QueryTransformers)
+}
+
+func TestRerankTrims(t *testing.T) {
+	var topN int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			TopN int `json:"top_n"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		topN = req.TopN
+		// Ignores top_n, as some APIs do
+		json.NewEncoder(w).Encode(map[string]interface{}{"results": []map[string]interface{}{
+			{"index": 2, "relevance_score": 0.9},
+			{"index": 0, "relevance_score": 0.4},
+			{"index": 1, "relevance_score": 0.1},
+		}})
+	}))
+	defer srv.Close()
+	reranker, err := llm.NewReranker(&config.ProviderConfig{BaseURL: srv.URL, Model: "r"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		cfg     RerankConfig
+		want    []string
+		dropped int
+	}{
+		{"keep all", RerankConfig{}, []string{"c", "a", "b"}, 0},
+		{"top n", RerankConfig{TopN: 2}, []string{"c", "a"}, 1},
+		{"min score", RerankConfig{MinRelevanceScore: 0.3}, []string{"c", "a"}, 1},
+		{"nothing relevant", RerankConfig{MinRelevanceScore: 0.95}, []string{}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := &Result{Query: "q", Chunks: []vector.SearchResult{{ID: "a"}, {ID: "b"}, {ID: "c"}}}
+			res.rerank(context.Background(), reranker, tt.cfg)
+			require.NoError(t, res.RerankErr)
+			got := []string{}
+			for _, c := range res.Chunks {
+				got = append(got, c.ID)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.dropped, res.RerankDropped)
+			assert.True(t, res.Reranked)
+			if tt.cfg.TopN > 0 {
+				assert.Equal(t, tt.cfg.TopN, topN)
+			} else {
+				assert.Equal(t, 3, topN)
+			}
+		})
+	}
+}
+
+func TestRerankFallsBack(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done() // hangs until the reranker gives up
+	}))
+	defer srv.Close()
+	reranker, err := llm.NewReranker(&config.ProviderConfig{BaseURL: srv.URL, Model: "r"})
+	require.NoError(t, err)
+	opts, err := RerankConfig{Timeout: "20ms", Retries: 1, Cooldown: "1h"}.RerankerOptions()
+	require.NoError(t, err)
+	reranker.Configure(opts)
+
+	for i := 1; i <= 4; i++ {
+		res := &Result{Query: "q", Chunks: []vector.SearchResult{{ID: "a"}, {ID: "b"}}}
+		res.rerank(context.Background(), reranker, RerankConfig{})
+		require.Error(t, res.RerankErr)
+		assert.False(t, res.Reranked)
+		assert.Len(t, res.Chunks, 2, "vector order is kept")
+		if i == 4 {
+			assert.ErrorIs(t, res.RerankErr, llm.ErrRerankerUnavailable)
+		}
+	}
+	assert.Equal(t, int32(6), calls.Load(), "three calls of two attempts, then none while cooling down")
+	assert.False(t, reranker.DownUntil().IsZero())
+
+	_, err = RerankConfig{Timeout: "soon"}.RerankerOptions()
+	assert.Error(t, err)
+}
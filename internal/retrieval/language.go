@@ -0,0 +1,108 @@
+package retrieval
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/akashicode/kash/internal/lang"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// Modes of language-aware retrieval.
+const (
+	// LanguageFilter drops chunks in another language than the query
+	LanguageFilter = "filter"
+	// LanguageBoost ranks chunks in the query's language first
+	LanguageBoost = "boost"
+)
+
+// DefaultLanguageBoost is the similarity LanguageBoost adds to a chunk in
+// the query's language when agent.yaml sets no boost.
+const DefaultLanguageBoost = 0.1
+
+// languageCandidates is how many times top_k vector results a
+// language-aware search considers, so dropping or demoting the chunks in
+// other languages still leaves top_k.
+const languageCandidates = 3
+
+// LanguageConfig is the language block under retrieval in agent.yaml. It
+// matches the language of the query, as detected or as the request gives
+// it, with the "language" metadata of the chunks. Chunks without one, such
+// as those of builds before chunks were tagged, always pass.
+type LanguageConfig struct {
+	// Mode is LanguageFilter or LanguageBoost; empty turns it off
+	Mode string `yaml:"mode"`
+	// Boost is the similarity LanguageBoost adds to a chunk in the query's
+	// language (default: DefaultLanguageBoost)
+	Boost float64 `yaml:"boost"`
+	// Query is the language of the query, e.g. "fr", instead of the one
+	// detected; set per request
+	Query string `yaml:"-"`
+}
+
+// Validate reports an unknown mode or a negative boost.
+func (c LanguageConfig) Validate() error {
+	switch c.Mode {
+	case "", LanguageFilter, LanguageBoost:
+	default:
+		return fmt.Errorf("language: unknown mode %q (want %s or %s)", c.Mode, LanguageFilter, LanguageBoost)
+	}
+	if c.Boost < 0 {
+		return fmt.Errorf("language: boost %g is negative", c.Boost)
+	}
+	return nil
+}
+
+// candidates is how many vector results to search for topK chunks.
+func (c LanguageConfig) candidates(topK int) int {
+	if c.Mode == "" {
+		return topK
+	}
+	return topK * languageCandidates
+}
+
+// matchLanguage keeps or ranks first the chunks in the query's language,
+// as cfg.Mode selects, and trims them to topK. It records the query's
+// language and the chunks dropped; when the language of the query cannot
+// be told, the chunks keep their order.
+func (r *Result) matchLanguage(chunks []vector.SearchResult, topK int, cfg LanguageConfig) []vector.SearchResult {
+	if cfg.Mode != "" {
+		r.Language = cfg.Query
+		if r.Language == "" {
+			r.Language = lang.Detect(r.Query)
+		}
+	}
+	if r.Language == "" {
+		return chunks[:min(topK, len(chunks))]
+	}
+	matches := func(c vector.SearchResult) bool {
+		code := c.Metadata[lang.MetadataKey]
+		return code == "" || code == r.Language
+	}
+
+	switch cfg.Mode {
+	case LanguageFilter:
+		kept := chunks[:0]
+		for _, c := range chunks {
+			if matches(c) {
+				kept = append(kept, c)
+			}
+		}
+		r.LanguageDropped = len(chunks) - len(kept)
+		chunks = kept
+	case LanguageBoost:
+		boost := cfg.Boost
+		if boost == 0 {
+			boost = DefaultLanguageBoost
+		}
+		score := func(c vector.SearchResult) float64 {
+			s := float64(c.Similarity)
+			if c.Metadata[lang.MetadataKey] == r.Language {
+				s += boost
+			}
+			return s
+		}
+		sort.SliceStable(chunks, func(i, j int) bool { return score(chunks[i]) > score(chunks[j]) })
+	}
+	return chunks[:min(topK, len(chunks))]
+}
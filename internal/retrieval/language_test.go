@@ -0,0 +1,66 @@
+package retrieval
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+func TestMatchLanguage(t *testing.T) {
+	chunks := func() []vector.SearchResult {
+		return []vector.SearchResult{
+			{ID: "en", Similarity: 0.82, Metadata: map[string]string{"language": "en"}},
+			{ID: "fr", Similarity: 0.78, Metadata: map[string]string{"language": "fr"}},
+			{ID: "untagged", Similarity: 0.60},
+			{ID: "fr-weak", Similarity: 0.40, Metadata: map[string]string{"language": "fr"}},
+		}
+	}
+	ids := func(chunks []vector.SearchResult) []string {
+		var out []string
+		for _, c := range chunks {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+	const query = "Comment les remboursements sont-ils effectués pour les commandes ?"
+
+	t.Run("filter drops other languages", func(t *testing.T) {
+		r := &Result{Query: query}
+		got := r.matchLanguage(chunks(), 3, LanguageConfig{Mode: LanguageFilter})
+		assert.Equal(t, []string{"fr", "untagged", "fr-weak"}, ids(got))
+		assert.Equal(t, "fr", r.Language)
+		assert.Equal(t, 1, r.LanguageDropped)
+	})
+
+	t.Run("boost ranks the query's language first", func(t *testing.T) {
+		r := &Result{Query: query}
+		got := r.matchLanguage(chunks(), 2, LanguageConfig{Mode: LanguageBoost})
+		assert.Equal(t, []string{"fr", "en"}, ids(got))
+		assert.Zero(t, r.LanguageDropped)
+	})
+
+	t.Run("request sets the query's language", func(t *testing.T) {
+		r := &Result{Query: query}
+		got := r.matchLanguage(chunks(), 4, LanguageConfig{Mode: LanguageFilter, Query: "en"})
+		assert.Equal(t, []string{"en", "untagged"}, ids(got))
+	})
+
+	t.Run("undetected query keeps the order", func(t *testing.T) {
+		r := &Result{Query: "Acme Cloud"}
+		got := r.matchLanguage(chunks(), 2, LanguageConfig{Mode: LanguageFilter})
+		assert.Equal(t, []string{"en", "fr"}, ids(got))
+		assert.Empty(t, r.Language)
+	})
+
+	t.Run("off", func(t *testing.T) {
+		r := &Result{Query: query}
+		got := r.matchLanguage(chunks(), 2, LanguageConfig{})
+		assert.Equal(t, []string{"en", "fr"}, ids(got))
+		assert.Empty(t, r.Language)
+	})
+
+	assert.Error(t, LanguageConfig{Mode: "prefer"}.Validate())
+	assert.Error(t, LanguageConfig{Mode: LanguageBoost, Boost: -1}.Validate())
+}
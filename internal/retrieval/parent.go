@@ -0,0 +1,66 @@
+package retrieval
+
+import (
+	"fmt"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// DefaultParentMaxTokens is the longest document ParentConfig returns whole
+// when it sets no max_tokens.
+const DefaultParentMaxTokens = 2000
+
+// ParentConfig is the parent_document block under retrieval in agent.yaml.
+type ParentConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// MaxTokens is the longest document returned whole, estimated at four
+	// characters a token; the chunks of longer documents are kept as they
+	// are (default: DefaultParentMaxTokens)
+	MaxTokens int `yaml:"max_tokens"`
+}
+
+// Documents holds the full text of the source documents, as 'kash build'
+// keeps it beside the vector index.
+type Documents interface {
+	// Size returns the length of the text of the document named name, and
+	// false when it is not stored
+	Size(name string) (int64, bool)
+	// Text returns the text of the document named name
+	Text(name string) (string, error)
+}
+
+// expandParents replaces each chunk with the whole document it came from,
+// when that is stored and within cfg.MaxTokens, so the LLM reads a short
+// document in full rather than the pieces of it that matched. A chunk whose
+// document was already returned whole is dropped, and one whose document
+// fails to read is kept, with the failure recorded in ParentErr.
+func (r *Result) expandParents(docs Documents, cfg ParentConfig) {
+	maxTokens := cfg.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultParentMaxTokens
+	}
+	whole := map[string]bool{}
+	out := make([]vector.SearchResult, 0, len(r.Chunks))
+	for _, c := range r.Chunks {
+		if whole[c.Source] {
+			continue
+		}
+		size, ok := docs.Size(c.Source)
+		// The estimate of llm.EstimateTokens, before reading the text
+		if !ok || (size+3)/4 > int64(maxTokens) {
+			out = append(out, c)
+			continue
+		}
+		text, err := docs.Text(c.Source)
+		if err != nil {
+			r.ParentErr = fmt.Errorf("read %s: %w", c.Source, err)
+			out = append(out, c)
+			continue
+		}
+		whole[c.Source] = true
+		c.Content = text
+		out = append(out, c)
+		r.Parents++
+	}
+	r.Chunks = out
+}
@@ -0,0 +1,59 @@
+package retrieval
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// fakeDocuments stores texts by name; a text "!" fails to read.
+type fakeDocuments map[string]string
+
+func (d fakeDocuments) Size(name string) (int64, bool) {
+	text, ok := d[name]
+	return int64(len(text)), ok
+}
+
+func (d fakeDocuments) Text(name string) (string, error) {
+	if d[name] == "!" {
+		return "", errors.New("corrupt")
+	}
+	return d[name], nil
+}
+
+func TestExpandParents(t *testing.T) {
+	docs := fakeDocuments{
+		"faq.md":    "Refunds take 14 days. Annual plans are prorated.",
+		"manual.md": strings.Repeat("A long manual. ", 1000),
+		"broken.md": "!",
+	}
+	res := &Result{Chunks: []vector.SearchResult{
+		{ID: "faq_1", Source: "faq.md", Content: "Refunds take 14 days."},
+		{ID: "manual_3", Source: "manual.md", Content: "A long manual."},
+		{ID: "faq_2", Source: "faq.md", Content: "Annual plans are prorated."},
+		{ID: "notes_0", Source: "notes.md", Content: "Not stored."},
+		{ID: "broken_0", Source: "broken.md", Content: "Kept."},
+	}}
+	res.expandParents(docs, ParentConfig{Enabled: true})
+
+	var got []string
+	for _, c := range res.Chunks {
+		got = append(got, c.ID+": "+c.Content)
+	}
+	assert.Equal(t, []string{
+		"faq_1: Refunds take 14 days. Annual plans are prorated.",
+		"manual_3: A long manual.",
+		"notes_0: Not stored.",
+		"broken_0: Kept.",
+	}, got, "a short document replaces its chunks; long, missing, and unreadable ones keep theirs")
+	assert.Equal(t, 1, res.Parents)
+	assert.ErrorContains(t, res.ParentErr, "broken.md")
+
+	res = &Result{Chunks: []vector.SearchResult{{ID: "manual_3", Source: "manual.md", Content: "A long manual."}}}
+	res.expandParents(docs, ParentConfig{Enabled: true, MaxTokens: 10_000})
+	assert.Equal(t, docs["manual.md"], res.Chunks[0].Content, "max_tokens admits longer documents")
+}
@@ -0,0 +1,345 @@
+// Package retrieval runs the hybrid search behind every answer: a vector
+// search over document chunks, a keyword search over knowledge graph triples,
+// and an optional reranking of the chunks, with Hooks to transform the query,
+// add retrievers, and filter the chunks.
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// Result counts used when agent.yaml sets no retrieval block.
+const (
+	DefaultTopK      = 5
+	DefaultGraphTopK = 10
+)
+
+// Options configures one search.
+type Options struct {
+	// TopK is the number of vector chunks (default: DefaultTopK)
+	TopK int
+	// GraphTopK is the number of graph triples (default: DefaultGraphTopK)
+	GraphTopK int
+	// Filter restricts chunks to those whose metadata matches it (see
+	// vector.MatchesFilter)
+	Filter map[string]string
+	// MinSimilarity drops vector results less similar to the query, so
+	// barely related chunks do not reach the LLM (default: keep all)
+	MinSimilarity float64
+	// Window widens each vector result with up to this many neighbouring
+	// chunks on each side in its source (default: DefaultSentenceWindow
+	// for sentence chunks, none for others; negative for none)
+	Window int
+	// Reranker reorders the chunks; nil keeps the vector order
+	Reranker *llm.Reranker
+	// Rerank trims the reranked chunks; it has no effect without a Reranker
+	Rerank RerankConfig
+	// Fusion orders the chunks and graph facts by one relevance score
+	Fusion FusionConfig
+	// Strategy merges the vector results with those of the retrievers and
+	// the graph: StrategyConcat (default) or StrategyRRF
+	Strategy string
+	// RRFK is the rank constant of StrategyRRF (default: DefaultRRFK)
+	RRFK int
+	// Compressor keeps the sentences of each chunk relevant to the query,
+	// after the filters; nil leaves the chunks whole
+	Compressor *llm.Client
+	// Compress bounds the compressed chunks; it has no effect without a
+	// Compressor
+	Compress CompressConfig
+	// Guard checks the filtered chunks for prompt injection
+	Guard GuardConfig
+	// Classifier has the guard check the chunks its patterns pass; nil
+	// checks with the patterns alone
+	Classifier *llm.Client
+	// Language filters or boosts the vector results in the query's language
+	Language LanguageConfig
+	// Parent returns the chunks of short documents as the whole document,
+	// after the filters; it has no effect without Documents
+	Parent ParentConfig
+	// Documents holds the full text of the sources; nil for none
+	Documents Documents
+	Hooks     Hooks
+}
+
+// RerankConfig is the rerank block under retrieval in agent.yaml.
+type RerankConfig struct {
+	// TopN keeps the N most relevant chunks (default: all of them)
+	TopN int `yaml:"top_n"`
+	// MinRelevanceScore drops chunks the reranker scores lower, so a query
+	// with nothing relevant gets no chunks rather than the least bad ones
+	MinRelevanceScore float64 `yaml:"min_relevance_score"`
+	// Timeout bounds each call to the reranker, e.g. "2s" (default 5s)
+	Timeout string `yaml:"timeout"`
+	// Retries is how often a call that timed out or failed with a 429 or
+	// 5xx is repeated (default 2; -1 for none)
+	Retries int `yaml:"retries"`
+	// Cooldown is how long the reranker is skipped after three calls in a
+	// row failed, e.g. "1m" (default 30s)
+	Cooldown string `yaml:"cooldown"`
+}
+
+// RerankerOptions converts the timeout, retries, and cooldown of c.
+func (c RerankConfig) RerankerOptions() (llm.RerankerOptions, error) {
+	opts := llm.RerankerOptions{Retries: c.Retries}
+	for _, d := range []struct {
+		name, value string
+		dst         *time.Duration
+	}{
+		{"timeout", c.Timeout, &opts.Timeout},
+		{"cooldown", c.Cooldown, &opts.Cooldown},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil || v <= 0 {
+			return opts, fmt.Errorf("rerank: invalid %s %q", d.name, d.value)
+		}
+		*d.dst = v
+	}
+	return opts, nil
+}
+
+// Result is what one hybrid search found for a query.
+type Result struct {
+	// Query is the query searched, after the query transformers
+	Query string
+	// Chunks are the vector results in the order they are given to the LLM
+	Chunks []vector.SearchResult
+	// Reranked is true when Chunks are in reranker order
+	Reranked bool
+	// RerankDropped counts the chunks the reranker ranked below
+	// RerankConfig.TopN or MinRelevanceScore
+	RerankDropped int
+	// BelowSimilarity counts the vector results dropped for a similarity
+	// below Options.MinSimilarity
+	BelowSimilarity int
+	// Fused is true when Chunks and Graph are ordered by their fused Score
+	Fused bool
+	// Language is the query's language when Options.Language matched the
+	// chunks against it; LanguageDropped counts the vector results in
+	// other languages that LanguageFilter dropped
+	Language        string
+	LanguageDropped int
+	// Compressed is true when Chunks hold only their sentences relevant to
+	// the query; CompressDropped counts the chunks with none, or beyond
+	// CompressConfig.MaxTokens
+	Compressed      bool
+	CompressDropped int
+	// Parents counts the chunks replaced by their whole document
+	Parents int
+	// Flagged are the chunks the guard found to look like a prompt
+	// injection, whatever it did with them
+	Flagged []FlaggedChunk
+	Graph   []graph.SearchResult
+	// GraphErr, RerankErr, CompressErr, GuardErr, and ParentErr are
+	// failures that did not fail the search: a failed graph search leaves
+	// Graph empty, a failed rerank keeps the vector order, a chunk that
+	// failed to compress is kept whole, one the classifier failed to check
+	// is passed, and one whose document failed to read is kept as it is
+	GraphErr    error
+	RerankErr   error
+	CompressErr error
+	GuardErr    error
+	ParentErr   error
+	// HookErrs are the failures of hooks that were skipped
+	HookErrs []error
+
+	// rerankScores are the reranker's scores of Chunks until fusion
+	rerankScores []float64
+}
+
+// Search runs the vector and graph searches for query and, when
+// opts.Reranker is set, reranks the vector results. Only a failed vector
+// search is an error.
+//
+// The hooks run around it: query transformers before the searches, extra
+// retrievers alongside the vector search, merged with its results as
+// opts.Strategy selects, and filters after reranking and fusion. Chunks of
+// short documents are then replaced by the whole document when opts.Parent
+// is enabled, the guard checks them when opts.Guard is enabled and, when
+// opts.Compressor is set, the chunks are compressed last.
+func Search(ctx context.Context, vectors *vector.Store, gdb *graph.DB, query string, opts Options) (*Result, error) {
+	topK, graphTopK := opts.TopK, opts.GraphTopK
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+	if graphTopK <= 0 {
+		graphTopK = DefaultGraphTopK
+	}
+
+	res := &Result{Query: query}
+	for _, t := range opts.Hooks.QueryTransformers {
+		q, err := t.TransformQuery(ctx, res.Query)
+		if err != nil {
+			res.HookErrs = append(res.HookErrs, fmt.Errorf("query transform: %w", err))
+			continue
+		}
+		if strings.TrimSpace(q) != "" {
+			res.Query = q
+		}
+	}
+
+	chunks, err := vectors.QueryLanguage(ctx, res.Query, opts.Language.Query, opts.Language.candidates(topK), opts.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("vector search: %w", err)
+	}
+	if opts.MinSimilarity > 0 {
+		kept := chunks[:0]
+		for _, c := range chunks {
+			if float64(c.Similarity) >= opts.MinSimilarity {
+				kept = append(kept, c)
+			}
+		}
+		res.BelowSimilarity = len(chunks) - len(kept)
+		chunks = kept
+	}
+	chunks = res.matchLanguage(chunks, topK, opts.Language)
+	chunks = expandWindows(ctx, vectors, chunks, opts.Window)
+	res.Graph, res.GraphErr = gdb.Search(ctx, res.Query, graphTopK)
+	if opts.Strategy == StrategyRRF {
+		res.Chunks = res.mergeRRF(ctx, chunks, topK, opts)
+	} else {
+		res.Chunks = res.retrieveMore(ctx, chunks, topK, opts)
+	}
+
+	if opts.Reranker != nil && len(res.Chunks) > 0 {
+		res.rerank(ctx, opts.Reranker, opts.Rerank)
+	}
+	if opts.Fusion.Enabled {
+		res.fuse(opts.Fusion.Weights)
+	}
+	res.rerankScores = nil
+	for _, f := range opts.Hooks.Filters {
+		filtered, err := f.FilterChunks(ctx, res.Query, res.Chunks)
+		if err != nil {
+			res.HookErrs = append(res.HookErrs, fmt.Errorf("chunk filter: %w", err))
+			continue
+		}
+		res.Chunks = filtered
+	}
+	if opts.Parent.Enabled && opts.Documents != nil {
+		res.expandParents(opts.Documents, opts.Parent)
+	}
+	if opts.Guard.Enabled && len(res.Chunks) > 0 {
+		res.guard(ctx, opts.Classifier, opts.Guard)
+	}
+	if opts.Compressor != nil && len(res.Chunks) > 0 {
+		res.compress(ctx, opts.Compressor, opts.Compress)
+	}
+	return res, nil
+}
+
+// retrieveMore appends the chunks of the extra retrievers to the vector
+// results, skipping chunks already found and those outside opts.Filter.
+func (r *Result) retrieveMore(ctx context.Context, chunks []vector.SearchResult, topK int, opts Options) []vector.SearchResult {
+	if len(opts.Hooks.Retrievers) == 0 {
+		return chunks
+	}
+	seen := make(map[string]bool, len(chunks))
+	for _, c := range chunks {
+		seen[c.ID] = true
+	}
+	for _, rt := range opts.Hooks.Retrievers {
+		more, err := rt.RetrieveChunks(ctx, r.Query, topK, opts.Filter)
+		if err != nil {
+			r.HookErrs = append(r.HookErrs, fmt.Errorf("retriever: %w", err))
+			continue
+		}
+		for _, c := range more {
+			if (c.ID != "" && seen[c.ID]) || !vector.MatchesFilter(c.Metadata, opts.Filter) {
+				continue
+			}
+			seen[c.ID] = true
+			chunks = append(chunks, c)
+		}
+	}
+	return chunks
+}
+
+// rerank reorders the chunks with reranker and keeps those cfg lets
+// through, keeping the order and every chunk on failure.
+func (r *Result) rerank(ctx context.Context, reranker *llm.Reranker, cfg RerankConfig) {
+	docs := make([]string, len(r.Chunks))
+	for i, c := range r.Chunks {
+		docs[i] = c.Content
+	}
+	ranked, err := reranker.RerankTop(ctx, r.Query, docs, cfg.TopN)
+	if err != nil {
+		r.RerankErr = err
+		return
+	}
+	reranked := make([]vector.SearchResult, 0, len(ranked))
+	r.rerankScores = make([]float64, 0, len(ranked))
+	for _, rk := range ranked {
+		if rk.RelevanceScore < cfg.MinRelevanceScore {
+			continue
+		}
+		reranked = append(reranked, r.Chunks[rk.Index])
+		r.rerankScores = append(r.rerankScores, rk.RelevanceScore)
+	}
+	r.RerankDropped = len(r.Chunks) - len(reranked)
+	r.Chunks, r.Reranked = reranked, true
+}
+
+// Format renders the chunks and triples as the context block given to the
+// LLM.
+func (r *Result) Format() string {
+	var sb strings.Builder
+
+	// Similarity scores are meaningless after reranking, so they are shown
+	// only in vector order; fusion shows its own score
+	if len(r.Chunks) > 0 {
+		sb.WriteString("## Relevant Knowledge\n\n")
+		for i, c := range r.Chunks {
+			if r.Fused {
+				sb.WriteString(fmt.Sprintf("**[%d] Source: %s** (relevance: %.2f)\n", i+1, Citation(c), c.Score))
+			} else if r.Reranked {
+				sb.WriteString(fmt.Sprintf("**[%d] Source: %s**\n", i+1, Citation(c)))
+			} else {
+				sb.WriteString(fmt.Sprintf("**[%d] Source: %s** (similarity: %.2f)\n", i+1, Citation(c), c.Similarity))
+			}
+			sb.WriteString(c.Content)
+			sb.WriteString("\n\n")
+		}
+	}
+
+	graphCtx := graph.FormatResults(r.Graph)
+	if r.Fused {
+		graphCtx = formatFusedGraph(r.Graph, r.Chunks)
+	}
+	if graphCtx != "" {
+		sb.WriteString("\n## Knowledge Graph Context\n\n")
+		sb.WriteString(graphCtx)
+	}
+	return sb.String()
+}
+
+// Citation describes where a chunk came from: its source file plus the
+// document title, date, and page or timestamp when known.
+func Citation(r vector.SearchResult) string {
+	var details []string
+	if title := r.Metadata["title"]; title != "" {
+		details = append(details, fmt.Sprintf("%q", title))
+	}
+	if date := r.Metadata["date"]; date != "" {
+		details = append(details, date)
+	}
+	if page := r.Metadata["page"]; page != "" {
+		details = append(details, "p. "+page)
+	} else if ts := r.Metadata["timestamp"]; ts != "" {
+		details = append(details, "at "+ts)
+	}
+	if len(details) == 0 {
+		return r.Source
+	}
+	return r.Source + " (" + strings.Join(details, ", ") + ")"
+}
@@ -0,0 +1,66 @@
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/chunker"
+	"github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+func TestSearchMinSimilarity(t *testing.T) {
+	// Texts about refunds embed along one axis, the weather along another,
+	// and anything else along the third
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		embedding := []float32{0, 0, 1}
+		switch {
+		case bytes.Contains(bytes.ToLower(body), []byte("refund")):
+			embedding = []float32{1, 0, 0}
+		case bytes.Contains(bytes.ToLower(body), []byte("weather")):
+			embedding = []float32{0, 1, 0}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"embedding": embedding}},
+		})
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store, err := vector.NewStore(&config.ProviderConfig{BaseURL: srv.URL, Dimensions: 3})
+	require.NoError(t, err)
+	require.NoError(t, store.AddChunks(ctx, []chunker.Chunk{
+		{ID: "refunds", Source: "billing.md", Content: "Refunds take 14 days."},
+		{ID: "weather", Source: "misc.md", Content: "The weather is sunny."},
+	}))
+	gdb, err := graph.NewDB()
+	require.NoError(t, err)
+
+	res, err := Search(ctx, store, gdb, "how long do refunds take", Options{MinSimilarity: 0.5})
+	require.NoError(t, err)
+	require.Len(t, res.Chunks, 1)
+	assert.Equal(t, "refunds", res.Chunks[0].ID)
+	assert.Equal(t, 1, res.BelowSimilarity)
+
+	res, err = Search(ctx, store, gdb, "who won the match", Options{MinSimilarity: 0.5})
+	require.NoError(t, err)
+	assert.Empty(t, res.Chunks)
+	assert.Empty(t, res.Format(), "nothing is left to give the LLM")
+
+	res, err = Search(ctx, store, gdb, "who won the match", Options{})
+	require.NoError(t, err)
+	assert.Len(t, res.Chunks, 2, "without a cutoff every chunk is kept")
+
+	_, err = HookConfig{MinSimilarity: 1.5}.Hooks()
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package retrieval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// Strategies for merging the ranked lists of one search.
+const (
+	// StrategyConcat appends the chunks of the extra retrievers to the
+	// vector results
+	StrategyConcat = "concat"
+	// StrategyRRF merges the vector results, the results of each retriever,
+	// and the graph's ranking with reciprocal rank fusion
+	StrategyRRF = "rrf"
+)
+
+// DefaultRRFK is the rank constant of reciprocal rank fusion. Larger values
+// flatten the difference between the top ranks and the rest.
+const DefaultRRFK = 60
+
+// ValidStrategy reports whether s names a merge strategy; empty selects
+// StrategyConcat.
+func ValidStrategy(s string) bool {
+	return s == "" || s == StrategyConcat || s == StrategyRRF
+}
+
+// mergeRRF merges the ranked lists of a search with reciprocal rank fusion:
+// a chunk scores the sum of 1/(k+rank) over the lists that hold it, so the
+// chunks several lists agree on come first. The lists are the vector
+// results, the results of each retriever, and the chunks found ranked by the
+// best graph fact they mention. Chunks outside opts.Filter are skipped.
+func (r *Result) mergeRRF(ctx context.Context, chunks []vector.SearchResult, topK int, opts Options) []vector.SearchResult {
+	lists := [][]vector.SearchResult{chunks}
+	for _, rt := range opts.Hooks.Retrievers {
+		more, err := rt.RetrieveChunks(ctx, r.Query, topK, opts.Filter)
+		if err != nil {
+			r.HookErrs = append(r.HookErrs, fmt.Errorf("retriever: %w", err))
+			continue
+		}
+		kept := more[:0:0]
+		for _, c := range more {
+			if vector.MatchesFilter(c.Metadata, opts.Filter) {
+				kept = append(kept, c)
+			}
+		}
+		lists = append(lists, kept)
+	}
+	k := opts.RRFK
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+
+	var merged []vector.SearchResult
+	scores := map[string]float64{}
+	add := func(list []vector.SearchResult) {
+		for rank, c := range list {
+			key := chunkKey(c)
+			if _, ok := scores[key]; !ok {
+				merged = append(merged, c)
+			}
+			scores[key] += 1 / float64(k+rank+1)
+		}
+	}
+	for _, list := range lists {
+		add(list)
+	}
+	add(r.graphRanking(merged))
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return scores[chunkKey(merged[i])] > scores[chunkKey(merged[j])]
+	})
+	return merged
+}
+
+// graphRanking orders the chunks that mention a graph fact by the rank of
+// the best fact they mention, leaving out the others.
+func (r *Result) graphRanking(chunks []vector.SearchResult) []vector.SearchResult {
+	if len(r.Graph) == 0 {
+		return nil
+	}
+	type ranked struct {
+		chunk vector.SearchResult
+		fact  int
+	}
+	var hits []ranked
+	for _, c := range chunks {
+		text := strings.ToLower(c.Content)
+		for j, f := range r.Graph {
+			if mentions(f, text) > 0 {
+				hits = append(hits, ranked{c, j})
+				break
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].fact < hits[j].fact })
+	out := make([]vector.SearchResult, len(hits))
+	for i, h := range hits {
+		out[i] = h.chunk
+	}
+	return out
+}
+
+// chunkKey identifies a chunk across lists: its ID, or its source and
+// content for retrievers that set no ID.
+func chunkKey(c vector.SearchResult) string {
+	if c.ID != "" {
+		return c.ID
+	}
+	return c.Source + "\x00" + c.Content
+}
@@ -0,0 +1,77 @@
+package retrieval
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// staticRetriever returns the same chunks for every query, or err.
+type staticRetriever struct {
+	chunks []vector.SearchResult
+	err    error
+}
+
+func (s staticRetriever) RetrieveChunks(context.Context, string, int, map[string]string) ([]vector.SearchResult, error) {
+	return s.chunks, s.err
+}
+
+func TestMergeRRF(t *testing.T) {
+	vectorHits := []vector.SearchResult{
+		{ID: "a", Content: "Plans start at $10."},
+		{ID: "b", Content: "Status page lists incidents."},
+		{ID: "c", Content: "Support answers tickets."},
+	}
+	opts := Options{Hooks: Hooks{Retrievers: []ChunkRetriever{
+		staticRetriever{chunks: []vector.SearchResult{
+			{ID: "c", Content: "Support answers tickets.", Metadata: map[string]string{"lang": "en"}},
+			{ID: "d", Content: "Annual plans get refunds within 30 days.", Metadata: map[string]string{"lang": "en"}},
+			{Source: "web", Content: "A page without an ID.", Metadata: map[string]string{"lang": "en"}},
+			{ID: "e", Content: "Auf Deutsch.", Metadata: map[string]string{"lang": "de"}},
+		}},
+		staticRetriever{err: errors.New("down")},
+	}}}
+	opts.Filter = map[string]string{"lang": "en"}
+	ids := func(chunks []vector.SearchResult) []string {
+		var out []string
+		for _, c := range chunks {
+			out = append(out, c.ID)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name  string
+		graph []graph.SearchResult
+		want  []string
+	}{
+		{"lists agree", nil, []string{"c", "a", "b", "d", ""}},
+		{"graph votes", []graph.SearchResult{{Subject: "Acme", Predicate: "offers", Object: "refunds"}}, []string{"d", "c", "a", "b", ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Result{Query: "q", Graph: tt.graph}
+			got := r.mergeRRF(context.Background(), vectorHits, 3, opts)
+			assert.Equal(t, tt.want, ids(got))
+			require.Len(t, r.HookErrs, 1)
+			assert.Contains(t, r.HookErrs[0].Error(), "down")
+		})
+	}
+
+	r := &Result{Query: "q"}
+	assert.Equal(t, []string{"a", "b", "c", "d", ""}, ids(r.retrieveMore(context.Background(), vectorHits, 3, opts)), "concat keeps the vector order")
+}
+
+func TestStrategyConfig(t *testing.T) {
+	_, err := HookConfig{Strategy: StrategyRRF, RRFK: 10}.Hooks()
+	require.NoError(t, err)
+	_, err = HookConfig{Strategy: "weighted"}.Hooks()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown retrieval strategy "weighted"`)
+}
@@ -0,0 +1,64 @@
+package retrieval
+
+import (
+	"context"
+
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// DefaultSentenceWindow is how many sentences on each side of a matched
+// sentence are returned with it when Options.Window is zero.
+const DefaultSentenceWindow = 2
+
+// expandWindows widens each vector result to a passage of up to window
+// chunks on each side of it in its source, so that a matched sentence
+// reaches the LLM with its surroundings. Sentence chunks, built with
+// chunking.strategy sentences, get DefaultSentenceWindow when window is
+// zero; a negative window expands nothing. A passage stops at a neighbour
+// in another section, e.g. on another page, or one already shown with a
+// better match, and a result shown that way is dropped.
+func expandWindows(ctx context.Context, vectors *vector.Store, chunks []vector.SearchResult, window int) []vector.SearchResult {
+	if window < 0 {
+		return chunks
+	}
+	shown := map[string]bool{}
+	out := make([]vector.SearchResult, 0, len(chunks))
+	for _, c := range chunks {
+		if shown[c.ID] {
+			continue
+		}
+		shown[c.ID] = true
+		n := window
+		if n == 0 && c.Metadata["unit"] == "sentence" {
+			n = DefaultSentenceWindow
+		}
+
+		passage := []vector.SearchResult{c}
+		for _, dir := range []int{-1, 1} {
+			for k := 1; k <= n; k++ {
+				nb, ok := vectors.Neighbour(ctx, c, dir*k)
+				if !ok || shown[nb.ID] || !sameSection(nb, c) {
+					break
+				}
+				shown[nb.ID] = true
+				if dir < 0 {
+					passage = append([]vector.SearchResult{nb}, passage...)
+				} else {
+					passage = append(passage, nb)
+				}
+			}
+		}
+		if len(passage) > 1 {
+			c.Content = passage[0].Content
+			for _, p := range passage[1:] {
+				if p.Metadata["unit"] == "sentence" {
+					c.Content += " " + p.Content
+				} else {
+					c.Content = joinOverlap(c.Content, p.Content)
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
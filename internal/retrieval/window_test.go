@@ -0,0 +1,88 @@
+package retrieval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/chunker"
+	"github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+func TestExpandWindows(t *testing.T) {
+	// Only texts about refunds embed close to the query
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		embedding := []float32{0, 1}
+		if bytes.Contains(bytes.ToLower(body), []byte("refund")) {
+			embedding = []float32{1, 0}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"embedding": embedding}},
+		})
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store, err := vector.NewStore(&config.ProviderConfig{BaseURL: srv.URL, Dimensions: 2})
+	require.NoError(t, err)
+	sentences := []string{
+		"Billing runs monthly.",
+		"Invoices are sent by email.",
+		"Refunds take 14 days.",
+		"Annual plans are prorated.",
+		"Support is open on weekdays.",
+		"See the pricing page.",
+	}
+	var chunks []chunker.Chunk
+	for i, s := range sentences {
+		ch := chunker.Chunk{ID: fmt.Sprintf("faq_%d", i), Source: "faq", Index: i, Content: s, Metadata: map[string]string{"unit": "sentence"}}
+		if i == 5 {
+			ch.Metadata["page"] = "2"
+		}
+		chunks = append(chunks, ch)
+	}
+	require.NoError(t, store.AddChunks(ctx, chunks))
+
+	hits, err := store.Query(ctx, "refunds", 6)
+	require.NoError(t, err)
+	require.Equal(t, "Refunds take 14 days.", hits[0].Content)
+
+	tests := []struct {
+		name   string
+		window int
+		want   []string
+	}{
+		{"sentence default", 0, []string{
+			"Billing runs monthly. Invoices are sent by email. Refunds take 14 days. Annual plans are prorated. Support is open on weekdays.",
+			"See the pricing page.",
+		}},
+		{"one each side", 1, []string{
+			"Invoices are sent by email. Refunds take 14 days. Annual plans are prorated.",
+		}},
+		{"off", -1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expandWindows(ctx, store, hits, tt.window)
+			if tt.window < 0 {
+				assert.Equal(t, hits, got)
+				return
+			}
+			require.GreaterOrEqual(t, len(got), len(tt.want))
+			for i, want := range tt.want {
+				assert.Equal(t, want, got[i].Content)
+			}
+			assert.Equal(t, hits[0].ID, got[0].ID, "a window keeps the matched sentence's ID")
+		})
+	}
+}
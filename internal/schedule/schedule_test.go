@@ -0,0 +1,43 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, time.March, 4, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, time.March, 4, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, time.March, 5, 3, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.March, 4, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2026, time.March, 5, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 * mon", time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2026, time.March, 4, 10, 25, 0, 0, time.UTC)},
+		{"@every 90m", from.Add(90 * time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, s.Next(from))
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * * * 8", "5-1 * * * *", "*/0 * * * *", "0 0 30 feb *", "@every 10s", "@often"} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,418 @@
+// Package selfupdate finds the latest kash release on GitHub, verifies the
+// archive for this platform against the release checksums (and their
+// signature when a release public key is compiled in), and replaces the
+// running binary in place.
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRepo is the GitHub repository kash releases are published to.
+const DefaultRepo = "akashicode/kash"
+
+// Release asset names written by the release workflow.
+const (
+	ChecksumsAsset = "checksums.txt"
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// maxDownloadSize bounds every download, so a bad response cannot fill the disk.
+const maxDownloadSize = 200 << 20
+
+// ErrNoSignature is returned when a public key is configured but the release
+// has no checksums signature.
+var ErrNoSignature = errors.New("release has no " + SignatureAsset)
+
+// Updater checks for and installs kash releases.
+type Updater struct {
+	// Repo is "owner/name" (default: DefaultRepo)
+	Repo string
+	// APIURL is the GitHub API base URL (default: https://api.github.com)
+	APIURL string
+	// Token is sent as a bearer token to raise GitHub's rate limit
+	Token string
+	// PublicKey verifies SignatureAsset; when nil, only checksums are verified
+	PublicKey ed25519.PublicKey
+	// HTTPClient defaults to a client with a 5 minute timeout
+	HTTPClient *http.Client
+	// GOOS and GOARCH select the asset (default: this platform)
+	GOOS, GOARCH string
+}
+
+// Release is a published kash release.
+type Release struct {
+	Tag        string
+	URL        string
+	Prerelease bool
+	// Assets maps asset names to download URLs
+	Assets map[string]string
+}
+
+// ParsePublicKey decodes a base64 DER (PKIX) Ed25519 public key, as printed by
+// 'openssl pkey -pubout -outform DER | base64'. An empty string returns nil.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	if s == "" {
+		return nil, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode release public key: %w", err)
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("parse release public key: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("release public key is %T, want Ed25519", key)
+	}
+	return pub, nil
+}
+
+// Latest returns the newest non-prerelease release.
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	var raw struct {
+		TagName    string `json:"tag_name"`
+		HTMLURL    string `json:"html_url"`
+		Prerelease bool   `json:"prerelease"`
+		Assets     []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/releases/latest", u.apiURL(), u.repo())
+	body, err := u.get(ctx, url, "application/vnd.github+json")
+	if err != nil {
+		return nil, fmt.Errorf("fetch latest release: %w", err)
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("parse latest release: %w", err)
+	}
+	if raw.TagName == "" {
+		return nil, errors.New("latest release has no tag")
+	}
+	rel := &Release{Tag: raw.TagName, URL: raw.HTMLURL, Prerelease: raw.Prerelease, Assets: map[string]string{}}
+	for _, a := range raw.Assets {
+		rel.Assets[a.Name] = a.URL
+	}
+	return rel, nil
+}
+
+// AssetName is the archive for this platform, e.g. kash_linux_amd64.tar.gz.
+func (u *Updater) AssetName() string {
+	goos, goarch := u.platform()
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("kash_%s_%s%s", goos, goarch, ext)
+}
+
+// Download fetches and verifies this platform's binary from rel.
+func (u *Updater) Download(ctx context.Context, rel *Release) ([]byte, error) {
+	asset := u.AssetName()
+	archiveURL, ok := rel.Assets[asset]
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s", rel.Tag, asset)
+	}
+	sumsURL, ok := rel.Assets[ChecksumsAsset]
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %s", rel.Tag, ChecksumsAsset)
+	}
+
+	sums, err := u.get(ctx, sumsURL, "")
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", ChecksumsAsset, err)
+	}
+	if u.PublicKey != nil {
+		sigURL, ok := rel.Assets[SignatureAsset]
+		if !ok {
+			return nil, ErrNoSignature
+		}
+		sig, err := u.get(ctx, sigURL, "")
+		if err != nil {
+			return nil, fmt.Errorf("download %s: %w", SignatureAsset, err)
+		}
+		if err := VerifySignature(u.PublicKey, sums, sig); err != nil {
+			return nil, err
+		}
+	}
+	want, err := checksumFor(sums, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := u.get(ctx, archiveURL, "")
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", asset, err)
+	}
+	got := sha256.Sum256(archive)
+	if hex.EncodeToString(got[:]) != want {
+		return nil, fmt.Errorf("checksum mismatch for %s: got %x, want %s", asset, got, want)
+	}
+	return extractBinary(asset, archive)
+}
+
+// VerifySignature checks sig, raw or base64-encoded, against data.
+func VerifySignature(pub ed25519.PublicKey, data, sig []byte) error {
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return fmt.Errorf("decode %s: %w", SignatureAsset, err)
+		}
+		sig = decoded
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return fmt.Errorf("%s signature is invalid", ChecksumsAsset)
+	}
+	return nil
+}
+
+// Replace swaps the executable at path for binary, keeping its file mode.
+// Elsewhere than on Windows, the new binary is renamed over the old one, so
+// path always holds one of them. Windows cannot replace or delete a running
+// executable, so there the current binary is first moved aside to
+// <path>.old, and put back if the new one cannot be installed.
+func Replace(path string, binary []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat %q: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".kash-upgrade-*")
+	if err != nil {
+		return fmt.Errorf("create temp file in %q: %w", dir, err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write new binary: %w", err)
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0o111); err != nil {
+		return fmt.Errorf("chmod new binary: %w", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		if err := os.Rename(tmpPath, path); err != nil {
+			return fmt.Errorf("install new binary: %w", err)
+		}
+		return nil
+	}
+	old := path + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(path, old); err != nil {
+		return fmt.Errorf("move current binary aside: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		// Put the current binary back so kash keeps working
+		_ = os.Rename(old, path)
+		return fmt.Errorf("install new binary: %w", err)
+	}
+	return nil
+}
+
+// Newer reports whether release tag latest is newer than current. Both are
+// semantic versions with an optional "v" prefix; a current version that is
+// not one (e.g. "dev") is never up to date.
+func Newer(latest, current string) bool {
+	l, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	c, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+	for i := range l.nums {
+		if l.nums[i] != c.nums[i] {
+			return l.nums[i] > c.nums[i]
+		}
+	}
+	// A release outranks its prereleases: v1.2.0 > v1.2.0-rc.1
+	if l.pre == "" || c.pre == "" {
+		return l.pre == "" && c.pre != ""
+	}
+	return comparePrerelease(l.pre, c.pre) > 0
+}
+
+// comparePrerelease orders prerelease strings such as "rc.9" and "rc.10" by
+// their dot-separated identifiers, numerically where both are numbers.
+func comparePrerelease(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return an - bn
+			}
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
+
+type version struct {
+	nums [3]int
+	pre  string
+}
+
+func parseVersion(s string) (version, bool) {
+	var v version
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	s, _, _ = strings.Cut(s, "+")
+	s, v.pre, _ = strings.Cut(s, "-")
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v.nums[i] = n
+	}
+	return v, true
+}
+
+// checksumFor finds the sha256sum line for asset.
+func checksumFor(sums []byte, asset string) (string, error) {
+	sc := bufio.NewScanner(bytes.NewReader(sums))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s has no entry for %s", ChecksumsAsset, asset)
+}
+
+// extractBinary returns the kash executable inside a release archive.
+func extractBinary(asset string, archive []byte) ([]byte, error) {
+	if strings.HasSuffix(asset, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", asset, err)
+		}
+		for _, f := range zr.File {
+			if filepath.Base(f.Name) != "kash.exe" {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("open kash.exe in %s: %w", asset, err)
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxDownloadSize))
+		}
+		return nil, fmt.Errorf("%s does not contain kash.exe", asset)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", asset, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s does not contain kash", asset)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", asset, err)
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == "kash" {
+			return io.ReadAll(io.LimitReader(tr, maxDownloadSize))
+		}
+	}
+}
+
+func (u *Updater) get(ctx context.Context, url, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if u.Token != "" && strings.HasPrefix(url, u.apiURL()) {
+		req.Header.Set("Authorization", "Bearer "+u.Token)
+	}
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("GET %s: response larger than %d bytes", url, maxDownloadSize)
+	}
+	return data, nil
+}
+
+func (u *Updater) repo() string {
+	if u.Repo != "" {
+		return u.Repo
+	}
+	return DefaultRepo
+}
+
+func (u *Updater) apiURL() string {
+	if u.APIURL != "" {
+		return strings.TrimRight(u.APIURL, "/")
+	}
+	return "https://api.github.com"
+}
+
+func (u *Updater) client() *http.Client {
+	if u.HTTPClient != nil {
+		return u.HTTPClient
+	}
+	return &http.Client{Timeout: 5 * time.Minute}
+}
+
+func (u *Updater) platform() (string, string) {
+	goos, goarch := u.GOOS, u.GOARCH
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	return goos, goarch
+}
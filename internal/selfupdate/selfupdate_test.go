@@ -0,0 +1,143 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.3.0", "v1.2.9", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.10.0", false},
+		{"v1.2.0", "v1.2.0-rc.1", true},
+		{"v1.2.0-rc.10", "v1.2.0-rc.9", true},
+		{"v1.2.0-rc.1", "v1.2.0", false},
+		{"v1.0.0", "dev", true},
+		{"nightly", "v1.0.0", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.latest+"_vs_"+tt.current, func(t *testing.T) {
+			assert.Equal(t, tt.want, Newer(tt.latest, tt.current))
+		})
+	}
+}
+
+// fakeRelease serves a GitHub release whose linux/amd64 archive contains
+// binary, with checksums signed by priv when priv is non-nil.
+func fakeRelease(t *testing.T, binary []byte, priv ed25519.PrivateKey, corrupt bool) *httptest.Server {
+	t.Helper()
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "kash", Mode: 0o755, Size: int64(len(binary)), Typeflag: tar.TypeReg}))
+	_, err := tw.Write(binary)
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	sum := sha256.Sum256(archive.Bytes())
+	sums := fmt.Sprintf("%x  kash_linux_amd64.tar.gz\n%x  kash_windows_amd64.zip\n", sum, sha256.Sum256(nil))
+	if corrupt {
+		archive.WriteString("tampered")
+	}
+
+	mux := http.NewServeMux()
+	var srv *httptest.Server
+	mux.HandleFunc("/repos/akashicode/kash/releases/latest", func(w http.ResponseWriter, r *http.Request) {
+		assets := fmt.Sprintf(`{"name":"kash_linux_amd64.tar.gz","browser_download_url":"%[1]s/a"},{"name":"checksums.txt","browser_download_url":"%[1]s/sums"}`, srv.URL)
+		if priv != nil {
+			assets += fmt.Sprintf(`,{"name":"checksums.txt.sig","browser_download_url":"%s/sig"}`, srv.URL)
+		}
+		fmt.Fprintf(w, `{"tag_name":"v9.9.9","html_url":"https://example.com/r","assets":[%s]}`, assets)
+	})
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) { w.Write(archive.Bytes()) })
+	mux.HandleFunc("/sums", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(sums)) })
+	mux.HandleFunc("/sig", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(sums)))))
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDownloadAndReplace(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	parsed, err := ParsePublicKey(base64.StdEncoding.EncodeToString(der))
+	require.NoError(t, err)
+
+	srv := fakeRelease(t, []byte("new kash"), priv, false)
+	u := &Updater{APIURL: srv.URL, PublicKey: parsed, GOOS: "linux", GOARCH: "amd64"}
+	rel, err := u.Latest(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "v9.9.9", rel.Tag)
+
+	binary, err := u.Download(context.Background(), rel)
+	require.NoError(t, err)
+	assert.Equal(t, "new kash", string(binary))
+
+	path := filepath.Join(t.TempDir(), "kash")
+	require.NoError(t, os.WriteFile(path, []byte("old kash"), 0o755))
+	require.NoError(t, Replace(path, binary))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new kash", string(data))
+	_, err = os.Stat(path + ".old")
+	assert.True(t, os.IsNotExist(err), "the previous binary is removed")
+}
+
+func TestDownloadRejectsBadReleases(t *testing.T) {
+	ctx := context.Background()
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		srv     *httptest.Server
+		key     ed25519.PublicKey
+		wantErr string
+	}{
+		{"checksum mismatch", fakeRelease(t, []byte("x"), nil, true), nil, "checksum mismatch"},
+		{"wrong signer", fakeRelease(t, []byte("x"), priv, false), otherPub, "signature is invalid"},
+		{"unsigned release", fakeRelease(t, []byte("x"), nil, false), otherPub, ErrNoSignature.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &Updater{APIURL: tt.srv.URL, PublicKey: tt.key, GOOS: "linux", GOARCH: "amd64"}
+			rel, err := u.Latest(ctx)
+			require.NoError(t, err)
+			_, err = u.Download(ctx, rel)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	u := &Updater{APIURL: tests[0].srv.URL, GOOS: "darwin", GOARCH: "arm64"}
+	rel, err := u.Latest(ctx)
+	require.NoError(t, err)
+	_, err = u.Download(ctx, rel)
+	assert.ErrorContains(t, err, "has no kash_darwin_arm64.tar.gz")
+}
@@ -1,9 +1,17 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+
+	"github.com/akashicode/kash/internal/a2a"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/moderation"
+	"github.com/akashicode/kash/internal/retrieval"
 )
 
 // A2ARequest is an Agent-to-Agent JSON-RPC request.
@@ -46,11 +54,13 @@ func (s *Server) handleA2A(w http.ResponseWriter, r *http.Request) {
 
 	switch req.Method {
 	case "agent.info":
-		result = s.a2aAgentInfo()
+		result = s.a2aAgentInfo(basePath(r))
 	case "agent.query":
 		result, rpcErr = s.a2aQuery(r, req.Params)
 	case "agent.search":
 		result, rpcErr = s.a2aSearch(r, req.Params)
+	case "agent.task":
+		result, rpcErr = s.a2aTask(r, req.Params)
 	default:
 		rpcErr = &A2AError{Code: -32601, Message: fmt.Sprintf("method not found: %s", req.Method)}
 	}
@@ -66,52 +76,143 @@ func (s *Server) handleA2A(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
-// a2aAgentInfo returns metadata about this agent.
-func (s *Server) a2aAgentInfo() map[string]interface{} {
+// a2aAgentInfo returns metadata about this agent. Endpoints are relative to
+// base, the agent's path prefix when several agents share the server.
+func (s *Server) a2aAgentInfo(base string) map[string]interface{} {
 	tools := s.buildMCPTools()
 	toolNames := make([]string, len(tools))
 	for i, t := range tools {
 		toolNames[i] = t.Name
 	}
+	st, release := s.acquireStores()
+	defer release()
 
 	return map[string]interface{}{
 		"name":        s.agentCfg.Agent.Name,
 		"description": s.agentCfg.Agent.Description,
 		"version":     "1.0.0",
 		"capabilities": map[string]interface{}{
-			"query":  true,
-			"search": true,
-			"stream": false,
+			"query":              true,
+			"search":             true,
+			"stream":             false,
+			"push_notifications": s.tasks != nil,
 		},
 		"tools":   toolNames,
-		"vectors": s.vectorStore.Count(),
-		"triples": s.graphDB.Count(),
+		"vectors": st.vectors.Count(),
+		"triples": st.graph.Count(),
 		"endpoints": map[string]string{
-			"rest": "/v1/chat/completions",
-			"mcp":  "/mcp",
-			"a2a":  "/rpc/agent",
+			"rest": base + "/v1/chat/completions",
+			"mcp":  base + "/mcp",
+			"a2a":  base + "/rpc/agent",
 		},
 	}
 }
 
+// a2aQueryParams are the params of agent.query.
+type a2aQueryParams struct {
+	Query        string                   `json:"query"`
+	SystemPrompt string                   `json:"system_prompt,omitempty"`
+	History      []map[string]interface{} `json:"history,omitempty"`
+	Filter       map[string]string        `json:"filter,omitempty"`
+	// PushNotification runs the query as a task that POSTs its updates to
+	// the callback
+	PushNotification *a2a.PushConfig `json:"push_notification,omitempty"`
+}
+
 // a2aQuery handles agent.query — a full chat-style query with context injection.
+// A query with a push_notification callback is answered in the background.
 func (s *Server) a2aQuery(r *http.Request, params json.RawMessage) (interface{}, *A2AError) {
-	var p struct {
-		Query        string                   `json:"query"`
-		SystemPrompt string                   `json:"system_prompt,omitempty"`
-		History      []map[string]interface{} `json:"history,omitempty"`
-	}
+	var p a2aQueryParams
 	if err := json.Unmarshal(params, &p); err != nil {
 		return nil, &A2AError{Code: -32602, Message: "invalid params: " + err.Error()}
 	}
 	if p.Query == "" {
 		return nil, &A2AError{Code: -32602, Message: "query is required"}
 	}
+	if p.PushNotification != nil {
+		return s.a2aStartTask(r, p)
+	}
+	return s.a2aAnswer(r.Context(), p, func(string) {})
+}
 
-	ctx := r.Context()
+// a2aStartTask returns a submitted task that answers p in the background and
+// POSTs its progress and answer to p's callback.
+func (s *Server) a2aStartTask(r *http.Request, p a2aQueryParams) (interface{}, *A2AError) {
+	if s.tasks == nil {
+		return nil, &A2AError{Code: -32003, Message: "push notifications are not supported"}
+	}
+	task, err := s.tasks.Start(requestKeyID(r), *p.PushNotification)
+	if errors.Is(err, a2a.ErrTooManyTasks) {
+		return nil, &A2AError{Code: -32005, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, &A2AError{Code: -32602, Message: "invalid params: " + err.Error()}
+	}
+	s.log.Info("A2A task started", "task", task.ID, "query", p.Query)
+
+	// The updates outlive the query, so the failure of one that timed out
+	// is still delivered
+	ctx := context.WithoutCancel(r.Context())
+	release := holdRecord(ctx)
+	go func() {
+		defer release()
+		queryCtx, cancel := context.WithTimeout(ctx, a2a.TaskTimeout)
+		defer cancel()
+		update := func(state, message string, artifacts []a2a.Artifact) {
+			if err := s.tasks.Update(ctx, task.ID, state, message, artifacts); err != nil {
+				s.log.Warn("A2A push notification failed", "task", task.ID, "error", err)
+			}
+		}
+		result, rpcErr := s.a2aAnswer(queryCtx, p, func(message string) {
+			update(a2a.TaskWorking, message, nil)
+		})
+		if rpcErr != nil {
+			update(a2a.TaskFailed, rpcErr.Message, nil)
+			return
+		}
+		answer, _ := result["answer"].(string)
+		update(a2a.TaskCompleted, "", []a2a.Artifact{{
+			Name:  "answer",
+			Parts: []a2a.Part{{Kind: "text", Text: answer}, {Kind: "data", Data: result}},
+		}})
+	}()
+	return task, nil
+}
+
+// a2aTask handles agent.task — the state of a task agent.query started,
+// for callers that missed its push notifications.
+func (s *Server) a2aTask(r *http.Request, params json.RawMessage) (interface{}, *A2AError) {
+	var p struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &A2AError{Code: -32602, Message: "invalid params: " + err.Error()}
+	}
+	if s.tasks == nil {
+		return nil, &A2AError{Code: -32003, Message: "push notifications are not supported"}
+	}
+	task, ok := s.tasks.Get(requestKeyID(r), p.ID)
+	if !ok {
+		return nil, &A2AError{Code: -32001, Message: "task not found"}
+	}
+	return task, nil
+}
+
+// a2aAnswer answers p, calling progress as it searches and generates. A
+// query or answer that moderation blocks is answered with the refusal and
+// the verdict, and a checked answer comes with its grounding report.
+func (s *Server) a2aAnswer(ctx context.Context, p a2aQueryParams, progress func(message string)) (map[string]interface{}, *A2AError) {
+	if v := s.moderate(ctx, moderation.Input, p.Query); v.Blocked() {
+		return map[string]interface{}{
+			"answer":     s.refuse(ctx),
+			"agent":      s.agentCfg.Agent.Name,
+			"moderation": v,
+		}, nil
+	}
 
 	// Run hybrid search
-	retrievedCtx, err := s.hybridSearch(ctx, p.Query)
+	progress("searching the knowledge base")
+	retrievedCtx, err := s.hybridSearch(ctx, p.Query, p.Filter)
 	if err != nil {
 		retrievedCtx = ""
 	}
@@ -134,25 +235,48 @@ func (s *Server) a2aQuery(r *http.Request, params json.RawMessage) (interface{},
 	}
 	messages = append(messages, map[string]string{"role": "user", "content": p.Query})
 
-	// Call LLM (simplified via Complete)
-	answer, err := s.llmClient.Complete(ctx, systemPrompt+"\n\n"+retrievedCtx, p.Query)
-	if err != nil {
-		s.log.Error("A2A LLM call failed", "error", err)
-		return nil, &A2AError{Code: -32603, Message: "upstream LLM request failed"}
+	// Call LLM (simplified via Complete), unless nothing was retrieved and
+	// retrieval.no_context sends a fixed reply
+	var answer checkedAnswer
+	if retrievedCtx == "" && err == nil {
+		answer.text = s.noContextReply()
+	}
+	if answer.text == "" {
+		progress("generating the answer")
+		raw, err := s.llmClient.Complete(ctx, systemPrompt+"\n\n"+retrievedCtx, p.Query)
+		if err != nil {
+			s.log.Error("A2A LLM call failed", "error", err)
+			return nil, &A2AError{Code: -32603, Message: "upstream LLM request failed"}
+		}
+		answer = s.checkAnswer(ctx, retrievedCtx, raw, func(note string) (string, error) {
+			return s.llmClient.Complete(ctx, systemPrompt+"\n\n"+retrievedCtx+"\n\n"+note, p.Query)
+		})
 	}
 
-	return map[string]interface{}{
-		"answer":  answer,
+	recordResponse(ctx, answer.text)
+
+	result := map[string]interface{}{
+		"answer":  answer.text,
 		"context": retrievedCtx,
 		"agent":   s.agentCfg.Agent.Name,
-	}, nil
+	}
+	if answer.moderation != nil {
+		result["moderation"] = answer.moderation
+	}
+	if answer.grounding != nil {
+		result["grounding"] = answer.grounding
+	}
+	return result, nil
 }
 
 // a2aSearch handles agent.search — raw knowledge retrieval without LLM.
+// With explain set, each result says which graph facts it matches, what the
+// reranker scores it, and which retrieval thresholds would cut it.
 func (s *Server) a2aSearch(r *http.Request, params json.RawMessage) (interface{}, *A2AError) {
 	var p struct {
-		Query string `json:"query"`
-		TopK  int    `json:"top_k,omitempty"`
+		Query   string `json:"query"`
+		TopK    int    `json:"top_k,omitempty"`
+		Explain bool   `json:"explain,omitempty"`
 	}
 	if err := json.Unmarshal(params, &p); err != nil {
 		return nil, &A2AError{Code: -32602, Message: "invalid params: " + err.Error()}
@@ -161,32 +285,67 @@ func (s *Server) a2aSearch(r *http.Request, params json.RawMessage) (interface{}
 		return nil, &A2AError{Code: -32602, Message: "query is required"}
 	}
 	if p.TopK <= 0 {
-		p.TopK = 5
+		p.TopK = s.topK()
 	}
 
 	ctx := r.Context()
+	st, release := s.acquireStores()
+	defer release()
 
-	vectorResults, err := s.vectorStore.Query(ctx, p.Query, p.TopK)
+	vectorResults, err := st.vectors.Query(ctx, p.Query, p.TopK)
 	if err != nil {
 		return nil, &A2AError{Code: -32603, Message: "vector search error: " + err.Error()}
 	}
 
-	graphResults, _ := s.graphDB.Search(ctx, p.Query, p.TopK*2)
+	graphResults, _ := st.graph.Search(ctx, p.Query, p.TopK*2)
+	recordQuery(ctx, p.Query, nil, vectorResults)
 
 	results := make([]map[string]interface{}, len(vectorResults))
+	var returned strings.Builder
 	for i, r := range vectorResults {
 		results[i] = map[string]interface{}{
 			"content":    r.Content,
 			"source":     r.Source,
 			"similarity": r.Similarity,
+			"metadata":   r.Metadata,
 		}
+		returned.WriteString(r.Content)
 	}
+	recordResponse(ctx, returned.String())
 
-	return map[string]interface{}{
+	resp := map[string]interface{}{
 		"vector_results": results,
 		"graph_results":  graphResults,
 		"query":          p.Query,
-	}, nil
+	}
+	if !p.Explain {
+		return resp, nil
+	}
+
+	rc := s.agentCfg.Retrieval
+	opts := retrieval.Options{TopK: s.topK(), MinSimilarity: rc.MinSimilarity}
+	if s.rerankerActive() {
+		opts.Reranker, opts.Rerank = s.reranker, rc.Rerank
+	}
+	explanations, err := retrieval.Explain(ctx, p.Query, vectorResults, graphResults, opts)
+	if err != nil {
+		s.log.Warn("explaining search results without rerank scores", "error", err)
+	}
+	for i, e := range explanations {
+		results[i]["explanation"] = e
+	}
+	facts := make([]map[string]interface{}, len(graphResults))
+	for i, f := range graphResults {
+		facts[i] = map[string]interface{}{
+			"subject":       f.Subject,
+			"predicate":     f.Predicate,
+			"object":        f.Object,
+			"score":         f.Score,
+			"matched_terms": graph.MatchedTerms(p.Query, f),
+		}
+	}
+	resp["graph_results"] = facts
+	return resp, nil
 }
 
 func writeA2AError(w http.ResponseWriter, id interface{}, code int, msg string) {
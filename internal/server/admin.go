@@ -0,0 +1,433 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/webhook"
+)
+
+// AdminPrefix is the path of the admin API. It is enabled by AGENT_ADMIN_KEY
+// and authenticated with that key instead of AGENT_API_KEY.
+const AdminPrefix = "/admin/"
+
+// apiKeys holds the API key clients authenticate with and, for a grace period
+// after a rotation, the key it replaced.
+type apiKeys struct {
+	mu        sync.RWMutex
+	current   string
+	previous  string
+	prevUntil time.Time
+}
+
+// enabled reports whether requests must carry an API key.
+func (k *apiKeys) enabled() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current != ""
+}
+
+// valid reports whether key is the current key or a rotated-out key still in
+// its grace period.
+func (k *apiKeys) valid(key string) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if key == "" {
+		return false
+	}
+	if secretEqual(key, k.current) {
+		return true
+	}
+	return k.previous != "" && time.Now().Before(k.prevUntil) && secretEqual(key, k.previous)
+}
+
+// rotate makes next the current key. The old key keeps working for grace.
+func (k *apiKeys) rotate(next string, grace time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.previous, k.prevUntil = k.current, time.Now().Add(grace)
+	k.current = next
+}
+
+func secretEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// reingestStatus reports the latest re-ingest started through the admin API
+// or the schedule.
+type reingestStatus struct {
+	Running bool `json:"running"`
+	// Trigger is what started the re-ingest: "admin" or "schedule"
+	Trigger    string     `json:"trigger,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// Unchanged is set when the sources had not changed since the last
+	// build, so nothing was rebuilt or reloaded
+	Unchanged bool   `json:"unchanged,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Output is the tail of the build log
+	Output string `json:"output,omitempty"`
+	// NextScheduled is when the scheduled re-ingest runs next
+	NextScheduled *time.Time `json:"next_scheduled,omitempty"`
+}
+
+// ReingestOptions are passed to the Config.Reingest hook.
+type ReingestOptions struct {
+	// IfChanged asks the hook to leave the stores alone when the sources
+	// have not changed since the last build
+	IfChanged bool
+}
+
+// reingestOutputLimit caps the build log kept in reingestStatus.Output.
+const reingestOutputLimit = 8 << 10
+
+func (s *Server) registerAdminRoutes() {
+	s.mux.Handle(AdminPrefix+"config", s.requireAdmin(s.handleAdminConfig))
+	s.mux.Handle(AdminPrefix+"reingest", s.requireAdmin(s.handleAdminReingest))
+	s.mux.Handle(AdminPrefix+"snapshots", s.requireAdmin(s.handleAdminSnapshots))
+	s.mux.Handle(AdminPrefix+"rollback", s.requireAdmin(s.handleAdminRollback))
+	s.mux.Handle(AdminPrefix+"cache/flush", s.requireAdmin(s.handleAdminFlush))
+	s.mux.Handle(AdminPrefix+"keys/rotate", s.requireAdmin(s.handleAdminRotateKey))
+	s.mux.Handle(AdminPrefix+"reranker", s.requireAdmin(s.handleAdminReranker))
+	s.mux.Handle(AdminPrefix+"usage", s.requireAdmin(s.handleAdminUsage))
+	s.mux.Handle(AdminPrefix+"log-level", s.requireAdmin(s.handleAdminLogLevel))
+}
+
+func isAdminPath(path string) bool {
+	return strings.HasPrefix(path, AdminPrefix)
+}
+
+// requireAdmin rejects requests without Authorization: Bearer <AGENT_ADMIN_KEY>.
+// The admin API is off, and answers 404, when no admin key is set.
+func (s *Server) requireAdmin(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.adminKey == "" {
+			writeJSONStatus(w, http.StatusNotFound, map[string]string{"error": "admin API is disabled — set AGENT_ADMIN_KEY to enable it"})
+			return
+		}
+		key, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !secretEqual(key, s.adminKey) {
+			writeJSONStatus(w, http.StatusUnauthorized, map[string]string{"error": "invalid or missing admin key — pass via Authorization: Bearer <AGENT_ADMIN_KEY>"})
+			return
+		}
+		next(w, r)
+	})
+}
+
+// handleAdminConfig serves GET /admin/config: the effective provider config
+// with secrets redacted, the agent.yaml in use, and runtime state that the
+// admin API can change.
+func (s *Server) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	settings := map[string]string{}
+	for _, k := range agentconfig.Keys() {
+		v, err := s.appCfg.Get(k.Name)
+		if err != nil || v == "" {
+			continue
+		}
+		if k.Secret {
+			v = agentconfig.Redact(v)
+		}
+		settings[k.Name] = v
+	}
+
+	var agentYAML map[string]interface{}
+	if data, err := os.ReadFile(s.agentYAMLPath); err == nil {
+		yaml.Unmarshal(data, &agentYAML)
+	}
+
+	st, release := s.acquireStores()
+	defer release()
+	writeJSON(w, map[string]interface{}{
+		"config": settings,
+		"agent":  agentYAML,
+		"runtime": map[string]interface{}{
+			"auth_enabled":        s.keys.enabled(),
+			"reranker_configured": s.reranker != nil,
+			"reranker_enabled":    s.rerankerActive(),
+			"peers":               len(s.peers),
+			"vectors":             st.vectors.Count(),
+			"triples":             st.graph.Count(),
+			"vector_store":        s.vectorPath,
+			"graph_db":            s.graphPath,
+			"agent_yaml":          s.agentYAMLPath,
+			"reingest_available":  s.reingest != nil,
+		},
+	})
+}
+
+// handleAdminReingest starts a rebuild of the knowledge base on POST and
+// reports its progress on GET. The stores are reloaded when it succeeds.
+func (s *Server) handleAdminReingest(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.reingestMu.Lock()
+		status := s.reingestState
+		s.reingestMu.Unlock()
+		if s.schedule != nil && s.reingest != nil {
+			next := s.schedule.Next(time.Now())
+			status.NextScheduled = &next
+		}
+		writeJSON(w, status)
+	case http.MethodPost:
+		if s.reingest == nil {
+			writeJSONStatus(w, http.StatusNotImplemented, map[string]string{"error": "re-ingest is not available in this server"})
+			return
+		}
+		status, started := s.startReingest("admin", ReingestOptions{})
+		if !started {
+			writeJSONStatus(w, http.StatusConflict, status)
+			return
+		}
+		writeJSONStatus(w, http.StatusAccepted, status)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// startReingest starts a re-ingest in the background unless one is already
+// running, and returns the re-ingest status.
+func (s *Server) startReingest(trigger string, opts ReingestOptions) (status reingestStatus, started bool) {
+	s.reingestMu.Lock()
+	defer s.reingestMu.Unlock()
+	if s.reingestState.Running {
+		return s.reingestState, false
+	}
+	now := time.Now().UTC()
+	s.reingestState = reingestStatus{Running: true, Trigger: trigger, StartedAt: &now}
+	go s.runReingest(opts)
+	return s.reingestState, true
+}
+
+// runReingest runs the re-ingest hook and records the outcome. It is not tied
+// to the request that started it, which returns immediately.
+func (s *Server) runReingest(opts ReingestOptions) {
+	s.log.Info("re-ingest started", "trigger", s.reingestTrigger())
+	var out bytes.Buffer
+	unchanged, err := s.reingestAndReload(&out, opts)
+
+	s.reingestMu.Lock()
+	defer s.reingestMu.Unlock()
+	now := time.Now().UTC()
+	s.reingestState.Running = false
+	s.reingestState.FinishedAt = &now
+	log := out.String()
+	if len(log) > reingestOutputLimit {
+		log = log[len(log)-reingestOutputLimit:]
+	}
+	s.reingestState.Output = log
+	took := now.Sub(*s.reingestState.StartedAt)
+	if err != nil {
+		s.reingestState.Error = err.Error()
+		s.log.Error("re-ingest failed", "error", err)
+		s.notify(webhook.IngestFailed, "re-ingest failed", err, map[string]interface{}{"duration_ms": took.Milliseconds()})
+		return
+	}
+	if unchanged {
+		s.reingestState.Unchanged = true
+		s.log.Info("re-ingest found no changes", "took", took.Round(time.Second))
+		return
+	}
+	s.log.Info("re-ingest finished", "took", took.Round(time.Second))
+	st, release := s.acquireStores()
+	defer release()
+	s.notify(webhook.IngestCompleted, fmt.Sprintf("re-ingest finished in %s", took.Round(time.Second)), nil, map[string]interface{}{
+		"vectors":     st.vectors.Count(),
+		"triples":     st.graph.Count(),
+		"duration_ms": took.Milliseconds(),
+	})
+}
+
+func (s *Server) reingestTrigger() string {
+	s.reingestMu.Lock()
+	defer s.reingestMu.Unlock()
+	return s.reingestState.Trigger
+}
+
+// reingestAndReload runs the re-ingest hook, then loads the new stores. With
+// opts.IfChanged, a hook that left the manifest untouched rebuilt nothing and
+// the stores are kept.
+func (s *Server) reingestAndReload(out io.Writer, opts ReingestOptions) (unchanged bool, err error) {
+	defer s.recoverTask("re-ingest", &err)
+	before := modTime(s.manifestPath)
+	if err := s.reingest(context.Background(), out, opts); err != nil {
+		return false, err
+	}
+	if opts.IfChanged && !before.IsZero() && modTime(s.manifestPath).Equal(before) {
+		return true, nil
+	}
+	return false, s.Reload()
+}
+
+// handleAdminFlush serves POST /admin/cache/flush. The compiled stores are
+// the runtime's only cache: they are dropped and loaded again from disk.
+func (s *Server) handleAdminFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := s.Reload(); err != nil {
+		writeJSONStatus(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	st, release := s.acquireStores()
+	defer release()
+	writeJSON(w, map[string]interface{}{
+		"status":  "reloaded",
+		"vectors": st.vectors.Count(),
+		"triples": st.graph.Count(),
+	})
+}
+
+// handleAdminRotateKey serves POST /admin/keys/rotate. The body may set the
+// new key and a grace period during which the old key is still accepted:
+//
+//	{"key": "...", "grace": "10m"}
+//
+// Without a key one is generated. The new key lasts until the server
+// restarts; update AGENT_API_KEY to keep it.
+func (s *Server) handleAdminRotateKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Key   string `json:"key"`
+		Grace string `json:"grace"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var grace time.Duration
+	if req.Grace != "" {
+		d, err := time.ParseDuration(req.Grace)
+		if err != nil || d < 0 {
+			http.Error(w, "invalid grace duration: "+req.Grace, http.StatusBadRequest)
+			return
+		}
+		grace = d
+	}
+	if req.Key == "" {
+		key, err := generateAPIKey()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		req.Key = key
+	}
+	if secretEqual(req.Key, s.adminKey) {
+		http.Error(w, "the API key must differ from the admin key", http.StatusBadRequest)
+		return
+	}
+
+	s.keys.rotate(req.Key, grace)
+	s.log.Info("API key rotated", "grace", grace)
+	resp := map[string]interface{}{"api_key": req.Key}
+	if grace > 0 {
+		resp["previous_valid_until"] = time.Now().Add(grace).UTC().Format(time.RFC3339)
+	}
+	writeJSON(w, resp)
+}
+
+func generateAPIKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "kash_" + hex.EncodeToString(b), nil
+}
+
+// handleAdminReranker reports the reranker state on GET and switches it on
+// or off on POST with {"enabled": true|false}.
+func (s *Server) handleAdminReranker(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+			http.Error(w, `request body must be {"enabled": true|false}`, http.StatusBadRequest)
+			return
+		}
+		if *req.Enabled && s.reranker == nil {
+			writeJSONStatus(w, http.StatusConflict, map[string]string{"error": "no reranker is configured — set RERANK_BASE_URL, or RERANK_PROVIDER=local"})
+			return
+		}
+		s.rerankerOff.Store(!*req.Enabled)
+		s.log.Info("reranker toggled", "enabled", *req.Enabled)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rc := s.agentCfg.Retrieval.Rerank
+	resp := map[string]interface{}{
+		"configured":          s.reranker != nil,
+		"enabled":             s.rerankerActive(),
+		"top_n":               rc.TopN,
+		"min_relevance_score": rc.MinRelevanceScore,
+	}
+	if s.reranker != nil {
+		if until := s.reranker.DownUntil(); !until.IsZero() {
+			resp["unavailable_until"] = until.UTC().Format(time.RFC3339)
+		}
+	}
+	writeJSON(w, resp)
+}
+
+// handleAdminLogLevel serves GET/POST /admin/log-level. POST takes
+// {"level": "debug"}; the change applies to every agent sharing the logger.
+func (s *Server) handleAdminLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, `request body must be {"level": "debug|info|warn|error"}`, http.StatusBadRequest)
+			return
+		}
+		if err := s.logger.SetLevel(req.Level); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.log.Warn("log level changed", "level", s.logger.Level())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, map[string]string{"level": s.logger.Level()})
+}
+
+// rerankerActive reports whether results are reranked: a reranker is
+// configured and has not been switched off through the admin API.
+func (s *Server) rerankerActive() bool {
+	return s.reranker != nil && !s.rerankerOff.Load()
+}
+
+func writeJSONStatus(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/akashicode/kash/internal/grounding"
+	"github.com/akashicode/kash/internal/moderation"
+)
+
+// checkedAnswer is an answer with what moderation and the grounding check
+// found.
+type checkedAnswer struct {
+	text string
+	// moderation is set when moderation blocked the question or answer,
+	// and text is the refusal
+	moderation *moderation.Verdict
+	// grounding is nil unless the answer's grounding was checked
+	grounding *grounding.Report
+}
+
+// completion is a chat completion with the moderation verdict that replaced
+// its answer with a refusal and the grounding check of its answer, if any.
+type completion struct {
+	openai.ChatCompletionResponse
+	Moderation *moderation.Verdict `json:"moderation,omitempty"`
+	Grounding  *grounding.Report   `json:"grounding,omitempty"`
+}
+
+// streamChunk is a streamed chat completion chunk; the last one carries
+// the grounding check of the answer, if any.
+type streamChunk struct {
+	openai.ChatCompletionStreamResponse
+	Grounding *grounding.Report `json:"grounding,omitempty"`
+}
+
+// streamReader reads back the events handleStreamingCompletion emits, for
+// the interfaces that send an answer in a shape of their own: the WebSocket
+// chat and gRPC.
+type streamReader struct {
+	text         strings.Builder
+	finishReason openai.FinishReason
+	grounding    *grounding.Report
+	// done is set by the end of the answer, failed by an error in its place
+	done, failed bool
+}
+
+// read parses one event and returns the piece of the answer it carries, or
+// the error that ended the stream.
+func (sr *streamReader) read(data []byte) (token string, err error) {
+	if string(data) == "[DONE]" {
+		sr.done = true
+		if sr.finishReason == "" {
+			sr.finishReason = openai.FinishReasonStop
+		}
+		return "", nil
+	}
+	var chunk streamChunk
+	if err := json.Unmarshal(data, &chunk); err != nil || len(chunk.Choices) == 0 {
+		var failed struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal(data, &failed)
+		sr.failed = true
+		return "", errors.New(failed.Error)
+	}
+	choice := chunk.Choices[0]
+	if choice.FinishReason != "" {
+		sr.finishReason = choice.FinishReason
+	}
+	if chunk.Grounding != nil {
+		sr.grounding = chunk.Grounding
+	}
+	sr.text.WriteString(choice.Delta.Content)
+	return choice.Delta.Content, nil
+}
+
+// checkAnswer moderates an answer the LLM gave and checks its grounding in
+// passages. A low-scoring answer is disclaimed, or generated again once
+// with regenerate, as agent.yaml's grounding block says; a regenerated
+// answer that still scores low is disclaimed.
+func (s *Server) checkAnswer(ctx context.Context, passages, answer string, regenerate func(note string) (string, error)) checkedAnswer {
+	if v := s.moderate(ctx, moderation.Output, answer); v.Blocked() {
+		return checkedAnswer{text: s.refuse(ctx), moderation: v}
+	}
+	report := s.ground(ctx, passages, answer)
+	if !s.verifier.Low(report) {
+		return checkedAnswer{text: answer, grounding: report}
+	}
+	if s.verifier.Action() == grounding.Regenerate {
+		again, err := regenerate(grounding.RegenerateNote(report))
+		if err != nil {
+			s.log.Error("regenerating the answer failed", "error", err)
+		} else {
+			if v := s.moderate(ctx, moderation.Output, again); v.Blocked() {
+				return checkedAnswer{text: s.refuse(ctx), moderation: v}
+			}
+			answer = again
+			if r := s.ground(ctx, passages, again); r != nil {
+				report = r
+			}
+			report.Regenerated = true
+			if !s.verifier.Low(report) {
+				return checkedAnswer{text: answer, grounding: report}
+			}
+		}
+	}
+	if s.verifier.Action() != grounding.Score {
+		answer += s.verifier.Disclaim(report)
+	}
+	return checkedAnswer{text: answer, grounding: report}
+}
+
+// writeCompletion writes answer as a chat completion, or as a refusal when
+// moderation blocked the request.
+func (s *Server) writeCompletion(w http.ResponseWriter, answer checkedAnswer) {
+	msg := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: answer.text}
+	finish := openai.FinishReasonStop
+	if answer.moderation != nil {
+		msg.Refusal = answer.text
+		finish = openai.FinishReasonContentFilter
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(completion{
+		ChatCompletionResponse: openai.ChatCompletionResponse{
+			ID:      "chatcmpl-" + generateID(),
+			Object:  "chat.completion",
+			Created: time.Now().Unix(),
+			Model:   s.llmClient.Model(),
+			Choices: []openai.ChatCompletionChoice{
+				{
+					Index:        0,
+					Message:      msg,
+					FinishReason: finish,
+				},
+			},
+		},
+		Moderation: answer.moderation,
+		Grounding:  answer.grounding,
+	})
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// minCompressSize is the smallest response worth compressing; smaller bodies
+// are sent as is.
+const minCompressSize = 1024
+
+// encoder is the part of gzip.Writer and zstd.Encoder the middleware uses.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	"zstd": {New: func() any {
+		// Only invalid options make NewWriter fail
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return enc
+	}},
+	"gzip": {New: func() any {
+		return gzip.NewWriter(nil)
+	}},
+}
+
+// compressMiddleware compresses JSON, text, and playground responses with
+// zstd or gzip, whichever the client prefers in Accept-Encoding. Event
+// streams, small bodies, and already encoded or partial responses are sent
+// unchanged.
+func compressMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiateEncoding picks zstd or gzip from an Accept-Encoding header, by
+// quality value and then in that order. It returns "" when neither is
+// acceptable.
+func negotiateEncoding(header string) string {
+	quality := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		quality[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range []string{"zstd", "gzip"} {
+		q, ok := quality[enc]
+		if !ok {
+			q, ok = quality["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// compressible reports whether a response with these headers should be
+// compressed.
+func compressible(h http.Header, status int) bool {
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	if status < http.StatusOK || status == http.StatusNoContent ||
+		status == http.StatusPartialContent || status == http.StatusNotModified {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/javascript":
+		return true
+	}
+	return false
+}
+
+// compressWriter holds back the status and the first minCompressSize bytes
+// until it knows whether the response is worth compressing.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	status   int
+	buf      []byte
+	decided  bool
+	// enc is nil when the response is passed through
+	enc encoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided || w.status != 0 {
+		return
+	}
+	w.status = code
+	if !compressible(w.Header(), code) {
+		w.passThrough()
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !compressible(w.Header(), w.status) {
+		w.passThrough()
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= minCompressSize {
+		if err := w.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush implements http.Flusher. A flush before the decision means the
+// handler streams, so compression starts right away.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		if compressible(w.Header(), w.status) {
+			w.startCompression()
+		} else {
+			w.passThrough()
+		}
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker for WebSocket upgrades, whose responses
+// are never compressed.
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return hijack(w.ResponseWriter)
+}
+
+// passThrough sends the response unchanged, including anything buffered.
+func (w *compressWriter) passThrough() error {
+	w.decided = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// startCompression sends the headers and the buffered bytes through a pooled
+// encoder.
+func (w *compressWriter) startCompression() error {
+	w.decided = true
+	h := w.Header()
+	h.Set("Content-Encoding", w.encoding)
+	h.Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.enc = encoderPools[w.encoding].Get().(encoder)
+	w.enc.Reset(w.ResponseWriter)
+	_, err := w.enc.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// close finishes the response once the handler returns.
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.passThrough()
+		return
+	}
+	if w.enc == nil {
+		return
+	}
+	w.enc.Close()
+	w.enc.Reset(nil)
+	encoderPools[w.encoding].Put(w.enc)
+	w.enc = nil
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/docstore"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// errNoDocuments is the reply of the document endpoints when the build kept
+// no document text.
+const errNoDocuments = "this build stored no documents — rebuild without ingest.skip_documents"
+
+// SourceDocument is a source document as GET /v1/documents lists it.
+type SourceDocument struct {
+	Name string `json:"name"`
+	// Origin is where the build took the document from, as in the
+	// manifest; empty for one live ingest added since
+	Origin string `json:"origin,omitempty"`
+	Chunks int    `json:"chunks"`
+	// Bytes is the length of the text of its chunks
+	Bytes int64 `json:"bytes"`
+	// Tags are the frontmatter tags of its chunks
+	Tags []string `json:"tags,omitempty"`
+	// IngestedAt is when its chunks were last written to the vector store,
+	// by a build or by live ingest
+	IngestedAt time.Time `json:"ingested_at"`
+	// DocumentURL is the path of its full text, when the build stored it
+	DocumentURL string `json:"document_url,omitempty"`
+}
+
+// DocumentList is the reply of GET /v1/documents.
+type DocumentList struct {
+	Documents []SourceDocument `json:"documents"`
+	Chunks    int              `json:"chunks"`
+	// BuiltAt is when the last 'kash build' finished, from its manifest
+	BuiltAt *time.Time `json:"built_at,omitempty"`
+}
+
+// DocumentChunk is a chunk as GET /v1/documents/{name}/chunks returns it.
+type DocumentChunk struct {
+	ID       string            `json:"id"`
+	Index    int               `json:"index"`
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// DocumentChunks is the reply of GET /v1/documents/{name}/chunks.
+type DocumentChunks struct {
+	Document SourceDocument  `json:"document"`
+	Chunks   []DocumentChunk `json:"chunks"`
+}
+
+// handleDocuments serves GET /v1/documents: every source document in the
+// vector store with its chunk count, size, tags, and ingest time, so an
+// operator can check what the running agent knows.
+func (s *Server) handleDocuments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	st, release := s.acquireStores()
+	defer release()
+	chunks, ok := s.storedChunks(w)
+	if !ok {
+		return
+	}
+	list := DocumentList{Documents: []SourceDocument{}, Chunks: len(chunks)}
+	m, _ := manifest.Load(s.manifestPath)
+	if m != nil {
+		list.BuiltAt = &m.BuiltAt
+	}
+	// ReadDocuments orders the chunks by source
+	for start := 0; start < len(chunks); {
+		end := start + 1
+		for end < len(chunks) && chunks[end].Source == chunks[start].Source {
+			end++
+		}
+		list.Documents = append(list.Documents, s.sourceDocument(r, st, m, chunks[start:end]))
+		start = end
+	}
+	writeJSON(w, list)
+}
+
+// handleDocumentChunks serves GET /v1/documents/{name}/chunks: the chunks
+// of one source document in order, as they are stored for retrieval.
+func (s *Server) handleDocumentChunks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	st, release := s.acquireStores()
+	defer release()
+	chunks, ok := s.storedChunks(w)
+	if !ok {
+		return
+	}
+	name := r.PathValue("name")
+	var own []vector.Document
+	for _, c := range chunks {
+		if c.Source == name {
+			own = append(own, c)
+		}
+	}
+	if len(own) == 0 {
+		http.Error(w, "no chunks of "+strconv.Quote(name)+" are stored", http.StatusNotFound)
+		return
+	}
+	m, _ := manifest.Load(s.manifestPath)
+	resp := DocumentChunks{Document: s.sourceDocument(r, st, m, own), Chunks: make([]DocumentChunk, len(own))}
+	for i, c := range own {
+		index, _ := strconv.Atoi(c.Metadata["index"])
+		resp.Chunks[i] = DocumentChunk{ID: c.ID, Index: index, Content: c.Content, Metadata: c.Metadata}
+	}
+	writeJSON(w, resp)
+}
+
+// storedChunks reads every chunk of the vector store, replying with an
+// error when that fails. The live store writes through to its directory,
+// so this includes what live ingest added.
+func (s *Server) storedChunks(w http.ResponseWriter) ([]vector.Document, bool) {
+	chunks, err := vector.ReadDocuments(s.vectorPath)
+	if err != nil {
+		s.log.Error("vector store read failed", "error", err)
+		http.Error(w, "failed to read the vector store", http.StatusInternalServerError)
+		return nil, false
+	}
+	return chunks, true
+}
+
+// sourceDocument summarizes the chunks of one source. m is the build
+// manifest, or nil.
+func (s *Server) sourceDocument(r *http.Request, st *dataStores, m *manifest.Manifest, chunks []vector.Document) SourceDocument {
+	doc := SourceDocument{
+		Name:        chunks[0].Source,
+		Chunks:      len(chunks),
+		DocumentURL: documentURL(r, st.documents, chunks[0].Source),
+	}
+	if m != nil {
+		if md, ok := m.Document(doc.Name); ok {
+			doc.Origin = md.Origin
+		}
+	}
+	for _, c := range chunks {
+		doc.Bytes += int64(len(c.Content))
+		if c.ModTime.After(doc.IngestedAt) {
+			doc.IngestedAt = c.ModTime
+		}
+		for _, tag := range strings.Split(c.Metadata["tags"], ",") {
+			if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(doc.Tags, tag) {
+				doc.Tags = append(doc.Tags, tag)
+			}
+		}
+	}
+	slices.Sort(doc.Tags)
+	return doc
+}
+
+// handleDocumentText serves GET /v1/documents/{name}/text: the full text of
+// a source document as the build stored it, for opening a citation. The
+// name is path-escaped, as in the document_url of a retrieved chunk.
+func (s *Server) handleDocumentText(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	st, release := s.acquireStores()
+	defer release()
+	if st.documents == nil {
+		http.Error(w, errNoDocuments, http.StatusNotFound)
+		return
+	}
+	rc, err := st.documents.Open(r.PathValue("name"))
+	if errors.Is(err, docstore.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.log.Error("document read failed", "error", err)
+		http.Error(w, "failed to read document", http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	io.Copy(w, rc)
+}
+
+// documentURL returns the path of GET /v1/documents/{name}/text for the
+// source name, or "" when docs does not hold its text.
+func documentURL(r *http.Request, docs *docstore.Store, name string) string {
+	if docs == nil {
+		return ""
+	}
+	if _, ok := docs.Get(name); !ok {
+		return ""
+	}
+	return basePath(r) + "/v1/documents/" + url.PathEscape(name) + "/text"
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+
+	"github.com/sashabaranov/go-openai"
+
+	"github.com/akashicode/kash/internal/grounding"
+)
+
+// ground checks the grounding of answer in passages as agent.yaml's
+// grounding block says and logs a low score. It returns nil when grounding
+// is disabled, there is no context to check against, or the check failed.
+func (s *Server) ground(ctx context.Context, passages, answer string) *grounding.Report {
+	r, err := s.verifier.Check(ctx, passages, answer)
+	if err != nil {
+		s.log.Error("grounding check failed", "error", err)
+		return nil
+	}
+	if s.verifier.Low(r) {
+		s.log.Warn("answer not grounded in the retrieved context", "score", r.Score, "unsupported", len(r.Unsupported))
+	}
+	return r
+}
+
+// withNote returns messages followed by a system message holding note.
+func withNote(messages []openai.ChatCompletionMessage, note string) []openai.ChatCompletionMessage {
+	out := make([]openai.ChatCompletionMessage, len(messages), len(messages)+1)
+	copy(out, messages)
+	return append(out, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: note})
+}
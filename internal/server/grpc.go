@@ -0,0 +1,339 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/akashicode/kash/internal/audit"
+	"github.com/akashicode/kash/internal/display"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/reader"
+	"github.com/akashicode/kash/internal/retrieval"
+	"github.com/akashicode/kash/internal/source"
+	"github.com/akashicode/kash/internal/vector"
+	"github.com/akashicode/kash/pkg/kashpb"
+)
+
+// maxGRPCMessageBytes bounds a message a gRPC client sends, which for Ingest
+// holds whole documents.
+const maxGRPCMessageBytes = 64 << 20
+
+// GRPCServer returns a gRPC server for the Kash service of proto/kash/v1,
+// the interface for services that would rather not parse SSE. It
+// authenticates calls like the HTTP API does, with the key in the
+// "authorization" metadata, and records them in the audit log and usage.
+func (s *Server) GRPCServer() *grpc.Server {
+	g := grpc.NewServer(
+		grpc.UnaryInterceptor(s.grpcUnary),
+		grpc.StreamInterceptor(s.grpcStream),
+		grpc.MaxRecvMsgSize(maxGRPCMessageBytes),
+	)
+	kashpb.RegisterKashServer(g, &grpcService{s: s})
+	return g
+}
+
+func (s *Server) grpcUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var resp interface{}
+	err := s.grpcCall(ctx, info.FullMethod, func(ctx context.Context) (err error) {
+		resp, err = handler(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (s *Server) grpcStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return s.grpcCall(ss.Context(), info.FullMethod, func(ctx context.Context) error {
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	})
+}
+
+// contextStream is a grpc.ServerStream with the context the interceptor
+// derived for the call.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
+
+// grpcCall authenticates a call to method, then handles it with a record of
+// its own, and logs it as loggingMiddleware logs HTTP requests.
+func (s *Server) grpcCall(ctx context.Context, method string, handle func(context.Context) error) error {
+	start := time.Now()
+	token := grpcToken(ctx)
+	o := recordOrigin{keyID: audit.KeyID(token), endpoint: method, counted: true}
+	if p, ok := peer.FromContext(ctx); ok {
+		o.remote = p.Addr.String()
+	}
+
+	err := s.grpcAuth(method, token)
+	if err == nil {
+		ctx, rec := s.newRecord(s.withPromptKey(ctx, token))
+		err = handle(ctx)
+		if method == kashpb.Kash_Ingest_FullMethodName {
+			// Ingest is an admin call, which does not count towards usage
+			o.counted = false
+		}
+		s.writeRecord(o, rec, start, grpcHTTPStatus(err))
+	}
+
+	switch {
+	case s.quiet:
+	case s.logger.Structured():
+		s.log.Info("grpc call",
+			"method", method,
+			"code", status.Code(err).String(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote", o.remote)
+	default:
+		display.LogRequest("RPC", method, grpcHTTPStatus(err), time.Since(start), o.remote)
+	}
+	return err
+}
+
+// grpcAuth checks the token of a call to method: the admin key for Ingest,
+// and the API key, when one is set, for everything else.
+func (s *Server) grpcAuth(method, token string) error {
+	if method == kashpb.Kash_Ingest_FullMethodName {
+		if s.adminKey == "" {
+			return status.Error(codes.PermissionDenied, "ingest is disabled — set AGENT_ADMIN_KEY to enable it")
+		}
+		if !secretEqual(token, s.adminKey) {
+			return status.Error(codes.Unauthenticated, "invalid or missing admin key — pass via authorization: Bearer <AGENT_ADMIN_KEY>")
+		}
+		return nil
+	}
+	if s.keys.enabled() && !(s.keys.valid(token) || (s.promptKey != "" && secretEqual(token, s.promptKey))) {
+		return status.Error(codes.Unauthenticated, "invalid or missing API key — pass via authorization: Bearer <AGENT_API_KEY>")
+	}
+	return nil
+}
+
+// grpcToken is the bearer token in the "authorization" metadata of a call.
+func grpcToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(v, "Bearer "); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// grpcHTTPStatus is the HTTP status that stands for the outcome of a call in
+// the audit log, usage, and request log.
+func grpcHTTPStatus(err error) int {
+	switch status.Code(err) {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.Canceled:
+		return 499
+	case codes.Unavailable:
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// grpcService implements kashpb.KashServer.
+type grpcService struct {
+	kashpb.UnimplementedKashServer
+	s *Server
+}
+
+// Chat streams the answer to a conversation through the same pipeline as a
+// streamed chat completion.
+func (g *grpcService) Chat(req *kashpb.ChatRequest, stream grpc.ServerStreamingServer[kashpb.ChatEvent]) error {
+	s := g.s
+	if len(req.GetMessages()) == 0 {
+		return status.Error(codes.InvalidArgument, "messages are required")
+	}
+	messages := make([]openai.ChatCompletionMessage, len(req.GetMessages()))
+	for i, m := range req.GetMessages() {
+		messages[i] = openai.ChatCompletionMessage{Role: m.GetRole(), Content: m.GetContent()}
+	}
+
+	ctx := stream.Context()
+	id := "chatcmpl-" + generateID()
+	ctx = withRetrievalObserver(ctx, func(res *hybridResult) {
+		stream.Send(&kashpb.ChatEvent{Id: id, Event: &kashpb.ChatEvent_Retrieval{Retrieval: &kashpb.Retrieval{
+			Chunks:  grpcChunks(res.Chunks),
+			Triples: grpcTriples(res.Graph),
+		}}})
+	})
+
+	var sr streamReader
+	completion := openai.ChatCompletionRequest{Messages: messages, Stream: true}
+	s.handleStreamingCompletion(ctx, id, func(data []byte) error {
+		token, err := sr.read(data)
+		switch {
+		case err != nil:
+			// Chat ends with the error below
+			return nil
+		case sr.done:
+			done := &kashpb.Done{FinishReason: string(sr.finishReason)}
+			if r := sr.grounding; r != nil {
+				done.Grounding = &kashpb.Grounding{
+					Score:       r.Score,
+					Claims:      int32(r.Claims),
+					Unsupported: r.Unsupported,
+					Regenerated: r.Regenerated,
+					Disclaimed:  r.Disclaimed,
+				}
+			}
+			return stream.Send(&kashpb.ChatEvent{Id: id, Event: &kashpb.ChatEvent_Done{Done: done}})
+		case token != "":
+			return stream.Send(&kashpb.ChatEvent{Id: id, Event: &kashpb.ChatEvent_Token{Token: token}})
+		}
+		return nil
+	}, completion, req.GetFilter())
+
+	switch {
+	case sr.failed:
+		return status.Error(codes.Unavailable, "upstream LLM request failed")
+	case !sr.done && ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case !sr.done:
+		return status.Error(codes.Aborted, "the answer could not be sent")
+	}
+	return nil
+}
+
+// Search runs the retrieval of a chat without the LLM call. Peer agents are
+// not asked, since they answer with theirs.
+func (g *grpcService) Search(ctx context.Context, req *kashpb.SearchRequest) (*kashpb.SearchResponse, error) {
+	s := g.s
+	if strings.TrimSpace(req.GetQuery()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	res, err := s.retrieve(ctx, req.GetQuery(), req.GetFilter(), false)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &kashpb.SearchResponse{
+		Chunks:  grpcChunks(res.Chunks),
+		Triples: grpcTriples(res.Graph),
+		Context: s.formatRetrieval(res),
+	}
+	if res.Query != req.GetQuery() {
+		resp.SearchQuery = res.Query
+	}
+	recordResponse(ctx, resp.Context)
+	return resp, nil
+}
+
+// GraphQuery searches the knowledge graph.
+func (g *grpcService) GraphQuery(ctx context.Context, req *kashpb.GraphQueryRequest) (*kashpb.GraphQueryResponse, error) {
+	s := g.s
+	if strings.TrimSpace(req.GetQuery()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	topK := int(req.GetTopK())
+	if topK <= 0 {
+		topK = s.graphTopK()
+	}
+	st, release := s.acquireStores()
+	defer release()
+	results, err := st.graph.Search(ctx, req.GetQuery(), topK)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	recordQuery(ctx, req.GetQuery(), nil, nil)
+	recordResponse(ctx, graph.FormatResults(results))
+	return &kashpb.GraphQueryResponse{Triples: grpcTriples(results)}, nil
+}
+
+// Ingest writes documents into the data directory and embeds them into the
+// live vector store, as LiveIngest does with documents dropped there.
+func (g *grpcService) Ingest(ctx context.Context, req *kashpb.IngestRequest) (*kashpb.IngestResponse, error) {
+	s := g.s
+	if s.dataDir == "" {
+		return nil, status.Error(codes.FailedPrecondition, "the server has no data directory")
+	}
+	if len(req.GetDocuments()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "documents are required")
+	}
+	rd, ck, scanner, err := s.ingestPipeline()
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	changed := map[string]bool{}
+	for _, doc := range req.GetDocuments() {
+		name := doc.GetName()
+		path := filepath.Join(s.dataDir, name)
+		if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") || name == source.URLListFile {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid document name %q", name)
+		}
+		if !rd.Supports(path) && !reader.IsSidecar(path) {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported document type %q", name)
+		}
+		changed[path] = true
+	}
+	for _, doc := range req.GetDocuments() {
+		path := filepath.Join(s.dataDir, doc.GetName())
+		if len(doc.GetContent()) == 0 {
+			err = os.Remove(path)
+			if errors.Is(err, os.ErrNotExist) {
+				err = nil
+			}
+		} else {
+			err = os.WriteFile(path, doc.GetContent(), 0644)
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "write %s: %v", doc.GetName(), err)
+		}
+	}
+
+	sum := s.ingestFiles(ctx, rd, ck, scanner, changed)
+	return &kashpb.IngestResponse{
+		Updated: int32(sum.updated),
+		Removed: int32(sum.removed),
+		Failed:  int32(sum.failed),
+		Vectors: int64(sum.vectors),
+	}, nil
+}
+
+func grpcChunks(chunks []vector.SearchResult) []*kashpb.Chunk {
+	out := make([]*kashpb.Chunk, len(chunks))
+	for i, c := range chunks {
+		out[i] = &kashpb.Chunk{
+			Id:         c.ID,
+			Source:     c.Source,
+			Citation:   retrieval.Citation(c),
+			Similarity: c.Similarity,
+			Content:    c.Content,
+			Metadata:   c.Metadata,
+		}
+	}
+	return out
+}
+
+func grpcTriples(results []graph.SearchResult) []*kashpb.Triple {
+	out := make([]*kashpb.Triple, len(results))
+	for i, r := range results {
+		out[i] = &kashpb.Triple{Subject: r.Subject, Predicate: r.Predicate, Object: r.Object, Score: r.Score}
+	}
+	return out
+}
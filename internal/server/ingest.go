@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/chunker"
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/ingest"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/pii"
+	"github.com/akashicode/kash/internal/reader"
+	"github.com/akashicode/kash/internal/source"
+)
+
+// fileState is what LiveIngest compares to notice a changed file.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// LiveIngest watches the data directory, checking every interval until ctx
+// is done, and chunks and embeds new and changed documents straight into the
+// live vector store. A deleted document's chunks are removed. Files are
+// ingested once they stayed the same for one interval, so a file still being
+// copied is not read half-written. The knowledge graph and the manifest are
+// left to the next 'kash build'. It returns at once when the server has no
+// data directory.
+func (s *Server) LiveIngest(ctx context.Context, interval time.Duration) {
+	if s.dataDir == "" {
+		return
+	}
+	rd, ck, scanner, err := s.ingestPipeline()
+	if err != nil {
+		s.log.Error("live ingest disabled", "error", err)
+		return
+	}
+
+	// Its embeddings count towards /metrics
+	ctx = llm.WithUsageFunc(ctx, s.tokens.add)
+	seen := s.scanData(rd)
+	s.log.Info("live ingest watching for documents", "dir", s.dataDir, "files", len(seen))
+	pending := map[string]bool{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current := s.scanData(rd)
+		changed := false
+		for path, st := range current {
+			if prev, ok := seen[path]; !ok || prev != st {
+				pending[path], changed = true, true
+			}
+		}
+		for path := range seen {
+			if _, ok := current[path]; !ok {
+				pending[path], changed = true, true
+			}
+		}
+		seen = current
+		if changed || len(pending) == 0 {
+			continue
+		}
+		s.ingestFiles(ctx, rd, ck, scanner, pending)
+		pending = map[string]bool{}
+	}
+}
+
+// ingestPipeline returns the reader, chunker, and PII scanner that agent.yaml
+// sets up for ingesting documents into the live vector store.
+func (s *Server) ingestPipeline() (*reader.Reader, *chunker.Chunker, *pii.Scanner, error) {
+	rd := reader.NewReader(ingest.ReaderOptions(agentconfig.AgentYAMLIngest(s.agentYAMLPath)))
+	chunkOpts, _ := ingest.ChunkerOptions(agentconfig.AgentYAMLMaxTokens(s.agentYAMLPath), agentconfig.AgentYAMLChunking(s.agentYAMLPath))
+	ck, err := chunker.NewChunker(chunkOpts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	scanner, err := pii.New(agentconfig.AgentYAMLPII(s.agentYAMLPath))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return rd, ck, scanner, nil
+}
+
+// scanData lists the documents in the data directory with their sidecars.
+// Like 'kash build', it does not descend into subdirectories.
+func (s *Server) scanData(rd *reader.Reader) map[string]fileState {
+	entries, err := os.ReadDir(s.dataDir)
+	if err != nil {
+		return nil
+	}
+	files := make(map[string]fileState, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(s.dataDir, name)
+		if entry.IsDir() || name == source.URLListFile || path == filepath.Clean(s.manifestPath) {
+			continue
+		}
+		if !rd.Supports(path) && !reader.IsSidecar(path) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files[path] = fileState{size: info.Size(), modTime: info.ModTime()}
+	}
+	return files
+}
+
+// ingestSummary counts the documents one ingestFiles call updated, removed,
+// and failed to ingest, and the chunks in the vector store afterwards.
+type ingestSummary struct {
+	updated, removed, failed, vectors int
+}
+
+// ingestFiles replaces the chunks of each changed path in the live vector
+// store and logs a summary.
+func (s *Server) ingestFiles(ctx context.Context, rd *reader.Reader, ck *chunker.Chunker, scanner *pii.Scanner, changed map[string]bool) ingestSummary {
+	// A changed sidecar re-ingests its document
+	docs := map[string]bool{}
+	for path := range changed {
+		docs[strings.TrimSuffix(path, reader.SidecarSuffix)] = true
+	}
+	paths := make([]string, 0, len(docs))
+	for path := range docs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	st, release := s.acquireStores()
+	defer release()
+	st.vectors.SetMaxConcurrency(agentconfig.AgentYAMLEmbedConcurrency(s.agentYAMLPath))
+	var updated, removed, failed int
+	for _, path := range paths {
+		name := filepath.Base(path)
+		var chunks []chunker.Chunk
+		if _, err := os.Stat(path); err == nil {
+			doc, err := rd.LoadFile(path)
+			if err == nil {
+				chunks, err = ingest.Chunk(ck, doc)
+			}
+			if err != nil {
+				failed++
+				s.log.Warn("live ingest skipped a document", "file", name, "error", err)
+				continue
+			}
+			chunks = scanner.Apply(chunks)
+		}
+		if _, err := st.vectors.ReplaceSource(ctx, name, chunks); err != nil {
+			failed++
+			s.log.Error("live ingest failed", "file", name, "error", err)
+			continue
+		}
+		if chunks == nil {
+			removed++
+			s.log.Info("live ingest removed a document", "file", name)
+			continue
+		}
+		updated++
+		s.log.Info("live ingest updated a document", "file", name, "chunks", len(chunks))
+	}
+	s.log.Info("live ingest finished", "updated", updated, "removed", removed, "failed", failed, "vectors", st.vectors.Count())
+	return ingestSummary{updated: updated, removed: removed, failed: failed, vectors: st.vectors.Count()}
+}
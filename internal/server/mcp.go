@@ -2,10 +2,14 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"github.com/akashicode/kash/internal/docstore"
 )
 
 // MCPTool represents an MCP tool definition.
@@ -78,7 +82,7 @@ func (s *Server) handleMCPSSE(w http.ResponseWriter, r *http.Request) {
 	// Send server info event
 	serverInfo := map[string]interface{}{
 		"type": "endpoint",
-		"url":  "/mcp",
+		"url":  basePath(r) + "/mcp",
 	}
 	infoJSON, _ := json.Marshal(serverInfo)
 	fmt.Fprintf(w, "data: %s\n\n", infoJSON)
@@ -118,6 +122,10 @@ func (s *Server) handleMCPRPC(w http.ResponseWriter, r *http.Request) {
 		result = s.mcpListTools()
 	case "tools/call":
 		result, rpcErr = s.mcpCallTool(r, req.Params)
+	case "resources/list":
+		result, rpcErr = s.mcpListResources()
+	case "resources/read":
+		result, rpcErr = s.mcpReadResource(req.Params)
 	default:
 		rpcErr = &MCPError{Code: -32601, Message: "method not found: " + req.Method}
 	}
@@ -134,11 +142,18 @@ func (s *Server) handleMCPRPC(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) mcpInitialize() map[string]interface{} {
+	capabilities := map[string]interface{}{
+		"tools": map[string]interface{}{},
+	}
+	// The documents are resources when the build stored them
+	st, release := s.acquireStores()
+	if st.documents != nil {
+		capabilities["resources"] = map[string]interface{}{}
+	}
+	release()
 	return map[string]interface{}{
 		"protocolVersion": "2024-11-05",
-		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{},
-		},
+		"capabilities":    capabilities,
 		"serverInfo": map[string]interface{}{
 			"name":    s.agentCfg.Agent.Name,
 			"version": "1.0.0",
@@ -146,6 +161,71 @@ func (s *Server) mcpInitialize() map[string]interface{} {
 	}
 }
 
+// MCPResource is a source document listed by resources/list.
+type MCPResource struct {
+	URI      string `json:"uri"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+	Size     int64  `json:"size"`
+}
+
+// mcpDocumentScheme prefixes the URI of a document resource; the document
+// name follows, path-escaped.
+const mcpDocumentScheme = "kash://documents/"
+
+// mcpListResources lists the stored documents as resources.
+func (s *Server) mcpListResources() (interface{}, *MCPError) {
+	st, release := s.acquireStores()
+	defer release()
+	if st.documents == nil {
+		return nil, &MCPError{Code: -32601, Message: errNoDocuments}
+	}
+	resources := []MCPResource{}
+	for _, doc := range st.documents.List() {
+		resources = append(resources, MCPResource{
+			URI:      mcpDocumentScheme + url.PathEscape(doc.Name),
+			Name:     doc.Name,
+			MimeType: "text/plain",
+			Size:     doc.Bytes,
+		})
+	}
+	return map[string]interface{}{"resources": resources}, nil
+}
+
+// mcpReadResource returns the full text of the document a URI names.
+func (s *Server) mcpReadResource(params json.RawMessage) (interface{}, *MCPError) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, &MCPError{Code: -32602, Message: "invalid params: " + err.Error()}
+	}
+	escaped, ok := strings.CutPrefix(p.URI, mcpDocumentScheme)
+	name, err := url.PathUnescape(escaped)
+	if !ok || err != nil {
+		return nil, &MCPError{Code: -32602, Message: "unknown resource: " + p.URI}
+	}
+
+	st, release := s.acquireStores()
+	defer release()
+	if st.documents == nil {
+		return nil, &MCPError{Code: -32601, Message: errNoDocuments}
+	}
+	text, err := st.documents.Text(name)
+	if errors.Is(err, docstore.ErrNotFound) {
+		// The code MCP gives a resource that does not exist
+		return nil, &MCPError{Code: -32002, Message: "resource not found: " + p.URI}
+	}
+	if err != nil {
+		return nil, &MCPError{Code: -32603, Message: "read error: " + err.Error()}
+	}
+	return map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"uri": p.URI, "mimeType": "text/plain", "text": text},
+		},
+	}, nil
+}
+
 func (s *Server) mcpListTools() map[string]interface{} {
 	tools := s.buildMCPTools()
 	return map[string]interface{}{
@@ -170,7 +250,11 @@ func (s *Server) buildMCPTools() []MCPTool {
 					},
 					"top_k": {
 						Type:        "integer",
-						Description: "Number of results to return (default: 5)",
+						Description: fmt.Sprintf("Number of results to return (default: %d)", s.topK()),
+					},
+					"filter": {
+						Type:        "object",
+						Description: "Only search chunks whose metadata matches these key/value pairs, e.g. {\"tags\": \"billing\"}",
 					},
 				},
 				Required: []string{"query"},
@@ -191,6 +275,10 @@ func (s *Server) buildMCPTools() []MCPTool {
 						Type:        "string",
 						Description: "The search query",
 					},
+					"filter": {
+						Type:        "object",
+						Description: "Only search chunks whose metadata matches these key/value pairs, e.g. {\"tags\": \"billing\"}",
+					},
 				},
 				Required: []string{"query"},
 			},
@@ -214,19 +302,28 @@ func (s *Server) mcpCallTool(r *http.Request, params json.RawMessage) (interface
 		return nil, &MCPError{Code: -32602, Message: "query argument is required"}
 	}
 
-	topK := 5
+	topK := s.topK()
 	if tk, ok := p.Arguments["top_k"].(float64); ok && tk > 0 {
 		topK = int(tk)
 	}
 
+	var filter map[string]string
+	if f, ok := p.Arguments["filter"].(map[string]interface{}); ok && len(f) > 0 {
+		filter = make(map[string]string, len(f))
+		for k, v := range f {
+			filter[k] = fmt.Sprint(v)
+		}
+	}
+
 	ctx := r.Context()
-	retrievedCtx, err := s.hybridSearch(ctx, query)
+	retrievedCtx, err := s.hybridSearch(ctx, query, filter)
 	if err != nil {
 		return nil, &MCPError{Code: -32603, Message: "search error: " + err.Error()}
 	}
 
 	// Limit to topK result segments
 	_ = topK
+	recordResponse(ctx, retrievedCtx)
 
 	return map[string]interface{}{
 		"content": []map[string]interface{}{
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/pricing"
+)
+
+// tokenMeter counts the tokens of the LLM and embedding calls a server makes,
+// by phase and model, from the time it starts.
+type tokenMeter struct {
+	mu     sync.Mutex
+	prices pricing.Table
+	counts map[meterKey]*meterCount
+}
+
+type meterKey struct {
+	phase, model string
+}
+
+type meterCount struct {
+	calls, prompt, completion int64
+	// estimated counts calls whose usage the provider did not report
+	estimated int64
+	costUSD   float64
+}
+
+func newTokenMeter(prices pricing.Table) *tokenMeter {
+	return &tokenMeter{prices: prices, counts: map[meterKey]*meterCount{}}
+}
+
+// add records one call. Calls made outside any phase are chat calls: the
+// server makes them to answer a request.
+func (m *tokenMeter) add(u llm.Usage) {
+	if u.Phase == "" {
+		u.Phase = llm.PhaseChat
+	}
+	cost, _ := m.prices.Cost(u.Model, u.PromptTokens, u.CompletionTokens)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := meterKey{phase: u.Phase, model: u.Model}
+	c := m.counts[k]
+	if c == nil {
+		c = &meterCount{}
+		m.counts[k] = c
+	}
+	c.calls++
+	c.prompt += int64(u.PromptTokens)
+	c.completion += int64(u.CompletionTokens)
+	if u.Estimated {
+		c.estimated++
+	}
+	c.costUSD += cost
+}
+
+// meterLine is one phase and model with its counts.
+type meterLine struct {
+	meterKey
+	meterCount
+}
+
+// snapshot returns the counts sorted by phase and model.
+func (m *tokenMeter) snapshot() []meterLine {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lines := make([]meterLine, 0, len(m.counts))
+	for k, c := range m.counts {
+		lines = append(lines, meterLine{k, *c})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].phase != lines[j].phase {
+			return lines[i].phase < lines[j].phase
+		}
+		return lines[i].model < lines[j].model
+	})
+	return lines
+}
+
+// labelEscaper escapes Prometheus label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// handleMetrics serves GET /metrics: the token counters in the Prometheus
+// text format.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	lines := s.tokens.snapshot()
+	var sb strings.Builder
+	header := func(name, help string) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	}
+	labels := func(l meterLine) string {
+		return fmt.Sprintf(`agent="%s",phase="%s",model="%s"`,
+			labelEscaper.Replace(s.agentCfg.Agent.Name), labelEscaper.Replace(l.phase), labelEscaper.Replace(l.model))
+	}
+
+	header("kash_provider_calls_total", "LLM and embedding calls made since the server started.")
+	for _, l := range lines {
+		fmt.Fprintf(&sb, "kash_provider_calls_total{%s} %d\n", labels(l), l.calls)
+	}
+	header("kash_provider_calls_estimated_total", "Calls whose token usage was estimated because the provider did not report it.")
+	for _, l := range lines {
+		fmt.Fprintf(&sb, "kash_provider_calls_estimated_total{%s} %d\n", labels(l), l.estimated)
+	}
+	header("kash_tokens_total", "Tokens used by LLM and embedding calls since the server started.")
+	for _, l := range lines {
+		fmt.Fprintf(&sb, "kash_tokens_total{%s,kind=\"prompt\"} %d\n", labels(l), l.prompt)
+		fmt.Fprintf(&sb, "kash_tokens_total{%s,kind=\"completion\"} %d\n", labels(l), l.completion)
+	}
+	header("kash_cost_usd_total", "Approximate cost in US dollars of the calls of priced models.")
+	for _, l := range lines {
+		fmt.Fprintf(&sb, "kash_cost_usd_total{%s} %s\n", labels(l), strconv.FormatFloat(l.costUSD, 'f', -1, 64))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(sb.String()))
+}
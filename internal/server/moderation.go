@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+
+	"github.com/akashicode/kash/internal/moderation"
+)
+
+// moderate checks text at stage as agent.yaml's moderation block says, logs
+// and records what it flags, and returns the verdict, or nil when the text
+// passes. A failed moderation call lets the text through unless the rules
+// flag it.
+func (s *Server) moderate(ctx context.Context, stage, text string) *moderation.Verdict {
+	v, err := s.moderator.Check(ctx, stage, text)
+	if err != nil {
+		s.log.Error("moderation failed", "stage", stage, "error", err)
+	}
+	if v == nil {
+		return nil
+	}
+	s.log.Warn("moderation flagged text", "stage", stage, "categories", v.Categories, "action", v.Action)
+	recordModeration(ctx, text, *v)
+	return v
+}
+
+// refuse records and returns the refusal sent instead of an answer.
+func (s *Server) refuse(ctx context.Context) string {
+	msg := s.moderator.Message()
+	recordResponse(ctx, msg)
+	return msg
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AgentsPrefix is the path under which Multi exposes each agent, e.g.
+// /agents/support/v1/chat/completions.
+const AgentsPrefix = "/agents/"
+
+// Multi hosts several agents in one process. A request goes to the agent
+// named by its /agents/<name>/ path prefix or, failing that, by the first
+// label of its Host header (support.example.com → support).
+type Multi struct {
+	agents   map[string]*Server
+	handlers map[string]http.Handler
+	names    []string
+}
+
+// NewMulti routes requests to the given agents, keyed by name.
+func NewMulti(agents map[string]*Server) (*Multi, error) {
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("at least one agent is required")
+	}
+	m := &Multi{
+		agents:   agents,
+		handlers: make(map[string]http.Handler, len(agents)),
+	}
+	for name, srv := range agents {
+		if !validAgentName(name) {
+			return nil, fmt.Errorf("invalid agent name %q: use lowercase letters, digits, '-' and '_'", name)
+		}
+		m.handlers[name] = srv.Handler()
+		m.names = append(m.names, name)
+	}
+	sort.Strings(m.names)
+	return m, nil
+}
+
+// Names returns the agent names, sorted.
+func (m *Multi) Names() []string {
+	return m.names
+}
+
+// Agent returns the named agent's server, or nil.
+func (m *Multi) Agent(name string) *Server {
+	return m.agents[name]
+}
+
+// Handler returns the HTTP handler that dispatches to the agents.
+func (m *Multi) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rest, ok := strings.CutPrefix(r.URL.Path, AgentsPrefix); ok {
+			name, path, _ := strings.Cut(rest, "/")
+			if h, ok := m.handlers[name]; ok {
+				r2 := r.Clone(withBasePath(r.Context(), AgentsPrefix+name))
+				r2.URL.Path = "/" + path
+				r2.URL.RawPath = ""
+				// Keep escaped slashes, e.g. in a document name
+				if raw, ok := strings.CutPrefix(r.URL.RawPath, AgentsPrefix+name+"/"); ok {
+					r2.URL.RawPath = "/" + raw
+				}
+				h.ServeHTTP(w, r2)
+				return
+			}
+		}
+
+		host, _, _ := strings.Cut(r.Host, ":")
+		label, _, _ := strings.Cut(host, ".")
+		if h, ok := m.handlers[strings.ToLower(label)]; ok {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/health":
+			m.handleHealth(w)
+			return
+		case "/livez":
+			writeJSON(w, map[string]string{"status": "ok"})
+			return
+		case "/readyz":
+			m.handleReadyz(w, r)
+			return
+		case "/agents", AgentsPrefix:
+			m.handleRegistry(w)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.Encode(map[string]interface{}{
+			"error":  "no agent matches this request — use " + AgentsPrefix + "<name>/... or a <name>.* host",
+			"agents": m.names,
+		})
+	})
+}
+
+// handleHealth summarizes every agent; per-agent details are at
+// /agents/<name>/health.
+func (m *Multi) handleHealth(w http.ResponseWriter) {
+	agents := make(map[string]interface{}, len(m.names))
+	for _, name := range m.names {
+		st, release := m.agents[name].acquireStores()
+		agents[name] = map[string]interface{}{
+			"agent":   m.agents[name].agentCfg.Agent.Name,
+			"vectors": st.vectors.Count(),
+			"triples": st.graph.Count(),
+			"path":    AgentsPrefix + name,
+		}
+		release()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok",
+		"agents": agents,
+		"time":   time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// Reload reloads every agent's stores and returns the first error.
+func (m *Multi) Reload() error {
+	var first error
+	for _, name := range m.names {
+		if err := m.agents[name].Reload(); err != nil && first == nil {
+			first = fmt.Errorf("reload agent %q: %w", name, err)
+		}
+	}
+	return first
+}
+
+// WatchData watches every agent's manifest; see Server.WatchData.
+func (m *Multi) WatchData(ctx context.Context, interval time.Duration) {
+	for _, name := range m.names {
+		go m.agents[name].WatchData(ctx, interval)
+	}
+	<-ctx.Done()
+}
+
+// LiveIngest watches every agent's data directory; see Server.LiveIngest.
+func (m *Multi) LiveIngest(ctx context.Context, interval time.Duration) {
+	for _, name := range m.names {
+		go m.agents[name].LiveIngest(ctx, interval)
+	}
+	<-ctx.Done()
+}
+
+// RunSchedule runs every agent's scheduled re-ingest; see
+// Server.RunSchedule.
+func (m *Multi) RunSchedule(ctx context.Context) {
+	for _, name := range m.names {
+		go m.agents[name].RunSchedule(ctx)
+	}
+	<-ctx.Done()
+}
+
+// Close releases every agent's stores.
+func (m *Multi) Close() error {
+	var first error
+	for _, name := range m.names {
+		if err := m.agents[name].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func validAgentName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+type basePathKey struct{}
+
+func withBasePath(ctx context.Context, base string) context.Context {
+	return context.WithValue(ctx, basePathKey{}, base)
+}
+
+// basePath is the prefix Multi stripped from the request path, or "" when the
+// agent is served on its own.
+func basePath(r *http.Request) string {
+	base, _ := r.Context().Value(basePathKey{}).(string)
+	return base
+}
@@ -0,0 +1,605 @@
+package server
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/grounding"
+	"github.com/akashicode/kash/internal/moderation"
+	"github.com/akashicode/kash/internal/usage"
+)
+
+// apiObject is a JSON object of an OpenAPI document.
+type apiObject = map[string]interface{}
+
+// handleOpenAPI serves GET /openapi.json: an OpenAPI 3.1 document of the
+// agent's HTTP API, for generating clients and importing the agent into API
+// gateways. Its schemas are generated from the types the handlers encode and
+// decode, and the fields Kash adds to the OpenAI API are marked with
+// "x-kash-extension". The admin API is described when it is enabled.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, s.openAPI(basePath(r)))
+}
+
+// openAPI builds the OpenAPI document of the agent served under base.
+func (s *Server) openAPI(base string) apiObject {
+	schemas := openAPISchemas{}
+	schemas["ChatCompletionRequest"] = chatRequestSchema(schemas)
+	schemas["ChatCompletion"] = chatCompletionSchema(schemas, false)
+	schemas["ChatCompletionChunk"] = chatCompletionSchema(schemas, true)
+	schemas["Error"] = apiObject{
+		"type":       "object",
+		"properties": apiObject{"error": apiObject{"type": "string"}},
+		"required":   []string{"error"},
+	}
+
+	public := []interface{}{}
+	paths := apiObject{
+		"/v1/chat/completions": apiObject{"post": apiObject{
+			"operationId": "createChatCompletion",
+			"summary":     "Answer a conversation from the agent's knowledge",
+			"description": "OpenAI-compatible chat completion. The agent searches its knowledge base for the last user message and answers with the retrieved context. With stream set, the answer is sent as server-sent events of ChatCompletionChunk objects, ending with [DONE].",
+			"tags":        []string{"chat"},
+			"parameters": []apiObject{{
+				"name":        "Last-Event-ID",
+				"in":          "header",
+				"description": "Resumes a stream from the event after this id, when http.sse_resume_window is set",
+				"schema":      apiObject{"type": "string"},
+			}},
+			"requestBody": jsonBody(schemaRef("ChatCompletionRequest")),
+			"responses": apiObject{
+				"200": apiObject{
+					"description": "The answer, or its stream",
+					"content": apiObject{
+						"application/json":  apiObject{"schema": schemaRef("ChatCompletion")},
+						"text/event-stream": apiObject{"schema": apiObject{"type": "string", "description": "data: lines holding ChatCompletionChunk objects, then data: [DONE]"}},
+					},
+				},
+				"400": textResponse("Invalid request body, filter, or kash object"),
+				"401": errorResponse("Invalid or missing API key"),
+				"404": textResponse("The stream to resume is unknown or expired"),
+				"502": textResponse("The LLM request failed"),
+			},
+		}},
+		"/v1/retrieve": apiObject{"post": apiObject{
+			"operationId": "retrieve",
+			"summary":     "Search the knowledge base without calling the LLM",
+			"description": "Runs the hybrid search of a chat completion and returns the chunks, graph facts, peer answers, and the context block a completion would inject.",
+			"tags":        []string{"search"},
+			"requestBody": jsonBody(schemas.of(RetrieveRequest{})),
+			"responses": apiObject{
+				"200": jsonResponse("What the search found", schemas.of(RetrieveResponse{})),
+				"400": textResponse("Invalid request body or missing query"),
+				"401": errorResponse("Invalid or missing API key"),
+			},
+		}},
+		"/v1/documents": apiObject{"get": apiObject{
+			"operationId": "listDocuments",
+			"summary":     "List the ingested source documents",
+			"description": "Every source in the vector store, including those live ingest added, with its chunk count, size, tags, and ingest time.",
+			"tags":        []string{"documents"},
+			"responses": apiObject{
+				"200": jsonResponse("The source documents, by name", schemas.of(DocumentList{})),
+				"401": errorResponse("Invalid or missing API key"),
+				"500": textResponse("The vector store could not be read"),
+			},
+		}},
+		"/v1/documents/{name}/chunks": apiObject{"get": apiObject{
+			"operationId": "getDocumentChunks",
+			"summary":     "Return the chunks of a source document",
+			"description": "The chunks stored for the document in index order, with their metadata, as retrieval finds them.",
+			"tags":        []string{"documents"},
+			"parameters":  []apiObject{documentNameParameter()},
+			"responses": apiObject{
+				"200": jsonResponse("The document and its chunks", schemas.of(DocumentChunks{})),
+				"401": errorResponse("Invalid or missing API key"),
+				"404": textResponse("No chunks of the document are stored"),
+				"500": textResponse("The vector store could not be read"),
+			},
+		}},
+		"/v1/documents/{name}/text": apiObject{"get": apiObject{
+			"operationId": "getDocumentText",
+			"summary":     "Return the full text of a source document",
+			"description": "The text the build stored for the document, with personal data redacted when pii is enabled. The document_url of a retrieved chunk links here.",
+			"tags":        []string{"documents"},
+			"parameters":  []apiObject{documentNameParameter()},
+			"responses": apiObject{
+				"200": textResponse("The document's text"),
+				"401": errorResponse("Invalid or missing API key"),
+				"404": textResponse("The document is not stored, or the build stored none"),
+			},
+		}},
+		"/v1/usage": apiObject{"get": apiObject{
+			"operationId": "getUsage",
+			"summary":     "Report request and token counts",
+			"description": "With auth enabled, callers see the usage of their own key.",
+			"tags":        []string{"usage"},
+			"parameters":  []apiObject{topParameter()},
+			"responses": apiObject{
+				"200": jsonResponse("Usage over the tracked window", schemas.of(usage.Report{})),
+				"401": errorResponse("Invalid or missing API key"),
+			},
+		}},
+		"/metrics": apiObject{"get": apiObject{
+			"operationId": "getMetrics",
+			"summary":     "Token and cost counters in the Prometheus text format",
+			"description": "Calls, tokens, and approximate cost of the LLM and embedding calls since the server started, by phase and model.",
+			"tags":        []string{"usage"},
+			"responses": apiObject{
+				"200": textResponse("Prometheus metrics"),
+				"401": errorResponse("Invalid or missing API key"),
+			},
+		}},
+		"/health": apiObject{"get": apiObject{
+			"operationId": "getHealth",
+			"summary":     "Report the agent's status and knowledge base size",
+			"tags":        []string{"health"},
+			"security":    public,
+			"parameters": []apiObject{{
+				"name":        "deep",
+				"in":          "query",
+				"description": "Also probe the LLM, embedder, and reranker",
+				"schema":      apiObject{"type": "string"},
+			}},
+			"responses": apiObject{
+				"200": jsonResponse("The agent is serving", healthSchema(schemas)),
+				"503": jsonResponse("A provider probe failed", healthSchema(schemas)),
+			},
+		}},
+		"/livez": apiObject{"get": apiObject{
+			"operationId": "getLiveness",
+			"summary":     "Liveness probe",
+			"tags":        []string{"health"},
+			"security":    public,
+			"responses": apiObject{
+				"200": jsonResponse("The process is serving HTTP", statusSchema()),
+			},
+		}},
+		"/readyz": apiObject{"get": apiObject{
+			"operationId": "getReadiness",
+			"summary":     "Readiness probe",
+			"tags":        []string{"health"},
+			"security":    public,
+			"parameters": []apiObject{{
+				"name":        "deep",
+				"in":          "query",
+				"description": "Also probe the LLM, embedder, and reranker",
+				"schema":      apiObject{"type": "string"},
+			}},
+			"responses": apiObject{
+				"200": jsonResponse("The agent can answer queries", readySchema(schemas)),
+				"503": jsonResponse("A check failed", readySchema(schemas)),
+			},
+		}},
+		"/.well-known/agent.json": apiObject{"get": apiObject{
+			"operationId": "getAgentCard",
+			"summary":     "Describe the agent for discovery",
+			"tags":        []string{"health"},
+			"security":    public,
+			"responses": apiObject{
+				"200": jsonResponse("The agent card", schemas.of(AgentCard{})),
+			},
+		}},
+	}
+	if s.adminKey != "" {
+		s.addAdminPaths(paths, schemas)
+	}
+
+	info := apiObject{
+		"title":   s.agentCfg.Agent.Name,
+		"version": s.agentCfg.Agent.Version,
+	}
+	if info["version"] == "" {
+		info["version"] = "1.0.0"
+	}
+	if d := s.agentCfg.Agent.Description; d != "" {
+		info["description"] = d
+	}
+	server := base
+	if server == "" {
+		server = "/"
+	}
+	doc := apiObject{
+		"openapi": "3.1.0",
+		"info":    info,
+		"servers": []apiObject{{"url": server}},
+		"paths":   paths,
+		"components": apiObject{
+			"schemas": schemas,
+			"securitySchemes": apiObject{
+				"apiKey":   apiObject{"type": "http", "scheme": "bearer", "description": "AGENT_API_KEY"},
+				"adminKey": apiObject{"type": "http", "scheme": "bearer", "description": "AGENT_ADMIN_KEY"},
+			},
+		},
+	}
+	if s.keys.enabled() {
+		doc["security"] = []apiObject{{"apiKey": []string{}}}
+	}
+	return doc
+}
+
+// addAdminPaths describes the admin API in paths.
+func (s *Server) addAdminPaths(paths apiObject, schemas openAPISchemas) {
+	admin := func(op apiObject) apiObject {
+		op["tags"] = []string{"admin"}
+		op["security"] = []apiObject{{"adminKey": []string{}}}
+		responses := op["responses"].(apiObject)
+		responses["401"] = errorResponse("Invalid or missing admin key")
+		return op
+	}
+	reingest := schemas.named("ReingestStatus", reflect.TypeOf(reingestStatus{}))
+	reranker := objectSchema(apiObject{
+		"configured":          apiObject{"type": "boolean"},
+		"enabled":             apiObject{"type": "boolean"},
+		"top_n":               apiObject{"type": "integer"},
+		"min_relevance_score": apiObject{"type": "number"},
+		"unavailable_until":   apiObject{"type": "string", "format": "date-time"},
+	}, "configured", "enabled")
+	logLevel := objectSchema(apiObject{
+		"level": apiObject{"type": "string", "enum": []string{"debug", "info", "warn", "error"}},
+	}, "level")
+
+	paths["/admin/config"] = apiObject{"get": admin(apiObject{
+		"operationId": "getAdminConfig",
+		"summary":     "Show the effective config with secrets redacted",
+		"responses":   apiObject{"200": jsonResponse("The config", apiObject{"type": "object"})},
+	})}
+	paths["/admin/reingest"] = apiObject{
+		"get": admin(apiObject{
+			"operationId": "getReingest",
+			"summary":     "Report the latest re-ingest",
+			"responses":   apiObject{"200": jsonResponse("Its status", reingest)},
+		}),
+		"post": admin(apiObject{
+			"operationId": "startReingest",
+			"summary":     "Rebuild the knowledge base and reload it",
+			"responses": apiObject{
+				"202": jsonResponse("The re-ingest started", reingest),
+				"409": jsonResponse("A re-ingest is already running", reingest),
+				"501": errorResponse("Re-ingest is not available in this server"),
+			},
+		}),
+	}
+	paths["/admin/snapshots"] = apiObject{"get": admin(apiObject{
+		"operationId": "listSnapshots",
+		"summary":     "List the kept build snapshots",
+		"responses":   apiObject{"200": jsonResponse("The snapshots", apiObject{"type": "object"})},
+	})}
+	paths["/admin/rollback"] = apiObject{"post": admin(apiObject{
+		"operationId": "rollback",
+		"summary":     "Restore a build snapshot and reload it",
+		"description": "Without a version, the snapshot before the current one is restored.",
+		"requestBody": optionalJSONBody(objectSchema(apiObject{"version": apiObject{"type": "string"}})),
+		"responses": apiObject{
+			"200": jsonResponse("The snapshot was restored", apiObject{"type": "object"}),
+			"404": errorResponse("Unknown snapshot"),
+			"409": errorResponse("A re-ingest is running"),
+			"501": errorResponse("Snapshots are not available in this server"),
+		},
+	})}
+	paths["/admin/cache/flush"] = apiObject{"post": admin(apiObject{
+		"operationId": "flushCache",
+		"summary":     "Reload the stores from disk",
+		"responses": apiObject{"200": jsonResponse("The stores were reloaded", objectSchema(apiObject{
+			"status":  apiObject{"type": "string"},
+			"vectors": apiObject{"type": "integer"},
+			"triples": apiObject{"type": "integer"},
+		}, "status", "vectors", "triples"))},
+	})}
+	paths["/admin/keys/rotate"] = apiObject{"post": admin(apiObject{
+		"operationId": "rotateAPIKey",
+		"summary":     "Replace the API key",
+		"description": "Without a key one is generated. The old key keeps working for the grace period, e.g. \"10m\".",
+		"requestBody": optionalJSONBody(objectSchema(apiObject{
+			"key":   apiObject{"type": "string"},
+			"grace": apiObject{"type": "string"},
+		})),
+		"responses": apiObject{
+			"200": jsonResponse("The new key", objectSchema(apiObject{
+				"api_key":              apiObject{"type": "string"},
+				"previous_valid_until": apiObject{"type": "string", "format": "date-time"},
+			}, "api_key")),
+			"400": textResponse("Invalid grace period or key"),
+		},
+	})}
+	paths["/admin/reranker"] = apiObject{
+		"get": admin(apiObject{
+			"operationId": "getReranker",
+			"summary":     "Report the reranker state",
+			"responses":   apiObject{"200": jsonResponse("The reranker state", reranker)},
+		}),
+		"post": admin(apiObject{
+			"operationId": "setReranker",
+			"summary":     "Switch the reranker on or off",
+			"requestBody": jsonBody(objectSchema(apiObject{"enabled": apiObject{"type": "boolean"}}, "enabled")),
+			"responses": apiObject{
+				"200": jsonResponse("The reranker state", reranker),
+				"409": errorResponse("No reranker is configured"),
+			},
+		}),
+	}
+	paths["/admin/usage"] = apiObject{"get": admin(apiObject{
+		"operationId": "getAdminUsage",
+		"summary":     "Report usage across every key",
+		"parameters": []apiObject{topParameter(), {
+			"name":        "key",
+			"in":          "query",
+			"description": "Only this key id, or \"anonymous\" for requests without a key",
+			"schema":      apiObject{"type": "string"},
+		}},
+		"responses": apiObject{"200": jsonResponse("Usage over the tracked window", schemas.of(usage.Report{}))},
+	})}
+	paths["/admin/log-level"] = apiObject{
+		"get": admin(apiObject{
+			"operationId": "getLogLevel",
+			"summary":     "Report the log level",
+			"responses":   apiObject{"200": jsonResponse("The log level", logLevel)},
+		}),
+		"post": admin(apiObject{
+			"operationId": "setLogLevel",
+			"summary":     "Change the log level",
+			"requestBody": jsonBody(logLevel),
+			"responses": apiObject{
+				"200": jsonResponse("The log level", logLevel),
+				"400": textResponse("Unknown level"),
+			},
+		}),
+	}
+}
+
+// chatRequestSchema describes the chat completion request: the OpenAI fields
+// the agent uses and Kash's extensions.
+func chatRequestSchema(schemas openAPISchemas) apiObject {
+	return apiObject{
+		"type":     "object",
+		"required": []string{"messages"},
+		"properties": apiObject{
+			"model": apiObject{"type": "string", "description": "Accepted for OpenAI compatibility; the agent answers with its configured model"},
+			"messages": apiObject{"type": "array", "items": objectSchema(apiObject{
+				"role":    apiObject{"type": "string", "enum": []string{"system", "user", "assistant"}},
+				"content": apiObject{"type": "string"},
+			}, "role", "content")},
+			"stream": apiObject{"type": "boolean"},
+			"filter": apiObject{
+				"type":                 "object",
+				"additionalProperties": apiObject{"type": "string"},
+				"description":          "Restricts retrieval to chunks whose metadata matches, e.g. {\"tags\": \"billing\"}",
+				"x-kash-extension":     true,
+			},
+			"kash": extension(schemas.of(RetrievalOverrides{}), "Overrides agent.yaml's retrieval settings for this request"),
+		},
+	}
+}
+
+// chatCompletionSchema describes a chat completion, or a streamed chunk of
+// one, with Kash's extensions.
+func chatCompletionSchema(schemas openAPISchemas, chunk bool) apiObject {
+	message := "message"
+	object := "chat.completion"
+	if chunk {
+		message, object = "delta", "chat.completion.chunk"
+	}
+	props := apiObject{
+		"id":      apiObject{"type": "string"},
+		"object":  apiObject{"type": "string", "const": object},
+		"created": apiObject{"type": "integer"},
+		"model":   apiObject{"type": "string"},
+		"choices": apiObject{"type": "array", "items": objectSchema(apiObject{
+			"index": apiObject{"type": "integer"},
+			message: objectSchema(apiObject{
+				"role":    apiObject{"type": "string"},
+				"content": apiObject{"type": "string"},
+			}),
+			"finish_reason": apiObject{"type": "string", "enum": []string{"stop", "length", "content_filter"}},
+		}, "index", message)},
+		"grounding": extension(schemas.of(grounding.Report{}), "How well the retrieved context supports the answer, when grounding verification is on"),
+	}
+	if !chunk {
+		props["usage"] = objectSchema(apiObject{
+			"prompt_tokens":     apiObject{"type": "integer"},
+			"completion_tokens": apiObject{"type": "integer"},
+			"total_tokens":      apiObject{"type": "integer"},
+		})
+		props["moderation"] = extension(schemas.of(moderation.Verdict{}), "What moderation flagged or blocked")
+	}
+	return objectSchema(props, "id", "object", "created", "model", "choices")
+}
+
+func healthSchema(schemas openAPISchemas) apiObject {
+	return objectSchema(apiObject{
+		"status":           apiObject{"type": "string", "enum": []string{"ok", "degraded"}},
+		"agent":            apiObject{"type": "string"},
+		"version":          apiObject{"type": "string"},
+		"vectors":          apiObject{"type": "integer"},
+		"triples":          apiObject{"type": "integer"},
+		"mcp_tools":        apiObject{"type": "integer"},
+		"embed_dimensions": apiObject{"type": "integer"},
+		"llm_model":        apiObject{"type": "string"},
+		"embed_model":      apiObject{"type": "string"},
+		"rerank_model":     apiObject{"type": "string"},
+		"reranker_enabled": apiObject{"type": "boolean"},
+		"auth_enabled":     apiObject{"type": "boolean"},
+		"time":             apiObject{"type": "string", "format": "date-time"},
+		"dependencies":     apiObject{"type": "array", "items": schemas.of(Check{})},
+	}, "status", "agent", "vectors", "triples")
+}
+
+func readySchema(schemas openAPISchemas) apiObject {
+	return objectSchema(apiObject{
+		"status": apiObject{"type": "string", "enum": []string{"ready", "not ready"}},
+		"checks": apiObject{"type": "array", "items": schemas.of(Check{})},
+	}, "status", "checks")
+}
+
+func statusSchema() apiObject {
+	return objectSchema(apiObject{"status": apiObject{"type": "string"}}, "status")
+}
+
+func topParameter() apiObject {
+	return apiObject{
+		"name":        "top",
+		"in":          "query",
+		"description": "Number of top queries to list (default 10)",
+		"schema":      apiObject{"type": "integer", "minimum": 0},
+	}
+}
+
+func documentNameParameter() apiObject {
+	return apiObject{
+		"name":        "name",
+		"in":          "path",
+		"required":    true,
+		"description": "The document's name, the source of its chunks, path-escaped",
+		"schema":      apiObject{"type": "string"},
+	}
+}
+
+func objectSchema(props apiObject, required ...string) apiObject {
+	o := apiObject{"type": "object", "properties": props}
+	if len(required) > 0 {
+		o["required"] = required
+	}
+	return o
+}
+
+// extension marks schema as a field Kash adds to the OpenAI API.
+func extension(schema apiObject, description string) apiObject {
+	return apiObject{"allOf": []apiObject{schema}, "description": description, "x-kash-extension": true}
+}
+
+func schemaRef(name string) apiObject {
+	return apiObject{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonBody(schema apiObject) apiObject {
+	return apiObject{"required": true, "content": apiObject{"application/json": apiObject{"schema": schema}}}
+}
+
+func optionalJSONBody(schema apiObject) apiObject {
+	body := jsonBody(schema)
+	body["required"] = false
+	return body
+}
+
+func jsonResponse(description string, schema apiObject) apiObject {
+	return apiObject{"description": description, "content": apiObject{"application/json": apiObject{"schema": schema}}}
+}
+
+func errorResponse(description string) apiObject {
+	return jsonResponse(description, schemaRef("Error"))
+}
+
+func textResponse(description string) apiObject {
+	return apiObject{"description": description, "content": apiObject{"text/plain": apiObject{"schema": apiObject{"type": "string"}}}}
+}
+
+// openAPISchemas holds the component schemas of a document, generated from
+// Go types by their JSON encoding.
+type openAPISchemas map[string]interface{}
+
+// of returns the schema of v's type, a reference for named structs.
+func (set openAPISchemas) of(v interface{}) apiObject {
+	return set.schema(reflect.TypeOf(v))
+}
+
+// named adds the schema of struct type t as name and returns a reference to
+// it.
+func (set openAPISchemas) named(name string, t reflect.Type) apiObject {
+	if _, ok := set[name]; !ok {
+		// A placeholder stops recursive types from recursing here
+		set[name] = apiObject{}
+		set[name] = set.object(t)
+	}
+	return schemaRef(name)
+}
+
+func (set openAPISchemas) schema(t reflect.Type) apiObject {
+	if t == reflect.TypeOf(time.Time{}) {
+		return apiObject{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return set.schema(t.Elem())
+	case reflect.Bool:
+		return apiObject{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return apiObject{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return apiObject{"type": "number"}
+	case reflect.String:
+		return apiObject{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return apiObject{"type": "string", "contentEncoding": "base64"}
+		}
+		return apiObject{"type": "array", "items": set.schema(t.Elem())}
+	case reflect.Map:
+		return apiObject{"type": "object", "additionalProperties": set.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return set.object(t)
+		}
+		return set.named(schemaName(t), t)
+	}
+	// interface{} holds any value
+	return apiObject{}
+}
+
+// schemaName names the schema of t. Types of other packages are prefixed
+// with their package's name, so usage.Report and grounding.Report differ.
+func schemaName(t reflect.Type) string {
+	pkg := path.Base(t.PkgPath())
+	if pkg == path.Base(reflect.TypeOf(Server{}).PkgPath()) {
+		return t.Name()
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name()
+}
+
+// object is the schema of struct type t. Fields without omitempty that are
+// not pointers are required.
+func (set openAPISchemas) object(t reflect.Type) apiObject {
+	props := apiObject{}
+	var required []string
+	set.fields(t, props, &required)
+	return objectSchema(props, required...)
+}
+
+func (set openAPISchemas) fields(t reflect.Type, props apiObject, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			// Embedded structs' fields are encoded inline
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				set.fields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = set.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/akashicode/kash/internal/retrieval"
+)
+
+// maxOverrideTopK bounds the top_k a request may ask for, so one request
+// cannot fill the LLM context with the whole store.
+const maxOverrideTopK = 50
+
+// RetrievalOverrides is the kash object of a chat completion request: the
+// retrieval settings of agent.yaml to change for that request only.
+type RetrievalOverrides struct {
+	TopK          int      `json:"top_k,omitempty"`
+	GraphTopK     int      `json:"graph_top_k,omitempty"`
+	MinSimilarity *float64 `json:"min_similarity,omitempty"`
+	Strategy      string   `json:"strategy,omitempty"`
+	// Tags restricts retrieval to chunks with this tag, like a filter on
+	// "tags"
+	Tags string `json:"tags,omitempty"`
+	// Language is the language of the query, e.g. "fr", for
+	// language-aware retrieval instead of the one detected; it boosts the
+	// chunks in that language unless agent.yaml sets retrieval.language
+	Language string `json:"language,omitempty"`
+	// DisableRAG answers from the LLM alone, without searching
+	DisableRAG bool `json:"disable_rag,omitempty"`
+}
+
+func (o *RetrievalOverrides) validate() error {
+	if o.TopK < 0 || o.TopK > maxOverrideTopK {
+		return fmt.Errorf("top_k must be between 0 and %d", maxOverrideTopK)
+	}
+	if o.GraphTopK < 0 || o.GraphTopK > maxOverrideTopK {
+		return fmt.Errorf("graph_top_k must be between 0 and %d", maxOverrideTopK)
+	}
+	if o.MinSimilarity != nil && (*o.MinSimilarity < 0 || *o.MinSimilarity > 1) {
+		return fmt.Errorf("min_similarity must be between 0 and 1")
+	}
+	if len(o.Language) > 8 || strings.IndexFunc(o.Language, func(r rune) bool { return !unicode.IsLetter(r) && r != '-' }) >= 0 {
+		return fmt.Errorf("language must be a language code such as \"fr\"")
+	}
+	if !retrieval.ValidStrategy(o.Strategy) {
+		return fmt.Errorf("unknown strategy %q (want %s or %s)", o.Strategy, retrieval.StrategyConcat, retrieval.StrategyRRF)
+	}
+	return nil
+}
+
+// apply sets the overridden settings in opts.
+func (o *RetrievalOverrides) apply(opts *retrieval.Options) {
+	if o.TopK > 0 {
+		opts.TopK = o.TopK
+	}
+	if o.GraphTopK > 0 {
+		opts.GraphTopK = o.GraphTopK
+	}
+	if o.MinSimilarity != nil {
+		opts.MinSimilarity = *o.MinSimilarity
+	}
+	if o.Strategy != "" {
+		opts.Strategy = o.Strategy
+	}
+	if o.Language != "" {
+		opts.Language.Query = strings.ToLower(o.Language)
+		if opts.Language.Mode == "" {
+			opts.Language.Mode = retrieval.LanguageBoost
+		}
+	}
+}
+
+type overridesKey struct{}
+
+// withOverrides returns ctx carrying o for the searches made for it.
+func withOverrides(ctx context.Context, o *RetrievalOverrides) context.Context {
+	return context.WithValue(ctx, overridesKey{}, o)
+}
+
+// overridesFrom returns the overrides ctx carries, or none.
+func overridesFrom(ctx context.Context) *RetrievalOverrides {
+	if o, ok := ctx.Value(overridesKey{}).(*RetrievalOverrides); ok {
+		return o
+	}
+	return &RetrievalOverrides{}
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+)
+
+// Check is the outcome of one readiness check.
+type Check struct {
+	Name string `json:"name"`
+	// Status is "ok", "warn" (degraded but still serving), or "fail"
+	Status    string `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	LatencyMS *int64 `json:"latency_ms,omitempty"`
+	// CheckedAt is set on provider probes, which are cached
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+func okCheck(name, detail string) Check {
+	return Check{Name: name, Status: "ok", Detail: detail}
+}
+
+func failCheck(name, detail string) Check {
+	return Check{Name: name, Status: "fail", Detail: detail}
+}
+
+// handleLivez serves GET /livez. It only shows the process is serving HTTP,
+// so a liveness probe never restarts an agent over a slow dependency.
+func (s *Server) handleLivez(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{"status": "ok"})
+}
+
+// handleReadyz serves GET /readyz: 200 when the agent can answer queries,
+// 503 with the failing checks otherwise. ?deep=1, or server.deep_health in
+// agent.yaml, adds the provider probes.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ready, checks := s.Ready(r.Context(), s.deepRequested(r))
+	status := http.StatusOK
+	body := map[string]interface{}{"status": "ready", "checks": checks}
+	if !ready {
+		status = http.StatusServiceUnavailable
+		body["status"] = "not ready"
+	}
+	writeJSONStatus(w, status, body)
+}
+
+// Ready runs the readiness checks: the stores are open and the runtime
+// config is complete. They are cheap; stores are not counted. deep adds the
+// cached provider probes (see dependencyProbe).
+func (s *Server) Ready(ctx context.Context, deep bool) (bool, []Check) {
+	var checks []Check
+
+	s.storesMu.RLock()
+	st := s.stores
+	s.storesMu.RUnlock()
+	if st != nil && st.vectors != nil && st.graph != nil {
+		checks = append(checks, okCheck("stores", "vector store and graph loaded"))
+	} else {
+		checks = append(checks, failCheck("stores", "stores are not loaded"))
+	}
+
+	if err := agentconfig.ValidateServe(s.appCfg); err != nil {
+		checks = append(checks, failCheck("config", err.Error()))
+	} else {
+		checks = append(checks, okCheck("config", ""))
+	}
+
+	if deep {
+		checks = append(checks, s.deps.check()...)
+	}
+	return allPassed(checks), checks
+}
+
+// allPassed reports whether no check failed; warnings pass.
+func allPassed(checks []Check) bool {
+	for _, c := range checks {
+		if c.Status == "fail" {
+			return false
+		}
+	}
+	return true
+}
+
+// deepRequested reports whether a health request asked for provider probes.
+func (s *Server) deepRequested(r *http.Request) bool {
+	switch r.URL.Query().Get("deep") {
+	case "1", "true", "yes":
+		return true
+	case "0", "false", "no":
+		return false
+	}
+	return s.agentCfg.ServerConfig.DeepHealth
+}
+
+// Dependency probes are cached for depCacheTTL and each bounded by
+// depProbeTimeout.
+const (
+	depCacheTTL     = 30 * time.Second
+	depProbeTimeout = 10 * time.Second
+)
+
+// dependencyProbe checks the LLM, embedder, and reranker endpoints. Results
+// are reused for depCacheTTL and only one probe runs at a time, so frequent
+// health checks cannot hammer the providers or run up cost.
+type dependencyProbe struct {
+	clients *Clients
+	// mu is held while probing: concurrent callers wait and share the result
+	mu        sync.Mutex
+	checkedAt time.Time
+	results   []Check
+}
+
+// check returns the latest probe results, probing again when they are older
+// than depCacheTTL.
+func (p *dependencyProbe) check() []Check {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if time.Since(p.checkedAt) < depCacheTTL {
+		return p.results
+	}
+
+	type probe struct {
+		name string
+		// failStatus is "warn" for optional dependencies
+		failStatus string
+		fn         func(ctx context.Context) error
+	}
+	probes := []probe{
+		{"llm", "fail", p.clients.LLM.Ping},
+	}
+	if p.clients.Embedder != nil {
+		probes = append(probes, probe{"embedder", "fail", func(ctx context.Context) error {
+			_, err := p.clients.Embedder.Embed(ctx, "ping")
+			return err
+		}})
+	}
+	if p.clients.Reranker != nil {
+		probes = append(probes, probe{"reranker", "warn", func(ctx context.Context) error {
+			_, err := p.clients.Reranker.Rerank(ctx, "ping", []string{"ping"})
+			return err
+		}})
+	}
+
+	now := time.Now().UTC()
+	results := make([]Check, len(probes))
+	var wg sync.WaitGroup
+	for i, pr := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if p := recover(); p != nil {
+					results[i] = Check{Name: pr.name, Status: pr.failStatus, Detail: fmt.Sprintf("probe panicked: %v", p)}
+				}
+			}()
+			ctx, cancel := context.WithTimeout(context.Background(), depProbeTimeout)
+			defer cancel()
+			start := time.Now()
+			err := pr.fn(ctx)
+			took := time.Since(start).Milliseconds()
+			c := okCheck(pr.name, "")
+			if err != nil {
+				c = Check{Name: pr.name, Status: pr.failStatus, Detail: err.Error()}
+			}
+			c.LatencyMS, c.CheckedAt = &took, &now
+			results[i] = c
+		}()
+	}
+	wg.Wait()
+
+	p.results, p.checkedAt = results, now
+	return p.results
+}
+
+// handleReadyz serves GET /readyz for all agents: ready only when every
+// agent is.
+func (m *Multi) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ready := true
+	agents := make(map[string]interface{}, len(m.names))
+	for _, name := range m.names {
+		ok, checks := m.agents[name].Ready(r.Context(), m.agents[name].deepRequested(r))
+		status := "ready"
+		if !ok {
+			ready, status = false, "not ready"
+		}
+		agents[name] = map[string]interface{}{"status": status, "checks": checks}
+	}
+	code, status := http.StatusOK, "ready"
+	if !ready {
+		code, status = http.StatusServiceUnavailable, "not ready"
+	}
+	writeJSONStatus(w, code, map[string]interface{}{
+		"status": status,
+		"agents": agents,
+		"time":   time.Now().UTC().Format(time.RFC3339),
+	})
+}
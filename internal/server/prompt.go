@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Policies of agent.yaml's agent.client_system_prompt for the system
+// messages a client sends with a conversation.
+const (
+	// promptDrop ignores them, keeping the agent's prompt (default)
+	promptDrop = "drop"
+	// promptAppend adds them after the agent's prompt
+	promptAppend = "append"
+	// promptReplace uses them instead of the agent's prompt when the
+	// request carries AGENT_PROMPT_KEY, and drops them otherwise
+	promptReplace = "replace"
+)
+
+func validPromptPolicy(p string) error {
+	switch p {
+	case "", promptDrop, promptAppend, promptReplace:
+		return nil
+	}
+	return fmt.Errorf("unknown policy %q (want %s, %s, or %s)", p, promptDrop, promptAppend, promptReplace)
+}
+
+type promptScopeKey struct{}
+
+// withPromptScope returns ctx marked as allowed to replace the agent's
+// system prompt when r carries the prompt key.
+func (s *Server) withPromptScope(ctx context.Context, r *http.Request) context.Context {
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ctx
+	}
+	return s.withPromptKey(ctx, key)
+}
+
+// withPromptKey is withPromptScope for a bearer token passed some other way,
+// e.g. in gRPC metadata.
+func (s *Server) withPromptKey(ctx context.Context, key string) context.Context {
+	if s.promptKey == "" || !secretEqual(key, s.promptKey) {
+		return ctx
+	}
+	return context.WithValue(ctx, promptScopeKey{}, true)
+}
+
+// systemPrompt is the system prompt for a conversation: the agent's, with
+// the client's system messages added or put in its place as
+// agent.client_system_prompt allows.
+func (s *Server) systemPrompt(ctx context.Context, messages []openai.ChatCompletionMessage) string {
+	agentPrompt := s.agentCfg.Agent.SystemPrompt
+	var client []string
+	for _, m := range messages {
+		if m.Role == openai.ChatMessageRoleSystem && strings.TrimSpace(m.Content) != "" {
+			client = append(client, m.Content)
+		}
+	}
+	if len(client) == 0 {
+		return agentPrompt
+	}
+
+	switch policy := s.agentCfg.Agent.ClientSystemPrompt; {
+	case policy == promptAppend && agentPrompt != "":
+		return agentPrompt + "\n\n" + strings.Join(client, "\n\n")
+	case policy == promptAppend:
+		return strings.Join(client, "\n\n")
+	case policy == promptReplace && ctx.Value(promptScopeKey{}) == true:
+		return strings.Join(client, "\n\n")
+	case policy == promptReplace:
+		s.log.Warn("client system prompt dropped: replacing it needs AGENT_PROMPT_KEY")
+	default:
+		s.log.Debug("client system prompt dropped", "messages", len(client))
+	}
+	return agentPrompt
+}
@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/akashicode/kash/internal/audit"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/moderation"
+	"github.com/akashicode/kash/internal/usage"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// requestRecord collects what the audit log and usage tracker need about one
+// request while it is handled: retrieve fills in the query and sources, the
+// handlers the response, and the LLM client the token usage.
+type requestRecord struct {
+	mu               sync.Mutex
+	query            string
+	filter           map[string]string
+	sources          []string
+	response         string
+	promptTokens     int
+	completionTokens int
+	moderation       []moderation.Verdict
+	// held counts the background work that holds the record back, and
+	// write writes it once the request has ended
+	held  int
+	write func()
+	// own is set when the handler writes records of its own, one per
+	// message of a WebSocket chat, instead of one for the request
+	own bool
+}
+
+// newRecord returns ctx with an empty requestRecord that collects the token
+// usage of LLM calls made with it. Embedding calls count towards the
+// server's token meter only.
+func (s *Server) newRecord(ctx context.Context) (context.Context, *requestRecord) {
+	rec := &requestRecord{}
+	ctx = context.WithValue(ctx, recordKey{}, rec)
+	ctx = llm.WithUsageFunc(ctx, func(u llm.Usage) {
+		s.tokens.add(u)
+		if u.Phase == llm.PhaseEmbedding {
+			return
+		}
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.promptTokens += u.PromptTokens
+		rec.completionTokens += u.CompletionTokens
+	})
+	return ctx, rec
+}
+
+// recordsOwn marks the request's record as replaced by records the handler
+// writes itself.
+func recordsOwn(ctx context.Context) {
+	rec, ok := ctx.Value(recordKey{}).(*requestRecord)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.own = true
+}
+
+type recordKey struct{}
+
+// recordQuery records the query a request searched for and the sources of the
+// chunks it found.
+func recordQuery(ctx context.Context, query string, filter map[string]string, chunks []vector.SearchResult) {
+	rec, ok := ctx.Value(recordKey{}).(*requestRecord)
+	if !ok {
+		return
+	}
+	seen := make(map[string]bool, len(chunks))
+	var sources []string
+	for _, c := range chunks {
+		if !seen[c.Source] {
+			seen[c.Source] = true
+			sources = append(sources, c.Source)
+		}
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.query, rec.filter, rec.sources = query, filter, sources
+}
+
+// recordModeration records what moderation flagged. A flagged question is
+// recorded as the query when the request was refused before its search.
+func recordModeration(ctx context.Context, query string, v moderation.Verdict) {
+	rec, ok := ctx.Value(recordKey{}).(*requestRecord)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.query == "" && v.Stage == moderation.Input {
+		rec.query = query
+	}
+	rec.moderation = append(rec.moderation, v)
+}
+
+// recordResponse records the text a request answered with.
+func recordResponse(ctx context.Context, text string) {
+	rec, ok := ctx.Value(recordKey{}).(*requestRecord)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.response = text
+}
+
+// holdRecord keeps the request's audit entry and usage event from being
+// written when the response is, until the returned func is called, for work
+// that goes on in the background, such as A2A tasks and resumable streams.
+func holdRecord(ctx context.Context) (release func()) {
+	rec, ok := ctx.Value(recordKey{}).(*requestRecord)
+	if !ok {
+		return func() {}
+	}
+	rec.mu.Lock()
+	rec.held++
+	rec.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			rec.mu.Lock()
+			rec.held--
+			write := rec.write
+			if rec.held > 0 {
+				write = nil
+			}
+			rec.mu.Unlock()
+			if write != nil {
+				write()
+			}
+		})
+	}
+}
+
+// recordMiddleware attaches a requestRecord to each request. Once the request
+// and any work holding its record are done, it writes an audit entry for
+// requests that searched the knowledge base and counts API requests for
+// /v1/usage.
+func (s *Server) recordMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, rec := s.newRecord(r.Context())
+		wrapped := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+		status := wrapped.status
+		rec.mu.Lock()
+		rec.write = func() { s.writeRecord(httpOrigin(r), rec, start, status) }
+		held := rec.held > 0
+		rec.mu.Unlock()
+		if !held {
+			rec.write()
+		}
+	})
+}
+
+// recordOrigin is who made a request, and to which endpoint, for its record.
+type recordOrigin struct {
+	keyID    string
+	endpoint string
+	remote   string
+	// counted is set when the request counts towards usage
+	counted bool
+}
+
+// httpOrigin is the origin of an HTTP request.
+func httpOrigin(r *http.Request) recordOrigin {
+	return recordOrigin{
+		keyID:    requestKeyID(r),
+		endpoint: basePath(r) + r.URL.Path,
+		remote:   r.RemoteAddr,
+		counted:  usageCounted(r),
+	}
+}
+
+// writeRecord counts the request towards usage and writes its audit entry
+// when it searched the knowledge base.
+func (s *Server) writeRecord(o recordOrigin, rec *requestRecord, start time.Time, status int) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.own {
+		return
+	}
+
+	if o.counted {
+		s.usage.Record(usage.Event{
+			Time:             start,
+			KeyID:            o.keyID,
+			Endpoint:         o.endpoint,
+			Query:            rec.query,
+			Status:           status,
+			PromptTokens:     rec.promptTokens,
+			CompletionTokens: rec.completionTokens,
+		})
+	}
+
+	if s.audit == nil || rec.query == "" {
+		return
+	}
+	err := s.audit.Log(audit.Entry{
+		Time:           start.UTC(),
+		Agent:          s.agentCfg.Agent.Name,
+		KeyID:          o.keyID,
+		Remote:         o.remote,
+		Endpoint:       o.endpoint,
+		Query:          rec.query,
+		Filter:         rec.filter,
+		Sources:        rec.sources,
+		ResponseLength: len(rec.response),
+		Response:       rec.response,
+		Status:         status,
+		DurationMS:     time.Since(start).Milliseconds(),
+		Moderation:     rec.moderation,
+	})
+	if err != nil {
+		s.log.Warn("audit log write failed", "error", err)
+	}
+}
+
+// usageCounted reports whether a request counts towards usage: API calls do;
+// CORS preflights, health checks, discovery, the playground page, the admin
+// API, and usage reports and metrics themselves do not.
+func usageCounted(r *http.Request) bool {
+	p := r.URL.Path
+	return r.Method != http.MethodOptions && !publicPaths[p] && !isUIPath(p) && !isAdminPath(p) && p != "/v1/usage" && p != "/metrics"
+}
+
+// handleUsage serves GET /v1/usage. When auth is enabled callers only see the
+// usage of the key they call with; GET /admin/usage shows every key.
+// ?top=N sets the number of top queries (default 10).
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var only *string
+	if s.keys.enabled() {
+		keyID := requestKeyID(r)
+		only = &keyID
+	}
+	writeJSON(w, s.usage.Report(time.Now(), only, topParam(r)))
+}
+
+// handleAdminUsage serves GET /admin/usage: usage across every key, or one
+// key with ?key=<key id> (or ?key=anonymous for requests without a key).
+func (s *Server) handleAdminUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var only *string
+	if k := r.URL.Query().Get("key"); k != "" {
+		if k == "anonymous" {
+			k = ""
+		}
+		only = &k
+	}
+	writeJSON(w, s.usage.Report(time.Now(), only, topParam(r)))
+}
+
+func topParam(r *http.Request) int {
+	top := 10
+	if v := r.URL.Query().Get("top"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			top = n
+		}
+	}
+	return top
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+)
+
+// recoverMiddleware turns a panic in a handler into a logged stack trace and,
+// when nothing has been sent yet, a 500 JSON error. http.ErrAbortHandler
+// passes through: handlers use it to abort a response on purpose.
+func (s *Server) recoverMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tw := &headerTracker{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			s.log.Error("panic serving request",
+				"panic", fmt.Sprint(p),
+				"method", r.Method,
+				"path", basePath(r)+r.URL.Path,
+				"remote", r.RemoteAddr,
+				"stack", string(debug.Stack()))
+			if tw.wroteHeader {
+				// A streamed response is already under way: cut the
+				// connection so the client sees it is incomplete
+				panic(http.ErrAbortHandler)
+			}
+			writeJSONStatus(w, http.StatusInternalServerError, map[string]string{"error": "internal server error"})
+		}()
+		next.ServeHTTP(tw, r)
+	})
+}
+
+// recoverTask recovers a panic in a background task, logging it instead of
+// crashing the process, and reports it through err when err is non-nil.
+// Defer it at the top of the task.
+func (s *Server) recoverTask(task string, err *error) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	s.log.Error("panic in background task", "task", task, "panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+	if err != nil {
+		*err = fmt.Errorf("%s panicked: %v", task, p)
+	}
+}
+
+// headerTracker records whether the response headers have been sent.
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *headerTracker) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerTracker) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher so streaming responses work through the wrapper.
+func (w *headerTracker) Flush() {
+	w.wroteHeader = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker so WebSocket upgrades work through the
+// wrapper.
+func (w *headerTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return hijack(w.ResponseWriter)
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/akashicode/kash/internal/manifest"
+)
+
+// AgentCard describes an agent for orchestrators and gateways that discover
+// Kash agents: what it knows, what it can do, and where to reach it.
+type AgentCard struct {
+	// Name is the routing name in multi-agent mode; empty when standalone
+	Name         string            `json:"name,omitempty"`
+	Agent        string            `json:"agent"`
+	Description  string            `json:"description,omitempty"`
+	Version      string            `json:"version,omitempty"`
+	Capabilities AgentCapabilities `json:"capabilities"`
+	Tools        []AgentTool       `json:"tools"`
+	Knowledge    AgentKnowledge    `json:"knowledge"`
+	// Peers names the agents this one delegates to
+	Peers     []string          `json:"peers,omitempty"`
+	Endpoints map[string]string `json:"endpoints"`
+}
+
+// AgentCapabilities lists the interfaces and features an agent supports.
+type AgentCapabilities struct {
+	REST      bool `json:"rest"`
+	MCP       bool `json:"mcp"`
+	A2A       bool `json:"a2a"`
+	Streaming bool `json:"streaming"`
+	Filters   bool `json:"metadata_filters"`
+	Reranker  bool `json:"reranker"`
+	Auth      bool `json:"auth_required"`
+}
+
+// AgentTool is an MCP tool the agent exposes.
+type AgentTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// AgentKnowledge summarizes the compiled knowledge base.
+type AgentKnowledge struct {
+	Documents      int    `json:"documents,omitempty"`
+	Vectors        int    `json:"vectors"`
+	Triples        int64  `json:"triples"`
+	EmbedDimension int    `json:"embed_dimensions"`
+	EmbedModel     string `json:"embed_model,omitempty"`
+	BuiltAt        string `json:"built_at,omitempty"`
+}
+
+// Card returns the agent's card. Endpoints are relative to base, the agent's
+// path prefix when several agents share the server.
+func (s *Server) Card(name, base string) AgentCard {
+	st, release := s.acquireStores()
+	defer release()
+
+	card := AgentCard{
+		Name:        name,
+		Agent:       s.agentCfg.Agent.Name,
+		Description: s.agentCfg.Agent.Description,
+		Version:     s.agentCfg.Agent.Version,
+		Capabilities: AgentCapabilities{
+			REST:      true,
+			MCP:       true,
+			A2A:       true,
+			Streaming: true,
+			Filters:   true,
+			Reranker:  s.rerankerActive(),
+			Auth:      s.keys.enabled(),
+		},
+		Knowledge: AgentKnowledge{
+			Vectors:        st.vectors.Count(),
+			Triples:        st.graph.Count(),
+			EmbedDimension: s.appCfg.Embedder.Dimensions,
+			EmbedModel:     s.appCfg.Embedder.Model,
+		},
+		Endpoints: map[string]string{
+			"rest":     base + "/v1/chat/completions",
+			"retrieve": base + "/v1/retrieve",
+			"usage":    base + "/v1/usage",
+			"mcp":      base + "/mcp",
+			"a2a":      base + "/rpc/agent",
+			"health":   base + "/health",
+			"card":     base + "/.well-known/agent.json",
+			"openapi":  base + "/openapi.json",
+			"ui":       base + "/ui/",
+		},
+	}
+	for _, p := range s.peers {
+		card.Peers = append(card.Peers, p.Peer().Name)
+	}
+	for _, t := range s.buildMCPTools() {
+		card.Tools = append(card.Tools, AgentTool{Name: t.Name, Description: t.Description})
+	}
+	if m, err := manifest.Load(s.manifestPath); err == nil {
+		card.Knowledge.Documents = len(m.Documents)
+		if !m.BuiltAt.IsZero() {
+			card.Knowledge.BuiltAt = m.BuiltAt.UTC().Format(time.RFC3339)
+		}
+	}
+	return card
+}
+
+// handleCard serves GET /.well-known/agent.json.
+func (s *Server) handleCard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, s.Card("", basePath(r)))
+}
+
+// handleRegistry serves GET /agents for a standalone agent: a registry with
+// a single entry, in the same shape Multi returns for several.
+func (s *Server) handleRegistry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"agents": []AgentCard{s.Card("", basePath(r))},
+	})
+}
+
+// handleRegistry serves GET /agents with the card of every agent.
+func (m *Multi) handleRegistry(w http.ResponseWriter) {
+	cards := make([]AgentCard, 0, len(m.names))
+	for _, name := range m.names {
+		cards = append(cards, m.agents[name].Card(name, AgentsPrefix+name))
+	}
+	writeJSON(w, map[string]interface{}{"agents": cards})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/akashicode/kash/internal/docstore"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/vector"
+	"github.com/akashicode/kash/internal/webhook"
+)
+
+// dataStores is one generation of the compiled databases. Requests hold a
+// reference while they query it, so Reload can swap in a new generation
+// without closing the graph under an in-flight request.
+type dataStores struct {
+	vectors *vector.Store
+	graph   *graph.DB
+	// documents holds the full text of the documents; nil when the build
+	// kept none
+	documents *docstore.Store
+	inUse     sync.WaitGroup
+}
+
+// openStores loads the vector store and a private snapshot of the graph, so
+// the files under data/ stay free for 'kash build' to rewrite. It refuses a
+// store whose manifest records other language embedders than configured. A
+// build without a document store is served without one.
+func (s *Server) openStores() (*dataStores, error) {
+	if m, err := manifest.Load(s.manifestPath); err == nil {
+		if err := m.CheckLanguages(manifest.Embedder(s.appCfg.Embedder)); err != nil {
+			return nil, err
+		}
+	}
+	vs, err := vector.NewStoreFromPath(s.vectorPath, &s.appCfg.Embedder)
+	if err != nil {
+		return nil, fmt.Errorf("open vector store: %w", err)
+	}
+	gdb, err := graph.OpenSnapshot(s.graphPath)
+	if err != nil {
+		return nil, fmt.Errorf("open graph db: %w", err)
+	}
+	gdb.SetSynonyms(s.agentCfg.Retrieval.Synonyms)
+	var docs *docstore.Store
+	if s.documentsPath != "" {
+		docs, err = docstore.Open(s.documentsPath)
+		if errors.Is(err, os.ErrNotExist) {
+			docs, err = nil, nil
+		}
+		if err != nil {
+			gdb.Close()
+			return nil, err
+		}
+	}
+	return &dataStores{vectors: vs, graph: gdb, documents: docs}, nil
+}
+
+// acquireStores returns the current stores; call release when done with them.
+func (s *Server) acquireStores() (st *dataStores, release func()) {
+	s.storesMu.RLock()
+	st = s.stores
+	st.inUse.Add(1)
+	s.storesMu.RUnlock()
+	return st, st.inUse.Done
+}
+
+// Reload reopens the vector and graph stores from disk and swaps them in.
+// Requests already running finish against the previous stores, which are
+// closed once the last of them returns. On error the current stores stay.
+func (s *Server) Reload() error {
+	next, err := s.openStores()
+	if err != nil {
+		s.log.Error("reload failed, keeping current stores", "error", err)
+		s.notify(webhook.ReloadFailed, "reload failed, keeping the current databases", err, nil)
+		return err
+	}
+
+	s.storesMu.Lock()
+	prev := s.stores
+	s.stores = next
+	s.storesMu.Unlock()
+
+	go func() {
+		prev.inUse.Wait()
+		prev.graph.Close()
+	}()
+
+	s.log.Info("stores reloaded", "vectors", next.vectors.Count(), "triples", next.graph.Count())
+	s.notify(webhook.ReloadCompleted, fmt.Sprintf("reloaded %d vector(s) and %d triple(s)", next.vectors.Count(), next.graph.Count()), nil,
+		map[string]interface{}{"vectors": next.vectors.Count(), "triples": next.graph.Count()})
+	return nil
+}
+
+// notify sends a webhook event in the background; Close waits for it. text
+// is prefixed with the agent name.
+func (s *Server) notify(typ, text string, err error, stats map[string]interface{}) {
+	if s.notifier == nil {
+		return
+	}
+	agent := s.agentCfg.Agent.Name
+	ev := webhook.NewEvent(typ, agent, "kash: "+agent+": "+text, err, stats)
+	s.notifying.Add(1)
+	go func() {
+		defer s.notifying.Done()
+		if err := s.notifier.Send(context.Background(), ev); err != nil {
+			s.log.Warn("webhook delivery failed", "event", typ, "error", err)
+		}
+	}()
+}
+
+// Close releases the stores once in-flight requests are done with them.
+func (s *Server) Close() error {
+	s.storesMu.Lock()
+	st := s.stores
+	s.storesMu.Unlock()
+	st.inUse.Wait()
+	s.notifying.Wait()
+	if s.audit != nil {
+		s.audit.Close()
+	}
+	if s.ownLogger {
+		s.logger.Close()
+	}
+	return st.graph.Close()
+}
+
+// WatchData reloads the stores whenever the manifest changes, checking every
+// interval until ctx is done. 'kash build' writes the manifest last, so a new
+// modification time means both stores are complete.
+func (s *Server) WatchData(ctx context.Context, interval time.Duration) {
+	last := modTime(s.manifestPath)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		mt := modTime(s.manifestPath)
+		if mt.IsZero() || mt.Equal(last) {
+			continue
+		}
+		last = mt
+		s.log.Info("data changed, reloading", "manifest", s.manifestPath)
+		s.Reload()
+	}
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akashicode/kash/internal/audit"
+)
+
+// maxResumableStreams bounds the chat streams kept for resuming. While that
+// many are kept, new streams are sent directly and cannot be resumed.
+const maxResumableStreams = 1000
+
+// streamRegistry keeps the events of recent chat streams so that a client
+// that lost its connection can resume one with Last-Event-ID. A stream's
+// generation runs apart from the request that started it: it goes on while
+// no client reads it for up to window, and its events are kept for window
+// after it ends.
+type streamRegistry struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	streams map[string]*streamBuffer
+}
+
+// streamBuffer holds the events of one chat stream.
+type streamBuffer struct {
+	id string
+	// keyID is the audit key id of the bearer token that started the stream;
+	// only the same key may resume it
+	keyID  string
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	events [][]byte
+	done   bool
+	// wake is closed, and replaced, when an event is added or the stream
+	// ends
+	wake    chan struct{}
+	readers int
+	// idle cancels the generation once no client read it for the window
+	idle *time.Timer
+}
+
+// newStreamRegistry returns a registry that keeps streams for window, or nil
+// when window is 0 and streams are not resumable.
+func newStreamRegistry(window time.Duration) *streamRegistry {
+	if window <= 0 {
+		return nil
+	}
+	return &streamRegistry{window: window, streams: map[string]*streamBuffer{}}
+}
+
+// start registers a new stream whose generation cancel stops. It returns nil
+// when the registry is full.
+func (g *streamRegistry) start(keyID string, cancel context.CancelFunc) (*streamBuffer, error) {
+	id, err := newStreamID()
+	if err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.streams) >= maxResumableStreams {
+		return nil, nil
+	}
+	b := &streamBuffer{id: id, keyID: keyID, cancel: cancel, wake: make(chan struct{})}
+	g.streams[id] = b
+	return b, nil
+}
+
+// get returns the stream id, or nil when it is unknown or expired.
+func (g *streamRegistry) get(id string) *streamBuffer {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.streams[id]
+}
+
+// finish marks b as ended and forgets it after the window.
+func (g *streamRegistry) finish(b *streamBuffer) {
+	b.mu.Lock()
+	b.done = true
+	if b.idle != nil {
+		b.idle.Stop()
+	}
+	close(b.wake)
+	b.mu.Unlock()
+	time.AfterFunc(g.window, func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		delete(g.streams, b.id)
+	})
+}
+
+// attach counts a client reading b.
+func (g *streamRegistry) attach(b *streamBuffer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.readers++
+	if b.idle != nil {
+		b.idle.Stop()
+		b.idle = nil
+	}
+}
+
+// detach stops counting a client reading b. When none is left and b has not
+// ended, its generation is cancelled unless a client resumes it within the
+// window.
+func (g *streamRegistry) detach(b *streamBuffer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.readers--
+	if b.readers == 0 && !b.done {
+		b.idle = time.AfterFunc(g.window, b.cancel)
+	}
+}
+
+// append adds an event to b and wakes its readers.
+func (b *streamBuffer) append(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, data)
+	close(b.wake)
+	b.wake = make(chan struct{})
+	return nil
+}
+
+// len is the number of events in b.
+func (b *streamBuffer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.events)
+}
+
+// follow sends the events of b after the first n to st, each with its event
+// id, as they are added, until b ends or the client goes away.
+func (s *Server) follow(st *sseStream, b *streamBuffer, n int) {
+	s.streams.attach(b)
+	defer s.streams.detach(b)
+	for {
+		b.mu.Lock()
+		events, done, wake := b.events[n:], b.done, b.wake
+		b.mu.Unlock()
+		for _, data := range events {
+			n++
+			if err := st.event(eventID(b.id, n), data); err != nil {
+				return
+			}
+		}
+		if done {
+			return
+		}
+		select {
+		case <-wake:
+		case <-st.ctx.Done():
+			return
+		}
+	}
+}
+
+// handleResume serves a chat completion request with a Last-Event-ID header:
+// it sends the events of the stream after that id, then follows the stream
+// until it ends.
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request, lastEventID string) {
+	id, n, ok := parseEventID(lastEventID)
+	if !ok {
+		http.Error(w, "invalid Last-Event-ID", http.StatusBadRequest)
+		return
+	}
+	b := s.streams.get(id)
+	if b == nil || b.keyID != requestKeyID(r) || n > b.len() {
+		http.Error(w, "stream not found or expired", http.StatusNotFound)
+		return
+	}
+	st, err := s.startSSE(r.Context(), w)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer st.close()
+	s.log.Info("chat stream resumed", "stream", id, "after", n)
+	s.follow(st, b, n)
+}
+
+// requestKeyID is the audit key id of the request's bearer token.
+func requestKeyID(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return audit.KeyID(token)
+}
+
+// newStreamID returns an unguessable chat completion id, since knowing a
+// stream's id is enough to resume it.
+func newStreamID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate stream id: %w", err)
+	}
+	return "chatcmpl-" + hex.EncodeToString(b), nil
+}
+
+// eventID is the id of the nth event of stream id: "<id>:<n>".
+func eventID(id string, n int) string {
+	return id + ":" + strconv.Itoa(n)
+}
+
+// parseEventID splits an event id into its stream id and event number.
+func parseEventID(s string) (id string, n int, ok bool) {
+	i := strings.LastIndexByte(s, ':')
+	if i <= 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(s[i+1:])
+	if err != nil || n < 0 {
+		return "", 0, false
+	}
+	return s[:i], n, true
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/a2a"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/retrieval"
+)
+
+// hybridResult is what one hybrid search found for a query.
+type hybridResult struct {
+	*retrieval.Result
+	// Peers holds one answer per peer agent; nil when peers were not asked
+	Peers []a2a.Answer
+}
+
+// hybridSearch performs both vector and graph search, then merges results.
+// If a reranker is configured, vector results are reranked before inclusion.
+// A non-empty filter restricts vector results to chunks whose metadata
+// matches it (see vector.MatchesFilter).
+func (s *Server) hybridSearch(ctx context.Context, query string, filter map[string]string) (string, error) {
+	res, err := s.retrieve(ctx, query, filter, true)
+	if err != nil {
+		return "", err
+	}
+	return s.formatRetrieval(res), nil
+}
+
+// retrieve runs the vector, graph and (when askPeers is set) peer searches
+// behind hybridSearch and returns their results unformatted.
+func (s *Server) retrieve(ctx context.Context, query string, filter map[string]string, askPeers bool) (*hybridResult, error) {
+	s.log.Debug("hybrid search starting", "query", query, "filter", filter)
+	st, release := s.acquireStores()
+	defer release()
+
+	// Ask peer agents while searching locally; their answers take longest
+	peerCh := make(chan []a2a.Answer, 1)
+	if askPeers && len(s.peers) > 0 && a2a.Depth(ctx) < a2a.MaxDepth {
+		go func() { peerCh <- a2a.AskAll(ctx, s.peers, query) }()
+	} else {
+		peerCh <- nil
+	}
+
+	rc := s.agentCfg.Retrieval
+	opts := retrieval.Options{
+		TopK: s.topK(), GraphTopK: s.graphTopK(), Filter: filter, Hooks: s.hooks,
+		Fusion: rc.Fusion, Strategy: rc.Strategy, RRFK: rc.RRFK, MinSimilarity: rc.MinSimilarity,
+		Compressor: s.compressor, Compress: rc.Compress, Window: rc.Window,
+		Guard: rc.Guard, Classifier: s.classifier, Language: rc.Language,
+		Parent: rc.ParentDocument,
+	}
+	if st.documents != nil {
+		opts.Documents = st.documents
+	}
+	if s.rerankerActive() {
+		opts.Reranker = s.reranker
+		opts.Rerank = s.agentCfg.Retrieval.Rerank
+	}
+	overridesFrom(ctx).apply(&opts)
+	found, err := retrieval.Search(ctx, st.vectors, st.graph, query, opts)
+	if err != nil {
+		s.log.Error("vector search failed", "error", err, "query", query)
+		return nil, err
+	}
+	if found.Query != query {
+		s.log.Debug("query transformed", "query", query, "search_query", found.Query)
+	}
+	for _, hookErr := range found.HookErrs {
+		s.log.Warn("retrieval hook failed (skipped)", "error", hookErr, "query", query)
+	}
+	if found.Language != "" {
+		s.log.Debug("query language matched", "language", found.Language, "dropped", found.LanguageDropped)
+	}
+	if found.BelowSimilarity > 0 {
+		s.log.Debug("chunks below min_similarity dropped", "count", found.BelowSimilarity, "min_similarity", rc.MinSimilarity)
+	}
+	s.log.Info("vector search completed", "results", len(found.Chunks), "query", query)
+	for i, c := range found.Chunks {
+		s.log.Debug("retrieved chunk", "rank", i+1, "source", c.Source, "similarity", c.Similarity, "score", c.Score, "id", c.ID)
+	}
+	if found.GraphErr != nil {
+		s.log.Warn("graph search failed (non-fatal)", "error", found.GraphErr, "query", query)
+	} else {
+		s.log.Info("graph search completed", "results", len(found.Graph), "query", query)
+		for _, t := range found.Graph {
+			s.log.Debug("retrieved fact", "subject", t.Subject, "predicate", t.Predicate, "object", t.Object, "score", t.Score)
+		}
+	}
+	if found.CompressErr != nil {
+		s.log.Warn("chunk compression failed (kept whole)", "error", found.CompressErr)
+	}
+	for _, f := range found.Flagged {
+		s.log.Warn("possible prompt injection in retrieved chunk",
+			"chunk", f.ID, "source", f.Source, "reason", f.Reason, "action", f.Action, "query", query)
+	}
+	if found.GuardErr != nil {
+		s.log.Warn("prompt injection classifier failed (chunks passed)", "error", found.GuardErr)
+	}
+	if found.Parents > 0 {
+		s.log.Debug("chunks replaced by their whole document", "count", found.Parents)
+	}
+	if found.ParentErr != nil {
+		s.log.Warn("document read failed (chunk kept)", "error", found.ParentErr)
+	}
+	if found.Compressed {
+		s.log.Debug("chunks compressed", "results", len(found.Chunks), "dropped", found.CompressDropped)
+	}
+	switch {
+	case errors.Is(found.RerankErr, llm.ErrRerankerUnavailable):
+		s.log.Debug("reranker skipped (using original order)", "error", found.RerankErr)
+	case found.RerankErr != nil:
+		s.log.Warn("reranker failed (using original order)", "error", found.RerankErr)
+		if until := s.reranker.DownUntil(); !until.IsZero() {
+			s.log.Warn("reranker unhealthy, skipping it", "until", until.Format(time.RFC3339))
+		}
+	case found.Reranked:
+		s.log.Info("reranker completed", "results", len(found.Chunks), "dropped", found.RerankDropped)
+	}
+
+	res := &hybridResult{Result: found, Peers: <-peerCh}
+	recordQuery(ctx, query, filter, res.Chunks)
+	if observe, ok := ctx.Value(retrievalObserverKey{}).(func(*hybridResult)); ok {
+		observe(res)
+	}
+	return res, nil
+}
+
+type retrievalObserverKey struct{}
+
+// withRetrievalObserver returns ctx whose searches are passed to observe as
+// they complete, e.g. to report them to a WebSocket client.
+func withRetrievalObserver(ctx context.Context, observe func(*hybridResult)) context.Context {
+	return context.WithValue(ctx, retrievalObserverKey{}, observe)
+}
+
+// topK is the number of vector chunks to retrieve.
+func (s *Server) topK() int {
+	if k := s.agentCfg.Retrieval.TopK; k > 0 {
+		return k
+	}
+	return retrieval.DefaultTopK
+}
+
+// graphTopK is the number of graph triples to retrieve.
+func (s *Server) graphTopK() int {
+	if k := s.agentCfg.Retrieval.GraphTopK; k > 0 {
+		return k
+	}
+	return retrieval.DefaultGraphTopK
+}
+
+// formatRetrieval renders a retrieval as the context block given to the LLM:
+// chunks and triples, then the answers of peer agents.
+func (s *Server) formatRetrieval(res *hybridResult) string {
+	var sb strings.Builder
+	sb.WriteString(res.Format())
+	if peerCtx := s.formatPeerAnswers(res.Peers); peerCtx != "" {
+		sb.WriteString("\n## Answers From Peer Agents\n\n")
+		sb.WriteString(peerCtx)
+	}
+	return sb.String()
+}
+
+// RetrieveRequest is the body of POST /v1/retrieve.
+type RetrieveRequest struct {
+	Query  string            `json:"query"`
+	Filter map[string]string `json:"filter,omitempty"`
+	// Peers asks peer agents too; defaults to true
+	Peers *bool `json:"peers,omitempty"`
+}
+
+// RetrievedChunk is a vector result as returned by POST /v1/retrieve.
+type RetrievedChunk struct {
+	Rank       int               `json:"rank"`
+	ID         string            `json:"id"`
+	Source     string            `json:"source"`
+	Citation   string            `json:"citation"`
+	Similarity float32           `json:"similarity"`
+	Score      float64           `json:"score,omitempty"`
+	Content    string            `json:"content"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	// DocumentURL is the path of the source's full text, when the build
+	// stored it
+	DocumentURL string `json:"document_url,omitempty"`
+}
+
+// PeerAnswer is a peer agent's reply as returned by POST /v1/retrieve.
+type PeerAnswer struct {
+	Peer    string `json:"peer"`
+	Content string `json:"content,omitempty"`
+	Error   string `json:"error,omitempty"`
+	TookMS  int64  `json:"took_ms"`
+}
+
+// RetrieveResponse is the body returned by POST /v1/retrieve.
+type RetrieveResponse struct {
+	Query string `json:"query"`
+	// SearchQuery is the query searched when a query transform changed it
+	SearchQuery string               `json:"search_query,omitempty"`
+	Reranked    bool                 `json:"reranked"`
+	Fused       bool                 `json:"fused,omitempty"`
+	Compressed  bool                 `json:"compressed,omitempty"`
+	Chunks      []RetrievedChunk     `json:"chunks"`
+	Graph       []graph.SearchResult `json:"graph"`
+	Peers       []PeerAnswer         `json:"peers,omitempty"`
+	// Flagged are the chunks the guard found to look like a prompt
+	// injection, including those it left out of Chunks
+	Flagged []retrieval.FlaggedChunk `json:"flagged,omitempty"`
+	// Context is the exact block a chat completion would inject
+	Context string `json:"context"`
+	TookMS  int64  `json:"took_ms"`
+}
+
+// handleRetrieve handles POST /v1/retrieve: the retrieval step of a chat
+// completion, without the LLM call, for debugging what an agent finds.
+func (s *Server) handleRetrieve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req RetrieveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		http.Error(w, "query is required", http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	res, err := s.retrieve(r.Context(), req.Query, req.Filter, req.Peers == nil || *req.Peers)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := RetrieveResponse{
+		Query:      req.Query,
+		Reranked:   res.Reranked,
+		Fused:      res.Fused,
+		Compressed: res.Compressed,
+		Chunks:     make([]RetrievedChunk, len(res.Chunks)),
+		Flagged:    res.Flagged,
+		Graph:      res.Graph,
+		Context:    s.formatRetrieval(res),
+	}
+	if res.Query != req.Query {
+		resp.SearchQuery = res.Query
+	}
+	if resp.Graph == nil {
+		resp.Graph = []graph.SearchResult{}
+	}
+	st, release := s.acquireStores()
+	defer release()
+	for i, c := range res.Chunks {
+		resp.Chunks[i] = RetrievedChunk{
+			Rank:       i + 1,
+			ID:         c.ID,
+			Source:     c.Source,
+			Citation:   retrieval.Citation(c),
+			Similarity: c.Similarity,
+			Score:      c.Score,
+			Content:    c.Content,
+			Metadata:   c.Metadata,
+		}
+		resp.Chunks[i].DocumentURL = documentURL(r, st.documents, c.Source)
+	}
+	for _, a := range res.Peers {
+		pa := PeerAnswer{Peer: a.Peer, Content: a.Content, TookMS: a.Took.Milliseconds()}
+		if a.Err != nil {
+			pa.Error = a.Err.Error()
+		}
+		resp.Peers = append(resp.Peers, pa)
+	}
+	resp.TookMS = time.Since(start).Milliseconds()
+	recordResponse(r.Context(), resp.Context)
+	writeJSON(w, resp)
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"time"
+)
+
+// RunSchedule runs the re-ingest set by agent.yaml's schedule.reingest each
+// time it is due, until ctx is done. A scheduled run rebuilds only when the
+// sources changed, and is skipped while another re-ingest is running. It
+// returns at once when no schedule is set or re-ingest is unavailable.
+func (s *Server) RunSchedule(ctx context.Context) {
+	if s.schedule == nil || s.reingest == nil {
+		return
+	}
+	for {
+		next := s.schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+		s.log.Info("re-ingest scheduled", "schedule", s.schedule.String(), "next", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if _, started := s.startReingest("schedule", ReingestOptions{IfChanged: true}); !started {
+			s.log.Warn("scheduled re-ingest skipped, a re-ingest is already running")
+		}
+	}
+}
@@ -1,23 +1,37 @@
 package server
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
 	"gopkg.in/yaml.v3"
 
+	"github.com/akashicode/kash/internal/a2a"
+	"github.com/akashicode/kash/internal/audit"
 	agentconfig "github.com/akashicode/kash/internal/config"
 	"github.com/akashicode/kash/internal/display"
-	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/grounding"
 	"github.com/akashicode/kash/internal/llm"
-	"github.com/akashicode/kash/internal/vector"
+	"github.com/akashicode/kash/internal/logging"
+	"github.com/akashicode/kash/internal/moderation"
+	"github.com/akashicode/kash/internal/retrieval"
+	"github.com/akashicode/kash/internal/schedule"
+	"github.com/akashicode/kash/internal/usage"
+	"github.com/akashicode/kash/internal/webhook"
 )
 
 // AgentConfig represents the runtime agent configuration loaded from agent.yaml.
@@ -27,12 +41,33 @@ type AgentConfig struct {
 		Description  string `yaml:"description"`
 		Version      string `yaml:"version"`
 		SystemPrompt string `yaml:"system_prompt"`
+		// ClientSystemPrompt is what happens to the system messages of a
+		// chat completion: "drop" (default), "append", or "replace"
+		ClientSystemPrompt string `yaml:"client_system_prompt"`
 	} `yaml:"agent"`
 	Runtime struct {
 		Embedder struct {
 			Dimensions int `yaml:"dimensions"`
 		} `yaml:"embedder"`
 	} `yaml:"runtime"`
+	// Retrieval sets how many results each search feeds the LLM
+	Retrieval struct {
+		// TopK is the number of vector chunks (default: 5)
+		TopK int `yaml:"top_k"`
+		// GraphTopK is the number of graph triples (default: 10)
+		GraphTopK int `yaml:"graph_top_k"`
+		// HookConfig selects built-in query transforms, retrievers, and
+		// chunk filters
+		retrieval.HookConfig `yaml:",inline"`
+		// NoContext sets how a question is answered when retrieval finds
+		// nothing
+		NoContext struct {
+			// Action is "answer" (default) to let the LLM answer without
+			// context, or "reply" to send Message without calling it
+			Action  string `yaml:"action"`
+			Message string `yaml:"message"`
+		} `yaml:"no_context"`
+	} `yaml:"retrieval"`
 	MCP struct {
 		Tools []struct {
 			Name        string `yaml:"name"`
@@ -42,20 +77,113 @@ type AgentConfig struct {
 	ServerConfig struct {
 		Port        int      `yaml:"port"`
 		CORSOrigins []string `yaml:"cors_origins"`
+		// UsageWindow is how far back GET /v1/usage reports, e.g. "24h"
+		UsageWindow string `yaml:"usage_window"`
+		// DeepHealth makes /readyz probe the providers without ?deep=1
+		DeepHealth bool `yaml:"deep_health"`
 	} `yaml:"server"`
+	// Moderation checks questions and answers
+	Moderation moderation.Config `yaml:"moderation"`
+	// Grounding checks that answers are supported by the retrieved context
+	Grounding grounding.Config `yaml:"grounding"`
+	// Peers are other agents consulted on every query
+	Peers []a2a.Peer `yaml:"peers"`
+	// Audit configures the query audit log
+	Audit audit.Options `yaml:"audit"`
+	// Webhooks are notified of re-ingests and reloads
+	Webhooks []webhook.Hook `yaml:"webhooks"`
+	// A2A configures the A2A endpoint beyond its methods
+	A2A struct {
+		// PushNotifications lets agent.query run as a task that POSTs its
+		// updates to a callback
+		PushNotifications a2a.PushOptions `yaml:"push_notifications"`
+	} `yaml:"a2a"`
+	// Schedule runs re-ingests periodically
+	Schedule struct {
+		// Reingest is a cron expression such as "0 */6 * * *", in the
+		// server's local time
+		Reingest string `yaml:"reingest"`
+	} `yaml:"schedule"`
 }
 
 // Server is the Kash runtime HTTP server.
 type Server struct {
-	vectorStore *vector.Store
-	graphDB     *graph.DB
-	llmClient   *llm.Client
-	reranker    *llm.Reranker
-	agentCfg    *AgentConfig
-	appCfg      *agentconfig.Config
-	mux         *http.ServeMux
-	log         *slog.Logger
-	apiKey string // optional API key for auth; empty = open access
+	// storesMu guards stores, which Reload replaces
+	storesMu     sync.RWMutex
+	stores       *dataStores
+	vectorPath   string
+	graphPath    string
+	manifestPath string
+	// documentsPath holds the document store; empty when not served
+	documentsPath string
+	// dataDir is watched by LiveIngest; empty disables it
+	dataDir string
+	// snapshotDir holds the build snapshots for /admin/rollback
+	snapshotDir string
+	// agentYAMLPath is shown by GET /admin/config
+	agentYAMLPath string
+	llmClient     *llm.Client
+	reranker      *llm.Reranker
+	// compressor compresses retrieved chunks; nil unless
+	// retrieval.compress is enabled
+	compressor *llm.Client
+	// classifier checks retrieved chunks for prompt injection; nil unless
+	// retrieval.guard.classify is set
+	classifier *llm.Client
+	// moderator checks questions and answers; nil unless moderation is
+	// enabled
+	moderator *moderation.Moderator
+	// verifier checks the grounding of answers; nil unless grounding is
+	// enabled
+	verifier *grounding.Verifier
+	// deps probes the providers for deep health checks
+	deps *dependencyProbe
+	// rerankerOff is set when the admin API switches the reranker off
+	rerankerOff atomic.Bool
+	// hooks extend every search: agent.yaml's built-ins, then Config.Hooks
+	hooks    retrieval.Hooks
+	peers    []*a2a.Client
+	agentCfg *AgentConfig
+	appCfg   *agentconfig.Config
+	mux      *http.ServeMux
+	log      *slog.Logger
+	// logger backs log and owns its level, changed via /admin/log-level
+	logger *logging.Logger
+	// ownLogger is set when New created logger, so Close closes it
+	ownLogger bool
+	// keys holds the optional API key for auth; empty = open access
+	keys apiKeys
+	// adminKey enables the /admin API; empty = disabled
+	adminKey string
+	// promptKey authorizes requests to replace the agent's system prompt
+	// under the "replace" policy; it is also a valid API key
+	promptKey string
+	// audit is the query audit log; nil when disabled
+	audit *audit.Logger
+	// notifier sends the ingest and reload webhooks; nil when none are set
+	notifier *webhook.Notifier
+	// notifying tracks webhook deliveries, which Close waits for
+	notifying sync.WaitGroup
+	usage     *usage.Tracker
+	// tokens counts the tokens of every provider call, for /metrics
+	tokens *tokenMeter
+	// reingest rebuilds the knowledge base for POST /admin/reingest
+	reingest      func(ctx context.Context, out io.Writer, opts ReingestOptions) error
+	reingestMu    sync.Mutex
+	reingestState reingestStatus
+	// schedule is agent.yaml's schedule.reingest; nil when unset
+	schedule *schedule.Schedule
+	// sseKeepAlive is how long a chat stream may be idle before a comment
+	// is sent; 0 disables keep-alives
+	sseKeepAlive time.Duration
+	// streams keeps chat streams for resuming; nil when
+	// http.sse_resume_window is 0
+	streams *streamRegistry
+	// tasks keeps A2A tasks; nil when push notifications are disabled
+	tasks *a2a.Tasks
+	// dev is set by Config.Dev
+	dev   bool
+	quiet bool
 }
 
 // Config holds the runtime server configuration.
@@ -63,7 +191,74 @@ type Config struct {
 	VectorStorePath string
 	GraphDBPath     string
 	AgentYAMLPath   string
-	AppCfg          *agentconfig.Config
+	// DocumentsPath holds the full text of the documents, served by the
+	// document endpoints and MCP resources; empty or missing disables them
+	DocumentsPath string
+	// ManifestPath is watched by WatchData to detect a finished build
+	ManifestPath string
+	// DataDir holds the source documents that LiveIngest watches
+	DataDir string
+	// SnapshotDir holds the build snapshots that /admin/snapshots lists and
+	// /admin/rollback restores; empty disables both
+	SnapshotDir string
+	AppCfg      *agentconfig.Config
+	// Clients are shared with other agents in the same process; when nil,
+	// New creates them from AppCfg
+	Clients *Clients
+	// Reingest rebuilds the knowledge base, writing its log to out. It backs
+	// POST /admin/reingest and the scheduled re-ingest, which are unavailable
+	// when Reingest is nil
+	Reingest func(ctx context.Context, out io.Writer, opts ReingestOptions) error
+	// Quiet discards request and diagnostic logs, e.g. when the handler is
+	// driven in-process by kash benchmark
+	Quiet bool
+	// Logger is shared with other agents in the same process; when nil, New
+	// creates one from LOG_LEVEL, LOG_FORMAT, and LOG_FILE
+	Logger *logging.Logger
+	// Hooks extend every search, after the built-in hooks agent.yaml selects
+	Hooks retrieval.Hooks
+	// Dev relaxes CORS for browser apps on other origins and redirects / to
+	// the playground, for kash serve --dev
+	Dev bool
+}
+
+// Clients are the provider clients a Server calls. Agents served from one
+// process share them.
+type Clients struct {
+	LLM *llm.Client
+	// Embedder embeds the deep health probe; queries embed through the
+	// vector store
+	Embedder *llm.Embedder
+	// Reranker is nil when no reranker is configured
+	Reranker *llm.Reranker
+
+	// deps is shared so agents in one process probe the providers once
+	deps *dependencyProbe
+}
+
+// NewClients creates the LLM client, the embedder and, when configured, the
+// reranker.
+func NewClients(cfg *agentconfig.Config) (*Clients, error) {
+	llmClient, err := llm.NewClient(&cfg.LLM)
+	if err != nil {
+		return nil, fmt.Errorf("create LLM client: %w", err)
+	}
+	embedder, err := llm.NewEmbedder(&cfg.Embedder)
+	if err != nil {
+		return nil, fmt.Errorf("create embedder: %w", err)
+	}
+
+	// Initialize reranker (optional — skip if not configured)
+	var reranker *llm.Reranker
+	if cfg.Reranker.BaseURL != "" || cfg.Reranker.Provider != "" {
+		reranker, err = llm.NewReranker(&cfg.Reranker)
+		if err != nil {
+			return nil, fmt.Errorf("create reranker: %w", err)
+		}
+	}
+	c := &Clients{LLM: llmClient, Embedder: embedder, Reranker: reranker}
+	c.deps = &dependencyProbe{clients: c}
+	return c, nil
 }
 
 // New creates and initializes a new runtime Server.
@@ -81,59 +276,207 @@ func New(cfg Config) (*Server, error) {
 	// Apply agent.yaml dimensions as fallback if not set via env/config
 	agentconfig.ApplyAgentYAMLDimensions(cfg.AppCfg, cfg.AgentYAMLPath)
 
-	// Initialize vector store
-	vs, err := vector.NewStoreFromPath(cfg.VectorStorePath, &cfg.AppCfg.Embedder)
-	if err != nil {
-		return nil, fmt.Errorf("open vector store: %w", err)
+	var peers []*a2a.Client
+	for _, p := range agentCfg.Peers {
+		c, err := a2a.NewClient(p)
+		if err != nil {
+			return nil, fmt.Errorf("agent.yaml peers: %w", err)
+		}
+		peers = append(peers, c)
 	}
 
-	// Initialize graph DB
-	gdb, err := graph.NewDBFromPath(cfg.GraphDBPath)
+	hooks, err := agentCfg.Retrieval.Hooks()
 	if err != nil {
-		return nil, fmt.Errorf("open graph db: %w", err)
+		return nil, fmt.Errorf("agent.yaml retrieval: %w", err)
 	}
-
-	// Initialize LLM client
-	llmClient, err := llm.NewClient(&cfg.AppCfg.LLM)
+	rerankOpts, err := agentCfg.Retrieval.Rerank.RerankerOptions()
 	if err != nil {
-		return nil, fmt.Errorf("create LLM client: %w", err)
+		return nil, fmt.Errorf("agent.yaml retrieval: %w", err)
+	}
+	switch a := agentCfg.Retrieval.NoContext.Action; a {
+	case "", noContextAnswer, noContextReply:
+	default:
+		return nil, fmt.Errorf("agent.yaml retrieval.no_context: unknown action %q (want %s or %s)", a, noContextAnswer, noContextReply)
+	}
+	if err := validPromptPolicy(agentCfg.Agent.ClientSystemPrompt); err != nil {
+		return nil, fmt.Errorf("agent.yaml agent.client_system_prompt: %w", err)
+	}
+	notifier, err := webhook.New(agentCfg.Webhooks)
+	if err != nil {
+		return nil, fmt.Errorf("agent.yaml webhooks: %w", err)
+	}
+	var sched *schedule.Schedule
+	if expr := agentCfg.Schedule.Reingest; expr != "" {
+		parsed, err := schedule.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("agent.yaml schedule.reingest: %w", err)
+		}
+		sched = &parsed
 	}
 
-	// Initialize reranker (optional — skip if not configured)
-	var reranker *llm.Reranker
-	if cfg.AppCfg.Reranker.BaseURL != "" {
-		reranker, err = llm.NewReranker(&cfg.AppCfg.Reranker)
+	clients := cfg.Clients
+	if clients == nil {
+		if clients, err = NewClients(cfg.AppCfg); err != nil {
+			return nil, err
+		}
+	}
+	if clients.deps == nil {
+		clients.deps = &dependencyProbe{clients: clients}
+	}
+	if clients.Reranker != nil {
+		clients.Reranker.Configure(rerankOpts)
+	}
+	// llmFor is the agent's LLM client, or one for model when it differs
+	llmFor := func(model string) (*llm.Client, error) {
+		if model == "" || model == clients.LLM.Model() {
+			return clients.LLM, nil
+		}
+		llmCfg := cfg.AppCfg.LLM
+		llmCfg.Model = model
+		return llm.NewClient(&llmCfg)
+	}
+	var compressor, classifier *llm.Client
+	if cc := agentCfg.Retrieval.Compress; cc.Enabled {
+		if compressor, err = llmFor(cc.Model); err != nil {
+			return nil, fmt.Errorf("create compression client: %w", err)
+		}
+	}
+	if gc := agentCfg.Retrieval.Guard; gc.Enabled && gc.Classify {
+		if classifier, err = llmFor(gc.Model); err != nil {
+			return nil, fmt.Errorf("create guard classifier: %w", err)
+		}
+	}
+	moderator, err := moderation.New(agentCfg.Moderation, clients.LLM)
+	if err != nil {
+		return nil, fmt.Errorf("agent.yaml moderation: %w", err)
+	}
+	var verifier *grounding.Verifier
+	if gc := agentCfg.Grounding; gc.Enabled {
+		judge, err := llmFor(gc.Model)
 		if err != nil {
-			return nil, fmt.Errorf("create reranker: %w", err)
+			return nil, fmt.Errorf("create grounding judge: %w", err)
+		}
+		if verifier, err = grounding.New(gc, judge); err != nil {
+			return nil, fmt.Errorf("agent.yaml grounding: %w", err)
 		}
 	}
 
-	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	logger, ownLogger := cfg.Logger, false
+	switch {
+	case cfg.Quiet:
+		logger = logging.Discard()
+	case logger == nil:
+		if logger, err = logging.New(logging.OptionsFromEnv()); err != nil {
+			return nil, err
+		}
+		ownLogger = true
+	}
 
 	// Optional API key — enables auth on all endpoints (except /health)
 	apiKey := os.Getenv("AGENT_API_KEY")
+	// Optional admin key — enables the /admin API
+	adminKey := os.Getenv("AGENT_ADMIN_KEY")
+	if adminKey != "" && adminKey == apiKey {
+		return nil, fmt.Errorf("AGENT_ADMIN_KEY must differ from AGENT_API_KEY")
+	}
+	// Optional prompt key — may replace the agent's system prompt
+	promptKey := os.Getenv("AGENT_PROMPT_KEY")
+	if promptKey != "" && (promptKey == apiKey || promptKey == adminKey) {
+		return nil, fmt.Errorf("AGENT_PROMPT_KEY must differ from AGENT_API_KEY and AGENT_ADMIN_KEY")
+	}
+
+	// Optional audit log — AUDIT_LOG_PATH overrides agent.yaml's audit.path,
+	// and relative paths are resolved against agent.yaml's directory
+	auditOpts := agentCfg.Audit
+	if p := os.Getenv("AUDIT_LOG_PATH"); p != "" {
+		auditOpts.Path = p
+	}
+	var auditLog *audit.Logger
+	if auditOpts.Path != "" {
+		if !filepath.IsAbs(auditOpts.Path) {
+			auditOpts.Path = filepath.Join(filepath.Dir(cfg.AgentYAMLPath), auditOpts.Path)
+		}
+		if auditLog, err = audit.New(auditOpts); err != nil {
+			return nil, fmt.Errorf("open audit log: %w", err)
+		}
+	}
+
+	sseKeepAlive, err := cfg.AppCfg.HTTP.KeepAlive()
+	if err != nil {
+		return nil, err
+	}
+	sseResume, err := cfg.AppCfg.HTTP.ResumeWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	usageWindow := 24 * time.Hour
+	if w := agentCfg.ServerConfig.UsageWindow; w != "" {
+		if usageWindow, err = time.ParseDuration(w); err != nil || usageWindow <= 0 {
+			return nil, fmt.Errorf("agent.yaml server.usage_window: invalid duration %q", w)
+		}
+	}
 
 	s := &Server{
-		vectorStore: vs,
-		graphDB:     gdb,
-		llmClient:   llmClient,
-		reranker:    reranker,
-		agentCfg:    agentCfg,
-		appCfg:      cfg.AppCfg,
-		mux:         http.NewServeMux(),
-		log:         logger,
-		apiKey:      apiKey,
+		vectorPath:    cfg.VectorStorePath,
+		graphPath:     cfg.GraphDBPath,
+		documentsPath: cfg.DocumentsPath,
+		manifestPath:  cfg.ManifestPath,
+		dataDir:       cfg.DataDir,
+		snapshotDir:   cfg.SnapshotDir,
+		agentYAMLPath: cfg.AgentYAMLPath,
+		llmClient:     clients.LLM,
+		reranker:      clients.Reranker,
+		compressor:    compressor,
+		classifier:    classifier,
+		moderator:     moderator,
+		verifier:      verifier,
+		deps:          clients.deps,
+		hooks:         hooks.Append(cfg.Hooks),
+		peers:         peers,
+		agentCfg:      agentCfg,
+		appCfg:        cfg.AppCfg,
+		mux:           http.NewServeMux(),
+		log:           logger.Logger,
+		logger:        logger,
+		ownLogger:     ownLogger,
+		keys:          apiKeys{current: apiKey},
+		adminKey:      adminKey,
+		promptKey:     promptKey,
+		audit:         auditLog,
+		notifier:      notifier,
+		usage:         usage.NewTracker(usageWindow),
+		tokens:        newTokenMeter(cfg.AppCfg.Pricing.Prices()),
+		reingest:      cfg.Reingest,
+		schedule:      sched,
+		sseKeepAlive:  sseKeepAlive,
+		streams:       newStreamRegistry(sseResume),
+		tasks:         a2a.NewTasks(agentCfg.A2A.PushNotifications),
+		dev:           cfg.Dev,
+		quiet:         cfg.Quiet,
+	}
+
+	// Initialize the vector store and graph DB
+	if s.stores, err = s.openStores(); err != nil {
+		return nil, err
 	}
 
 	logger.Info("server initialized",
 		"agent", agentCfg.Agent.Name,
-		"vectors", vs.Count(),
-		"triples", gdb.Count(),
+		"vectors", s.stores.vectors.Count(),
+		"triples", s.stores.graph.Count(),
 		"llm_model", cfg.AppCfg.LLM.Model,
 		"embed_model", cfg.AppCfg.Embedder.Model,
 		"embed_dimensions", cfg.AppCfg.Embedder.Dimensions,
+		"profile", cfg.AppCfg.Profile,
+		"peers", len(peers),
 		"auth_enabled", apiKey != "",
+		"admin_enabled", adminKey != "",
+		"audit_log", auditOpts.Path,
 	)
+	if msg := agentconfig.AgentYAMLEmbedParallelWarning(cfg.AgentYAMLPath); msg != "" {
+		logger.Warn(msg)
+	}
 
 	s.registerRoutes()
 	return s, nil
@@ -141,12 +484,14 @@ func New(cfg Config) (*Server, error) {
 
 // Info returns a ServerInfo struct for displaying the startup banner.
 func (s *Server) Info() display.ServerInfo {
+	st, release := s.acquireStores()
+	defer release()
 	info := display.ServerInfo{
 		AgentName:        s.agentCfg.Agent.Name,
 		AgentDescription: s.agentCfg.Agent.Description,
 		AgentVersion:     s.agentCfg.Agent.Version,
-		VectorCount:      s.vectorStore.Count(),
-		TripleCount:      s.graphDB.Count(),
+		VectorCount:      st.vectors.Count(),
+		TripleCount:      st.graph.Count(),
 		MCPTools:         len(s.agentCfg.MCP.Tools),
 		EmbedDimensions:  s.appCfg.Embedder.Dimensions,
 		EmbedModel:       s.appCfg.Embedder.Model,
@@ -156,18 +501,45 @@ func (s *Server) Info() display.ServerInfo {
 		RerankModel:      s.appCfg.Reranker.Model,
 		RerankBaseURL:    s.appCfg.Reranker.BaseURL,
 		Port:             s.appCfg.Port,
-		AuthEnabled:      s.apiKey != "",
+		GRPCPort:         s.appCfg.GRPCPort,
+		Dev:              s.dev,
+		AuthEnabled:      s.keys.enabled(),
+		AdminEnabled:     s.adminKey != "",
+	}
+	if s.audit != nil {
+		info.AuditLog = s.audit.Path()
 	}
 	return info
 }
 
 // Handler returns the HTTP handler for the server.
 func (s *Server) Handler() http.Handler {
-	return s.loggingMiddleware(corsMiddleware(s.authMiddleware(s.mux)))
+	return s.loggingMiddleware(compressMiddleware(s.recoverMiddleware(s.corsMiddleware(s.authMiddleware(s.recordMiddleware(peerDepthMiddleware(s.mux)))))))
+}
+
+// peerDepthMiddleware records how many agents the request has passed
+// through, so delegation to peers stops before it can loop.
+func peerDepthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d := a2a.ParseDepth(r.Header); d > 0 {
+			r = r.WithContext(a2a.WithDepth(r.Context(), d))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// publicPaths are served without the API key.
+var publicPaths = map[string]bool{
+	"/health":                 true,
+	"/livez":                  true,
+	"/readyz":                 true,
+	"/.well-known/agent.json": true,
+	"/agents":                 true,
+	"/openapi.json":           true,
 }
 
 // authMiddleware enforces API key auth when AGENT_API_KEY is set.
-// The /health endpoint is always public. All other endpoints require
+// The /health, discovery and /ui playground pages are always public. All other endpoints require
 // Authorization: Bearer <AGENT_API_KEY> when auth is enabled.
 // This is compatible with:
 //   - curl / HTTP clients: -H "Authorization: Bearer <key>"
@@ -176,14 +548,20 @@ func (s *Server) Handler() http.Handler {
 //   - A2A clients: standard Bearer auth per A2A spec
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The admin API checks its own key
+		if isAdminPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// No API key configured — open access
-		if s.apiKey == "" {
+		if !s.keys.enabled() {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// /health is always public
-		if r.URL.Path == "/health" {
+		// /health, discovery and the playground page are always public
+		if publicPaths[r.URL.Path] || isUIPath(r.URL.Path) {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -197,7 +575,8 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 		// Check Authorization: Bearer <key>
 		auth := r.Header.Get("Authorization")
 		const prefix = "Bearer "
-		if !strings.HasPrefix(auth, prefix) || strings.TrimPrefix(auth, prefix) != s.apiKey {
+		key := strings.TrimPrefix(auth, prefix)
+		if !strings.HasPrefix(auth, prefix) || !(s.keys.valid(key) || (s.promptKey != "" && secretEqual(key, s.promptKey))) {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
 			json.NewEncoder(w).Encode(map[string]string{"error": "invalid or missing API key — pass via Authorization: Bearer <AGENT_API_KEY>"})
@@ -214,7 +593,19 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 		start := time.Now()
 		wrapped := &statusWriter{ResponseWriter: w, status: http.StatusOK}
 		next.ServeHTTP(wrapped, r)
-		display.LogRequest(r.Method, r.URL.Path, wrapped.status, time.Since(start), r.RemoteAddr)
+		switch {
+		case s.quiet:
+		case s.logger.Structured():
+			// JSON and file logs get the request line as a structured entry
+			s.log.Info("request",
+				"method", r.Method,
+				"path", basePath(r)+r.URL.Path,
+				"status", wrapped.status,
+				"duration_ms", time.Since(start).Milliseconds(),
+				"remote", r.RemoteAddr)
+		default:
+			display.LogRequest(r.Method, basePath(r)+r.URL.Path, wrapped.status, time.Since(start), r.RemoteAddr)
+		}
 	})
 }
 
@@ -236,115 +627,131 @@ func (w *statusWriter) Flush() {
 	}
 }
 
+// Hijack implements http.Hijacker so WebSocket upgrades work through the
+// wrapper.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.status = http.StatusSwitchingProtocols
+	return hijack(w.ResponseWriter)
+}
+
+// hijack takes over the connection of w, for the Hijack methods of the
+// middleware's response writers.
+func hijack(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("connection cannot be hijacked")
+	}
+	return h.Hijack()
+}
+
 func (s *Server) registerRoutes() {
 	// Health check
 	s.mux.HandleFunc("/health", s.handleHealth)
 
+	// Kubernetes-style probes: liveness is trivial, readiness checks the stores
+	s.mux.HandleFunc("/livez", s.handleLivez)
+	s.mux.HandleFunc("/readyz", s.handleReadyz)
+
 	// OpenAI-compatible REST API
 	s.mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("/v1/chat/ws", s.handleChatWS)
 
 	// MCP (Model Context Protocol) over HTTP SSE
 	s.mux.HandleFunc("/mcp", s.handleMCP)
 
 	// A2A (Agent-to-Agent) JSON-RPC
 	s.mux.HandleFunc("/rpc/agent", s.handleA2A)
-}
-
-// hybridSearch performs both vector and graph search, then merges results.
-// If a reranker is configured, vector results are reranked before inclusion.
-func (s *Server) hybridSearch(ctx context.Context, query string) (string, error) {
-	s.log.Debug("hybrid search starting", "query", query)
 
-	// Vector search
-	vectorResults, err := s.vectorStore.Query(ctx, query, 5)
-	if err != nil {
-		s.log.Error("vector search failed", "error", err, "query", query)
-		return "", fmt.Errorf("vector search: %w", err)
+	// Discovery: this agent's card, and a registry listing it
+	s.mux.HandleFunc("/.well-known/agent.json", s.handleCard)
+	s.mux.HandleFunc("/agents", s.handleRegistry)
+	s.mux.HandleFunc("/openapi.json", s.handleOpenAPI)
+
+	// Request and token counts per key and endpoint
+	s.mux.HandleFunc("/v1/usage", s.handleUsage)
+	// Token and cost counters by phase, for Prometheus
+	s.mux.HandleFunc("/metrics", s.handleMetrics)
+
+	// Retrieval only, and the web playground built on it
+	s.mux.HandleFunc("/v1/retrieve", s.handleRetrieve)
+	// The ingested documents and their chunks, and their full text for
+	// opening a citation
+	s.mux.HandleFunc("/v1/documents", s.handleDocuments)
+	s.mux.HandleFunc("/v1/documents/{name}/chunks", s.handleDocumentChunks)
+	s.mux.HandleFunc("/v1/documents/{name}/text", s.handleDocumentText)
+	s.mux.Handle("/ui", uiHandler())
+	s.mux.Handle("/ui/", uiHandler())
+	if s.dev {
+		s.mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, basePath(r)+"/ui/", http.StatusFound)
+		})
 	}
-	s.log.Info("vector search completed", "results", len(vectorResults), "query", query)
 
-	// Graph search
-	graphResults, err := s.graphDB.Search(ctx, query, 10)
-	if err != nil {
-		s.log.Warn("graph search failed (non-fatal)", "error", err, "query", query)
-		graphResults = nil
-	} else {
-		s.log.Info("graph search completed", "results", len(graphResults), "query", query)
-	}
-
-	// Rerank vector results if reranker is configured
-	var rerankedDocs []string
-	if s.reranker != nil && len(vectorResults) > 0 {
-		docs := make([]string, len(vectorResults))
-		for i, r := range vectorResults {
-			docs[i] = r.Content
-		}
-		rerankResults, rerankErr := s.reranker.Rerank(ctx, query, docs)
-		if rerankErr != nil {
-			s.log.Warn("reranker failed (using original order)", "error", rerankErr)
-		} else {
-			s.log.Info("reranker completed", "results", len(rerankResults),
-				"top_score", fmt.Sprintf("%.3f", rerankResults[0].RelevanceScore))
-			rerankedDocs = make([]string, len(rerankResults))
-			for i, r := range rerankResults {
-				rerankedDocs[i] = r.Content
-			}
-		}
-	}
+	// Runtime operations, behind AGENT_ADMIN_KEY
+	s.registerAdminRoutes()
+}
 
+// formatPeerAnswers renders the answers of the peers that replied, labelled
+// with each peer's description. Failed peers are logged and left out.
+func (s *Server) formatPeerAnswers(answers []a2a.Answer) string {
 	var sb strings.Builder
-
-	// Add vector results (reranked if available, original order otherwise)
-	if len(vectorResults) > 0 {
-		sb.WriteString("## Relevant Knowledge\n\n")
-		if len(rerankedDocs) > 0 {
-			for i, content := range rerankedDocs {
-				sb.WriteString(fmt.Sprintf("**[%d]**\n", i+1))
-				sb.WriteString(content)
-				sb.WriteString("\n\n")
-			}
-		} else {
-			for i, r := range vectorResults {
-				sb.WriteString(fmt.Sprintf("**[%d] Source: %s** (similarity: %.2f)\n", i+1, r.Source, r.Similarity))
-				sb.WriteString(r.Content)
-				sb.WriteString("\n\n")
-			}
+	for i, a := range answers {
+		if a.Err != nil {
+			s.log.Warn("peer agent failed (non-fatal)", "peer", a.Peer, "error", a.Err)
+			continue
 		}
+		s.log.Info("peer agent answered", "peer", a.Peer, "length", len(a.Content), "took", a.Took.Round(time.Millisecond))
+		if strings.TrimSpace(a.Content) == "" {
+			continue
+		}
+		label := a.Peer
+		if desc := s.peers[i].Peer().Description; desc != "" {
+			label += " — " + desc
+		}
+		sb.WriteString(fmt.Sprintf("**[%s]**\n", label))
+		sb.WriteString(strings.TrimSpace(a.Content))
+		sb.WriteString("\n\n")
 	}
-
-	// Add graph results
-	graphCtx := graph.FormatResults(graphResults)
-	if graphCtx != "" {
-		sb.WriteString("\n## Knowledge Graph Context\n\n")
-		sb.WriteString(graphCtx)
-	}
-
-	return sb.String(), nil
+	return sb.String()
 }
 
 // handleHealth returns a detailed health status including all key metrics.
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	st, release := s.acquireStores()
+	defer release()
 
 	resp := map[string]interface{}{
 		"status":           "ok",
 		"agent":            s.agentCfg.Agent.Name,
 		"version":          s.agentCfg.Agent.Version,
-		"vectors":          s.vectorStore.Count(),
-		"triples":          s.graphDB.Count(),
+		"vectors":          st.vectors.Count(),
+		"triples":          st.graph.Count(),
 		"mcp_tools":        len(s.agentCfg.MCP.Tools),
 		"embed_dimensions": s.appCfg.Embedder.Dimensions,
 		"llm_model":        s.appCfg.LLM.Model,
 		"embed_model":      s.appCfg.Embedder.Model,
-		"reranker_enabled": s.appCfg.Reranker.BaseURL != "",
-		"auth_enabled":     s.apiKey != "",
+		"reranker_enabled": s.rerankerActive(),
+		"auth_enabled":     s.keys.enabled(),
 		"time":             time.Now().UTC().Format(time.RFC3339),
 	}
 
-	if s.appCfg.Reranker.BaseURL != "" {
+	if s.reranker != nil {
 		resp["rerank_model"] = s.appCfg.Reranker.Model
 	}
 
+	// ?deep=1 probes the providers (cached; see dependencyProbe)
+	status := http.StatusOK
+	if r.URL.Query().Get("deep") != "" && s.deepRequested(r) {
+		deps := s.deps.check()
+		resp["dependencies"] = deps
+		if !allPassed(deps) {
+			resp["status"] = "degraded"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(resp)
 }
 
@@ -355,115 +762,284 @@ func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	// A client that lost a stream resumes it from the last event it got
+	if last := r.Header.Get("Last-Event-ID"); last != "" && s.streams != nil {
+		s.handleResume(w, r, last)
+		return
+	}
 
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "read request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 	var req openai.ChatCompletionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	ctx := r.Context()
-
-	// Extract user query for retrieval
-	userQuery := extractLastUserMessage(req.Messages)
-	s.log.Info("chat completion request", "query", userQuery, "stream", req.Stream)
-
-	// Run hybrid search
-	retrievedCtx, err := s.hybridSearch(ctx, userQuery)
-	if err != nil {
-		s.log.Error("hybrid search failed, proceeding without RAG context", "error", err)
-		retrievedCtx = ""
+	// filter is a Kash extension to the OpenAI request that restricts
+	// retrieval to chunks with matching metadata, e.g. {"tags": "billing"},
+	// and kash overrides agent.yaml's retrieval settings for the request
+	var ext struct {
+		Filter map[string]string   `json:"filter"`
+		Kash   *RetrievalOverrides `json:"kash"`
 	}
-
-	if retrievedCtx == "" {
-		s.log.Warn("no RAG context retrieved for query", "query", userQuery)
-	} else {
-		s.log.Debug("RAG context injected", "context_length", len(retrievedCtx))
+	if err := json.Unmarshal(body, &ext); err != nil {
+		http.Error(w, "invalid filter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx := s.withPromptScope(r.Context(), r)
+	if ext.Kash != nil {
+		if err := ext.Kash.validate(); err != nil {
+			http.Error(w, "invalid kash: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if ext.Kash.Tags != "" {
+			ext.Filter = maps.Clone(ext.Filter)
+			if ext.Filter == nil {
+				ext.Filter = map[string]string{}
+			}
+			ext.Filter["tags"] = ext.Kash.Tags
+		}
+		ctx = withOverrides(ctx, ext.Kash)
+		s.log.Debug("retrieval overrides", "overrides", *ext.Kash)
 	}
 
-	// Build augmented messages with system prompt and context
-	augmented := buildAugmentedMessages(s.agentCfg.Agent.SystemPrompt, retrievedCtx, req.Messages)
+	query := extractLastUserMessage(req.Messages)
+	s.log.Info("chat completion request", "query", query, "stream", req.Stream)
 
 	if req.Stream {
-		s.handleStreamingCompletion(w, r, req, augmented)
+		// The stream starts before retrieval, so keep-alives cover it
+		st, err := s.startSSE(ctx, w)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer st.close()
+		direct := func(data []byte) error { return st.event("", data) }
+		if s.streams == nil {
+			s.handleStreamingCompletion(st.ctx, "chatcmpl-"+generateID(), direct, req, ext.Filter)
+			return
+		}
+		// A resumable stream is generated apart from the request, into a
+		// buffer the request (and any that resume it) follows
+		genCtx, cancel := context.WithCancel(context.WithoutCancel(st.ctx))
+		b, err := s.streams.start(requestKeyID(r), cancel)
+		if err != nil || b == nil {
+			cancel()
+			s.log.Warn("chat stream not resumable", "error", err)
+			s.handleStreamingCompletion(st.ctx, "chatcmpl-"+generateID(), direct, req, ext.Filter)
+			return
+		}
+		release := holdRecord(st.ctx)
+		go func() {
+			defer release()
+			defer cancel()
+			defer s.streams.finish(b)
+			s.handleStreamingCompletion(genCtx, b.id, b.append, req, ext.Filter)
+		}()
+		s.follow(st, b, 0)
 		return
 	}
 
 	// Non-streaming response
-	s.log.Debug("calling LLM", "messages", len(augmented))
-	response, err := s.llmClient.ChatWithContext(ctx, augmented, "")
+	answer, err := s.chat(ctx, req.Messages, ext.Filter)
 	if err != nil {
 		s.log.Error("LLM call failed", "error", err)
 		http.Error(w, "upstream LLM request failed", http.StatusBadGateway)
 		return
 	}
-	s.log.Info("LLM response received", "length", len(response))
+	s.writeCompletion(w, answer)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
-		ID:      "chatcmpl-" + generateID(),
-		Object:  "chat.completion",
-		Created: time.Now().Unix(),
-		Model:   s.llmClient.Model(),
-		Choices: []openai.ChatCompletionChoice{
-			{
-				Index: 0,
-				Message: openai.ChatCompletionMessage{
-					Role:    openai.ChatMessageRoleAssistant,
-					Content: response,
-				},
-				FinishReason: openai.FinishReasonStop,
-			},
-		},
+// Chat answers a conversation the way POST /v1/chat/completions does, for
+// callers that run the agent in-process. A non-empty filter restricts
+// retrieval as the request's filter field does. A question or answer that
+// moderation blocks is answered with the refusal.
+func (s *Server) Chat(ctx context.Context, messages []openai.ChatCompletionMessage, filter map[string]string) (string, error) {
+	answer, err := s.chat(ctx, messages, filter)
+	return answer.text, err
+}
+
+// chat is Chat, also returning what moderation and the grounding check
+// found.
+func (s *Server) chat(ctx context.Context, messages []openai.ChatCompletionMessage, filter map[string]string) (checkedAnswer, error) {
+	if v := s.moderate(ctx, moderation.Input, extractLastUserMessage(messages)); v.Blocked() {
+		return checkedAnswer{text: s.refuse(ctx), moderation: v}, nil
+	}
+	augmented, retrieved, reply := s.augment(ctx, messages, filter)
+	if reply != "" {
+		recordResponse(ctx, reply)
+		return checkedAnswer{text: reply}, nil
+	}
+	s.log.Debug("calling LLM", "messages", len(augmented))
+	response, err := s.llmClient.ChatWithContext(ctx, augmented, "")
+	if err != nil {
+		return checkedAnswer{}, fmt.Errorf("LLM request: %w", err)
+	}
+	s.log.Info("LLM response received", "length", len(response))
+	answer := s.checkAnswer(ctx, retrieved, response, func(note string) (string, error) {
+		return s.llmClient.ChatWithContext(ctx, withNote(augmented, note), "")
 	})
+	recordResponse(ctx, answer.text)
+	return answer, nil
 }
 
-func (s *Server) handleStreamingCompletion(w http.ResponseWriter, r *http.Request, req openai.ChatCompletionRequest, messages []openai.ChatCompletionMessage) {
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
+// augment runs hybrid search for the last user message and returns the
+// messages for the LLM: the system prompt, the retrieved context, and the
+// conversation without its own system messages, which reach the system
+// prompt only as agent.client_system_prompt allows. When the search found
+// nothing and retrieval.no_context asks for it, it returns the reply to
+// send instead of calling the LLM. A request whose overrides disable RAG
+// is not searched. retrieved is the context the messages hold.
+func (s *Server) augment(ctx context.Context, messages []openai.ChatCompletionMessage, filter map[string]string) (augmented []openai.ChatCompletionMessage, retrieved, reply string) {
+	if overridesFrom(ctx).DisableRAG {
+		s.log.Debug("retrieval disabled for the request")
+		return buildAugmentedMessages(s.systemPrompt(ctx, messages), "", messages), "", ""
+	}
+	userQuery := extractLastUserMessage(messages)
+	retrievedCtx, err := s.hybridSearch(ctx, userQuery, filter)
+	if err != nil {
+		s.log.Error("hybrid search failed, proceeding without RAG context", "error", err)
+		retrievedCtx = ""
+	}
 
-	flusher, ok := w.(http.Flusher)
-	if !ok {
-		http.Error(w, "streaming not supported", http.StatusInternalServerError)
-		return
+	if retrievedCtx == "" {
+		s.log.Warn("no RAG context retrieved for query", "query", userQuery)
+		if reply := s.noContextReply(); err == nil && reply != "" {
+			s.log.Info("answering with the no-context reply", "query", userQuery)
+			return nil, "", reply
+		}
+	} else {
+		s.log.Debug("RAG context injected", "context_length", len(retrievedCtx))
 	}
+	return buildAugmentedMessages(s.systemPrompt(ctx, messages), retrievedCtx, messages), retrievedCtx, ""
+}
+
+// No-context actions of agent.yaml's retrieval.no_context block.
+const (
+	noContextAnswer = "answer"
+	noContextReply  = "reply"
+)
+
+// defaultNoContextMessage is the no-context reply when agent.yaml sets none.
+const defaultNoContextMessage = "I don't have information about that."
 
+// noContextReply is the answer to a question retrieval found nothing for,
+// or "" when the LLM answers it.
+func (s *Server) noContextReply() string {
+	nc := s.agentCfg.Retrieval.NoContext
+	if nc.Action != noContextReply {
+		return ""
+	}
+	if strings.TrimSpace(nc.Message) == "" {
+		return defaultNoContextMessage
+	}
+	return nc.Message
+}
+
+// handleStreamingCompletion answers req as chat completion chunks with id,
+// passing each event to emit. A question moderation blocks is answered with
+// the refusal, ending the stream with the content_filter finish reason, and
+// the no-context reply is sent as one chunk. When moderation checks answers
+// or grounding may regenerate them, the answer is checked whole and sent as
+// one chunk. Otherwise its grounding is checked once it is streamed, and
+// reported in a last chunk after the disclaimer, if any. The LLM call stops
+// as soon as ctx is done, e.g. when the client goes away.
+func (s *Server) handleStreamingCompletion(ctx context.Context, id string, emit func([]byte) error, req openai.ChatCompletionRequest, filter map[string]string) {
+	var messages []openai.ChatCompletionMessage
+	var retrieved, reply string
+	refusal := s.moderate(ctx, moderation.Input, extractLastUserMessage(req.Messages))
+	if refusal.Blocked() {
+		reply = s.refuse(ctx)
+	} else {
+		refusal = nil
+		messages, retrieved, reply = s.augment(ctx, req.Messages, filter)
+	}
 	req.Messages = messages
-	id := "chatcmpl-" + generateID()
-
-	err := s.llmClient.ChatCompletionStream(r.Context(), req, func(delta string) error {
-		chunk := openai.ChatCompletionStreamResponse{
-			ID:      id,
-			Object:  "chat.completion.chunk",
-			Created: time.Now().Unix(),
-			Model:   s.llmClient.Model(),
-			Choices: []openai.ChatCompletionStreamChoice{
-				{
-					Index: 0,
-					Delta: openai.ChatCompletionStreamChoiceDelta{
-						Role:    openai.ChatMessageRoleAssistant,
-						Content: delta,
+
+	var answer strings.Builder
+	defer func() {
+		if refusal == nil {
+			recordResponse(ctx, answer.String())
+		}
+	}()
+	var report *grounding.Report
+	write := func(delta string, finish openai.FinishReason, report *grounding.Report) error {
+		chunk := streamChunk{
+			ChatCompletionStreamResponse: openai.ChatCompletionStreamResponse{
+				ID:      id,
+				Object:  "chat.completion.chunk",
+				Created: time.Now().Unix(),
+				Model:   s.llmClient.Model(),
+				Choices: []openai.ChatCompletionStreamChoice{
+					{
+						Index: 0,
+						Delta: openai.ChatCompletionStreamChoiceDelta{
+							Role:    openai.ChatMessageRoleAssistant,
+							Content: delta,
+						},
+						FinishReason: finish,
 					},
 				},
 			},
+			Grounding: report,
 		}
 		data, _ := json.Marshal(chunk)
-		fmt.Fprintf(w, "data: %s\n\n", data)
-		flusher.Flush()
-		return nil
-	})
+		return emit(data)
+	}
+	send := func(delta string) error {
+		answer.WriteString(delta)
+		var finish openai.FinishReason
+		if refusal != nil {
+			finish = openai.FinishReasonContentFilter
+		}
+		return write(delta, finish, nil)
+	}
+	var err error
+	switch {
+	case reply != "":
+		err = send(reply)
+	case s.moderator.Checks(moderation.Output) || s.verifier.Action() == grounding.Regenerate:
+		var full strings.Builder
+		err = s.llmClient.ChatCompletionStream(ctx, req, func(delta string) error {
+			full.WriteString(delta)
+			return nil
+		})
+		if err != nil || ctx.Err() != nil {
+			break
+		}
+		checked := s.checkAnswer(ctx, retrieved, full.String(), func(note string) (string, error) {
+			return s.llmClient.ChatWithContext(ctx, withNote(messages, note), "")
+		})
+		refusal, report = checked.moderation, checked.grounding
+		err = send(checked.text)
+	default:
+		err = s.llmClient.ChatCompletionStream(ctx, req, send)
+		if err == nil && s.verifier != nil && ctx.Err() == nil {
+			report = s.ground(ctx, retrieved, answer.String())
+			if s.verifier.Low(report) && s.verifier.Action() == grounding.Disclaim {
+				err = send(s.verifier.Disclaim(report))
+			}
+		}
+	}
 
+	if ctx.Err() != nil {
+		s.log.Info("stream stopped before the answer ended", "sent_length", answer.Len())
+		return
+	}
 	if err != nil {
 		s.log.Error("streaming LLM error", "error", err)
 		errPayload, _ := json.Marshal(map[string]string{"error": "upstream LLM request failed"})
-		fmt.Fprintf(w, "data: %s\n\n", errPayload)
-		flusher.Flush()
+		emit(errPayload)
 		return
 	}
 
-	fmt.Fprintf(w, "data: [DONE]\n\n")
-	flusher.Flush()
+	if report != nil {
+		write("", openai.FinishReasonStop, report)
+	}
+	emit([]byte("[DONE]"))
 }
 
 func extractLastUserMessage(messages []openai.ChatCompletionMessage) string {
@@ -516,11 +1092,23 @@ func loadAgentConfig(path string) (*AgentConfig, error) {
 	return &cfg, nil
 }
 
-func corsMiddleware(next http.Handler) http.Handler {
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", "*")
+		h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		h.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID")
+		if origin := r.Header.Get("Origin"); s.dev && origin != "" {
+			// In development any origin may call with credentials and any
+			// headers, e.g. a frontend on its own dev server port
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Credentials", "true")
+			h.Set("Access-Control-Expose-Headers", "*")
+			h.Add("Vary", "Origin")
+			if req := r.Header.Get("Access-Control-Request-Headers"); req != "" {
+				h.Set("Access-Control-Allow-Headers", req)
+			}
+		}
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/akashicode/kash/internal/snapshot"
+)
+
+// snapshotPaths locates the stores a rollback replaces.
+func (s *Server) snapshotPaths() snapshot.Paths {
+	return snapshot.Paths{Vectors: s.vectorPath, Graph: s.graphPath, Documents: s.documentsPath, Manifest: s.manifestPath}
+}
+
+// handleAdminSnapshots serves GET /admin/snapshots: the kept snapshots and
+// the one being served.
+func (s *Server) handleAdminSnapshots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.snapshotDir == "" {
+		writeJSONStatus(w, http.StatusNotImplemented, map[string]string{"error": "snapshots are not available in this server"})
+		return
+	}
+	infos, err := snapshot.List(s.snapshotDir)
+	if err != nil {
+		writeJSONStatus(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if infos == nil {
+		infos = []snapshot.Info{}
+	}
+	writeJSON(w, map[string]interface{}{
+		"current":   snapshot.Current(infos, s.manifestPath),
+		"snapshots": infos,
+	})
+}
+
+// handleAdminRollback serves POST /admin/rollback: it restores a snapshot
+// into data/ and reloads the stores. The body may name the version,
+// {"version": "v3"}; without one the snapshot before the current is used.
+func (s *Server) handleAdminRollback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.snapshotDir == "" {
+		writeJSONStatus(w, http.StatusNotImplemented, map[string]string{"error": "snapshots are not available in this server"})
+		return
+	}
+	var req struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, `request body must be {"version": "v3"}`, http.StatusBadRequest)
+		return
+	}
+
+	// A re-ingest would overwrite the restored stores, so the two exclude
+	// each other
+	s.reingestMu.Lock()
+	if s.reingestState.Running {
+		s.reingestMu.Unlock()
+		writeJSONStatus(w, http.StatusConflict, map[string]string{"error": "a re-ingest is running — retry when it finishes"})
+		return
+	}
+	info, err := s.rollback(req.Version)
+	s.reingestMu.Unlock()
+	if errors.Is(err, snapshot.ErrNotFound) {
+		writeJSONStatus(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSONStatus(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := s.Reload(); err != nil {
+		writeJSONStatus(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	st, release := s.acquireStores()
+	defer release()
+	writeJSON(w, map[string]interface{}{
+		"status":  "rolled back",
+		"version": info.Version,
+		"vectors": st.vectors.Count(),
+		"triples": st.graph.Count(),
+	})
+}
+
+func (s *Server) rollback(version string) (snapshot.Info, error) {
+	infos, err := snapshot.List(s.snapshotDir)
+	if err != nil {
+		return snapshot.Info{}, err
+	}
+	info, err := snapshot.Find(infos, version, snapshot.Current(infos, s.manifestPath))
+	if err != nil {
+		return snapshot.Info{}, err
+	}
+	if err := snapshot.Restore(s.snapshotDir, info, s.snapshotPaths()); err != nil {
+		return snapshot.Info{}, err
+	}
+	s.log.Info("rolled back", "version", info.Version, "built_at", info.BuiltAt)
+	return info, nil
+}
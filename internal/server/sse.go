@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sseStream writes a server-sent event stream. While nothing else is
+// written, e.g. during retrieval or before the LLM's first token, it sends
+// a comment every keepAlive so that proxies do not close the idle stream.
+// When the client goes away, its context is cancelled at once, which stops
+// the upstream LLM call.
+type sseStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	done    chan struct{}
+
+	mu   sync.Mutex
+	last time.Time
+	err  error
+}
+
+// startSSE sends the headers of an event stream and starts its keep-alives.
+// The stream's context, derived from ctx, is cancelled when ctx is or a
+// write fails; close the stream when done.
+func (s *Server) startSSE(ctx context.Context, w http.ResponseWriter) (*sseStream, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming not supported")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Tell nginx not to buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithCancel(ctx)
+	st := &sseStream{
+		w: w, flusher: flusher, ctx: ctx, cancel: cancel,
+		stop: make(chan struct{}), done: make(chan struct{}),
+		last: time.Now(),
+	}
+	go st.keepAlive(s.sseKeepAlive)
+	return st, nil
+}
+
+// keepAlive sends a comment whenever the stream was idle for interval,
+// until the stream is closed or its context is done.
+func (st *sseStream) keepAlive(interval time.Duration) {
+	defer close(st.done)
+	if interval <= 0 {
+		select {
+		case <-st.stop:
+		case <-st.ctx.Done():
+		}
+		return
+	}
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-st.stop:
+			return
+		case <-st.ctx.Done():
+			return
+		case <-ticker.C:
+			st.mu.Lock()
+			if time.Since(st.last) >= interval {
+				st.writeLocked(": keep-alive\n\n")
+			}
+			st.mu.Unlock()
+		}
+	}
+}
+
+// event sends data as one event, with an id line unless id is "". It
+// returns an error once the client is gone.
+func (st *sseStream) event(id string, data []byte) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	text := "data: " + string(data) + "\n\n"
+	if id != "" {
+		text = "id: " + id + "\n" + text
+	}
+	return st.writeLocked(text)
+}
+
+func (st *sseStream) writeLocked(text string) error {
+	if st.err != nil {
+		return st.err
+	}
+	if err := st.ctx.Err(); err != nil {
+		st.err = err
+		return err
+	}
+	if _, err := fmt.Fprint(st.w, text); err != nil {
+		st.err = fmt.Errorf("client disconnected: %w", err)
+		st.cancel()
+		return st.err
+	}
+	st.flusher.Flush()
+	st.last = time.Now()
+	return nil
+}
+
+// close stops the keep-alives and releases the stream's context.
+func (st *sseStream) close() {
+	close(st.stop)
+	<-st.done
+	st.cancel()
+}
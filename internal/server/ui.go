@@ -0,0 +1,37 @@
+package server
+
+import (
+	"embed"
+	"net/http"
+	"strings"
+)
+
+// uiFiles is the chat playground served at /ui/.
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// uiHandler serves the playground. The page and its assets are public; the
+// API calls it makes carry the key the user enters in it.
+func uiHandler() http.Handler {
+	// Request paths (/ui/app.js) match the embedded paths (ui/app.js)
+	files := http.FileServer(http.FS(uiFiles))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		// The page resolves API paths relative to <base>/ui/, so it needs the
+		// trailing slash
+		if r.URL.Path == "/ui" {
+			http.Redirect(w, r, basePath(r)+"/ui/", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	})
+}
+
+func isUIPath(path string) bool {
+	return path == "/ui" || strings.HasPrefix(path, "/ui/")
+}
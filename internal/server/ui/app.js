@@ -0,0 +1,251 @@
+// Kash playground. All API paths are relative to the page (served at
+// <base>/ui/), so the same page works for a standalone agent and for one
+// agent under /agents/<name>/ in multi-agent mode.
+(function () {
+  "use strict";
+
+  const api = (path) => new URL("../" + path, window.location.href).toString();
+  const $ = (id) => document.getElementById(id);
+
+  const keyInput = $("api-key");
+  keyInput.value = localStorage.getItem("kash.apiKey") || "";
+  keyInput.addEventListener("change", () => localStorage.setItem("kash.apiKey", keyInput.value.trim()));
+
+  function headers() {
+    const h = { "Content-Type": "application/json" };
+    const key = keyInput.value.trim();
+    if (key) h["Authorization"] = "Bearer " + key;
+    return h;
+  }
+
+  // el builds a DOM element; text is always set through textContent.
+  function el(tag, cls, text) {
+    const e = document.createElement(tag);
+    if (cls) e.className = cls;
+    if (text !== undefined) e.textContent = text;
+    return e;
+  }
+
+  // parseFilter turns "tags=billing, lang=en" into {tags: "billing", lang: "en"}.
+  function parseFilter(s) {
+    const filter = {};
+    s.split(",").forEach((pair) => {
+      const i = pair.indexOf("=");
+      if (i > 0) filter[pair.slice(0, i).trim()] = pair.slice(i + 1).trim();
+    });
+    return Object.keys(filter).length ? filter : undefined;
+  }
+
+  async function errorText(resp) {
+    const body = (await resp.text()).trim();
+    try {
+      return JSON.parse(body).error || body;
+    } catch (_) {
+      return body || resp.status + " " + resp.statusText;
+    }
+  }
+
+  // ── Agent card ──────────────────────────────────────────────────────────
+  fetch(api(".well-known/agent.json"))
+    .then((r) => (r.ok ? r.json() : null))
+    .then((card) => {
+      if (!card) return;
+      document.title = card.agent + " · Kash Playground";
+      $("agent-name").textContent = card.agent;
+      const k = card.knowledge || {};
+      const parts = [
+        card.description,
+        k.vectors + " vectors",
+        k.triples + " triples",
+        card.capabilities && card.capabilities.reranker ? "reranker" : "",
+        card.peers && card.peers.length ? "peers: " + card.peers.join(", ") : "",
+      ].filter(Boolean);
+      $("agent-meta").textContent = parts.join(" · ");
+      if (card.capabilities && card.capabilities.auth_required && !keyInput.value) {
+        keyInput.focus();
+      }
+    })
+    .catch(() => {});
+
+  // ── Tabs ────────────────────────────────────────────────────────────────
+  document.querySelectorAll(".tab").forEach((tab) => {
+    tab.addEventListener("click", () => {
+      document.querySelectorAll(".tab").forEach((t) => t.classList.toggle("active", t === tab));
+      document.querySelectorAll(".panel").forEach((p) => p.classList.toggle("active", p.id === "tab-" + tab.dataset.tab));
+    });
+  });
+
+  // ── Retrieval results ───────────────────────────────────────────────────
+  async function retrieve(query, filter, peers) {
+    const resp = await fetch(api("v1/retrieve"), {
+      method: "POST",
+      headers: headers(),
+      body: JSON.stringify({ query: query, filter: filter, peers: peers }),
+    });
+    if (!resp.ok) throw new Error(await errorText(resp));
+    return resp.json();
+  }
+
+  function renderRetrieval(target, res, showContext) {
+    target.replaceChildren();
+    const summary = res.chunks.length + " chunks, " + res.graph.length + " graph facts" +
+      (res.reranked ? ", reranked" : "") + " · " + res.took_ms + " ms";
+    target.append(el("p", "muted", summary));
+
+    if (res.chunks.length) target.append(el("h3", "", "Chunks"));
+    res.chunks.forEach((c) => {
+      const d = el("details", "chunk");
+      const s = el("summary");
+      s.append(el("span", "rank", "[" + c.rank + "]"), el("span", "", c.citation));
+      if (!res.reranked) s.append(el("span", "score", c.similarity.toFixed(3)));
+      d.append(s, el("div", "content", c.content));
+      const meta = Object.entries(c.metadata || {}).map(([k, v]) => k + "=" + v).join("  ");
+      if (meta) d.append(el("div", "meta", meta));
+      target.append(d);
+    });
+
+    if (res.graph.length) {
+      target.append(el("h3", "", "Knowledge graph"));
+      const table = el("table");
+      const head = el("tr");
+      ["Subject", "Predicate", "Object"].forEach((h) => head.append(el("th", "", h)));
+      table.append(head);
+      res.graph.forEach((t) => {
+        const row = el("tr");
+        [t.subject, t.predicate, t.object].forEach((v) => row.append(el("td", "", v)));
+        table.append(row);
+      });
+      target.append(table);
+    }
+
+    (res.peers || []).forEach((p) => {
+      target.append(el("h3", "", "Peer: " + p.peer + " (" + p.took_ms + " ms)"));
+      target.append(p.error ? el("p", "error", p.error) : el("div", "chunk content", p.content));
+    });
+
+    if (showContext) {
+      target.append(el("h3", "", "Context sent to the LLM"));
+      target.append(el("pre", "", res.context || "(empty)"));
+    }
+  }
+
+  $("retrieve-form").addEventListener("submit", async (e) => {
+    e.preventDefault();
+    const query = $("retrieve-query").value.trim();
+    if (!query) return;
+    const out = $("retrieve-results");
+    out.replaceChildren(el("p", "muted", "Searching…"));
+    try {
+      const res = await retrieve(query, parseFilter($("retrieve-filter").value), $("retrieve-peers").checked);
+      renderRetrieval(out, res, true);
+    } catch (err) {
+      out.replaceChildren(el("p", "error", err.message));
+    }
+  });
+
+  // ── Chat ────────────────────────────────────────────────────────────────
+  const history = [];
+  const messagesEl = $("messages");
+
+  function addMessage(role, text) {
+    messagesEl.querySelector(".empty")?.remove();
+    const m = el("div", "msg " + role, text);
+    messagesEl.append(m);
+    messagesEl.scrollTop = messagesEl.scrollHeight;
+    return m;
+  }
+
+  // streamCompletion reads the server-sent events of a streaming completion
+  // and calls onDelta with each content fragment.
+  async function streamCompletion(resp, onDelta) {
+    const reader = resp.body.getReader();
+    const decoder = new TextDecoder();
+    let buf = "";
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) return;
+      buf += decoder.decode(value, { stream: true });
+      let i;
+      while ((i = buf.indexOf("\n\n")) >= 0) {
+        const event = buf.slice(0, i);
+        buf = buf.slice(i + 2);
+        for (const line of event.split("\n")) {
+          if (!line.startsWith("data:")) continue;
+          const data = line.slice(5).trim();
+          if (data === "[DONE]") return;
+          const chunk = JSON.parse(data);
+          if (chunk.error) throw new Error(chunk.error);
+          const delta = chunk.choices && chunk.choices[0] && chunk.choices[0].delta;
+          if (delta && delta.content) onDelta(delta.content);
+        }
+      }
+    }
+  }
+
+  async function send(text) {
+    const filter = parseFilter($("chat-filter").value);
+    const stream = $("chat-stream").checked;
+    history.push({ role: "user", content: text });
+    addMessage("user", text);
+    const reply = addMessage("assistant", "…");
+    $("chat-send").disabled = true;
+
+    // The retrieval panel runs the same search the completion does; peers
+    // are skipped here so they are only asked once.
+    const sources = $("chat-sources");
+    sources.replaceChildren(el("p", "muted", "Retrieving…"));
+    retrieve(text, filter, false)
+      .then((res) => renderRetrieval(sources, res, false))
+      .catch((err) => sources.replaceChildren(el("p", "error", err.message)));
+
+    let answer = "";
+    try {
+      const resp = await fetch(api("v1/chat/completions"), {
+        method: "POST",
+        headers: headers(),
+        body: JSON.stringify({ model: "kash", messages: history, stream: stream, filter: filter }),
+      });
+      if (!resp.ok) throw new Error(await errorText(resp));
+      if (stream) {
+        await streamCompletion(resp, (delta) => {
+          answer += delta;
+          reply.textContent = answer;
+          messagesEl.scrollTop = messagesEl.scrollHeight;
+        });
+      } else {
+        const body = await resp.json();
+        answer = body.choices[0].message.content;
+        reply.textContent = answer;
+      }
+      history.push({ role: "assistant", content: answer });
+    } catch (err) {
+      reply.textContent = (answer ? answer + "\n\n" : "") + "Error: " + err.message;
+      reply.classList.add("error");
+      history.pop();
+    } finally {
+      $("chat-send").disabled = false;
+    }
+  }
+
+  $("chat-form").addEventListener("submit", (e) => {
+    e.preventDefault();
+    const input = $("chat-input");
+    const text = input.value.trim();
+    if (!text || $("chat-send").disabled) return;
+    input.value = "";
+    send(text);
+  });
+
+  $("chat-input").addEventListener("keydown", (e) => {
+    if (e.key === "Enter" && !e.shiftKey) {
+      e.preventDefault();
+      $("chat-form").requestSubmit();
+    }
+  });
+
+  $("chat-clear").addEventListener("click", () => {
+    history.length = 0;
+    messagesEl.replaceChildren(el("p", "empty muted", "Ask the agent something. Retrieved chunks appear on the right."));
+    $("chat-sources").replaceChildren(el("p", "muted", "Nothing retrieved yet."));
+  });
+})();
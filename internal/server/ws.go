@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"golang.org/x/net/websocket"
+
+	"github.com/akashicode/kash/internal/grounding"
+)
+
+// maxWSMessageBytes bounds a message a WebSocket chat client sends.
+const maxWSMessageBytes = 1 << 20
+
+// wsMessage is a message from a WebSocket chat client.
+type wsMessage struct {
+	// Type is "message" (ask), "cancel" (stop the answer being streamed),
+	// or "reset" (forget the conversation)
+	Type    string              `json:"type"`
+	Content string              `json:"content"`
+	Filter  map[string]string   `json:"filter"`
+	Kash    *RetrievalOverrides `json:"kash"`
+}
+
+// wsEvent is an event the server sends a WebSocket chat client.
+type wsEvent struct {
+	// Type is "retrieval", "tool", "token", "done", "cancelled", "reset",
+	// or "error"
+	Type string `json:"type"`
+	// ID is the answer's chat completion id
+	ID      string `json:"id,omitempty"`
+	Content string `json:"content,omitempty"`
+	// Sources and Triples describe a retrieval
+	Sources []wsSource `json:"sources,omitempty"`
+	Triples int        `json:"triples,omitempty"`
+	// Tool, Name, and DurationMS describe a call to a peer agent
+	Tool       string `json:"tool,omitempty"`
+	Name       string `json:"name,omitempty"`
+	DurationMS int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// FinishReason and Grounding end an answer
+	FinishReason openai.FinishReason `json:"finish_reason,omitempty"`
+	Grounding    *grounding.Report   `json:"grounding,omitempty"`
+}
+
+// wsSource is a chunk a retrieval found.
+type wsSource struct {
+	Source     string  `json:"source"`
+	Similarity float32 `json:"similarity"`
+}
+
+// handleChatWS serves /v1/chat/ws, a chat over a WebSocket for interactive
+// clients. The connection holds the conversation: each message the client
+// sends is answered with its history, through the same pipeline as a
+// streamed chat completion, and the server sends the retrieval, the calls to
+// peer agents, and the answer's tokens as events.
+func (s *Server) handleChatWS(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 1 {
+		http.Error(w, "WebSocket needs HTTP/1.1", http.StatusBadRequest)
+		return
+	}
+	ws := websocket.Server{
+		// Clients without an Origin header are welcome, as with CORS
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			conn.MaxPayloadBytes = maxWSMessageBytes
+			s.serveChatWS(r, conn)
+		},
+	}
+	ws.ServeHTTP(w, r)
+}
+
+// wsChat is the conversation of one WebSocket connection.
+type wsChat struct {
+	s    *Server
+	r    *http.Request
+	conn *websocket.Conn
+
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	history []openai.ChatCompletionMessage
+	// cancel stops the answer being streamed; nil when there is none
+	cancel  context.CancelFunc
+	answers sync.WaitGroup
+}
+
+func (s *Server) serveChatWS(r *http.Request, conn *websocket.Conn) {
+	ctx, cancel := context.WithCancel(s.withPromptScope(r.Context(), r))
+	recordsOwn(ctx)
+	c := &wsChat{s: s, r: r, conn: conn}
+	defer func() {
+		cancel()
+		c.answers.Wait()
+	}()
+	s.log.Info("chat websocket opened")
+
+	for {
+		var msg wsMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.log.Debug("chat websocket closed", "error", err)
+			}
+			return
+		}
+		switch msg.Type {
+		case "message":
+			c.ask(ctx, msg)
+		case "cancel":
+			c.mu.Lock()
+			if c.cancel != nil {
+				c.cancel()
+			}
+			c.mu.Unlock()
+		case "reset":
+			c.mu.Lock()
+			if c.cancel != nil {
+				c.cancel()
+			}
+			c.history = nil
+			c.mu.Unlock()
+			c.send(wsEvent{Type: "reset"})
+		default:
+			c.send(wsEvent{Type: "error", Error: fmt.Sprintf("unknown message type %q", msg.Type)})
+		}
+	}
+}
+
+// ask answers msg in the background, unless an answer is still streaming.
+func (c *wsChat) ask(ctx context.Context, msg wsMessage) {
+	if strings.TrimSpace(msg.Content) == "" {
+		c.send(wsEvent{Type: "error", Error: "content is required"})
+		return
+	}
+	if msg.Kash != nil {
+		if err := msg.Kash.validate(); err != nil {
+			c.send(wsEvent{Type: "error", Error: "invalid kash: " + err.Error()})
+			return
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.send(wsEvent{Type: "error", Error: "an answer is still streaming; send cancel first"})
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.history = append(c.history, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: msg.Content})
+	messages := slices.Clone(c.history)
+
+	c.answers.Add(1)
+	go func() {
+		defer c.answers.Done()
+		defer cancel()
+		answer := c.answer(ctx, messages, msg)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.cancel = nil
+		if answer != "" {
+			c.history = append(c.history, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: answer})
+		}
+	}()
+}
+
+// answer streams the answer to messages as events and returns the text the
+// client was sent. Each answer is a request of its own in the audit log and
+// the usage report.
+func (c *wsChat) answer(ctx context.Context, messages []openai.ChatCompletionMessage, msg wsMessage) string {
+	s := c.s
+	start := time.Now()
+	ctx, rec := s.newRecord(ctx)
+	defer s.writeRecord(httpOrigin(c.r), rec, start, http.StatusOK)
+
+	filter := msg.Filter
+	if msg.Kash != nil {
+		if msg.Kash.Tags != "" {
+			filter = maps.Clone(filter)
+			if filter == nil {
+				filter = map[string]string{}
+			}
+			filter["tags"] = msg.Kash.Tags
+		}
+		ctx = withOverrides(ctx, msg.Kash)
+	}
+	id := "chatcmpl-" + generateID()
+	ctx = withRetrievalObserver(ctx, func(res *hybridResult) {
+		ev := wsEvent{Type: "retrieval", ID: id, Triples: len(res.Graph)}
+		for _, ch := range res.Chunks {
+			ev.Sources = append(ev.Sources, wsSource{Source: ch.Source, Similarity: ch.Similarity})
+		}
+		c.send(ev)
+		for _, a := range res.Peers {
+			ev := wsEvent{Type: "tool", ID: id, Tool: "peer", Name: a.Peer, DurationMS: a.Took.Milliseconds()}
+			if a.Err != nil {
+				ev.Error = a.Err.Error()
+			}
+			c.send(ev)
+		}
+	})
+	s.log.Info("chat websocket message", "query", msg.Content)
+
+	var sr streamReader
+	req := openai.ChatCompletionRequest{Messages: messages, Stream: true}
+	s.handleStreamingCompletion(ctx, id, func(data []byte) error {
+		token, err := sr.read(data)
+		switch {
+		case err != nil:
+			return c.send(wsEvent{Type: "error", ID: id, Error: err.Error()})
+		case sr.done:
+			return c.send(wsEvent{Type: "done", ID: id, FinishReason: sr.finishReason, Grounding: sr.grounding})
+		case token != "":
+			return c.send(wsEvent{Type: "token", ID: id, Content: token})
+		}
+		return nil
+	}, req, filter)
+
+	if !sr.done && !sr.failed {
+		c.send(wsEvent{Type: "cancelled", ID: id})
+	}
+	return sr.text.String()
+}
+
+// send writes ev to the client. A failed write closes the connection, which
+// ends the chat.
+func (c *wsChat) send(ev wsEvent) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if err := websocket.JSON.Send(c.conn, ev); err != nil {
+		c.conn.Close()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// Diff is what changed in the knowledge base between two builds.
+type Diff struct {
+	// Documents lists the added, removed, and changed documents by name
+	Documents []DocumentChange `json:"documents"`
+	// UnchangedDocuments counts the documents whose chunks all stayed the same
+	UnchangedDocuments int `json:"unchanged_documents"`
+	ChunksBefore       int `json:"chunks_before"`
+	ChunksAfter        int `json:"chunks_after"`
+	TriplesBefore      int `json:"triples_before"`
+	TriplesAfter       int `json:"triples_after"`
+	// TriplesAdded and TriplesRemoved are sorted by subject, predicate, and
+	// object
+	TriplesAdded   []graph.Triple `json:"triples_added"`
+	TriplesRemoved []graph.Triple `json:"triples_removed"`
+}
+
+// Document change statuses.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// DocumentChange describes one document that differs between two builds.
+type DocumentChange struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// ChunksBefore and ChunksAfter are the document's chunk counts
+	ChunksBefore int `json:"chunks_before"`
+	ChunksAfter  int `json:"chunks_after"`
+	// NewChunks counts the chunks whose text the older build did not have
+	NewChunks int `json:"new_chunks"`
+}
+
+// Empty reports whether the two builds hold the same knowledge.
+func (d Diff) Empty() bool {
+	return len(d.Documents) == 0 && len(d.TriplesAdded) == 0 && len(d.TriplesRemoved) == 0
+}
+
+// Compare reports how the build at to differs from the build at from. Both
+// may be a snapshot or the working stores in data/; the graph stores are
+// read from temporary copies, so a running server does not block it.
+func Compare(ctx context.Context, from, to Paths) (Diff, error) {
+	before, err := loadBuild(ctx, from)
+	if err != nil {
+		return Diff{}, err
+	}
+	after, err := loadBuild(ctx, to)
+	if err != nil {
+		return Diff{}, err
+	}
+
+	var d Diff
+	d.ChunksBefore, d.ChunksAfter = before.chunks, after.chunks
+	d.TriplesBefore, d.TriplesAfter = len(before.triples), len(after.triples)
+
+	names := map[string]bool{}
+	for name := range before.docs {
+		names[name] = true
+	}
+	for name := range after.docs {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		a, inBefore := before.docs[name]
+		b, inAfter := after.docs[name]
+		change := DocumentChange{Name: name, ChunksBefore: len(a), ChunksAfter: len(b)}
+		switch {
+		case !inBefore:
+			change.Status, change.NewChunks = Added, len(b)
+		case !inAfter:
+			change.Status = Removed
+		default:
+			change.NewChunks = newChunks(a, b)
+			if change.NewChunks == 0 && len(a) == len(b) {
+				d.UnchangedDocuments++
+				continue
+			}
+			change.Status = Changed
+		}
+		d.Documents = append(d.Documents, change)
+	}
+
+	d.TriplesAdded = subtract(after.triples, before.triples)
+	d.TriplesRemoved = subtract(before.triples, after.triples)
+	return d, nil
+}
+
+// build is what Compare reads from one build.
+type build struct {
+	// docs maps each document to the text of its chunks
+	docs    map[string][]string
+	chunks  int
+	triples []graph.Triple
+}
+
+func loadBuild(ctx context.Context, p Paths) (build, error) {
+	chunks, err := vector.ReadDocuments(p.Vectors)
+	if err != nil {
+		return build{}, err
+	}
+	b := build{docs: map[string][]string{}, chunks: len(chunks)}
+	for _, c := range chunks {
+		b.docs[c.Source] = append(b.docs[c.Source], c.Content)
+	}
+	// Documents that produced no chunks are only in the manifest
+	m, err := manifest.Load(p.Manifest)
+	if err != nil && !errors.Is(err, manifest.ErrNotFound) {
+		return build{}, err
+	}
+	if m != nil {
+		for _, doc := range m.Documents {
+			if _, ok := b.docs[doc.Name]; !ok {
+				b.docs[doc.Name] = nil
+			}
+		}
+	}
+
+	gdb, err := graph.OpenSnapshot(p.Graph)
+	if err != nil {
+		return build{}, fmt.Errorf("open graph db: %w", err)
+	}
+	defer gdb.Close()
+	if b.triples, err = gdb.Triples(ctx); err != nil {
+		return build{}, err
+	}
+	return b, nil
+}
+
+// newChunks counts the chunks of after whose text is not among before.
+func newChunks(before, after []string) int {
+	have := map[string]int{}
+	for _, text := range before {
+		have[text]++
+	}
+	n := 0
+	for _, text := range after {
+		if have[text] > 0 {
+			have[text]--
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// subtract returns the triples of a that b lacks, keeping a's order.
+func subtract(a, b []graph.Triple) []graph.Triple {
+	in := make(map[graph.Triple]bool, len(b))
+	for _, t := range b {
+		in[t] = true
+	}
+	out := []graph.Triple{}
+	for _, t := range a {
+		if !in[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,349 @@
+// Package snapshot keeps every successful build as an immutable, numbered
+// version of the compiled stores, so a bad knowledge update can be rolled
+// back by restoring an earlier one.
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/manifest"
+)
+
+// DefaultDir is where 'kash build' keeps snapshots, relative to the project.
+var DefaultDir = filepath.Join(".kash", "snapshots")
+
+// DefaultKeep is how many snapshots are kept when agent.yaml does not say.
+const DefaultKeep = 5
+
+// infoFile describes a snapshot inside its directory.
+const infoFile = "snapshot.json"
+
+// Names of the copies inside a snapshot directory.
+const (
+	vectorsName   = "memory.chromem"
+	graphName     = "knowledge.cayley"
+	documentsName = "documents.store"
+	manifestName  = "manifest.json"
+)
+
+// ErrNotFound is returned for a version that is not kept.
+var ErrNotFound = errors.New("snapshot not found")
+
+// Paths locates the compiled stores of an agent.
+type Paths struct {
+	Vectors string
+	Graph   string
+	// Documents is the full text of the documents; empty when not kept
+	Documents string
+	Manifest  string
+}
+
+// Info describes one snapshot.
+type Info struct {
+	Version   string    `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// BuiltAt is the build time recorded in the snapshot's manifest
+	BuiltAt     time.Time `json:"built_at"`
+	KashVersion string    `json:"kash_version,omitempty"`
+	Documents   int       `json:"documents"`
+	Chunks      int       `json:"chunks"`
+	Vectors     int       `json:"vectors"`
+	Triples     int64     `json:"triples"`
+	// Bytes is the size of the snapshot on disk
+	Bytes int64 `json:"bytes"`
+}
+
+// Paths locates the copies of the stores inside the snapshot, kept under dir.
+func (info Info) Paths(dir string) Paths {
+	src := filepath.Join(dir, info.Version)
+	return Paths{
+		Vectors:   filepath.Join(src, vectorsName),
+		Graph:     filepath.Join(src, graphName),
+		Documents: filepath.Join(src, documentsName),
+		Manifest:  filepath.Join(src, manifestName),
+	}
+}
+
+// Create copies the stores at p into the next version under dir and removes
+// the oldest snapshots beyond keep. A snapshot appears only once it is
+// complete.
+func Create(dir string, p Paths, keep int) (Info, error) {
+	m, err := manifest.Load(p.Manifest)
+	if err != nil {
+		return Info{}, err
+	}
+	infos, err := List(dir)
+	if err != nil {
+		return Info{}, err
+	}
+	next := 1
+	if len(infos) > 0 {
+		next = number(infos[len(infos)-1].Version) + 1
+	}
+	info := Info{
+		Version:     "v" + strconv.Itoa(next),
+		CreatedAt:   time.Now().UTC(),
+		BuiltAt:     m.BuiltAt,
+		KashVersion: m.KashVersion,
+		Documents:   len(m.Documents),
+		Chunks:      m.Chunks,
+		Vectors:     m.Vectors,
+		Triples:     m.Triples,
+	}
+
+	tmp := filepath.Join(dir, "."+info.Version+".tmp")
+	if err := os.RemoveAll(tmp); err != nil {
+		return Info{}, fmt.Errorf("clear %s: %w", tmp, err)
+	}
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return Info{}, fmt.Errorf("create snapshot directory: %w", err)
+	}
+	if err := copyStores(p, Paths{
+		Vectors:   filepath.Join(tmp, vectorsName),
+		Graph:     filepath.Join(tmp, graphName),
+		Documents: filepath.Join(tmp, documentsName),
+		Manifest:  filepath.Join(tmp, manifestName),
+	}); err != nil {
+		os.RemoveAll(tmp)
+		return Info{}, fmt.Errorf("snapshot %s: %w", info.Version, err)
+	}
+	if info.Bytes, err = dirSize(tmp); err != nil {
+		os.RemoveAll(tmp)
+		return Info{}, err
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		os.RemoveAll(tmp)
+		return Info{}, fmt.Errorf("marshal snapshot info: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, infoFile), append(data, '\n'), 0644); err != nil {
+		os.RemoveAll(tmp)
+		return Info{}, fmt.Errorf("write snapshot info: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, info.Version)); err != nil {
+		os.RemoveAll(tmp)
+		return Info{}, fmt.Errorf("save snapshot %s: %w", info.Version, err)
+	}
+
+	infos = append(infos, info)
+	for keep > 0 && len(infos) > keep {
+		if err := os.RemoveAll(filepath.Join(dir, infos[0].Version)); err != nil {
+			return info, fmt.Errorf("remove snapshot %s: %w", infos[0].Version, err)
+		}
+		infos = infos[1:]
+	}
+	return info, nil
+}
+
+// List returns the snapshots under dir, oldest first. A missing dir has none.
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read snapshots: %w", err)
+	}
+	var infos []Info
+	for _, e := range entries {
+		if !e.IsDir() || number(e.Name()) == 0 {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name(), infoFile))
+		if err != nil {
+			continue
+		}
+		var info Info
+		if err := json.Unmarshal(data, &info); err != nil {
+			return nil, fmt.Errorf("parse snapshot %s: %w", e.Name(), err)
+		}
+		info.Version = e.Name()
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return number(infos[i].Version) < number(infos[j].Version) })
+	return infos, nil
+}
+
+// Current returns the version whose build the manifest at manifestPath
+// describes, or "" when it matches no snapshot.
+func Current(infos []Info, manifestPath string) string {
+	m, err := manifest.Load(manifestPath)
+	if err != nil {
+		return ""
+	}
+	for _, info := range infos {
+		if info.BuiltAt.Equal(m.BuiltAt) {
+			return info.Version
+		}
+	}
+	return ""
+}
+
+// Find returns the snapshot named version, as "v3" or "3". An empty version
+// picks the snapshot before current, which is what a rollback returns to.
+func Find(infos []Info, version, current string) (Info, error) {
+	if version == "" {
+		for i, info := range infos {
+			if info.Version == current && i > 0 {
+				return infos[i-1], nil
+			}
+		}
+		return Info{}, errors.New("no snapshot before the current build — name a version")
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	for _, info := range infos {
+		if info.Version == version {
+			return info, nil
+		}
+	}
+	return Info{}, fmt.Errorf("%w: %s", ErrNotFound, version)
+}
+
+// Restore replaces the stores at p with the copies in the snapshot. Each
+// store is copied next to its target and renamed into place, and the manifest
+// goes last, so a server watching it reloads only once the stores are whole.
+// A snapshot of a build without a graph or documents removes that store.
+func Restore(dir string, info Info, p Paths) error {
+	src := info.Paths(dir)
+	for _, s := range []struct{ from, to string }{
+		{src.Vectors, p.Vectors},
+		{src.Graph, p.Graph},
+		{src.Documents, p.Documents},
+	} {
+		if s.to == "" {
+			continue
+		}
+		staged, old := s.to+".rollback", s.to+".old"
+		os.RemoveAll(staged)
+		os.RemoveAll(old)
+		if _, err := os.Stat(s.from); errors.Is(err, fs.ErrNotExist) && s.from != src.Vectors {
+			if err := os.RemoveAll(s.to); err != nil {
+				return fmt.Errorf("restore %s: %w", s.to, err)
+			}
+			continue
+		}
+		if err := copyDir(s.from, staged); err != nil {
+			os.RemoveAll(staged)
+			return fmt.Errorf("restore %s: %w", s.to, err)
+		}
+		if err := os.Rename(s.to, old); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			os.RemoveAll(staged)
+			return fmt.Errorf("restore %s: %w", s.to, err)
+		}
+		if err := os.Rename(staged, s.to); err != nil {
+			return fmt.Errorf("restore %s: %w", s.to, err)
+		}
+		os.RemoveAll(old)
+	}
+	staged := p.Manifest + ".rollback"
+	if err := copyFile(src.Manifest, staged); err != nil {
+		return fmt.Errorf("restore %s: %w", p.Manifest, err)
+	}
+	if err := os.Rename(staged, p.Manifest); err != nil {
+		return fmt.Errorf("restore %s: %w", p.Manifest, err)
+	}
+	return nil
+}
+
+// number returns the N of a "vN" version, or 0 for other names.
+func number(version string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(version, "v"))
+	if err != nil || n <= 0 || !strings.HasPrefix(version, "v") {
+		return 0
+	}
+	return n
+}
+
+func copyStores(from, to Paths) error {
+	if err := copyDir(from.Vectors, to.Vectors); err != nil {
+		return err
+	}
+	// A build with --skip-graph has no graph store, and one with
+	// ingest.skip_documents no document store
+	for _, s := range []struct{ from, to string }{
+		{from.Graph, to.Graph},
+		{from.Documents, to.Documents},
+	} {
+		if s.from == "" {
+			continue
+		}
+		if _, err := os.Stat(s.from); err == nil {
+			if err := copyDir(s.from, s.to); err != nil {
+				return err
+			}
+		}
+	}
+	return copyFile(from.Manifest, to.Manifest)
+}
+
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func dirSize(path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("measure %s: %w", path, err)
+	}
+	return total, nil
+}
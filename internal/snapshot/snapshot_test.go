@@ -0,0 +1,198 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/chunker"
+	"github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// writeBuild fakes the stores of a build whose content is marker.
+func writeBuild(t *testing.T, p Paths, marker string, builtAt time.Time) {
+	t.Helper()
+	for _, dir := range []string{p.Vectors, p.Graph} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "store"), []byte(marker), 0644))
+	}
+	m := &manifest.Manifest{BuiltAt: builtAt, Chunks: 3, Vectors: 3, Triples: 2}
+	require.NoError(t, m.Save(p.Manifest))
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, DefaultDir)
+	p := Paths{
+		Vectors:  filepath.Join(root, "data", "memory.chromem"),
+		Graph:    filepath.Join(root, "data", "knowledge.cayley"),
+		Manifest: filepath.Join(root, "data", "manifest.json"),
+	}
+
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, marker := range []string{"one", "two", "three"} {
+		writeBuild(t, p, marker, start.Add(time.Duration(i)*time.Hour))
+		info, err := Create(dir, p, 2)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("v%d", i+1), info.Version)
+		assert.Equal(t, 3, info.Chunks)
+		assert.Positive(t, info.Bytes)
+	}
+
+	infos, err := List(dir)
+	require.NoError(t, err)
+	require.Len(t, infos, 2, "keep prunes the oldest snapshot")
+	assert.Equal(t, "v2", infos[0].Version)
+	assert.Equal(t, "v3", infos[1].Version)
+	current := Current(infos, p.Manifest)
+	assert.Equal(t, "v3", current)
+
+	prev, err := Find(infos, "", current)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", prev.Version)
+	_, err = Find(infos, "1", current)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, Restore(dir, prev, p))
+	data, err := os.ReadFile(filepath.Join(p.Vectors, "store"))
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(data))
+	data, err = os.ReadFile(filepath.Join(p.Graph, "store"))
+	require.NoError(t, err)
+	assert.Equal(t, "two", string(data))
+	assert.Equal(t, "v2", Current(infos, p.Manifest))
+
+	_, err = Find(infos, "", "v2")
+	assert.Error(t, err, "nothing before the oldest snapshot")
+}
+
+// buildStores writes real vector and graph stores under root.
+func buildStores(t *testing.T, root, embedURL string, chunks []chunker.Chunk, triples []graph.Triple, docs ...string) Paths {
+	t.Helper()
+	ctx := context.Background()
+	p := Paths{
+		Vectors:  filepath.Join(root, "memory.chromem"),
+		Graph:    filepath.Join(root, "knowledge.cayley"),
+		Manifest: filepath.Join(root, "manifest.json"),
+	}
+	store, err := vector.NewPersistentStore(p.Vectors, &config.ProviderConfig{BaseURL: embedURL, Dimensions: 2})
+	require.NoError(t, err)
+	require.NoError(t, store.AddChunks(ctx, chunks))
+	gdb, err := graph.NewDBFromPath(p.Graph)
+	require.NoError(t, err)
+	require.NoError(t, gdb.AddTriples(ctx, triples))
+	require.NoError(t, gdb.Close())
+	m := &manifest.Manifest{}
+	for _, name := range docs {
+		m.Documents = append(m.Documents, manifest.Document{Name: name})
+	}
+	require.NoError(t, m.Save(p.Manifest))
+	return p
+}
+
+func TestCreateWithoutGraph(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, DefaultDir)
+	p := Paths{
+		Vectors:  filepath.Join(root, "data", "memory.chromem"),
+		Graph:    filepath.Join(root, "data", "knowledge.cayley"),
+		Manifest: filepath.Join(root, "data", "manifest.json"),
+	}
+	writeBuild(t, p, "one", time.Now())
+	require.NoError(t, os.RemoveAll(p.Graph))
+	info, err := Create(dir, p, 0)
+	require.NoError(t, err)
+
+	// Restoring the vector-only build removes the graph of a later one
+	writeBuild(t, p, "two", time.Now())
+	require.NoError(t, Restore(dir, info, p))
+	assert.NoDirExists(t, p.Graph)
+	data, err := os.ReadFile(filepath.Join(p.Vectors, "store"))
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(data))
+}
+
+func TestCreateWithDocuments(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, DefaultDir)
+	p := Paths{
+		Vectors:   filepath.Join(root, "data", "memory.chromem"),
+		Graph:     filepath.Join(root, "data", "knowledge.cayley"),
+		Documents: filepath.Join(root, "data", "documents.store"),
+		Manifest:  filepath.Join(root, "data", "manifest.json"),
+	}
+	writeBuild(t, p, "one", time.Now())
+	require.NoError(t, os.MkdirAll(p.Documents, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(p.Documents, "index.json"), []byte("one"), 0644))
+	withDocs, err := Create(dir, p, 0)
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(p.Documents))
+	withoutDocs, err := Create(dir, p, 0)
+	require.NoError(t, err)
+
+	require.NoError(t, Restore(dir, withDocs, p))
+	data, err := os.ReadFile(filepath.Join(p.Documents, "index.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "one", string(data))
+
+	require.NoError(t, Restore(dir, withoutDocs, p))
+	assert.NoDirExists(t, p.Documents, "restoring a build without documents removes them")
+}
+
+func TestCompare(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"embedding": []float32{0.6, 0.8}}},
+		})
+	}))
+	defer srv.Close()
+
+	from := buildStores(t, t.TempDir(), srv.URL, []chunker.Chunk{
+		{ID: "a_0", Source: "a.md", Content: "same"},
+		{ID: "b_0", Source: "b.md", Content: "old text"},
+		{ID: "c_0", Source: "c.md", Content: "gone"},
+	}, []graph.Triple{
+		{Subject: "Kash", Predicate: "serves", Object: "agents"},
+		{Subject: "Kash", Predicate: "uses", Object: "bolt"},
+	}, "a.md", "b.md", "c.md")
+	to := buildStores(t, t.TempDir(), srv.URL, []chunker.Chunk{
+		{ID: "a_0", Source: "a.md", Content: "same"},
+		{ID: "b_0", Source: "b.md", Content: "new text"},
+		{ID: "b_1", Source: "b.md", Content: "more text"},
+		{ID: "d_0", Source: "d.md", Content: "fresh"},
+	}, []graph.Triple{
+		{Subject: "Kash", Predicate: "serves", Object: "agents"},
+		{Subject: "Kash", Predicate: "speaks", Object: "MCP"},
+	}, "a.md", "b.md", "d.md", "empty.txt")
+
+	d, err := Compare(context.Background(), from, to)
+	require.NoError(t, err)
+	assert.Equal(t, []DocumentChange{
+		{Name: "b.md", Status: Changed, ChunksBefore: 1, ChunksAfter: 2, NewChunks: 2},
+		{Name: "c.md", Status: Removed, ChunksBefore: 1},
+		{Name: "d.md", Status: Added, ChunksAfter: 1, NewChunks: 1},
+		{Name: "empty.txt", Status: Added},
+	}, d.Documents)
+	assert.Equal(t, 1, d.UnchangedDocuments)
+	assert.Equal(t, 3, d.ChunksBefore)
+	assert.Equal(t, 4, d.ChunksAfter)
+	assert.Equal(t, []graph.Triple{{Subject: "Kash", Predicate: "speaks", Object: "MCP"}}, d.TriplesAdded)
+	assert.Equal(t, []graph.Triple{{Subject: "Kash", Predicate: "uses", Object: "bolt"}}, d.TriplesRemoved)
+	assert.False(t, d.Empty())
+
+	same, err := Compare(context.Background(), to, to)
+	require.NoError(t, err)
+	assert.True(t, same.Empty())
+}
@@ -0,0 +1,87 @@
+package source
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache stores fetched remote content on disk so rebuilds can issue
+// conditional requests (ETag / Last-Modified) and reuse unchanged bodies.
+type Cache struct {
+	dir string
+}
+
+// cacheEntry is the metadata persisted next to each cached body.
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+	// File is the cached body filename (relative to the cache directory)
+	File string `json:"file"`
+}
+
+// NewCache creates a Cache rooted at dir, creating the directory if needed.
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create cache directory %q: %w", dir, err)
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Dir returns the cache root directory.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// key returns the stable cache key for a URL.
+func (c *Cache) key(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:12])
+}
+
+// lookup returns the cached entry for a URL, if its body still exists.
+func (c *Cache) lookup(url string) (cacheEntry, bool) {
+	data, err := os.ReadFile(filepath.Join(c.dir, c.key(url)+".json"))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cacheEntry{}, false
+	}
+	if _, err := os.Stat(c.path(entry)); err != nil {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store writes a body and its metadata, returning the body path.
+// ext selects the body file extension so readers can dispatch on it.
+func (c *Cache) store(entry cacheEntry, ext string, body []byte) (string, error) {
+	k := c.key(entry.URL)
+	entry.File = k + ext
+	bodyPath := filepath.Join(c.dir, entry.File)
+	if err := os.WriteFile(bodyPath, body, 0644); err != nil {
+		return "", fmt.Errorf("write cached body: %w", err)
+	}
+	meta, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal cache entry: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, k+".json"), meta, 0644); err != nil {
+		return "", fmt.Errorf("write cache entry: %w", err)
+	}
+	return bodyPath, nil
+}
+
+// path returns the absolute body path of an entry.
+func (c *Cache) path(entry cacheEntry) string {
+	return filepath.Join(c.dir, entry.File)
+}
@@ -0,0 +1,431 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+// CrawlOptions bounds a website crawl.
+type CrawlOptions struct {
+	// StartURL is the first page fetched; only links on the same host are followed
+	StartURL string
+	// MaxDepth is the number of link hops followed from StartURL (default: 2)
+	MaxDepth int
+	// MaxPages caps the number of pages ingested (default: 100)
+	MaxPages int
+	// Include lists regular expressions; when set, a URL must match one of them
+	Include []string
+	// Exclude lists regular expressions; matching URLs are never fetched
+	Exclude []string
+	// Sitemap seeds the crawl with URLs from the site's sitemap.xml
+	Sitemap bool
+	// IgnoreRobots disables robots.txt checks
+	IgnoreRobots bool
+	// Delay is the pause between page requests
+	Delay time.Duration
+}
+
+// CrawlResult summarizes a crawl.
+type CrawlResult struct {
+	Documents []reader.Document
+	// Skipped maps URLs to the reason they were not ingested
+	Skipped map[string]string
+	// NotModified counts pages served from cache after a 304
+	NotModified int
+}
+
+// crawlItem is a queued URL with its link depth.
+type crawlItem struct {
+	url   string
+	depth int
+}
+
+// Crawl walks a website breadth-first from opts.StartURL, honoring robots.txt,
+// depth/page limits, and include/exclude patterns.
+func (f *Fetcher) Crawl(ctx context.Context, opts CrawlOptions) (CrawlResult, error) {
+	start, err := url.Parse(opts.StartURL)
+	if err != nil || start.Host == "" {
+		return CrawlResult{}, fmt.Errorf("invalid start URL %q", opts.StartURL)
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = 2
+	}
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = 100
+	}
+
+	include, err := compilePatterns(opts.Include)
+	if err != nil {
+		return CrawlResult{}, fmt.Errorf("include patterns: %w", err)
+	}
+	exclude, err := compilePatterns(opts.Exclude)
+	if err != nil {
+		return CrawlResult{}, fmt.Errorf("exclude patterns: %w", err)
+	}
+
+	var robots robotsRules
+	if !opts.IgnoreRobots {
+		robots = f.fetchRobots(ctx, start)
+	}
+
+	res := CrawlResult{Skipped: map[string]string{}}
+	seen := map[string]bool{}
+	queue := []crawlItem{}
+
+	enqueue := func(raw string, depth int) {
+		u := canonicalURL(raw)
+		if u == "" || seen[u] {
+			return
+		}
+		seen[u] = true
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Host != start.Host {
+			return
+		}
+		if matchesAny(exclude, u) {
+			res.Skipped[u] = "excluded by pattern"
+			return
+		}
+		if len(include) > 0 && !matchesAny(include, u) && u != canonicalURL(opts.StartURL) {
+			res.Skipped[u] = "not matched by include patterns"
+			return
+		}
+		if !robots.allowed(parsed.RequestURI()) {
+			res.Skipped[u] = "disallowed by robots.txt"
+			return
+		}
+		queue = append(queue, crawlItem{url: u, depth: depth})
+	}
+
+	enqueue(opts.StartURL, 0)
+	if opts.Sitemap {
+		sitemaps := robots.sitemaps
+		if len(sitemaps) == 0 {
+			sitemaps = []string{start.Scheme + "://" + start.Host + "/sitemap.xml"}
+		}
+		for _, sm := range sitemaps {
+			for _, u := range f.fetchSitemap(ctx, sm, 0) {
+				enqueue(u, 1)
+			}
+		}
+	}
+
+	for len(queue) > 0 && len(res.Documents) < opts.MaxPages {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		item := queue[0]
+		queue = queue[1:]
+
+		bodyPath, status, err := f.download(ctx, item.url)
+		if err != nil {
+			res.Skipped[item.url] = err.Error()
+			continue
+		}
+		if status == StatusNotModified {
+			res.NotModified++
+		}
+
+		if item.depth < opts.MaxDepth && isHTMLFile(bodyPath) {
+			for _, link := range extractLinks(bodyPath, item.url) {
+				enqueue(link, item.depth+1)
+			}
+		}
+
+		doc, err := f.load(bodyPath, item.url)
+		if err != nil {
+			res.Skipped[item.url] = err.Error()
+			continue
+		}
+		if strings.TrimSpace(doc.Content) == "" {
+			res.Skipped[item.url] = "no text content"
+			continue
+		}
+		doc.Metadata["crawl_depth"] = fmt.Sprintf("%d", item.depth)
+		res.Documents = append(res.Documents, doc)
+
+		if opts.Delay > 0 && len(queue) > 0 {
+			select {
+			case <-time.After(opts.Delay):
+			case <-ctx.Done():
+				return res, ctx.Err()
+			}
+		}
+	}
+	return res, nil
+}
+
+// compilePatterns compiles a list of regular expressions.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("compile %q: %w", p, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalURL strips fragments and rejects non-HTTP schemes.
+func canonicalURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	u.Fragment = ""
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+func isHTMLFile(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	return ext == ".html" || ext == ".htm"
+}
+
+// extractLinks returns absolute hrefs of <a> elements in a cached HTML body.
+func extractLinks(bodyPath, pageURL string) []string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+	f, err := os.Open(bodyPath)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var links []string
+	z := html.NewTokenizer(f)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return links
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		tok := z.Token()
+		if tok.DataAtom == atom.Base {
+			for _, a := range tok.Attr {
+				if a.Key == "href" {
+					if b, err := base.Parse(a.Val); err == nil {
+						base = b
+					}
+				}
+			}
+			continue
+		}
+		if tok.DataAtom != atom.A {
+			continue
+		}
+		for _, a := range tok.Attr {
+			if a.Key != "href" {
+				continue
+			}
+			if ref, err := base.Parse(a.Val); err == nil {
+				links = append(links, ref.String())
+			}
+		}
+	}
+}
+
+// robotsRules holds the robots.txt directives that apply to Kash.
+type robotsRules struct {
+	allow    []robotsRule
+	disallow []robotsRule
+	sitemaps []string
+}
+
+// robotsRule is an Allow or Disallow path pattern. As in RFC 9309, "*"
+// matches any characters and a trailing "$" anchors the end of the path;
+// otherwise the pattern matches as a prefix.
+type robotsRule struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRobotsRule(pattern string) robotsRule {
+	body, anchored := strings.CutSuffix(pattern, "$")
+	parts := strings.Split(body, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return robotsRule{pattern: pattern, re: regexp.MustCompile(expr)}
+}
+
+// allowed applies the longest matching rule, Allow winning a tie with
+// Disallow; a path no rule matches is allowed.
+func (r robotsRules) allowed(requestURI string) bool {
+	best := -1
+	ok := true
+	for _, rule := range r.disallow {
+		if len(rule.pattern) > best && rule.re.MatchString(requestURI) {
+			best, ok = len(rule.pattern), false
+		}
+	}
+	for _, rule := range r.allow {
+		if len(rule.pattern) >= best && rule.re.MatchString(requestURI) {
+			best, ok = len(rule.pattern), true
+		}
+	}
+	return ok
+}
+
+// fetchRobots downloads and parses robots.txt. Failures yield an allow-all policy.
+func (f *Fetcher) fetchRobots(ctx context.Context, start *url.URL) robotsRules {
+	body, err := f.get(ctx, start.Scheme+"://"+start.Host+"/robots.txt")
+	if err != nil {
+		return robotsRules{}
+	}
+	return parseRobots(strings.NewReader(string(body)))
+}
+
+// parseRobots extracts the rules of the most specific group that applies to
+// kash: a group naming kash when there is one, the "*" group otherwise.
+// Sitemaps apply whatever the group.
+func parseRobots(r io.Reader) robotsRules {
+	var kash, all robotsRules
+	hasKash := false
+	// forKash and forAll say whom the current group's rules are for
+	forKash, forAll := false, false
+	inAgents := false
+	var sitemaps []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, val, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				forKash, forAll = false, false
+			}
+			inAgents = true
+			agent := strings.ToLower(val)
+			if strings.Contains(agent, "kash") {
+				forKash, hasKash = true, true
+			} else if agent == "*" {
+				forAll = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			var rules *robotsRules
+			switch {
+			case forKash:
+				rules = &kash
+			case forAll:
+				rules = &all
+			default:
+				continue
+			}
+			// An empty rule matches nothing
+			if val == "" {
+				continue
+			}
+			if key == "allow" {
+				rules.allow = append(rules.allow, newRobotsRule(val))
+			} else {
+				rules.disallow = append(rules.disallow, newRobotsRule(val))
+			}
+		case "sitemap":
+			sitemaps = append(sitemaps, val)
+		default:
+			inAgents = false
+		}
+	}
+	rules := all
+	if hasKash {
+		rules = kash
+	}
+	rules.sitemaps = sitemaps
+	return rules
+}
+
+// sitemapDoc covers both <urlset> and <sitemapindex> documents.
+type sitemapDoc struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// fetchSitemap returns page URLs from a sitemap, following nested sitemap
+// indexes up to three levels deep.
+func (f *Fetcher) fetchSitemap(ctx context.Context, sitemapURL string, level int) []string {
+	if level > 2 {
+		return nil
+	}
+	body, err := f.get(ctx, sitemapURL)
+	if err != nil {
+		return nil
+	}
+	var doc sitemapDoc
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	urls := make([]string, 0, len(doc.URLs))
+	for _, u := range doc.URLs {
+		urls = append(urls, strings.TrimSpace(u))
+	}
+	for _, sm := range doc.Sitemaps {
+		urls = append(urls, f.fetchSitemap(ctx, strings.TrimSpace(sm), level+1)...)
+	}
+	return urls
+}
+
+// get performs a plain, uncached GET and returns the body.
+func (f *Fetcher) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+}
@@ -0,0 +1,152 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	pages := map[string]string{
+		"/":             `<a href="/docs/a">A</a> <a href="/private/x">P</a> <a href="https://elsewhere.dev/">E</a> <p>Home</p>`,
+		"/docs/a":       `<a href="b#section">B</a><p>Page A</p>`,
+		"/docs/b":       `<a href="/docs/c">C</a><p>Page B</p>`,
+		"/docs/c":       `<p>Page C</p>`,
+		"/docs/mapped":  `<p>From sitemap</p>`,
+		"/private/x":    `<p>Secret</p>`,
+		"/blog/ignored": `<p>Blog</p>`,
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private/\n"))
+			return
+		case "/sitemap.xml":
+			w.Write([]byte(`<urlset><url><loc>` + srv.URL + `/docs/mapped</loc></url><url><loc>` + srv.URL + `/blog/ignored</loc></url></urlset>`))
+			return
+		}
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body>" + body + "</body></html>"))
+	}))
+	return srv
+}
+
+func TestFetcher_Crawl(t *testing.T) {
+	srv := newTestSite(t)
+	defer srv.Close()
+
+	f, err := NewFetcher(t.TempDir(), reader.NewReader(reader.DefaultOptions()))
+	require.NoError(t, err)
+
+	res, err := f.Crawl(context.Background(), CrawlOptions{
+		StartURL: srv.URL + "/",
+		MaxDepth: 2,
+		Exclude:  []string{"/blog/"},
+		Sitemap:  true,
+	})
+	require.NoError(t, err)
+
+	var got []string
+	for _, d := range res.Documents {
+		got = append(got, strings.TrimPrefix(d.Name, srv.URL))
+	}
+	sort.Strings(got)
+	// /docs/c is three hops away and exceeds MaxDepth
+	assert.Equal(t, []string{"/", "/docs/a", "/docs/b", "/docs/mapped"}, got)
+	assert.Equal(t, "disallowed by robots.txt", res.Skipped[srv.URL+"/private/x"])
+	assert.Equal(t, "excluded by pattern", res.Skipped[srv.URL+"/blog/ignored"])
+}
+
+func TestFetcher_CrawlMaxPages(t *testing.T) {
+	srv := newTestSite(t)
+	defer srv.Close()
+
+	f, err := NewFetcher(t.TempDir(), reader.NewReader(reader.DefaultOptions()))
+	require.NoError(t, err)
+
+	res, err := f.Crawl(context.Background(), CrawlOptions{StartURL: srv.URL, MaxDepth: 5, MaxPages: 2})
+	require.NoError(t, err)
+	assert.Len(t, res.Documents, 2)
+}
+
+func TestRobotsRules_Allowed(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`
+User-agent: googlebot
+Disallow: /
+
+User-agent: *
+Disallow: /admin
+Allow: /admin/public
+Sitemap: https://x.dev/sitemap.xml
+`))
+	assert.True(t, rules.allowed("/docs"))
+	assert.False(t, rules.allowed("/admin/secret"))
+	assert.True(t, rules.allowed("/admin/public/page"))
+	assert.Equal(t, []string{"https://x.dev/sitemap.xml"}, rules.sitemaps)
+}
+
+func TestRobotsRules_KashGroup(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`
+User-agent: *
+Disallow: /
+
+User-agent: kash
+Disallow: /private
+`))
+	assert.True(t, rules.allowed("/docs"), "the kash group replaces the * group")
+	assert.False(t, rules.allowed("/private/x"))
+
+	rules = parseRobots(strings.NewReader(`
+User-agent: *
+Disallow: /
+
+User-agent: Kash
+Disallow:
+`))
+	assert.True(t, rules.allowed("/docs"), "an empty kash group allows everything")
+}
+
+func TestRobotsRules_Patterns(t *testing.T) {
+	rules := parseRobots(strings.NewReader(`
+User-agent: *
+Disallow: /*.pdf$
+Disallow: /*?
+Disallow: /private*/
+Allow: /private/open$
+Allow: /docs/*.pdf$
+`))
+	tests := []struct {
+		uri  string
+		want bool
+	}{
+		{"/guide.html", true},
+		{"/files/manual.pdf", false},
+		{"/files/manual.pdf.html", true},
+		{"/search?q=refunds", false},
+		{"/search", true},
+		{"/private/x", false},
+		{"/private-notes/x", false},
+		{"/private/open", true},
+		{"/private/open/more", false},
+		{"/docs/guide.pdf", true},
+		{"/a.b+c(d)", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, rules.allowed(tt.uri), tt.uri)
+	}
+}
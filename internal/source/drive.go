@@ -0,0 +1,262 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+const (
+	driveAPIBase = "https://www.googleapis.com/drive/v3"
+	driveScope   = "https://www.googleapis.com/auth/drive.readonly"
+
+	driveFolderType = "application/vnd.google-apps.folder"
+)
+
+// driveExports maps Google Workspace document types to the export format
+// requested from Drive and the extension used to read the result.
+// Sheets export only the first sheet as CSV.
+var driveExports = map[string]struct{ mime, ext string }{
+	"application/vnd.google-apps.document":     {"text/markdown", ".md"},
+	"application/vnd.google-apps.spreadsheet":  {"text/csv", ".csv"},
+	"application/vnd.google-apps.presentation": {"text/plain", ".txt"},
+}
+
+// DriveOptions selects the Google Drive folder to ingest.
+type DriveOptions struct {
+	// FolderID is the ID from the folder URL (drive.google.com/drive/folders/<id>)
+	FolderID string
+	// Recursive descends into subfolders
+	Recursive bool
+}
+
+// DriveResult summarizes a Drive folder ingest.
+type DriveResult struct {
+	Documents []reader.Document
+	// Skipped maps file paths to the reason they were not ingested
+	Skipped map[string]string
+	// NotModified counts files reused from cache because modifiedTime was unchanged
+	NotModified int
+}
+
+// driveFile is the subset of Drive file metadata that Kash requests.
+type driveFile struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	MimeType     string `json:"mimeType"`
+	ModifiedTime string `json:"modifiedTime"`
+	WebViewLink  string `json:"webViewLink"`
+	Owners       []struct {
+		DisplayName  string `json:"displayName"`
+		EmailAddress string `json:"emailAddress"`
+	} `json:"owners"`
+}
+
+// Drive ingests files from Google Drive using a service account. The folder
+// must be shared with the service account's email address.
+type Drive struct {
+	client  *http.Client
+	auth    *googleAuth
+	cache   *Cache
+	reader  *reader.Reader
+	baseURL string
+}
+
+// NewDrive creates a Drive source authenticating with the service account
+// key in credentialsFile and caching downloads in cacheDir.
+func NewDrive(credentialsFile, cacheDir string, rd *reader.Reader) (*Drive, error) {
+	if rd == nil {
+		return nil, errors.New("reader is required")
+	}
+	client := &http.Client{Timeout: 60 * time.Second}
+	auth, err := newGoogleAuth(client, credentialsFile, driveScope)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Drive{client: client, auth: auth, cache: cache, reader: rd, baseURL: driveAPIBase}, nil
+}
+
+// Fetch lists opts.FolderID and reads every supported file. Google Docs,
+// Sheets, and Slides are exported to text; other files are downloaded as-is.
+func (d *Drive) Fetch(ctx context.Context, opts DriveOptions) (DriveResult, error) {
+	if opts.FolderID == "" {
+		return DriveResult{}, errors.New("drive folder_id is required")
+	}
+	res := DriveResult{Skipped: map[string]string{}}
+	if err := d.fetchFolder(ctx, opts.FolderID, "", opts.Recursive, &res); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+func (d *Drive) fetchFolder(ctx context.Context, folderID, prefix string, recursive bool, res *DriveResult) error {
+	files, err := d.list(ctx, folderID)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		rel := path.Join(prefix, f.Name)
+		if f.MimeType == driveFolderType {
+			if recursive {
+				if err := d.fetchFolder(ctx, f.ID, rel, recursive, res); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		doc, status, err := d.fetchFile(ctx, f)
+		if err != nil {
+			res.Skipped[rel] = err.Error()
+			continue
+		}
+		if strings.TrimSpace(doc.Content) == "" {
+			res.Skipped[rel] = "no text content"
+			continue
+		}
+		if status == StatusNotModified {
+			res.NotModified++
+		}
+
+		doc.Name = "drive/" + rel
+		doc.Path = f.WebViewLink
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]string{}
+		}
+		doc.Metadata["drive_id"] = f.ID
+		doc.Metadata["modified_time"] = f.ModifiedTime
+		if len(f.Owners) > 0 {
+			owner := f.Owners[0].EmailAddress
+			if owner == "" {
+				owner = f.Owners[0].DisplayName
+			}
+			doc.Metadata["owner"] = owner
+		}
+		if f.WebViewLink != "" {
+			doc.Metadata["url"] = f.WebViewLink
+		}
+		res.Documents = append(res.Documents, doc)
+	}
+	return nil
+}
+
+// list returns every non-trashed child of a folder, following pagination.
+func (d *Drive) list(ctx context.Context, folderID string) ([]driveFile, error) {
+	var files []driveFile
+	pageToken := ""
+	for {
+		q := url.Values{
+			"q":                         {fmt.Sprintf("'%s' in parents and trashed = false", strings.ReplaceAll(folderID, "'", `\'`))},
+			"fields":                    {"nextPageToken, files(id, name, mimeType, modifiedTime, webViewLink, owners(displayName, emailAddress))"},
+			"pageSize":                  {"1000"},
+			"supportsAllDrives":         {"true"},
+			"includeItemsFromAllDrives": {"true"},
+		}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		body, err := d.get(ctx, d.baseURL+"/files?"+q.Encode())
+		if err != nil {
+			return nil, fmt.Errorf("list drive folder %s: %w", folderID, err)
+		}
+		var page struct {
+			NextPageToken string      `json:"nextPageToken"`
+			Files         []driveFile `json:"files"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("parse drive listing: %w", err)
+		}
+		files = append(files, page.Files...)
+		if page.NextPageToken == "" {
+			return files, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// fetchFile downloads or exports a file, reusing the cached copy when the
+// file's modifiedTime has not changed since the last build.
+func (d *Drive) fetchFile(ctx context.Context, f driveFile) (reader.Document, FetchStatus, error) {
+	var downloadURL, ext string
+	if exp, ok := driveExports[f.MimeType]; ok {
+		downloadURL = fmt.Sprintf("%s/files/%s/export?mimeType=%s", d.baseURL, url.PathEscape(f.ID), url.QueryEscape(exp.mime))
+		ext = exp.ext
+	} else if strings.HasPrefix(f.MimeType, "application/vnd.google-apps.") {
+		return reader.Document{}, StatusFetched, fmt.Errorf("%w: %s", reader.ErrUnsupportedFormat, f.MimeType)
+	} else {
+		ext = extensionFor(f.MimeType, "")
+		if ext == "" || f.MimeType == "" {
+			ext = strings.ToLower(path.Ext(f.Name))
+		}
+		if !isSupportedExt(ext) {
+			return reader.Document{}, StatusFetched, fmt.Errorf("%w: %s", reader.ErrUnsupportedFormat, f.Name)
+		}
+		downloadURL = fmt.Sprintf("%s/files/%s?alt=media&supportsAllDrives=true", d.baseURL, url.PathEscape(f.ID))
+	}
+
+	cacheKey := "drive://" + f.ID
+	status := StatusFetched
+	var bodyPath string
+	if cached, ok := d.cache.lookup(cacheKey); ok && cached.LastModified == f.ModifiedTime && f.ModifiedTime != "" {
+		bodyPath = d.cache.path(cached)
+		status = StatusNotModified
+	} else {
+		body, err := d.get(ctx, downloadURL)
+		if err != nil {
+			return reader.Document{}, status, fmt.Errorf("download: %w", err)
+		}
+		bodyPath, err = d.cache.store(cacheEntry{
+			URL:          cacheKey,
+			LastModified: f.ModifiedTime,
+			ContentType:  f.MimeType,
+			FetchedAt:    time.Now().UTC(),
+		}, ext, body)
+		if err != nil {
+			return reader.Document{}, status, err
+		}
+	}
+
+	doc, err := d.reader.LoadFile(bodyPath)
+	return doc, status, err
+}
+
+// get performs an authorized GET and returns the body.
+func (d *Drive) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.auth.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if len(body) > maxBodySize {
+		return nil, fmt.Errorf("body exceeds %d MB", maxBodySize>>20)
+	}
+	return body, nil
+}
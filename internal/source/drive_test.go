@@ -0,0 +1,102 @@
+package source
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+// writeServiceAccount creates a service account key file whose token_uri
+// points at tokenURL.
+func writeServiceAccount(t *testing.T, tokenURL string) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	data, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "kash@test.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    tokenURL,
+	})
+	require.NoError(t, err)
+	p := filepath.Join(t.TempDir(), "sa.json")
+	require.NoError(t, os.WriteFile(p, data, 0600))
+	return p
+}
+
+func TestDrive_Fetch(t *testing.T) {
+	listing := map[string]string{
+		"root": `{"files":[
+			{"id":"doc1","name":"Handbook","mimeType":"application/vnd.google-apps.document","modifiedTime":"2026-01-02T00:00:00Z","owners":[{"emailAddress":"ana@example.com"}]},
+			{"id":"sub","name":"Specs","mimeType":"application/vnd.google-apps.folder"},
+			{"id":"form1","name":"Survey","mimeType":"application/vnd.google-apps.form"}]}`,
+		"sub": `{"files":[{"id":"md1","name":"api.md","mimeType":"text/markdown","modifiedTime":"2026-01-03T00:00:00Z"}]}`,
+	}
+	downloads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+			w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+			return
+		}
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		switch {
+		case r.URL.Path == "/files":
+			q := r.URL.Query().Get("q")
+			id := strings.TrimSuffix(strings.TrimPrefix(q, "'"), "' in parents and trashed = false")
+			w.Write([]byte(listing[id]))
+		case r.URL.Path == "/files/doc1/export":
+			downloads++
+			assert.Equal(t, "text/markdown", r.URL.Query().Get("mimeType"))
+			w.Write([]byte("# Handbook\n\nBe kind."))
+		case r.URL.Path == "/files/md1":
+			downloads++
+			w.Write([]byte("# API\n\nGET /items"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	d, err := NewDrive(writeServiceAccount(t, srv.URL+"/token"), cacheDir, reader.NewReader(reader.DefaultOptions()))
+	require.NoError(t, err)
+	d.baseURL = srv.URL
+
+	res, err := d.Fetch(context.Background(), DriveOptions{FolderID: "root", Recursive: true})
+	require.NoError(t, err)
+
+	var names []string
+	for _, doc := range res.Documents {
+		names = append(names, doc.Name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"drive/Handbook", "drive/Specs/api.md"}, names)
+	assert.Contains(t, res.Skipped, "Survey")
+	assert.Equal(t, "ana@example.com", res.Documents[0].Metadata["owner"])
+	assert.Equal(t, 2, downloads)
+
+	// Unchanged modifiedTime reuses the cached export
+	res, err = d.Fetch(context.Background(), DriveOptions{FolderID: "root", Recursive: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.NotModified)
+	assert.Equal(t, 2, downloads)
+}
@@ -0,0 +1,82 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	gcsAPIBase = "https://storage.googleapis.com/storage/v1"
+	gcsScope   = "https://www.googleapis.com/auth/devstorage.read_only"
+)
+
+// gcsStore reads a Google Cloud Storage bucket through the JSON API.
+type gcsStore struct {
+	client  *http.Client
+	auth    *googleAuth
+	bucket  string
+	baseURL string
+}
+
+func (g *gcsStore) list(ctx context.Context, prefix string) ([]objectInfo, error) {
+	var out []objectInfo
+	pageToken := ""
+	for {
+		q := url.Values{
+			"prefix": {prefix},
+			"fields": {"nextPageToken,items(name,etag,size,updated)"},
+		}
+		if pageToken != "" {
+			q.Set("pageToken", pageToken)
+		}
+		body, err := g.do(ctx, fmt.Sprintf("%s/b/%s/o?%s", g.baseURL, url.PathEscape(g.bucket), q.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			NextPageToken string `json:"nextPageToken"`
+			Items         []struct {
+				Name    string    `json:"name"`
+				ETag    string    `json:"etag"`
+				Size    string    `json:"size"`
+				Updated time.Time `json:"updated"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("parse GCS listing: %w", err)
+		}
+		for _, it := range page.Items {
+			size, _ := strconv.ParseInt(it.Size, 10, 64)
+			out = append(out, objectInfo{Key: it.Name, ETag: it.ETag, Size: size, Modified: it.Updated})
+		}
+		if page.NextPageToken == "" {
+			return out, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+func (g *gcsStore) get(ctx context.Context, key string) ([]byte, error) {
+	return g.do(ctx, fmt.Sprintf("%s/b/%s/o/%s?alt=media", g.baseURL, url.PathEscape(g.bucket), url.PathEscape(key)))
+}
+
+func (g *gcsStore) do(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.auth.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return readObject(resp)
+}
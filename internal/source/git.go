@@ -0,0 +1,235 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+// GitOptions describes a git repository to ingest.
+type GitOptions struct {
+	// URL is anything `git fetch` accepts (https, ssh, or a local path)
+	URL string
+	// Ref is a branch, tag, or commit SHA (default: the remote HEAD)
+	Ref string
+	// Paths are glob patterns (with ** support) selecting files to ingest.
+	// Files matched here are ingested as plain text even if their format is
+	// not otherwise recognized (e.g. source code). Empty means every file
+	// with a supported document extension.
+	Paths []string
+	// Exclude are glob patterns for files to skip
+	Exclude []string
+}
+
+// GitResult holds the documents read from a repository checkout.
+type GitResult struct {
+	Documents []reader.Document
+	// Commit is the resolved commit SHA that was ingested
+	Commit string
+	// Skipped maps repository paths to the reason they were not ingested
+	Skipped map[string]string
+}
+
+// GitRepo ingests files from a git repository using the system git binary.
+// Checkouts are cached under cacheDir and updated with shallow fetches.
+type GitRepo struct {
+	cacheDir string
+	reader   *reader.Reader
+}
+
+// NewGitRepo creates a GitRepo caching checkouts in cacheDir.
+func NewGitRepo(cacheDir string, rd *reader.Reader) (*GitRepo, error) {
+	if rd == nil {
+		return nil, errors.New("reader is required")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("git executable not found in PATH")
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("create git cache directory: %w", err)
+	}
+	return &GitRepo{cacheDir: cacheDir, reader: rd}, nil
+}
+
+// Fetch checks out opts.Ref and reads every selected file.
+func (g *GitRepo) Fetch(ctx context.Context, opts GitOptions) (GitResult, error) {
+	if opts.URL == "" {
+		return GitResult{}, errors.New("git url is required")
+	}
+	ref := opts.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// git would read them as options, such as --upload-pack
+	if strings.HasPrefix(opts.URL, "-") {
+		return GitResult{}, fmt.Errorf("git url %q must not start with '-'", opts.URL)
+	}
+	if strings.HasPrefix(ref, "-") {
+		return GitResult{}, fmt.Errorf("git ref %q must not start with '-'", ref)
+	}
+
+	include, err := compileGlobs(opts.Paths)
+	if err != nil {
+		return GitResult{}, fmt.Errorf("paths: %w", err)
+	}
+	exclude, err := compileGlobs(opts.Exclude)
+	if err != nil {
+		return GitResult{}, fmt.Errorf("exclude: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(opts.URL))
+	dir, err := filepath.Abs(filepath.Join(g.cacheDir, hex.EncodeToString(sum[:8])))
+	if err != nil {
+		return GitResult{}, fmt.Errorf("resolve checkout directory: %w", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return GitResult{}, fmt.Errorf("create checkout directory: %w", err)
+		}
+		if _, err := runGit(ctx, dir, "init", "--quiet"); err != nil {
+			return GitResult{}, err
+		}
+		if _, err := runGit(ctx, dir, "remote", "add", "--", "origin", opts.URL); err != nil {
+			return GitResult{}, err
+		}
+	} else if _, err := runGit(ctx, dir, "remote", "set-url", "--", "origin", opts.URL); err != nil {
+		return GitResult{}, err
+	}
+
+	if _, err := runGit(ctx, dir, "fetch", "--quiet", "--depth", "1", "--tags", "--", "origin", ref); err != nil {
+		return GitResult{}, err
+	}
+	if _, err := runGit(ctx, dir, "checkout", "--quiet", "--force", "FETCH_HEAD"); err != nil {
+		return GitResult{}, err
+	}
+	if _, err := runGit(ctx, dir, "clean", "-fdq"); err != nil {
+		return GitResult{}, err
+	}
+	commit, err := runGit(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return GitResult{}, err
+	}
+
+	res := GitResult{Commit: commit, Skipped: map[string]string{}}
+	repoName := strings.TrimSuffix(path.Base(strings.TrimRight(opts.URL, "/")), ".git")
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matchesAny(exclude, rel) || reader.IsSidecar(rel) {
+			return nil
+		}
+
+		explicit := len(include) > 0 && matchesAny(include, rel)
+		if len(include) > 0 && !explicit {
+			return nil
+		}
+
+		doc, err := g.reader.LoadFile(p)
+		if errors.Is(err, reader.ErrUnsupportedFormat) && explicit {
+			doc, err = reader.LoadPlainText(p)
+		}
+		if err != nil {
+			if !errors.Is(err, reader.ErrUnsupportedFormat) || explicit {
+				res.Skipped[rel] = err.Error()
+			}
+			return nil
+		}
+		if strings.TrimSpace(doc.Content) == "" {
+			return nil
+		}
+
+		doc.Path = rel
+		doc.Name = repoName + "/" + rel
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]string{}
+		}
+		doc.Metadata["repo"] = opts.URL
+		doc.Metadata["commit"] = commit
+		doc.Metadata["path"] = rel
+		res.Documents = append(res.Documents, doc)
+		return nil
+	})
+	if err != nil {
+		return res, fmt.Errorf("walk checkout: %w", err)
+	}
+	return res, nil
+}
+
+// runGit runs a git subcommand in dir and returns its trimmed stdout.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// compileGlobs converts glob patterns into regular expressions. "**" matches
+// across directories, "*" and "?" stay within a path segment, and a pattern
+// without a slash matches the file's base name at any depth.
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(globs))
+	for _, g := range globs {
+		g = strings.TrimPrefix(filepath.ToSlash(g), "./")
+		if !strings.Contains(g, "/") {
+			g = "**/" + g
+		}
+		var sb strings.Builder
+		sb.WriteString("^")
+		for i := 0; i < len(g); i++ {
+			switch c := g[i]; c {
+			case '*':
+				if i+1 < len(g) && g[i+1] == '*' {
+					i++
+					if i+1 < len(g) && g[i+1] == '/' {
+						i++
+						sb.WriteString("(?:.*/)?")
+					} else {
+						sb.WriteString(".*")
+					}
+				} else {
+					sb.WriteString("[^/]*")
+				}
+			case '?':
+				sb.WriteString("[^/]")
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+		sb.WriteString("$")
+		re, err := regexp.Compile(sb.String())
+		if err != nil {
+			return nil, fmt.Errorf("compile glob %q: %w", g, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package source
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+func TestCompileGlobs(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"*.md", "README.md", true},
+		{"*.md", "docs/guide/intro.md", true},
+		{"docs/*.md", "docs/intro.md", true},
+		{"docs/*.md", "docs/guide/intro.md", false},
+		{"docs/**/*.md", "docs/intro.md", true},
+		{"docs/**/*.md", "docs/guide/intro.md", true},
+		{"docs/**", "docs/a/b/c.txt", true},
+		{"src/*.go", "src/main.go", true},
+		{"src/*.go", "other/src/main.go", false},
+		{"file?.txt", "file1.txt", true},
+		{"file?.txt", "file12.txt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.path, func(t *testing.T) {
+			res, err := compileGlobs([]string{tt.glob})
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, matchesAny(res, tt.path))
+		})
+	}
+}
+
+func TestGitRepo_Fetch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	origin := t.TempDir()
+	write := func(rel, content string) {
+		p := filepath.Join(origin, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+	write("README.md", "# Project\n\nOverview.")
+	write("docs/guide.md", "Guide text.")
+	write("docs/archive/old.md", "Old text.")
+	write("main.go", "package main\n")
+
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+		{"tag", "v1"},
+	} {
+		_, err := runGit(ctx, origin, args...)
+		require.NoError(t, err)
+	}
+
+	repo, err := NewGitRepo(t.TempDir(), reader.NewReader(reader.DefaultOptions()))
+	require.NoError(t, err)
+
+	res, err := repo.Fetch(ctx, GitOptions{
+		URL:     origin,
+		Ref:     "v1",
+		Paths:   []string{"*.md", "main.go"},
+		Exclude: []string{"docs/archive/**"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, res.Commit, 40)
+
+	var got []string
+	for _, d := range res.Documents {
+		got = append(got, d.Metadata["path"])
+		assert.Equal(t, res.Commit, d.Metadata["commit"])
+	}
+	sort.Strings(got)
+	assert.Equal(t, []string{"README.md", "docs/guide.md", "main.go"}, got)
+
+	_, err = repo.Fetch(ctx, GitOptions{URL: origin, Ref: "--upload-pack=touch /tmp/pwned"})
+	assert.ErrorContains(t, err, "must not start with '-'")
+	_, err = repo.Fetch(ctx, GitOptions{URL: "--upload-pack=touch /tmp/pwned"})
+	assert.ErrorContains(t, err, "must not start with '-'")
+}
@@ -0,0 +1,164 @@
+package source
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultGoogleTokenURL is used when the service account key omits token_uri.
+const defaultGoogleTokenURL = "https://oauth2.googleapis.com/token"
+
+// serviceAccountKey is the subset of a Google service account JSON key used
+// for the JWT bearer flow.
+type serviceAccountKey struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// googleAuth exchanges a signed service account JWT for OAuth access tokens
+// and caches them until shortly before they expire.
+type googleAuth struct {
+	client *http.Client
+	email  string
+	key    *rsa.PrivateKey
+	tokURL string
+	scope  string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// newGoogleAuth loads a service account key file and prepares token exchange
+// for the given OAuth scope.
+func newGoogleAuth(client *http.Client, credentialsFile, scope string) (*googleAuth, error) {
+	if credentialsFile == "" {
+		return nil, errors.New("google credentials file is required (google.credentials_file / GOOGLE_APPLICATION_CREDENTIALS)")
+	}
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("read google credentials %q: %w", credentialsFile, err)
+	}
+	var sa serviceAccountKey
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, fmt.Errorf("parse google credentials %q: %w", credentialsFile, err)
+	}
+	if sa.Type != "service_account" || sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, fmt.Errorf("google credentials %q: not a service account key", credentialsFile)
+	}
+
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return nil, errors.New("google credentials: invalid private key PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("google credentials: parse private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("google credentials: private key is not RSA")
+	}
+
+	tokURL := sa.TokenURI
+	if tokURL == "" {
+		tokURL = defaultGoogleTokenURL
+	}
+	return &googleAuth{client: client, email: sa.ClientEmail, key: key, tokURL: tokURL, scope: scope}, nil
+}
+
+// accessToken returns a valid access token, refreshing it when needed.
+func (a *googleAuth) accessToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Before(a.expires) {
+		return a.token, nil
+	}
+
+	assertion, err := a.signJWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.tokURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request google access token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request google access token: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("parse google access token: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return "", errors.New("google token response has no access_token")
+	}
+	a.token = tok.AccessToken
+	// Refresh a minute early to avoid using a token that expires in flight
+	a.expires = time.Now().Add(time.Duration(tok.ExpiresIn)*time.Second - time.Minute)
+	return a.token, nil
+}
+
+// signJWT builds the RS256-signed assertion for the JWT bearer grant.
+func (a *googleAuth) signJWT(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   a.email,
+		"scope": a.scope,
+		"aud":   a.tokURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	signing := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("sign google JWT: %w", err)
+	}
+	return signing + "." + enc.EncodeToString(sig), nil
+}
+
+// authorize sets the bearer token on req.
+func (a *googleAuth) authorize(ctx context.Context, req *http.Request) error {
+	tok, err := a.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok)
+	return nil
+}
@@ -0,0 +1,248 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+// StorageCredentials holds cloud credentials for object-storage sources.
+// Only the credentials for the schemes actually used need to be set.
+type StorageCredentials struct {
+	// AWS (s3://)
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	AWSRegion          string
+
+	// Google Cloud Storage (gs://): service account key file
+	GoogleCredentialsFile string
+
+	// Azure Blob Storage (az://): account key or SAS token
+	AzureAccount  string
+	AzureKey      string
+	AzureSASToken string
+}
+
+// StorageOptions selects the objects to ingest from a bucket.
+type StorageOptions struct {
+	// URL is s3://bucket/prefix, gs://bucket/prefix, or az://container/prefix
+	URL string
+	// Region overrides the AWS region for this bucket
+	Region string
+	// Endpoint is an S3-compatible endpoint (MinIO, R2, ...); enables path-style requests
+	Endpoint string
+	// Exclude are glob patterns (relative to the prefix) for objects to skip
+	Exclude []string
+}
+
+// StorageResult summarizes an object-storage ingest.
+type StorageResult struct {
+	Documents []reader.Document
+	// Skipped maps object keys to the reason they were not ingested
+	Skipped map[string]string
+	// NotModified counts objects reused from cache because their ETag was unchanged
+	NotModified int
+}
+
+// objectInfo describes a listed object.
+type objectInfo struct {
+	Key      string
+	ETag     string
+	Size     int64
+	Modified time.Time
+}
+
+// objectStore is implemented by each cloud provider.
+type objectStore interface {
+	list(ctx context.Context, prefix string) ([]objectInfo, error)
+	get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Storage ingests documents from S3, GCS, or Azure Blob Storage.
+type Storage struct {
+	client *http.Client
+	creds  StorageCredentials
+	cache  *Cache
+	reader *reader.Reader
+}
+
+// NewStorage creates a Storage source caching objects in cacheDir.
+func NewStorage(creds StorageCredentials, cacheDir string, rd *reader.Reader) (*Storage, error) {
+	if rd == nil {
+		return nil, errors.New("reader is required")
+	}
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Storage{
+		client: &http.Client{Timeout: 60 * time.Second},
+		creds:  creds,
+		cache:  cache,
+		reader: rd,
+	}, nil
+}
+
+// Fetch lists every object under the URL's prefix and reads the supported ones.
+// Objects whose ETag matches the cached copy are not downloaded again.
+func (s *Storage) Fetch(ctx context.Context, opts StorageOptions) (StorageResult, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || u.Host == "" {
+		return StorageResult{}, fmt.Errorf("invalid storage URL %q", opts.URL)
+	}
+	bucket := u.Host
+	prefix := strings.TrimPrefix(u.Path, "/")
+
+	store, err := s.store(u.Scheme, bucket, opts)
+	if err != nil {
+		return StorageResult{}, err
+	}
+	exclude, err := compileGlobs(opts.Exclude)
+	if err != nil {
+		return StorageResult{}, fmt.Errorf("exclude: %w", err)
+	}
+
+	objects, err := store.list(ctx, prefix)
+	if err != nil {
+		return StorageResult{}, fmt.Errorf("list %s: %w", opts.URL, err)
+	}
+
+	res := StorageResult{Skipped: map[string]string{}}
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, "/") {
+			continue
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(obj.Key, prefix), "/")
+		if matchesAny(exclude, rel) {
+			continue
+		}
+		ext := strings.ToLower(path.Ext(obj.Key))
+		if !isSupportedExt(ext) {
+			continue
+		}
+		if obj.Size > maxBodySize {
+			res.Skipped[obj.Key] = fmt.Sprintf("object exceeds %d MB", maxBodySize>>20)
+			continue
+		}
+
+		objURL := u.Scheme + "://" + bucket + "/" + obj.Key
+		status := StatusFetched
+		var bodyPath string
+		if cached, ok := s.cache.lookup(objURL); ok && obj.ETag != "" && cached.ETag == obj.ETag {
+			bodyPath = s.cache.path(cached)
+			status = StatusNotModified
+		} else {
+			body, err := store.get(ctx, obj.Key)
+			if err != nil {
+				res.Skipped[obj.Key] = err.Error()
+				continue
+			}
+			bodyPath, err = s.cache.store(cacheEntry{
+				URL:          objURL,
+				ETag:         obj.ETag,
+				LastModified: obj.Modified.UTC().Format(time.RFC3339),
+				FetchedAt:    time.Now().UTC(),
+			}, ext, body)
+			if err != nil {
+				return res, err
+			}
+		}
+
+		doc, err := s.reader.LoadFile(bodyPath)
+		if err != nil {
+			res.Skipped[obj.Key] = err.Error()
+			continue
+		}
+		if strings.TrimSpace(doc.Content) == "" {
+			continue
+		}
+		if status == StatusNotModified {
+			res.NotModified++
+		}
+
+		doc.Name = objURL
+		doc.Path = objURL
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]string{}
+		}
+		doc.Metadata["object_url"] = objURL
+		if !obj.Modified.IsZero() {
+			doc.Metadata["modified_time"] = obj.Modified.UTC().Format(time.RFC3339)
+		}
+		res.Documents = append(res.Documents, doc)
+	}
+	return res, nil
+}
+
+// store returns the provider client for a URL scheme.
+func (s *Storage) store(scheme, bucket string, opts StorageOptions) (objectStore, error) {
+	switch scheme {
+	case "s3":
+		region := opts.Region
+		if region == "" {
+			region = s.creds.AWSRegion
+		}
+		if region == "" {
+			region = "us-east-1"
+		}
+		if s.creds.AWSAccessKeyID == "" || s.creds.AWSSecretAccessKey == "" {
+			return nil, errors.New("s3: aws.access_key_id and aws.secret_access_key (or AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY) are required")
+		}
+		return &s3Store{
+			client:   s.client,
+			bucket:   bucket,
+			region:   region,
+			endpoint: strings.TrimRight(opts.Endpoint, "/"),
+			keyID:    s.creds.AWSAccessKeyID,
+			secret:   s.creds.AWSSecretAccessKey,
+			token:    s.creds.AWSSessionToken,
+		}, nil
+	case "gs":
+		auth, err := newGoogleAuth(s.client, s.creds.GoogleCredentialsFile, gcsScope)
+		if err != nil {
+			return nil, fmt.Errorf("gcs: %w", err)
+		}
+		return &gcsStore{client: s.client, auth: auth, bucket: bucket, baseURL: gcsAPIBase}, nil
+	case "az":
+		if s.creds.AzureAccount == "" {
+			return nil, errors.New("azure: azure.account_name (or AZURE_STORAGE_ACCOUNT) is required")
+		}
+		if s.creds.AzureKey == "" && s.creds.AzureSASToken == "" {
+			return nil, errors.New("azure: azure.account_key or azure.sas_token (or AZURE_STORAGE_KEY / AZURE_STORAGE_SAS_TOKEN) is required")
+		}
+		return &azureStore{
+			client:    s.client,
+			account:   s.creds.AzureAccount,
+			key:       s.creds.AzureKey,
+			sas:       strings.TrimPrefix(s.creds.AzureSASToken, "?"),
+			container: bucket,
+			baseURL:   "https://" + s.creds.AzureAccount + ".blob.core.windows.net",
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported storage scheme %q (use s3://, gs://, or az://)", scheme)
+}
+
+// readObject reads a response body, enforcing maxBodySize and a 200 status.
+func readObject(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if len(body) > maxBodySize {
+		return nil, fmt.Errorf("body exceeds %d MB", maxBodySize>>20)
+	}
+	return body, nil
+}
@@ -0,0 +1,228 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+// URLListFile is the optional file in data/ listing one URL per line.
+const URLListFile = "urls.txt"
+
+// maxBodySize caps how much of a single remote document is downloaded.
+const maxBodySize = 64 << 20
+
+// userAgent identifies Kash to remote servers.
+const userAgent = "Kash (+https://github.com/akashicode/kash)"
+
+// FetchStatus describes how a remote document was obtained.
+type FetchStatus int
+
+const (
+	// StatusFetched means the body was downloaded.
+	StatusFetched FetchStatus = iota
+	// StatusNotModified means the server returned 304 and the cached body was reused.
+	StatusNotModified
+)
+
+// String returns a human-readable status label.
+func (s FetchStatus) String() string {
+	if s == StatusNotModified {
+		return "not modified"
+	}
+	return "fetched"
+}
+
+// Fetcher downloads remote documents with conditional requests and converts
+// them into reader Documents.
+type Fetcher struct {
+	client *http.Client
+	cache  *Cache
+	reader *reader.Reader
+}
+
+// NewFetcher creates a Fetcher that caches bodies in cacheDir and extracts
+// text with rd.
+func NewFetcher(cacheDir string, rd *reader.Reader) (*Fetcher, error) {
+	if rd == nil {
+		return nil, errors.New("reader is required")
+	}
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	return &Fetcher{
+		client: &http.Client{Timeout: 60 * time.Second},
+		cache:  cache,
+		reader: rd,
+	}, nil
+}
+
+// Fetch downloads rawURL (or reuses the cached copy when the server reports
+// it unchanged) and extracts it into a Document named after the URL.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (reader.Document, FetchStatus, error) {
+	bodyPath, status, err := f.download(ctx, rawURL)
+	if err != nil {
+		return reader.Document{}, status, err
+	}
+	doc, err := f.load(bodyPath, rawURL)
+	return doc, status, err
+}
+
+// load extracts a cached body into a Document named after its URL.
+func (f *Fetcher) load(bodyPath, rawURL string) (reader.Document, error) {
+	doc, err := f.reader.LoadFile(bodyPath)
+	if err != nil {
+		return reader.Document{}, fmt.Errorf("extract %s: %w", rawURL, err)
+	}
+	doc.Path = rawURL
+	doc.Name = rawURL
+	if doc.Metadata == nil {
+		doc.Metadata = map[string]string{}
+	}
+	doc.Metadata["url"] = rawURL
+	return doc, nil
+}
+
+// download performs a conditional GET and returns the path of the cached body.
+func (f *Fetcher) download(ctx context.Context, rawURL string) (string, FetchStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", StatusFetched, fmt.Errorf("create request for %s: %w", rawURL, err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	cached, hasCache := f.cache.lookup(rawURL)
+	if hasCache {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", StatusFetched, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hasCache {
+		return f.cache.path(cached), StatusNotModified, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", StatusFetched, fmt.Errorf("fetch %s: status %d", rawURL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return "", StatusFetched, fmt.Errorf("read %s: %w", rawURL, err)
+	}
+	if len(body) > maxBodySize {
+		return "", StatusFetched, fmt.Errorf("fetch %s: body exceeds %d MB", rawURL, maxBodySize>>20)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	ext := extensionFor(contentType, rawURL)
+	if ext == "" {
+		return "", StatusFetched, fmt.Errorf("fetch %s: %w: %s", rawURL, reader.ErrUnsupportedFormat, contentType)
+	}
+
+	bodyPath, err := f.cache.store(cacheEntry{
+		URL:          rawURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ContentType:  contentType,
+		FetchedAt:    time.Now().UTC(),
+	}, ext, body)
+	if err != nil {
+		return "", StatusFetched, err
+	}
+	return bodyPath, StatusFetched, nil
+}
+
+// extensionFor maps a response content type (or, failing that, the URL path)
+// to a file extension understood by the reader package.
+func extensionFor(contentType, rawURL string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		return ".html"
+	case "application/pdf":
+		return ".pdf"
+	case "text/markdown", "text/x-markdown":
+		return ".md"
+	case "text/plain":
+		return ".txt"
+	case "application/json":
+		return ".json"
+	case "application/x-ndjson", "application/jsonl":
+		return ".jsonl"
+	case "text/csv":
+		return ".csv"
+	case "text/tab-separated-values":
+		return ".tsv"
+	case "application/epub+zip":
+		return ".epub"
+	}
+
+	if u, err := url.Parse(rawURL); err == nil {
+		ext := strings.ToLower(path.Ext(u.Path))
+		if isSupportedExt(ext) {
+			return ext
+		}
+		// Extension-less pages are almost always HTML
+		if ext == "" && mediaType == "" {
+			return ".html"
+		}
+	}
+	return ""
+}
+
+// isSupportedExt reports whether the reader package can load files with ext.
+func isSupportedExt(ext string) bool {
+	switch ext {
+	case ".html", ".htm", ".pdf", ".md", ".markdown", ".txt", ".json", ".jsonl", ".csv", ".tsv", ".epub":
+		return true
+	}
+	return false
+}
+
+// LoadURLList reads a URL list file: one URL per line, blank lines and
+// lines starting with # are ignored. A missing file yields no URLs.
+func LoadURLList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open URL list %q: %w", path, err)
+	}
+	defer f.Close()
+
+	var urls []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read URL list %q: %w", path, err)
+	}
+	return urls, nil
+}
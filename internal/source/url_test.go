@@ -0,0 +1,76 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+func TestFetcher_ConditionalRequests(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><head><title>Guide</title></head><body><p>Hello from the docs.</p><script>x()</script></body></html>"))
+	}))
+	defer srv.Close()
+
+	f, err := NewFetcher(t.TempDir(), reader.NewReader(reader.DefaultOptions()))
+	require.NoError(t, err)
+
+	doc, status, err := f.Fetch(context.Background(), srv.URL+"/guide")
+	require.NoError(t, err)
+	assert.Equal(t, StatusFetched, status)
+	assert.Equal(t, "Hello from the docs.", doc.Content)
+	assert.Equal(t, "Guide", doc.Metadata["title"])
+	assert.Equal(t, srv.URL+"/guide", doc.Name)
+
+	doc, status, err = f.Fetch(context.Background(), srv.URL+"/guide")
+	require.NoError(t, err)
+	assert.Equal(t, StatusNotModified, status)
+	assert.Equal(t, "Hello from the docs.", doc.Content)
+	assert.Equal(t, 2, hits)
+}
+
+func TestExtensionFor(t *testing.T) {
+	tests := []struct {
+		contentType string
+		url         string
+		want        string
+	}{
+		{"text/html; charset=utf-8", "https://x.dev/a", ".html"},
+		{"application/pdf", "https://x.dev/a", ".pdf"},
+		{"application/octet-stream", "https://x.dev/manual.pdf", ".pdf"},
+		{"", "https://x.dev/docs/intro", ".html"},
+		{"image/png", "https://x.dev/logo.png", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, extensionFor(tt.contentType, tt.url), tt.url)
+	}
+}
+
+func TestLoadURLList(t *testing.T) {
+	p := filepath.Join(t.TempDir(), URLListFile)
+	require.NoError(t, os.WriteFile(p, []byte("# docs\nhttps://a.dev\n\n  https://b.dev  \n"), 0644))
+
+	urls, err := LoadURLList(p)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://a.dev", "https://b.dev"}, urls)
+
+	urls, err = LoadURLList(filepath.Join(t.TempDir(), "missing.txt"))
+	require.NoError(t, err)
+	assert.Empty(t, urls)
+}
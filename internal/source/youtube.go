@@ -0,0 +1,443 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akashicode/kash/internal/reader"
+)
+
+const youtubeBase = "https://www.youtube.com"
+
+// YouTubeOptions lists the videos and channels whose captions are ingested.
+type YouTubeOptions struct {
+	// Videos are watch URLs (youtube.com/watch?v=, youtu.be/, /shorts/) or bare video IDs
+	Videos []string
+	// Channels are channel URLs (/channel/UC..., /@handle); their latest uploads are ingested
+	Channels []string
+	// Language is the preferred caption language code (default: "en")
+	Language string
+	// MaxVideos caps how many recent uploads are taken per channel (default: 15,
+	// which is also the most a channel feed returns)
+	MaxVideos int
+	// SegmentSeconds is the length of each timestamped section (default: 60)
+	SegmentSeconds int
+}
+
+// YouTubeResult summarizes a transcript ingest.
+type YouTubeResult struct {
+	Documents []reader.Document
+	// Skipped maps video or channel URLs to the reason they were not ingested
+	Skipped map[string]string
+	// Cached counts transcripts reused from the local cache
+	Cached int
+}
+
+// transcriptCue is a single caption line.
+type transcriptCue struct {
+	Start    float64 `json:"start"`
+	Duration float64 `json:"dur"`
+	Text     string  `json:"text"`
+}
+
+// transcript is a video's captions plus the details stored with them.
+type transcript struct {
+	VideoID   string          `json:"video_id"`
+	Title     string          `json:"title"`
+	Channel   string          `json:"channel"`
+	Language  string          `json:"language"`
+	Generated bool            `json:"generated"`
+	Cues      []transcriptCue `json:"cues"`
+}
+
+// YouTube fetches video captions from public YouTube pages. Transcripts are
+// cached by video ID and language since published captions rarely change.
+type YouTube struct {
+	client  *http.Client
+	cache   *Cache
+	baseURL string
+}
+
+// NewYouTube creates a YouTube source caching transcripts in cacheDir.
+func NewYouTube(cacheDir string) (*YouTube, error) {
+	cache, err := NewCache(cacheDir)
+	if err != nil {
+		return nil, err
+	}
+	return &YouTube{
+		client:  &http.Client{Timeout: 60 * time.Second},
+		cache:   cache,
+		baseURL: youtubeBase,
+	}, nil
+}
+
+// Fetch downloads transcripts for every listed video and the recent uploads
+// of every listed channel, returning one Document per video with a section
+// per SegmentSeconds window.
+func (y *YouTube) Fetch(ctx context.Context, opts YouTubeOptions) (YouTubeResult, error) {
+	if opts.Language == "" {
+		opts.Language = "en"
+	}
+	if opts.MaxVideos <= 0 {
+		opts.MaxVideos = 15
+	}
+	if opts.SegmentSeconds <= 0 {
+		opts.SegmentSeconds = 60
+	}
+
+	res := YouTubeResult{Skipped: map[string]string{}}
+	seen := map[string]bool{}
+	var ids []string
+	for _, v := range opts.Videos {
+		id := videoID(v)
+		if id == "" {
+			res.Skipped[v] = "not a YouTube video URL"
+			continue
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, ch := range opts.Channels {
+		chIDs, err := y.channelVideos(ctx, ch, opts.MaxVideos)
+		if err != nil {
+			res.Skipped[ch] = err.Error()
+			continue
+		}
+		for _, id := range chIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		watchURL := youtubeBase + "/watch?v=" + id
+		tr, cached, err := y.transcript(ctx, id, opts.Language)
+		if err != nil {
+			res.Skipped[watchURL] = err.Error()
+			continue
+		}
+		if cached {
+			res.Cached++
+		}
+		doc := transcriptDocument(tr, opts.SegmentSeconds)
+		if strings.TrimSpace(doc.Content) == "" {
+			res.Skipped[watchURL] = "empty transcript"
+			continue
+		}
+		res.Documents = append(res.Documents, doc)
+	}
+	return res, nil
+}
+
+// transcriptDocument renders a transcript into a Document whose sections
+// cover consecutive windows of segmentSeconds.
+func transcriptDocument(tr transcript, segmentSeconds int) reader.Document {
+	watchURL := youtubeBase + "/watch?v=" + tr.VideoID
+	doc := reader.Document{
+		Path: watchURL,
+		Name: watchURL,
+		Metadata: map[string]string{
+			"url":      watchURL,
+			"video_id": tr.VideoID,
+			"language": tr.Language,
+		},
+	}
+	if tr.Title != "" {
+		doc.Metadata["title"] = tr.Title
+	}
+	if tr.Channel != "" {
+		doc.Metadata["channel"] = tr.Channel
+	}
+	if tr.Generated {
+		doc.Metadata["captions"] = "auto-generated"
+	}
+
+	segments := make([]reader.TranscriptSegment, 0, len(tr.Cues))
+	var all []string
+	for _, c := range tr.Cues {
+		if text := strings.TrimSpace(c.Text); text != "" {
+			segments = append(segments, reader.TranscriptSegment{Start: c.Start, End: c.Start + c.Duration, Text: text})
+			all = append(all, text)
+		}
+	}
+	doc.Sections = reader.TranscriptSections(segments, segmentSeconds)
+	for _, sec := range doc.Sections {
+		sec.Metadata["timestamp_url"] = fmt.Sprintf("%s&t=%ss", watchURL, sec.Metadata["start_seconds"])
+	}
+
+	doc.Content = strings.Join(all, " ")
+	if tr.Title != "" {
+		doc.Content = tr.Title + "\n\n" + doc.Content
+	}
+	return doc
+}
+
+var videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+// videoID extracts the 11-character video ID from a YouTube URL or bare ID.
+func videoID(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if videoIDPattern.MatchString(raw) {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimPrefix(u.Host, "www.")
+	host = strings.TrimPrefix(host, "m.")
+	var id string
+	switch host {
+	case "youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case "youtube.com", "music.youtube.com":
+		if v := u.Query().Get("v"); v != "" {
+			id = v
+		} else {
+			parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+			if len(parts) == 2 && (parts[0] == "shorts" || parts[0] == "embed" || parts[0] == "live") {
+				id = parts[1]
+			}
+		}
+	}
+	if !videoIDPattern.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+var channelIDPattern = regexp.MustCompile(`(?:channel_id=|"externalId":"|/channel/)(UC[A-Za-z0-9_-]{22})`)
+
+// channelVideos returns the most recent upload IDs of a channel from its
+// public Atom feed.
+func (y *YouTube) channelVideos(ctx context.Context, channelURL string, max int) ([]string, error) {
+	channelID := ""
+	if m := channelIDPattern.FindStringSubmatch(channelURL); m != nil {
+		channelID = m[1]
+	} else {
+		// Handles and custom URLs need the channel page to resolve the ID
+		page, err := y.get(ctx, channelURL)
+		if err != nil {
+			return nil, fmt.Errorf("resolve channel: %w", err)
+		}
+		m := channelIDPattern.FindSubmatch(page)
+		if m == nil {
+			return nil, errors.New("could not find channel ID on channel page")
+		}
+		channelID = string(m[1])
+	}
+
+	body, err := y.get(ctx, y.baseURL+"/feeds/videos.xml?channel_id="+channelID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch channel feed: %w", err)
+	}
+	var feed struct {
+		Entries []struct {
+			VideoID string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, fmt.Errorf("parse channel feed: %w", err)
+	}
+	var ids []string
+	for _, e := range feed.Entries {
+		if e.VideoID != "" {
+			ids = append(ids, e.VideoID)
+		}
+		if len(ids) >= max {
+			break
+		}
+	}
+	return ids, nil
+}
+
+// transcript returns the captions for a video, preferring the cached copy.
+func (y *YouTube) transcript(ctx context.Context, id, lang string) (transcript, bool, error) {
+	key := "youtube://" + id + "/" + lang
+	if entry, ok := y.cache.lookup(key); ok {
+		data, err := os.ReadFile(y.cache.path(entry))
+		if err == nil {
+			var tr transcript
+			if json.Unmarshal(data, &tr) == nil {
+				return tr, true, nil
+			}
+		}
+	}
+
+	page, err := y.get(ctx, y.baseURL+"/watch?v="+id+"&hl="+url.QueryEscape(lang))
+	if err != nil {
+		return transcript{}, false, fmt.Errorf("fetch watch page: %w", err)
+	}
+	player, err := parsePlayerResponse(page)
+	if err != nil {
+		return transcript{}, false, err
+	}
+	track, ok := player.captionTrack(lang)
+	if !ok {
+		return transcript{}, false, errors.New("no captions available")
+	}
+	body, err := y.get(ctx, track.BaseURL)
+	if err != nil {
+		return transcript{}, false, fmt.Errorf("fetch captions: %w", err)
+	}
+	cues, err := parseCaptions(body)
+	if err != nil {
+		return transcript{}, false, err
+	}
+
+	tr := transcript{
+		VideoID:   id,
+		Title:     player.VideoDetails.Title,
+		Channel:   player.VideoDetails.Author,
+		Language:  track.LanguageCode,
+		Generated: track.Kind == "asr",
+		Cues:      cues,
+	}
+	if data, err := json.Marshal(tr); err == nil {
+		if _, err := y.cache.store(cacheEntry{URL: key, FetchedAt: time.Now().UTC()}, ".transcript.json", data); err != nil {
+			return tr, false, err
+		}
+	}
+	return tr, false, nil
+}
+
+// captionTrack is an entry of the player response caption track list.
+type captionTrack struct {
+	BaseURL      string `json:"baseUrl"`
+	LanguageCode string `json:"languageCode"`
+	Kind         string `json:"kind"`
+}
+
+// playerResponse is the subset of ytInitialPlayerResponse that Kash reads.
+type playerResponse struct {
+	VideoDetails struct {
+		Title  string `json:"title"`
+		Author string `json:"author"`
+	} `json:"videoDetails"`
+	Captions struct {
+		Renderer struct {
+			Tracks []captionTrack `json:"captionTracks"`
+		} `json:"playerCaptionsTracklistRenderer"`
+	} `json:"captions"`
+}
+
+// captionTrack picks the best track for lang: manual captions in the exact
+// language, then auto-generated ones, then a regional variant, then any track.
+func (p playerResponse) captionTrack(lang string) (captionTrack, bool) {
+	tracks := p.Captions.Renderer.Tracks
+	if len(tracks) == 0 {
+		return captionTrack{}, false
+	}
+	for _, pass := range []func(captionTrack) bool{
+		func(t captionTrack) bool { return t.LanguageCode == lang && t.Kind != "asr" },
+		func(t captionTrack) bool { return t.LanguageCode == lang },
+		func(t captionTrack) bool { return strings.HasPrefix(t.LanguageCode, lang+"-") },
+	} {
+		for _, t := range tracks {
+			if pass(t) {
+				return t, true
+			}
+		}
+	}
+	return tracks[0], true
+}
+
+// parsePlayerResponse extracts ytInitialPlayerResponse from a watch page.
+func parsePlayerResponse(page []byte) (playerResponse, error) {
+	marker := []byte("ytInitialPlayerResponse = ")
+	i := bytes.Index(page, marker)
+	if i < 0 {
+		return playerResponse{}, errors.New("player response not found on watch page (video may be private or age-restricted)")
+	}
+	var p playerResponse
+	if err := json.NewDecoder(bytes.NewReader(page[i+len(marker):])).Decode(&p); err != nil {
+		return playerResponse{}, fmt.Errorf("parse player response: %w", err)
+	}
+	return p, nil
+}
+
+// parseCaptions decodes both the legacy <transcript><text start dur> format
+// and the srv3 <timedtext><body><p t d> format (times in milliseconds).
+func parseCaptions(body []byte) ([]transcriptCue, error) {
+	var doc struct {
+		Texts []struct {
+			Start string `xml:"start,attr"`
+			Dur   string `xml:"dur,attr"`
+			Text  string `xml:",chardata"`
+		} `xml:"text"`
+		Paragraphs []struct {
+			T     string `xml:"t,attr"`
+			D     string `xml:"d,attr"`
+			Text  string `xml:",chardata"`
+			Words []struct {
+				Text string `xml:",chardata"`
+			} `xml:"s"`
+		} `xml:"body>p"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("parse captions: %w", err)
+	}
+
+	var cues []transcriptCue
+	for _, t := range doc.Texts {
+		start, _ := strconv.ParseFloat(t.Start, 64)
+		dur, _ := strconv.ParseFloat(t.Dur, 64)
+		cues = append(cues, transcriptCue{Start: start, Duration: dur, Text: cleanCaption(t.Text)})
+	}
+	for _, p := range doc.Paragraphs {
+		start, _ := strconv.ParseFloat(p.T, 64)
+		dur, _ := strconv.ParseFloat(p.D, 64)
+		text := p.Text
+		for _, w := range p.Words {
+			text += w.Text
+		}
+		cues = append(cues, transcriptCue{Start: start / 1000, Duration: dur / 1000, Text: cleanCaption(text)})
+	}
+	return cues, nil
+}
+
+// cleanCaption undoes the second level of HTML escaping YouTube applies and
+// collapses line breaks.
+func cleanCaption(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
+
+// get performs a GET with headers that avoid the cookie consent interstitial.
+func (y *YouTube) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	req.Header.Set("Cookie", "CONSENT=YES+1")
+	resp, err := y.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+}
@@ -0,0 +1,85 @@
+package source
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42s", "dQw4w9WgXcQ"},
+		{"https://youtu.be/dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"https://m.youtube.com/shorts/dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"https://www.youtube.com/embed/dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"https://www.youtube.com/@kash", ""},
+		{"https://example.com/watch?v=dQw4w9WgXcQ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, videoID(tt.in))
+		})
+	}
+}
+
+func TestParseCaptions(t *testing.T) {
+	legacy := `<transcript><text start="0.5" dur="2.1">Hello &amp;#39;world&amp;#39;</text><text start="3" dur="1">line
+two</text></transcript>`
+	cues, err := parseCaptions([]byte(legacy))
+	require.NoError(t, err)
+	assert.Equal(t, []transcriptCue{
+		{Start: 0.5, Duration: 2.1, Text: "Hello 'world'"},
+		{Start: 3, Duration: 1, Text: "line two"},
+	}, cues)
+
+	srv3 := `<timedtext format="3"><body><p t="1500" d="2000"><s>Hi</s><s> there</s></p></body></timedtext>`
+	cues, err = parseCaptions([]byte(srv3))
+	require.NoError(t, err)
+	assert.Equal(t, []transcriptCue{{Start: 1.5, Duration: 2, Text: "Hi there"}}, cues)
+}
+
+func TestYouTube_Fetch(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/watch":
+			w.Write([]byte(`<script>var ytInitialPlayerResponse = {"videoDetails":{"title":"Intro Talk","author":"Kash"},
+				"captions":{"playerCaptionsTracklistRenderer":{"captionTracks":[
+				{"baseUrl":"` + srv.URL + `/asr","languageCode":"en","kind":"asr"},
+				{"baseUrl":"` + srv.URL + `/manual","languageCode":"en"}]}}};var other = {};</script>`))
+		case "/manual":
+			w.Write([]byte(`<transcript><text start="0" dur="5">Welcome.</text><text start="30" dur="5">Setup.</text><text start="75" dur="5">Questions.</text></transcript>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	y, err := NewYouTube(t.TempDir())
+	require.NoError(t, err)
+	y.baseURL = srv.URL
+
+	res, err := y.Fetch(context.Background(), YouTubeOptions{Videos: []string{"https://youtu.be/abcdefghijk"}})
+	require.NoError(t, err)
+	require.Len(t, res.Documents, 1)
+
+	doc := res.Documents[0]
+	assert.Equal(t, "Intro Talk", doc.Metadata["title"])
+	require.Len(t, doc.Sections, 2)
+	assert.Equal(t, "Welcome. Setup.", doc.Sections[0].Content)
+	assert.Equal(t, "1:15", doc.Sections[1].Metadata["timestamp"])
+	assert.Equal(t, "https://www.youtube.com/watch?v=abcdefghijk&t=75s", doc.Sections[1].Metadata["timestamp_url"])
+
+	// Second fetch is served from the transcript cache
+	res, err = y.Fetch(context.Background(), YouTubeOptions{Videos: []string{"abcdefghijk"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Cached)
+}
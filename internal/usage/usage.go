@@ -0,0 +1,214 @@
+// Package usage keeps in-memory request, token, and query counts over a
+// rolling window, so operators can see who uses an agent and how much.
+package usage
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bucketSize is the granularity of the rolling window.
+const bucketSize = time.Minute
+
+// maxQueriesPerBucket bounds the distinct queries kept per minute, so a flood
+// of unique queries cannot exhaust memory. Further queries are still counted
+// as requests.
+const maxQueriesPerBucket = 1000
+
+// maxQueryLength truncates long queries before they are counted.
+const maxQueryLength = 200
+
+// Event is one request.
+type Event struct {
+	Time     time.Time
+	KeyID    string
+	Endpoint string
+	// Query is empty for requests that did not search the knowledge base
+	Query            string
+	Status           int
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Counts aggregates requests.
+type Counts struct {
+	Requests         int `json:"requests"`
+	Errors           int `json:"errors"`
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (c *Counts) add(e Event) {
+	c.Requests++
+	if e.Status >= 400 {
+		c.Errors++
+	}
+	c.PromptTokens += e.PromptTokens
+	c.CompletionTokens += e.CompletionTokens
+	c.TotalTokens += e.PromptTokens + e.CompletionTokens
+}
+
+func (c *Counts) merge(o *Counts) {
+	c.Requests += o.Requests
+	c.Errors += o.Errors
+	c.PromptTokens += o.PromptTokens
+	c.CompletionTokens += o.CompletionTokens
+	c.TotalTokens += o.TotalTokens
+}
+
+// QueryCount is a query and how often it was asked.
+type QueryCount struct {
+	Query string `json:"query"`
+	Count int    `json:"count"`
+}
+
+// Report summarizes the usage within the window.
+type Report struct {
+	Window     string            `json:"window"`
+	Since      time.Time         `json:"since"`
+	Total      Counts            `json:"total"`
+	ByKey      map[string]Counts `json:"by_key"`
+	ByEndpoint map[string]Counts `json:"by_endpoint"`
+	TopQueries []QueryCount      `json:"top_queries"`
+}
+
+type callKey struct{ keyID, endpoint string }
+
+type queryKey struct{ keyID, query string }
+
+type bucket struct {
+	start   time.Time
+	calls   map[callKey]*Counts
+	queries map[queryKey]int
+}
+
+// Tracker records events and reports on the last window of them. It is safe
+// for concurrent use.
+type Tracker struct {
+	window  time.Duration
+	mu      sync.Mutex
+	buckets []*bucket // oldest first
+}
+
+// NewTracker keeps usage for the given window (at least one minute).
+func NewTracker(window time.Duration) *Tracker {
+	if window < bucketSize {
+		window = bucketSize
+	}
+	return &Tracker{window: window}
+}
+
+// Window returns the length of the rolling window.
+func (t *Tracker) Window() time.Duration {
+	return t.window
+}
+
+// Record counts one event.
+func (t *Tracker) Record(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	start := e.Time.Truncate(bucketSize)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(e.Time)
+	var b *bucket
+	if n := len(t.buckets); n > 0 && t.buckets[n-1].start.Equal(start) {
+		b = t.buckets[n-1]
+	} else {
+		b = &bucket{start: start, calls: map[callKey]*Counts{}, queries: map[queryKey]int{}}
+		t.buckets = append(t.buckets, b)
+	}
+
+	ck := callKey{e.KeyID, e.Endpoint}
+	if b.calls[ck] == nil {
+		b.calls[ck] = &Counts{}
+	}
+	b.calls[ck].add(e)
+
+	if q := normalizeQuery(e.Query); q != "" {
+		qk := queryKey{e.KeyID, q}
+		if _, ok := b.queries[qk]; ok || len(b.queries) < maxQueriesPerBucket {
+			b.queries[qk]++
+		}
+	}
+}
+
+// Report summarizes the window ending now. When keyID is non-nil only that
+// key's requests are included. top limits the number of top queries.
+func (t *Tracker) Report(now time.Time, keyID *string, top int) Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(now)
+
+	r := Report{
+		Window:     t.window.String(),
+		Since:      now.Add(-t.window).UTC(),
+		ByKey:      map[string]Counts{},
+		ByEndpoint: map[string]Counts{},
+		TopQueries: []QueryCount{},
+	}
+	queries := map[string]int{}
+	for _, b := range t.buckets {
+		for ck, c := range b.calls {
+			if keyID != nil && ck.keyID != *keyID {
+				continue
+			}
+			r.Total.merge(c)
+			name := ck.keyID
+			if name == "" {
+				name = "anonymous"
+			}
+			byKey := r.ByKey[name]
+			byKey.merge(c)
+			r.ByKey[name] = byKey
+			byEndpoint := r.ByEndpoint[ck.endpoint]
+			byEndpoint.merge(c)
+			r.ByEndpoint[ck.endpoint] = byEndpoint
+		}
+		for qk, n := range b.queries {
+			if keyID != nil && qk.keyID != *keyID {
+				continue
+			}
+			queries[qk.query] += n
+		}
+	}
+
+	for q, n := range queries {
+		r.TopQueries = append(r.TopQueries, QueryCount{Query: q, Count: n})
+	}
+	sort.Slice(r.TopQueries, func(i, j int) bool {
+		if r.TopQueries[i].Count != r.TopQueries[j].Count {
+			return r.TopQueries[i].Count > r.TopQueries[j].Count
+		}
+		return r.TopQueries[i].Query < r.TopQueries[j].Query
+	})
+	if top >= 0 && len(r.TopQueries) > top {
+		r.TopQueries = r.TopQueries[:top]
+	}
+	return r
+}
+
+// expire drops buckets that ended before the window. Callers hold t.mu.
+func (t *Tracker) expire(now time.Time) {
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i < len(t.buckets) && !t.buckets[i].start.Add(bucketSize).After(cutoff) {
+		i++
+	}
+	t.buckets = t.buckets[i:]
+}
+
+// normalizeQuery folds case and whitespace so repeats of a query count
+// together.
+func normalizeQuery(q string) string {
+	q = strings.ToLower(strings.Join(strings.Fields(q), " "))
+	if len(q) > maxQueryLength {
+		q = q[:maxQueryLength]
+	}
+	return q
+}
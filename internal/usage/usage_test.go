@@ -0,0 +1,42 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackerReport(t *testing.T) {
+	now := time.Date(2026, 2, 27, 12, 0, 0, 0, time.UTC)
+	tr := NewTracker(time.Hour)
+	events := []Event{
+		{Time: now.Add(-2 * time.Hour), KeyID: "key_a", Endpoint: "/v1/chat/completions", Query: "expired"},
+		{Time: now.Add(-30 * time.Minute), KeyID: "key_a", Endpoint: "/v1/chat/completions", Query: "Refund policy?", Status: 200, PromptTokens: 100, CompletionTokens: 20},
+		{Time: now.Add(-10 * time.Minute), KeyID: "key_a", Endpoint: "/v1/chat/completions", Query: "refund   POLICY?", Status: 200, PromptTokens: 80, CompletionTokens: 10},
+		{Time: now.Add(-5 * time.Minute), KeyID: "key_b", Endpoint: "/mcp", Query: "invoices", Status: 502},
+		{Time: now.Add(-time.Minute), Endpoint: "/v1/retrieve", Query: "invoices", Status: 200},
+	}
+	for _, e := range events {
+		tr.Record(e)
+	}
+
+	r := tr.Report(now, nil, 10)
+	assert.Equal(t, Counts{Requests: 4, Errors: 1, PromptTokens: 180, CompletionTokens: 30, TotalTokens: 210}, r.Total)
+	assert.Equal(t, 2, r.ByKey["key_a"].Requests)
+	assert.Equal(t, 1, r.ByKey["anonymous"].Requests)
+	assert.Equal(t, 1, r.ByEndpoint["/mcp"].Errors)
+	require.Len(t, r.TopQueries, 2)
+	assert.Equal(t, QueryCount{Query: "invoices", Count: 2}, r.TopQueries[0])
+	assert.Equal(t, QueryCount{Query: "refund policy?", Count: 2}, r.TopQueries[1])
+
+	key := "key_b"
+	own := tr.Report(now, &key, 10)
+	assert.Equal(t, 1, own.Total.Requests)
+	assert.Len(t, own.ByKey, 1)
+	assert.Equal(t, []QueryCount{{Query: "invoices", Count: 1}}, own.TopQueries)
+
+	assert.Len(t, tr.Report(now, nil, 1).TopQueries, 1)
+	assert.Zero(t, tr.Report(now.Add(2*time.Hour), nil, 10).Total.Requests)
+}
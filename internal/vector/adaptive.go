@@ -0,0 +1,149 @@
+package vector
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMaxConcurrency caps the concurrent embedding calls of a store when
+// runtime.embedder.concurrency in agent.yaml does not.
+const DefaultMaxConcurrency = 32
+
+// latencyRise is how much slower than the best seen a window of calls may
+// get before the limiter takes it as a sign of overload. Differences below
+// latencyNoise are jitter, whatever the ratio.
+const (
+	latencyRise  = 2.0
+	latencyNoise = 10 * time.Millisecond
+)
+
+// EmbedStats describes how the concurrency of a store's embedding calls
+// adapted to the embedder.
+type EmbedStats struct {
+	// Concurrency is the current limit, Peak the highest it reached
+	Concurrency int
+	Peak        int
+	// RateLimited counts the calls the embedder refused with a 429
+	RateLimited int
+}
+
+// limiter bounds concurrent embedding calls with a limit that adapts to the
+// embedder. The limit starts at one and doubles after each window of calls,
+// a window being as many calls as the limit, until the embedder rate-limits
+// a call, a window is much slower than the fastest, or the maximum is
+// reached. From then on it grows by one per window, halves on a rate limit,
+// and shrinks by a quarter when latency rises. Calls started before the
+// last change of the limit do not count, so one burst of 429s halves it
+// once.
+type limiter struct {
+	mu        sync.Mutex
+	wake      chan struct{}
+	limit     int
+	max       int
+	inflight  int
+	slowStart bool
+	changed   time.Time
+	// baseline is the fastest mean latency of a window, drifting slowly up
+	// when the embedder gets slower for good
+	baseline time.Duration
+	sum      time.Duration
+	samples  int
+	stats    EmbedStats
+}
+
+func newLimiter(max int) *limiter {
+	l := &limiter{wake: make(chan struct{}), limit: 1, slowStart: true}
+	l.setMax(max)
+	return l
+}
+
+// setMax changes the maximum limit; zero or less means DefaultMaxConcurrency.
+func (l *limiter) setMax(max int) {
+	if max <= 0 {
+		max = DefaultMaxConcurrency
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.max = max
+	l.limit = min(l.limit, max)
+}
+
+// acquire waits until a call may start.
+func (l *limiter) acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.inflight < l.limit {
+			l.inflight++
+			l.mu.Unlock()
+			return nil
+		}
+		wake := l.wake
+		l.mu.Unlock()
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release ends a call started at start, adapting the limit to its outcome.
+func (l *limiter) release(start time.Time, rateLimited bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inflight--
+	close(l.wake)
+	l.wake = make(chan struct{})
+
+	if rateLimited {
+		l.stats.RateLimited++
+	}
+	if start.Before(l.changed) {
+		return
+	}
+	if rateLimited {
+		l.setLimit(max(1, l.limit/2))
+		l.slowStart = false
+		return
+	}
+
+	l.sum += time.Since(start)
+	l.samples++
+	if l.samples < l.limit {
+		return
+	}
+	mean := l.sum / time.Duration(l.samples)
+	switch {
+	case l.baseline == 0 || mean < l.baseline:
+		l.baseline = mean
+	case mean > l.baseline:
+		l.baseline += (mean - l.baseline) / 8
+	}
+	switch {
+	case float64(mean) > float64(l.baseline)*latencyRise && mean-l.baseline > latencyNoise:
+		l.setLimit(max(1, l.limit*3/4))
+		l.slowStart = false
+	case l.slowStart:
+		l.setLimit(min(l.max, l.limit*2))
+	default:
+		l.setLimit(min(l.max, l.limit+1))
+	}
+}
+
+// setLimit changes the limit and starts a new window; l.mu must be held.
+func (l *limiter) setLimit(limit int) {
+	l.limit = limit
+	l.changed = time.Now()
+	l.sum, l.samples = 0, 0
+	l.stats.Peak = max(l.stats.Peak, limit)
+}
+
+func (l *limiter) snapshot() EmbedStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := l.stats
+	s.Concurrency = l.limit
+	s.Peak = max(s.Peak, l.limit)
+	return s
+}
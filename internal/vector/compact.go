@@ -0,0 +1,254 @@
+package vector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	chromem "github.com/philippgille/chromem-go"
+)
+
+// CompactOptions configures Compact.
+type CompactOptions struct {
+	// Keep reports whether a decodable chunk still belongs in the store;
+	// chunks it rejects are removed as orphans. Nil keeps every chunk
+	Keep func(Document) bool
+	// DryRun reports what Compact would remove without rewriting the store
+	DryRun bool
+}
+
+// CompactReport describes what Compact found. Every list names what was, or
+// on a dry run would be, removed from the store.
+type CompactReport struct {
+	// Chunks is the number of chunks kept
+	Chunks int `json:"chunks"`
+	// Dimensions is the embedding length of the kept chunks: the most common
+	// one in the store
+	Dimensions int `json:"dimensions"`
+	// Undecodable lists document files chromem-go cannot read, relative to
+	// the store; a single one keeps the whole store from loading
+	Undecodable []string `json:"undecodable"`
+	// WrongDimensions lists chunk IDs whose embedding length differs
+	WrongDimensions []string `json:"wrong_dimensions"`
+	// InvalidVectors lists chunk IDs without an embedding or with NaN or
+	// infinite components
+	InvalidVectors []string `json:"invalid_vectors"`
+	// Duplicates lists chunk IDs stored in more than one file
+	Duplicates []string `json:"duplicates"`
+	// Orphaned lists chunk IDs that CompactOptions.Keep rejected
+	Orphaned []string `json:"orphaned"`
+	// StrayFiles lists files and directories that are not part of the
+	// documents collection, relative to the store
+	StrayFiles  []string `json:"stray_files"`
+	BytesBefore int64    `json:"bytes_before"`
+	BytesAfter  int64    `json:"bytes_after"`
+	// Rewritten is set when the store was rewritten
+	Rewritten bool `json:"rewritten"`
+}
+
+// Removed counts the chunks and files removed from the store.
+func (r CompactReport) Removed() int {
+	return len(r.Undecodable) + len(r.WrongDimensions) + len(r.InvalidVectors) +
+		len(r.Duplicates) + len(r.Orphaned) + len(r.StrayFiles)
+}
+
+// collectionMetadata is the collection metadata file chromem-go writes.
+type collectionMetadata struct {
+	Name     string
+	Metadata map[string]string
+}
+
+// Compact verifies every chunk in the chromem-go database directory at path
+// and rewrites the directory with only the chunks that decode, have a finite
+// embedding of the common dimensions, and pass opts.Keep. A store with
+// nothing to remove is left as it is. The rewritten store is built next to
+// path and swapped in, so a failure leaves the original untouched.
+func Compact(ctx context.Context, path string, opts CompactOptions) (CompactReport, error) {
+	var report CompactReport
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return report, fmt.Errorf("open vector store: %w", err)
+	}
+	if report.BytesBefore, err = dirBytes(path); err != nil {
+		return report, err
+	}
+
+	var docs []Document
+	for _, e := range entries {
+		if !e.IsDir() {
+			report.StrayFiles = append(report.StrayFiles, e.Name())
+			continue
+		}
+		dir := filepath.Join(path, e.Name())
+		found, ok, err := readCollection(dir, e.Name(), &report)
+		if err != nil {
+			return report, err
+		}
+		if !ok {
+			report.StrayFiles = append(report.StrayFiles, e.Name()+string(filepath.Separator))
+			continue
+		}
+		docs = append(docs, found...)
+	}
+	report.Dimensions = commonDimensions(docs)
+
+	seen := map[string]bool{}
+	var kept []Document
+	for _, d := range docs {
+		switch {
+		case seen[d.ID]:
+			report.Duplicates = append(report.Duplicates, d.ID)
+		case !finite(d.Embedding):
+			report.InvalidVectors = append(report.InvalidVectors, d.ID)
+		case len(d.Embedding) != report.Dimensions:
+			report.WrongDimensions = append(report.WrongDimensions, d.ID)
+		case opts.Keep != nil && !opts.Keep(d):
+			report.Orphaned = append(report.Orphaned, d.ID)
+		default:
+			kept = append(kept, d)
+		}
+		seen[d.ID] = true
+	}
+	report.Chunks = len(kept)
+	report.BytesAfter = report.BytesBefore
+	if opts.DryRun || report.Removed() == 0 {
+		return report, nil
+	}
+
+	tmp, old := path+".compact", path+".old"
+	os.RemoveAll(tmp)
+	os.RemoveAll(old)
+	if err := WriteDocuments(ctx, tmp, kept); err != nil {
+		os.RemoveAll(tmp)
+		return report, err
+	}
+	if err := os.Rename(path, old); err != nil {
+		os.RemoveAll(tmp)
+		return report, fmt.Errorf("replace vector store: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Rename(old, path)
+		return report, fmt.Errorf("replace vector store: %w", err)
+	}
+	os.RemoveAll(old)
+	report.Rewritten = true
+	if report.BytesAfter, err = dirBytes(path); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// documentsDir is the directory chromem-go keeps the documents collection
+// in: the first four bytes of the SHA-256 of its name, in hex.
+var documentsDir = func() string {
+	sum := sha256.Sum256([]byte("documents"))
+	return hex.EncodeToString(sum[:4])
+}()
+
+// readCollection reads the documents of the collection in dir. ok is false
+// when dir is not kash's documents collection, which is recognized by its
+// metadata or, when that is damaged, by its directory name.
+func readCollection(dir, rel string, report *CompactReport) (docs []Document, ok bool, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, false, fmt.Errorf("read %s: %w", dir, err)
+	}
+	var meta collectionMetadata
+	metaFile := collectionMetadataFile + ".gob"
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), collectionMetadataFile+".") {
+			metaFile = e.Name()
+			decodeFile(filepath.Join(dir, metaFile), &meta)
+		}
+	}
+	if meta.Name != "documents" {
+		if filepath.Base(dir) != documentsDir {
+			return nil, false, nil
+		}
+		// The rewrite restores the collection metadata
+		report.Undecodable = append(report.Undecodable, filepath.Join(rel, metaFile))
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, collectionMetadataFile+".") {
+			continue
+		}
+		relPath := filepath.Join(rel, name)
+		if e.IsDir() || !(strings.HasSuffix(name, ".gob") || strings.HasSuffix(name, ".gob.gz")) {
+			report.StrayFiles = append(report.StrayFiles, relPath)
+			continue
+		}
+		var doc chromem.Document
+		if err := decodeFile(filepath.Join(dir, name), &doc); err != nil || doc.ID == "" {
+			report.Undecodable = append(report.Undecodable, relPath)
+			continue
+		}
+		docs = append(docs, Document{
+			ID:        doc.ID,
+			Content:   doc.Content,
+			Source:    doc.Metadata["source"],
+			Metadata:  doc.Metadata,
+			Embedding: doc.Embedding,
+		})
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs, true, nil
+}
+
+// commonDimensions returns the most frequent embedding length, preferring
+// the larger one on a tie.
+func commonDimensions(docs []Document) int {
+	counts := map[int]int{}
+	for _, d := range docs {
+		if len(d.Embedding) > 0 {
+			counts[len(d.Embedding)]++
+		}
+	}
+	best := 0
+	for dims, n := range counts {
+		if n > counts[best] || n == counts[best] && dims > best {
+			best = dims
+		}
+	}
+	return best
+}
+
+func finite(v []float32) bool {
+	if len(v) == 0 {
+		return false
+	}
+	for _, x := range v {
+		if math.IsNaN(float64(x)) || math.IsInf(float64(x), 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func dirBytes(path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, fmt.Errorf("measure %s: %w", path, err)
+	}
+	return total, nil
+}
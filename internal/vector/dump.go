@@ -0,0 +1,147 @@
+package vector
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	chromem "github.com/philippgille/chromem-go"
+)
+
+// collectionMetadataFile is the name chromem-go gives the per-collection
+// metadata file; every other .gob file in a collection is a document.
+const collectionMetadataFile = "00000000"
+
+// ReadDocuments reads every chunk persisted in a chromem-go database
+// directory, including its embedding, without needing an embedder. Results
+// are sorted by source and chunk index.
+func ReadDocuments(path string) ([]Document, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("open vector store: %w", err)
+	}
+
+	var docs []Document
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		name := d.Name()
+		if d.IsDir() || !(strings.HasSuffix(name, ".gob") || strings.HasSuffix(name, ".gob.gz")) {
+			return nil
+		}
+		if strings.HasPrefix(name, collectionMetadataFile+".") {
+			return nil
+		}
+		doc, err := readDocumentFile(p)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", p, err)
+		}
+		if info, err := d.Info(); err == nil {
+			doc.ModTime = info.ModTime()
+		}
+		docs = append(docs, doc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].Source != docs[j].Source {
+			return docs[i].Source < docs[j].Source
+		}
+		return chunkIndex(docs[i]) < chunkIndex(docs[j])
+	})
+	return docs, nil
+}
+
+// WriteDocuments persists docs with the embeddings they carry into a
+// chromem-go database directory at path, creating it if needed, without
+// calling an embedder. Documents without an embedding are rejected.
+func WriteDocuments(ctx context.Context, path string, docs []Document) error {
+	db, err := chromem.NewPersistentDB(path, false)
+	if err != nil {
+		return fmt.Errorf("create persistent db at %q: %w", path, err)
+	}
+	noEmbedder := func(context.Context, string) ([]float32, error) {
+		return nil, errors.New("document has no embedding")
+	}
+	collection, err := db.GetOrCreateCollection("documents", nil, noEmbedder)
+	if err != nil {
+		return fmt.Errorf("get or create collection: %w", err)
+	}
+	out := make([]chromem.Document, len(docs))
+	for i, d := range docs {
+		out[i] = chromem.Document{ID: d.ID, Content: d.Content, Metadata: d.Metadata, Embedding: d.Embedding}
+	}
+	if err := collection.AddDocuments(ctx, out, runtime.NumCPU()); err != nil {
+		return fmt.Errorf("add documents to collection: %w", err)
+	}
+	return nil
+}
+
+// StoredDimensions returns the embedding length of the chunks persisted at
+// path, or 0 when the store is empty.
+func StoredDimensions(path string) (int, error) {
+	docs, err := ReadDocuments(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(docs) == 0 {
+		return 0, nil
+	}
+	return len(docs[0].Embedding), nil
+}
+
+func readDocumentFile(path string) (Document, error) {
+	var doc chromem.Document
+	if err := decodeFile(path, &doc); err != nil {
+		return Document{}, err
+	}
+	if doc.ID == "" {
+		return Document{}, errors.New("not a chromem document")
+	}
+	return Document{
+		ID:        doc.ID,
+		Content:   doc.Content,
+		Source:    doc.Metadata["source"],
+		Metadata:  doc.Metadata,
+		Embedding: doc.Embedding,
+	}, nil
+}
+
+// decodeFile reads a gob file written by chromem-go, gzipped when its name
+// ends in .gz.
+func decodeFile(path string, v interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return gob.NewDecoder(r).Decode(v)
+}
+
+func chunkIndex(d Document) int {
+	var n int
+	fmt.Sscanf(d.Metadata["index"], "%d", &n)
+	return n
+}
@@ -0,0 +1,109 @@
+package vector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	chromem "github.com/philippgille/chromem-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDocuments(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "memory.chromem")
+	db, err := chromem.NewPersistentDB(dir, false)
+	require.NoError(t, err)
+	col, err := db.CreateCollection("documents", nil, nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	docs := []chromem.Document{
+		{ID: "b-1", Content: "second", Metadata: map[string]string{"source": "b.md", "index": "1"}, Embedding: []float32{0, 1, 0}},
+		{ID: "b-0", Content: "first", Metadata: map[string]string{"source": "b.md", "index": "0"}, Embedding: []float32{1, 0, 0}},
+		{ID: "a-10", Content: "a tenth", Metadata: map[string]string{"source": "a.md", "index": "10"}, Embedding: []float32{0, 0, 1}},
+		{ID: "a-2", Content: "a second", Metadata: map[string]string{"source": "a.md", "index": "2"}, Embedding: []float32{0, 0, 1}},
+	}
+	require.NoError(t, col.AddDocuments(ctx, docs, 1))
+
+	got, err := ReadDocuments(dir)
+	require.NoError(t, err)
+	require.Len(t, got, 4)
+	var ids []string
+	for _, d := range got {
+		ids = append(ids, d.ID)
+	}
+	assert.Equal(t, []string{"a-2", "a-10", "b-0", "b-1"}, ids)
+	assert.Equal(t, "b.md", got[2].Source)
+	assert.Equal(t, "first", got[2].Content)
+	assert.Len(t, got[2].Embedding, 3)
+	assert.False(t, got[2].ModTime.IsZero(), "the time the chunk was written")
+
+	dims, err := StoredDimensions(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, dims)
+
+	_, err = ReadDocuments(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
+
+func TestWriteDocuments(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "memory.chromem")
+	docs := []Document{
+		{ID: "a-0", Content: "first", Metadata: map[string]string{"source": "a.md", "index": "0"}, Embedding: []float32{1, 0}},
+		{ID: "a-1", Content: "second", Metadata: map[string]string{"source": "a.md", "index": "1"}, Embedding: []float32{0, 1}},
+	}
+	require.NoError(t, WriteDocuments(context.Background(), dir, docs))
+
+	got, err := ReadDocuments(dir)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "second", got[1].Content)
+	assert.Equal(t, "a.md", got[1].Source)
+	assert.Equal(t, []float32{0, 1}, got[1].Embedding)
+
+	err = WriteDocuments(context.Background(), dir, []Document{{ID: "b-0", Content: "no vector"}})
+	assert.Error(t, err)
+}
+
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "memory.chromem")
+	require.NoError(t, WriteDocuments(ctx, dir, []Document{
+		{ID: "a-0", Content: "kept", Metadata: map[string]string{"source": "a.md"}, Embedding: []float32{1, 0, 0}},
+		{ID: "a-1", Content: "kept too", Metadata: map[string]string{"source": "a.md"}, Embedding: []float32{0, 1, 0}},
+		{ID: "gone-0", Content: "orphan", Metadata: map[string]string{"source": "gone.md"}, Embedding: []float32{0, 0, 1}},
+		{ID: "b-0", Content: "short", Metadata: map[string]string{"source": "b.md"}, Embedding: []float32{1, 0}},
+	}))
+	collection := filepath.Join(dir, documentsDir)
+	require.NoError(t, os.WriteFile(filepath.Join(collection, "deadbeef.gob"), []byte("not gob"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("stray"), 0644))
+	keep := func(d Document) bool { return d.Source != "gone.md" }
+
+	report, err := Compact(ctx, dir, CompactOptions{Keep: keep, DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Chunks)
+	assert.Equal(t, 3, report.Dimensions)
+	assert.Equal(t, []string{filepath.Join(documentsDir, "deadbeef.gob")}, report.Undecodable)
+	assert.Equal(t, []string{"b-0"}, report.WrongDimensions)
+	assert.Equal(t, []string{"gone-0"}, report.Orphaned)
+	assert.Equal(t, []string{"notes.txt"}, report.StrayFiles)
+	assert.False(t, report.Rewritten)
+	assert.FileExists(t, filepath.Join(dir, "notes.txt"), "a dry run changes nothing")
+
+	report, err = Compact(ctx, dir, CompactOptions{Keep: keep})
+	require.NoError(t, err)
+	assert.True(t, report.Rewritten)
+	assert.Less(t, report.BytesAfter, report.BytesBefore)
+	docs, err := ReadDocuments(dir)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	_, err = chromem.NewPersistentDB(dir, false)
+	require.NoError(t, err, "the compacted store loads")
+
+	report, err = Compact(ctx, dir, CompactOptions{Keep: keep})
+	require.NoError(t, err)
+	assert.Zero(t, report.Removed())
+	assert.False(t, report.Rewritten)
+}
@@ -9,13 +9,18 @@ import (
 	"io"
 	"net/http"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	chromem "github.com/philippgille/chromem-go"
 
 	"github.com/akashicode/kash/internal/chunker"
 	"github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/lang"
+	"github.com/akashicode/kash/internal/llm"
 )
 
 // ErrNilConfig is returned when a nil config is provided.
@@ -24,12 +29,26 @@ var ErrNilConfig = errors.New("vector store config is nil")
 // ErrNotFound is returned when a query returns no results.
 var ErrNotFound = errors.New("no results found")
 
+// EmbedderKey is the chunk metadata key naming the embedder a chunk was
+// embedded with when the embedder routes languages to models of their own:
+// a language code of its Languages, or DefaultEmbedder.
+const EmbedderKey = "embedder"
+
+// DefaultEmbedder is the EmbedderKey of chunks embedded with the embedder
+// itself, in a language without a model of its own.
+const DefaultEmbedder = "default"
+
 // Document represents a document stored in the vector store.
 type Document struct {
 	ID       string
 	Content  string
 	Source   string
 	Metadata map[string]string
+	// Embedding is only populated by ReadDocuments
+	Embedding []float32
+	// ModTime is when the chunk was last written to the store; only
+	// populated by ReadDocuments
+	ModTime time.Time
 }
 
 // SearchResult represents a single vector search result.
@@ -38,7 +57,10 @@ type SearchResult struct {
 	Content    string
 	Source     string
 	Similarity float32
-	Metadata   map[string]string
+	// Score is the relevance retrieval fusion gives the chunk, between 0
+	// and 1; zero without fusion
+	Score    float64
+	Metadata map[string]string
 }
 
 // Store wraps a chromem-go database for vector operations.
@@ -46,6 +68,28 @@ type Store struct {
 	db         *chromem.DB
 	collection *chromem.Collection
 	embedCfg   *config.ProviderConfig
+	embed      chromem.EmbeddingFunc
+	// languages embed the texts of embedCfg.Languages, by language code
+	languages map[string]chromem.EmbeddingFunc
+	limiter   *limiter
+}
+
+func newStore(db *chromem.DB, collection *chromem.Collection, embedCfg *config.ProviderConfig, embed chromem.EmbeddingFunc) *Store {
+	s := &Store{
+		db:         db,
+		collection: collection,
+		embedCfg:   embedCfg,
+		embed:      embed,
+		limiter:    newLimiter(0),
+	}
+	for _, code := range embedCfg.LanguageCodes() {
+		route, _ := embedCfg.ForLanguage(code)
+		if s.languages == nil {
+			s.languages = make(map[string]chromem.EmbeddingFunc, len(embedCfg.Languages))
+		}
+		s.languages[code] = newEmbeddingFuncWithDimensions(&route)
+	}
+	return s
 }
 
 // NewStore creates a new vector Store backed by an in-memory chromem-go database.
@@ -63,11 +107,7 @@ func NewStore(embedCfg *config.ProviderConfig) (*Store, error) {
 		return nil, fmt.Errorf("create collection: %w", err)
 	}
 
-	return &Store{
-		db:         db,
-		collection: collection,
-		embedCfg:   embedCfg,
-	}, nil
+	return newStore(db, collection, embedCfg, embeddingFunc), nil
 }
 
 // NewStoreFromPath loads a persisted chromem-go database from disk.
@@ -92,11 +132,7 @@ func NewStoreFromPath(path string, embedCfg *config.ProviderConfig) (*Store, err
 		}
 	}
 
-	return &Store{
-		db:         db,
-		collection: collection,
-		embedCfg:   embedCfg,
-	}, nil
+	return newStore(db, collection, embedCfg, embeddingFunc), nil
 }
 
 // NewPersistentStore creates a Store backed by a persistent on-disk chromem-go database.
@@ -112,103 +148,193 @@ func NewPersistentStore(path string, embedCfg *config.ProviderConfig) (*Store, e
 
 	embeddingFunc := newEmbeddingFuncWithDimensions(embedCfg)
 
-	collection, err := db.CreateCollection("documents", nil, embeddingFunc)
+	// CreateCollection would replace a collection already on disk with an
+	// empty one, losing the embeddings a rebuild reuses
+	collection, err := db.GetOrCreateCollection("documents", nil, embeddingFunc)
 	if err != nil {
-		// Collection may already exist
-		existing := db.GetCollection("documents", embeddingFunc)
-		if existing == nil {
-			return nil, fmt.Errorf("get or create collection: %w", err)
-		}
-		collection = existing
+		return nil, fmt.Errorf("get or create collection: %w", err)
 	}
 
-	return &Store{
-		db:         db,
-		collection: collection,
-		embedCfg:   embedCfg,
-	}, nil
+	return newStore(db, collection, embedCfg, embeddingFunc), nil
+}
+
+// SetMaxConcurrency caps the concurrent embedding calls of AddChunks and the
+// methods built on it; zero or less means DefaultMaxConcurrency.
+func (s *Store) SetMaxConcurrency(max int) {
+	s.limiter.setMax(max)
 }
 
-// AddChunks adds a batch of document chunks to the vector store.
-// When parallel is true, all documents are embedded concurrently using all CPU
-// cores (ideal for local embedders). When false, documents are added in small
-// sequential batches with retry/backoff (safe for hosted APIs with rate limits).
-func (s *Store) AddChunks(ctx context.Context, chunks []chunker.Chunk, parallel bool) error {
+// EmbedStats reports how the embedding concurrency has adapted so far.
+func (s *Store) EmbedStats() EmbedStats {
+	return s.limiter.snapshot()
+}
+
+// AddChunks embeds chunks and adds them to the vector store. The number of
+// concurrent embedding calls adapts to the embedder: it ramps up while the
+// calls stay fast and backs off when they slow down or get rate-limited, so
+// local embedders and rate-limited hosted APIs both run near their capacity.
+// Rate-limited calls are retried with exponential backoff.
+func (s *Store) AddChunks(ctx context.Context, chunks []chunker.Chunk) error {
 	if len(chunks) == 0 {
 		return nil
 	}
 
-	if parallel {
-		return s.addChunksParallel(ctx, chunks)
-	}
-	return s.addChunksSequential(ctx, chunks)
-}
-
-// addChunksParallel adds all chunks concurrently using runtime.NumCPU().
-func (s *Store) addChunksParallel(ctx context.Context, chunks []chunker.Chunk) error {
-	docs := make([]chromem.Document, len(chunks))
-	for i, ch := range chunks {
-		docs[i] = chromem.Document{
-			ID:      ch.ID,
-			Content: ch.Content,
-			Metadata: map[string]string{
-				"source": ch.Source,
-				"index":  fmt.Sprintf("%d", ch.Index),
-			},
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var wg sync.WaitGroup
+	for _, ch := range chunks {
+		if err := s.limiter.acquire(ctx); err != nil {
+			break
 		}
+		wg.Add(1)
+		go func(doc chromem.Document) {
+			defer wg.Done()
+			err := s.embedDocument(ctx, &doc)
+			if err == nil {
+				err = s.collection.AddDocument(ctx, doc)
+			}
+			if err != nil {
+				cancel(fmt.Errorf("couldn't add document '%s': %w", doc.ID, err))
+			}
+		}(s.chunkDocument(ch))
 	}
-	if err := s.collection.AddDocuments(ctx, docs, runtime.NumCPU()); err != nil {
+	wg.Wait()
+
+	if err := context.Cause(ctx); err != nil {
 		return fmt.Errorf("add documents to collection: %w", err)
 	}
 	return nil
 }
 
-// addChunksSequential adds chunks in small batches with concurrency=1 and
-// retries with exponential backoff on 429 rate-limit errors.
-func (s *Store) addChunksSequential(ctx context.Context, chunks []chunker.Chunk) error {
-	const batchSize = 20
+// embedDocument sets the embedding of doc. It is called holding a limiter
+// slot, which it releases.
+func (s *Store) embedDocument(ctx context.Context, doc *chromem.Document) error {
 	const maxRetries = 5
 
-	for i := 0; i < len(chunks); i += batchSize {
-		end := i + batchSize
-		if end > len(chunks) {
-			end = len(chunks)
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		v, err := s.embedder(doc.Metadata[EmbedderKey])(ctx, doc.Content)
+		rateLimited := isRateLimitError(err)
+		s.limiter.release(start, rateLimited)
+		if err == nil {
+			doc.Embedding = v
+			return nil
 		}
-
-		docs := make([]chromem.Document, end-i)
-		for j, ch := range chunks[i:end] {
-			docs[j] = chromem.Document{
-				ID:      ch.ID,
-				Content: ch.Content,
-				Metadata: map[string]string{
-					"source": ch.Source,
-					"index":  fmt.Sprintf("%d", ch.Index),
-				},
-			}
+		if !rateLimited || attempt == maxRetries-1 {
+			return err
+		}
+		select {
+		case <-time.After(time.Duration(1<<uint(attempt)) * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := s.limiter.acquire(ctx); err != nil {
+			return err
 		}
+	}
+}
 
-		var err error
-		for attempt := 0; attempt < maxRetries; attempt++ {
-			err = s.collection.AddDocuments(ctx, docs, 1)
-			if err == nil {
-				break
-			}
-			if isRateLimitError(err) {
-				backoff := time.Duration(1<<uint(attempt)) * time.Second
-				select {
-				case <-time.After(backoff):
-					continue
-				case <-ctx.Done():
-					return ctx.Err()
-				}
-			}
-			break
+// UpdateChunks adds chunks like AddChunks, except that a chunk already stored
+// with the same ID and content keeps its embedding instead of being embedded
+// again. Use it only when the store was built with the current embedding
+// model. It returns the number of embeddings reused.
+func (s *Store) UpdateChunks(ctx context.Context, chunks []chunker.Chunk) (int, error) {
+	fresh, reused := s.reusable(ctx, chunks)
+	return len(reused), s.addReused(ctx, fresh, reused)
+}
+
+// Pending returns the chunks UpdateChunks would embed, leaving out those
+// whose embedding it would reuse.
+func (s *Store) Pending(ctx context.Context, chunks []chunker.Chunk) []chunker.Chunk {
+	fresh, _ := s.reusable(ctx, chunks)
+	return fresh
+}
+
+// ReplaceSource replaces every chunk stored for source with chunks, which
+// keep the embeddings of unchanged chunks as in UpdateChunks. Empty chunks
+// remove the source. It returns the number of embeddings reused.
+func (s *Store) ReplaceSource(ctx context.Context, source string, chunks []chunker.Chunk) (int, error) {
+	fresh, reused := s.reusable(ctx, chunks)
+	if err := s.collection.Delete(ctx, map[string]string{"source": source}, nil); err != nil {
+		return 0, fmt.Errorf("delete chunks of %q: %w", source, err)
+	}
+	return len(reused), s.addReused(ctx, fresh, reused)
+}
+
+// DeleteChunks removes the chunks with the given IDs; IDs that are not stored
+// are ignored.
+func (s *Store) DeleteChunks(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.collection.Delete(ctx, nil, nil, ids...); err != nil {
+		return fmt.Errorf("delete chunks: %w", err)
+	}
+	return nil
+}
+
+// reusable splits chunks into those that need embedding and the documents of
+// those already stored with the same content and a usable embedding.
+func (s *Store) reusable(ctx context.Context, chunks []chunker.Chunk) (fresh []chunker.Chunk, reused []chromem.Document) {
+	for _, ch := range chunks {
+		doc := s.chunkDocument(ch)
+		old, err := s.collection.GetByID(ctx, ch.ID)
+		if err != nil || old.Content != ch.Content || len(old.Embedding) == 0 ||
+			(s.embedCfg.Dimensions > 0 && len(old.Embedding) != s.embedCfg.Dimensions) ||
+			old.Metadata[EmbedderKey] != doc.Metadata[EmbedderKey] {
+			fresh = append(fresh, ch)
+			continue
 		}
-		if err != nil {
+		doc.Embedding = old.Embedding
+		reused = append(reused, doc)
+	}
+	return fresh, reused
+}
+
+func (s *Store) addReused(ctx context.Context, fresh []chunker.Chunk, reused []chromem.Document) error {
+	if len(reused) > 0 {
+		// Metadata may have changed, so reused chunks are written again
+		if err := s.collection.AddDocuments(ctx, reused, runtime.NumCPU()); err != nil {
 			return fmt.Errorf("add documents to collection: %w", err)
 		}
 	}
-	return nil
+	return s.AddChunks(ctx, fresh)
+}
+
+// chunkDocument converts a chunk into a chromem document. Chunk metadata is
+// stored alongside the reserved "source" and "index" keys, and EmbedderKey
+// when the embedder routes languages.
+func (s *Store) chunkDocument(ch chunker.Chunk) chromem.Document {
+	meta := make(map[string]string, len(ch.Metadata)+3)
+	for k, v := range ch.Metadata {
+		meta[k] = v
+	}
+	meta["source"] = ch.Source
+	meta["index"] = fmt.Sprintf("%d", ch.Index)
+	if s.languages != nil {
+		meta[EmbedderKey] = s.route(ch.Metadata[lang.MetadataKey])
+	}
+	return chromem.Document{
+		ID:       ch.ID,
+		Content:  ch.Content,
+		Metadata: meta,
+	}
+}
+
+// route returns the EmbedderKey of texts in the language code.
+func (s *Store) route(code string) string {
+	if _, ok := s.languages[code]; ok {
+		return code
+	}
+	return DefaultEmbedder
+}
+
+// embedder returns the embedding function of the EmbedderKey route.
+func (s *Store) embedder(route string) chromem.EmbeddingFunc {
+	if embed, ok := s.languages[route]; ok {
+		return embed
+	}
+	return s.embed
 }
 
 // isRateLimitError checks if an error message indicates a 429 rate limit.
@@ -222,6 +348,23 @@ func isRateLimitError(err error) bool {
 
 // Query performs a semantic similarity search against the vector store.
 func (s *Store) Query(ctx context.Context, query string, topK int) ([]SearchResult, error) {
+	return s.QueryFiltered(ctx, query, topK, nil)
+}
+
+// QueryFiltered performs a semantic similarity search restricted to chunks
+// whose metadata matches every key in filter (see MatchesFilter). With a
+// filter, all chunks are ranked before filtering so that topK matching
+// results are returned whenever that many exist.
+func (s *Store) QueryFiltered(ctx context.Context, query string, topK int, filter map[string]string) ([]SearchResult, error) {
+	return s.QueryLanguage(ctx, query, "", topK, filter)
+}
+
+// QueryLanguage is QueryFiltered for a query in the language code, such as
+// "fr"; empty detects it. When the embedder routes languages, the query is
+// embedded with the model of its language and matched against the chunks
+// embedded with it. A query whose language cannot be told is matched
+// against every model's chunks.
+func (s *Store) QueryLanguage(ctx context.Context, query, language string, topK int, filter map[string]string) ([]SearchResult, error) {
 	if query == "" {
 		return nil, errors.New("query cannot be empty")
 	}
@@ -229,24 +372,120 @@ func (s *Store) Query(ctx context.Context, query string, topK int) ([]SearchResu
 		topK = 5
 	}
 
-	results, err := s.collection.Query(ctx, query, topK, nil, nil)
+	n := topK
+	if len(filter) > 0 {
+		n = s.collection.Count()
+	}
+	if count := s.collection.Count(); n > count {
+		n = count
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	results, err := s.query(ctx, query, language, n)
 	if err != nil {
 		return nil, fmt.Errorf("vector query: %w", err)
 	}
 
-	searchResults := make([]SearchResult, len(results))
-	for i, r := range results {
-		searchResults[i] = SearchResult{
+	searchResults := make([]SearchResult, 0, topK)
+	for _, r := range results {
+		if !MatchesFilter(r.Metadata, filter) {
+			continue
+		}
+		searchResults = append(searchResults, SearchResult{
 			ID:         r.ID,
 			Content:    r.Content,
 			Source:     r.Metadata["source"],
 			Similarity: r.Similarity,
 			Metadata:   r.Metadata,
+		})
+		if len(searchResults) == topK {
+			break
 		}
 	}
 	return searchResults, nil
 }
 
+// query returns the n chunks most similar to query, searching the chunks of
+// the embedders routed to as QueryLanguage describes.
+func (s *Store) query(ctx context.Context, query, language string, n int) ([]chromem.Result, error) {
+	if s.languages == nil {
+		return s.collection.Query(ctx, query, n, nil, nil)
+	}
+	if language == "" {
+		language = lang.Detect(query)
+	}
+	routes := []string{s.route(language)}
+	if language == "" {
+		routes = append([]string{DefaultEmbedder}, s.embedCfg.LanguageCodes()...)
+	}
+	var results []chromem.Result
+	for _, route := range routes {
+		v, err := s.embedder(route)(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't create embedding of query: %w", err)
+		}
+		found, err := s.collection.QueryEmbedding(ctx, v, n, map[string]string{EmbedderKey: route}, nil)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, found...)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Similarity > results[j].Similarity })
+	return results[:min(n, len(results))], nil
+}
+
+// Neighbour returns the chunk offset places after r in its source, or
+// before it for a negative offset. Chunk IDs end in their index, as in
+// "guide_md_3", so the neighbour is looked up by the ID with that index
+// replaced; ok is false when there is no such chunk.
+func (s *Store) Neighbour(ctx context.Context, r SearchResult, offset int) (SearchResult, bool) {
+	idx, err := strconv.Atoi(r.Metadata["index"])
+	suffix := "_" + strconv.Itoa(idx)
+	if err != nil || !strings.HasSuffix(r.ID, suffix) || idx+offset < 0 {
+		return SearchResult{}, false
+	}
+	id := strings.TrimSuffix(r.ID, suffix) + "_" + strconv.Itoa(idx+offset)
+	doc, err := s.collection.GetByID(ctx, id)
+	if err != nil || doc.Metadata["source"] != r.Source {
+		return SearchResult{}, false
+	}
+	return SearchResult{
+		ID:       doc.ID,
+		Content:  doc.Content,
+		Source:   doc.Metadata["source"],
+		Metadata: doc.Metadata,
+	}, true
+}
+
+// MatchesFilter reports whether metadata satisfies every key in filter.
+// Values compare case-insensitively, and a comma-separated metadata value
+// such as frontmatter tags matches when any of its items does.
+func MatchesFilter(metadata, filter map[string]string) bool {
+	for k, want := range filter {
+		got, ok := metadata[k]
+		if !ok {
+			return false
+		}
+		want = strings.TrimSpace(want)
+		if strings.EqualFold(strings.TrimSpace(got), want) {
+			continue
+		}
+		matched := false
+		for _, item := range strings.Split(got, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), want) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
 // Count returns the number of documents in the store.
 func (s *Store) Count() int {
 	return s.collection.Count()
@@ -264,6 +503,9 @@ type embedResponse struct {
 	Data []struct {
 		Embedding []float32 `json:"embedding"`
 	} `json:"data"`
+	Usage *struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage"`
 }
 
 // newEmbeddingFuncWithDimensions returns a chromem-go EmbeddingFunc that calls
@@ -327,6 +569,11 @@ func newEmbeddingFuncWithDimensions(cfg *config.ProviderConfig) chromem.Embeddin
 		if len(embedResp.Data) == 0 || len(embedResp.Data[0].Embedding) == 0 {
 			return nil, errors.New("embedding API returned no embeddings")
 		}
+		u := llm.Usage{Phase: llm.PhaseEmbedding, Model: cfg.Model, PromptTokens: llm.EstimateTokens(text), Estimated: true}
+		if embedResp.Usage != nil && embedResp.Usage.PromptTokens > 0 {
+			u.PromptTokens, u.Estimated = embedResp.Usage.PromptTokens, false
+		}
+		llm.ReportUsage(ctx, u)
 
 		v := embedResp.Data[0].Embedding
 
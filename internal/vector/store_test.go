@@ -0,0 +1,215 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/chunker"
+	"github.com/akashicode/kash/internal/config"
+)
+
+func TestMatchesFilter(t *testing.T) {
+	meta := map[string]string{"tags": "billing, Refunds", "audience": "support", "source": "faq.md"}
+
+	tests := []struct {
+		name   string
+		filter map[string]string
+		want   bool
+	}{
+		{name: "no filter", want: true},
+		{name: "exact value", filter: map[string]string{"audience": "support"}, want: true},
+		{name: "case insensitive", filter: map[string]string{"audience": "Support"}, want: true},
+		{name: "list item", filter: map[string]string{"tags": "refunds"}, want: true},
+		{name: "all keys must match", filter: map[string]string{"tags": "billing", "audience": "sales"}, want: false},
+		{name: "missing key", filter: map[string]string{"owner": "ana"}, want: false},
+		{name: "partial item does not match", filter: map[string]string{"tags": "bill"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchesFilter(meta, tt.filter))
+		})
+	}
+}
+
+func TestUpdateChunksReusesEmbeddings(t *testing.T) {
+	var embedded atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		embedded.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"embedding": []float32{0.6, 0.8, 0}}},
+		})
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store, err := NewPersistentStore(filepath.Join(t.TempDir(), "memory.chromem"), &config.ProviderConfig{BaseURL: srv.URL, Dimensions: 3})
+	require.NoError(t, err)
+	chunks := []chunker.Chunk{
+		{ID: "a_0", Source: "a.md", Content: "unchanged"},
+		{ID: "a_1", Source: "a.md", Content: "old text"},
+	}
+	require.NoError(t, store.AddChunks(ctx, chunks))
+	require.Equal(t, int32(2), embedded.Load())
+
+	chunks[1].Content = "new text"
+	chunks = append(chunks, chunker.Chunk{ID: "b_0", Source: "b.md", Content: "added"})
+	chunks[0].Metadata = map[string]string{"title": "A"}
+	reused, err := store.UpdateChunks(ctx, chunks)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reused)
+	assert.Equal(t, int32(4), embedded.Load(), "only the edited and added chunks are embedded")
+	assert.Equal(t, 3, store.Count())
+
+	doc, err := store.collection.GetByID(ctx, "a_0")
+	require.NoError(t, err)
+	assert.Equal(t, "A", doc.Metadata["title"], "reused chunks get their new metadata")
+
+	reused, err = store.ReplaceSource(ctx, "a.md", chunks[:1])
+	require.NoError(t, err)
+	assert.Equal(t, 1, reused)
+	assert.Equal(t, 2, store.Count(), "a_1 is gone with the shorter a.md")
+	_, err = store.ReplaceSource(ctx, "b.md", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Count())
+}
+
+func TestLimiterAdapts(t *testing.T) {
+	l := newLimiter(8)
+	ctx := context.Background()
+	call := func(rateLimited bool) {
+		require.NoError(t, l.acquire(ctx))
+		l.release(time.Now(), rateLimited)
+	}
+
+	for range 1 + 2 + 4 {
+		call(false)
+	}
+	assert.Equal(t, 8, l.snapshot().Concurrency, "the limit doubles after each window")
+	for range 8 {
+		call(false)
+	}
+	assert.Equal(t, 8, l.snapshot().Concurrency, "the limit stays at the maximum")
+
+	early := time.Now().Add(-time.Second)
+	call(true)
+	require.NoError(t, l.acquire(ctx))
+	l.release(early, true)
+	stats := l.snapshot()
+	assert.Equal(t, 4, stats.Concurrency, "one burst of 429s halves the limit once")
+	assert.Equal(t, 8, stats.Peak)
+	assert.Equal(t, 2, stats.RateLimited)
+
+	for range 4 {
+		call(false)
+	}
+	assert.Equal(t, 5, l.snapshot().Concurrency, "after a rate limit the limit grows by one")
+}
+
+func TestAddChunksBacksOffOnRateLimits(t *testing.T) {
+	var inflight, limited atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer inflight.Add(-1)
+		if inflight.Add(1) > 3 {
+			limited.Add(1)
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"embedding": []float32{0.6, 0.8, 0}}},
+		})
+	}))
+	defer srv.Close()
+
+	store, err := NewStore(&config.ProviderConfig{BaseURL: srv.URL, Dimensions: 3})
+	require.NoError(t, err)
+	chunks := make([]chunker.Chunk, 40)
+	for i := range chunks {
+		chunks[i] = chunker.Chunk{ID: fmt.Sprintf("c_%d", i), Source: "c.md", Index: i, Content: fmt.Sprintf("chunk %d", i)}
+	}
+	require.NoError(t, store.AddChunks(context.Background(), chunks))
+	assert.Equal(t, 40, store.Count(), "rate-limited calls are retried")
+
+	stats := store.EmbedStats()
+	assert.Positive(t, limited.Load())
+	assert.Equal(t, int(limited.Load()), stats.RateLimited)
+	assert.Less(t, stats.Concurrency, DefaultMaxConcurrency, "the limit backed off")
+}
+
+func TestLanguageEmbedders(t *testing.T) {
+	// Each model embeds every text along an axis of its own
+	var calls sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embedRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		n, _ := calls.LoadOrStore(req.Model, new(atomic.Int32))
+		n.(*atomic.Int32).Add(1)
+		embedding := []float32{1, 0, 0}
+		if req.Model == "camembert" {
+			embedding = []float32{0, 1, 0}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"embedding": embedding}},
+		})
+	}))
+	defer srv.Close()
+	count := func(model string) int32 {
+		n, ok := calls.Load(model)
+		if !ok {
+			return 0
+		}
+		return n.(*atomic.Int32).Load()
+	}
+
+	ctx := context.Background()
+	store, err := NewStore(&config.ProviderConfig{
+		BaseURL: srv.URL, Model: "minilm", Dimensions: 3,
+		Languages: map[string]config.ProviderConfig{"fr": {Model: "camembert"}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.AddChunks(ctx, []chunker.Chunk{
+		{ID: "en", Source: "en.md", Content: "Refunds take 14 days.", Metadata: map[string]string{"language": "en"}},
+		{ID: "fr", Source: "fr.md", Content: "Les remboursements prennent 14 jours.", Metadata: map[string]string{"language": "fr"}},
+		{ID: "untagged", Source: "misc.md", Content: "14"},
+	}))
+	assert.Equal(t, int32(2), count("minilm"))
+	assert.Equal(t, int32(1), count("camembert"))
+
+	doc, err := store.collection.GetByID(ctx, "fr")
+	require.NoError(t, err)
+	assert.Equal(t, "fr", doc.Metadata[EmbedderKey])
+	doc, err = store.collection.GetByID(ctx, "untagged")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultEmbedder, doc.Metadata[EmbedderKey])
+
+	ids := func(results []SearchResult) []string {
+		var out []string
+		for _, r := range results {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+	results, err := store.Query(ctx, "Comment les remboursements sont-ils effectués ?", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fr"}, ids(results), "a French query searches the chunks of the French model")
+	assert.Equal(t, int32(2), count("camembert"))
+
+	results, err = store.QueryLanguage(ctx, "remboursements", "en", 5, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"en", "untagged"}, ids(results))
+
+	results, err = store.Query(ctx, "Acme Cloud", 5)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"en", "fr", "untagged"}, ids(results), "a query in no telling language searches every model's chunks")
+}
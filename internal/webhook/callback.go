@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for a callback to an address Poster
+// refuses.
+var ErrForbiddenAddress = errors.New("address is not allowed for callbacks")
+
+// CheckAddr returns ErrForbiddenAddress when a callback may not be sent to
+// addr: a link-local or unspecified address, or, unless allowPrivate, a
+// loopback or private one.
+func CheckAddr(addr netip.Addr, allowPrivate bool) error {
+	addr = addr.Unmap()
+	switch {
+	case !addr.IsValid(), addr.IsUnspecified(), addr.IsLinkLocalUnicast(), addr.IsLinkLocalMulticast(),
+		addr.IsInterfaceLocalMulticast(), addr.IsMulticast():
+		return fmt.Errorf("%s: %w", addr, ErrForbiddenAddress)
+	case !allowPrivate && (addr.IsLoopback() || addr.IsPrivate()):
+		return fmt.Errorf("%s: %w", addr, ErrForbiddenAddress)
+	}
+	return nil
+}
+
+// callbackClient returns the client of Poster. Every address is checked as
+// it is dialed, after name resolution, and redirects are returned rather
+// than followed, so neither DNS nor an allowed host can lead elsewhere.
+func callbackClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%s: %w", address, ErrForbiddenAddress)
+			}
+			return CheckAddr(ap.Addr(), allowPrivate)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the callback's host
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
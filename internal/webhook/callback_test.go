@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAddr(t *testing.T) {
+	tests := []struct {
+		addr         string
+		allowPrivate bool
+		allowed      bool
+	}{
+		{"93.184.216.34", false, true},
+		{"2606:2800:220:1::1", false, true},
+		{"127.0.0.1", false, false},
+		{"::1", false, false},
+		{"10.0.0.5", false, false},
+		{"192.168.1.1", false, false},
+		{"::ffff:127.0.0.1", false, false},
+		{"169.254.169.254", false, false},
+		{"0.0.0.0", false, false},
+		{"127.0.0.1", true, true},
+		{"10.0.0.5", true, true},
+		{"169.254.169.254", true, false},
+		{"fe80::1", true, false},
+		{"::", true, false},
+	}
+	for _, tt := range tests {
+		err := CheckAddr(netip.MustParseAddr(tt.addr), tt.allowPrivate)
+		if tt.allowed {
+			assert.NoError(t, err, tt.addr)
+		} else {
+			assert.ErrorIs(t, err, ErrForbiddenAddress, tt.addr)
+		}
+	}
+}
+
+func TestPoster(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { calls.Add(1) }))
+	defer receiver.Close()
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, receiver.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirect.Close()
+
+	ctx := context.Background()
+	err := Post(ctx, receiver.URL, "task.completed", nil, []byte("{}"))
+	assert.ErrorIs(t, err, ErrForbiddenAddress, "a loopback callback")
+	err = Post(ctx, "http://169.254.169.254/latest/meta-data/", "task.completed", nil, []byte("{}"))
+	assert.ErrorIs(t, err, ErrForbiddenAddress, "the metadata endpoint")
+	err = Poster(true)(ctx, "http://169.254.169.254/latest/meta-data/", "task.completed", nil, []byte("{}"))
+	assert.ErrorIs(t, err, ErrForbiddenAddress, "allowPrivate still refuses link-local addresses")
+	assert.Zero(t, calls.Load())
+
+	err = Poster(true)(ctx, redirect.URL, "task.completed", nil, []byte("{}"))
+	assert.ErrorContains(t, err, "returned 307")
+	assert.Zero(t, calls.Load(), "the redirect is not followed")
+
+	assert.NoError(t, Poster(true)(ctx, receiver.URL, "task.completed", nil, []byte("{}")))
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,268 @@
+// Package webhook notifies external services when an agent's knowledge
+// changes: builds, runtime re-ingests, and reloads. Each event is POSTed as
+// JSON, signed with an HMAC of the body when a secret is configured.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Event types.
+const (
+	BuildCompleted  = "build.completed"
+	BuildFailed     = "build.failed"
+	IngestCompleted = "ingest.completed"
+	IngestFailed    = "ingest.failed"
+	ReloadCompleted = "reload.completed"
+	ReloadFailed    = "reload.failed"
+)
+
+var eventTypes = []string{BuildCompleted, BuildFailed, IngestCompleted, IngestFailed, ReloadCompleted, ReloadFailed}
+
+// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request
+// body, keyed with the webhook's secret.
+const SignatureHeader = "X-Kash-Signature"
+
+// Hook is an entry of the webhooks: list in agent.yaml.
+type Hook struct {
+	URL string `yaml:"url"`
+	// SecretEnv names the environment variable holding the signing secret;
+	// without it requests are not signed
+	SecretEnv string `yaml:"secret_env"`
+	// Events limits the hook to these event types, or to every event of a
+	// kind such as "build"; empty sends all events
+	Events []string `yaml:"events"`
+	// Timeout bounds each attempt, e.g. "5s" (default 5s)
+	Timeout string `yaml:"timeout"`
+}
+
+// Event is the JSON body of a webhook request.
+type Event struct {
+	Event string    `json:"event"`
+	Agent string    `json:"agent"`
+	Time  time.Time `json:"timestamp"`
+	// Text is a one-line summary, so Slack-compatible incoming webhooks can
+	// display the event as is
+	Text  string `json:"text"`
+	Error string `json:"error,omitempty"`
+	// Stats holds the event's counts, such as documents, chunks, and triples
+	Stats map[string]interface{} `json:"stats,omitempty"`
+}
+
+// NewEvent returns an event of type typ for agent, summarized by text, with
+// err recorded when non-nil.
+func NewEvent(typ, agent, text string, err error, stats map[string]interface{}) Event {
+	ev := Event{Event: typ, Agent: agent, Time: time.Now().UTC(), Text: text, Stats: stats}
+	if err != nil {
+		ev.Error = err.Error()
+		ev.Text += ": " + err.Error()
+	}
+	return ev
+}
+
+// attempts is how often a delivery is tried before it is given up.
+const attempts = 3
+
+// Notifier delivers events to the configured hooks. A nil Notifier sends
+// nothing.
+type Notifier struct {
+	hooks []target
+	http  *http.Client
+	// backoff is the wait before the first retry, doubled for each one
+	backoff time.Duration
+}
+
+type target struct {
+	hook    Hook
+	secret  string
+	timeout time.Duration
+	// header is added to each request
+	header http.Header
+}
+
+// New validates hooks and returns a Notifier for them, or nil when there
+// are none.
+func New(hooks []Hook) (*Notifier, error) {
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+	n := &Notifier{http: &http.Client{}, backoff: time.Second}
+	for _, h := range hooks {
+		if h.URL == "" {
+			return nil, errors.New("webhook url is required")
+		}
+		for _, e := range h.Events {
+			if !knownEvent(e) {
+				return nil, fmt.Errorf("webhook %s: unknown event %q (use %s, or build, ingest, reload)", h.URL, e, strings.Join(eventTypes, ", "))
+			}
+		}
+		t := target{hook: h, timeout: 5 * time.Second}
+		if h.Timeout != "" {
+			d, err := time.ParseDuration(h.Timeout)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("webhook %s: invalid timeout %q", h.URL, h.Timeout)
+			}
+			t.timeout = d
+		}
+		if h.SecretEnv != "" {
+			t.secret = os.Getenv(h.SecretEnv)
+		}
+		n.hooks = append(n.hooks, t)
+	}
+	return n, nil
+}
+
+func knownEvent(e string) bool {
+	for _, t := range eventTypes {
+		if e == t || strings.HasPrefix(t, e+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// wants reports whether the hook subscribes to typ.
+func (t target) wants(typ string) bool {
+	if len(t.hook.Events) == 0 {
+		return true
+	}
+	for _, e := range t.hook.Events {
+		if e == typ || strings.HasPrefix(typ, e+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Send delivers ev to every hook subscribed to it, retrying failed attempts,
+// and returns the deliveries that failed.
+func (n *Notifier) Send(ctx context.Context, ev Event) error {
+	if n == nil {
+		return nil
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshal webhook event: %w", err)
+	}
+	var errs []error
+	for _, t := range n.hooks {
+		if !t.wants(ev.Event) {
+			continue
+		}
+		if err := n.deliver(ctx, t, ev.Event, body); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", t.hook.URL, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Post delivers body to url as an event of type typ, with header added and
+// the retries of a configured hook, for callbacks that are not listed in
+// agent.yaml such as A2A push notifications. Since a caller chose url, it
+// is sent only to public addresses, as Poster(false) sends it.
+func Post(ctx context.Context, url, typ string, header http.Header, body []byte) error {
+	return Poster(false)(ctx, url, typ, header, body)
+}
+
+// Poster returns a Post that connects only to public addresses, checked
+// when dialing so a host name cannot resolve around it, and follows no
+// redirects. With allowPrivate it also reaches loopback and private
+// addresses; link-local and unspecified ones, such as a cloud metadata
+// endpoint, are refused either way.
+func Poster(allowPrivate bool) func(ctx context.Context, url, typ string, header http.Header, body []byte) error {
+	client := callbackClient(allowPrivate)
+	return func(ctx context.Context, url, typ string, header http.Header, body []byte) error {
+		n := &Notifier{http: client, backoff: time.Second}
+		return n.deliver(ctx, target{hook: Hook{URL: url}, timeout: 5 * time.Second, header: header}, typ, body)
+	}
+}
+
+// deliver POSTs body to t, retrying network errors and 5xx responses.
+func (n *Notifier) deliver(ctx context.Context, t target, typ string, body []byte) error {
+	var err error
+	wait := n.backoff
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			wait *= 2
+		}
+		var retry bool
+		if retry, err = n.post(ctx, t, typ, body); err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+func (n *Notifier) post(ctx context.Context, t target, typ string, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for k, v := range t.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Kash-Event", typ)
+	if t.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(t.secret, body))
+	}
+	resp, err := n.http.Do(req)
+	if err != nil {
+		// A refused address stays refused
+		return !errors.Is(err, ErrForbiddenAddress), err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests,
+			fmt.Errorf("returned %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// Sign returns the SignatureHeader value for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// AgentYAMLHooks reads the webhooks list from an agent.yaml file. A missing
+// file has none.
+func AgentYAMLHooks(path string) ([]Hook, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var parsed struct {
+		Webhooks []Hook `yaml:"webhooks"`
+	}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return parsed.Webhooks, nil
+}
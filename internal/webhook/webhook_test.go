@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSend(t *testing.T) {
+	var got []Event
+	var signatures []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var ev Event
+		require.NoError(t, json.Unmarshal(body, &ev))
+		assert.Equal(t, ev.Event, r.Header.Get("X-Kash-Event"))
+		got = append(got, ev)
+		if sig := r.Header.Get(SignatureHeader); sig != "" {
+			assert.Equal(t, Sign("s3cret", body), sig)
+			signatures = append(signatures, sig)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("HOOK_SECRET", "s3cret")
+
+	n, err := New([]Hook{
+		{URL: srv.URL + "/all", SecretEnv: "HOOK_SECRET"},
+		{URL: srv.URL + "/failures", Events: []string{BuildFailed, "ingest"}},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, n.Send(ctx, NewEvent(BuildCompleted, "guide", "built", nil, map[string]interface{}{"chunks": 3})))
+	require.NoError(t, n.Send(ctx, NewEvent(IngestFailed, "guide", "re-ingest failed", errors.New("disk full"), nil)))
+
+	require.Len(t, got, 3, "the failures hook skips build.completed")
+	assert.Equal(t, BuildCompleted, got[0].Event)
+	assert.Equal(t, float64(3), got[0].Stats["chunks"])
+	assert.Equal(t, "re-ingest failed: disk full", got[1].Text)
+	assert.Equal(t, "disk full", got[2].Error)
+	assert.Len(t, signatures, 2, "only the hook with a secret signs")
+
+	var nilNotifier *Notifier
+	assert.NoError(t, nilNotifier.Send(ctx, NewEvent(ReloadCompleted, "guide", "", nil, nil)))
+}
+
+func TestSendRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    []int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{"succeeds after a server error", []int{500, 200}, 2, false},
+		{"gives up after three attempts", []int{503, 503, 503}, 3, true},
+		{"does not retry a client error", []int{404}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				w.WriteHeader(tt.status[n-1])
+			}))
+			defer srv.Close()
+
+			n, err := New([]Hook{{URL: srv.URL}})
+			require.NoError(t, err)
+			n.backoff = 0
+			err = n.Send(context.Background(), NewEvent(ReloadCompleted, "guide", "reloaded", nil, nil))
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	n, err := New(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, n)
+
+	_, err = New([]Hook{{URL: "http://x", Events: []string{"build.started"}}})
+	assert.ErrorContains(t, err, "unknown event")
+	_, err = New([]Hook{{URL: "http://x", Timeout: "fast"}})
+	assert.ErrorContains(t, err, "invalid timeout")
+	_, err = New([]Hook{{}})
+	assert.ErrorContains(t, err, "url is required")
+}
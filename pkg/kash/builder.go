@@ -0,0 +1,1401 @@
+package kash
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/akashicode/kash/internal/buildreport"
+	"github.com/akashicode/kash/internal/chunker"
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/docstore"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/ingest"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/ocr"
+	"github.com/akashicode/kash/internal/pii"
+	"github.com/akashicode/kash/internal/reader"
+	"github.com/akashicode/kash/internal/snapshot"
+	"github.com/akashicode/kash/internal/source"
+	"github.com/akashicode/kash/internal/vector"
+	"github.com/akashicode/kash/internal/webhook"
+)
+
+// Progress receives a build's progress as it runs. The kash CLI prints it;
+// a nil Progress builds silently. Detail may be called concurrently while
+// documents are read in parallel.
+type Progress interface {
+	// Step starts step n of total
+	Step(n, total int, msg string)
+	// Result reports the outcome of a step, e.g. ("Loaded", "3 document(s)")
+	Result(label, detail string)
+	// Detail reports one line of detail within a step
+	Detail(msg string)
+	// Warn reports a problem that did not stop the build
+	Warn(msg string)
+}
+
+// BuildOptions configures a Builder.
+type BuildOptions struct {
+	// Dir is the agent directory (default: the working directory)
+	Dir    string
+	Config *Config
+	// ReportDir receives build-report.json and build-report.md, even when
+	// the build fails. Relative paths are resolved against Dir; empty writes
+	// no report
+	ReportDir string
+	// Version is recorded in the manifest and build report (default: "dev")
+	Version  string
+	Progress Progress
+	// Readers extract formats kash does not read natively, alongside the
+	// readers registered with RegisterReader and the ingest.plugins in
+	// agent.yaml, which take precedence over them
+	Readers []DocumentReader
+	// SkipUnchanged stops the build after loading and chunking when the
+	// chunks and models match the last build, leaving the databases as they
+	// are
+	SkipUnchanged bool
+	// Confirm is called with the estimate of a build whose cost exceeds
+	// pricing.confirm_above, before the first embedding or LLM call. An
+	// error stops the build; return ErrBuildDeclined when the user said no.
+	// Nil builds without asking
+	Confirm func(BuildEstimate) error
+	// ReviewFile receives the triples the LLM extracts, for a person to
+	// approve before 'kash graph apply' loads them, instead of the graph.
+	// Structured, curated, and sidecar triples still go into the graph.
+	// Relative paths are resolved against Dir; empty adds them directly
+	ReviewFile string
+	// SkipGraph builds a vector-only agent, as extraction.skip_graph in
+	// agent.yaml does: no triple is extracted or loaded, and the graph
+	// store of an earlier build is removed
+	SkipGraph bool
+	// GraphOnly rebuilds the knowledge graph from scratch and leaves the
+	// vector index of the last build untouched: nothing is embedded. It
+	// runs every stage but StageEmbed
+	GraphOnly bool
+	// Stages are the stages to run; empty runs them all. A skipped load or
+	// chunk stage reads the output cached in StagesDir when it last ran, a
+	// skipped embed, extract, or describe stage keeps the vector index,
+	// graph, or tool description of the last build. A selected extract
+	// stage starts from an empty graph
+	Stages []Stage
+}
+
+// BuildReport describes one build: chunk counts per document, skipped files,
+// triple extraction results, LLM token usage, warnings, and stage timings.
+type BuildReport = buildreport.Report
+
+// BuildResult summarizes a finished build.
+type BuildResult struct {
+	Documents int
+	Chunks    int
+	Vectors   int
+	Triples   int64
+	Duration  time.Duration
+	// Unchanged is set when SkipUnchanged found nothing to rebuild; the
+	// counts are then those of the last build
+	Unchanged bool
+	// Snapshot is the version the build was saved as, e.g. "v3"; empty when
+	// snapshots are disabled or could not be taken
+	Snapshot string
+	// Report is the full build report, also saved to ReportDir when set
+	Report *BuildReport
+	// ReviewTriples is how many extracted triples were written to
+	// BuildOptions.ReviewFile
+	ReviewTriples int
+	// NoGraph is set when the build skipped the knowledge graph
+	NoGraph bool
+}
+
+// Builder compiles an agent's documents into its vector index and knowledge
+// graph. A Builder runs one build at a time.
+type Builder struct {
+	opts     BuildOptions
+	progress Progress
+	// report is the report of the running build
+	report *buildreport.Report
+	// notifier sends the build.completed and build.failed webhooks
+	notifier *webhook.Notifier
+}
+
+// NewBuilder checks that opts.Dir is an agent directory and that the
+// providers a build needs are configured. It applies the embedding
+// dimensions from agent.yaml to opts.Config.
+func NewBuilder(opts BuildOptions) (*Builder, error) {
+	if opts.Config == nil {
+		return nil, errors.New("config is required")
+	}
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if opts.Version == "" {
+		opts.Version = "dev"
+	}
+	if opts.GraphOnly {
+		if len(opts.Stages) > 0 {
+			return nil, errors.New("a graph-only build runs fixed stages; give one or the other")
+		}
+		opts.Stages = []Stage{StageLoad, StageChunk, StageExtract, StageDescribe}
+	}
+	b := &Builder{opts: opts, progress: opts.Progress}
+	if b.progress == nil {
+		b.progress = silentProgress{}
+	}
+
+	// Ensure we're in a Kash agent project
+	if _, err := os.Stat(b.path(AgentFile)); os.IsNotExist(err) {
+		return nil, errors.New("agent.yaml not found — run 'kash init <name>' first")
+	}
+	if _, err := os.Stat(b.path(DataDir)); os.IsNotExist(err) {
+		return nil, errors.New("data/ directory not found — run 'kash init <name>' first")
+	}
+
+	// Apply dimensions from agent.yaml (canonical source) before validation
+	agentconfig.ApplyAgentYAMLDimensions(opts.Config, b.path(AgentFile))
+
+	if err := agentconfig.ValidateBuild(opts.Config); err != nil {
+		return nil, err
+	}
+
+	hooks, err := webhook.AgentYAMLHooks(b.path(AgentFile))
+	if err != nil {
+		return nil, err
+	}
+	if b.notifier, err = webhook.New(hooks); err != nil {
+		return nil, fmt.Errorf("agent.yaml webhooks: %w", err)
+	}
+	return b, nil
+}
+
+// path joins elem to the agent directory.
+func (b *Builder) path(elem ...string) string {
+	return filepath.Join(append([]string{b.opts.Dir}, elem...)...)
+}
+
+// warn reports a problem that did not stop the build and records it in the
+// build report.
+func (b *Builder) warn(msg string) {
+	b.progress.Warn(msg)
+	b.report.Warnings = append(b.report.Warnings, msg)
+}
+
+// Build loads and chunks the documents in data/ and the sources listed in
+// agent.yaml, embeds the chunks into data/memory.chromem, extracts knowledge
+// graph triples into data/knowledge.cayley, updates the MCP tool description
+// in agent.yaml, and writes data/manifest.json.
+func (b *Builder) Build(ctx context.Context) (res *BuildResult, err error) {
+	cfg := b.opts.Config
+	start := time.Now()
+	agentYAML := b.path(AgentFile)
+
+	report := buildreport.New(b.opts.Version)
+	b.report = report
+	// Registered first so it runs last, once the report is complete
+	defer func() { b.notify(ctx, res, err) }()
+	defer func() {
+		report.DurationMS = time.Since(start).Milliseconds()
+		if b.opts.ReportDir == "" {
+			return
+		}
+		if err != nil {
+			report.Fail(err)
+		}
+		dir := b.opts.ReportDir
+		if !filepath.IsAbs(dir) {
+			dir = b.path(dir)
+		}
+		if saveErr := report.Save(dir); saveErr != nil {
+			b.progress.Warn(fmt.Sprintf("failed to write build report: %v", saveErr))
+		}
+	}()
+	// writesStores is unset for a build of stages that leave the vector
+	// index and graph as they are, which writes no manifest or snapshot
+	writesStores := true
+	// Registered before the graph store is opened, so it runs once the
+	// store is closed
+	defer func() {
+		if err == nil && !res.Unchanged && writesStores {
+			res.Snapshot = b.snapshot()
+		}
+	}()
+	prices := cfg.Pricing.Prices()
+	ctx = llm.WithUsageFunc(ctx, func(u llm.Usage) {
+		if u.Phase != llm.PhaseEmbedding {
+			report.AddTokens(u.PromptTokens, u.CompletionTokens, u.Estimated)
+		}
+		cost, _ := prices.Cost(u.Model, u.PromptTokens, u.CompletionTokens)
+		report.AddPhase(u.Phase, u.PromptTokens, u.CompletionTokens, cost, u.Estimated)
+	})
+	stageStart := time.Now()
+	stageDone := func(name string) {
+		report.Stage(name, time.Since(stageStart))
+		stageStart = time.Now()
+	}
+
+	// Step 1: Load documents
+	if b.runs(StageLoad) {
+		b.progress.Step(1, 5, "Loading documents from data/...")
+	} else {
+		b.progress.Step(1, 5, "Loading documents from the last load stage...")
+	}
+	ingestCfg := agentconfig.AgentYAMLIngest(agentYAML)
+	extraction := agentconfig.AgentYAMLExtraction(agentYAML)
+	if err := extraction.Validate(); err != nil {
+		return nil, fmt.Errorf("agent.yaml %w", err)
+	}
+	skipGraph := b.opts.SkipGraph || extraction.SkipGraph
+	if skipGraph && b.opts.ReviewFile != "" {
+		return nil, errors.New("a build that skips the knowledge graph has no triples to review")
+	}
+	if skipGraph && len(b.opts.Stages) > 0 && b.runs(StageExtract) {
+		return nil, errors.New("the extract stage cannot run when the knowledge graph is skipped")
+	}
+	writesStores = b.runs(StageEmbed) || b.runs(StageExtract) || skipGraph
+	transcriber, err := llm.NewTranscriber(&cfg.Transcriber)
+	if err != nil {
+		return nil, fmt.Errorf("create transcriber: %w", err)
+	}
+	var audio reader.Transcriber
+	if transcriber != nil {
+		audio = audioTranscriber{ctx: ctx, t: transcriber, progress: b.progress}
+	}
+	imageOCR, ocrEngine, err := b.newOCR(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("create OCR engine: %w", err)
+	}
+	if ocrEngine != "" {
+		b.progress.Detail("OCR engine: " + ocrEngine)
+	}
+	plugins, err := b.readerPlugins(ingestCfg.Plugins)
+	if err != nil {
+		return nil, err
+	}
+	readerOpts := ingest.ReaderOptions(ingestCfg)
+	// The manifest of the last build is not a document
+	readerOpts.SkipFiles = []string{source.URLListFile, filepath.Base(ManifestFile)}
+	readerOpts.Transcriber = audio
+	readerOpts.OCR = imageOCR
+	// Files that could not be read are warned about; files of other formats
+	// are listed once loading is done
+	var unsupported []string
+	readerOpts.OnSkip = func(path, reason string) {
+		report.Skip("data", path, reason)
+		switch reason {
+		case "excluded":
+		case "unsupported format":
+			unsupported = append(unsupported, filepath.Base(path))
+		default:
+			b.warn(fmt.Sprintf("skipped data/%s: %s", filepath.Base(path), reason))
+		}
+	}
+	readerOpts.Plugins = plugins
+	rd := reader.NewReader(readerOpts)
+	ck, err := b.newChunker(agentYAML)
+	if err != nil {
+		return nil, fmt.Errorf("create chunker: %w", err)
+	}
+	scanner, err := pii.New(agentconfig.AgentYAMLPII(agentYAML))
+	if err != nil {
+		return nil, fmt.Errorf("agent.yaml %w", err)
+	}
+
+	// Files in data/ are read in parallel and chunked as they arrive; files
+	// too large to read whole are streamed in the embed step
+	var docs []reader.Document
+	var allChunks []chunker.Chunk
+	var streamed []*streamedFile
+	var remote loadedSources
+	// unchunked are the documents of data/ left to the chunk step
+	var unchunked []reader.Document
+	loadTimes := map[string]time.Duration{}
+	if b.runs(StageLoad) {
+		err = rd.WalkDirectory(b.path(DataDir), func(doc reader.Document, took time.Duration) error {
+			if doc.Streamed {
+				docs = append(docs, doc)
+				streamed = append(streamed, &streamedFile{doc: doc})
+				return nil
+			}
+			docs = append(docs, doc)
+			loadTimes[doc.Name] = took
+			if !b.runs(StageChunk) {
+				return nil
+			}
+			chunks, err := ingest.Chunk(ck, doc)
+			if err != nil {
+				return fmt.Errorf("chunk document %q: %w", doc.Name, err)
+			}
+			allChunks = append(allChunks, scanner.Apply(chunks)...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("load documents: %w", err)
+		}
+
+		remote, err = b.loadSources(ctx, rd)
+		if err != nil {
+			return nil, fmt.Errorf("load sources: %w", err)
+		}
+		if err := b.saveStage(loadCacheFile, loadCache{Data: docs, Remote: remote.docs, Origins: remote.origins, Sources: remote.sources}); err != nil {
+			return nil, err
+		}
+	} else {
+		var cache loadCache
+		if err := b.loadStage(StageLoad, loadCacheFile, &cache); err != nil {
+			return nil, err
+		}
+		docs = cache.Data
+		remote = loadedSources{docs: cache.Remote, origins: cache.Origins, sources: cache.Sources}
+		if remote.origins == nil {
+			remote.origins = map[string]string{}
+		}
+		for _, doc := range docs {
+			if doc.Streamed {
+				streamed = append(streamed, &streamedFile{doc: doc})
+			} else {
+				unchunked = append(unchunked, doc)
+			}
+		}
+	}
+	docs = append(docs, remote.docs...)
+	if len(docs) == 0 {
+		return nil, errors.New("no supported documents found in data/ or sources (add .md, .txt, .html, .pdf, .epub, .csv, .tsv, .json, .jsonl, audio, or image files)")
+	}
+	b.progress.Result("Loaded", fmt.Sprintf("%d document(s)", len(docs)))
+	for _, doc := range docs {
+		if took := loadTimes[doc.Name]; took >= time.Second {
+			b.progress.Detail(fmt.Sprintf("• %s (%s)", doc.Name, took.Round(100*time.Millisecond)))
+			continue
+		}
+		b.progress.Detail("• " + doc.Name)
+	}
+	if len(unsupported) > 0 {
+		b.progress.Result("Skipped", fmt.Sprintf("%d file(s) of unsupported formats", len(unsupported)))
+		for i, name := range unsupported {
+			if i == 10 {
+				b.progress.Detail(fmt.Sprintf("… %d more (see the build report)", len(unsupported)-i))
+				break
+			}
+			b.progress.Detail("• " + name)
+		}
+	}
+	stageDone("load")
+
+	// Step 2: Chunk documents
+	if b.runs(StageChunk) {
+		b.progress.Step(2, 5, "Chunking documents...")
+		for _, doc := range append(unchunked, remote.docs...) {
+			chunks, err := ingest.Chunk(ck, doc)
+			if err != nil {
+				return nil, fmt.Errorf("chunk document %q: %w", doc.Name, err)
+			}
+			allChunks = append(allChunks, scanner.Apply(chunks)...)
+		}
+		b.progress.Result("Created", fmt.Sprintf("%d chunk(s)", len(allChunks)))
+		b.reportPII(scanner)
+		if err := b.saveStage(chunkCacheFile, chunkCache{Chunks: allChunks}); err != nil {
+			return nil, err
+		}
+	} else {
+		b.progress.Step(2, 5, "Loading chunks from the last chunk stage...")
+		var cache chunkCache
+		if err := b.loadStage(StageChunk, chunkCacheFile, &cache); err != nil {
+			return nil, err
+		}
+		allChunks = cache.Chunks
+		b.progress.Result("Reused", fmt.Sprintf("%d chunk(s)", len(allChunks)))
+	}
+	for _, sf := range streamed {
+		if err := sf.hash(); err != nil {
+			return nil, fmt.Errorf("read streamed document: %w", err)
+		}
+		b.progress.Detail(fmt.Sprintf("%s: %.1f MB, streamed into the index in the next step", sf.doc.Name, float64(sf.bytes)/(1<<20)))
+	}
+	report.Chunks = len(allChunks)
+	report.Documents = reportDocuments(docs, allChunks, remote, loadTimes, streamed)
+	stageDone("chunk")
+
+	// The last build's manifest tells whether anything changed and whether
+	// its embeddings can be reused
+	fingerprint := buildFingerprint(cfg, docs, allChunks, streamed, ck.Options(), extraction, skipGraph)
+	prev, _ := manifest.Load(b.path(ManifestFile))
+	if b.opts.SkipUnchanged && prev != nil && prev.Fingerprint == fingerprint && b.built(skipGraph) && b.storedDocuments() != ingestCfg.SkipDocuments {
+		b.progress.Result("Up to date", "no changes since the build of "+prev.BuiltAt.Local().Format(time.DateTime))
+		// The streamed files have the chunks of the last build
+		for _, sf := range streamed {
+			if doc, ok := prev.Document(sf.doc.Name); ok {
+				sf.chunkIDs = doc.ChunkIDs
+			}
+		}
+		report.Chunks += streamedChunks(streamed)
+		report.Documents = reportDocuments(docs, allChunks, remote, loadTimes, streamed)
+		report.Vectors, report.Triples = prev.Vectors, prev.Triples
+		return &BuildResult{
+			Documents: len(docs),
+			Chunks:    report.Chunks,
+			Vectors:   prev.Vectors,
+			Triples:   prev.Triples,
+			Duration:  time.Since(start),
+			Unchanged: true,
+			NoGraph:   skipGraph,
+			Report:    report,
+		}, nil
+	}
+	reuse := prev != nil && prev.Embedder.Equal(manifest.Embedder(cfg.Embedder))
+	if !skipGraph && !b.runs(StageExtract) {
+		// The kept graph may not match these chunks, so the next build must
+		// not find the agent up to date
+		fingerprint = ""
+	}
+
+	vectorPath := b.path(VectorDir)
+	var vs *vector.Store
+	if !b.runs(StageEmbed) {
+		// The index of the last build is read, never written
+		switch {
+		case !b.built(true) && writesStores:
+			return nil, fmt.Errorf("%s not found — run the embed stage before a build without it", VectorDir)
+		case !b.built(true):
+			// Nothing is written; an empty index stands in
+			vs, err = vector.NewStore(&cfg.Embedder)
+		case !reuse && prev != nil:
+			return nil, fmt.Errorf("the vector index was embedded with %s, not the configured %s — run a full build", prev.Embedder.Model, cfg.Embedder.Model)
+		default:
+			vs, err = vector.NewStoreFromPath(vectorPath, &cfg.Embedder)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("open vector store: %w", err)
+		}
+	} else {
+		if err := os.MkdirAll(vectorPath, 0755); err != nil {
+			return nil, fmt.Errorf("create vector store directory: %w", err)
+		}
+		vs, err = vector.NewPersistentStore(vectorPath, &cfg.Embedder)
+		if err != nil {
+			return nil, fmt.Errorf("create vector store: %w", err)
+		}
+	}
+	vs.SetMaxConcurrency(agentconfig.AgentYAMLEmbedConcurrency(agentYAML))
+	if msg := agentconfig.AgentYAMLEmbedParallelWarning(agentYAML); msg != "" {
+		b.warn(msg)
+	}
+
+	// Estimate the provider calls before making any
+	pending := allChunks
+	if reuse || !b.runs(StageEmbed) {
+		pending = vs.Pending(ctx, allChunks)
+	}
+	embed, embedStreamed := pending, streamed
+	if !b.runs(StageEmbed) {
+		embed, embedStreamed = nil, nil
+	}
+	var batches [][]chunker.Chunk
+	if !skipGraph && b.runs(StageExtract) {
+		batches = extractionBatches(extractionChunks(docs, allChunks), extraction)
+	}
+	est := b.estimate(embed, embedStreamed, ck.Options().ChunkSize, batches, agentconfig.AgentYAMLEmbedConcurrency(agentYAML))
+	report.Estimate = est
+	b.progress.Result("Estimate", FormatEstimate(*est))
+	if threshold := cfg.Pricing.Threshold(); b.opts.Confirm != nil && threshold >= 0 && est.CostUSD > threshold {
+		if err := b.opts.Confirm(*est); err != nil {
+			return nil, err
+		}
+	}
+
+	// Step 3: Build vector store
+	if !b.runs(StageEmbed) {
+		b.progress.Step(3, 5, "Keeping the vector index (embed stage skipped)...")
+		changed := len(pending)
+		if prev != nil {
+			// The streamed files have the chunks of the last build
+			for _, sf := range streamed {
+				if doc, ok := prev.Document(sf.doc.Name); ok {
+					sf.chunkIDs = doc.ChunkIDs
+				}
+			}
+			changed += len(staleChunkIDs(prev, allChunks, streamed))
+		}
+		if changed > 0 && writesStores {
+			b.warn(fmt.Sprintf("%d chunk(s) changed since the vector index was built; run a full build to embed them", changed))
+			// The index does not hold these chunks, so the next build must
+			// not find the agent up to date
+			fingerprint = ""
+		}
+		report.Chunks += streamedChunks(streamed)
+		report.Documents = reportDocuments(docs, allChunks, remote, loadTimes, streamed)
+		b.progress.Result("Kept", fmt.Sprintf("%d vectors", vs.Count()))
+	} else {
+		b.progress.Step(3, 5, "Building vector index (this may take a while)...")
+		if reuse {
+			reused, err := vs.UpdateChunks(ctx, allChunks)
+			if err != nil {
+				return nil, fmt.Errorf("add chunks to vector store: %w", err)
+			}
+			if reused > 0 {
+				b.progress.Detail(fmt.Sprintf("Reused %d unchanged embedding(s)", reused))
+			}
+		} else if err := vs.AddChunks(ctx, allChunks); err != nil {
+			return nil, fmt.Errorf("add chunks to vector store: %w", err)
+		}
+		for _, sf := range streamed {
+			b.progress.Detail(fmt.Sprintf("Streaming %s...", sf.doc.Name))
+			reused, err := sf.embed(ctx, rd, ck, scanner, vs, reuse)
+			if err != nil {
+				return nil, fmt.Errorf("add chunks to vector store: %w", err)
+			}
+			b.progress.Detail(fmt.Sprintf("%s: %d chunk(s), %d reused", sf.doc.Name, len(sf.chunkIDs), reused))
+		}
+		if st := vs.EmbedStats(); st.Peak > 1 || st.RateLimited > 0 {
+			b.progress.Detail(fmt.Sprintf("Embedding concurrency reached %d and ended at %d; %d rate-limited call(s) retried",
+				st.Peak, st.Concurrency, st.RateLimited))
+		}
+		report.Chunks += streamedChunks(streamed)
+		report.Documents = reportDocuments(docs, allChunks, remote, loadTimes, streamed)
+		if prev != nil {
+			if stale := staleChunkIDs(prev, allChunks, streamed); len(stale) > 0 {
+				if err := vs.DeleteChunks(ctx, stale); err != nil {
+					return nil, fmt.Errorf("remove stale chunks: %w", err)
+				}
+				b.progress.Detail(fmt.Sprintf("Removed %d stale chunk(s)", len(stale)))
+			}
+		}
+		if len(streamed) > 0 {
+			b.reportPII(scanner)
+		}
+		b.progress.Result("Indexed", fmt.Sprintf("%d vectors", vs.Count()))
+		if err := b.storeDocuments(docs, remote, scanner, ingestCfg.SkipDocuments); err != nil {
+			return nil, err
+		}
+	}
+	report.Vectors = vs.Count()
+	stageDone("embed")
+
+	// Step 4: Extract knowledge graph
+	b.progress.Step(4, 5, "Extracting knowledge graph triples...")
+	buildLLM := cfg.BuildLLMConfig()
+	llmClient, err := llm.NewClient(&buildLLM)
+	if err != nil {
+		return nil, fmt.Errorf("create LLM client: %w", err)
+	}
+	gdb, review, err := b.buildGraph(ctx, skipGraph, llmClient, extraction, docs, allChunks, streamed)
+	if err != nil {
+		return nil, err
+	}
+	defer gdb.Close()
+	reviewTriples := 0
+	if review != nil {
+		reviewTriples = len(review.Triples)
+		path := b.opts.ReviewFile
+		if !filepath.IsAbs(path) {
+			path = b.path(path)
+		}
+		if err := review.Save(path); err != nil {
+			return nil, err
+		}
+		b.progress.Result("Review", fmt.Sprintf("%d extracted triples → %s", reviewTriples, b.opts.ReviewFile))
+	}
+	report.Triples = gdb.Count()
+	stageDone("graph")
+
+	// Step 5: Generate MCP descriptions
+	b.progress.Step(5, 5, "Generating optimized MCP tool descriptions...")
+	if b.runs(StageDescribe) {
+		samples := allChunks[:len(allChunks):len(allChunks)]
+		for _, sf := range streamed {
+			samples = append(samples, sf.samples...)
+		}
+		if err := b.describe(llm.WithPhase(ctx, llm.PhaseDescription), llmClient, samples); err != nil {
+			return nil, err
+		}
+	} else {
+		b.progress.Result("Kept", "the tool description in agent.yaml (describe stage skipped)")
+	}
+	stageDone("describe")
+
+	// Record what went into this build
+	if !writesStores {
+		b.progress.Detail("The vector index and graph were not written; the manifest is left as it is")
+	} else if err := b.writeManifest(report.Documents, allChunks, streamed, remote, vs.Count(), gdb.Count(), fingerprint, skipGraph); err != nil {
+		b.warn(fmt.Sprintf("failed to write build manifest: %v", err))
+	}
+
+	return &BuildResult{
+		Documents:     len(docs),
+		Chunks:        report.Chunks,
+		Vectors:       vs.Count(),
+		Triples:       gdb.Count(),
+		Duration:      time.Since(start),
+		Report:        report,
+		ReviewTriples: reviewTriples,
+		NoGraph:       skipGraph,
+	}, nil
+}
+
+// snapshot saves the finished build as the next snapshot version, unless
+// agent.yaml disables snapshots. A failure is only a warning.
+func (b *Builder) snapshot() string {
+	version, err := saveSnapshot(b.opts.Dir, b.progress)
+	if err != nil {
+		b.warn(fmt.Sprintf("failed to save snapshot: %v", err))
+		return ""
+	}
+	return version
+}
+
+// saveSnapshot saves the stores of the agent in dir as the next snapshot
+// version and reports it to progress. It returns "" when agent.yaml disables
+// snapshots.
+func saveSnapshot(dir string, progress Progress) (string, error) {
+	cfg := agentconfig.AgentYAMLSnapshots(filepath.Join(dir, AgentFile))
+	if cfg.Disabled {
+		return "", nil
+	}
+	keep := cfg.Keep
+	if keep <= 0 {
+		keep = snapshot.DefaultKeep
+	}
+	info, err := snapshot.Create(filepath.Join(dir, snapshot.DefaultDir), snapshot.Paths{
+		Vectors:   filepath.Join(dir, VectorDir),
+		Graph:     filepath.Join(dir, GraphDir),
+		Documents: filepath.Join(dir, DocumentsDir),
+		Manifest:  filepath.Join(dir, ManifestFile),
+	}, keep)
+	if err != nil {
+		return "", err
+	}
+	progress.Result("Snapshot", fmt.Sprintf("%s (%s)", info.Version, filepath.Join(snapshot.DefaultDir, info.Version)))
+	return info.Version, nil
+}
+
+// buildGraph opens the graph store and extracts the triples into it, or
+// into the returned review. A build of selected stages starts from an empty
+// store, and one without the extract stage opens the last build's graph as
+// it is.
+// Skipping the graph removes the store of an earlier build and returns an
+// empty in-memory graph.
+func (b *Builder) buildGraph(ctx context.Context, skip bool, client *llm.Client, extraction agentconfig.ExtractionConfig, docs []reader.Document, chunks []chunker.Chunk, streamed []*streamedFile) (*graph.DB, *graph.Review, error) {
+	graphPath := b.path(GraphDir)
+	if skip {
+		if _, err := os.Stat(graphPath); err == nil {
+			if err := os.RemoveAll(graphPath); err != nil {
+				return nil, nil, fmt.Errorf("remove graph store: %w", err)
+			}
+			b.progress.Detail("Removed the graph store of the last build")
+		}
+		if data, err := os.ReadFile(b.path("Dockerfile")); err == nil && strings.Contains(string(data), "COPY data/knowledge.cayley") {
+			b.warn("the Dockerfile copies data/knowledge.cayley, which a vector-only build does not create — remove that line")
+		}
+		gdb, err := graph.NewDB()
+		if err != nil {
+			return nil, nil, err
+		}
+		b.progress.Result("Knowledge graph", "skipped (vector-only build)")
+		return gdb, nil, nil
+	}
+	if !b.runs(StageExtract) {
+		// A snapshot leaves the store of the last build as it is
+		gdb, err := graph.OpenSnapshot(graphPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open graph store: %w", err)
+		}
+		b.progress.Result("Kept", fmt.Sprintf("%d triples (extract stage skipped)", gdb.Count()))
+		return gdb, nil, nil
+	}
+
+	if b.selective() {
+		if err := os.RemoveAll(graphPath); err != nil {
+			return nil, nil, fmt.Errorf("remove graph store: %w", err)
+		}
+	}
+	if err := os.MkdirAll(graphPath, 0755); err != nil {
+		return nil, nil, fmt.Errorf("create graph store directory: %w", err)
+	}
+	gdb, err := graph.NewDBFromPath(graphPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create graph store: %w", err)
+	}
+	if len(streamed) > 0 {
+		// A file too large to read whole would take more LLM calls than
+		// extraction is worth
+		b.progress.Detail(fmt.Sprintf("Streamed file(s) are not sent to triple extraction: %d", len(streamed)))
+	}
+	review := b.extractGraph(llm.WithPhase(ctx, llm.PhaseExtraction), gdb, client, extraction, docs, chunks)
+	b.progress.Result("Knowledge graph", fmt.Sprintf("%d triples", gdb.Count()))
+	return gdb, review, nil
+}
+
+// storedDocuments reports whether the full text of the documents was kept.
+func (b *Builder) storedDocuments() bool {
+	_, err := os.Stat(b.path(DocumentsDir))
+	return err == nil
+}
+
+// storeDocuments keeps the full text of docs beside the vector index, with
+// the personal data redacted, so the server can return whole documents
+// without data/. Streamed files are too large to keep whole. skip removes
+// the documents of an earlier build instead.
+func (b *Builder) storeDocuments(docs []reader.Document, remote loadedSources, scanner *pii.Scanner, skip bool) error {
+	dir := b.path(DocumentsDir)
+	if skip {
+		if _, err := os.Stat(dir); err == nil {
+			if err := os.RemoveAll(dir); err != nil {
+				return fmt.Errorf("remove document store: %w", err)
+			}
+			b.progress.Detail("Removed the document store of the last build")
+		}
+		if data, err := os.ReadFile(b.path("Dockerfile")); err == nil && strings.Contains(string(data), "COPY data/documents.store") {
+			b.warn("the Dockerfile copies data/documents.store, which ingest.skip_documents leaves out — remove that line")
+		}
+		return nil
+	}
+	w, err := docstore.Create(dir)
+	if err != nil {
+		return err
+	}
+	stored := 0
+	for _, doc := range docs {
+		if doc.Streamed {
+			continue
+		}
+		origin := remote.origins[doc.Name]
+		if origin == "" {
+			origin = "data"
+		}
+		if err := w.Add(docstore.Document{Name: doc.Name, Origin: origin, Metadata: doc.Metadata}, scanner.Redact(documentText(doc))); err != nil {
+			w.Abort()
+			return err
+		}
+		stored++
+	}
+	if err := w.Commit(); err != nil {
+		w.Abort()
+		return err
+	}
+	b.progress.Detail(fmt.Sprintf("Stored the full text of %d document(s) in %s", stored, DocumentsDir))
+	return nil
+}
+
+// documentText returns the full text of doc: its content, or its sections
+// when a reader set only those.
+func documentText(doc reader.Document) string {
+	if doc.Content != "" || len(doc.Sections) == 0 {
+		return doc.Content
+	}
+	parts := make([]string, len(doc.Sections))
+	for i, sec := range doc.Sections {
+		parts[i] = sec.Content
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// built reports whether the vector index and, unless the graph is skipped,
+// the knowledge graph exist.
+func (b *Builder) built(skipGraph bool) bool {
+	dirs := []string{VectorDir, GraphDir}
+	if skipGraph {
+		dirs = dirs[:1]
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(b.path(dir)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// buildFingerprint hashes what a build's databases are made of: the chunks,
+// the triples taken directly from documents, and the models. Two builds with
+// the same fingerprint produce the same index.
+func buildFingerprint(cfg *Config, docs []reader.Document, chunks []chunker.Chunk, streamed []*streamedFile, chunking chunker.Options, extraction agentconfig.ExtractionConfig, skipGraph bool) string {
+	h := sha256.New()
+	field := func(s string) {
+		// Length-prefixed so adjacent fields cannot run into each other
+		fmt.Fprintf(h, "%d:%s", len(s), s)
+	}
+	metadata := func(m map[string]string) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			field(k)
+			field(m[k])
+		}
+	}
+	field(cfg.BuildLLMConfig().Model)
+	field(cfg.Embedder.Model)
+	field(fmt.Sprint(cfg.Embedder.Dimensions))
+	metadata(manifest.Embedder(cfg.Embedder).Languages)
+	// A custom extraction prompt shapes the graph as the models do
+	if prompt := llm.ExtractionPrompt(extraction); prompt != llm.ExtractionPrompt(agentconfig.ExtractionConfig{}) {
+		field(prompt)
+	}
+	if skipGraph {
+		field("skip graph")
+	}
+	for _, ch := range chunks {
+		field(ch.ID)
+		field(ch.Source)
+		field(ch.Content)
+		metadata(ch.Metadata)
+	}
+	// Streamed files are not chunked yet: their content and the chunk sizes
+	// stand in for their chunks
+	if len(streamed) > 0 {
+		sizes := fmt.Sprint(chunking.ChunkSize, chunking.Overlap)
+		if chunking.Sentences {
+			sizes += " sentences"
+		}
+		field(sizes)
+	}
+	for _, sf := range streamed {
+		field(sf.doc.Name)
+		field(sf.digest)
+		metadata(sf.doc.Metadata)
+	}
+	for _, doc := range docs {
+		for _, t := range doc.Triples {
+			field(t.Subject)
+			field(t.Predicate)
+			field(t.Object)
+		}
+		for _, t := range sidecarTriples(doc) {
+			field(t.Subject)
+			field(t.Predicate)
+			field(t.Object)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// notify sends the build webhooks. A failed delivery is only a warning.
+func (b *Builder) notify(ctx context.Context, res *BuildResult, buildErr error) {
+	// An up-to-date build changed nothing worth announcing
+	if b.notifier == nil || (res != nil && res.Unchanged) {
+		return
+	}
+	r := b.report
+	agent := agentconfig.AgentYAMLName(b.path(AgentFile))
+	typ, text := webhook.BuildCompleted, fmt.Sprintf("kash: build of %s completed: %d document(s), %d chunk(s), %d triple(s) in %s",
+		agent, len(r.Documents), r.Chunks, r.Triples, time.Duration(r.DurationMS)*time.Millisecond)
+	if buildErr != nil {
+		typ, text = webhook.BuildFailed, "kash: build of "+agent+" failed"
+	}
+	ev := webhook.NewEvent(typ, agent, text, buildErr, map[string]interface{}{
+		"documents":   len(r.Documents),
+		"chunks":      r.Chunks,
+		"vectors":     r.Vectors,
+		"triples":     r.Triples,
+		"skipped":     len(r.Skipped),
+		"warnings":    len(r.Warnings),
+		"duration_ms": r.DurationMS,
+		"version":     r.KashVersion,
+	})
+	// Report a canceled build too
+	if err := b.notifier.Send(context.WithoutCancel(ctx), ev); err != nil {
+		b.progress.Warn(fmt.Sprintf("failed to send webhook: %v", err))
+	}
+}
+
+// newChunker sizes chunks from agent.yaml: chunking.size when set and within
+// the embedder's max_tokens, else the size derived from max_tokens, else the
+// default.
+func (b *Builder) newChunker(agentYAML string) (*chunker.Chunker, error) {
+	maxTokens := agentconfig.AgentYAMLMaxTokens(agentYAML)
+	if maxTokens > 0 {
+		b.progress.Detail(fmt.Sprintf("Embed max tokens: %d", maxTokens))
+	}
+	chunking := agentconfig.AgentYAMLChunking(agentYAML)
+	if err := chunking.Validate(); err != nil {
+		return nil, fmt.Errorf("agent.yaml %w", err)
+	}
+	chunkOpts, capped := ingest.ChunkerOptions(maxTokens, chunking)
+	if capped {
+		b.warn(fmt.Sprintf("chunking.size %d exceeds the embedder's max_tokens; using %d", chunking.Size, chunkOpts.ChunkSize))
+	}
+	if maxTokens > 0 || chunking.Size > 0 {
+		b.progress.Detail(fmt.Sprintf("Chunk size: %d characters", chunkOpts.ChunkSize))
+	}
+	if chunkOpts.Sentences {
+		b.progress.Detail("Chunking: one sentence per chunk")
+	}
+	return chunker.NewChunker(chunkOpts)
+}
+
+// reportPII records what scanner found so far in the build report and
+// shows it; it does nothing without a scanner.
+func (b *Builder) reportPII(scanner *pii.Scanner) {
+	if scanner == nil {
+		return
+	}
+	sum := scanner.Summary()
+	b.report.PII = &sum
+	if sum.Chunks == 0 {
+		b.progress.Result("Personal data", "none found")
+		return
+	}
+	kinds := make([]string, 0, len(sum.Found))
+	for kind := range sum.Found {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	found := make([]string, len(kinds))
+	for i, kind := range kinds {
+		found[i] = fmt.Sprintf("%d %s", sum.Found[kind], kind)
+	}
+	done := map[string]string{pii.Redact: "redacted", pii.Tag: "tagged", pii.Block: "left out"}[sum.Action]
+	b.progress.Result("Personal data", fmt.Sprintf("%s in %d chunk(s), %s", strings.Join(found, ", "), sum.Chunks, done))
+}
+
+// extractGraph adds structured triples read from documents (e.g. CSV rows),
+// sidecar metadata, and triples extracted by the LLM from the remaining
+// chunks to gdb, prompted with the extraction block of agent.yaml. Failures
+// are recorded as warnings and do not stop the build. With
+// BuildOptions.ReviewFile, the LLM's triples go into the returned review
+// instead of gdb.
+func (b *Builder) extractGraph(ctx context.Context, gdb *graph.DB, llmClient *llm.Client, extraction agentconfig.ExtractionConfig, docs []reader.Document, allChunks []chunker.Chunk) *graph.Review {
+	report := b.report
+	var review *graph.Review
+	if b.opts.ReviewFile != "" {
+		review = &graph.Review{BuiltAt: time.Now().UTC()}
+	}
+	totalTriples := int64(0)
+
+	// Load structured triples read directly from documents (e.g. CSV rows)
+	// and curated triple files. Chunks from those documents are excluded
+	// from LLM extraction.
+	for _, doc := range docs {
+		if len(doc.Triples) == 0 {
+			continue
+		}
+		kind := "structured"
+		if reader.IsTripleFile(doc.Name) {
+			kind = "curated"
+		}
+		triples := make([]llm.Triple, len(doc.Triples))
+		for i, t := range doc.Triples {
+			triples[i] = llm.Triple{Subject: t.Subject, Predicate: t.Predicate, Object: t.Object}
+		}
+		if err := gdb.AddTriples(ctx, triples); err != nil {
+			b.warn(fmt.Sprintf("failed to add structured triples from %s: %v", doc.Name, err))
+			continue
+		}
+		totalTriples += int64(len(triples))
+		b.progress.Detail(fmt.Sprintf("%s: +%d %s triples", doc.Name, len(triples), kind))
+	}
+
+	// Record sidecar metadata as facts about each document so the graph can
+	// answer questions like who owns a file
+	for _, doc := range docs {
+		triples := sidecarTriples(doc)
+		if len(triples) == 0 {
+			continue
+		}
+		if err := gdb.AddTriples(ctx, triples); err != nil {
+			b.warn(fmt.Sprintf("failed to add sidecar metadata from %s: %v", doc.Name, err))
+			continue
+		}
+		totalTriples += int64(len(triples))
+	}
+
+	// Process chunks in batches to extract triples
+	end := 0
+	for _, batch := range extractionBatches(extractionChunks(docs, allChunks), extraction) {
+		i := end
+		end += len(batch)
+		report.Extraction.Batches++
+
+		// Combine batch into single text for efficiency
+		var combined strings.Builder
+		for _, ch := range batch {
+			combined.WriteString(ch.Content)
+			combined.WriteString("\n\n")
+		}
+
+		var triples []llm.Triple
+		var extractErr error
+		maxRetries := 2
+		for attempt := 0; attempt <= maxRetries; attempt++ {
+			triples, extractErr = llmClient.ExtractTriples(ctx, combined.String(), extraction)
+			if extractErr == nil {
+				break
+			}
+			if attempt < maxRetries {
+				report.Extraction.Retries++
+				b.warn(fmt.Sprintf("triple extraction failed for batch %d-%d (attempt %d/%d, retrying): %v", i, end, attempt+1, maxRetries+1, extractErr))
+			}
+		}
+		if extractErr != nil {
+			b.warn(fmt.Sprintf("triple extraction failed for batch %d-%d after %d attempts: %v", i, end, maxRetries+1, extractErr))
+			report.Extraction.Failed++
+			continue
+		}
+
+		if review != nil {
+			var sources []string
+			for _, ch := range batch {
+				if !slices.Contains(sources, ch.Source) {
+					sources = append(sources, ch.Source)
+				}
+			}
+			for _, t := range triples {
+				review.Add(t, sources)
+			}
+		} else if err := gdb.AddTriples(ctx, triples); err != nil {
+			b.warn(fmt.Sprintf("failed to add triples for batch %d-%d: %v", i, end, err))
+			report.Extraction.Failed++
+			continue
+		}
+		report.Extraction.Succeeded++
+		report.Extraction.Triples += int64(len(triples))
+
+		totalTriples += int64(len(triples))
+		if review != nil {
+			b.progress.Detail(fmt.Sprintf("Chunks %d-%d: +%d triples for review (total: %d)", i+1, end, len(triples), totalTriples))
+			continue
+		}
+		b.progress.Detail(fmt.Sprintf("Chunks %d-%d: +%d triples (total: %d)", i+1, end, len(triples), totalTriples))
+	}
+	return review
+}
+
+// describe asks the LLM for an MCP tool description based on the first
+// chunks and writes it to agent.yaml.
+func (b *Builder) describe(ctx context.Context, llmClient *llm.Client, allChunks []chunker.Chunk) error {
+	agentYAML := b.path(AgentFile)
+	var sampleContent strings.Builder
+	limit := 3
+	if len(allChunks) < limit {
+		limit = len(allChunks)
+	}
+	for i := 0; i < limit; i++ {
+		sampleContent.WriteString(allChunks[i].Content)
+		sampleContent.WriteString("\n\n")
+	}
+
+	agentYAMLData, err := os.ReadFile(agentYAML)
+	if err != nil {
+		return fmt.Errorf("read agent.yaml: %w", err)
+	}
+
+	var agentConfig map[string]interface{}
+	if err := yaml.Unmarshal(agentYAMLData, &agentConfig); err != nil {
+		return fmt.Errorf("parse agent.yaml: %w", err)
+	}
+
+	agentName := "agent"
+	if a, ok := agentConfig["agent"].(map[string]interface{}); ok {
+		if name, ok := a["name"].(string); ok {
+			agentName = strings.ToLower(strings.ReplaceAll(name, " ", "_"))
+		}
+	}
+
+	mcpDesc, err := llmClient.GenerateMCPDescription(ctx, agentName, sampleContent.String())
+	if err != nil {
+		b.warn(fmt.Sprintf("MCP description generation failed: %v", err))
+		mcpDesc = fmt.Sprintf("Search the %s expert knowledge base for relevant information.", agentName)
+	}
+
+	// Update agent.yaml with new MCP description
+	if err := updateAgentYAMLMCPDescription(agentYAML, agentName, mcpDesc); err != nil {
+		b.warn(fmt.Sprintf("failed to update agent.yaml: %v", err))
+	} else {
+		b.progress.Result("Updated", "agent.yaml with MCP tool description")
+	}
+	return nil
+}
+
+// reportDocuments lists each document with its origin and chunk count.
+func reportDocuments(docs []reader.Document, chunks []chunker.Chunk, remote loadedSources, loadTimes map[string]time.Duration, streamed []*streamedFile) []buildreport.Document {
+	chunkCounts := map[string]int{}
+	for _, ch := range chunks {
+		chunkCounts[ch.Source]++
+	}
+	streamedBytes := map[string]int{}
+	for _, sf := range streamed {
+		chunkCounts[sf.doc.Name] = len(sf.chunkIDs)
+		streamedBytes[sf.doc.Name] = int(sf.bytes)
+	}
+	out := make([]buildreport.Document, 0, len(docs))
+	for _, doc := range docs {
+		origin := remote.origins[doc.Name]
+		if origin == "" {
+			origin = "data"
+		}
+		out = append(out, buildreport.Document{
+			Name:   doc.Name,
+			Origin: origin,
+			Chunks: chunkCounts[doc.Name],
+			Bytes:  len(doc.Content) + streamedBytes[doc.Name],
+			LoadMS: loadTimes[doc.Name].Milliseconds(),
+		})
+	}
+	return out
+}
+
+// writeManifest saves data/manifest.json describing the documents, sources,
+// and models used for this build.
+func (b *Builder) writeManifest(docs []buildreport.Document, chunks []chunker.Chunk, streamed []*streamedFile, remote loadedSources, vectors int, triples int64, fingerprint string, noGraph bool) error {
+	cfg := b.opts.Config
+	m := &manifest.Manifest{
+		BuiltAt:     time.Now().UTC(),
+		KashVersion: b.opts.Version,
+		LLMModel:    cfg.BuildLLMConfig().Model,
+		Embedder:    manifest.Embedder(cfg.Embedder),
+		Documents:   make([]manifest.Document, 0, len(docs)),
+		Sources:     remote.sources,
+		Chunks:      len(chunks) + streamedChunks(streamed),
+		Vectors:     vectors,
+		Triples:     triples,
+		NoGraph:     noGraph,
+		Fingerprint: fingerprint,
+	}
+	ids := map[string][]string{}
+	for _, ch := range chunks {
+		ids[ch.Source] = append(ids[ch.Source], ch.ID)
+	}
+	for _, sf := range streamed {
+		ids[sf.doc.Name] = sf.chunkIDs
+	}
+	for _, doc := range docs {
+		m.Documents = append(m.Documents, manifest.Document{
+			Name:     doc.Name,
+			Origin:   doc.Origin,
+			Chunks:   doc.Chunks,
+			Bytes:    doc.Bytes,
+			ChunkIDs: ids[doc.Name],
+		})
+	}
+	return m.Save(b.path(ManifestFile))
+}
+
+// staleChunkIDs returns the chunks of the previous build that this build no
+// longer produces: those of deleted documents and of documents that shrank.
+func staleChunkIDs(prev *manifest.Manifest, chunks []chunker.Chunk, streamed []*streamedFile) []string {
+	current := make(map[string]bool, len(chunks))
+	for _, ch := range chunks {
+		current[ch.ID] = true
+	}
+	for _, sf := range streamed {
+		for _, id := range sf.chunkIDs {
+			current[id] = true
+		}
+	}
+	var stale []string
+	for _, id := range prev.ChunkIDs() {
+		if !current[id] {
+			stale = append(stale, id)
+		}
+	}
+	return stale
+}
+
+// audioTranscriber adapts llm.Transcriber to the reader.Transcriber interface.
+type audioTranscriber struct {
+	ctx      context.Context
+	t        *llm.Transcriber
+	progress Progress
+}
+
+func (a audioTranscriber) Transcribe(path string) ([]reader.TranscriptSegment, error) {
+	a.progress.Detail("Transcribing " + filepath.Base(path) + "...")
+	segs, err := a.t.Transcribe(a.ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]reader.TranscriptSegment, len(segs))
+	for i, s := range segs {
+		out[i] = reader.TranscriptSegment{Start: s.Start, End: s.End, Speaker: s.Speaker, Text: s.Text}
+	}
+	return out, nil
+}
+
+// ocrFunc adapts an OCR engine to the reader.OCR interface.
+type ocrFunc struct {
+	ctx       context.Context
+	recognize func(ctx context.Context, path string) (string, error)
+	progress  Progress
+}
+
+func (o ocrFunc) Recognize(path string) (string, error) {
+	o.progress.Detail("Running OCR on " + filepath.Base(path) + "...")
+	return o.recognize(o.ctx, path)
+}
+
+// newOCR selects the OCR engine from config and returns it with its name.
+// It returns a nil engine when OCR is disabled or unavailable.
+func (b *Builder) newOCR(ctx context.Context) (reader.OCR, string, error) {
+	cfg := b.opts.Config
+	switch strings.ToLower(cfg.OCR.Engine) {
+	case "none":
+		return nil, "", nil
+	case "", "tesseract":
+		if cfg.OCR.Engine == "" && !ocr.Available() {
+			return nil, "", nil
+		}
+		t, err := ocr.NewTesseract(cfg.OCR.Language)
+		if err != nil {
+			return nil, "", err
+		}
+		return ocrFunc{ctx: ctx, recognize: t.Recognize, progress: b.progress}, "tesseract", nil
+	case "vision":
+		client, err := llm.NewClient(&cfg.LLM)
+		if err != nil {
+			return nil, "", err
+		}
+		model := cfg.OCR.Model
+		if model == "" {
+			model = cfg.LLM.Model
+		}
+		recognize := func(ctx context.Context, path string) (string, error) {
+			return client.ExtractImageText(ctx, path, model)
+		}
+		return ocrFunc{ctx: ctx, recognize: recognize, progress: b.progress}, "vision (" + model + ")", nil
+	}
+	return nil, "", fmt.Errorf("unknown OCR engine %q (use tesseract, vision, or none)", cfg.OCR.Engine)
+}
+
+// readerPlugins returns opts.Readers followed by an ExecReader for each
+// ingest.plugins entry, so the agent.yaml plugins win on shared extensions.
+func (b *Builder) readerPlugins(cfgs []agentconfig.ReaderPluginConfig) ([]reader.FormatReader, error) {
+	plugins := append([]reader.FormatReader(nil), b.opts.Readers...)
+	dir, err := filepath.Abs(b.opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve agent directory: %w", err)
+	}
+	for i, pc := range cfgs {
+		if len(pc.Extensions) == 0 || len(pc.Command) == 0 {
+			return nil, fmt.Errorf("ingest.plugins[%d]: extensions and command are required", i)
+		}
+		p := &reader.ExecReader{Exts: pc.Extensions, Command: append([]string(nil), pc.Command...), Dir: dir}
+		// Run ./scripts/read-tickets.sh from the agent directory, and bare
+		// names like pandoc from PATH.
+		if prog := p.Command[0]; !filepath.IsAbs(prog) && strings.ContainsAny(prog, `/\`) {
+			p.Command[0] = filepath.Join(dir, prog)
+		}
+		switch strings.ToLower(pc.Output) {
+		case "", "text":
+		case "json":
+			p.JSON = true
+		default:
+			return nil, fmt.Errorf("ingest.plugins[%d]: unknown output %q (use text or json)", i, pc.Output)
+		}
+		if pc.Timeout != "" {
+			d, err := time.ParseDuration(pc.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("ingest.plugins[%d]: invalid timeout %q", i, pc.Timeout)
+			}
+			p.Timeout = d
+		}
+		plugins = append(plugins, p)
+		b.progress.Detail(fmt.Sprintf("Reader plugin: %s for %s", filepath.Base(pc.Command[0]), strings.Join(pc.Extensions, ", ")))
+	}
+	return plugins, nil
+}
+
+// sidecarTriples turns a document's sidecar metadata into triples with the
+// document name as subject, e.g. (handbook.pdf, acl group, hr).
+func sidecarTriples(doc reader.Document) []llm.Triple {
+	keys := make([]string, 0, len(doc.Sidecar))
+	for k := range doc.Sidecar {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	triples := make([]llm.Triple, 0, len(keys))
+	for _, k := range keys {
+		triples = append(triples, llm.Triple{
+			Subject:   doc.Name,
+			Predicate: strings.ReplaceAll(k, "_", " "),
+			Object:    doc.Sidecar[k],
+		})
+	}
+	return triples
+}
+
+func updateAgentYAMLMCPDescription(path, agentName, description string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read agent.yaml: %w", err)
+	}
+
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("parse agent.yaml: %w", err)
+	}
+
+	// Update or create mcp.tools section
+	mcpSection, _ := config["mcp"].(map[string]interface{})
+	if mcpSection == nil {
+		mcpSection = map[string]interface{}{}
+	}
+
+	// Keep a tool name chosen in agent.yaml, e.g. by an init template
+	toolName := "search_" + agentName + "_knowledge"
+	if existing, ok := mcpSection["tools"].([]interface{}); ok && len(existing) > 0 {
+		if t, ok := existing[0].(map[string]interface{}); ok {
+			if name, ok := t["name"].(string); ok && name != "" {
+				toolName = name
+			}
+		}
+	}
+	tools := []map[string]interface{}{
+		{
+			"name":        toolName,
+			"description": description,
+		},
+	}
+	mcpSection["tools"] = tools
+	config["mcp"] = mcpSection
+
+	// Marshal back to YAML
+	output, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("marshal agent.yaml: %w", err)
+	}
+
+	return os.WriteFile(path, output, 0644)
+}
+
+// silentProgress discards progress when BuildOptions.Progress is nil.
+type silentProgress struct{}
+
+func (silentProgress) Step(int, int, string) {}
+func (silentProgress) Result(string, string) {}
+func (silentProgress) Detail(string)         {}
+func (silentProgress) Warn(string)           {}
+
+// TextProgress prints build progress to w as plain lines. It is safe for
+// concurrent use.
+func TextProgress(w io.Writer) Progress {
+	return &textProgress{w: w}
+}
+
+type textProgress struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (p *textProgress) Step(n, total int, msg string) { p.printf("[%d/%d] %s\n", n, total, msg) }
+func (p *textProgress) Result(label, detail string)   { p.printf("  %s: %s\n", label, detail) }
+func (p *textProgress) Detail(msg string)             { p.printf("    %s\n", msg) }
+func (p *textProgress) Warn(msg string)               { p.printf("  warning: %s\n", msg) }
+
+func (p *textProgress) printf(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, format, args...)
+}
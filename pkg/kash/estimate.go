@@ -0,0 +1,209 @@
+package kash
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/akashicode/kash/internal/buildreport"
+	"github.com/akashicode/kash/internal/chunker"
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/reader"
+)
+
+// BuildEstimate predicts the provider calls, tokens, cost, and duration of a
+// build before it makes any of them.
+type BuildEstimate = buildreport.Estimate
+
+// ErrBuildDeclined is returned by Build when BuildOptions.Confirm turned the
+// build down.
+var ErrBuildDeclined = errors.New("build cancelled")
+
+// extractBatchSize is how many chunks one triple extraction call reads
+// unless extraction.batch_size in agent.yaml sets it.
+const extractBatchSize = 10
+
+// Assumptions of the estimate where an earlier build report cannot tell.
+const (
+	// promptOverhead is the system prompt and instructions of an LLM call
+	promptOverhead = 300
+	// completionPerCall is the answer of an LLM call
+	completionPerCall = 400
+	// embedCallTime is the round trip of one embedding
+	embedCallTime = 150 * time.Millisecond
+	// embedConcurrency is how many embedding calls the adaptive limit of
+	// the vector store keeps in flight on average
+	embedConcurrency = 4
+	// llmCallTime is the latency of an LLM call before it writes its answer
+	llmCallTime = 2 * time.Second
+	// llmTokensPerSecond is how fast the LLM writes its answer
+	llmTokensPerSecond = 50
+)
+
+// extractionChunks returns the chunks sent to triple extraction: those of
+// documents without structured triples of their own.
+func extractionChunks(docs []reader.Document, allChunks []chunker.Chunk) []chunker.Chunk {
+	directSources := map[string]bool{}
+	for _, doc := range docs {
+		if len(doc.Triples) > 0 {
+			directSources[doc.Name] = true
+		}
+	}
+	if len(directSources) == 0 {
+		return allChunks
+	}
+	chunks := make([]chunker.Chunk, 0, len(allChunks))
+	for _, ch := range allChunks {
+		if !directSources[ch.Source] {
+			chunks = append(chunks, ch)
+		}
+	}
+	return chunks
+}
+
+// extractionBatches splits chunks into the batches of one triple
+// extraction call each: up to extraction.batch_size chunks, ended early
+// before their text exceeds extraction.max_batch_tokens. A chunk above the
+// limit on its own gets a batch of its own.
+func extractionBatches(chunks []chunker.Chunk, extraction agentconfig.ExtractionConfig) [][]chunker.Chunk {
+	size := extraction.BatchSize
+	if size <= 0 {
+		size = extractBatchSize
+	}
+	var batches [][]chunker.Chunk
+	start, tokens := 0, 0
+	for i, ch := range chunks {
+		n := llm.EstimateTokens(ch.Content)
+		if i > start && (i-start == size || extraction.MaxBatchTokens > 0 && tokens+n > extraction.MaxBatchTokens) {
+			batches = append(batches, chunks[start:i])
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	if start < len(chunks) {
+		batches = append(batches, chunks[start:])
+	}
+	return batches
+}
+
+// estimate predicts the embed, graph, and describe steps the build runs:
+// embed are the chunks that need an embedding, and streamed files, whose
+// chunks are not known yet, are counted by size. The last build report, when
+// there is one, supplies the completion length and the speed of the
+// providers.
+// maxConcurrency caps the concurrent embedding calls, zero for the default.
+func (b *Builder) estimate(embed []chunker.Chunk, streamed []*streamedFile, chunkSize int, extract [][]chunker.Chunk, maxConcurrency int) *BuildEstimate {
+	cfg := b.opts.Config
+	est := &BuildEstimate{EmbedModel: cfg.Embedder.Model, LLMModel: cfg.BuildLLMConfig().Model}
+	for _, ch := range embed {
+		est.EmbedCalls++
+		est.EmbedTokens += llm.EstimateTokens(ch.Content)
+	}
+	for _, sf := range streamed {
+		est.EmbedCalls += int(sf.bytes/int64(max(chunkSize, 1))) + 1
+		est.EmbedTokens += int(sf.bytes / 4)
+	}
+
+	prev := b.lastReport()
+	completion := completionPerCall
+	if prev != nil && prev.Tokens.Calls > 0 {
+		completion = prev.Tokens.CompletionTokens / prev.Tokens.Calls
+	}
+	for _, batch := range extract {
+		prompt := promptOverhead
+		for _, ch := range batch {
+			prompt += llm.EstimateTokens(ch.Content)
+		}
+		est.LLMCalls++
+		est.LLMPromptTokens += prompt
+	}
+	if b.runs(StageDescribe) {
+		// The MCP description reads up to three chunks
+		est.LLMCalls++
+		est.LLMPromptTokens += promptOverhead
+		for _, ch := range embed[:min(3, len(embed))] {
+			est.LLMPromptTokens += llm.EstimateTokens(ch.Content)
+		}
+	}
+	est.LLMCompletionTokens = est.LLMCalls * completion
+
+	prices := cfg.Pricing.Prices()
+	if est.EmbedCalls > 0 {
+		if cost, ok := prices.Cost(est.EmbedModel, est.EmbedTokens, 0); ok {
+			est.CostUSD += cost
+		} else {
+			est.Unpriced = append(est.Unpriced, modelName(est.EmbedModel, "embedder"))
+		}
+	}
+	if cost, ok := prices.Cost(est.LLMModel, est.LLMPromptTokens, est.LLMCompletionTokens); ok {
+		est.CostUSD += cost
+	} else {
+		est.Unpriced = append(est.Unpriced, modelName(est.LLMModel, "LLM"))
+	}
+
+	embedTime := embedCallTime / embedConcurrency
+	if maxConcurrency > 0 && maxConcurrency < embedConcurrency {
+		embedTime = embedCallTime / time.Duration(maxConcurrency)
+	}
+	if prev != nil && prev.Estimate != nil && prev.Estimate.EmbedCalls > 0 && prev.StageDuration("embed") > 0 {
+		embedTime = prev.StageDuration("embed") / time.Duration(prev.Estimate.EmbedCalls)
+	}
+	llmTime := llmCallTime + time.Duration(completion)*time.Second/llmTokensPerSecond
+	if prev != nil && prev.Extraction.Batches > 0 && prev.StageDuration("graph") > 0 {
+		llmTime = prev.StageDuration("graph") / time.Duration(prev.Extraction.Batches)
+	}
+	duration := time.Duration(est.EmbedCalls)*embedTime + time.Duration(est.LLMCalls)*llmTime
+	est.DurationMS = duration.Milliseconds()
+	return est
+}
+
+// lastReport reads the report of the previous build, or returns nil.
+func (b *Builder) lastReport() *buildreport.Report {
+	dir := b.opts.ReportDir
+	if dir == "" {
+		return nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = b.path(dir)
+	}
+	r, err := buildreport.Load(dir)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// modelName names a model in the estimate, or the role of a router that
+// picks the model itself.
+func modelName(model, role string) string {
+	if model == "" {
+		return fmt.Sprintf("%s (no model set)", role)
+	}
+	return model
+}
+
+// FormatEstimate summarizes est on one line, e.g. "120 embedding call(s),
+// 13 LLM call(s), ~52k tokens, ~$0.01, ~30s".
+func FormatEstimate(est BuildEstimate) string {
+	cost := fmt.Sprintf("~$%.2f", est.CostUSD)
+	if len(est.Unpriced) > 0 {
+		cost += " + unpriced models"
+	}
+	tokens := est.EmbedTokens + est.LLMPromptTokens + est.LLMCompletionTokens
+	duration := (time.Duration(est.DurationMS) * time.Millisecond).Round(time.Second)
+	return fmt.Sprintf("%d embedding call(s), %d LLM call(s), ~%s tokens, %s, ~%s",
+		est.EmbedCalls, est.LLMCalls, formatCount(tokens), cost, duration)
+}
+
+// formatCount abbreviates large counts, e.g. 52300 as "52k".
+func formatCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 10_000:
+		return fmt.Sprintf("%dk", n/1000)
+	}
+	return fmt.Sprint(n)
+}
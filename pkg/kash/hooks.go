@@ -0,0 +1,95 @@
+package kash
+
+import (
+	"context"
+
+	"github.com/akashicode/kash/internal/retrieval"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// QueryTransformer rewrites a query before it is searched, e.g. to expand
+// internal jargon. The rewritten query is used by every search and the
+// reranker.
+type QueryTransformer = retrieval.QueryTransformer
+
+// QueryTransformFunc adapts a function to a QueryTransformer.
+type QueryTransformFunc = retrieval.QueryTransformFunc
+
+// ChunkRetriever finds chunks the vector index does not hold, e.g. in a
+// keyword index or an external search API. Its chunks are merged with the
+// vector results before reranking; those outside the search filter are
+// dropped.
+type ChunkRetriever interface {
+	RetrieveChunks(ctx context.Context, query string, topK int, filter map[string]string) ([]Chunk, error)
+}
+
+// ChunkFilter processes the chunks after reranking, before they reach the
+// LLM: dropping, reordering, or rewriting them.
+type ChunkFilter interface {
+	FilterChunks(ctx context.Context, query string, chunks []Chunk) ([]Chunk, error)
+}
+
+// ChunkFilterFunc adapts a function to a ChunkFilter.
+type ChunkFilterFunc func(ctx context.Context, query string, chunks []Chunk) ([]Chunk, error)
+
+// FilterChunks implements ChunkFilter.
+func (f ChunkFilterFunc) FilterChunks(ctx context.Context, query string, chunks []Chunk) ([]Chunk, error) {
+	return f(ctx, query, chunks)
+}
+
+// Hooks insert custom logic into retrieval. Each hook runs in order, after
+// the built-in hooks selected in agent.yaml. A hook that returns an error is
+// skipped; it does not fail the retrieval.
+type Hooks struct {
+	QueryTransformers []QueryTransformer
+	Retrievers        []ChunkRetriever
+	Filters           []ChunkFilter
+}
+
+// internal converts h to the hooks of the retrieval package.
+func (h Hooks) internal() retrieval.Hooks {
+	out := retrieval.Hooks{QueryTransformers: h.QueryTransformers}
+	for _, r := range h.Retrievers {
+		out.Retrievers = append(out.Retrievers, chunkRetriever{r})
+	}
+	for _, f := range h.Filters {
+		out.Filters = append(out.Filters, chunkFilter{f})
+	}
+	return out
+}
+
+// chunkRetriever adapts a ChunkRetriever to retrieval.ChunkRetriever.
+type chunkRetriever struct{ r ChunkRetriever }
+
+func (a chunkRetriever) RetrieveChunks(ctx context.Context, query string, topK int, filter map[string]string) ([]vector.SearchResult, error) {
+	chunks, err := a.r.RetrieveChunks(ctx, query, topK, filter)
+	if err != nil {
+		return nil, err
+	}
+	return searchResultsOf(chunks), nil
+}
+
+// chunkFilter adapts a ChunkFilter to retrieval.ChunkFilter.
+type chunkFilter struct{ f ChunkFilter }
+
+func (a chunkFilter) FilterChunks(ctx context.Context, query string, results []vector.SearchResult) ([]vector.SearchResult, error) {
+	chunks, err := a.f.FilterChunks(ctx, query, chunksOf(results))
+	if err != nil {
+		return nil, err
+	}
+	return searchResultsOf(chunks), nil
+}
+
+func searchResultsOf(chunks []Chunk) []vector.SearchResult {
+	results := make([]vector.SearchResult, len(chunks))
+	for i, c := range chunks {
+		results[i] = vector.SearchResult{
+			ID:         c.ID,
+			Source:     c.Source,
+			Similarity: c.Similarity,
+			Content:    c.Content,
+			Metadata:   c.Metadata,
+		}
+	}
+	return results
+}
@@ -0,0 +1,66 @@
+// Package kash embeds Kash agents in Go applications. It exposes the build
+// pipeline and the retrieval runtime that the kash CLI is built on, so an
+// application can compile, query, and serve an agent in-process instead of
+// shelling out to the CLI or calling its HTTP API.
+//
+// An agent is a directory laid out by 'kash init': agent.yaml, and data/
+// holding the documents and, after a build, the compiled databases.
+//
+//	cfg, err := kash.LoadConfig()
+//	b, err := kash.NewBuilder(kash.BuildOptions{Dir: "my-agent", Config: cfg})
+//	res, err := b.Build(ctx)
+//
+//	store, err := kash.OpenStore("my-agent", cfg)
+//	defer store.Close()
+//	r, err := kash.NewRetriever(store, kash.RetrieverOptions{})
+//	found, err := r.Retrieve(ctx, "How do I reset my password?", nil)
+//
+//	srv, err := kash.NewServer(kash.ServerOptions{Dir: "my-agent", Config: cfg})
+//	defer srv.Close()
+//	answer, err := srv.Ask(ctx, "How do I reset my password?")
+//	http.ListenAndServe(":8000", srv.Handler())
+package kash
+
+import (
+	"path/filepath"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/manifest"
+)
+
+// Config holds the provider settings for building and serving: LLM,
+// embedder, reranker, and the optional transcriber, OCR, and source
+// credentials.
+type Config = agentconfig.Config
+
+// ProviderConfig holds the endpoint, key, and model of one provider.
+type ProviderConfig = agentconfig.ProviderConfig
+
+// LoadConfig reads the provider settings the way the kash CLI does:
+// environment variables first, then .env and config.yaml in the working
+// directory. Applications may also fill in a Config directly.
+func LoadConfig() (*Config, error) {
+	return agentconfig.Load()
+}
+
+// Project layout, relative to the agent directory.
+const (
+	AgentFile = "agent.yaml"
+	DataDir   = "data"
+)
+
+var (
+	// VectorDir holds the compiled vector index
+	VectorDir = filepath.Join(DataDir, "memory.chromem")
+	// GraphDir holds the compiled knowledge graph
+	GraphDir = filepath.Join(DataDir, "knowledge.cayley")
+	// DocumentsDir holds the full text of the documents, for whole-document
+	// retrieval
+	DocumentsDir = filepath.Join(DataDir, "documents.store")
+	// StagesDir caches the loaded documents and their chunks, for a build
+	// that skips the load or chunk stage
+	StagesDir = filepath.Join(DataDir, "build-stages")
+	// ManifestFile describes the last build; it is written last, so its
+	// modification time marks a complete build
+	ManifestFile = manifest.DefaultPath
+)
@@ -0,0 +1,655 @@
+package kash
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/akashicode/kash/internal/chunker"
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/pricing"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// fakeProvider serves OpenAI-compatible embeddings and chat completions. The
+// chat endpoint extracts one triple, writes a tool description, or answers
+// with whether the knowledge base context reached the LLM.
+func fakeProvider(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input    []string `json:"input"`
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			data := make([]map[string]interface{}, len(body.Input))
+			for i := range body.Input {
+				data[i] = map[string]interface{}{"index": i, "embedding": []float32{0.5, 0.5, 0.5, 0.5}}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+			return
+		}
+
+		system := ""
+		for _, m := range body.Messages {
+			if m.Role == "system" {
+				system += m.Content
+			}
+		}
+		var answer string
+		switch {
+		case strings.Contains(system, "knowledge extraction"):
+			answer = `[{"subject": "Kash", "predicate": "compiles", "object": "documents"}]`
+		case strings.Contains(system, "MCP"):
+			answer = "Search the guide."
+		default:
+			answer = fmt.Sprintf("grounded: %v", strings.Contains(system, "guide.md"))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": answer}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBuildRetrieveServe(t *testing.T) {
+	ctx := context.Background()
+	provider := fakeProvider(t)
+	var events []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events = append(events, r.Header.Get("X-Kash-Event"))
+	}))
+	defer hook.Close()
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte(`agent:
+  name: guide
+  system_prompt: You answer from the guide.
+runtime:
+  embedder:
+    dimensions: 4
+webhooks:
+  - url: `+hook.URL+`
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"),
+		[]byte("# Guide\n\nKash compiles documents into a vector index and a knowledge graph.\n"), 0644))
+
+	cfg := func() *Config {
+		c := &Config{
+			LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+			Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+		}
+		c.OCR.Engine = "none"
+		return c
+	}
+
+	var progress strings.Builder
+	b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg(), ReportDir: ".kash", Progress: TextProgress(&progress)})
+	require.NoError(t, err)
+	res, err := b.Build(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Documents)
+	assert.Equal(t, 1, res.Vectors)
+	assert.Equal(t, int64(1), res.Triples)
+	assert.Equal(t, "ok", res.Report.Status)
+	assert.Contains(t, progress.String(), "[5/5] Generating optimized MCP tool descriptions...")
+	assert.FileExists(t, filepath.Join(dir, ManifestFile))
+	assert.FileExists(t, filepath.Join(dir, ".kash", "build-report.json"))
+	assert.Equal(t, []string{"build.completed"}, events)
+
+	b, err = NewBuilder(BuildOptions{Dir: dir, Config: cfg(), SkipUnchanged: true})
+	require.NoError(t, err)
+	res, err = b.Build(ctx)
+	require.NoError(t, err)
+	assert.True(t, res.Unchanged)
+	assert.Equal(t, 1, res.Vectors)
+	assert.Equal(t, []string{"build.completed"}, events, "an unchanged build sends no webhook")
+
+	store, err := OpenStore(dir, cfg())
+	require.NoError(t, err)
+	defer store.Close()
+	r, err := NewRetriever(store, RetrieverOptions{TopK: 3})
+	require.NoError(t, err)
+	found, err := r.Retrieve(ctx, "what does Kash compile", nil)
+	require.NoError(t, err)
+	require.Len(t, found.Chunks, 1)
+	assert.Equal(t, "guide.md", found.Chunks[0].Source)
+	require.Len(t, found.Graph, 1)
+	assert.Equal(t, "compiles", found.Graph[0].Predicate)
+	assert.Contains(t, found.Context, "## Relevant Knowledge")
+
+	found, err = r.Retrieve(ctx, "Kash", map[string]string{"tags": "missing"})
+	require.NoError(t, err)
+	assert.Empty(t, found.Chunks, "the filter excludes every chunk")
+
+	hooks := Hooks{
+		QueryTransformers: []QueryTransformer{QueryTransformFunc(func(_ context.Context, q string) (string, error) {
+			return strings.ReplaceAll(q, "kash", "Kash"), nil
+		})},
+		Retrievers: []ChunkRetriever{staticRetriever{{ID: "faq-1", Source: "faq", Content: "Kash is a compiler."}}},
+		Filters: []ChunkFilter{ChunkFilterFunc(func(_ context.Context, _ string, chunks []Chunk) ([]Chunk, error) {
+			return chunks[len(chunks)-1:], nil
+		})},
+	}
+	r, err = NewRetriever(store, RetrieverOptions{Hooks: hooks})
+	require.NoError(t, err)
+	found, err = r.Retrieve(ctx, "what is kash", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "what is Kash", found.SearchQuery)
+	require.Len(t, found.Chunks, 1, "the filter keeps only the last chunk")
+	assert.Equal(t, "faq", found.Chunks[0].Source)
+
+	srv, err := NewServer(ServerOptions{Dir: dir, Config: cfg(), Quiet: true})
+	require.NoError(t, err)
+	answer, err := srv.Ask(ctx, "What does Kash compile?")
+	require.NoError(t, err)
+	assert.Equal(t, "grounded: true", answer)
+
+	require.NoError(t, srv.Reload())
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/documents/guide.md/text", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Kash compiles documents into a vector index")
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"kash://documents/guide.md"}}`)))
+	assert.Contains(t, rec.Body.String(), "Kash compiles documents into a vector index")
+
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	go srv.LiveIngest(watchCtx, 10*time.Millisecond)
+	// Let the watcher take stock of data/ before the new document appears
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "faq.md"), []byte("# FAQ\n\nKash serves agents.\n"), 0644))
+	assert.Eventually(t, func() bool {
+		live, err := OpenStore(dir, cfg())
+		if err != nil {
+			return false
+		}
+		defer live.Close()
+		return live.Vectors() == 2
+	}, 5*time.Second, 20*time.Millisecond, "faq.md is embedded into the running server")
+	stopWatching()
+
+	var list struct {
+		Documents []struct {
+			Name        string    `json:"name"`
+			Origin      string    `json:"origin"`
+			Chunks      int       `json:"chunks"`
+			IngestedAt  time.Time `json:"ingested_at"`
+			DocumentURL string    `json:"document_url"`
+		} `json:"documents"`
+		Chunks int `json:"chunks"`
+	}
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/documents", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, 2, list.Chunks)
+	require.Len(t, list.Documents, 2)
+	assert.Equal(t, "faq.md", list.Documents[0].Name)
+	assert.Empty(t, list.Documents[0].Origin, "live ingest added faq.md after the build")
+	assert.Empty(t, list.Documents[0].DocumentURL, "the build stored no text of faq.md")
+	assert.Equal(t, "guide.md", list.Documents[1].Name)
+	assert.Equal(t, "data", list.Documents[1].Origin)
+	assert.Equal(t, 1, list.Documents[1].Chunks)
+	assert.False(t, list.Documents[1].IngestedAt.IsZero())
+	assert.Equal(t, "/v1/documents/guide.md/text", list.Documents[1].DocumentURL)
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/documents/faq.md/chunks", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Kash serves agents.")
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/documents/missing.md/chunks", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.NoError(t, srv.Close(), "Close waits for the webhook")
+	assert.Equal(t, []string{"build.completed", "reload.completed"}, events)
+}
+
+type staticRetriever []Chunk
+
+func (s staticRetriever) RetrieveChunks(context.Context, string, int, map[string]string) ([]Chunk, error) {
+	return s, nil
+}
+
+func TestNewBuilderRequiresProject(t *testing.T) {
+	_, err := NewBuilder(BuildOptions{Dir: t.TempDir(), Config: &Config{}})
+	assert.ErrorContains(t, err, "agent.yaml not found")
+
+	_, err = OpenStore(t.TempDir(), &Config{})
+	assert.ErrorContains(t, err, "run 'kash build' first")
+}
+
+func TestRebuildRemovesStaleChunks(t *testing.T) {
+	provider := fakeProvider(t)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte("agent:\n  name: guide\nruntime:\n  embedder:\n    dimensions: 4\nchunking:\n  size: 40\n  overlap: 0\n"), 0644))
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, name), []byte(content), 0644))
+	}
+	build := func() *BuildResult {
+		cfg := &Config{
+			LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+			Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+		}
+		cfg.OCR.Engine = "none"
+		b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg})
+		require.NoError(t, err)
+		res, err := b.Build(context.Background())
+		require.NoError(t, err)
+		return res
+	}
+
+	write("guide.md", "Kash compiles documents.\n\nIt builds a vector index.\n\nIt extracts a knowledge graph.\n")
+	write("faq.md", "Kash serves agents.\n")
+	first := build()
+	require.Greater(t, first.Vectors, 2)
+
+	write("guide.md", "Kash compiles documents.\n")
+	require.NoError(t, os.Remove(filepath.Join(dir, DataDir, "faq.md")))
+	res := build()
+	assert.Equal(t, 1, res.Chunks)
+	assert.Equal(t, 1, res.Vectors, "the chunks of faq.md and of the shrunk guide.md are removed")
+}
+
+func TestBuildReviewTriples(t *testing.T) {
+	provider := fakeProvider(t)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte("agent:\n  name: guide\nruntime:\n  embedder:\n    dimensions: 4\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"), []byte("Kash compiles documents.\n"), 0644))
+	cfg := &Config{
+		LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+		Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+	}
+	cfg.OCR.Engine = "none"
+
+	b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg, ReviewFile: graph.DefaultReviewFile})
+	require.NoError(t, err)
+	res, err := b.Build(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, res.Triples, "extracted triples wait for review")
+	assert.Equal(t, 1, res.ReviewTriples)
+
+	review, err := graph.LoadReview(filepath.Join(dir, graph.DefaultReviewFile))
+	require.NoError(t, err)
+	require.Len(t, review.Triples, 1)
+	assert.Equal(t, graph.ReviewTriple{Subject: "Kash", Predicate: "compiles", Object: "documents", Sources: []string{"guide.md"}}, review.Triples[0])
+}
+
+func TestBuildSkipGraph(t *testing.T) {
+	provider := fakeProvider(t)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte("agent:\n  name: guide\nruntime:\n  embedder:\n    dimensions: 4\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"), []byte("Kash compiles documents.\n"), 0644))
+	cfg := &Config{
+		LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+		Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+	}
+	cfg.OCR.Engine = "none"
+	build := func(skip bool) *BuildResult {
+		b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg, SkipGraph: skip, SkipUnchanged: true})
+		require.NoError(t, err)
+		res, err := b.Build(context.Background())
+		require.NoError(t, err)
+		return res
+	}
+
+	require.Equal(t, int64(1), build(false).Triples)
+	res := build(true)
+	assert.False(t, res.Unchanged, "skipping the graph changes the build")
+	assert.True(t, res.NoGraph)
+	assert.Zero(t, res.Triples)
+	assert.Equal(t, 1, res.Report.Estimate.LLMCalls, "only the MCP description calls the LLM")
+	assert.NoDirExists(t, filepath.Join(dir, GraphDir), "the graph of the last build is removed")
+	m, err := manifest.Load(filepath.Join(dir, ManifestFile))
+	require.NoError(t, err)
+	assert.True(t, m.NoGraph)
+	assert.True(t, build(true).Unchanged)
+
+	store, err := OpenStore(dir, cfg)
+	require.NoError(t, err)
+	defer store.Close()
+	assert.Equal(t, 1, store.Vectors())
+	assert.Zero(t, store.Triples())
+	_, err = store.SearchChunks(context.Background(), "kash", 1, nil)
+	require.NoError(t, err)
+}
+
+func TestBuildStoresDocuments(t *testing.T) {
+	provider := fakeProvider(t)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"), []byte("Kash compiles documents. Mail jane@example.com.\n"), 0644))
+	cfg := &Config{
+		LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+		Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+	}
+	cfg.OCR.Engine = "none"
+	build := func(agentYAML string) *BuildResult {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte("agent:\n  name: guide\nruntime:\n  embedder:\n    dimensions: 4\n"+agentYAML), 0644))
+		b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg, SkipUnchanged: true})
+		require.NoError(t, err)
+		res, err := b.Build(context.Background())
+		require.NoError(t, err)
+		return res
+	}
+
+	res := build("pii:\n  enabled: true\n  action: tag\n")
+	assert.DirExists(t, filepath.Join(dir, ".kash", "snapshots", res.Snapshot, "documents.store"), "the snapshot keeps the documents")
+	store, err := OpenStore(dir, cfg)
+	require.NoError(t, err)
+	text, err := store.Document("guide.md")
+	require.NoError(t, err)
+	assert.Contains(t, text, "Kash compiles documents.")
+	assert.Contains(t, text, "[EMAIL]", "the stored text is redacted even when chunks are only tagged")
+	_, err = store.Document("missing.md")
+	assert.ErrorIs(t, err, ErrDocumentNotStored)
+	require.NoError(t, store.Close())
+
+	os.RemoveAll(filepath.Join(dir, DocumentsDir))
+	assert.False(t, build("pii:\n  enabled: true\n  action: tag\n").Unchanged, "a build without its documents is not up to date")
+	assert.DirExists(t, filepath.Join(dir, DocumentsDir))
+
+	assert.False(t, build("ingest:\n  skip_documents: true\n").Unchanged)
+	assert.NoDirExists(t, filepath.Join(dir, DocumentsDir), "skip_documents removes the documents of the last build")
+	store, err = OpenStore(dir, cfg)
+	require.NoError(t, err)
+	defer store.Close()
+	_, err = store.Document("guide.md")
+	assert.ErrorIs(t, err, ErrDocumentNotStored)
+}
+
+func TestBuildGraphOnly(t *testing.T) {
+	ctx := context.Background()
+	provider := fakeProvider(t)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte("agent:\n  name: guide\nruntime:\n  embedder:\n    dimensions: 4\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"), []byte("Kash compiles documents.\n"), 0644))
+	cfg := &Config{
+		LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+		Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+	}
+	cfg.OCR.Engine = "none"
+	build := func(graphOnly bool) *BuildResult {
+		b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg, GraphOnly: graphOnly})
+		require.NoError(t, err)
+		res, err := b.Build(ctx)
+		require.NoError(t, err)
+		return res
+	}
+
+	b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg, GraphOnly: true})
+	require.NoError(t, err)
+	_, err = b.Build(ctx)
+	assert.ErrorContains(t, err, "run the embed stage")
+
+	build(false)
+	gdb, err := graph.NewDBFromPath(filepath.Join(dir, GraphDir))
+	require.NoError(t, err)
+	require.NoError(t, gdb.AddTriples(ctx, []graph.Triple{{Subject: "Kash", Predicate: "was", Object: "wrong"}}))
+	require.NoError(t, gdb.Close())
+	before, err := vector.ReadDocuments(filepath.Join(dir, VectorDir))
+	require.NoError(t, err)
+
+	// A changed document is extracted again but not embedded
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"), []byte("Kash compiles documents into agents.\n"), 0644))
+	res := build(true)
+	assert.Equal(t, int64(1), res.Triples, "the graph is extracted from scratch")
+	assert.Zero(t, res.Report.Estimate.EmbedCalls)
+	assert.Contains(t, res.Report.Warnings, "1 chunk(s) changed since the vector index was built; run a full build to embed them")
+	after, err := vector.ReadDocuments(filepath.Join(dir, VectorDir))
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "the vector index is untouched")
+	m, err := manifest.Load(filepath.Join(dir, ManifestFile))
+	require.NoError(t, err)
+	assert.Empty(t, m.Fingerprint, "the next build embeds the changed chunk")
+}
+
+func TestParseStages(t *testing.T) {
+	stages, err := ParseStages("Embed, extract,embed")
+	require.NoError(t, err)
+	assert.Equal(t, []Stage{StageEmbed, StageExtract}, stages)
+	_, err = ParseStages("embed,index")
+	assert.ErrorContains(t, err, `unknown build stage "index"`)
+	_, err = ParseStages(" , ")
+	assert.Error(t, err)
+}
+
+func TestBuildStages(t *testing.T) {
+	ctx := context.Background()
+	provider := fakeProvider(t)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte("agent:\n  name: guide\nruntime:\n  embedder:\n    dimensions: 4\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"), []byte("Kash compiles documents.\n"), 0644))
+	cfg := &Config{
+		LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+		Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+	}
+	cfg.OCR.Engine = "none"
+	build := func(stages ...Stage) (*BuildResult, error) {
+		b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg, Stages: stages})
+		require.NoError(t, err)
+		return b.Build(ctx)
+	}
+
+	_, err := build(StageChunk)
+	assert.ErrorContains(t, err, "the load stage has not run yet")
+
+	// Loading and chunking alone call no provider and write no store
+	res, err := build(StageLoad, StageChunk)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Chunks)
+	assert.Zero(t, res.Report.Estimate.LLMCalls)
+	assert.FileExists(t, filepath.Join(dir, StagesDir, "chunks.json"))
+	assert.NoDirExists(t, filepath.Join(dir, VectorDir))
+	assert.NoFileExists(t, filepath.Join(dir, ManifestFile))
+
+	// The later stages read the cached chunks, not data/
+	require.NoError(t, os.Remove(filepath.Join(dir, DataDir, "guide.md")))
+	res, err = build(StageEmbed, StageExtract, StageDescribe)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Vectors)
+	assert.Equal(t, int64(1), res.Triples)
+	m, err := manifest.Load(filepath.Join(dir, ManifestFile))
+	require.NoError(t, err)
+	require.Len(t, m.Documents, 1)
+	assert.Equal(t, "guide.md", m.Documents[0].Name)
+
+	// Without the extract stage the graph is kept and the build is not
+	// marked up to date
+	res, err = build(StageEmbed)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), res.Triples)
+	m, err = manifest.Load(filepath.Join(dir, ManifestFile))
+	require.NoError(t, err)
+	assert.Empty(t, m.Fingerprint)
+}
+
+func TestExtractionBatches(t *testing.T) {
+	chunks := func(sizes ...int) []chunker.Chunk {
+		out := make([]chunker.Chunk, len(sizes))
+		for i, n := range sizes {
+			out[i] = chunker.Chunk{ID: fmt.Sprint(i), Content: strings.Repeat("x", n*4)}
+		}
+		return out
+	}
+	lens := func(batches [][]chunker.Chunk) []int {
+		var out []int
+		for _, b := range batches {
+			out = append(out, len(b))
+		}
+		return out
+	}
+
+	assert.Equal(t, []int{10, 2}, lens(extractionBatches(chunks(make([]int, 12)...), agentconfig.ExtractionConfig{})))
+	assert.Equal(t, []int{3, 3, 1}, lens(extractionBatches(chunks(make([]int, 7)...), agentconfig.ExtractionConfig{BatchSize: 3})))
+	// The token limit ends batches early; a chunk above it is sent alone
+	assert.Equal(t, []int{2, 1, 1, 2}, lens(extractionBatches(chunks(40, 50, 30, 500, 10, 10), agentconfig.ExtractionConfig{MaxBatchTokens: 100})))
+	assert.Empty(t, extractionBatches(nil, agentconfig.ExtractionConfig{}))
+}
+
+func TestBuildConfirmsEstimatedCost(t *testing.T) {
+	provider := fakeProvider(t)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte("agent:\n  name: guide\nruntime:\n  embedder:\n    dimensions: 4\nchunking:\n  size: 40\n  overlap: 0\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"), []byte("Kash compiles documents.\n\nIt builds a vector index.\n\nIt extracts a knowledge graph.\n"), 0644))
+
+	var asked []BuildEstimate
+	build := func(answer error) (*BuildResult, error) {
+		cfg := &Config{
+			LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+			Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+		}
+		cfg.OCR.Engine = "none"
+		cfg.Pricing.ConfirmAbove = 0.0001
+		cfg.Pricing.Models = pricing.Table{"e": {Input: 1000}, "m": {Input: 1000, Output: 1000}}
+		b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg, Confirm: func(est BuildEstimate) error {
+			asked = append(asked, est)
+			return answer
+		}})
+		require.NoError(t, err)
+		return b.Build(context.Background())
+	}
+
+	_, err := build(ErrBuildDeclined)
+	require.ErrorIs(t, err, ErrBuildDeclined)
+	require.Len(t, asked, 1)
+	first := asked[0]
+	assert.Equal(t, 3, first.EmbedCalls)
+	assert.Equal(t, 2, first.LLMCalls, "one extraction batch and the MCP description")
+	assert.Greater(t, first.CostUSD, 0.0)
+	assert.Empty(t, first.Unpriced)
+	_, err = os.Stat(filepath.Join(dir, ManifestFile))
+	assert.True(t, os.IsNotExist(err), "a declined build writes nothing")
+
+	res, err := build(nil)
+	require.NoError(t, err)
+	require.NotNil(t, res.Report.Estimate)
+	phases := res.Report.Phases
+	assert.Equal(t, 3, phases["embedding"].Calls)
+	assert.Equal(t, 1, phases["extraction"].Calls)
+	assert.Equal(t, 1, phases["description"].Calls)
+	assert.Equal(t, 2, res.Report.Tokens.Calls, "embeddings are not LLM tokens")
+	assert.InDelta(t, phases["embedding"].CostUSD+phases["extraction"].CostUSD+phases["description"].CostUSD, res.Report.CostUSD, 1e-9)
+
+	_, err = build(nil)
+	require.NoError(t, err)
+	assert.Zero(t, asked[2].EmbedCalls, "unchanged chunks keep their embeddings")
+}
+
+func TestBuildStreamsLargeFiles(t *testing.T) {
+	provider := fakeProvider(t)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte("agent:\n  name: logs\nruntime:\n  embedder:\n    dimensions: 4\nchunking:\n  size: 4000\ningest:\n  stream_threshold_mb: 1\n"), 0644))
+	var log strings.Builder
+	for i := 0; log.Len() < 1<<20; i++ {
+		fmt.Fprintf(&log, "2026-01-02 10:00:%02d worker %d finished job %d.\n", i%60, i%7, i)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "app.txt"), []byte(log.String()), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"), []byte("Kash compiles documents.\n"), 0644))
+	build := func(skipUnchanged bool) *BuildResult {
+		cfg := &Config{
+			LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+			Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+		}
+		cfg.OCR.Engine = "none"
+		b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg, ReportDir: ".kash", SkipUnchanged: skipUnchanged})
+		require.NoError(t, err)
+		res, err := b.Build(context.Background())
+		require.NoError(t, err)
+		return res
+	}
+
+	res := build(false)
+	assert.Equal(t, 2, res.Documents)
+	require.Greater(t, res.Chunks, streamBatch, "the log is embedded in several batches")
+	assert.Equal(t, res.Chunks, res.Vectors)
+	assert.Equal(t, int64(1), res.Triples, "only guide.md goes to triple extraction")
+	for _, d := range res.Report.Documents {
+		if d.Name == "app.txt" {
+			assert.Equal(t, res.Chunks-1, d.Chunks)
+			assert.Equal(t, log.Len(), d.Bytes)
+		}
+	}
+
+	unchanged := build(true)
+	assert.True(t, unchanged.Unchanged)
+	assert.Equal(t, res.Chunks, unchanged.Chunks)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "app.txt"), []byte(log.String()+"tail\n"), 0644))
+	changed := build(true)
+	assert.False(t, changed.Unchanged, "the fingerprint covers streamed content")
+	assert.Equal(t, res.Chunks, changed.Vectors)
+}
+
+func TestMerge(t *testing.T) {
+	ctx := context.Background()
+	provider := fakeProvider(t)
+	build := func(name, doc string) string {
+		dir := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, DataDir), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, AgentFile), []byte("agent:\n  name: "+name+"\nruntime:\n  embedder:\n    dimensions: 4\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, DataDir, "guide.md"), []byte(doc), 0644))
+		cfg := &Config{
+			LLM:      ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "m"},
+			Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Model: "e"},
+		}
+		cfg.OCR.Engine = "none"
+		b, err := NewBuilder(BuildOptions{Dir: dir, Config: cfg})
+		require.NoError(t, err)
+		_, err = b.Build(ctx)
+		require.NoError(t, err)
+		return dir
+	}
+	billing := build("billing", "Kash bills monthly.\n")
+	support := build("support", "Kash answers tickets.\n")
+
+	out := filepath.Join(t.TempDir(), "help-desk")
+	res, err := Merge(ctx, MergeOptions{Dir: out, Agents: []MergeAgent{{Dir: billing}, {Dir: support}}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Documents)
+	assert.Equal(t, 2, res.Chunks)
+	assert.Equal(t, 4, res.Dimensions)
+	assert.Equal(t, int64(1), res.Triples)
+	assert.Equal(t, 1, res.DuplicateTriples, "both agents extracted the same triple")
+	assert.FileExists(t, filepath.Join(out, AgentFile))
+
+	store, err := OpenStore(out, &Config{Embedder: ProviderConfig{BaseURL: provider.URL, APIKey: "k", Dimensions: 4}})
+	require.NoError(t, err)
+	defer store.Close()
+	chunks, err := store.SearchChunks(ctx, "tickets", 5, map[string]string{"agent": "support"})
+	require.NoError(t, err)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "support/guide.md", chunks[0].Source)
+
+	_, err = Merge(ctx, MergeOptions{Dir: out, Agents: []MergeAgent{{Dir: billing}, {Dir: support}}})
+	assert.ErrorContains(t, err, "--force")
+	_, err = Merge(ctx, MergeOptions{Dir: out, Agents: []MergeAgent{{Name: "a", Dir: billing}, {Name: "a", Dir: support}}, Force: true})
+	assert.ErrorContains(t, err, "used twice")
+}
@@ -0,0 +1,352 @@
+package kash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// MergeAgent is one built agent combined by Merge.
+type MergeAgent struct {
+	// Name prefixes the agent's document names and chunk IDs, as
+	// "<name>/<document>" (default: the base name of Dir)
+	Name string
+	Dir  string
+}
+
+// MergeOptions configures Merge.
+type MergeOptions struct {
+	// Dir is the agent directory that receives the merged stores. Its
+	// agent.yaml is kept; without one, the first agent's is copied
+	Dir    string
+	Agents []MergeAgent
+	// Force replaces the stores of an agent already built in Dir
+	Force bool
+	// AllowMixedModels merges agents embedded with different models of the
+	// same dimensions. Their similarity scores are not comparable, so
+	// retrieval is skewed toward one agent's chunks
+	AllowMixedModels bool
+	// Version is recorded in the manifest (default: "dev")
+	Version  string
+	Progress Progress
+}
+
+// MergeResult summarizes a finished merge.
+type MergeResult struct {
+	Documents  int
+	Chunks     int
+	Dimensions int
+	Triples    int64
+	// DuplicateTriples counts the triples held by more than one agent,
+	// stored once
+	DuplicateTriples int
+	// Snapshot is the version the merge was saved as; see BuildResult
+	Snapshot string
+	Duration time.Duration
+}
+
+// mergedAgent is what Merge reads from one agent.
+type mergedAgent struct {
+	MergeAgent
+	chunks   []vector.Document
+	manifest *manifest.Manifest
+}
+
+// Merge combines the vector indexes and knowledge graphs of several built
+// agents into the agent at opts.Dir without embedding or extracting again.
+// Every agent's vectors must have the same dimensions. Document names and
+// chunk IDs are prefixed with the agent name, and each chunk carries an
+// "agent" metadata field, so chunks never collide and can be filtered by
+// origin. Triples that several agents hold are stored once.
+func Merge(ctx context.Context, opts MergeOptions) (*MergeResult, error) {
+	start := time.Now()
+	if opts.Dir == "" {
+		opts.Dir = "."
+	}
+	if opts.Version == "" {
+		opts.Version = "dev"
+	}
+	progress := opts.Progress
+	if progress == nil {
+		progress = silentProgress{}
+	}
+	if len(opts.Agents) < 2 {
+		return nil, errors.New("merge needs at least two agents")
+	}
+	const totalSteps = 4
+
+	progress.Step(1, totalSteps, "Checking agents...")
+	agents, dims, err := loadMergeAgents(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareMergeDir(opts, agents[0], dims, progress); err != nil {
+		return nil, err
+	}
+	progress.Result("Agents", fmt.Sprintf("%d, with %d-dimensional vectors", len(agents), dims))
+
+	progress.Step(2, totalSteps, "Merging vector indexes...")
+	var chunks []vector.Document
+	for _, a := range agents {
+		for _, c := range a.chunks {
+			meta := make(map[string]string, len(c.Metadata)+1)
+			for k, v := range c.Metadata {
+				meta[k] = v
+			}
+			meta["source"] = a.Name + "/" + c.Source
+			meta["agent"] = a.Name
+			chunks = append(chunks, vector.Document{
+				ID:        a.Name + "/" + c.ID,
+				Content:   c.Content,
+				Source:    meta["source"],
+				Metadata:  meta,
+				Embedding: c.Embedding,
+			})
+		}
+		progress.Detail(fmt.Sprintf("%s: %d chunk(s)", a.Name, len(a.chunks)))
+	}
+	if err := vector.WriteDocuments(ctx, filepath.Join(opts.Dir, VectorDir), chunks); err != nil {
+		return nil, err
+	}
+	progress.Result("Chunks", fmt.Sprintf("%d → %s", len(chunks), VectorDir))
+
+	progress.Step(3, totalSteps, "Merging knowledge graphs...")
+	triples, dups, err := mergeTriples(ctx, agents)
+	if err != nil {
+		return nil, err
+	}
+	gdb, err := graph.NewDBFromPath(filepath.Join(opts.Dir, GraphDir))
+	if err != nil {
+		return nil, fmt.Errorf("open graph db: %w", err)
+	}
+	if err := gdb.AddTriples(ctx, triples); err != nil {
+		gdb.Close()
+		return nil, err
+	}
+	count := gdb.Count()
+	if err := gdb.Close(); err != nil {
+		return nil, fmt.Errorf("close graph db: %w", err)
+	}
+	progress.Result("Triples", fmt.Sprintf("%d (%d duplicate(s) dropped) → %s", count, dups, GraphDir))
+
+	progress.Step(4, totalSteps, "Writing manifest...")
+	m := mergeManifest(agents, chunks, dims, count, opts.Version)
+	if err := m.Save(filepath.Join(opts.Dir, ManifestFile)); err != nil {
+		return nil, err
+	}
+	progress.Result("Manifest", ManifestFile)
+
+	res := &MergeResult{
+		Documents:        len(m.Documents),
+		Chunks:           len(chunks),
+		Dimensions:       dims,
+		Triples:          count,
+		DuplicateTriples: dups,
+	}
+	if res.Snapshot, err = saveSnapshot(opts.Dir, progress); err != nil {
+		progress.Warn(fmt.Sprintf("failed to save snapshot: %v", err))
+	}
+	res.Duration = time.Since(start)
+	return res, nil
+}
+
+// loadMergeAgents reads the chunks and manifest of every agent and checks
+// that their vectors can share one index.
+func loadMergeAgents(opts MergeOptions) ([]mergedAgent, int, error) {
+	out, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("resolve directory %q: %w", opts.Dir, err)
+	}
+	agents := make([]mergedAgent, 0, len(opts.Agents))
+	names := map[string]bool{}
+	dims, dimsAgent := 0, ""
+	for _, spec := range opts.Agents {
+		a := mergedAgent{MergeAgent: spec}
+		abs, err := filepath.Abs(a.Dir)
+		if err != nil {
+			return nil, 0, fmt.Errorf("resolve directory %q: %w", a.Dir, err)
+		}
+		if abs == out {
+			return nil, 0, fmt.Errorf("%s is both merged and the merge target — merge into a new directory", a.Dir)
+		}
+		if a.Name == "" {
+			a.Name = filepath.Base(abs)
+		}
+		if strings.Contains(a.Name, "/") {
+			return nil, 0, fmt.Errorf("agent name %q must not contain '/'", a.Name)
+		}
+		if names[a.Name] {
+			return nil, 0, fmt.Errorf("agent name %q is used twice — name them with name=dir", a.Name)
+		}
+		names[a.Name] = true
+
+		// A vector-only agent has no graph store to merge
+		if _, err := os.Stat(filepath.Join(a.Dir, VectorDir)); err != nil {
+			return nil, 0, fmt.Errorf("agent %q has no %s — run 'kash build' in %s first", a.Name, VectorDir, a.Dir)
+		}
+		if a.chunks, err = vector.ReadDocuments(filepath.Join(a.Dir, VectorDir)); err != nil {
+			return nil, 0, fmt.Errorf("agent %q: %w", a.Name, err)
+		}
+		for _, c := range a.chunks {
+			if dims == 0 {
+				dims, dimsAgent = len(c.Embedding), a.Name
+			}
+			if len(c.Embedding) != dims {
+				return nil, 0, fmt.Errorf("agent %q has %d-dimensional vectors, but %q has %d — rebuild it with the same embedder",
+					a.Name, len(c.Embedding), dimsAgent, dims)
+			}
+		}
+		if a.manifest, err = manifest.Load(filepath.Join(a.Dir, ManifestFile)); err != nil && !errors.Is(err, manifest.ErrNotFound) {
+			return nil, 0, fmt.Errorf("agent %q: %w", a.Name, err)
+		}
+		agents = append(agents, a)
+	}
+
+	// Vectors of different models live in different spaces, even at the
+	// same dimensions
+	var model, modelAgent string
+	for _, a := range agents {
+		if a.manifest != nil && len(a.manifest.Embedder.Languages) > 0 && !opts.AllowMixedModels {
+			return nil, 0, fmt.Errorf("agent %q embeds some languages with models of their own — rebuild it without embedder.languages, or allow mixed models", a.Name)
+		}
+		if a.manifest == nil || a.manifest.Embedder.Model == "" {
+			continue
+		}
+		if model == "" {
+			model, modelAgent = a.manifest.Embedder.Model, a.Name
+			continue
+		}
+		if a.manifest.Embedder.Model != model && !opts.AllowMixedModels {
+			return nil, 0, fmt.Errorf("agent %q was embedded with %s, but %q with %s — rebuild one with the other's embedder, or allow mixed models",
+				a.Name, a.manifest.Embedder.Model, modelAgent, model)
+		}
+	}
+	return agents, dims, nil
+}
+
+// prepareMergeDir makes opts.Dir an agent directory without built stores.
+func prepareMergeDir(opts MergeOptions, first mergedAgent, dims int, progress Progress) error {
+	agentYAML := filepath.Join(opts.Dir, AgentFile)
+	if _, err := os.Stat(agentYAML); errors.Is(err, os.ErrNotExist) {
+		data, err := os.ReadFile(filepath.Join(first.Dir, AgentFile))
+		if err != nil {
+			return fmt.Errorf("copy agent.yaml of %q: %w", first.Name, err)
+		}
+		if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+			return fmt.Errorf("create %s: %w", opts.Dir, err)
+		}
+		if err := os.WriteFile(agentYAML, data, 0644); err != nil {
+			return fmt.Errorf("write agent.yaml: %w", err)
+		}
+		progress.Detail(fmt.Sprintf("Copied agent.yaml from %s — edit its name and system prompt", first.Name))
+	} else if d := agentconfig.AgentYAMLDimensions(agentYAML); d > 0 && dims > 0 && d != dims {
+		return fmt.Errorf("agent.yaml in %s configures %d dimensions, but the merged vectors have %d", opts.Dir, d, dims)
+	}
+
+	for _, dir := range []string{VectorDir, GraphDir} {
+		path := filepath.Join(opts.Dir, dir)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if !opts.Force {
+			return fmt.Errorf("%s already exists — pass --force to replace it", path)
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("remove %s: %w", path, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(opts.Dir, DataDir), 0755); err != nil {
+		return fmt.Errorf("create %s: %w", DataDir, err)
+	}
+	return nil
+}
+
+// mergeTriples reads every agent's graph and drops the triples an earlier
+// agent already held, comparing case-insensitively.
+func mergeTriples(ctx context.Context, agents []mergedAgent) ([]graph.Triple, int, error) {
+	seen := map[graph.Triple]bool{}
+	var out []graph.Triple
+	dups := 0
+	for _, a := range agents {
+		gdb, err := graph.OpenSnapshot(filepath.Join(a.Dir, GraphDir))
+		if err != nil {
+			return nil, 0, fmt.Errorf("agent %q: open graph db: %w", a.Name, err)
+		}
+		triples, err := gdb.Triples(ctx)
+		gdb.Close()
+		if err != nil {
+			return nil, 0, fmt.Errorf("agent %q: %w", a.Name, err)
+		}
+		for _, t := range triples {
+			key := graph.Triple{
+				Subject:   strings.ToLower(strings.TrimSpace(t.Subject)),
+				Predicate: strings.ToLower(strings.TrimSpace(t.Predicate)),
+				Object:    strings.ToLower(strings.TrimSpace(t.Object)),
+			}
+			if seen[key] {
+				dups++
+				continue
+			}
+			seen[key] = true
+			out = append(out, t)
+		}
+	}
+	return out, dups, nil
+}
+
+// mergeManifest describes the merged stores, with each agent's documents and
+// sources under its name.
+func mergeManifest(agents []mergedAgent, chunks []vector.Document, dims int, triples int64, version string) *manifest.Manifest {
+	m := &manifest.Manifest{
+		BuiltAt:     time.Now().UTC(),
+		KashVersion: version,
+		Embedder:    manifest.EmbedderInfo{Dimensions: dims},
+		Chunks:      len(chunks),
+		Vectors:     len(chunks),
+		Triples:     triples,
+	}
+	ids := map[string][]string{}
+	for _, c := range chunks {
+		ids[c.Source] = append(ids[c.Source], c.ID)
+	}
+	for _, a := range agents {
+		m.Merged = append(m.Merged, a.Name)
+		listed := map[string]bool{}
+		if a.manifest != nil {
+			if m.Embedder.Model == "" {
+				m.Embedder.Model = a.manifest.Embedder.Model
+			}
+			if m.LLMModel == "" {
+				m.LLMModel = a.manifest.LLMModel
+			}
+			for _, doc := range a.manifest.Documents {
+				doc.Name = a.Name + "/" + doc.Name
+				doc.ChunkIDs = ids[doc.Name]
+				doc.Chunks = len(doc.ChunkIDs)
+				m.Documents = append(m.Documents, doc)
+				listed[doc.Name] = true
+			}
+			m.Sources = append(m.Sources, a.manifest.Sources...)
+		}
+		// Chunks the manifest does not list, e.g. from live ingestion,
+		// still make documents of the merged agent
+		for _, c := range a.chunks {
+			name := a.Name + "/" + c.Source
+			if listed[name] {
+				continue
+			}
+			listed[name] = true
+			m.Documents = append(m.Documents, manifest.Document{Name: name, Origin: "merge", Chunks: len(ids[name]), ChunkIDs: ids[name]})
+		}
+	}
+	return m
+}
@@ -0,0 +1,30 @@
+package kash
+
+import "github.com/akashicode/kash/internal/reader"
+
+// DocumentReader extracts documents from a file format kash does not read
+// natively. Extensions lists the extensions it handles, e.g. ".tix"; Read
+// returns the document at a path.
+type DocumentReader = reader.FormatReader
+
+// Document is a file's extracted text. Sections optionally split it into
+// parts, such as tickets in an export, whose metadata flows into chunk
+// metadata; Triples are facts added to the knowledge graph verbatim.
+type Document = reader.Document
+
+// Section is a logical part of a Document.
+type Section = reader.Section
+
+// DocumentTriple is a Subject-Predicate-Object fact read from a Document.
+type DocumentTriple = reader.Triple
+
+// ExecReader is a DocumentReader that runs an external program, the same
+// protocol as the ingest.plugins entries in agent.yaml.
+type ExecReader = reader.ExecReader
+
+// RegisterReader makes r the reader for its extensions in every build in this
+// process, taking precedence over the built-in readers. Call it from an init
+// function; BuildOptions.Readers scopes a reader to one Builder instead.
+func RegisterReader(r DocumentReader) {
+	reader.Register(r)
+}
@@ -0,0 +1,170 @@
+package kash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/akashicode/kash/internal/llm"
+	"github.com/akashicode/kash/internal/retrieval"
+)
+
+// RetrieverOptions configures a Retriever.
+type RetrieverOptions struct {
+	// TopK is the number of chunks per query (default: 5)
+	TopK int
+	// GraphTopK is the number of triples per query (default: 10)
+	GraphTopK int
+	// Reranker reorders the chunks when its BaseURL is set, or with
+	// Provider "local" a cross-encoder at its Model path does
+	Reranker ProviderConfig
+	// RerankTopN keeps the N most relevant reranked chunks, and reranked
+	// chunks scoring below MinRelevanceScore are dropped (default: the
+	// retrieval.rerank block of agent.yaml)
+	RerankTopN        int
+	MinRelevanceScore float64
+	// MinSimilarity drops chunks less similar to the query (default:
+	// retrieval.min_similarity in agent.yaml)
+	MinSimilarity float64
+	// LLM compresses the chunks when retrieval.compress is enabled in
+	// agent.yaml, and classifies them when retrieval.guard.classify is;
+	// without its BaseURL the chunks are left whole and checked with the
+	// guard's patterns alone
+	LLM ProviderConfig
+	// Hooks run after the built-in hooks selected in agent.yaml
+	Hooks Hooks
+}
+
+// Retriever runs the hybrid search behind an agent's answers: vector search
+// over chunks, graph search over triples, optional reranking, and the
+// retrieval hooks. It does not call the LLM.
+type Retriever struct {
+	store *Store
+	opts  retrieval.Options
+}
+
+// Retrieval is what a Retriever found for a query.
+type Retrieval struct {
+	Query string `json:"query"`
+	// SearchQuery is the query searched, after the query transformers
+	SearchQuery string  `json:"search_query"`
+	Chunks      []Chunk `json:"chunks"`
+	// Reranked is true when Chunks are in reranker order
+	Reranked bool `json:"reranked"`
+	// Fused is true when Chunks and Graph are ordered by their fused Score
+	Fused bool `json:"fused,omitempty"`
+	// Compressed is true when Chunks hold only their sentences relevant to
+	// the query
+	Compressed bool     `json:"compressed,omitempty"`
+	Graph      []Triple `json:"graph"`
+	// Context is the block an agent gives the LLM for this query
+	Context string `json:"context"`
+}
+
+// NewRetriever creates a Retriever searching store.
+func NewRetriever(store *Store, opts RetrieverOptions) (*Retriever, error) {
+	if store == nil {
+		return nil, errors.New("store is required")
+	}
+	hookCfg, err := retrieval.AgentYAMLHooks(filepath.Join(store.dir, AgentFile))
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := hookCfg.Hooks()
+	if err != nil {
+		return nil, fmt.Errorf("agent.yaml retrieval: %w", err)
+	}
+	r := &Retriever{store: store, opts: retrieval.Options{
+		TopK:          opts.TopK,
+		GraphTopK:     opts.GraphTopK,
+		Rerank:        hookCfg.Rerank,
+		Fusion:        hookCfg.Fusion,
+		Strategy:      hookCfg.Strategy,
+		RRFK:          hookCfg.RRFK,
+		MinSimilarity: hookCfg.MinSimilarity,
+		Window:        hookCfg.Window,
+		Language:      hookCfg.Language,
+		Parent:        hookCfg.ParentDocument,
+		Hooks:         hooks.Append(opts.Hooks.internal()),
+	}}
+	if store.documents != nil {
+		r.opts.Documents = store.documents
+	}
+	if opts.RerankTopN > 0 {
+		r.opts.Rerank.TopN = opts.RerankTopN
+	}
+	if opts.MinRelevanceScore > 0 {
+		r.opts.Rerank.MinRelevanceScore = opts.MinRelevanceScore
+	}
+	if opts.MinSimilarity > 0 {
+		r.opts.MinSimilarity = opts.MinSimilarity
+	}
+	if opts.Reranker.BaseURL != "" || opts.Reranker.Provider != "" {
+		reranker, err := llm.NewReranker(&opts.Reranker)
+		if err != nil {
+			return nil, fmt.Errorf("create reranker: %w", err)
+		}
+		rerankOpts, err := hookCfg.Rerank.RerankerOptions()
+		if err != nil {
+			return nil, fmt.Errorf("agent.yaml retrieval: %w", err)
+		}
+		reranker.Configure(rerankOpts)
+		r.opts.Reranker = reranker
+	}
+	if cc := hookCfg.Compress; cc.Enabled && opts.LLM.BaseURL != "" {
+		llmCfg := opts.LLM
+		if cc.Model != "" {
+			llmCfg.Model = cc.Model
+		}
+		compressor, err := llm.NewClient(&llmCfg)
+		if err != nil {
+			return nil, fmt.Errorf("create compression client: %w", err)
+		}
+		r.opts.Compressor = compressor
+		r.opts.Compress = cc
+	}
+	r.opts.Guard = hookCfg.Guard
+	if gc := hookCfg.Guard; gc.Enabled && gc.Classify && opts.LLM.BaseURL != "" {
+		llmCfg := opts.LLM
+		if gc.Model != "" {
+			llmCfg.Model = gc.Model
+		}
+		classifier, err := llm.NewClient(&llmCfg)
+		if err != nil {
+			return nil, fmt.Errorf("create guard classifier: %w", err)
+		}
+		r.opts.Classifier = classifier
+	}
+	return r, nil
+}
+
+// Retrieve searches for query. A non-empty filter keeps only chunks whose
+// metadata matches it. A failed graph search or rerank does not fail the
+// retrieval: it leaves Graph empty or keeps the vector order.
+func (r *Retriever) Retrieve(ctx context.Context, query string, filter map[string]string) (*Retrieval, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("query is required")
+	}
+	opts := r.opts
+	opts.Filter = filter
+	res, err := retrieval.Search(ctx, r.store.vectors, r.store.graph, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := &Retrieval{
+		Query:       query,
+		SearchQuery: res.Query,
+		Chunks:      chunksOf(res.Chunks),
+		Reranked:    res.Reranked,
+		Fused:       res.Fused,
+		Compressed:  res.Compressed,
+		Graph:       res.Graph,
+		Context:     res.Format(),
+	}
+	if out.Graph == nil {
+		out.Graph = []Triple{}
+	}
+	return out, nil
+}
@@ -0,0 +1,151 @@
+package kash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/server"
+	"github.com/akashicode/kash/internal/snapshot"
+)
+
+// ServerOptions configures a Server.
+type ServerOptions struct {
+	// Dir is the agent directory (default: the working directory)
+	Dir    string
+	Config *Config
+	// Quiet discards request and diagnostic logs; otherwise they follow
+	// LOG_LEVEL, LOG_FORMAT, and LOG_FILE
+	Quiet bool
+	// Hooks run after the built-in hooks selected in agent.yaml
+	Hooks Hooks
+}
+
+// Server is a built agent running in-process: the same runtime 'kash serve'
+// starts. Mount Handler on an HTTP server, or call Chat and Ask directly.
+// The environment variables 'kash serve' reads, such as AGENT_API_KEY and
+// AGENT_ADMIN_KEY, apply to Handler too.
+type Server struct {
+	srv *server.Server
+}
+
+// Message is one turn of a conversation.
+type Message struct {
+	// Role is "user", "assistant", or "system"; system messages are replaced
+	// by the agent's system prompt
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// NewServer opens the agent built in opts.Dir and creates its provider
+// clients.
+func NewServer(opts ServerOptions) (*Server, error) {
+	if opts.Config == nil {
+		return nil, errors.New("config is required")
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = "."
+	}
+	agentconfig.ApplyAgentYAMLDimensions(opts.Config, filepath.Join(dir, AgentFile))
+	if err := agentconfig.ValidateServe(opts.Config); err != nil {
+		return nil, err
+	}
+
+	srv, err := server.New(server.Config{
+		VectorStorePath: filepath.Join(dir, VectorDir),
+		GraphDBPath:     filepath.Join(dir, GraphDir),
+		DocumentsPath:   filepath.Join(dir, DocumentsDir),
+		AgentYAMLPath:   filepath.Join(dir, AgentFile),
+		ManifestPath:    filepath.Join(dir, ManifestFile),
+		DataDir:         filepath.Join(dir, DataDir),
+		SnapshotDir:     filepath.Join(dir, snapshot.DefaultDir),
+		AppCfg:          opts.Config,
+		Reingest:        reingest(dir, opts.Config),
+		Quiet:           opts.Quiet,
+		Hooks:           opts.Hooks.internal(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("initialize server: %w", err)
+	}
+	return &Server{srv: srv}, nil
+}
+
+// reingest rebuilds the agent in dir for POST /admin/reingest and the
+// scheduled re-ingest.
+func reingest(dir string, cfg *Config) func(ctx context.Context, out io.Writer, opts server.ReingestOptions) error {
+	return func(ctx context.Context, out io.Writer, opts server.ReingestOptions) error {
+		buildCfg := *cfg
+		b, err := NewBuilder(BuildOptions{
+			Dir:           dir,
+			Config:        &buildCfg,
+			Progress:      TextProgress(out),
+			SkipUnchanged: opts.IfChanged,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = b.Build(ctx)
+		return err
+	}
+}
+
+// Handler serves the agent's HTTP API: OpenAI-compatible chat completions,
+// /v1/retrieve, MCP, A2A, health probes, and the /ui playground.
+func (s *Server) Handler() http.Handler {
+	return s.srv.Handler()
+}
+
+// Chat answers a conversation with the agent's system prompt and the context
+// retrieved for the last user message. A non-empty filter keeps only chunks
+// whose metadata matches it.
+func (s *Server) Chat(ctx context.Context, messages []Message, filter map[string]string) (string, error) {
+	msgs := make([]openai.ChatCompletionMessage, len(messages))
+	for i, m := range messages {
+		msgs[i] = openai.ChatCompletionMessage{Role: m.Role, Content: m.Content}
+	}
+	return s.srv.Chat(ctx, msgs, filter)
+}
+
+// Ask answers a single question.
+func (s *Server) Ask(ctx context.Context, question string) (string, error) {
+	return s.Chat(ctx, []Message{{Role: openai.ChatMessageRoleUser, Content: question}}, nil)
+}
+
+// Reload reopens the databases after a rebuild. Requests already running
+// finish against the previous ones.
+func (s *Server) Reload() error {
+	return s.srv.Reload()
+}
+
+// WatchData reloads the databases whenever a build finishes, checking every
+// interval until ctx is done.
+func (s *Server) WatchData(ctx context.Context, interval time.Duration) {
+	s.srv.WatchData(ctx, interval)
+}
+
+// LiveIngest embeds documents added to or changed in the agent's data/
+// directory into the running server's vector index, checking every interval
+// until ctx is done. Deleted documents are removed from the index.
+func (s *Server) LiveIngest(ctx context.Context, interval time.Duration) {
+	s.srv.LiveIngest(ctx, interval)
+}
+
+// RunSchedule runs the re-ingest scheduled by schedule.reingest in agent.yaml
+// until ctx is done: each run rebuilds the agent when its sources changed and
+// reloads the databases. It returns at once when no schedule is set.
+func (s *Server) RunSchedule(ctx context.Context) {
+	s.srv.RunSchedule(ctx)
+}
+
+// Close releases the databases once in-flight requests are done with them.
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
@@ -0,0 +1,230 @@
+package kash
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/reader"
+	"github.com/akashicode/kash/internal/source"
+)
+
+// sourceCacheDir holds cached remote content between builds, relative to the
+// agent directory.
+var sourceCacheDir = filepath.Join(".kash", "cache")
+
+// loadedSources is the output of loadSources: documents plus the provenance
+// recorded in the build manifest.
+type loadedSources struct {
+	docs []reader.Document
+	// origins maps document name → source type ("url", "crawl", "git")
+	origins map[string]string
+	sources []manifest.Source
+}
+
+// loadSources fetches remote documents listed in agent.yaml (sources.urls,
+// sources.crawl, sources.git, sources.drive, sources.storage, sources.youtube)
+// and data/urls.txt. Unreachable
+// sources are skipped with a warning so one dead link does not fail the build.
+// Skipped items are recorded in the build report.
+func (b *Builder) loadSources(ctx context.Context, rd *reader.Reader) (loadedSources, error) {
+	cfg, report := b.opts.Config, b.report
+	out := loadedSources{origins: map[string]string{}}
+	cacheDir := b.path(sourceCacheDir)
+
+	srcCfg := agentconfig.AgentYAMLSources(b.path(AgentFile))
+	listed, err := source.LoadURLList(b.path(DataDir, source.URLListFile))
+	if err != nil {
+		return out, err
+	}
+
+	seen := map[string]bool{}
+	var urls []string
+	for _, u := range append(srcCfg.URLs, listed...) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	add := func(origin string, docs []reader.Document) {
+		for _, d := range docs {
+			out.origins[d.Name] = origin
+		}
+		out.docs = append(out.docs, docs...)
+	}
+
+	if len(urls) > 0 || len(srcCfg.Crawl) > 0 {
+		fetcher, err := source.NewFetcher(filepath.Join(cacheDir, "urls"), rd)
+		if err != nil {
+			return out, err
+		}
+
+		if len(urls) > 0 {
+			notModified := 0
+			var fetched []reader.Document
+			for _, u := range urls {
+				doc, status, err := fetcher.Fetch(ctx, u)
+				if err != nil {
+					b.warn(fmt.Sprintf("skipping URL: %v", err))
+					report.Skip("url", u, err.Error())
+					continue
+				}
+				if status == source.StatusNotModified {
+					notModified++
+				}
+				fetched = append(fetched, doc)
+				out.sources = append(out.sources, manifest.Source{Type: "url", URL: u, Documents: 1})
+			}
+			add("url", fetched)
+			b.progress.Detail(fmt.Sprintf("Fetched %d of %d URL(s) (%d unchanged since last build)", len(fetched), len(urls), notModified))
+		}
+
+		for _, c := range srcCfg.Crawl {
+			res, err := fetcher.Crawl(ctx, source.CrawlOptions{
+				StartURL:     c.StartURL,
+				MaxDepth:     c.MaxDepth,
+				MaxPages:     c.MaxPages,
+				Include:      c.Include,
+				Exclude:      c.Exclude,
+				Sitemap:      c.Sitemap,
+				IgnoreRobots: c.IgnoreRobots,
+				Delay:        time.Duration(c.DelayMS) * time.Millisecond,
+			})
+			if err != nil {
+				b.warn(fmt.Sprintf("crawl %s failed: %v", c.StartURL, err))
+			}
+			b.progress.Detail(fmt.Sprintf("Crawled %s: %d page(s), %d skipped (%d unchanged since last build)",
+				c.StartURL, len(res.Documents), len(res.Skipped), res.NotModified))
+			add("crawl", res.Documents)
+			report.SkipAll("crawl", res.Skipped)
+			out.sources = append(out.sources, manifest.Source{Type: "crawl", URL: c.StartURL, Documents: len(res.Documents)})
+		}
+	}
+
+	if len(srcCfg.Git) > 0 {
+		repo, err := source.NewGitRepo(filepath.Join(cacheDir, "git"), rd)
+		if err != nil {
+			return out, err
+		}
+		for _, c := range srcCfg.Git {
+			res, err := repo.Fetch(ctx, source.GitOptions{
+				URL:     c.URL,
+				Ref:     c.Ref,
+				Paths:   c.Paths,
+				Exclude: c.Exclude,
+			})
+			if err != nil {
+				b.warn(fmt.Sprintf("git source %s failed: %v", c.URL, err))
+				continue
+			}
+			b.progress.Detail(fmt.Sprintf("Git %s@%s (%s): %d file(s), %d skipped",
+				c.URL, refOrHead(c.Ref), shortSHA(res.Commit), len(res.Documents), len(res.Skipped)))
+			add("git", res.Documents)
+			report.SkipAll("git", res.Skipped)
+			out.sources = append(out.sources, manifest.Source{
+				Type:      "git",
+				URL:       c.URL,
+				Ref:       c.Ref,
+				Commit:    res.Commit,
+				Documents: len(res.Documents),
+			})
+		}
+	}
+
+	if len(srcCfg.Drive) > 0 {
+		drive, err := source.NewDrive(cfg.Google.CredentialsFile, filepath.Join(cacheDir, "drive"), rd)
+		if err != nil {
+			return out, fmt.Errorf("google drive: %w", err)
+		}
+		for _, c := range srcCfg.Drive {
+			res, err := drive.Fetch(ctx, source.DriveOptions{FolderID: c.FolderID, Recursive: c.Recursive})
+			if err != nil {
+				b.warn(fmt.Sprintf("drive folder %s failed: %v", c.FolderID, err))
+				continue
+			}
+			b.progress.Detail(fmt.Sprintf("Drive folder %s: %d file(s), %d skipped (%d unchanged since last build)",
+				c.FolderID, len(res.Documents), len(res.Skipped), res.NotModified))
+			add("drive", res.Documents)
+			report.SkipAll("drive", res.Skipped)
+			out.sources = append(out.sources, manifest.Source{Type: "drive", URL: c.FolderID, Documents: len(res.Documents)})
+		}
+	}
+
+	if len(srcCfg.Storage) > 0 {
+		storage, err := source.NewStorage(source.StorageCredentials{
+			AWSAccessKeyID:        cfg.AWS.AccessKeyID,
+			AWSSecretAccessKey:    cfg.AWS.SecretAccessKey,
+			AWSSessionToken:       cfg.AWS.SessionToken,
+			AWSRegion:             cfg.AWS.Region,
+			GoogleCredentialsFile: cfg.Google.CredentialsFile,
+			AzureAccount:          cfg.Azure.AccountName,
+			AzureKey:              cfg.Azure.AccountKey,
+			AzureSASToken:         cfg.Azure.SASToken,
+		}, filepath.Join(cacheDir, "storage"), rd)
+		if err != nil {
+			return out, err
+		}
+		for _, c := range srcCfg.Storage {
+			res, err := storage.Fetch(ctx, source.StorageOptions{
+				URL:      c.URL,
+				Region:   c.Region,
+				Endpoint: c.Endpoint,
+				Exclude:  c.Exclude,
+			})
+			if err != nil {
+				b.warn(fmt.Sprintf("storage source %s failed: %v", c.URL, err))
+				continue
+			}
+			b.progress.Detail(fmt.Sprintf("Storage %s: %d object(s), %d skipped (%d unchanged since last build)",
+				c.URL, len(res.Documents), len(res.Skipped), res.NotModified))
+			add("storage", res.Documents)
+			report.SkipAll("storage", res.Skipped)
+			out.sources = append(out.sources, manifest.Source{Type: "storage", URL: c.URL, Documents: len(res.Documents)})
+		}
+	}
+
+	if yt := srcCfg.YouTube; len(yt.Videos) > 0 || len(yt.Channels) > 0 {
+		youtube, err := source.NewYouTube(filepath.Join(cacheDir, "youtube"))
+		if err != nil {
+			return out, err
+		}
+		res, err := youtube.Fetch(ctx, source.YouTubeOptions{
+			Videos:         yt.Videos,
+			Channels:       yt.Channels,
+			Language:       yt.Language,
+			MaxVideos:      yt.MaxVideos,
+			SegmentSeconds: yt.SegmentSeconds,
+		})
+		if err != nil {
+			b.warn(fmt.Sprintf("youtube source failed: %v", err))
+		}
+		for u, reason := range res.Skipped {
+			b.warn(fmt.Sprintf("skipping %s: %s", u, reason))
+		}
+		report.SkipAll("youtube", res.Skipped)
+		b.progress.Detail(fmt.Sprintf("YouTube: %d transcript(s) (%d cached)", len(res.Documents), res.Cached))
+		add("youtube", res.Documents)
+		for _, doc := range res.Documents {
+			out.sources = append(out.sources, manifest.Source{Type: "youtube", URL: doc.Name, Documents: 1})
+		}
+	}
+	return out, nil
+}
+
+func refOrHead(ref string) string {
+	if ref == "" {
+		return "HEAD"
+	}
+	return ref
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -0,0 +1,117 @@
+package kash
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/akashicode/kash/internal/chunker"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/reader"
+)
+
+// Stage is a step of the build pipeline that BuildOptions.Stages can run on
+// its own.
+type Stage string
+
+// The build stages, in the order they run.
+const (
+	// StageLoad reads data/ and fetches the sources of agent.yaml
+	StageLoad Stage = "load"
+	// StageChunk splits the documents into chunks
+	StageChunk Stage = "chunk"
+	// StageEmbed adds the chunks to the vector index
+	StageEmbed Stage = "embed"
+	// StageExtract builds the knowledge graph
+	StageExtract Stage = "extract"
+	// StageDescribe writes the MCP tool description to agent.yaml
+	StageDescribe Stage = "describe"
+)
+
+// Stages lists every build stage in order.
+var Stages = []Stage{StageLoad, StageChunk, StageEmbed, StageExtract, StageDescribe}
+
+// ParseStages reads a comma-separated list of stages, e.g. "embed,extract".
+func ParseStages(s string) ([]Stage, error) {
+	var out []Stage
+	for _, name := range strings.Split(s, ",") {
+		st := Stage(strings.ToLower(strings.TrimSpace(name)))
+		if st == "" {
+			continue
+		}
+		if !slices.Contains(Stages, st) {
+			return nil, fmt.Errorf("unknown build stage %q (want load, chunk, embed, extract, or describe)", name)
+		}
+		if !slices.Contains(out, st) {
+			out = append(out, st)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no build stages given")
+	}
+	return out, nil
+}
+
+// Names of the stage caches in StagesDir.
+const (
+	loadCacheFile  = "load.json"
+	chunkCacheFile = "chunks.json"
+)
+
+// loadCache is the output of the load stage.
+type loadCache struct {
+	// Data are the documents read from data/, streamed files included
+	Data    []reader.Document `json:"data"`
+	Remote  []reader.Document `json:"remote"`
+	Origins map[string]string `json:"origins"`
+	Sources []manifest.Source `json:"sources"`
+}
+
+// chunkCache is the output of the chunk stage.
+type chunkCache struct {
+	Chunks []chunker.Chunk `json:"chunks"`
+}
+
+// runs reports whether the build runs stage.
+func (b *Builder) runs(stage Stage) bool {
+	return len(b.opts.Stages) == 0 || slices.Contains(b.opts.Stages, stage)
+}
+
+// selective reports whether the build runs only some of the stages.
+func (b *Builder) selective() bool {
+	return len(b.opts.Stages) > 0 && len(b.opts.Stages) < len(Stages)
+}
+
+// saveStage writes the output of stage to its cache file in StagesDir.
+func (b *Builder) saveStage(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+	if err := os.MkdirAll(b.path(StagesDir), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", StagesDir, err)
+	}
+	if err := os.WriteFile(b.path(StagesDir, name), data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Join(StagesDir, name), err)
+	}
+	return nil
+}
+
+// loadStage reads the output a skipped stage cached when it last ran.
+func (b *Builder) loadStage(stage Stage, name string, v interface{}) error {
+	data, err := os.ReadFile(b.path(StagesDir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("the %s stage has not run yet — include it in the stages", stage)
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", filepath.Join(StagesDir, name), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s: %w", filepath.Join(StagesDir, name), err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package kash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	agentconfig "github.com/akashicode/kash/internal/config"
+	"github.com/akashicode/kash/internal/docstore"
+	"github.com/akashicode/kash/internal/graph"
+	"github.com/akashicode/kash/internal/manifest"
+	"github.com/akashicode/kash/internal/retrieval"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// Store is a built agent's vector index and knowledge graph, opened for
+// searching. The graph is a private snapshot, so the agent can be rebuilt
+// while a Store is open. A Store is safe for concurrent use.
+type Store struct {
+	dir     string
+	vectors *vector.Store
+	graph   *graph.DB
+	// documents is nil when the build kept no document text
+	documents *docstore.Store
+}
+
+// Chunk is a document chunk found by a search.
+type Chunk struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	// Citation is Source plus the title, date, and page or timestamp when
+	// known
+	Citation   string  `json:"citation"`
+	Similarity float32 `json:"similarity"`
+	// Score is the fused relevance, with fusion enabled in agent.yaml
+	Score    float64           `json:"score,omitempty"`
+	Content  string            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Triple is a knowledge graph fact found by a search.
+type Triple = graph.SearchResult
+
+// ErrDocumentNotStored is returned by Store.Document for a document whose
+// text the build did not keep.
+var ErrDocumentNotStored = docstore.ErrNotFound
+
+// OpenStore opens the databases built in the agent directory dir. Queries are
+// embedded with cfg.Embedder, after the embedding dimensions from agent.yaml
+// are applied to cfg. Its language embedders must be those of the build.
+// Graph searches expand the synonyms of agent.yaml's retrieval block.
+func OpenStore(dir string, cfg *Config) (*Store, error) {
+	if cfg == nil {
+		return nil, errors.New("config is required")
+	}
+	vectorPath := filepath.Join(dir, VectorDir)
+	if _, err := os.Stat(vectorPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s not found — run 'kash build' first", vectorPath)
+	}
+	agentconfig.ApplyAgentYAMLDimensions(cfg, filepath.Join(dir, AgentFile))
+	if err := agentconfig.ValidateEmbedder(cfg); err != nil {
+		return nil, err
+	}
+
+	hookCfg, err := retrieval.AgentYAMLHooks(filepath.Join(dir, AgentFile))
+	if err != nil {
+		return nil, fmt.Errorf("agent.yaml retrieval: %w", err)
+	}
+	if m, err := manifest.Load(filepath.Join(dir, ManifestFile)); err == nil {
+		if err := m.CheckLanguages(manifest.Embedder(cfg.Embedder)); err != nil {
+			return nil, err
+		}
+	}
+
+	vs, err := vector.NewStoreFromPath(vectorPath, &cfg.Embedder)
+	if err != nil {
+		return nil, fmt.Errorf("open vector store: %w", err)
+	}
+	gdb, err := graph.OpenSnapshot(filepath.Join(dir, GraphDir))
+	if err != nil {
+		return nil, fmt.Errorf("open graph db: %w", err)
+	}
+	gdb.SetSynonyms(hookCfg.Synonyms)
+	docs, err := docstore.Open(filepath.Join(dir, DocumentsDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		gdb.Close()
+		return nil, err
+	}
+	return &Store{dir: dir, vectors: vs, graph: gdb, documents: docs}, nil
+}
+
+// SearchChunks returns the topK chunks most similar to query. A non-empty
+// filter keeps only chunks whose metadata matches it, e.g. {"tags": "billing"}.
+func (s *Store) SearchChunks(ctx context.Context, query string, topK int, filter map[string]string) ([]Chunk, error) {
+	results, err := s.vectors.QueryFiltered(ctx, query, topK, filter)
+	if err != nil {
+		return nil, fmt.Errorf("vector search: %w", err)
+	}
+	return chunksOf(results), nil
+}
+
+// SearchGraph returns up to topK triples matching the terms of query.
+func (s *Store) SearchGraph(ctx context.Context, query string, topK int) ([]Triple, error) {
+	return s.graph.Search(ctx, query, topK)
+}
+
+// Document returns the full text of the source document name, the Source of
+// its chunks. It fails with ErrDocumentNotStored for a document the build did
+// not keep, e.g. one built with ingest.skip_documents.
+func (s *Store) Document(name string) (string, error) {
+	if s.documents == nil {
+		return "", fmt.Errorf("%w: %s", ErrDocumentNotStored, name)
+	}
+	return s.documents.Text(name)
+}
+
+// Vectors returns the number of chunks in the vector index.
+func (s *Store) Vectors() int {
+	return s.vectors.Count()
+}
+
+// Triples returns the number of triples in the knowledge graph.
+func (s *Store) Triples() int64 {
+	return s.graph.Count()
+}
+
+// Close releases the graph snapshot.
+func (s *Store) Close() error {
+	return s.graph.Close()
+}
+
+func chunksOf(results []vector.SearchResult) []Chunk {
+	chunks := make([]Chunk, len(results))
+	for i, r := range results {
+		chunks[i] = Chunk{
+			ID:         r.ID,
+			Source:     r.Source,
+			Citation:   retrieval.Citation(r),
+			Similarity: r.Similarity,
+			Score:      r.Score,
+			Content:    r.Content,
+			Metadata:   r.Metadata,
+		}
+	}
+	return chunks
+}
@@ -0,0 +1,113 @@
+package kash
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/akashicode/kash/internal/chunker"
+	"github.com/akashicode/kash/internal/ingest"
+	"github.com/akashicode/kash/internal/pii"
+	"github.com/akashicode/kash/internal/reader"
+	"github.com/akashicode/kash/internal/vector"
+)
+
+// streamBatch is how many chunks of a streamed file are embedded at once.
+const streamBatch = 256
+
+// streamSamples is how many chunks of a streamed file are kept for the MCP
+// tool description.
+const streamSamples = 3
+
+// streamedFile is a file in data/ too large to read whole. It is chunked and
+// embedded section by section in the embed step; only its chunk IDs and a
+// few sample chunks stay in memory.
+type streamedFile struct {
+	doc reader.Document
+	// bytes and digest identify the content for the build fingerprint
+	bytes  int64
+	digest string
+	// chunkIDs are the IDs of the chunks added to the vector store
+	chunkIDs []string
+	// samples are the first chunks, for the MCP tool description
+	samples []chunker.Chunk
+}
+
+// hash reads the file once to record its size and digest.
+func (sf *streamedFile) hash() error {
+	f, err := os.Open(sf.doc.Path)
+	if err != nil {
+		return fmt.Errorf("open %q: %w", sf.doc.Path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return fmt.Errorf("read %q: %w", sf.doc.Path, err)
+	}
+	sf.bytes = n
+	sf.digest = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// embed chunks the file as rd streams it, applies scanner to the chunks,
+// and adds them to vs in batches of streamBatch, reusing unchanged
+// embeddings when reuse is set. It returns the number of embeddings reused.
+func (sf *streamedFile) embed(ctx context.Context, rd *reader.Reader, ck *chunker.Chunker, scanner *pii.Scanner, vs *vector.Store, reuse bool) (int, error) {
+	sf.chunkIDs, sf.samples = nil, nil
+	stream := ck.NewStream(sf.doc.Name)
+	reused := 0
+	var batch []chunker.Chunk
+	flush := func() error {
+		if reuse {
+			n, err := vs.UpdateChunks(ctx, batch)
+			reused += n
+			if err != nil {
+				return err
+			}
+		} else if err := vs.AddChunks(ctx, batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	err := rd.StreamFile(sf.doc.Path, func(sec reader.Section) error {
+		chunks, err := stream.Split(ingest.Section(sf.doc, sec))
+		if err != nil {
+			return err
+		}
+		ingest.TagLanguage(chunks)
+		chunks = scanner.Apply(chunks)
+		for _, ch := range chunks {
+			sf.chunkIDs = append(sf.chunkIDs, ch.ID)
+			if len(sf.samples) < streamSamples {
+				sf.samples = append(sf.samples, ch)
+			}
+		}
+		batch = append(batch, chunks...)
+		if len(batch) >= streamBatch {
+			return flush()
+		}
+		return ctx.Err()
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	if err != nil {
+		return reused, fmt.Errorf("stream %q: %w", sf.doc.Name, err)
+	}
+	return reused, nil
+}
+
+// streamedChunks counts the chunks of the streamed files.
+func streamedChunks(streamed []*streamedFile) int {
+	n := 0
+	for _, sf := range streamed {
+		n += len(sf.chunkIDs)
+	}
+	return n
+}
@@ -0,0 +1,1142 @@
+// The gRPC interface of the Kash runtime, for services that would rather not
+// parse SSE. It serves the same agent as the HTTP API: pass the API key as
+// "authorization: Bearer <AGENT_API_KEY>" metadata when auth is enabled.
+//
+// Regenerate pkg/kashpb with 'make proto' after changing this file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        v5.29.3
+// source: kash/v1/kash.proto
+
+package kashpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Message struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Role is "system", "user", or "assistant"
+	Role          string `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	Content       string `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_kash_v1_kash_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Message) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Message) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type ChatRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Messages []*Message             `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+	// Filter restricts retrieval to chunks whose metadata matches
+	Filter        map[string]string `protobuf:"bytes,2,rep,name=filter,proto3" json:"filter,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatRequest) Reset() {
+	*x = ChatRequest{}
+	mi := &file_kash_v1_kash_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatRequest) ProtoMessage() {}
+
+func (x *ChatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatRequest.ProtoReflect.Descriptor instead.
+func (*ChatRequest) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChatRequest) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *ChatRequest) GetFilter() map[string]string {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type ChatEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID is the answer's chat completion id, the same in every event
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*ChatEvent_Retrieval
+	//	*ChatEvent_Token
+	//	*ChatEvent_Done
+	Event         isChatEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChatEvent) Reset() {
+	*x = ChatEvent{}
+	mi := &file_kash_v1_kash_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChatEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatEvent) ProtoMessage() {}
+
+func (x *ChatEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatEvent.ProtoReflect.Descriptor instead.
+func (*ChatEvent) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ChatEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetEvent() isChatEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ChatEvent) GetRetrieval() *Retrieval {
+	if x != nil {
+		if x, ok := x.Event.(*ChatEvent_Retrieval); ok {
+			return x.Retrieval
+		}
+	}
+	return nil
+}
+
+func (x *ChatEvent) GetToken() string {
+	if x != nil {
+		if x, ok := x.Event.(*ChatEvent_Token); ok {
+			return x.Token
+		}
+	}
+	return ""
+}
+
+func (x *ChatEvent) GetDone() *Done {
+	if x != nil {
+		if x, ok := x.Event.(*ChatEvent_Done); ok {
+			return x.Done
+		}
+	}
+	return nil
+}
+
+type isChatEvent_Event interface {
+	isChatEvent_Event()
+}
+
+type ChatEvent_Retrieval struct {
+	Retrieval *Retrieval `protobuf:"bytes,2,opt,name=retrieval,proto3,oneof"`
+}
+
+type ChatEvent_Token struct {
+	// Token is the next piece of the answer
+	Token string `protobuf:"bytes,3,opt,name=token,proto3,oneof"`
+}
+
+type ChatEvent_Done struct {
+	Done *Done `protobuf:"bytes,4,opt,name=done,proto3,oneof"`
+}
+
+func (*ChatEvent_Retrieval) isChatEvent_Event() {}
+
+func (*ChatEvent_Token) isChatEvent_Event() {}
+
+func (*ChatEvent_Done) isChatEvent_Event() {}
+
+// Retrieval is what the search before the answer found.
+type Retrieval struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Chunks        []*Chunk               `protobuf:"bytes,1,rep,name=chunks,proto3" json:"chunks,omitempty"`
+	Triples       []*Triple              `protobuf:"bytes,2,rep,name=triples,proto3" json:"triples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Retrieval) Reset() {
+	*x = Retrieval{}
+	mi := &file_kash_v1_kash_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Retrieval) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Retrieval) ProtoMessage() {}
+
+func (x *Retrieval) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Retrieval.ProtoReflect.Descriptor instead.
+func (*Retrieval) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Retrieval) GetChunks() []*Chunk {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+func (x *Retrieval) GetTriples() []*Triple {
+	if x != nil {
+		return x.Triples
+	}
+	return nil
+}
+
+// Done ends an answer.
+type Done struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// FinishReason is "stop", "length", or "content_filter"
+	FinishReason string `protobuf:"bytes,1,opt,name=finish_reason,json=finishReason,proto3" json:"finish_reason,omitempty"`
+	// Grounding is set when the agent verifies answers against the context
+	Grounding     *Grounding `protobuf:"bytes,2,opt,name=grounding,proto3" json:"grounding,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Done) Reset() {
+	*x = Done{}
+	mi := &file_kash_v1_kash_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Done) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Done) ProtoMessage() {}
+
+func (x *Done) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Done.ProtoReflect.Descriptor instead.
+func (*Done) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Done) GetFinishReason() string {
+	if x != nil {
+		return x.FinishReason
+	}
+	return ""
+}
+
+func (x *Done) GetGrounding() *Grounding {
+	if x != nil {
+		return x.Grounding
+	}
+	return nil
+}
+
+type Grounding struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Score         float64                `protobuf:"fixed64,1,opt,name=score,proto3" json:"score,omitempty"`
+	Claims        int32                  `protobuf:"varint,2,opt,name=claims,proto3" json:"claims,omitempty"`
+	Unsupported   []string               `protobuf:"bytes,3,rep,name=unsupported,proto3" json:"unsupported,omitempty"`
+	Regenerated   bool                   `protobuf:"varint,4,opt,name=regenerated,proto3" json:"regenerated,omitempty"`
+	Disclaimed    bool                   `protobuf:"varint,5,opt,name=disclaimed,proto3" json:"disclaimed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Grounding) Reset() {
+	*x = Grounding{}
+	mi := &file_kash_v1_kash_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Grounding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Grounding) ProtoMessage() {}
+
+func (x *Grounding) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Grounding.ProtoReflect.Descriptor instead.
+func (*Grounding) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Grounding) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Grounding) GetClaims() int32 {
+	if x != nil {
+		return x.Claims
+	}
+	return 0
+}
+
+func (x *Grounding) GetUnsupported() []string {
+	if x != nil {
+		return x.Unsupported
+	}
+	return nil
+}
+
+func (x *Grounding) GetRegenerated() bool {
+	if x != nil {
+		return x.Regenerated
+	}
+	return false
+}
+
+func (x *Grounding) GetDisclaimed() bool {
+	if x != nil {
+		return x.Disclaimed
+	}
+	return false
+}
+
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Source        string                 `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Citation      string                 `protobuf:"bytes,3,opt,name=citation,proto3" json:"citation,omitempty"`
+	Similarity    float32                `protobuf:"fixed32,4,opt,name=similarity,proto3" json:"similarity,omitempty"`
+	Content       string                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	Metadata      map[string]string      `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_kash_v1_kash_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Chunk) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Chunk) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Chunk) GetCitation() string {
+	if x != nil {
+		return x.Citation
+	}
+	return ""
+}
+
+func (x *Chunk) GetSimilarity() float32 {
+	if x != nil {
+		return x.Similarity
+	}
+	return 0
+}
+
+func (x *Chunk) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Chunk) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type Triple struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Subject       string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	Predicate     string                 `protobuf:"bytes,2,opt,name=predicate,proto3" json:"predicate,omitempty"`
+	Object        string                 `protobuf:"bytes,3,opt,name=object,proto3" json:"object,omitempty"`
+	Score         float64                `protobuf:"fixed64,4,opt,name=score,proto3" json:"score,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Triple) Reset() {
+	*x = Triple{}
+	mi := &file_kash_v1_kash_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Triple) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Triple) ProtoMessage() {}
+
+func (x *Triple) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Triple.ProtoReflect.Descriptor instead.
+func (*Triple) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Triple) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *Triple) GetPredicate() string {
+	if x != nil {
+		return x.Predicate
+	}
+	return ""
+}
+
+func (x *Triple) GetObject() string {
+	if x != nil {
+		return x.Object
+	}
+	return ""
+}
+
+func (x *Triple) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Filter        map[string]string      `protobuf:"bytes,2,rep,name=filter,proto3" json:"filter,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_kash_v1_kash_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetFilter() map[string]string {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type SearchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// SearchQuery is the query searched when a query transform changed it
+	SearchQuery string    `protobuf:"bytes,1,opt,name=search_query,json=searchQuery,proto3" json:"search_query,omitempty"`
+	Chunks      []*Chunk  `protobuf:"bytes,2,rep,name=chunks,proto3" json:"chunks,omitempty"`
+	Triples     []*Triple `protobuf:"bytes,3,rep,name=triples,proto3" json:"triples,omitempty"`
+	// Context is the exact block a chat would inject
+	Context       string `protobuf:"bytes,4,opt,name=context,proto3" json:"context,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_kash_v1_kash_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchResponse) GetSearchQuery() string {
+	if x != nil {
+		return x.SearchQuery
+	}
+	return ""
+}
+
+func (x *SearchResponse) GetChunks() []*Chunk {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetTriples() []*Triple {
+	if x != nil {
+		return x.Triples
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetContext() string {
+	if x != nil {
+		return x.Context
+	}
+	return ""
+}
+
+type GraphQueryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// TopK is the number of facts to return (default: the agent's graph_top_k)
+	TopK          int32 `protobuf:"varint,2,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GraphQueryRequest) Reset() {
+	*x = GraphQueryRequest{}
+	mi := &file_kash_v1_kash_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GraphQueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GraphQueryRequest) ProtoMessage() {}
+
+func (x *GraphQueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GraphQueryRequest.ProtoReflect.Descriptor instead.
+func (*GraphQueryRequest) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GraphQueryRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *GraphQueryRequest) GetTopK() int32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+type GraphQueryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Triples       []*Triple              `protobuf:"bytes,1,rep,name=triples,proto3" json:"triples,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GraphQueryResponse) Reset() {
+	*x = GraphQueryResponse{}
+	mi := &file_kash_v1_kash_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GraphQueryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GraphQueryResponse) ProtoMessage() {}
+
+func (x *GraphQueryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GraphQueryResponse.ProtoReflect.Descriptor instead.
+func (*GraphQueryResponse) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GraphQueryResponse) GetTriples() []*Triple {
+	if x != nil {
+		return x.Triples
+	}
+	return nil
+}
+
+type IngestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Documents     []*Document            `protobuf:"bytes,1,rep,name=documents,proto3" json:"documents,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestRequest) Reset() {
+	*x = IngestRequest{}
+	mi := &file_kash_v1_kash_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestRequest) ProtoMessage() {}
+
+func (x *IngestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestRequest.ProtoReflect.Descriptor instead.
+func (*IngestRequest) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *IngestRequest) GetDocuments() []*Document {
+	if x != nil {
+		return x.Documents
+	}
+	return nil
+}
+
+// Document is a file for the data directory. An empty content removes the
+// document and its chunks.
+type Document struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name is the file name, e.g. "faq.md"; it may not contain a path
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Content       []byte `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Document) Reset() {
+	*x = Document{}
+	mi := &file_kash_v1_kash_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Document) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Document) ProtoMessage() {}
+
+func (x *Document) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Document.ProtoReflect.Descriptor instead.
+func (*Document) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Document) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Document) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+type IngestResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Updated, Removed, and Failed count the documents embedded, removed, and
+	// left as they were because they could not be read or embedded
+	Updated int32 `protobuf:"varint,1,opt,name=updated,proto3" json:"updated,omitempty"`
+	Removed int32 `protobuf:"varint,2,opt,name=removed,proto3" json:"removed,omitempty"`
+	Failed  int32 `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	// Vectors is the number of chunks in the index afterwards
+	Vectors       int64 `protobuf:"varint,4,opt,name=vectors,proto3" json:"vectors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestResponse) Reset() {
+	*x = IngestResponse{}
+	mi := &file_kash_v1_kash_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestResponse) ProtoMessage() {}
+
+func (x *IngestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kash_v1_kash_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestResponse.ProtoReflect.Descriptor instead.
+func (*IngestResponse) Descriptor() ([]byte, []int) {
+	return file_kash_v1_kash_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *IngestResponse) GetUpdated() int32 {
+	if x != nil {
+		return x.Updated
+	}
+	return 0
+}
+
+func (x *IngestResponse) GetRemoved() int32 {
+	if x != nil {
+		return x.Removed
+	}
+	return 0
+}
+
+func (x *IngestResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *IngestResponse) GetVectors() int64 {
+	if x != nil {
+		return x.Vectors
+	}
+	return 0
+}
+
+var File_kash_v1_kash_proto protoreflect.FileDescriptor
+
+const file_kash_v1_kash_proto_rawDesc = "" +
+	"\n" +
+	"\x12kash/v1/kash.proto\x12\akash.v1\"7\n" +
+	"\aMessage\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"\xb0\x01\n" +
+	"\vChatRequest\x12,\n" +
+	"\bmessages\x18\x01 \x03(\v2\x10.kash.v1.MessageR\bmessages\x128\n" +
+	"\x06filter\x18\x02 \x03(\v2 .kash.v1.ChatRequest.FilterEntryR\x06filter\x1a9\n" +
+	"\vFilterEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x95\x01\n" +
+	"\tChatEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x122\n" +
+	"\tretrieval\x18\x02 \x01(\v2\x12.kash.v1.RetrievalH\x00R\tretrieval\x12\x16\n" +
+	"\x05token\x18\x03 \x01(\tH\x00R\x05token\x12#\n" +
+	"\x04done\x18\x04 \x01(\v2\r.kash.v1.DoneH\x00R\x04doneB\a\n" +
+	"\x05event\"^\n" +
+	"\tRetrieval\x12&\n" +
+	"\x06chunks\x18\x01 \x03(\v2\x0e.kash.v1.ChunkR\x06chunks\x12)\n" +
+	"\atriples\x18\x02 \x03(\v2\x0f.kash.v1.TripleR\atriples\"]\n" +
+	"\x04Done\x12#\n" +
+	"\rfinish_reason\x18\x01 \x01(\tR\ffinishReason\x120\n" +
+	"\tgrounding\x18\x02 \x01(\v2\x12.kash.v1.GroundingR\tgrounding\"\x9d\x01\n" +
+	"\tGrounding\x12\x14\n" +
+	"\x05score\x18\x01 \x01(\x01R\x05score\x12\x16\n" +
+	"\x06claims\x18\x02 \x01(\x05R\x06claims\x12 \n" +
+	"\vunsupported\x18\x03 \x03(\tR\vunsupported\x12 \n" +
+	"\vregenerated\x18\x04 \x01(\bR\vregenerated\x12\x1e\n" +
+	"\n" +
+	"disclaimed\x18\x05 \x01(\bR\n" +
+	"disclaimed\"\xfc\x01\n" +
+	"\x05Chunk\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06source\x18\x02 \x01(\tR\x06source\x12\x1a\n" +
+	"\bcitation\x18\x03 \x01(\tR\bcitation\x12\x1e\n" +
+	"\n" +
+	"similarity\x18\x04 \x01(\x02R\n" +
+	"similarity\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\tR\acontent\x128\n" +
+	"\bmetadata\x18\x06 \x03(\v2\x1c.kash.v1.Chunk.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"n\n" +
+	"\x06Triple\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12\x1c\n" +
+	"\tpredicate\x18\x02 \x01(\tR\tpredicate\x12\x16\n" +
+	"\x06object\x18\x03 \x01(\tR\x06object\x12\x14\n" +
+	"\x05score\x18\x04 \x01(\x01R\x05score\"\x9c\x01\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12:\n" +
+	"\x06filter\x18\x02 \x03(\v2\".kash.v1.SearchRequest.FilterEntryR\x06filter\x1a9\n" +
+	"\vFilterEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa0\x01\n" +
+	"\x0eSearchResponse\x12!\n" +
+	"\fsearch_query\x18\x01 \x01(\tR\vsearchQuery\x12&\n" +
+	"\x06chunks\x18\x02 \x03(\v2\x0e.kash.v1.ChunkR\x06chunks\x12)\n" +
+	"\atriples\x18\x03 \x03(\v2\x0f.kash.v1.TripleR\atriples\x12\x18\n" +
+	"\acontext\x18\x04 \x01(\tR\acontext\">\n" +
+	"\x11GraphQueryRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x13\n" +
+	"\x05top_k\x18\x02 \x01(\x05R\x04topK\"?\n" +
+	"\x12GraphQueryResponse\x12)\n" +
+	"\atriples\x18\x01 \x03(\v2\x0f.kash.v1.TripleR\atriples\"@\n" +
+	"\rIngestRequest\x12/\n" +
+	"\tdocuments\x18\x01 \x03(\v2\x11.kash.v1.DocumentR\tdocuments\"8\n" +
+	"\bDocument\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\"v\n" +
+	"\x0eIngestResponse\x12\x18\n" +
+	"\aupdated\x18\x01 \x01(\x05R\aupdated\x12\x18\n" +
+	"\aremoved\x18\x02 \x01(\x05R\aremoved\x12\x16\n" +
+	"\x06failed\x18\x03 \x01(\x05R\x06failed\x12\x18\n" +
+	"\avectors\x18\x04 \x01(\x03R\avectors2\xf7\x01\n" +
+	"\x04Kash\x122\n" +
+	"\x04Chat\x12\x14.kash.v1.ChatRequest\x1a\x12.kash.v1.ChatEvent0\x01\x129\n" +
+	"\x06Search\x12\x16.kash.v1.SearchRequest\x1a\x17.kash.v1.SearchResponse\x12E\n" +
+	"\n" +
+	"GraphQuery\x12\x1a.kash.v1.GraphQueryRequest\x1a\x1b.kash.v1.GraphQueryResponse\x129\n" +
+	"\x06Ingest\x12\x16.kash.v1.IngestRequest\x1a\x17.kash.v1.IngestResponseB.Z,github.com/akashicode/kash/pkg/kashpb;kashpbb\x06proto3"
+
+var (
+	file_kash_v1_kash_proto_rawDescOnce sync.Once
+	file_kash_v1_kash_proto_rawDescData []byte
+)
+
+func file_kash_v1_kash_proto_rawDescGZIP() []byte {
+	file_kash_v1_kash_proto_rawDescOnce.Do(func() {
+		file_kash_v1_kash_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_kash_v1_kash_proto_rawDesc), len(file_kash_v1_kash_proto_rawDesc)))
+	})
+	return file_kash_v1_kash_proto_rawDescData
+}
+
+var file_kash_v1_kash_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
+var file_kash_v1_kash_proto_goTypes = []any{
+	(*Message)(nil),            // 0: kash.v1.Message
+	(*ChatRequest)(nil),        // 1: kash.v1.ChatRequest
+	(*ChatEvent)(nil),          // 2: kash.v1.ChatEvent
+	(*Retrieval)(nil),          // 3: kash.v1.Retrieval
+	(*Done)(nil),               // 4: kash.v1.Done
+	(*Grounding)(nil),          // 5: kash.v1.Grounding
+	(*Chunk)(nil),              // 6: kash.v1.Chunk
+	(*Triple)(nil),             // 7: kash.v1.Triple
+	(*SearchRequest)(nil),      // 8: kash.v1.SearchRequest
+	(*SearchResponse)(nil),     // 9: kash.v1.SearchResponse
+	(*GraphQueryRequest)(nil),  // 10: kash.v1.GraphQueryRequest
+	(*GraphQueryResponse)(nil), // 11: kash.v1.GraphQueryResponse
+	(*IngestRequest)(nil),      // 12: kash.v1.IngestRequest
+	(*Document)(nil),           // 13: kash.v1.Document
+	(*IngestResponse)(nil),     // 14: kash.v1.IngestResponse
+	nil,                        // 15: kash.v1.ChatRequest.FilterEntry
+	nil,                        // 16: kash.v1.Chunk.MetadataEntry
+	nil,                        // 17: kash.v1.SearchRequest.FilterEntry
+}
+var file_kash_v1_kash_proto_depIdxs = []int32{
+	0,  // 0: kash.v1.ChatRequest.messages:type_name -> kash.v1.Message
+	15, // 1: kash.v1.ChatRequest.filter:type_name -> kash.v1.ChatRequest.FilterEntry
+	3,  // 2: kash.v1.ChatEvent.retrieval:type_name -> kash.v1.Retrieval
+	4,  // 3: kash.v1.ChatEvent.done:type_name -> kash.v1.Done
+	6,  // 4: kash.v1.Retrieval.chunks:type_name -> kash.v1.Chunk
+	7,  // 5: kash.v1.Retrieval.triples:type_name -> kash.v1.Triple
+	5,  // 6: kash.v1.Done.grounding:type_name -> kash.v1.Grounding
+	16, // 7: kash.v1.Chunk.metadata:type_name -> kash.v1.Chunk.MetadataEntry
+	17, // 8: kash.v1.SearchRequest.filter:type_name -> kash.v1.SearchRequest.FilterEntry
+	6,  // 9: kash.v1.SearchResponse.chunks:type_name -> kash.v1.Chunk
+	7,  // 10: kash.v1.SearchResponse.triples:type_name -> kash.v1.Triple
+	7,  // 11: kash.v1.GraphQueryResponse.triples:type_name -> kash.v1.Triple
+	13, // 12: kash.v1.IngestRequest.documents:type_name -> kash.v1.Document
+	1,  // 13: kash.v1.Kash.Chat:input_type -> kash.v1.ChatRequest
+	8,  // 14: kash.v1.Kash.Search:input_type -> kash.v1.SearchRequest
+	10, // 15: kash.v1.Kash.GraphQuery:input_type -> kash.v1.GraphQueryRequest
+	12, // 16: kash.v1.Kash.Ingest:input_type -> kash.v1.IngestRequest
+	2,  // 17: kash.v1.Kash.Chat:output_type -> kash.v1.ChatEvent
+	9,  // 18: kash.v1.Kash.Search:output_type -> kash.v1.SearchResponse
+	11, // 19: kash.v1.Kash.GraphQuery:output_type -> kash.v1.GraphQueryResponse
+	14, // 20: kash.v1.Kash.Ingest:output_type -> kash.v1.IngestResponse
+	17, // [17:21] is the sub-list for method output_type
+	13, // [13:17] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_kash_v1_kash_proto_init() }
+func file_kash_v1_kash_proto_init() {
+	if File_kash_v1_kash_proto != nil {
+		return
+	}
+	file_kash_v1_kash_proto_msgTypes[2].OneofWrappers = []any{
+		(*ChatEvent_Retrieval)(nil),
+		(*ChatEvent_Token)(nil),
+		(*ChatEvent_Done)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kash_v1_kash_proto_rawDesc), len(file_kash_v1_kash_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   18,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_kash_v1_kash_proto_goTypes,
+		DependencyIndexes: file_kash_v1_kash_proto_depIdxs,
+		MessageInfos:      file_kash_v1_kash_proto_msgTypes,
+	}.Build()
+	File_kash_v1_kash_proto = out.File
+	file_kash_v1_kash_proto_goTypes = nil
+	file_kash_v1_kash_proto_depIdxs = nil
+}
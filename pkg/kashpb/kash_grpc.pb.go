@@ -0,0 +1,261 @@
+// The gRPC interface of the Kash runtime, for services that would rather not
+// parse SSE. It serves the same agent as the HTTP API: pass the API key as
+// "authorization: Bearer <AGENT_API_KEY>" metadata when auth is enabled.
+//
+// Regenerate pkg/kashpb with 'make proto' after changing this file.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: kash/v1/kash.proto
+
+package kashpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Kash_Chat_FullMethodName       = "/kash.v1.Kash/Chat"
+	Kash_Search_FullMethodName     = "/kash.v1.Kash/Search"
+	Kash_GraphQuery_FullMethodName = "/kash.v1.Kash/GraphQuery"
+	Kash_Ingest_FullMethodName     = "/kash.v1.Kash/Ingest"
+)
+
+// KashClient is the client API for Kash service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type KashClient interface {
+	// Chat answers a conversation from the agent's knowledge, like a streamed
+	// chat completion: the retrieval, then the answer's tokens, then its end.
+	Chat(ctx context.Context, in *ChatRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatEvent], error)
+	// Search returns the chunks and graph facts a chat would be given for a
+	// query, without calling the LLM.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// GraphQuery searches the knowledge graph alone.
+	GraphQuery(ctx context.Context, in *GraphQueryRequest, opts ...grpc.CallOption) (*GraphQueryResponse, error)
+	// Ingest writes documents into the agent's data directory and embeds them
+	// into the running vector index, as live ingest does. It needs the admin
+	// key (AGENT_ADMIN_KEY).
+	Ingest(ctx context.Context, in *IngestRequest, opts ...grpc.CallOption) (*IngestResponse, error)
+}
+
+type kashClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKashClient(cc grpc.ClientConnInterface) KashClient {
+	return &kashClient{cc}
+}
+
+func (c *kashClient) Chat(ctx context.Context, in *ChatRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Kash_ServiceDesc.Streams[0], Kash_Chat_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChatRequest, ChatEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Kash_ChatClient = grpc.ServerStreamingClient[ChatEvent]
+
+func (c *kashClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, Kash_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kashClient) GraphQuery(ctx context.Context, in *GraphQueryRequest, opts ...grpc.CallOption) (*GraphQueryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GraphQueryResponse)
+	err := c.cc.Invoke(ctx, Kash_GraphQuery_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kashClient) Ingest(ctx context.Context, in *IngestRequest, opts ...grpc.CallOption) (*IngestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestResponse)
+	err := c.cc.Invoke(ctx, Kash_Ingest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KashServer is the server API for Kash service.
+// All implementations must embed UnimplementedKashServer
+// for forward compatibility.
+type KashServer interface {
+	// Chat answers a conversation from the agent's knowledge, like a streamed
+	// chat completion: the retrieval, then the answer's tokens, then its end.
+	Chat(*ChatRequest, grpc.ServerStreamingServer[ChatEvent]) error
+	// Search returns the chunks and graph facts a chat would be given for a
+	// query, without calling the LLM.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// GraphQuery searches the knowledge graph alone.
+	GraphQuery(context.Context, *GraphQueryRequest) (*GraphQueryResponse, error)
+	// Ingest writes documents into the agent's data directory and embeds them
+	// into the running vector index, as live ingest does. It needs the admin
+	// key (AGENT_ADMIN_KEY).
+	Ingest(context.Context, *IngestRequest) (*IngestResponse, error)
+	mustEmbedUnimplementedKashServer()
+}
+
+// UnimplementedKashServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedKashServer struct{}
+
+func (UnimplementedKashServer) Chat(*ChatRequest, grpc.ServerStreamingServer[ChatEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Chat not implemented")
+}
+func (UnimplementedKashServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedKashServer) GraphQuery(context.Context, *GraphQueryRequest) (*GraphQueryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GraphQuery not implemented")
+}
+func (UnimplementedKashServer) Ingest(context.Context, *IngestRequest) (*IngestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ingest not implemented")
+}
+func (UnimplementedKashServer) mustEmbedUnimplementedKashServer() {}
+func (UnimplementedKashServer) testEmbeddedByValue()              {}
+
+// UnsafeKashServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KashServer will
+// result in compilation errors.
+type UnsafeKashServer interface {
+	mustEmbedUnimplementedKashServer()
+}
+
+func RegisterKashServer(s grpc.ServiceRegistrar, srv KashServer) {
+	// If the following call pancis, it indicates UnimplementedKashServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Kash_ServiceDesc, srv)
+}
+
+func _Kash_Chat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ChatRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KashServer).Chat(m, &grpc.GenericServerStream[ChatRequest, ChatEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Kash_ChatServer = grpc.ServerStreamingServer[ChatEvent]
+
+func _Kash_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KashServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kash_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KashServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kash_GraphQuery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GraphQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KashServer).GraphQuery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kash_GraphQuery_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KashServer).GraphQuery(ctx, req.(*GraphQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kash_Ingest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IngestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KashServer).Ingest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kash_Ingest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KashServer).Ingest(ctx, req.(*IngestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Kash_ServiceDesc is the grpc.ServiceDesc for Kash service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Kash_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kash.v1.Kash",
+	HandlerType: (*KashServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Search",
+			Handler:    _Kash_Search_Handler,
+		},
+		{
+			MethodName: "GraphQuery",
+			Handler:    _Kash_GraphQuery_Handler,
+		},
+		{
+			MethodName: "Ingest",
+			Handler:    _Kash_Ingest_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Chat",
+			Handler:       _Kash_Chat_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "kash/v1/kash.proto",
+}